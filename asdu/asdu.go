@@ -6,6 +6,7 @@
 package asdu
 
 import (
+	"encoding"
 	"fmt"
 	"io"
 	"math/bits"
@@ -56,6 +57,26 @@ type Params struct {
 	// InfoObjTimeZone controls the time tag interpretation.
 	// The standard fails to mention this one.
 	InfoObjTimeZone *time.Location
+
+	// Profile the interoperability list the params are restricted to, nil for none.
+	Profile *Profile
+	// Causes the causes of transmission allowed to send each type identification, nil for the standard ones.
+	Causes *CauseTable
+	// AutoSequence let the senders without time tag choose the sequence (SQ = 1) themselves,
+	// it is used if the information object addresses are contiguous, whatever isSequence is given.
+	AutoSequence bool
+
+	// TimeTagPolicy how the received time tags flagged invalid or out of the tolerance are decoded.
+	TimeTagPolicy TimeTagPolicy
+	// TimeTagTolerance the time tags farther than it from the receive time are treated as invalid, 0 for no limit.
+	TimeTagTolerance time.Duration
+
+	// Clock the source of the receive time of the time tags and of the timeouts of the connections, nil for the system time.
+	Clock Clock
+
+	// MaxSize the maximum size of the asdu, smaller for some vendor profiles, for example of cs101 at low
+	// baud rates, 0 for ASDUSizeMax, the limit of the transport. See MaxASDUSize.
+	MaxSize int
 }
 
 // Valid returns the validation result of params.
@@ -66,9 +87,24 @@ func (sf Params) Valid() error {
 		(sf.InfoObjTimeZone == nil) {
 		return ErrParam
 	}
+	if sf.MaxSize < 0 || sf.MaxSize > ASDUSizeMax ||
+		sf.MaxSize > 0 && sf.MaxSize <= sf.IdentifierSize()+sf.InfoObjAddrSize {
+		return ErrParam
+	}
+	if sf.Profile != nil {
+		return sf.Profile.Validate(sf)
+	}
 	return nil
 }
 
+// MaxASDUSize returns the maximum size of the asdu, MaxSize or ASDUSizeMax if none
+func (sf Params) MaxASDUSize() int {
+	if sf.MaxSize > 0 {
+		return sf.MaxSize
+	}
+	return ASDUSizeMax
+}
+
 // ValidCommonAddr returns the validation result of a station common address.
 func (sf Params) ValidCommonAddr(addr CommonAddr) error {
 	if addr == InvalidCommonAddr {
@@ -168,6 +204,38 @@ func (sf *ASDU) Reply(c Cause, addr CommonAddr) *ASDU {
 	return r
 }
 
+// Mirror returns a new asdu confirming the received one, with the same type, addresses and information objects.
+// The cause is the cause of the confirmation, for example ActivationCon, DeactivationCon, ActivationTerm,
+// Unused derives it from the cause of the received asdu: Activation to ActivationCon, Deactivation to DeactivationCon.
+// The information objects are copied in whole, even if some have been decoded yet.
+func (sf *ASDU) Mirror(cause Cause, negative bool) *ASDU {
+	if cause == Unused {
+		switch sf.Coa.Cause {
+		case Activation:
+			cause = ActivationCon
+		case Deactivation:
+			cause = DeactivationCon
+		default:
+			cause = sf.Coa.Cause
+		}
+	}
+	r := NewASDU(sf.Params, sf.Identifier)
+	r.Coa.Cause = cause
+	r.Coa.IsNegative = negative
+	r.infoObj = append(r.infoObj, sf.wholeInfoObj()...)
+	return r
+}
+
+// wholeInfoObj returns the information objects include the decoded ones
+func (sf *ASDU) wholeInfoObj() []byte {
+	whole := sf.bootstrap[sf.IdentifierSize():]
+	decoded := cap(whole) - cap(sf.infoObj)
+	if decoded < 0 || cap(sf.infoObj) == 0 || &whole[decoded] != &sf.infoObj[:1][0] {
+		return sf.infoObj // not backed by the bootstrap
+	}
+	return whole[:decoded+len(sf.infoObj)]
+}
+
 // SendReplyMirror send a reply of the mirror request but cause different
 func (sf *ASDU) SendReplyMirror(c Connect, cause Cause) error {
 	r := NewASDU(sf.Params, sf.Identifier)
@@ -226,9 +294,18 @@ func (sf *ASDU) SendReplyMirror(c Connect, cause Cause) error {
 //	return buf.String()
 //}
 
+var (
+	_ encoding.BinaryMarshaler   = (*ASDU)(nil)
+	_ encoding.BinaryUnmarshaler = (*ASDU)(nil)
+)
+
 // MarshalBinary honors the encoding.BinaryMarshaler interface.
+// It serializes the full data unit, include the information objects decoded yet.
+// The returned data is valid until the asdu is modified.
 func (sf *ASDU) MarshalBinary() (data []byte, err error) {
 	switch {
+	case sf.Params == nil:
+		return nil, ErrParam
 	case sf.Coa.Cause == Unused:
 		return nil, ErrCauseZero
 	case !(sf.CauseSize == 1 || sf.CauseSize == 2):
@@ -241,9 +318,17 @@ func (sf *ASDU) MarshalBinary() (data []byte, err error) {
 		return nil, ErrParam
 	case sf.CommonAddrSize == 1 && sf.CommonAddr != GlobalCommonAddr && sf.CommonAddr >= 255:
 		return nil, ErrParam
+	case sf.Coa.Cause != UnknownTypeID && !sf.Profile.allows(sf.Type):
+		return nil, fmt.Errorf("%w: %s", ErrProfileTypeID, sf.Type)
 	}
 
-	raw := sf.bootstrap[:(sf.IdentifierSize() + len(sf.infoObj))]
+	lenDUI := sf.IdentifierSize()
+	infoObj := sf.wholeInfoObj()
+	if max := sf.MaxASDUSize(); lenDUI+len(infoObj) > max {
+		return nil, fmt.Errorf("%w: %d", ErrLengthOutOfRange, max)
+	}
+	raw := sf.bootstrap[:lenDUI+len(infoObj)]
+	copy(raw[lenDUI:], infoObj) // in place if backed by the bootstrap
 	raw[0] = byte(sf.Type)
 	raw[1] = sf.Variable.Value()
 	raw[2] = sf.Coa.Value()
@@ -269,8 +354,10 @@ func (sf *ASDU) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary honors the encoding.BinaryUnmarshaler interface.
 // ASDUParams must be set in advance. All other fields are initialized.
 func (sf *ASDU) UnmarshalBinary(rawAsdu []byte) error {
-	if !(sf.CauseSize == 1 || sf.CauseSize == 2) ||
-		!(sf.CommonAddrSize == 1 || sf.CommonAddrSize == 2) {
+	if sf.Params == nil ||
+		!(sf.CauseSize == 1 || sf.CauseSize == 2) ||
+		!(sf.CommonAddrSize == 1 || sf.CommonAddrSize == 2) ||
+		!(sf.InfoObjAddrSize >= 1 && sf.InfoObjAddrSize <= 3) {
 		return ErrParam
 	}
 
@@ -279,6 +366,9 @@ func (sf *ASDU) UnmarshalBinary(rawAsdu []byte) error {
 	if lenDUI > len(rawAsdu) {
 		return io.EOF
 	}
+	if max := sf.MaxASDUSize(); len(rawAsdu) > max {
+		return fmt.Errorf("%w: %d", ErrLengthOutOfRange, max)
+	}
 
 	// parse rawAsdu unit identifier
 	sf.Type = TypeID(rawAsdu[0])
@@ -310,6 +400,10 @@ func (sf *ASDU) fixInfoObjSize() error {
 		return err
 	}
 
+	if sf.Variable.Number == 0 {
+		return ErrInfoObjIndexFit
+	}
+
 	var size int
 	// read the variable structure qualifier
 	if sf.Variable.IsSequence {
@@ -319,8 +413,6 @@ func (sf *ASDU) fixInfoObjSize() error {
 	}
 
 	switch {
-	case size == 0:
-		return ErrInfoObjIndexFit
 	case size > len(sf.infoObj):
 		return io.EOF
 	case size < len(sf.infoObj): // not explicitly prohibited
@@ -1,6 +1,7 @@
 package asdu
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
@@ -15,6 +16,9 @@ func TestParams_Valid(t *testing.T) {
 		{"invalid", &Params{}, true},
 		{"ParamsNarrow", ParamsNarrow, false},
 		{"ParamsWide", ParamsWide, false},
+		{"max size", &Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 2, InfoObjTimeZone: time.UTC, MaxSize: 64}, false},
+		{"max size over transport", &Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 2, InfoObjTimeZone: time.UTC, MaxSize: ASDUSizeMax + 1}, true},
+		{"max size no object", &Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 2, InfoObjTimeZone: time.UTC, MaxSize: 6}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -134,6 +138,86 @@ func TestASDU_Reply(t *testing.T) {
 	}
 }
 
+func TestASDU_Mirror(t *testing.T) {
+	c := &lastConn{}
+	cmd := SingleCommandInfo{Ioa: 100, Value: true, Qoc: QualifierOfCommand{Qual: QOCShortPulseDuration}}
+	if err := SingleCmd(c, C_SC_NA_1, CauseOfTransmission{Cause: Activation, IsTest: true}, 1, cmd); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := c.a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := NewEmptyASDU(ParamsWide)
+	if err = req.UnmarshalBinary(raw); err != nil {
+		t.Fatal(err)
+	}
+	req.GetSingleCmd() // decoded before mirror
+
+	tests := []struct {
+		name     string
+		cause    Cause
+		negative bool
+		want     CauseOfTransmission
+	}{
+		{"derived", Unused, false, CauseOfTransmission{Cause: ActivationCon, IsTest: true}},
+		{"termination", ActivationTerm, false, CauseOfTransmission{Cause: ActivationTerm, IsTest: true}},
+		{"negative", UnknownIOA, true, CauseOfTransmission{Cause: UnknownIOA, IsNegative: true, IsTest: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := req.Mirror(tt.cause, tt.negative)
+			if got.Type != C_SC_NA_1 || got.Coa != tt.want {
+				t.Errorf("ASDU.Mirror() = %v, want %v", got.Identifier, tt.want)
+			}
+			if info := got.GetSingleCmd(); !reflect.DeepEqual(info, cmd) {
+				t.Errorf("ASDU.Mirror() information = %+v, want %+v", info, cmd)
+			}
+		})
+	}
+}
+
+func TestASDU_Reset(t *testing.T) {
+	c := &lastConn{}
+	infos := []MeasuredValueFloatInfo{{Ioa: 1, Value: 1.5}, {Ioa: 2, Value: -2.5}}
+	if err := MeasuredValueFloat(c, false, CauseOfTransmission{Cause: Spontaneous}, 1, infos...); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := c.a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewEmptyASDU(ParamsWide)
+	if err = a.UnmarshalBinary(raw); err != nil {
+		t.Fatal(err)
+	}
+	size := a.Remaining()
+	if size != 2*(3+5) {
+		t.Fatalf("Remaining() = %d, want %d", size, 2*(3+5))
+	}
+
+	if ioa := a.DecodeInfoObjAddr(); ioa != 1 {
+		t.Fatalf("DecodeInfoObjAddr() = %d, want 1", ioa)
+	}
+	m := a.Mark()
+	if v := a.DecodeFloat32(); v != 1.5 || a.Remaining() != size-3-4 {
+		t.Errorf("DecodeFloat32() = %v, Remaining() = %d", v, a.Remaining())
+	}
+	a.Rewind(m)
+	if v := a.DecodeFloat32(); v != 1.5 {
+		t.Errorf("DecodeFloat32() after Rewind = %v, want 1.5", v)
+	}
+
+	a.Reset()
+	if got := a.GetMeasuredValueFloat(); !reflect.DeepEqual(got, infos) || a.Remaining() != 0 {
+		t.Errorf("GetMeasuredValueFloat() after Reset = %+v, want %+v", got, infos)
+	}
+	a.Reset()
+	if got := a.GetMeasuredValueFloat(); !reflect.DeepEqual(got, infos) {
+		t.Errorf("GetMeasuredValueFloat() decoded twice = %+v, want %+v", got, infos)
+	}
+}
+
 func TestASDU_MarshalBinary(t *testing.T) {
 	type fields struct {
 		Params     *Params
@@ -363,3 +447,46 @@ func TestASDU_UnmarshalBinary(t *testing.T) {
 		})
 	}
 }
+
+func TestParams_JSON(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	if l, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		loc = l
+	}
+	want := Params{CauseSize: 2, OrigAddress: 3, CommonAddrSize: 2, InfoObjAddrSize: 3, InfoObjTimeZone: loc,
+		AutoSequence: true, TimeTagPolicy: TimeTagSubstitute, TimeTagTolerance: time.Minute, MaxSize: 200}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := *ParamsWide
+	if err = json.Unmarshal([]byte(`{"maxSize": 300}`), &big); err != nil {
+		t.Fatal(err)
+	}
+	if err = big.Valid(); err == nil {
+		t.Errorf("Valid() error nil, want max size %d out of range", big.MaxSize)
+	}
+	if loc.String() != "Asia/Shanghai" {
+		return // no time zone database to load it back
+	}
+	got := Params{Profile: ProfileCS104}
+	if err = json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want.Profile = ProfileCS104
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseTypeID(t *testing.T) {
+	if got := M_ME_TF_1.Name(); got != "M_ME_TF_1" {
+		t.Errorf("Name() = %q, want M_ME_TF_1", got)
+	}
+	if got, err := ParseTypeID("M_ME_TF_1"); err != nil || got != M_ME_TF_1 {
+		t.Errorf("ParseTypeID() = %v, %v, want M_ME_TF_1", got, err)
+	}
+	if _, err := ParseTypeID("M_XX_NA_1"); err != ErrTypeIdentifier {
+		t.Errorf("ParseTypeID() error = %v, want ErrTypeIdentifier", err)
+	}
+}
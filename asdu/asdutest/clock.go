@@ -0,0 +1,95 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdutest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Clock a manual asdu.Clock, the time goes forward only by Advance and Set,
+// firing the timers whose deadline is reached.
+type Clock struct {
+	mux    sync.Mutex
+	now    time.Time
+	timers map[*clockTimer]struct{}
+}
+
+var _ asdu.Clock = (*Clock)(nil)
+
+// NewClock new a manual clock at the time
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now, timers: make(map[*clockTimer]struct{})}
+}
+
+// Now imp interface asdu.Clock
+func (sf *Clock) Now() time.Time {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.now
+}
+
+// NewTimer imp interface asdu.Clock
+func (sf *Clock) NewTimer(d time.Duration) asdu.Timer {
+	t := &clockTimer{clock: sf, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance move the time forward by d, firing the timers due
+func (sf *Clock) Advance(d time.Duration) {
+	sf.mux.Lock()
+	sf.set(sf.now.Add(d))
+	sf.mux.Unlock()
+}
+
+// Set move the time to t, firing the timers due, a time in the past fires nothing
+func (sf *Clock) Set(t time.Time) {
+	sf.mux.Lock()
+	sf.set(t)
+	sf.mux.Unlock()
+}
+
+func (sf *Clock) set(t time.Time) {
+	sf.now = t
+	for timer := range sf.timers {
+		if !timer.at.After(t) {
+			delete(sf.timers, timer)
+			select {
+			case timer.c <- t:
+			default:
+			}
+		}
+	}
+}
+
+// clockTimer a timer of the manual clock
+type clockTimer struct {
+	clock *Clock
+	c     chan time.Time
+	at    time.Time
+}
+
+func (sf *clockTimer) C() <-chan time.Time { return sf.c }
+
+func (sf *clockTimer) Stop() bool {
+	sf.clock.mux.Lock()
+	defer sf.clock.mux.Unlock()
+	_, armed := sf.clock.timers[sf]
+	delete(sf.clock.timers, sf)
+	return armed
+}
+
+func (sf *clockTimer) Reset(d time.Duration) bool {
+	sf.clock.mux.Lock()
+	defer sf.clock.mux.Unlock()
+	_, armed := sf.clock.timers[sf]
+	sf.at = sf.clock.now.Add(d)
+	sf.clock.timers[sf] = struct{}{}
+	sf.clock.set(sf.clock.now) // fire at once if due
+	return armed
+}
@@ -0,0 +1,50 @@
+package asdutest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(t0)
+	timer := c.NewTimer(time.Second)
+	stopped := c.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("Stop() armed timer = false")
+	}
+
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+	c.Advance(time.Millisecond)
+	select {
+	case now := <-timer.C():
+		if !now.Equal(t0.Add(time.Second)) {
+			t.Errorf("fired at %v", now)
+		}
+	default:
+		t.Fatal("timer not fired")
+	}
+	select {
+	case <-stopped.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+
+	if timer.Reset(-time.Second) {
+		t.Error("Reset() of a fired timer = true")
+	}
+	select {
+	case <-timer.C():
+	default:
+		t.Error("timer reset in the past not fired at once")
+	}
+	c.Set(t0)
+	if !c.Now().Equal(t0) {
+		t.Errorf("Now() = %v, want %v", c.Now(), t0)
+	}
+}
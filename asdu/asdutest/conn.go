@@ -0,0 +1,179 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package asdutest provides the test doubles of asdu, to unit test the handlers
+// without the connections.
+package asdutest
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Handler the handler of the inbound asdu, cs104.Dispatch wraps the server handlers
+type Handler func(c asdu.Connect, a *asdu.ASDU) error
+
+// Conn a mock asdu.Station recording the asdu sent and delivering the scripted inbound asdu.
+// The asdu sent and delivered go through the encoding, as over the wire.
+type Conn struct {
+	params *asdu.Params
+
+	mux       sync.Mutex
+	sent      []*asdu.ASDU
+	inbound   []*asdu.ASDU
+	sendErr   error
+	connected bool
+}
+
+var _ asdu.Station = (*Conn)(nil)
+
+// NewConn new a connected mock with the params, nil is asdu.ParamsWide
+func NewConn(p *asdu.Params) *Conn {
+	if p == nil {
+		p = asdu.ParamsWide
+	}
+	return &Conn{params: p, connected: true}
+}
+
+// SetSendError set the error returned by Send, nil records the asdu again
+func (sf *Conn) SetSendError(err error) *Conn {
+	sf.mux.Lock()
+	sf.sendErr = err
+	sf.mux.Unlock()
+	return sf
+}
+
+// SetConnected set the state returned by IsConnected
+func (sf *Conn) SetConnected(b bool) *Conn {
+	sf.mux.Lock()
+	sf.connected = b
+	sf.mux.Unlock()
+	return sf
+}
+
+// Params imp interface asdu.Connect
+func (sf *Conn) Params() *asdu.Params { return sf.params }
+
+// UnderlyingConn imp interface asdu.Connect, always nil
+func (sf *Conn) UnderlyingConn() net.Conn { return nil }
+
+// IsConnected imp interface asdu.Station
+func (sf *Conn) IsConnected() bool {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.connected
+}
+
+// Send imp interface asdu.Connect, record the asdu decoded again from its encoding
+func (sf *Conn) Send(a *asdu.ASDU) error {
+	sf.mux.Lock()
+	err := sf.sendErr
+	sf.mux.Unlock()
+	if err != nil {
+		return err
+	}
+	r, err := sf.roundTrip(a)
+	if err != nil {
+		return err
+	}
+	sf.mux.Lock()
+	sf.sent = append(sf.sent, r)
+	sf.mux.Unlock()
+	return nil
+}
+
+func (sf *Conn) roundTrip(a *asdu.ASDU) (*asdu.ASDU, error) {
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	r := asdu.NewEmptyASDU(sf.params)
+	if err = r.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Sent returns the asdu sent so far
+func (sf *Conn) Sent() []*asdu.ASDU {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return append([]*asdu.ASDU(nil), sf.sent...)
+}
+
+// Take returns and forgets the asdu sent so far
+func (sf *Conn) Take() []*asdu.ASDU {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	s := sf.sent
+	sf.sent = nil
+	return s
+}
+
+// Script queue the inbound asdu, delivered in order by Run
+func (sf *Conn) Script(in ...*asdu.ASDU) error {
+	for _, a := range in {
+		r, err := sf.roundTrip(a)
+		if err != nil {
+			return err
+		}
+		sf.mux.Lock()
+		sf.inbound = append(sf.inbound, r)
+		sf.mux.Unlock()
+	}
+	return nil
+}
+
+// Run deliver the scripted inbound asdu to the handler, the errors of the handler joined
+func (sf *Conn) Run(h Handler) error {
+	var errs []error
+	for {
+		sf.mux.Lock()
+		if len(sf.inbound) == 0 {
+			sf.mux.Unlock()
+			return errors.Join(errs...)
+		}
+		a := sf.inbound[0]
+		sf.inbound = sf.inbound[1:]
+		sf.mux.Unlock()
+		if err := h(sf, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+}
+
+// ExpectNothing fail the test if any asdu sent
+func (sf *Conn) ExpectNothing(t testing.TB) {
+	t.Helper()
+	if sent := sf.Take(); len(sent) != 0 {
+		t.Errorf("asdutest: sent %d asdu, want none: %v", len(sent), sent)
+	}
+}
+
+// Expect fail the test unless the asdu sent match the identifiers in order, compared by type,
+// cause, negative flag and common address. It returns and forgets the asdu sent.
+func (sf *Conn) Expect(t testing.TB, want ...asdu.Identifier) []*asdu.ASDU {
+	t.Helper()
+	sent := sf.Take()
+	if len(sent) != len(want) {
+		t.Errorf("asdutest: sent %d asdu, want %d: %v", len(sent), len(want), sent)
+		return sent
+	}
+	for i, a := range sent {
+		if !Match(a.Identifier, want[i]) {
+			t.Errorf("asdutest: sent[%d] = %v, want %v", i, a.Identifier, want[i])
+		}
+	}
+	return sent
+}
+
+// Match whether the identifiers match by type, cause, negative flag and common address
+func Match(got, want asdu.Identifier) bool {
+	return got.Type == want.Type && got.Coa.Cause == want.Coa.Cause &&
+		got.Coa.IsNegative == want.Coa.IsNegative && got.CommonAddr == want.CommonAddr
+}
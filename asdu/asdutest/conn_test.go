@@ -0,0 +1,66 @@
+package asdutest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func interrogation(ca asdu.CommonAddr) *asdu.ASDU {
+	a := asdu.NewASDU(asdu.ParamsWide, asdu.Identifier{Type: asdu.C_IC_NA_1, Variable: asdu.VariableStruct{Number: 1},
+		Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: ca})
+	_ = a.AppendInfoObjAddr(asdu.InfoObjAddrIrrelevant)
+	a.AppendBytes(byte(asdu.QOIStation))
+	return a
+}
+
+func TestConn_Run(t *testing.T) {
+	c := NewConn(nil)
+	if err := c.Script(interrogation(1), interrogation(2)); err != nil {
+		t.Fatal(err)
+	}
+	var qois []asdu.QualifierOfInterrogation
+	err := c.Run(func(c asdu.Connect, a *asdu.ASDU) error {
+		_, qoi := a.GetInterrogationCmd()
+		qois = append(qois, qoi)
+		if a.CommonAddr == 2 {
+			return errors.New("unknown station")
+		}
+		return c.Send(a.Mirror(asdu.ActivationCon, false))
+	})
+	if err == nil {
+		t.Error("Run() error = nil, want the handler error")
+	}
+	if len(qois) != 2 || qois[0] != asdu.QOIStation {
+		t.Errorf("qualifiers = %v", qois)
+	}
+	sent := c.Expect(t, asdu.Identifier{Type: asdu.C_IC_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.ActivationCon}, CommonAddr: 1})
+	if len(sent) == 1 {
+		if ioa, qoi := sent[0].GetInterrogationCmd(); ioa != 0 || qoi != asdu.QOIStation {
+			t.Errorf("sent ioa, qoi = %v, %v", ioa, qoi)
+		}
+	}
+	c.ExpectNothing(t)
+	if err = c.Run(nil); err != nil {
+		t.Errorf("Run() empty script error = %v", err)
+	}
+}
+
+func TestConn_Send(t *testing.T) {
+	c := NewConn(asdu.ParamsNarrow)
+	if !c.IsConnected() || c.SetConnected(false).IsConnected() {
+		t.Error("IsConnected() not set")
+	}
+	bad := asdu.NewASDU(asdu.ParamsNarrow, asdu.Identifier{Type: asdu.C_IC_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}})
+	if err := c.Send(bad); err == nil {
+		t.Error("Send() invalid common address, want error")
+	}
+	errSend := errors.New("link down")
+	if err := c.SetSendError(errSend).Send(interrogation(1)); err != errSend {
+		t.Errorf("Send() error = %v, want %v", err, errSend)
+	}
+	if len(c.Sent()) != 0 {
+		t.Errorf("Sent() = %v, want none", c.Sent())
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+// the batch senders split the information objects which do not fit into a single asdu
+// into as few asdu as possible, sent in order. The runs of contiguous information object
+// addresses are sent in sequence (SQ = 1) when the type identification has no time tag
+// and the sequence saves octets, the others as single information objects (SQ = 0).
+
+// batchSizeMax the maximum number of information objects of an asdu
+const batchSizeMax = 127
+
+// batch split the n information objects, with the address ioa(i), of the type identification
+// and send them in order with send(isSequence, i, j) carrying the objects [i, j).
+func batch(c Connect, typeID TypeID, sequence bool, n int, ioa func(i int) InfoObjAddr, send func(isSequence bool, i, j int) error) error {
+	if n == 0 {
+		return ErrNotAnyObjInfo
+	}
+	objSize, err := GetInfoObjSize(typeID)
+	if err != nil {
+		return err
+	}
+	p := c.Params()
+	if err = p.Valid(); err != nil {
+		return err
+	}
+	maxSingle := min((p.MaxASDUSize()-p.IdentifierSize())/(p.InfoObjAddrSize+objSize), batchSizeMax)
+	maxSeq := min((p.MaxASDUSize()-p.IdentifierSize()-p.InfoObjAddrSize)/objSize, batchSizeMax)
+
+	chunk := func(isSequence bool, i, j, max int) error {
+		for ; i < j; i += max {
+			if err := send(isSequence, i, min(i+max, j)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := 0 // the pending single information objects [start, i)
+	for i := 0; i < n; {
+		r := i + 1
+		for r < n && ioa(r) == ioa(r-1)+1 {
+			r++
+		}
+		// a sequence is worth it if the saved addresses exceed the identifier of an extra asdu
+		if sequence && (r-i-1)*p.InfoObjAddrSize > p.IdentifierSize() {
+			if err = chunk(false, start, i, maxSingle); err != nil {
+				return err
+			}
+			if err = chunk(true, i, r, maxSeq); err != nil {
+				return err
+			}
+			start = r
+		}
+		i = r
+	}
+	return chunk(false, start, n, maxSingle)
+}
+
+// SingleBatch sends the single point information of type identification [M_SP_NA_1], [M_SP_TA_1] or [M_SP_TB_1]
+// in as many asdu as needed, see Single
+func SingleBatch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...SinglePointInfo) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_SP_NA_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return single(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
+
+// DoubleBatch sends the double point information of type identification [M_DP_NA_1], [M_DP_TA_1] or [M_DP_TB_1]
+// in as many asdu as needed, see Double
+func DoubleBatch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...DoublePointInfo) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_DP_NA_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return double(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
+
+// StepBatch sends the step position information of type identification [M_ST_NA_1], [M_ST_TA_1] or [M_ST_TB_1]
+// in as many asdu as needed, see Step
+func StepBatch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...StepPositionInfo) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_ST_NA_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return step(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
+
+// BitString32Batch sends the bitstring of 32 bit of type identification [M_BO_NA_1], [M_BO_TA_1] or [M_BO_TB_1]
+// in as many asdu as needed, see BitString32
+func BitString32Batch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...BitString32Info) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_BO_NA_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return bitString32(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
+
+// MeasuredValueNormalBatch sends the normalized measured values of type identification [M_ME_NA_1], [M_ME_TA_1],
+// [M_ME_TD_1] or [M_ME_ND_1] in as many asdu as needed, see MeasuredValueNormal
+func MeasuredValueNormalBatch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueNormalInfo) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_ME_NA_1 || typeID == M_ME_ND_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return measuredValueNormal(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
+
+// MeasuredValueScaledBatch sends the scaled measured values of type identification [M_ME_NB_1], [M_ME_TB_1]
+// or [M_ME_TE_1] in as many asdu as needed, see MeasuredValueScaled
+func MeasuredValueScaledBatch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueScaledInfo) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_ME_NB_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return measuredValueScaled(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
+
+// MeasuredValueFloatBatch sends the short floating point measured values of type identification [M_ME_NC_1],
+// [M_ME_TC_1] or [M_ME_TF_1] in as many asdu as needed, see MeasuredValueFloat
+func MeasuredValueFloatBatch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueFloatInfo) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_ME_NC_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return measuredValueFloat(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
+
+// IntegratedTotalsBatch sends the integrated totals of type identification [M_IT_NA_1], [M_IT_TA_1] or [M_IT_TB_1]
+// in as many asdu as needed, see IntegratedTotals
+func IntegratedTotalsBatch(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...BinaryCounterReadingInfo) error {
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
+	}
+	return batch(c, typeID, typeID == M_IT_NA_1, len(infos),
+		func(i int) InfoObjAddr { return infos[i].Ioa },
+		func(isSequence bool, i, j int) error {
+			return integratedTotals(c, typeID, isSequence, coa, ca, infos[i:j]...)
+		})
+}
@@ -0,0 +1,116 @@
+package asdu
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// batchConn keeps all the sent asdu
+type batchConn struct {
+	p    *Params // nil for ParamsWide
+	sent []*ASDU
+}
+
+func (sf *batchConn) Params() *Params {
+	if sf.p == nil {
+		return ParamsWide
+	}
+	return sf.p
+}
+func (sf *batchConn) UnderlyingConn() net.Conn { return nil }
+func (sf *batchConn) Send(u *ASDU) error {
+	if _, err := u.MarshalBinary(); err != nil {
+		return err
+	}
+	sf.sent = append(sf.sent, u.Clone())
+	return nil
+}
+
+func TestMeasuredValueFloatBatch(t *testing.T) {
+	// 300 contiguous, then 50 scattered
+	infos := make([]MeasuredValueFloatInfo, 0, 350)
+	for i := 0; i < 300; i++ {
+		infos = append(infos, MeasuredValueFloatInfo{Ioa: InfoObjAddr(100 + i), Value: float32(i)})
+	}
+	for i := 0; i < 50; i++ {
+		infos = append(infos, MeasuredValueFloatInfo{Ioa: InfoObjAddr(1000 + 2*i), Value: float32(i)})
+	}
+	c := &batchConn{}
+	if err := MeasuredValueFloatBatch(c, M_ME_NC_1, CauseOfTransmission{Cause: Spontaneous}, 1, infos...); err != nil {
+		t.Fatal(err)
+	}
+	// sequence (249-6-3)/5 = 48 a asdu, single (249-6)/8 = 30 a asdu
+	if len(c.sent) != 7+2 {
+		t.Fatalf("sent %d asdu, want 9", len(c.sent))
+	}
+	var got []MeasuredValueFloatInfo
+	for i, a := range c.sent {
+		if a.Variable.IsSequence != (i < 7) {
+			t.Errorf("asdu %d IsSequence %v", i, a.Variable.IsSequence)
+		}
+		got = append(got, a.GetMeasuredValueFloat()...)
+	}
+	if len(got) != len(infos) {
+		t.Fatalf("sent %d infos, want %d", len(got), len(infos))
+	}
+	for i := range infos {
+		if got[i].Ioa != infos[i].Ioa || got[i].Value != infos[i].Value {
+			t.Fatalf("info %d = %+v, want %+v", i, got[i], infos[i])
+		}
+	}
+}
+
+func TestSingleBatch(t *testing.T) {
+	infos := make([]SinglePointInfo, 200)
+	for i := range infos {
+		infos[i] = SinglePointInfo{Ioa: InfoObjAddr(i + 1), Value: i%2 == 0}
+	}
+	c := &batchConn{}
+	if err := SingleBatch(c, M_SP_TB_1, CauseOfTransmission{Cause: Spontaneous}, 1, infos...); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, a := range c.sent {
+		if a.Variable.IsSequence {
+			t.Errorf("time tagged asdu in sequence")
+		}
+		n += len(a.GetSinglePoint())
+	}
+	if n != len(infos) || len(c.sent) != 10 { // (249-6)/(3+8) = 22 a asdu
+		t.Errorf("sent %d infos in %d asdu, want %d in 10", n, len(c.sent), len(infos))
+	}
+
+	if err := SingleBatch(c, M_SP_NA_1, CauseOfTransmission{Cause: Activation}, 1, infos...); err == nil {
+		t.Errorf("SingleBatch() want error of the cause")
+	}
+	if err := SingleBatch(c, M_SP_NA_1, CauseOfTransmission{Cause: Spontaneous}, 1); err != ErrNotAnyObjInfo {
+		t.Errorf("SingleBatch() error %v, want %v", err, ErrNotAnyObjInfo)
+	}
+}
+
+func TestSingleBatch_MaxSize(t *testing.T) {
+	p := *ParamsWide
+	p.MaxSize = 6 + 3 + 10 // 10 objects in sequence, 2 single
+	infos := make([]SinglePointInfo, 25)
+	for i := range infos {
+		infos[i] = SinglePointInfo{Ioa: InfoObjAddr(1 + i)}
+	}
+	c := &batchConn{p: &p}
+	if err := SingleBatch(c, M_SP_NA_1, CauseOfTransmission{Cause: Spontaneous}, 1, infos...); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 3 {
+		t.Fatalf("sent %d asdu, want 3", len(c.sent))
+	}
+	for i, a := range c.sent {
+		if b, _ := a.MarshalBinary(); len(b) > p.MaxSize {
+			t.Errorf("asdu %d size %d, want at most %d", i, len(b), p.MaxSize)
+		}
+	}
+	if err := Single(c, false, CauseOfTransmission{Cause: Spontaneous}, 1, infos[:4]...); !errors.Is(err, ErrLengthOutOfRange) {
+		t.Errorf("Single() error = %v, want %v", err, ErrLengthOutOfRange)
+	} else if want := "asdu: asdu filed length large than max: 19"; err.Error() != want {
+		t.Errorf("Single() error = %q, want %q", err, want)
+	}
+}
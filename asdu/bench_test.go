@@ -0,0 +1,89 @@
+package asdu
+
+import (
+	"sort"
+	"testing"
+)
+
+// benchTypes returns the standard type identifications in order
+func benchTypes() []TypeID {
+	ids := make([]TypeID, 0, len(infoObjSize))
+	for id := range infoObjSize {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// benchEncode encode the asdu of the type with zero valued information objects,
+// ten of them for the monitor direction, one for the others.
+func benchEncode(a *ASDU, buf []byte) ([]byte, error) {
+	size, err := GetInfoObjSize(a.Type)
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	if a.Type < C_SC_NA_1 {
+		n = 10
+	}
+	var obj [16]byte
+	for i := 0; i < n; i++ {
+		if err = a.AppendInfoObjAddr(InfoObjAddr(i + 1)); err != nil {
+			return nil, err
+		}
+		a.AppendBytes(obj[:size]...)
+	}
+	if err = a.SetVariableNumber(n); err != nil {
+		return nil, err
+	}
+	return a.AppendBinary(buf[:0])
+}
+
+func benchIdentifier(id TypeID) Identifier {
+	coa := CauseOfTransmission{Cause: Spontaneous}
+	if id >= C_SC_NA_1 {
+		coa.Cause = Activation
+	}
+	return Identifier{Type: id, Coa: coa, CommonAddr: 1}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	buf := make([]byte, 0, ASDUSizeMax)
+	for _, id := range benchTypes() {
+		b.Run(id.Name(), func(b *testing.B) {
+			raw, err := benchEncode(NewASDU(ParamsWide, benchIdentifier(id)), nil)
+			if err != nil {
+				b.Skip(err)
+			}
+			b.SetBytes(int64(len(raw)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				a := AcquireASDU(ParamsWide, benchIdentifier(id))
+				if _, err = benchEncode(a, buf); err != nil {
+					b.Fatal(err)
+				}
+				ReleaseASDU(a)
+			}
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, id := range benchTypes() {
+		b.Run(id.Name(), func(b *testing.B) {
+			raw, err := benchEncode(NewASDU(ParamsWide, benchIdentifier(id)), nil)
+			if err != nil {
+				b.Skip(err)
+			}
+			b.SetBytes(int64(len(raw)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err = ParseASDU(ParamsWide, raw); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Language of a description catalog, for example "en", "zh"
+type Language string
+
+// Language defined
+const (
+	English Language = "en"
+	Chinese Language = "zh"
+)
+
+// Catalog the human readable descriptions of the type identifications,
+// causes of transmission and qualifiers in a language.
+// A missing description falls back to the English catalog.
+type Catalog struct {
+	Language Language
+	TypeID   map[TypeID]string
+	Cause    map[Cause]string
+	QOI      map[QualifierOfInterrogation]string
+	QCC      map[QCCRequest]string
+	QPM      map[QPMCategory]string
+	QPA      map[QualifierOfParameterAct]string
+	QOC      map[QOCQual]string
+	QRP      map[QualifierOfResetProcessCmd]string
+}
+
+var catalogs = struct {
+	sync.RWMutex
+	m map[Language]*Catalog
+}{m: map[Language]*Catalog{English: englishCatalog, Chinese: chineseCatalog}}
+
+// RegisterCatalog register or replace the catalog of its language
+func RegisterCatalog(c *Catalog) {
+	catalogs.Lock()
+	catalogs.m[c.Language] = c
+	catalogs.Unlock()
+}
+
+// LookupCatalog returns the catalog of the language
+func LookupCatalog(lang Language) (*Catalog, bool) {
+	catalogs.RLock()
+	defer catalogs.RUnlock()
+	c, ok := catalogs.m[lang]
+	return c, ok
+}
+
+func describe(name, desc string) string {
+	if desc == "" {
+		return name
+	}
+	return name + " " + desc
+}
+
+// DescribeTypeID returns the description of the type identification
+func (sf *Catalog) DescribeTypeID(id TypeID) string {
+	s, ok := sf.TypeID[id]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.TypeID[id]
+	}
+	return describe(id.String(), s)
+}
+
+// DescribeCause returns the description of the cause of transmission
+func (sf *Catalog) DescribeCause(coa CauseOfTransmission) string {
+	s, ok := sf.Cause[coa.Cause]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.Cause[coa.Cause]
+	}
+	return describe(coa.String(), s)
+}
+
+// DescribeQOI returns the description of the qualifier of interrogation
+func (sf *Catalog) DescribeQOI(qoi QualifierOfInterrogation) string {
+	s, ok := sf.QOI[qoi]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.QOI[qoi]
+	}
+	return describe(fmt.Sprintf("QOI<%d>", qoi), s)
+}
+
+// DescribeQCC returns the description of the qualifier of counter interrogation
+func (sf *Catalog) DescribeQCC(qcc QualifierCountCall) string {
+	s, ok := sf.QCC[qcc.Request]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.QCC[qcc.Request]
+	}
+	return describe(fmt.Sprintf("QCC<%d>", qcc.Value()), s)
+}
+
+// DescribeQPM returns the description of the qualifier of parameter of measured values
+func (sf *Catalog) DescribeQPM(qpm QualifierOfParameterMV) string {
+	s, ok := sf.QPM[qpm.Category]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.QPM[qpm.Category]
+	}
+	return describe(fmt.Sprintf("QPM<%d>", qpm.Value()), s)
+}
+
+// DescribeQPA returns the description of the qualifier of parameter activation
+func (sf *Catalog) DescribeQPA(qpa QualifierOfParameterAct) string {
+	s, ok := sf.QPA[qpa]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.QPA[qpa]
+	}
+	return describe(fmt.Sprintf("QPA<%d>", qpa), s)
+}
+
+// DescribeQOC returns the description of the qualifier of command
+func (sf *Catalog) DescribeQOC(qoc QualifierOfCommand) string {
+	s, ok := sf.QOC[qoc.Qual]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.QOC[qoc.Qual]
+	}
+	return describe(fmt.Sprintf("QOC<%d>", qoc.Value()), s)
+}
+
+// DescribeQRP returns the description of the qualifier of reset process command
+func (sf *Catalog) DescribeQRP(qrp QualifierOfResetProcessCmd) string {
+	s, ok := sf.QRP[qrp]
+	if !ok && sf != englishCatalog {
+		s = englishCatalog.QRP[qrp]
+	}
+	return describe(fmt.Sprintf("QRP<%d>", qrp), s)
+}
+
+// DescribeIdentifier returns the description of the data unit identifier
+func (sf *Catalog) DescribeIdentifier(id Identifier) string {
+	return fmt.Sprintf("%s, %s, %s, @%d",
+		sf.DescribeTypeID(id.Type), id.Variable, sf.DescribeCause(id.Coa), id.CommonAddr)
+}
+
+// DescribeASDU returns the description of the asdu
+func (sf *Catalog) DescribeASDU(a *ASDU) string {
+	return fmt.Sprintf("%s [% x]", sf.DescribeIdentifier(a.Identifier), a.infoObj)
+}
+
+func init() {
+	for i := QOIGroup1; i <= QOIGroup16; i++ {
+		englishCatalog.QOI[i] = fmt.Sprintf("interrogated by group %d", i-QOIStation)
+		chineseCatalog.QOI[i] = fmt.Sprintf("第%d组召唤", i-QOIStation)
+	}
+	for i := InterrogatedByGroup1; i <= InterrogatedByGroup16; i++ {
+		englishCatalog.Cause[i] = fmt.Sprintf("interrogated by group %d", i-InterrogatedByStation)
+		chineseCatalog.Cause[i] = fmt.Sprintf("响应第%d组召唤", i-InterrogatedByStation)
+	}
+	for i := RequestByGroup1Counter; i <= RequestByGroup4Counter; i++ {
+		englishCatalog.Cause[i] = fmt.Sprintf("requested by group %d counter request", i-RequestByGeneralCounter)
+		chineseCatalog.Cause[i] = fmt.Sprintf("响应第%d组计数量召唤", i-RequestByGeneralCounter)
+	}
+}
+
+var englishCatalog = &Catalog{
+	Language: English,
+	TypeID: map[TypeID]string{
+		M_SP_NA_1: "single-point information",
+		M_SP_TA_1: "single-point information with time tag",
+		M_DP_NA_1: "double-point information",
+		M_DP_TA_1: "double-point information with time tag",
+		M_ST_NA_1: "step position information",
+		M_ST_TA_1: "step position information with time tag",
+		M_BO_NA_1: "bitstring of 32 bit",
+		M_BO_TA_1: "bitstring of 32 bit with time tag",
+		M_ME_NA_1: "measured value, normalized value",
+		M_ME_TA_1: "measured value, normalized value with time tag",
+		M_ME_NB_1: "measured value, scaled value",
+		M_ME_TB_1: "measured value, scaled value with time tag",
+		M_ME_NC_1: "measured value, short floating point number",
+		M_ME_TC_1: "measured value, short floating point number with time tag",
+		M_IT_NA_1: "integrated totals",
+		M_IT_TA_1: "integrated totals with time tag",
+		M_EP_TA_1: "event of protection equipment with time tag",
+		M_EP_TB_1: "packed start events of protection equipment with time tag",
+		M_EP_TC_1: "packed output circuit information of protection equipment with time tag",
+		M_PS_NA_1: "packed single-point information with status change detection",
+		M_ME_ND_1: "measured value, normalized value without quality descriptor",
+		M_SP_TB_1: "single-point information with time tag CP56Time2a",
+		M_DP_TB_1: "double-point information with time tag CP56Time2a",
+		M_ST_TB_1: "step position information with time tag CP56Time2a",
+		M_BO_TB_1: "bitstring of 32 bit with time tag CP56Time2a",
+		M_ME_TD_1: "measured value, normalized value with time tag CP56Time2a",
+		M_ME_TE_1: "measured value, scaled value with time tag CP56Time2a",
+		M_ME_TF_1: "measured value, short floating point number with time tag CP56Time2a",
+		M_IT_TB_1: "integrated totals with time tag CP56Time2a",
+		M_EP_TD_1: "event of protection equipment with time tag CP56Time2a",
+		M_EP_TE_1: "packed start events of protection equipment with time tag CP56Time2a",
+		M_EP_TF_1: "packed output circuit information of protection equipment with time tag CP56Time2a",
+		C_SC_NA_1: "single command",
+		C_DC_NA_1: "double command",
+		C_RC_NA_1: "regulating step command",
+		C_SE_NA_1: "set-point command, normalized value",
+		C_SE_NB_1: "set-point command, scaled value",
+		C_SE_NC_1: "set-point command, short floating point number",
+		C_BO_NA_1: "bitstring of 32 bit command",
+		C_SC_TA_1: "single command with time tag CP56Time2a",
+		C_DC_TA_1: "double command with time tag CP56Time2a",
+		C_RC_TA_1: "regulating step command with time tag CP56Time2a",
+		C_SE_TA_1: "set-point command, normalized value with time tag CP56Time2a",
+		C_SE_TB_1: "set-point command, scaled value with time tag CP56Time2a",
+		C_SE_TC_1: "set-point command, short floating point number with time tag CP56Time2a",
+		C_BO_TA_1: "bitstring of 32 bit command with time tag CP56Time2a",
+		M_EI_NA_1: "end of initialization",
+		C_IC_NA_1: "interrogation command",
+		C_CI_NA_1: "counter interrogation command",
+		C_RD_NA_1: "read command",
+		C_CS_NA_1: "clock synchronization command",
+		C_TS_NA_1: "test command",
+		C_RP_NA_1: "reset process command",
+		C_CD_NA_1: "delay acquisition command",
+		C_TS_TA_1: "test command with time tag CP56Time2a",
+		P_ME_NA_1: "parameter of measured value, normalized value",
+		P_ME_NB_1: "parameter of measured value, scaled value",
+		P_ME_NC_1: "parameter of measured value, short floating point number",
+		P_AC_NA_1: "parameter activation",
+		F_FR_NA_1: "file ready",
+		F_SR_NA_1: "section ready",
+		F_SC_NA_1: "call directory, select file, call file, call section",
+		F_LS_NA_1: "last section, last segment",
+		F_AF_NA_1: "ack file, ack section",
+		F_SG_NA_1: "segment",
+		F_DR_TA_1: "directory",
+		F_SC_NB_1: "query log, request archive file",
+	},
+	Cause: map[Cause]string{
+		Periodic:                "periodic, cyclic",
+		Background:              "background scan",
+		Spontaneous:             "spontaneous",
+		Initialized:             "initialized",
+		Request:                 "request or requested",
+		Activation:              "activation",
+		ActivationCon:           "activation confirmation",
+		Deactivation:            "deactivation",
+		DeactivationCon:         "deactivation confirmation",
+		ActivationTerm:          "activation termination",
+		ReturnInfoRemote:        "return information caused by a remote command",
+		ReturnInfoLocal:         "return information caused by a local command",
+		FileTransfer:            "file transfer",
+		InterrogatedByStation:   "interrogated by station interrogation",
+		RequestByGeneralCounter: "requested by general counter request",
+		UnknownTypeID:           "unknown type identification",
+		UnknownCOT:              "unknown cause of transmission",
+		UnknownCA:               "unknown common address of ASDU",
+		UnknownIOA:              "unknown information object address",
+	},
+	QOI: map[QualifierOfInterrogation]string{
+		QOIUnused:  "not used",
+		QOIStation: "station interrogation",
+	},
+	QCC: map[QCCRequest]string{
+		QCCUnused: "no counter requested",
+		QCCGroup1: "request counter group 1",
+		QCCGroup2: "request counter group 2",
+		QCCGroup3: "request counter group 3",
+		QCCGroup4: "request counter group 4",
+		QCCTotal:  "general request counter",
+	},
+	QPM: map[QPMCategory]string{
+		QPMUnused:    "not used",
+		QPMThreshold: "threshold value",
+		QPMSmoothing: "smoothing factor",
+		QPMLowLimit:  "low limit for transmission of measured values",
+		QPMHighLimit: "high limit for transmission of measured values",
+	},
+	QPA: map[QualifierOfParameterAct]string{
+		QPAUnused:                   "not used",
+		QPADeActPrevLoadedParameter: "act/deact of previously loaded parameters",
+		QPADeActObjectParameter:     "act/deact of the parameter of the addressed object",
+		QPADeActObjectTransmission:  "act/deact of persistent cyclic or periodic transmission of the addressed object",
+	},
+	QOC: map[QOCQual]string{
+		QOCNoAdditionalDefinition: "no additional definition",
+		QOCShortPulseDuration:     "short pulse duration",
+		QOCLongPulseDuration:      "long pulse duration",
+		QOCPersistentOutput:       "persistent output",
+	},
+	QRP: map[QualifierOfResetProcessCmd]string{
+		QRPUnused:                      "not used",
+		QPRGeneralRest:                 "general reset of process",
+		QPRResetPendingInfoWithTimeTag: "reset of pending information with time tag of the event buffer",
+	},
+}
+
+var chineseCatalog = &Catalog{
+	Language: Chinese,
+	TypeID: map[TypeID]string{
+		M_SP_NA_1: "单点信息",
+		M_SP_TA_1: "带时标的单点信息",
+		M_DP_NA_1: "双点信息",
+		M_DP_TA_1: "带时标的双点信息",
+		M_ST_NA_1: "步位置信息",
+		M_ST_TA_1: "带时标的步位置信息",
+		M_BO_NA_1: "32比特串",
+		M_BO_TA_1: "带时标的32比特串",
+		M_ME_NA_1: "测量值,归一化值",
+		M_ME_TA_1: "带时标的测量值,归一化值",
+		M_ME_NB_1: "测量值,标度化值",
+		M_ME_TB_1: "带时标的测量值,标度化值",
+		M_ME_NC_1: "测量值,短浮点数",
+		M_ME_TC_1: "带时标的测量值,短浮点数",
+		M_IT_NA_1: "累计量",
+		M_IT_TA_1: "带时标的累计量",
+		M_EP_TA_1: "带时标的继电保护设备事件",
+		M_EP_TB_1: "带时标的继电保护设备成组启动事件",
+		M_EP_TC_1: "带时标的继电保护设备成组输出电路信息",
+		M_PS_NA_1: "带变位检出的成组单点信息",
+		M_ME_ND_1: "不带品质描述词的测量值,归一化值",
+		M_SP_TB_1: "带CP56Time2a时标的单点信息",
+		M_DP_TB_1: "带CP56Time2a时标的双点信息",
+		M_ST_TB_1: "带CP56Time2a时标的步位置信息",
+		M_BO_TB_1: "带CP56Time2a时标的32比特串",
+		M_ME_TD_1: "带CP56Time2a时标的测量值,归一化值",
+		M_ME_TE_1: "带CP56Time2a时标的测量值,标度化值",
+		M_ME_TF_1: "带CP56Time2a时标的测量值,短浮点数",
+		M_IT_TB_1: "带CP56Time2a时标的累计量",
+		M_EP_TD_1: "带CP56Time2a时标的继电保护设备事件",
+		M_EP_TE_1: "带CP56Time2a时标的继电保护设备成组启动事件",
+		M_EP_TF_1: "带CP56Time2a时标的继电保护设备成组输出电路信息",
+		C_SC_NA_1: "单命令",
+		C_DC_NA_1: "双命令",
+		C_RC_NA_1: "步调节命令",
+		C_SE_NA_1: "设点命令,归一化值",
+		C_SE_NB_1: "设点命令,标度化值",
+		C_SE_NC_1: "设点命令,短浮点数",
+		C_BO_NA_1: "32比特串命令",
+		C_SC_TA_1: "带CP56Time2a时标的单命令",
+		C_DC_TA_1: "带CP56Time2a时标的双命令",
+		C_RC_TA_1: "带CP56Time2a时标的步调节命令",
+		C_SE_TA_1: "带CP56Time2a时标的设点命令,归一化值",
+		C_SE_TB_1: "带CP56Time2a时标的设点命令,标度化值",
+		C_SE_TC_1: "带CP56Time2a时标的设点命令,短浮点数",
+		C_BO_TA_1: "带CP56Time2a时标的32比特串命令",
+		M_EI_NA_1: "初始化结束",
+		C_IC_NA_1: "总召唤命令",
+		C_CI_NA_1: "电能量召唤命令",
+		C_RD_NA_1: "读命令",
+		C_CS_NA_1: "时钟同步命令",
+		C_TS_NA_1: "测试命令",
+		C_RP_NA_1: "复位进程命令",
+		C_CD_NA_1: "延时获得命令",
+		C_TS_TA_1: "带CP56Time2a时标的测试命令",
+		P_ME_NA_1: "测量值参数,归一化值",
+		P_ME_NB_1: "测量值参数,标度化值",
+		P_ME_NC_1: "测量值参数,短浮点数",
+		P_AC_NA_1: "参数激活",
+		F_FR_NA_1: "文件已准备好",
+		F_SR_NA_1: "节已准备好",
+		F_SC_NA_1: "召唤目录,选择文件,召唤文件,召唤节",
+		F_LS_NA_1: "最后的节,最后的段",
+		F_AF_NA_1: "认可文件,认可节",
+		F_SG_NA_1: "段",
+		F_DR_TA_1: "目录",
+		F_SC_NB_1: "查询日志,请求归档文件",
+	},
+	Cause: map[Cause]string{
+		Periodic:                "周期,循环",
+		Background:              "背景扫描",
+		Spontaneous:             "突发(自发)",
+		Initialized:             "初始化",
+		Request:                 "请求或被请求",
+		Activation:              "激活",
+		ActivationCon:           "激活确认",
+		Deactivation:            "停止激活",
+		DeactivationCon:         "停止激活确认",
+		ActivationTerm:          "激活终止",
+		ReturnInfoRemote:        "远方命令引起的返送信息",
+		ReturnInfoLocal:         "当地命令引起的返送信息",
+		FileTransfer:            "文件传输",
+		InterrogatedByStation:   "响应站召唤",
+		RequestByGeneralCounter: "响应计数量站总召唤",
+		UnknownTypeID:           "未知的类型标识",
+		UnknownCOT:              "未知的传送原因",
+		UnknownCA:               "未知的应用服务数据单元公共地址",
+		UnknownIOA:              "未知的信息对象地址",
+	},
+	QOI: map[QualifierOfInterrogation]string{
+		QOIUnused:  "未用",
+		QOIStation: "站召唤(总召唤)",
+	},
+	QCC: map[QCCRequest]string{
+		QCCUnused: "无请求计数量",
+		QCCGroup1: "请求计数量第1组",
+		QCCGroup2: "请求计数量第2组",
+		QCCGroup3: "请求计数量第3组",
+		QCCGroup4: "请求计数量第4组",
+		QCCTotal:  "总的请求计数量",
+	},
+	QPM: map[QPMCategory]string{
+		QPMUnused:    "未用",
+		QPMThreshold: "门限值",
+		QPMSmoothing: "平滑系数(滤波时间常数)",
+		QPMLowLimit:  "传送测量值的下限",
+		QPMHighLimit: "传送测量值的上限",
+	},
+	QPA: map[QualifierOfParameterAct]string{
+		QPAUnused:                   "未用",
+		QPADeActPrevLoadedParameter: "激活/停止激活之前装载的参数",
+		QPADeActObjectParameter:     "激活/停止激活所寻址信息对象的参数",
+		QPADeActObjectTransmission:  "激活/停止激活所寻址信息对象的持续循环或周期传输",
+	},
+	QOC: map[QOCQual]string{
+		QOCNoAdditionalDefinition: "无另外的定义",
+		QOCShortPulseDuration:     "短脉冲持续时间",
+		QOCLongPulseDuration:      "长脉冲持续时间",
+		QOCPersistentOutput:       "持续输出",
+	},
+	QRP: map[QualifierOfResetProcessCmd]string{
+		QRPUnused:                      "未采用",
+		QPRGeneralRest:                 "进程的总复位",
+		QPRResetPendingInfoWithTimeTag: "复位事件缓冲区等待处理的带时标的信息",
+	},
+}
@@ -0,0 +1,56 @@
+package asdu
+
+import (
+	"testing"
+)
+
+func TestCatalog_DescribeTypeID(t *testing.T) {
+	custom := &Catalog{Language: "de", TypeID: map[TypeID]string{M_SP_NA_1: "Einzelmeldung"}}
+	RegisterCatalog(custom)
+
+	tests := []struct {
+		name string
+		lang Language
+		id   TypeID
+		want string
+	}{
+		{"english", English, C_IC_NA_1, "TID<C_IC_NA_1> interrogation command"},
+		{"chinese", Chinese, C_IC_NA_1, "TID<C_IC_NA_1> 总召唤命令"},
+		{"custom", "de", M_SP_NA_1, "TID<M_SP_NA_1> Einzelmeldung"},
+		{"fall back to english", "de", M_DP_NA_1, "TID<M_DP_NA_1> double-point information"},
+		{"no description", English, 200, "TID<200>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := LookupCatalog(tt.lang)
+			if !ok {
+				t.Fatalf("LookupCatalog(%q) not found", tt.lang)
+			}
+			if got := c.DescribeTypeID(tt.id); got != tt.want {
+				t.Errorf("DescribeTypeID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalog_DescribeCause(t *testing.T) {
+	c, _ := LookupCatalog(Chinese)
+	tests := []struct {
+		name string
+		coa  CauseOfTransmission
+		want string
+	}{
+		{"negative", CauseOfTransmission{Cause: UnknownIOA, IsNegative: true}, "COT<UnknownIOA,neg> 未知的信息对象地址"},
+		{"group", CauseOfTransmission{Cause: InterrogatedByGroup2}, "COT<InterrogatedByGroup2> 响应第2组召唤"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.DescribeCause(tt.coa); got != tt.want {
+				t.Errorf("DescribeCause() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got, want := c.DescribeQOI(QOIGroup3), "QOI<23> 第3组召唤"; got != want {
+		t.Errorf("DescribeQOI() = %v, want %v", got, want)
+	}
+}
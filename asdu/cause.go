@@ -0,0 +1,141 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+// CauseSet a set of causes of transmission
+type CauseSet uint64
+
+// NewCauseSet returns the set of the causes
+func NewCauseSet(causes ...Cause) CauseSet {
+	return CauseSet(0).With(causes...)
+}
+
+// CauseRange returns the set of the causes from through to
+func CauseRange(from, to Cause) CauseSet {
+	var s CauseSet
+	for c := from; c <= to && c < 64; c++ {
+		s |= 1 << c
+	}
+	return s
+}
+
+// With returns the set with the causes added
+func (sf CauseSet) With(causes ...Cause) CauseSet {
+	for _, c := range causes {
+		sf |= 1 << (c & 0x3f)
+	}
+	return sf
+}
+
+// Has whether the cause is in the set
+func (sf CauseSet) Has(c Cause) bool {
+	return sf&(1<<(c&0x3f)) != 0
+}
+
+// CauseTable the causes of transmission allowed to send each type identification,
+// an empty set does not restrict the cause. See Params.Causes
+type CauseTable [256]CauseSet
+
+// StandardCauseTable returns a copy of the causes of transmission of the companion standard,
+// to be extended or relaxed for vendor specific usages, for example the private range <48..63>.
+func StandardCauseTable() *CauseTable {
+	t := standardCauses
+	return &t
+}
+
+// Allow add the causes allowed to send the type identification
+func (sf *CauseTable) Allow(t TypeID, causes ...Cause) *CauseTable {
+	sf[t] = sf[t].With(causes...)
+	return sf
+}
+
+// Relax remove any restriction of the cause to send the type identification
+func (sf *CauseTable) Relax(t TypeID) *CauseTable {
+	sf[t] = 0
+	return sf
+}
+
+// Check returns ErrCmdCause if the cause is not allowed to send the type identification
+func (sf *CauseTable) Check(t TypeID, coa CauseOfTransmission) error {
+	if s := sf[t]; s != 0 && !s.Has(coa.Cause) {
+		return ErrCmdCause
+	}
+	return nil
+}
+
+// checkCause check the cause against the table of the params of c, default the standard one
+func checkCause(c Connect, t TypeID, coa CauseOfTransmission) error {
+	if p := c.Params(); p != nil && p.Causes != nil {
+		return p.Causes.Check(t, coa)
+	}
+	return standardCauses.Check(t, coa)
+}
+
+var (
+	causesInterrogated = CauseRange(InterrogatedByStation, InterrogatedByGroup16)
+	causesStatus       = NewCauseSet(Background, Spontaneous, Request, ReturnInfoRemote, ReturnInfoLocal) | causesInterrogated
+	causesStatusTime   = NewCauseSet(Spontaneous, Request, ReturnInfoRemote, ReturnInfoLocal)
+	causesMeasured     = NewCauseSet(Periodic, Background, Spontaneous, Request) | causesInterrogated
+	causesMeasuredTime = NewCauseSet(Spontaneous, Request)
+	causesTotals       = NewCauseSet(Spontaneous) | CauseRange(RequestByGeneralCounter, RequestByGroup4Counter)
+	causesCommand      = NewCauseSet(Activation, Deactivation)
+)
+
+var standardCauses = CauseTable{
+	M_SP_NA_1: causesStatus,
+	M_SP_TA_1: causesStatusTime,
+	M_SP_TB_1: causesStatusTime,
+	M_DP_NA_1: causesStatus,
+	M_DP_TA_1: causesStatusTime,
+	M_DP_TB_1: causesStatusTime,
+	M_ST_NA_1: causesStatus,
+	M_ST_TA_1: causesStatusTime,
+	M_ST_TB_1: causesStatusTime,
+	M_BO_NA_1: NewCauseSet(Background, Spontaneous, Request) | causesInterrogated,
+	M_BO_TA_1: causesMeasuredTime,
+	M_BO_TB_1: causesMeasuredTime,
+	M_ME_NA_1: causesMeasured,
+	M_ME_TA_1: causesMeasuredTime,
+	M_ME_TD_1: causesMeasuredTime,
+	M_ME_ND_1: causesMeasured,
+	M_ME_NB_1: causesMeasured,
+	M_ME_TB_1: causesMeasuredTime,
+	M_ME_TE_1: causesMeasuredTime,
+	M_ME_NC_1: causesMeasured,
+	M_ME_TC_1: causesMeasuredTime,
+	M_ME_TF_1: causesMeasuredTime,
+	M_IT_NA_1: causesTotals,
+	M_IT_TA_1: causesTotals,
+	M_IT_TB_1: causesTotals,
+	M_EP_TA_1: NewCauseSet(Spontaneous),
+	M_EP_TB_1: NewCauseSet(Spontaneous),
+	M_EP_TC_1: NewCauseSet(Spontaneous),
+	M_EP_TD_1: NewCauseSet(Spontaneous),
+	M_EP_TE_1: NewCauseSet(Spontaneous),
+	M_EP_TF_1: NewCauseSet(Spontaneous),
+	M_PS_NA_1: causesStatus,
+
+	C_SC_NA_1: causesCommand,
+	C_DC_NA_1: causesCommand,
+	C_RC_NA_1: causesCommand,
+	C_SE_NA_1: causesCommand,
+	C_SE_NB_1: causesCommand,
+	C_SE_NC_1: causesCommand,
+	C_BO_NA_1: causesCommand,
+	C_SC_TA_1: causesCommand,
+	C_DC_TA_1: causesCommand,
+	C_RC_TA_1: causesCommand,
+	C_SE_TA_1: causesCommand,
+	C_SE_TB_1: causesCommand,
+	C_SE_TC_1: causesCommand,
+	C_BO_TA_1: causesCommand,
+
+	C_IC_NA_1: causesCommand,
+	C_CD_NA_1: NewCauseSet(Spontaneous, Activation),
+	P_ME_NA_1: NewCauseSet(Activation),
+	P_ME_NB_1: NewCauseSet(Activation),
+	P_ME_NC_1: NewCauseSet(Activation),
+	P_AC_NA_1: causesCommand,
+}
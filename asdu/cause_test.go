@@ -0,0 +1,68 @@
+package asdu
+
+import (
+	"net"
+	"testing"
+)
+
+// paramsConn discards the sent asdu with the params
+type paramsConn struct {
+	p *Params
+}
+
+func (sf paramsConn) Params() *Params          { return sf.p }
+func (sf paramsConn) UnderlyingConn() net.Conn { return nil }
+func (sf paramsConn) Send(u *ASDU) error {
+	_, err := u.MarshalBinary()
+	return err
+}
+
+func TestCauseTable(t *testing.T) {
+	private := Cause(50)
+	p := *ParamsWide
+	p.Causes = StandardCauseTable().Allow(M_SP_NA_1, private).Relax(M_ME_NC_1)
+	c := paramsConn{&p}
+
+	tests := []struct {
+		name string
+		c    Connect
+		send func(c Connect) error
+		want error
+	}{
+		{"standard cause", paramsConn{ParamsWide}, func(c Connect) error {
+			return Single(c, false, CauseOfTransmission{Cause: Spontaneous}, 1, SinglePointInfo{Ioa: 1})
+		}, nil},
+		{"private cause by standard table", paramsConn{ParamsWide}, func(c Connect) error {
+			return Single(c, false, CauseOfTransmission{Cause: private}, 1, SinglePointInfo{Ioa: 1})
+		}, ErrCmdCause},
+		{"private cause allowed", c, func(c Connect) error {
+			return Single(c, false, CauseOfTransmission{Cause: private}, 1, SinglePointInfo{Ioa: 1})
+		}, nil},
+		{"other type keeps standard", c, func(c Connect) error {
+			return Double(c, false, CauseOfTransmission{Cause: private}, 1, DoublePointInfo{Ioa: 1})
+		}, ErrCmdCause},
+		{"relaxed", c, func(c Connect) error {
+			return MeasuredValueFloat(c, false, CauseOfTransmission{Cause: ActivationCon}, 1, MeasuredValueFloatInfo{Ioa: 1})
+		}, nil},
+		{"command", c, func(c Connect) error {
+			return SingleCmd(c, C_SC_NA_1, CauseOfTransmission{Cause: Spontaneous}, 1, SingleCommandInfo{Ioa: 1})
+		}, ErrCmdCause},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.send(tt.c); got != tt.want {
+				t.Errorf("send error = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if standardCauses[M_SP_NA_1].Has(private) {
+		t.Errorf("StandardCauseTable() shares the standard table")
+	}
+}
+
+func TestCauseRange(t *testing.T) {
+	s := CauseRange(InterrogatedByStation, InterrogatedByGroup16)
+	if !s.Has(InterrogatedByStation) || !s.Has(InterrogatedByGroup16) || s.Has(RequestByGeneralCounter) || s.Has(Spontaneous) {
+		t.Errorf("CauseRange() = %b", s)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"time"
+)
+
+// Clock the source of the time of the time tags and the timeouts, injected by the tests
+// to fast-forward the time and to get reproducible time tags. See Params.Clock.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer the timer of a Clock, like time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SystemClock the Clock of the system time
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (sf systemTimer) C() <-chan time.Time { return sf.Timer.C }
+
+// Now returns the current time of the clock of the params, the system time if none
+func (sf *Params) Now() time.Time {
+	if sf.Clock == nil {
+		return time.Now()
+	}
+	return sf.Clock.Now()
+}
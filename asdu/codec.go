@@ -169,7 +169,7 @@ func (sf *ASDU) DecodeBitsString32() uint32 {
 
 // AppendCP56Time2a append a CP56Time2a value to info object
 func (sf *ASDU) AppendCP56Time2a(t time.Time, loc *time.Location) *ASDU {
-	sf.infoObj = append(sf.infoObj, CP56Time2a(t, loc)...)
+	sf.infoObj = appendCP56Time2a(sf.infoObj, t, loc)
 	return sf
 }
 
@@ -182,20 +182,20 @@ func (sf *ASDU) DecodeCP56Time2a() time.Time {
 
 // AppendCP24Time2a append CP24Time2a to asdu info object
 func (sf *ASDU) AppendCP24Time2a(t time.Time, loc *time.Location) *ASDU {
-	sf.infoObj = append(sf.infoObj, CP24Time2a(t, loc)...)
+	sf.infoObj = appendCP24Time2a(sf.infoObj, t, loc)
 	return sf
 }
 
 // DecodeCP24Time2a decode info object byte to CP24Time2a
 func (sf *ASDU) DecodeCP24Time2a() time.Time {
-	t := ParseCP24Time2a(sf.infoObj, sf.Params.InfoObjTimeZone)
+	t := parseCP24Time2aAt(sf.infoObj, sf.InfoObjTimeZone, sf.Params.Now())
 	sf.infoObj = sf.infoObj[3:]
 	return t
 }
 
 // AppendCP16Time2a append CP16Time2a to asdu info object
 func (sf *ASDU) AppendCP16Time2a(msec uint16) *ASDU {
-	sf.infoObj = append(sf.infoObj, CP16Time2a(msec)...)
+	sf.infoObj = append(sf.infoObj, byte(msec), byte(msec>>8))
 	return sf
 }
 
@@ -218,3 +218,30 @@ func (sf *ASDU) DecodeStatusAndStatusChangeDetection() StatusAndStatusChangeDete
 	sf.infoObj = sf.infoObj[4:]
 	return s
 }
+
+// DecodeMark a position of the decode cursor in the information objects, see Mark
+type DecodeMark struct {
+	infoObj []byte
+}
+
+// Remaining returns the size of the information objects not decoded yet
+func (sf *ASDU) Remaining() int {
+	return len(sf.infoObj)
+}
+
+// Mark returns the position of the decode cursor, to decode again from it with Rewind
+func (sf *ASDU) Mark() DecodeMark {
+	return DecodeMark{sf.infoObj}
+}
+
+// Rewind move the decode cursor back to the position marked on this asdu
+func (sf *ASDU) Rewind(m DecodeMark) {
+	sf.infoObj = m.infoObj
+}
+
+// Reset move the decode cursor back to the first information object, so that the asdu
+// can be decoded once more, like Mirror the information objects decoded are recovered
+// only if the asdu holds them itself, as it does after UnmarshalBinary, NewASDU or Clone.
+func (sf *ASDU) Reset() {
+	sf.infoObj = sf.wholeInfoObj()
+}
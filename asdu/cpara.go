@@ -30,8 +30,8 @@ type ParameterNormalInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func ParameterNormal(c Connect, coa CauseOfTransmission, ca CommonAddr, p ParameterNormalInfo) error {
-	if coa.Cause != Activation {
-		return ErrCmdCause
+	if err := checkCause(c, P_ME_NA_1, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -76,8 +76,8 @@ type ParameterScaledInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func ParameterScaled(c Connect, coa CauseOfTransmission, ca CommonAddr, p ParameterScaledInfo) error {
-	if coa.Cause != Activation {
-		return ErrCmdCause
+	if err := checkCause(c, P_ME_NB_1, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -121,8 +121,8 @@ type ParameterFloatInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func ParameterFloat(c Connect, coa CauseOfTransmission, ca CommonAddr, p ParameterFloatInfo) error {
-	if coa.Cause != Activation {
-		return ErrCmdCause
+	if err := checkCause(c, P_ME_NC_1, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -162,8 +162,8 @@ type ParameterActivationInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func ParameterActivation(c Connect, coa CauseOfTransmission, ca CommonAddr, p ParameterActivationInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, P_AC_NA_1, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -34,8 +34,8 @@ type SingleCommandInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func SingleCmd(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, cmd SingleCommandInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -60,7 +60,7 @@ func SingleCmd(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr,
 	switch typeID {
 	case C_SC_NA_1:
 	case C_SC_TA_1:
-		u.AppendBytes(CP56Time2a(cmd.Time, u.InfoObjTimeZone)...)
+		u.AppendCP56Time2a(cmd.Time, u.InfoObjTimeZone)
 	default:
 		return ErrTypeIDNotMatch
 	}
@@ -92,8 +92,8 @@ type DoubleCommandInfo struct {
 // <47> := Unknown message object address
 func DoubleCmd(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr,
 	cmd DoubleCommandInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -114,7 +114,7 @@ func DoubleCmd(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr,
 	switch typeID {
 	case C_DC_NA_1:
 	case C_DC_TA_1:
-		u.AppendBytes(CP56Time2a(cmd.Time, u.InfoObjTimeZone)...)
+		u.AppendCP56Time2a(cmd.Time, u.InfoObjTimeZone)
 	default:
 		return ErrTypeIDNotMatch
 	}
@@ -145,8 +145,8 @@ type StepCommandInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func StepCmd(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, cmd StepCommandInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -167,7 +167,7 @@ func StepCmd(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, c
 	switch typeID {
 	case C_RC_NA_1:
 	case C_RC_TA_1:
-		u.AppendBytes(CP56Time2a(cmd.Time, u.InfoObjTimeZone)...)
+		u.AppendCP56Time2a(cmd.Time, u.InfoObjTimeZone)
 	default:
 		return ErrTypeIDNotMatch
 	}
@@ -198,8 +198,8 @@ type SetpointCommandNormalInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func SetpointCmdNormal(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, cmd SetpointCommandNormalInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -219,7 +219,7 @@ func SetpointCmdNormal(c Connect, typeID TypeID, coa CauseOfTransmission, ca Com
 	switch typeID {
 	case C_SE_NA_1:
 	case C_SE_TA_1:
-		u.AppendBytes(CP56Time2a(cmd.Time, u.InfoObjTimeZone)...)
+		u.AppendCP56Time2a(cmd.Time, u.InfoObjTimeZone)
 	default:
 		return ErrTypeIDNotMatch
 	}
@@ -250,8 +250,8 @@ type SetpointCommandScaledInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func SetpointCmdScaled(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, cmd SetpointCommandScaledInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -271,7 +271,7 @@ func SetpointCmdScaled(c Connect, typeID TypeID, coa CauseOfTransmission, ca Com
 	switch typeID {
 	case C_SE_NB_1:
 	case C_SE_TB_1:
-		u.AppendBytes(CP56Time2a(cmd.Time, u.InfoObjTimeZone)...)
+		u.AppendCP56Time2a(cmd.Time, u.InfoObjTimeZone)
 	default:
 		return ErrTypeIDNotMatch
 	}
@@ -302,8 +302,8 @@ type SetpointCommandFloatInfo struct {
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func SetpointCmdFloat(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, cmd SetpointCommandFloatInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -324,7 +324,7 @@ func SetpointCmdFloat(c Connect, typeID TypeID, coa CauseOfTransmission, ca Comm
 	switch typeID {
 	case C_SE_NC_1:
 	case C_SE_TC_1:
-		u.AppendBytes(CP56Time2a(cmd.Time, u.InfoObjTimeZone)...)
+		u.AppendCP56Time2a(cmd.Time, u.InfoObjTimeZone)
 	default:
 		return ErrTypeIDNotMatch
 	}
@@ -356,8 +356,8 @@ type BitsString32CommandInfo struct {
 // <47> := Unknown message object address
 func BitsString32Cmd(c Connect, typeID TypeID, coa CauseOfTransmission, commonAddr CommonAddr,
 	cmd BitsString32CommandInfo) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -378,7 +378,7 @@ func BitsString32Cmd(c Connect, typeID TypeID, coa CauseOfTransmission, commonAd
 	switch typeID {
 	case C_BO_NA_1:
 	case C_BO_TA_1:
-		u.AppendBytes(CP56Time2a(cmd.Time, u.InfoObjTimeZone)...)
+		u.AppendCP56Time2a(cmd.Time, u.InfoObjTimeZone)
 	default:
 		return ErrTypeIDNotMatch
 	}
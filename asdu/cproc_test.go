@@ -868,3 +868,57 @@ func TestASDU_GetBitsString32Cmd(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeTaggedCommands_roundTrip(t *testing.T) {
+	tm := time.Date(2020, 6, 7, 8, 9, 10, 11e6, time.UTC)
+	act := CauseOfTransmission{Cause: Activation}
+	tests := []struct {
+		typeID TypeID
+		send   func(c Connect) error
+		check  func(a *ASDU) bool
+	}{
+		{C_SC_TA_1, func(c Connect) error {
+			return SingleCmd(c, C_SC_TA_1, act, 1, SingleCommandInfo{Ioa: 1, Value: true, Time: tm})
+		}, func(a *ASDU) bool { v := a.GetSingleCmd(); return v.Value && v.Time.Equal(tm) }},
+		{C_DC_TA_1, func(c Connect) error {
+			return DoubleCmd(c, C_DC_TA_1, act, 1, DoubleCommandInfo{Ioa: 1, Value: DCOOn, Time: tm})
+		}, func(a *ASDU) bool { v := a.GetDoubleCmd(); return v.Value == DCOOn && v.Time.Equal(tm) }},
+		{C_RC_TA_1, func(c Connect) error {
+			return StepCmd(c, C_RC_TA_1, act, 1, StepCommandInfo{Ioa: 1, Value: SCOStepUP, Time: tm})
+		}, func(a *ASDU) bool { v := a.GetStepCmd(); return v.Value == SCOStepUP && v.Time.Equal(tm) }},
+		{C_SE_TA_1, func(c Connect) error {
+			return SetpointCmdNormal(c, C_SE_TA_1, act, 1, SetpointCommandNormalInfo{Ioa: 1, Value: 100, Time: tm})
+		}, func(a *ASDU) bool { v := a.GetSetpointNormalCmd(); return v.Value == 100 && v.Time.Equal(tm) }},
+		{C_SE_TB_1, func(c Connect) error {
+			return SetpointCmdScaled(c, C_SE_TB_1, act, 1, SetpointCommandScaledInfo{Ioa: 1, Value: -5, Time: tm})
+		}, func(a *ASDU) bool { v := a.GetSetpointCmdScaled(); return v.Value == -5 && v.Time.Equal(tm) }},
+		{C_SE_TC_1, func(c Connect) error {
+			return SetpointCmdFloat(c, C_SE_TC_1, act, 1, SetpointCommandFloatInfo{Ioa: 1, Value: 1.5, Time: tm})
+		}, func(a *ASDU) bool { v := a.GetSetpointFloatCmd(); return v.Value == 1.5 && v.Time.Equal(tm) }},
+		{C_BO_TA_1, func(c Connect) error {
+			return BitsString32Cmd(c, C_BO_TA_1, act, 1, BitsString32CommandInfo{Ioa: 1, Value: 0xdeadbeef, Time: tm})
+		}, func(a *ASDU) bool { v := a.GetBitsString32Cmd(); return v.Value == 0xdeadbeef && v.Time.Equal(tm) }},
+		{C_TS_TA_1, func(c Connect) error {
+			return TestCommandCP56Time2a(c, act, 1, tm)
+		}, func(a *ASDU) bool { _, ok, v := a.GetTestCommandCP56Time2a(); return ok && v.Equal(tm) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typeID.String(), func(t *testing.T) {
+			c := &lastConn{}
+			if err := tt.send(c); err != nil {
+				t.Fatal(err)
+			}
+			data, err := c.a.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := NewEmptyASDU(ParamsWide)
+			if err = a.UnmarshalBinary(data); err != nil {
+				t.Fatalf("UnmarshalBinary() error %v", err)
+			}
+			if a.Type != tt.typeID || !tt.check(a) {
+				t.Errorf("round trip of %v failed", tt.typeID)
+			}
+		})
+	}
+}
@@ -25,8 +25,8 @@ import (
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func InterrogationCmd(c Connect, coa CauseOfTransmission, ca CommonAddr, qoi QualifierOfInterrogation) error {
-	if !(coa.Cause == Activation || coa.Cause == Deactivation) {
-		return ErrCmdCause
+	if err := checkCause(c, C_IC_NA_1, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -132,7 +132,7 @@ func ClockSynchronizationCmd(c Connect, coa CauseOfTransmission, ca CommonAddr,
 	if err := u.AppendInfoObjAddr(InfoObjAddrIrrelevant); err != nil {
 		return err
 	}
-	u.AppendBytes(CP56Time2a(t, u.InfoObjTimeZone)...)
+	u.AppendCP56Time2a(t, u.InfoObjTimeZone)
 	return c.Send(u)
 }
 
@@ -209,8 +209,8 @@ func ResetProcessCmd(c Connect, coa CauseOfTransmission, ca CommonAddr, qrp Qual
 // <46> := Unknown ASDU public address
 // <47> := Unknown message object address
 func DelayAcquireCommand(c Connect, coa CauseOfTransmission, ca CommonAddr, msec uint16) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Activation) {
-		return ErrCmdCause
+	if err := checkCause(c, C_CD_NA_1, coa); err != nil {
+		return err
 	}
 	if err := c.Params().Valid(); err != nil {
 		return err
@@ -6,26 +6,69 @@ package asdu
 
 import (
 	"errors"
-	"fmt"
 )
 
+// error category, the errors of the packages wrap one of them, test with errors.Is or Category
+var (
+	// ErrConfig the configuration or the system parameters are out of range
+	ErrConfig = errors.New("invalid configuration")
+	// ErrProtocol the asdu or the frame violates the standard, whether built or received
+	ErrProtocol = errors.New("protocol violation")
+	// ErrPeer the peer station refused or did not answer
+	ErrPeer = errors.New("peer failure")
+	// ErrTransport the connection is closed, down or congested
+	ErrTransport = errors.New("transport failure")
+)
+
+// Error an error of a category, it unwraps to the category
+type Error struct {
+	Category error
+	Msg      string
+}
+
+// NewError new an error of the category
+func NewError(category error, msg string) error {
+	return &Error{category, msg}
+}
+
+func (sf *Error) Error() string { return sf.Msg }
+
+// Unwrap returns the category
+func (sf *Error) Unwrap() error { return sf.Category }
+
+// Category returns the category of the error: ErrConfig, ErrProtocol, ErrPeer or ErrTransport, nil for none
+func Category(err error) error {
+	for _, c := range []error{ErrConfig, ErrProtocol, ErrPeer, ErrTransport} {
+		if errors.Is(err, c) {
+			return c
+		}
+	}
+	return nil
+}
+
 // error defined
 var (
-	ErrTypeIdentifier = errors.New("asdu: type identification unknown")
-	ErrCauseZero      = errors.New("asdu: cause of transmission 0 is not used")
-	ErrCommonAddrZero = errors.New("asdu: common address 0 is not used")
-
-	ErrParam           = errors.New("asdu: system parameter out of range")
-	ErrInvalidTimeTag  = errors.New("asdu: invalid time tag")
-	ErrOriginAddrFit   = errors.New("asdu: originator address not allowed with cause size 1 system parameter")
-	ErrCommonAddrFit   = errors.New("asdu: common address exceeds size system parameter")
-	ErrInfoObjAddrFit  = errors.New("asdu: information object address exceeds size system parameter")
-	ErrInfoObjIndexFit = errors.New("asdu: information object index not in [1, 127]")
-	ErrInroGroupNumFit = errors.New("asdu: interrogation group number exceeds 16")
-
-	ErrLengthOutOfRange = fmt.Errorf("asdu: asdu filed length large than max %d", ASDUSizeMax)
-	ErrNotAnyObjInfo    = errors.New("asdu: not any object information")
-	ErrTypeIDNotMatch   = errors.New("asdu: type identifier doesn't match call or time tag")
-
-	ErrCmdCause = errors.New("asdu: cause of transmission for command not standard requirement")
+	ErrTypeIdentifier = NewError(ErrProtocol, "asdu: type identification unknown")
+	ErrCauseZero      = NewError(ErrProtocol, "asdu: cause of transmission 0 is not used")
+	ErrCommonAddrZero = NewError(ErrProtocol, "asdu: common address 0 is not used")
+
+	ErrParam               = NewError(ErrConfig, "asdu: system parameter out of range")
+	ErrInvalidTimeTag      = NewError(ErrProtocol, "asdu: invalid time tag")
+	ErrOriginAddrFit       = NewError(ErrProtocol, "asdu: originator address not allowed with cause size 1 system parameter")
+	ErrCommonAddrFit       = NewError(ErrProtocol, "asdu: common address exceeds size system parameter")
+	ErrInfoObjAddrFit      = NewError(ErrProtocol, "asdu: information object address exceeds size system parameter")
+	ErrInfoObjAddrNotation = NewError(ErrProtocol, "asdu: invalid information object address notation")
+	ErrInfoObjIndexFit     = NewError(ErrProtocol, "asdu: information object index not in [1, 127]")
+	ErrInroGroupNumFit     = NewError(ErrProtocol, "asdu: interrogation group number exceeds 16")
+	ErrNormalizeFit        = NewError(ErrProtocol, "asdu: normalized value not in [-1, 1-2^-15]")
+
+	ErrLengthOutOfRange = NewError(ErrProtocol, "asdu: asdu filed length large than max")
+	ErrNotAnyObjInfo    = NewError(ErrProtocol, "asdu: not any object information")
+	ErrTypeIDNotMatch   = NewError(ErrProtocol, "asdu: type identifier doesn't match call or time tag")
+
+	ErrCmdCause = NewError(ErrProtocol, "asdu: cause of transmission for command not standard requirement")
+
+	ErrProfileParam  = NewError(ErrConfig, "asdu: system parameter not selected by profile")
+	ErrProfileTypeID = NewError(ErrProtocol, "asdu: type identification not selected by profile")
+	ErrPrivateType   = NewError(ErrProtocol, "asdu: invalid private type identification")
 )
@@ -0,0 +1,37 @@
+package asdu
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCategory(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"param", ErrParam, ErrConfig},
+		{"wrapped", fmt.Errorf("decode: %w", ErrTypeIDNotMatch), ErrProtocol},
+		{"custom", NewError(ErrPeer, "no answer"), ErrPeer},
+		{"category", ErrTransport, ErrTransport},
+		{"none", errors.New("other"), nil},
+		{"nil", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Category(tt.err); got != tt.want {
+				t.Errorf("Category() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var e *Error
+	if !errors.As(fmt.Errorf("send: %w", ErrCommonAddrFit), &e) || e.Category != ErrProtocol {
+		t.Errorf("errors.As() = %v", e)
+	}
+	if ErrParam.Error() != "asdu: system parameter out of range" {
+		t.Errorf("Error() = %q", ErrParam.Error())
+	}
+}
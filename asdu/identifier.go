@@ -163,7 +163,7 @@ const (
 )
 
 // infoObjSize maps the type identification (TypeID) to the serial octet size.
-// Type extensions must register here.
+// Type extensions must register here, private ones with RegisterPrivateType.
 var infoObjSize = map[TypeID]int{
 	M_SP_NA_1: 1,
 	M_SP_TA_1: 4,
@@ -206,6 +206,13 @@ var infoObjSize = map[TypeID]int{
 	C_SE_NB_1: 3,
 	C_SE_NC_1: 5,
 	C_BO_NA_1: 4,
+	C_SC_TA_1: 8,
+	C_DC_TA_1: 8,
+	C_RC_TA_1: 8,
+	C_SE_TA_1: 10,
+	C_SE_TB_1: 10,
+	C_SE_TC_1: 12,
+	C_BO_TA_1: 11,
 
 	M_EI_NA_1: 1,
 
@@ -216,6 +223,7 @@ var infoObjSize = map[TypeID]int{
 	C_TS_NA_1: 2,
 	C_RP_NA_1: 1,
 	C_CD_NA_1: 2,
+	C_TS_TA_1: 9,
 
 	P_ME_NA_1: 3,
 	P_ME_NB_1: 3,
@@ -235,7 +243,11 @@ var infoObjSize = map[TypeID]int{
 func GetInfoObjSize(id TypeID) (int, error) {
 	size, exists := infoObjSize[id]
 	if !exists {
-		return 0, ErrTypeIdentifier
+		t, ok := LookupPrivateType(id)
+		if !ok {
+			return 0, ErrTypeIdentifier
+		}
+		size = t.Size
 	}
 	return size, nil
 }
@@ -286,7 +298,11 @@ func (sf TypeID) String() string {
 		sf -= 120
 		s = _TypeIDName9[sf*9 : 9*(sf+1)]
 	default:
-		s = strconv.FormatInt(int64(sf), 10)
+		if t, ok := LookupPrivateType(sf); ok && t.Name != "" {
+			s = t.Name
+		} else {
+			s = strconv.FormatInt(int64(sf), 10)
+		}
 	}
 	return "TID<" + s + ">"
 }
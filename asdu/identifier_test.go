@@ -16,6 +16,7 @@ func TestGetInfoObjSize(t *testing.T) {
 		wantErr bool
 	}{
 		{"defined", args{F_DR_TA_1}, 13, false},
+		{"time tagged command", args{C_SE_TC_1}, 12, false},
 		{"no defined", args{F_SG_NA_1}, 0, true},
 	}
 	for _, tt := range tests {
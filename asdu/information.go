@@ -4,6 +4,10 @@
 
 package asdu
 
+import (
+	"math"
+)
+
 // about information object Application Service Data Unit -Information Object
 
 // InfoObjAddr is the information object address.
@@ -144,6 +148,44 @@ func (sf Normalize) Float64() float64 {
 	return float64(sf) / 32768
 }
 
+// Float32 returns the value in [-1, 1 − 2⁻¹⁵].
+func (sf Normalize) Float32() float32 {
+	return float32(sf) / 32768
+}
+
+// NewNormalize returns the normalized value of f rounded to the nearest, ErrNormalizeFit
+// when f is not in [-1, 1 − 2⁻¹⁵] or NaN.
+func NewNormalize(f float64) (Normalize, error) {
+	if math.IsNaN(f) || f < -1 || f > 1-1.0/32768 {
+		return 0, ErrNormalizeFit
+	}
+	return Normalize(math.Round(f * 32768)), nil
+}
+
+// NewNormalizeFloat32 returns the normalized value of f like NewNormalize.
+func NewNormalizeFloat32(f float32) (Normalize, error) {
+	return NewNormalize(float64(f))
+}
+
+// SaturateNormalize returns the normalized value of f rounded to the nearest, f clamped
+// to [-1, 1 − 2⁻¹⁵], NaN is 0.
+func SaturateNormalize(f float64) Normalize {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f < -1:
+		return math.MinInt16
+	case f > 1-1.0/32768:
+		return math.MaxInt16
+	}
+	return Normalize(math.Round(f * 32768))
+}
+
+// SaturateNormalizeFloat32 returns the normalized value of f like SaturateNormalize.
+func SaturateNormalizeFloat32(f float32) Normalize {
+	return SaturateNormalize(float64(f))
+}
+
 // BinaryCounterReading is binary counter reading
 // See companion standard 101, subclass 7.2.6.9.
 // CounterReading: [bit0...bit31]
@@ -245,3 +245,40 @@ func TestQualifierOfParam_Value(t *testing.T) {
 		})
 	}
 }
+
+func TestNewNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		f       float64
+		want    Normalize
+		wantErr error
+		sat     Normalize
+	}{
+		{"min", -1, math.MinInt16, nil, math.MinInt16},
+		{"max", 1 - 1.0/32768, math.MaxInt16, nil, math.MaxInt16},
+		{"zero", 0, 0, nil, 0},
+		{"rounded", 0.5 + 0.4/32768, 16384, nil, 16384},
+		{"one", 1, 0, ErrNormalizeFit, math.MaxInt16},
+		{"below", -1.5, 0, ErrNormalizeFit, math.MinInt16},
+		{"NaN", math.NaN(), 0, ErrNormalizeFit, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNormalize(tt.f)
+			if got != tt.want || err != tt.wantErr {
+				t.Errorf("NewNormalize() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+			if got := SaturateNormalize(tt.f); got != tt.sat {
+				t.Errorf("SaturateNormalize() = %v, want %v", got, tt.sat)
+			}
+			if got := SaturateNormalizeFloat32(float32(tt.f)); got != tt.sat {
+				t.Errorf("SaturateNormalizeFloat32() = %v, want %v", got, tt.sat)
+			}
+		})
+	}
+	for v := math.MinInt16; v <= math.MaxInt16; v++ {
+		if got, err := NewNormalizeFloat32(Normalize(v).Float32()); got != Normalize(v) || err != nil {
+			t.Fatalf("NewNormalizeFloat32(%d.Float32()) = %v, %v", v, got, err)
+		}
+	}
+}
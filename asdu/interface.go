@@ -8,9 +8,22 @@ import (
 	"net"
 )
 
-// Connect interface
+// Connect interface, the peer station the asdu are sent to, over any transport:
+// the cs104 sessions and clients, the cs101 links.
 type Connect interface {
+	// Params the params of the asdu of the connection
 	Params() *Params
+	// Send the asdu to the peer station
 	Send(a *ASDU) error
+	// UnderlyingConn the net.Conn of the connection, nil if none
 	UnderlyingConn() net.Conn
 }
+
+// Station interface, a Connect with its state. The interrogation, command and publication code
+// written against Station runs unchanged over cs101 and cs104.
+type Station interface {
+	Connect
+	// IsConnected whether the asdu sent reach the peer station:
+	// the cs104 data transfer started, the cs101 link reset.
+	IsConnected() bool
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Structured returns the structured notation of the information object address with size octets,
+// the decimal octets most significant first separated by dots, for example 3 octets 0x010203 is "1.2.3".
+// The size 1 or an address not fitting the size is rendered as a plain decimal.
+func (sf InfoObjAddr) Structured(size int) string {
+	if size < 2 || size > 3 || bits.Len(uint(sf)) > size*8 {
+		return strconv.FormatUint(uint64(sf), 10)
+	}
+	octets := make([]string, size)
+	for i := size - 1; i >= 0; i-- {
+		octets[i] = strconv.FormatUint(uint64(sf&0xff), 10)
+		sf >>= 8
+	}
+	return strings.Join(octets, ".")
+}
+
+// ParseInfoObjAddr parse the information object address of size octets in plain decimal,
+// or in the structured notation with exactly size octets, see InfoObjAddr.Structured.
+func ParseInfoObjAddr(s string, size int) (InfoObjAddr, error) {
+	if size < 1 || size > 3 {
+		return 0, ErrParam
+	}
+	octets := strings.Split(s, ".")
+	if len(octets) == 1 {
+		v, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return 0, ErrInfoObjAddrNotation
+		}
+		if bits.Len64(v) > size*8 {
+			return 0, ErrInfoObjAddrFit
+		}
+		return InfoObjAddr(v), nil
+	}
+	if len(octets) != size {
+		return 0, ErrInfoObjAddrNotation
+	}
+	var addr InfoObjAddr
+	for _, o := range octets {
+		v, err := strconv.ParseUint(o, 10, 8)
+		if err != nil {
+			return 0, ErrInfoObjAddrNotation
+		}
+		addr = addr<<8 | InfoObjAddr(v)
+	}
+	return addr, nil
+}
+
+// ValidInfoObjAddr returns the validation result of an information object address.
+func (sf Params) ValidInfoObjAddr(addr InfoObjAddr) error {
+	if bits.Len(uint(addr)) > sf.InfoObjAddrSize*8 {
+		return ErrInfoObjAddrFit
+	}
+	return nil
+}
+
+// FormatInfoObjAddr returns the structured notation of the information object address, see InfoObjAddr.Structured
+func (sf Params) FormatInfoObjAddr(addr InfoObjAddr) string {
+	return addr.Structured(sf.InfoObjAddrSize)
+}
+
+// ParseInfoObjAddr parse the information object address of InfoObjAddrSize octets, see ParseInfoObjAddr
+func (sf Params) ParseInfoObjAddr(s string) (InfoObjAddr, error) {
+	return ParseInfoObjAddr(s, sf.InfoObjAddrSize)
+}
@@ -0,0 +1,68 @@
+package asdu
+
+import (
+	"testing"
+)
+
+func TestInfoObjAddr_Structured(t *testing.T) {
+	tests := []struct {
+		name string
+		addr InfoObjAddr
+		size int
+		want string
+	}{
+		{"1 octet", 200, 1, "200"},
+		{"2 octets", 0x0102, 2, "1.2"},
+		{"3 octets", 0x010203, 3, "1.2.3"},
+		{"3 octets leading zero", 0x0001ff, 3, "0.1.255"},
+		{"not fitting", 0x010203, 2, "66051"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.addr.Structured(tt.size); got != tt.want {
+				t.Errorf("Structured() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInfoObjAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		size    int
+		want    InfoObjAddr
+		wantErr error
+	}{
+		{"decimal", "4097", 3, 4097, nil},
+		{"structured", "1.2.3", 3, 0x010203, nil},
+		{"structured 2 octets", "16.1", 2, 0x1001, nil},
+		{"decimal not fitting", "256", 1, 0, ErrInfoObjAddrFit},
+		{"octet count", "1.2", 3, 0, ErrInfoObjAddrNotation},
+		{"octet range", "1.256.3", 3, 0, ErrInfoObjAddrNotation},
+		{"not a number", "a.b.c", 3, 0, ErrInfoObjAddrNotation},
+		{"invalid size", "1", 4, 0, ErrParam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInfoObjAddr(tt.s, tt.size)
+			if err != tt.wantErr || got != tt.want {
+				t.Errorf("ParseInfoObjAddr() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+			if err == nil {
+				if back, _ := ParseInfoObjAddr(got.Structured(tt.size), tt.size); back != got {
+					t.Errorf("round trip %v, want %v", back, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParams_ValidInfoObjAddr(t *testing.T) {
+	if err := ParamsNarrow.ValidInfoObjAddr(255); err != nil {
+		t.Errorf("ValidInfoObjAddr(255) = %v, want nil", err)
+	}
+	if err := ParamsNarrow.ValidInfoObjAddr(256); err != ErrInfoObjAddrFit {
+		t.Errorf("ValidInfoObjAddr(256) = %v, want %v", err, ErrInfoObjAddrFit)
+	}
+}
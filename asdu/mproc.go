@@ -5,11 +5,30 @@
 package asdu
 
 import (
+	"fmt"
+	"io"
 	"time"
 )
 
 // Application service data unit for process information in the monitoring direction
 
+// autoSequence returns the sequence (SQ = 1) if the n information object addresses ioa(i) are contiguous
+// when Params.AutoSequence is set, otherwise isSequence.
+func autoSequence(c Connect, isSequence bool, n int, ioa func(i int) InfoObjAddr) bool {
+	if !c.Params().AutoSequence {
+		return isSequence
+	}
+	if n < 2 {
+		return false
+	}
+	for i := 1; i < n; i++ {
+		if ioa(i) != ioa(i-1)+1 {
+			return false
+		}
+	}
+	return true
+}
+
 // checkValid check common parameter of request is valid
 func checkValid(c Connect, typeID TypeID, isSequence bool, infosLen int) error {
 	if infosLen == 0 {
@@ -31,8 +50,8 @@ func checkValid(c Connect, typeID TypeID, isSequence bool, infosLen int) error {
 		asduLen = param.IdentifierSize() + infosLen*(objSize+param.InfoObjAddrSize)
 	}
 
-	if asduLen > ASDUSizeMax {
-		return ErrLengthOutOfRange
+	if max := param.MaxASDUSize(); asduLen > max {
+		return fmt.Errorf("%w: %d", ErrLengthOutOfRange, max)
 	}
 	return nil
 }
@@ -46,6 +65,8 @@ type SinglePointInfo struct {
 	Qds QualityDescriptor
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // single sends a type identification [M_SP_NA_1], [M_SP_TA_1] or [M_SP_TB_1].单点信息
@@ -84,9 +105,9 @@ func single(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmission,
 		switch typeID {
 		case M_SP_NA_1:
 		case M_SP_TA_1:
-			u.AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
 		case M_SP_TB_1:
-			u.AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		default:
 			return ErrTypeIDNotMatch
 		}
@@ -108,11 +129,10 @@ func single(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmission,
 // to
 // <36> := Respond to Group 16 Call
 func Single(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...SinglePointInfo) error {
-	if !(coa.Cause == Background || coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_SP_NA_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return single(c, M_SP_NA_1, isSequence, coa, ca, infos...)
 }
 
@@ -125,9 +145,8 @@ func Single(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr,
 // <11> := Return information caused by remote commands
 // <12> := Return messages caused by local commands
 func SingleCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...SinglePointInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal) {
-		return ErrCmdCause
+	if err := checkCause(c, M_SP_TA_1, coa); err != nil {
+		return err
 	}
 	return single(c, M_SP_TA_1, false, coa, ca, infos...)
 }
@@ -141,9 +160,8 @@ func SingleCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos .
 // <11> := Return information caused by remote command
 // <12> := Return information caused by local commands
 func SingleCP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...SinglePointInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal) {
-		return ErrCmdCause
+	if err := checkCause(c, M_SP_TB_1, coa); err != nil {
+		return err
 	}
 	return single(c, M_SP_TB_1, false, coa, ca, infos...)
 }
@@ -156,6 +174,8 @@ type DoublePointInfo struct {
 	Qds QualityDescriptor
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // double sends a type identification [M_DP_NA_1], [M_DP_TA_1] or [M_DP_TB_1]. double point information
@@ -190,9 +210,9 @@ func double(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmission,
 		switch typeID {
 		case M_DP_NA_1:
 		case M_DP_TA_1:
-			u.AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
 		case M_DP_TB_1:
-			u.AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		default:
 			return ErrTypeIDNotMatch
 		}
@@ -214,11 +234,10 @@ func double(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmission,
 // to
 // <36> := Respond to the 16th group call
 func Double(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...DoublePointInfo) error {
-	if !(coa.Cause == Background || coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_DP_NA_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return double(c, M_DP_NA_1, isSequence, coa, ca, infos...)
 }
 
@@ -231,9 +250,8 @@ func Double(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr,
 // <11> := Return information caused by remote command
 // <12> := Return information caused by local commands
 func DoubleCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...DoublePointInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal) {
-		return ErrCmdCause
+	if err := checkCause(c, M_DP_TA_1, coa); err != nil {
+		return err
 	}
 	return double(c, M_DP_TA_1, false, coa, ca, infos...)
 }
@@ -247,9 +265,8 @@ func DoubleCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos .
 // <11> := Return information caused by remote command
 // <12> := Return information caused by local commands
 func DoubleCP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...DoublePointInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal) {
-		return ErrCmdCause
+	if err := checkCause(c, M_DP_TB_1, coa); err != nil {
+		return err
 	}
 	return double(c, M_DP_TB_1, false, coa, ca, infos...)
 }
@@ -262,6 +279,8 @@ type StepPositionInfo struct {
 	Qds QualityDescriptor
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // step sends a type identification [M_ST_NA_1], [M_ST_TA_1] or [M_ST_TB_1]. step location information
@@ -296,9 +315,9 @@ func step(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmission, ca
 		switch typeID {
 		case M_ST_NA_1:
 		case M_ST_TA_1:
-			u.AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
-		case M_SP_TB_1:
-			u.AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
+		case M_ST_TB_1:
+			u.AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		default:
 			return ErrTypeIDNotMatch
 		}
@@ -320,11 +339,10 @@ func step(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmission, ca
 // to
 // <36> := Respond to the 16th group call
 func Step(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...StepPositionInfo) error {
-	if !(coa.Cause == Background || coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ST_NA_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return step(c, M_ST_NA_1, isSequence, coa, ca, infos...)
 }
 
@@ -337,9 +355,8 @@ func Step(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, in
 // <11> := Return information caused by remote command
 // <12> := Return information caused by local commands
 func StepCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...StepPositionInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ST_TA_1, coa); err != nil {
+		return err
 	}
 	return step(c, M_ST_TA_1, false, coa, ca, infos...)
 }
@@ -353,11 +370,10 @@ func StepCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...
 // <11> := Return information caused by remote command
 // <12> := Return information caused by local commands
 func StepCP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...StepPositionInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ST_TB_1, coa); err != nil {
+		return err
 	}
-	return step(c, M_SP_TB_1, false, coa, ca, infos...)
+	return step(c, M_ST_TB_1, false, coa, ca, infos...)
 }
 
 // BitString32Info the measured value attributes.
@@ -368,6 +384,8 @@ type BitString32Info struct {
 	Qds QualityDescriptor
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // bitString32 sends a type identification [M_BO_NA_1], [M_BO_TA_1] or [M_BO_TB_1].比特位串
@@ -402,9 +420,9 @@ func bitString32(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmiss
 		switch typeID {
 		case M_BO_NA_1:
 		case M_BO_TA_1:
-			u.AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
 		case M_BO_TB_1:
-			u.AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		default:
 			return ErrTypeIDNotMatch
 		}
@@ -424,10 +442,10 @@ func bitString32(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmiss
 // to
 // <36> := Respond to the 16th group call
 func BitString32(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...BitString32Info) error {
-	if !(coa.Cause == Background || coa.Cause == Spontaneous || coa.Cause == Request ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_BO_NA_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return bitString32(c, M_BO_NA_1, isSequence, coa, ca, infos...)
 }
 
@@ -438,8 +456,8 @@ func BitString32(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonA
 // <3> := burst (spontaneous)
 // <5> := requested
 func BitString32CP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...BitString32Info) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_BO_TA_1, coa); err != nil {
+		return err
 	}
 	return bitString32(c, M_BO_TA_1, false, coa, ca, infos...)
 }
@@ -451,8 +469,8 @@ func BitString32CP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, in
 // <3> := burst (spontaneous)
 // <5> := requested
 func BitString32CP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...BitString32Info) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_BO_TB_1, coa); err != nil {
+		return err
 	}
 	return bitString32(c, M_BO_TB_1, false, coa, ca, infos...)
 }
@@ -465,6 +483,8 @@ type MeasuredValueNormalInfo struct {
 	Qds QualityDescriptor
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // measuredValueNormal sends a type identification [M_ME_NA_1], [M_ME_TA_1],[ M_ME_TD_1] or [M_ME_ND_1].测量值,规一化值
@@ -500,9 +520,9 @@ func measuredValueNormal(c Connect, typeID TypeID, isSequence bool, coa CauseOfT
 		case M_ME_NA_1:
 			u.AppendBytes(byte(v.Qds))
 		case M_ME_TA_1:
-			u.AppendBytes(byte(v.Qds)).AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendBytes(byte(v.Qds)).AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
 		case M_ME_TD_1:
-			u.AppendBytes(byte(v.Qds)).AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendBytes(byte(v.Qds)).AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		case M_ME_ND_1: // without quality
 		default:
 			return ErrTypeIDNotMatch
@@ -524,11 +544,10 @@ func measuredValueNormal(c Connect, typeID TypeID, isSequence bool, coa CauseOfT
 // to
 // <36> := Respond to the 16th group call
 func MeasuredValueNormal(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueNormalInfo) error {
-	if !(coa.Cause == Periodic || coa.Cause == Background ||
-		coa.Cause == Spontaneous || coa.Cause == Request ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_NA_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return measuredValueNormal(c, M_ME_NA_1, isSequence, coa, ca, infos...)
 }
 
@@ -540,8 +559,8 @@ func MeasuredValueNormal(c Connect, isSequence bool, coa CauseOfTransmission, ca
 // <5> := requested
 func MeasuredValueNormalCP24Time2a(c Connect, coa CauseOfTransmission,
 	ca CommonAddr, infos ...MeasuredValueNormalInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_TA_1, coa); err != nil {
+		return err
 	}
 	return measuredValueNormal(c, M_ME_TA_1, false, coa, ca, infos...)
 }
@@ -553,8 +572,8 @@ func MeasuredValueNormalCP24Time2a(c Connect, coa CauseOfTransmission,
 // <3> := burst (spontaneous)
 // <5> := requested
 func MeasuredValueNormalCP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueNormalInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_TD_1, coa); err != nil {
+		return err
 	}
 	return measuredValueNormal(c, M_ME_TD_1, false, coa, ca, infos...)
 }
@@ -573,11 +592,10 @@ func MeasuredValueNormalCP56Time2a(c Connect, coa CauseOfTransmission, ca Common
 // to
 // <36> := Respond to the 16th group call
 func MeasuredValueNormalNoQuality(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueNormalInfo) error {
-	if !(coa.Cause == Periodic || coa.Cause == Background ||
-		coa.Cause == Spontaneous || coa.Cause == Request ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_ND_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return measuredValueNormal(c, M_ME_ND_1, isSequence, coa, ca, infos...)
 }
 
@@ -589,6 +607,8 @@ type MeasuredValueScaledInfo struct {
 	Qds QualityDescriptor
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // measuredValueScaled sends a type identification [M_ME_NB_1], [M_ME_TB_1] or [M_ME_TE_1]. measured value, scaled value
@@ -622,9 +642,9 @@ func measuredValueScaled(c Connect, typeID TypeID, isSequence bool, coa CauseOfT
 		switch typeID {
 		case M_ME_NB_1:
 		case M_ME_TB_1:
-			u.AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
 		case M_ME_TE_1:
-			u.AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		default:
 			return ErrTypeIDNotMatch
 		}
@@ -645,11 +665,10 @@ func measuredValueScaled(c Connect, typeID TypeID, isSequence bool, coa CauseOfT
 // to
 // <36> := Respond to the 16th group call
 func MeasuredValueScaled(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueScaledInfo) error {
-	if !(coa.Cause == Periodic || coa.Cause == Background ||
-		coa.Cause == Spontaneous || coa.Cause == Request ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_NB_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return measuredValueScaled(c, M_ME_NB_1, isSequence, coa, ca, infos...)
 }
 
@@ -660,8 +679,8 @@ func MeasuredValueScaled(c Connect, isSequence bool, coa CauseOfTransmission, ca
 // <3> := burst (spontaneous)
 // <5> := requested
 func MeasuredValueScaledCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueScaledInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_TB_1, coa); err != nil {
+		return err
 	}
 	return measuredValueScaled(c, M_ME_TB_1, false, coa, ca, infos...)
 }
@@ -673,8 +692,8 @@ func MeasuredValueScaledCP24Time2a(c Connect, coa CauseOfTransmission, ca Common
 // <3> := burst (spontaneous)
 // <5> := requested
 func MeasuredValueScaledCP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueScaledInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_TE_1, coa); err != nil {
+		return err
 	}
 	return measuredValueScaled(c, M_ME_TE_1, false, coa, ca, infos...)
 }
@@ -687,6 +706,8 @@ type MeasuredValueFloatInfo struct {
 	Qds QualityDescriptor
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // measuredValueFloat sends a type identification [M_ME_NC_1], [M_ME_TC_1] or [M_ME_TF_1]. measured value, short float
@@ -721,9 +742,9 @@ func measuredValueFloat(c Connect, typeID TypeID, isSequence bool, coa CauseOfTr
 		switch typeID {
 		case M_ME_NC_1:
 		case M_ME_TC_1:
-			u.AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
 		case M_ME_TF_1:
-			u.AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		default:
 			return ErrTypeIDNotMatch
 		}
@@ -744,11 +765,10 @@ func measuredValueFloat(c Connect, typeID TypeID, isSequence bool, coa CauseOfTr
 // to
 // <36> := Respond to the 16th group call
 func MeasuredValueFloat(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueFloatInfo) error {
-	if !(coa.Cause == Periodic || coa.Cause == Background ||
-		coa.Cause == Spontaneous || coa.Cause == Request ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_NC_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return measuredValueFloat(c, M_ME_NC_1, isSequence, coa, ca, infos...)
 }
 
@@ -759,8 +779,8 @@ func MeasuredValueFloat(c Connect, isSequence bool, coa CauseOfTransmission, ca
 // <3> := burst (spontaneous)
 // <5> := requested
 func MeasuredValueFloatCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueFloatInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_TC_1, coa); err != nil {
+		return err
 	}
 	return measuredValueFloat(c, M_ME_TC_1, false, coa, ca, infos...)
 }
@@ -772,8 +792,8 @@ func MeasuredValueFloatCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonA
 // <3> := burst (spontaneous)
 // <5> := requested
 func MeasuredValueFloatCP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...MeasuredValueFloatInfo) error {
-	if !(coa.Cause == Spontaneous || coa.Cause == Request) {
-		return ErrCmdCause
+	if err := checkCause(c, M_ME_TF_1, coa); err != nil {
+		return err
 	}
 	return measuredValueFloat(c, M_ME_TF_1, false, coa, ca, infos...)
 }
@@ -784,6 +804,8 @@ type BinaryCounterReadingInfo struct {
 	Value BinaryCounterReading
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // integratedTotals sends a type identification [M_IT_NA_1], [M_IT_TA_1] or [M_IT_TB_1]. Cumulative amount
@@ -817,9 +839,9 @@ func integratedTotals(c Connect, typeID TypeID, isSequence bool, coa CauseOfTran
 		switch typeID {
 		case M_IT_NA_1:
 		case M_IT_TA_1:
-			u.AppendBytes(CP24Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP24Time2a(v.Time, u.InfoObjTimeZone)
 		case M_IT_TB_1:
-			u.AppendBytes(CP56Time2a(v.Time, u.InfoObjTimeZone)...)
+			u.AppendCP56Time2a(v.Time, u.InfoObjTimeZone)
 		default:
 			return ErrTypeIDNotMatch
 		}
@@ -838,9 +860,10 @@ func integratedTotals(c Connect, typeID TypeID, isSequence bool, coa CauseOfTran
 // <40> := Respond to the 3rd group count call
 // <41> := Respond to the 4th group count call
 func IntegratedTotals(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...BinaryCounterReadingInfo) error {
-	if !(coa.Cause == Spontaneous || (coa.Cause >= RequestByGeneralCounter && coa.Cause <= RequestByGroup4Counter)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_IT_NA_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	return integratedTotals(c, M_IT_NA_1, isSequence, coa, ca, infos...)
 }
 
@@ -855,8 +878,8 @@ func IntegratedTotals(c Connect, isSequence bool, coa CauseOfTransmission, ca Co
 // <40> := Respond to the 3rd group count call
 // <41> := Respond to the 4th group count call
 func IntegratedTotalsCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...BinaryCounterReadingInfo) error {
-	if !(coa.Cause == Spontaneous || (coa.Cause >= RequestByGeneralCounter && coa.Cause <= RequestByGroup4Counter)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_IT_TA_1, coa); err != nil {
+		return err
 	}
 	return integratedTotals(c, M_IT_TA_1, false, coa, ca, infos...)
 }
@@ -872,8 +895,8 @@ func IntegratedTotalsCP24Time2a(c Connect, coa CauseOfTransmission, ca CommonAdd
 // <40> := Respond to the 3rd group count call
 // <41> := Respond to the 4th group count call
 func IntegratedTotalsCP56Time2a(c Connect, coa CauseOfTransmission, ca CommonAddr, infos ...BinaryCounterReadingInfo) error {
-	if !(coa.Cause == Spontaneous || (coa.Cause >= RequestByGeneralCounter && coa.Cause <= RequestByGroup4Counter)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_IT_TB_1, coa); err != nil {
+		return err
 	}
 	return integratedTotals(c, M_IT_TB_1, false, coa, ca, infos...)
 }
@@ -886,14 +909,16 @@ type EventOfProtectionEquipmentInfo struct {
 	Msec  uint16
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // eventOfProtectionEquipment sends a type identification [M_EP_TA_1], [M_EP_TD_1]. Relay Protection Device Events
 // [M_EP_TA_1] See companion standard 101, subclass 7.3.1.17
 // [M_EP_TD_1] See companion standard 101, subclass 7.3.1.30
 func eventOfProtectionEquipment(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, infos ...EventOfProtectionEquipmentInfo) error {
-	if coa.Cause != Spontaneous {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := checkValid(c, typeID, false, len(infos)); err != nil {
 		return err
@@ -953,14 +978,16 @@ type PackedStartEventsOfProtectionEquipmentInfo struct {
 	Msec  uint16
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // packedStartEventsOfProtectionEquipment sends a type identification [M_EP_TB_1], [M_EP_TE_1]. Relay Protection Device Events
 // [M_EP_TB_1] See companion standard 101, subclass 7.3.1.18
 // [M_EP_TE_1] See companion standard 101, subclass 7.3.1.31
 func packedStartEventsOfProtectionEquipment(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, info PackedStartEventsOfProtectionEquipmentInfo) error {
-	if coa.Cause != Spontaneous {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := checkValid(c, typeID, false, 1); err != nil {
 		return err
@@ -1017,14 +1044,16 @@ type PackedOutputCircuitInfoInfo struct {
 	Msec uint16
 	// the type does not include timing will ignore
 	Time time.Time
+	// the invalid flag IV of the time tag as received whatever the TimeTagPolicy, the senders ignore it
+	TimeInvalid bool
 }
 
 // packedOutputCircuitInfo sends a type identification [M_EP_TC_1], [M_EP_TF_1]. Relay protection equipment outputs circuit information in groups
 // [M_EP_TC_1] See companion standard 101, subclass 7.3.1.19
 // [M_EP_TF_1] See companion standard 101, subclass 7.3.1.32
 func packedOutputCircuitInfo(c Connect, typeID TypeID, coa CauseOfTransmission, ca CommonAddr, info PackedOutputCircuitInfoInfo) error {
-	if coa.Cause != Spontaneous {
-		return ErrCmdCause
+	if err := checkCause(c, typeID, coa); err != nil {
+		return err
 	}
 	if err := checkValid(c, typeID, false, 1); err != nil {
 		return err
@@ -1094,11 +1123,10 @@ type PackedSinglePointWithSCDInfo struct {
 // to
 // <36> := Respond to the 16th group call
 func PackedSinglePointWithSCD(c Connect, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...PackedSinglePointWithSCDInfo) error {
-	if !(coa.Cause == Background || coa.Cause == Spontaneous || coa.Cause == Request ||
-		coa.Cause == ReturnInfoRemote || coa.Cause == ReturnInfoLocal ||
-		(coa.Cause >= InterrogatedByStation && coa.Cause <= InterrogatedByGroup16)) {
-		return ErrCmdCause
+	if err := checkCause(c, M_PS_NA_1, coa); err != nil {
+		return err
 	}
+	isSequence = autoSequence(c, isSequence, len(infos), func(i int) InfoObjAddr { return infos[i].Ioa })
 	if err := checkValid(c, M_PS_NA_1, isSequence, len(infos)); err != nil {
 		return err
 	}
@@ -1141,21 +1169,23 @@ func (sf *ASDU) GetSinglePoint() []SinglePointInfo {
 		value := sf.DecodeByte()
 
 		var t time.Time
+		var iv bool
 		switch sf.Type {
 		case M_SP_NA_1:
 		case M_SP_TA_1:
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_SP_TB_1:
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 
 		info = append(info, SinglePointInfo{
-			Ioa:   infoObjAddr,
-			Value: value&0x01 == 0x01,
-			Qds:   QualityDescriptor(value & 0xf0),
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       value&0x01 == 0x01,
+			Qds:         QualityDescriptor(value & 0xf0),
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
@@ -1174,27 +1204,63 @@ func (sf *ASDU) GetDoublePoint() []DoublePointInfo {
 		value := sf.DecodeByte()
 
 		var t time.Time
+		var iv bool
 		switch sf.Type {
 		case M_DP_NA_1:
 		case M_DP_TA_1:
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_DP_TB_1:
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 
 		info = append(info, DoublePointInfo{
-			Ioa:   infoObjAddr,
-			Value: DoublePoint(value & 0x03),
-			Qds:   QualityDescriptor(value & 0xf0),
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       DoublePoint(value & 0x03),
+			Qds:         QualityDescriptor(value & 0xf0),
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
 
-// GetStepPosition [M_ST_NA_1], [M_ST_TA_1] or [M_ST_TB_1] Obtain the set of step position information
+// GetStepPosition [M_ST_NA_1], [M_ST_TA_1] or [M_ST_TB_1] Obtain the set of step position information,
+// it panics if the asdu is not a step position information or is truncated, see DecodeStepPosition
 func (sf *ASDU) GetStepPosition() []StepPositionInfo {
+	info, err := sf.DecodeStepPosition()
+	if err != nil {
+		panic(err)
+	}
+	return info
+}
+
+// DecodeStepPosition [M_ST_NA_1], [M_ST_TA_1] or [M_ST_TB_1] Obtain the set of step position information,
+// ErrTypeIDNotMatch for another type identification, io.ErrUnexpectedEOF if the information objects are
+// truncated, the asdu is left unchanged on error.
+func (sf *ASDU) DecodeStepPosition() ([]StepPositionInfo, error) {
+	var tagSize int
+	switch sf.Type {
+	case M_ST_NA_1:
+	case M_ST_TA_1:
+		tagSize = 3
+	case M_ST_TB_1:
+		tagSize = 7
+	default:
+		return nil, ErrTypeIDNotMatch
+	}
+	if sf.InfoObjAddrSize < 1 || sf.InfoObjAddrSize > 3 {
+		return nil, ErrParam
+	}
+	objSize := 2 + tagSize
+	size := int(sf.Variable.Number) * (sf.InfoObjAddrSize + objSize)
+	if sf.Variable.IsSequence && sf.Variable.Number > 0 {
+		size = sf.InfoObjAddrSize + int(sf.Variable.Number)*objSize
+	}
+	if len(sf.infoObj) < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+
 	info := make([]StepPositionInfo, 0, sf.Variable.Number)
 	infoObjAddr := InfoObjAddr(0)
 	for i, once := 0, false; i < int(sf.Variable.Number); i++ {
@@ -1208,23 +1274,18 @@ func (sf *ASDU) GetStepPosition() []StepPositionInfo {
 		qds := QualityDescriptor(sf.DecodeByte())
 
 		var t time.Time
-		switch sf.Type {
-		case M_ST_NA_1:
-		case M_ST_TA_1:
-			t = sf.DecodeCP24Time2a()
-		case M_ST_TB_1:
-			t = sf.DecodeCP56Time2a()
-		default:
-			panic(ErrTypeIDNotMatch)
+		var iv bool
+		if tagSize > 0 {
+			t, iv = sf.decodeTimeTag(tagSize)
 		}
-
 		info = append(info, StepPositionInfo{
-			Ioa:   infoObjAddr,
-			Value: value,
-			Qds:   qds,
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       value,
+			Qds:         qds,
+			Time:        t,
+			TimeInvalid: iv})
 	}
-	return info
+	return info, nil
 }
 
 // GetBitString32 [M_BO_NA_1], [M_BO_TA_1] or [M_BO_TB_1] Obtain the set of bit string information bodies
@@ -1243,21 +1304,23 @@ func (sf *ASDU) GetBitString32() []BitString32Info {
 		qds := QualityDescriptor(sf.DecodeByte())
 
 		var t time.Time
+		var iv bool
 		switch sf.Type {
 		case M_BO_NA_1:
 		case M_BO_TA_1:
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_BO_TB_1:
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 
 		info = append(info, BitString32Info{
-			Ioa:   infoObjAddr,
-			Value: value,
-			Qds:   qds,
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       value,
+			Qds:         qds,
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
@@ -1277,26 +1340,28 @@ func (sf *ASDU) GetMeasuredValueNormal() []MeasuredValueNormalInfo {
 		value := sf.DecodeNormalize()
 
 		var t time.Time
+		var iv bool
 		var qds QualityDescriptor
 		switch sf.Type {
 		case M_ME_NA_1:
 			qds = QualityDescriptor(sf.DecodeByte())
 		case M_ME_TA_1:
 			qds = QualityDescriptor(sf.DecodeByte())
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_ME_TD_1:
 			qds = QualityDescriptor(sf.DecodeByte())
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		case M_ME_ND_1: // 不带品质
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 
 		info = append(info, MeasuredValueNormalInfo{
-			Ioa:   infoObjAddr,
-			Value: value,
-			Qds:   qds,
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       value,
+			Qds:         qds,
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
@@ -1317,21 +1382,23 @@ func (sf *ASDU) GetMeasuredValueScaled() []MeasuredValueScaledInfo {
 		qds := QualityDescriptor(sf.DecodeByte())
 
 		var t time.Time
+		var iv bool
 		switch sf.Type {
 		case M_ME_NB_1:
 		case M_ME_TB_1:
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_ME_TE_1:
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 
 		info = append(info, MeasuredValueScaledInfo{
-			Ioa:   infoObjAddr,
-			Value: value,
-			Qds:   qds,
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       value,
+			Qds:         qds,
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
@@ -1352,20 +1419,22 @@ func (sf *ASDU) GetMeasuredValueFloat() []MeasuredValueFloatInfo {
 		qua := sf.DecodeByte() & 0xf1
 
 		var t time.Time
+		var iv bool
 		switch sf.Type {
 		case M_ME_NC_1:
 		case M_ME_TC_1:
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_ME_TF_1:
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 		info = append(info, MeasuredValueFloatInfo{
-			Ioa:   infoObjAddr,
-			Value: value,
-			Qds:   QualityDescriptor(qua),
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       value,
+			Qds:         QualityDescriptor(qua),
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
@@ -1385,19 +1454,21 @@ func (sf *ASDU) GetIntegratedTotals() []BinaryCounterReadingInfo {
 		value := sf.DecodeBinaryCounterReading()
 
 		var t time.Time
+		var iv bool
 		switch sf.Type {
 		case M_IT_NA_1:
 		case M_IT_TA_1:
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_IT_TB_1:
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 		info = append(info, BinaryCounterReadingInfo{
-			Ioa:   infoObjAddr,
-			Value: value,
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Value:       value,
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
@@ -1417,20 +1488,22 @@ func (sf *ASDU) GetEventOfProtectionEquipment() []EventOfProtectionEquipmentInfo
 		value := sf.DecodeByte()
 		msec := sf.DecodeCP16Time2a()
 		var t time.Time
+		var iv bool
 		switch sf.Type {
 		case M_EP_TA_1:
-			t = sf.DecodeCP24Time2a()
+			t, iv = sf.decodeTimeTag(3)
 		case M_EP_TD_1:
-			t = sf.DecodeCP56Time2a()
+			t, iv = sf.decodeTimeTag(7)
 		default:
 			panic(ErrTypeIDNotMatch)
 		}
 		info = append(info, EventOfProtectionEquipmentInfo{
-			Ioa:   infoObjAddr,
-			Event: SingleEvent(value & 0x03),
-			Qdp:   QualityDescriptorProtection(value & 0xf1),
-			Msec:  msec,
-			Time:  t})
+			Ioa:         infoObjAddr,
+			Event:       SingleEvent(value & 0x03),
+			Qdp:         QualityDescriptorProtection(value & 0xf1),
+			Msec:        msec,
+			Time:        t,
+			TimeInvalid: iv})
 	}
 	return info
 }
@@ -1449,9 +1522,9 @@ func (sf *ASDU) GetPackedStartEventsOfProtectionEquipment() PackedStartEventsOfP
 	info.Msec = sf.DecodeCP16Time2a()
 	switch sf.Type {
 	case M_EP_TB_1:
-		info.Time = sf.DecodeCP24Time2a()
+		info.Time, info.TimeInvalid = sf.decodeTimeTag(3)
 	case M_EP_TE_1:
-		info.Time = sf.DecodeCP56Time2a()
+		info.Time, info.TimeInvalid = sf.decodeTimeTag(7)
 	default:
 		panic(ErrTypeIDNotMatch)
 	}
@@ -1472,9 +1545,9 @@ func (sf *ASDU) GetPackedOutputCircuitInfo() PackedOutputCircuitInfoInfo {
 	info.Msec = sf.DecodeCP16Time2a()
 	switch sf.Type {
 	case M_EP_TC_1:
-		info.Time = sf.DecodeCP24Time2a()
+		info.Time, info.TimeInvalid = sf.decodeTimeTag(3)
 	case M_EP_TF_1:
-		info.Time = sf.DecodeCP56Time2a()
+		info.Time, info.TimeInvalid = sf.decodeTimeTag(7)
 	default:
 		panic(ErrTypeIDNotMatch)
 	}
@@ -1,6 +1,7 @@
 package asdu
 
 import (
+	"io"
 	"math"
 	"reflect"
 	"testing"
@@ -87,8 +88,8 @@ func TestSingle(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]SinglePointInfo{
-					{0x000001, true, QDSBlocked, time.Time{}},
-					{0x000002, false, QDSBlocked, time.Time{}},
+					{0x000001, true, QDSBlocked, time.Time{}, false},
+					{0x000002, false, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -101,8 +102,8 @@ func TestSingle(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]SinglePointInfo{
-					{0x000001, true, QDSBlocked, time.Time{}},
-					{0x000002, false, QDSBlocked, time.Time{}},
+					{0x000001, true, QDSBlocked, time.Time{}, false},
+					{0x000002, false, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -146,8 +147,8 @@ func TestSingleCP24Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]SinglePointInfo{
-					{0x000001, true, QDSBlocked, tm0},
-					{0x000002, false, QDSBlocked, tm0},
+					{0x000001, true, QDSBlocked, tm0, false},
+					{0x000002, false, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -191,8 +192,8 @@ func TestSingleCP56Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]SinglePointInfo{
-					{0x000001, true, QDSBlocked, tm0},
-					{0x000002, false, QDSBlocked, tm0},
+					{0x000001, true, QDSBlocked, tm0, false},
+					{0x000002, false, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -263,8 +264,8 @@ func TestDouble(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]DoublePointInfo{
-					{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}},
-					{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}},
+					{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}, false},
+					{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -277,8 +278,8 @@ func TestDouble(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]DoublePointInfo{
-					{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}},
-					{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}},
+					{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}, false},
+					{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -322,8 +323,8 @@ func TestDoubleCP24Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]DoublePointInfo{
-					{0x000001, DPIDeterminedOn, QDSBlocked, tm0},
-					{0x000002, DPIDeterminedOff, QDSBlocked, tm0},
+					{0x000001, DPIDeterminedOn, QDSBlocked, tm0, false},
+					{0x000002, DPIDeterminedOff, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -367,8 +368,8 @@ func TestDoubleCP56Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]DoublePointInfo{
-					{0x000001, DPIDeterminedOn, QDSBlocked, tm0},
-					{0x000002, DPIDeterminedOff, QDSBlocked, tm0},
+					{0x000001, DPIDeterminedOn, QDSBlocked, tm0, false},
+					{0x000002, DPIDeterminedOff, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -396,7 +397,17 @@ func Test_step(t *testing.T) {
 		args    args
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			"M_SP_TB_1 not a step position",
+			args{
+				newConn(nil, t),
+				M_SP_TB_1,
+				false,
+				CauseOfTransmission{Cause: Spontaneous},
+				0x1234,
+				[]StepPositionInfo{{Ioa: 0x000001}}},
+			true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -439,8 +450,8 @@ func TestStep(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]StepPositionInfo{
-					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}},
-					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}},
+					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}, false},
+					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -453,8 +464,8 @@ func TestStep(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]StepPositionInfo{
-					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}},
-					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}},
+					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}, false},
+					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -498,8 +509,8 @@ func TestStepCP24Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]StepPositionInfo{
-					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0},
-					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0},
+					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0, false},
+					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -535,16 +546,16 @@ func TestStepCP56Time2a(t *testing.T) {
 			true,
 		},
 		{
-			"M_SP_TB_1 CP56Time2a Number = 2",
+			"M_ST_TB_1 CP56Time2a Number = 2",
 			args{
-				newConn(append(append([]byte{byte(M_SP_TB_1), 0x02, 0x03, 0x00, 0x34, 0x12},
+				newConn(append(append([]byte{byte(M_ST_TB_1), 0x02, 0x03, 0x00, 0x34, 0x12},
 					append([]byte{0x01, 0x00, 0x00, 0x01, 0x10}, tm0CP56Time2aBytes...)...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x10}, tm0CP56Time2aBytes...)...), t),
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]StepPositionInfo{
-					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0},
-					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0},
+					{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0, false},
+					{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -615,8 +626,8 @@ func TestBitString32(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]BitString32Info{
-					{0x000001, 1, QDSBlocked, time.Time{}},
-					{0x000002, 2, QDSBlocked, time.Time{}},
+					{0x000001, 1, QDSBlocked, time.Time{}, false},
+					{0x000002, 2, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -629,8 +640,8 @@ func TestBitString32(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]BitString32Info{
-					{0x000001, 1, QDSBlocked, time.Time{}},
-					{0x000002, 2, QDSBlocked, time.Time{}},
+					{0x000001, 1, QDSBlocked, time.Time{}, false},
+					{0x000002, 2, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -674,8 +685,8 @@ func TestBitString32CP24Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]BitString32Info{
-					{0x000001, 1, QDSBlocked, tm0},
-					{0x000002, 2, QDSBlocked, tm0},
+					{0x000001, 1, QDSBlocked, tm0, false},
+					{0x000002, 2, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -719,8 +730,8 @@ func TestBitString32CP56Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]BitString32Info{
-					{0x000001, 1, QDSBlocked, tm0},
-					{0x000002, 2, QDSBlocked, tm0},
+					{0x000001, 1, QDSBlocked, tm0, false},
+					{0x000002, 2, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -791,8 +802,8 @@ func TestMeasuredValueNormal(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueNormalInfo{
-					{0x000001, 1, QDSBlocked, time.Time{}},
-					{0x000002, 2, QDSBlocked, time.Time{}},
+					{0x000001, 1, QDSBlocked, time.Time{}, false},
+					{0x000002, 2, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -805,8 +816,8 @@ func TestMeasuredValueNormal(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueNormalInfo{
-					{0x000001, 1, QDSBlocked, time.Time{}},
-					{0x000002, 2, QDSBlocked, time.Time{}},
+					{0x000001, 1, QDSBlocked, time.Time{}, false},
+					{0x000002, 2, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -850,8 +861,8 @@ func TestMeasuredValueNormalCP24Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]MeasuredValueNormalInfo{
-					{0x000001, 1, QDSBlocked, tm0},
-					{0x000002, 2, QDSBlocked, tm0},
+					{0x000001, 1, QDSBlocked, tm0, false},
+					{0x000002, 2, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -895,8 +906,8 @@ func TestMeasuredValueNormalCP56Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]MeasuredValueNormalInfo{
-					{0x000001, 1, QDSBlocked, tm0},
-					{0x000002, 2, QDSBlocked, tm0},
+					{0x000001, 1, QDSBlocked, tm0, false},
+					{0x000002, 2, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -942,8 +953,8 @@ func TestMeasuredValueNormalNoQuality(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueNormalInfo{
-					{0x000001, 1, QDSGood, time.Time{}},
-					{0x000002, 2, QDSGood, time.Time{}},
+					{0x000001, 1, QDSGood, time.Time{}, false},
+					{0x000002, 2, QDSGood, time.Time{}, false},
 				}},
 			false,
 		},
@@ -956,8 +967,8 @@ func TestMeasuredValueNormalNoQuality(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueNormalInfo{
-					{0x000001, 1, QDSGood, time.Time{}},
-					{0x000002, 2, QDSGood, time.Time{}},
+					{0x000001, 1, QDSGood, time.Time{}, false},
+					{0x000002, 2, QDSGood, time.Time{}, false},
 				}},
 			false,
 		},
@@ -1028,8 +1039,8 @@ func TestMeasuredValueScaled(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueScaledInfo{
-					{0x000001, 1, QDSBlocked, time.Time{}},
-					{0x000002, 2, QDSBlocked, time.Time{}},
+					{0x000001, 1, QDSBlocked, time.Time{}, false},
+					{0x000002, 2, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -1042,8 +1053,8 @@ func TestMeasuredValueScaled(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueScaledInfo{
-					{0x000001, 1, QDSBlocked, time.Time{}},
-					{0x000002, 2, QDSBlocked, time.Time{}},
+					{0x000001, 1, QDSBlocked, time.Time{}, false},
+					{0x000002, 2, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -1087,8 +1098,8 @@ func TestMeasuredValueScaledCP24Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]MeasuredValueScaledInfo{
-					{0x000001, 1, QDSBlocked, tm0},
-					{0x000002, 2, QDSBlocked, tm0},
+					{0x000001, 1, QDSBlocked, tm0, false},
+					{0x000002, 2, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -1132,8 +1143,8 @@ func TestMeasuredValueScaledCP56Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]MeasuredValueScaledInfo{
-					{0x000001, 1, QDSBlocked, tm0},
-					{0x000002, 2, QDSBlocked, tm0},
+					{0x000001, 1, QDSBlocked, tm0, false},
+					{0x000002, 2, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -1208,8 +1219,8 @@ func TestMeasuredValueFloat(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueFloatInfo{
-					{0x000001, 100, QDSBlocked, time.Time{}},
-					{0x000002, 101, QDSBlocked, time.Time{}},
+					{0x000001, 100, QDSBlocked, time.Time{}, false},
+					{0x000002, 101, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -1223,8 +1234,8 @@ func TestMeasuredValueFloat(t *testing.T) {
 				CauseOfTransmission{Cause: Background},
 				0x1234,
 				[]MeasuredValueFloatInfo{
-					{0x000001, 100, QDSBlocked, time.Time{}},
-					{0x000002, 101, QDSBlocked, time.Time{}},
+					{0x000001, 100, QDSBlocked, time.Time{}, false},
+					{0x000002, 101, QDSBlocked, time.Time{}, false},
 				}},
 			false,
 		},
@@ -1271,8 +1282,8 @@ func TestMeasuredValueFloatCP24Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]MeasuredValueFloatInfo{
-					{0x000001, 100, QDSBlocked, tm0},
-					{0x000002, 101, QDSBlocked, tm0},
+					{0x000001, 100, QDSBlocked, tm0, false},
+					{0x000002, 101, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -1318,8 +1329,8 @@ func TestMeasuredValueFloatCP56Time2a(t *testing.T) {
 				CauseOfTransmission{Cause: Spontaneous},
 				0x1234,
 				[]MeasuredValueFloatInfo{
-					{0x000001, 100, QDSBlocked, tm0},
-					{0x000002, 101, QDSBlocked, tm0},
+					{0x000001, 100, QDSBlocked, tm0, false},
+					{0x000002, 101, QDSBlocked, tm0, false},
 				}},
 			false,
 		},
@@ -1353,8 +1364,8 @@ func TestASDU_GetSinglePoint(t *testing.T) {
 					Variable: VariableStruct{IsSequence: false, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x11, 0x02, 0x00, 0x00, 0x10}},
 			[]SinglePointInfo{
-				{0x000001, true, QDSBlocked, time.Time{}},
-				{0x000002, false, QDSBlocked, time.Time{}}},
+				{0x000001, true, QDSBlocked, time.Time{}, false},
+				{0x000002, false, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_SP_NA_1 seq = true Number = 2",
@@ -1365,8 +1376,8 @@ func TestASDU_GetSinglePoint(t *testing.T) {
 					Variable: VariableStruct{IsSequence: true, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x11, 0x10}},
 			[]SinglePointInfo{
-				{0x000001, true, QDSBlocked, time.Time{}},
-				{0x000002, false, QDSBlocked, time.Time{}}},
+				{0x000001, true, QDSBlocked, time.Time{}, false},
+				{0x000002, false, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_SP_TB_1 CP56Time2a  Number = 2",
@@ -1378,8 +1389,8 @@ func TestASDU_GetSinglePoint(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x11}, tm0CP56Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x10}, tm0CP56Time2aBytes...)...)},
 			[]SinglePointInfo{
-				{0x000001, true, QDSBlocked, tm0},
-				{0x000002, false, QDSBlocked, tm0}},
+				{0x000001, true, QDSBlocked, tm0, false},
+				{0x000002, false, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1418,8 +1429,8 @@ func TestASDU_GetSinglePointCP24Time2a(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x11}, tm0CP24Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x10}, tm0CP24Time2aBytes...)...)},
 			[]SinglePointInfo{
-				{0x000001, true, QDSBlocked, tm0},
-				{0x000002, false, QDSBlocked, tm0}},
+				{0x000001, true, QDSBlocked, tm0, false},
+				{0x000002, false, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1475,8 +1486,8 @@ func TestASDU_GetDoublePoint(t *testing.T) {
 					Variable: VariableStruct{IsSequence: false, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x12, 0x02, 0x00, 0x00, 0x11}},
 			[]DoublePointInfo{
-				{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}},
-				{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}}},
+				{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}, false},
+				{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_DP_NA_1 seq = true Number = 2",
@@ -1487,8 +1498,8 @@ func TestASDU_GetDoublePoint(t *testing.T) {
 					Variable: VariableStruct{IsSequence: true, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x12, 0x11}},
 			[]DoublePointInfo{
-				{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}},
-				{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}}},
+				{0x000001, DPIDeterminedOn, QDSBlocked, time.Time{}, false},
+				{0x000002, DPIDeterminedOff, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_DP_TB_1 CP56Time2a  Number = 2",
@@ -1500,8 +1511,8 @@ func TestASDU_GetDoublePoint(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x12}, tm0CP56Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x11}, tm0CP56Time2aBytes...)...)},
 			[]DoublePointInfo{
-				{0x000001, DPIDeterminedOn, QDSBlocked, tm0},
-				{0x000002, DPIDeterminedOff, QDSBlocked, tm0}},
+				{0x000001, DPIDeterminedOn, QDSBlocked, tm0, false},
+				{0x000002, DPIDeterminedOff, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1540,8 +1551,8 @@ func TestASDU_GetDoublePointCP24Time2a(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x12}, tm0CP24Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x11}, tm0CP24Time2aBytes...)...)},
 			[]DoublePointInfo{
-				{0x000001, DPIDeterminedOn, QDSBlocked, tm0},
-				{0x000002, DPIDeterminedOff, QDSBlocked, tm0}},
+				{0x000001, DPIDeterminedOn, QDSBlocked, tm0, false},
+				{0x000002, DPIDeterminedOff, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1597,8 +1608,8 @@ func TestASDU_GetStepPosition(t *testing.T) {
 					Variable: VariableStruct{IsSequence: false, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x10, 0x02, 0x00, 0x00, 0x02, 0x10}},
 			[]StepPositionInfo{
-				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}},
-				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}}},
+				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}, false},
+				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ST_NA_1 seq = true Number = 2",
@@ -1609,8 +1620,8 @@ func TestASDU_GetStepPosition(t *testing.T) {
 					Variable: VariableStruct{IsSequence: true, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x10, 0x02, 0x10}},
 			[]StepPositionInfo{
-				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}},
-				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}}},
+				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, time.Time{}, false},
+				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ST_TB_1 CP56Time2a  Number = 2",
@@ -1622,8 +1633,8 @@ func TestASDU_GetStepPosition(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x10}, tm0CP56Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x10}, tm0CP56Time2aBytes...)...)},
 			[]StepPositionInfo{
-				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0},
-				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0}},
+				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0, false},
+				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1662,8 +1673,8 @@ func TestASDU_GetStepPositionCP24Time2a(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x10}, tm0CP24Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x10}, tm0CP24Time2aBytes...)...)},
 			[]StepPositionInfo{
-				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0},
-				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0}},
+				{0x000001, StepPosition{Val: 0x01}, QDSBlocked, tm0, false},
+				{0x000002, StepPosition{Val: 0x02}, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1719,8 +1730,8 @@ func TestASDU_GetBitString32(t *testing.T) {
 					Variable: VariableStruct{IsSequence: false, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10, 0x02, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x10}},
 			[]BitString32Info{
-				{0x000001, 1, QDSBlocked, time.Time{}},
-				{0x000002, 2, QDSBlocked, time.Time{}}},
+				{0x000001, 1, QDSBlocked, time.Time{}, false},
+				{0x000002, 2, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_BO_NA_1 seq = true Number = 2",
@@ -1731,8 +1742,8 @@ func TestASDU_GetBitString32(t *testing.T) {
 					Variable: VariableStruct{IsSequence: true, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10, 0x02, 0x00, 0x00, 0x00, 0x10}},
 			[]BitString32Info{
-				{0x000001, 1, QDSBlocked, time.Time{}},
-				{0x000002, 2, QDSBlocked, time.Time{}}},
+				{0x000001, 1, QDSBlocked, time.Time{}, false},
+				{0x000002, 2, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_BO_TB_1 CP56Time2a  Number = 2",
@@ -1744,8 +1755,8 @@ func TestASDU_GetBitString32(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10}, tm0CP56Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x10}, tm0CP56Time2aBytes...)...)},
 			[]BitString32Info{
-				{0x000001, 1, QDSBlocked, tm0},
-				{0x000002, 2, QDSBlocked, tm0}},
+				{0x000001, 1, QDSBlocked, tm0, false},
+				{0x000002, 2, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1783,8 +1794,8 @@ func TestASDU_GetBitString32CP24Time2a(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10}, tm0CP24Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x10}, tm0CP24Time2aBytes...)...)},
 			[]BitString32Info{
-				{0x000001, 1, QDSBlocked, tm0},
-				{0x000002, 2, QDSBlocked, tm0}},
+				{0x000001, 1, QDSBlocked, tm0, false},
+				{0x000002, 2, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1840,8 +1851,8 @@ func TestASDU_GetMeasuredValueNormal(t *testing.T) {
 					Variable: VariableStruct{IsSequence: false, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10, 0x02, 0x00, 0x00, 0x02, 0x00, 0x10}},
 			[]MeasuredValueNormalInfo{
-				{0x000001, 1, QDSBlocked, time.Time{}},
-				{0x000002, 2, QDSBlocked, time.Time{}}},
+				{0x000001, 1, QDSBlocked, time.Time{}, false},
+				{0x000002, 2, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ME_NA_1 seq = true Number = 2",
@@ -1852,8 +1863,8 @@ func TestASDU_GetMeasuredValueNormal(t *testing.T) {
 					Variable: VariableStruct{IsSequence: true, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10, 0x02, 0x00, 0x10}},
 			[]MeasuredValueNormalInfo{
-				{0x000001, 1, QDSBlocked, time.Time{}},
-				{0x000002, 2, QDSBlocked, time.Time{}}},
+				{0x000001, 1, QDSBlocked, time.Time{}, false},
+				{0x000002, 2, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ME_TD_1 CP56Time2a  Number = 2",
@@ -1865,8 +1876,8 @@ func TestASDU_GetMeasuredValueNormal(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10}, tm0CP56Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x00, 0x10}, tm0CP56Time2aBytes...)...)},
 			[]MeasuredValueNormalInfo{
-				{0x000001, 1, QDSBlocked, tm0},
-				{0x000002, 2, QDSBlocked, tm0}},
+				{0x000001, 1, QDSBlocked, tm0, false},
+				{0x000002, 2, QDSBlocked, tm0, false}},
 		},
 		{
 			"M_ME_ND_1 seq = false Number = 2",
@@ -1877,8 +1888,8 @@ func TestASDU_GetMeasuredValueNormal(t *testing.T) {
 					Variable: VariableStruct{IsSequence: false, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x00, 0x02, 0x00}},
 			[]MeasuredValueNormalInfo{
-				{0x000001, 1, QDSGood, time.Time{}},
-				{0x000002, 2, QDSGood, time.Time{}}},
+				{0x000001, 1, QDSGood, time.Time{}, false},
+				{0x000002, 2, QDSGood, time.Time{}, false}},
 		},
 		{
 			"M_ME_ND_1 seq = true Number = 2",
@@ -1889,8 +1900,8 @@ func TestASDU_GetMeasuredValueNormal(t *testing.T) {
 					Variable: VariableStruct{IsSequence: true, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00}},
 			[]MeasuredValueNormalInfo{
-				{0x000001, 1, QDSGood, time.Time{}},
-				{0x000002, 2, QDSGood, time.Time{}}},
+				{0x000001, 1, QDSGood, time.Time{}, false},
+				{0x000002, 2, QDSGood, time.Time{}, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1929,8 +1940,8 @@ func TestASDU_GetMeasuredValueNormalCP24Time2a(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10}, tm0CP24Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x00, 0x10}, tm0CP24Time2aBytes...)...)},
 			[]MeasuredValueNormalInfo{
-				{0x000001, 1, QDSBlocked, tm0},
-				{0x000002, 2, QDSBlocked, tm0}},
+				{0x000001, 1, QDSBlocked, tm0, false},
+				{0x000002, 2, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -1986,8 +1997,8 @@ func TestASDU_GetMeasuredValueScaled(t *testing.T) {
 					Variable: VariableStruct{IsSequence: false, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10, 0x02, 0x00, 0x00, 0x02, 0x00, 0x10}},
 			[]MeasuredValueScaledInfo{
-				{0x000001, 1, QDSBlocked, time.Time{}},
-				{0x000002, 2, QDSBlocked, time.Time{}}},
+				{0x000001, 1, QDSBlocked, time.Time{}, false},
+				{0x000002, 2, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ME_NB_1 seq = true Number = 2",
@@ -1998,8 +2009,8 @@ func TestASDU_GetMeasuredValueScaled(t *testing.T) {
 					Variable: VariableStruct{IsSequence: true, Number: 2}},
 				[]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10, 0x02, 0x00, 0x10}},
 			[]MeasuredValueScaledInfo{
-				{0x000001, 1, QDSBlocked, time.Time{}},
-				{0x000002, 2, QDSBlocked, time.Time{}}},
+				{0x000001, 1, QDSBlocked, time.Time{}, false},
+				{0x000002, 2, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ME_TE_1 CP56Time2a  Number = 2",
@@ -2011,8 +2022,8 @@ func TestASDU_GetMeasuredValueScaled(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10}, tm0CP56Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x00, 0x10}, tm0CP56Time2aBytes...)...)},
 			[]MeasuredValueScaledInfo{
-				{0x000001, 1, QDSBlocked, tm0},
-				{0x000002, 2, QDSBlocked, tm0}},
+				{0x000001, 1, QDSBlocked, tm0, false},
+				{0x000002, 2, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -2050,8 +2061,8 @@ func TestASDU_GetMeasuredValueScaledCP24Time2a(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, 0x01, 0x00, 0x10}, tm0CP24Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, 0x02, 0x00, 0x10}, tm0CP24Time2aBytes...)...)},
 			[]MeasuredValueScaledInfo{
-				{0x000001, 1, QDSBlocked, tm0},
-				{0x000002, 2, QDSBlocked, tm0}},
+				{0x000001, 1, QDSBlocked, tm0, false},
+				{0x000002, 2, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -2111,8 +2122,8 @@ func TestASDU_GetMeasuredValueFloat(t *testing.T) {
 					0x01, 0x00, 0x00, byte(bits1), byte(bits1 >> 8), byte(bits1 >> 16), byte(bits1 >> 24), 0x10,
 					0x02, 0x00, 0x00, byte(bits2), byte(bits2 >> 8), byte(bits2 >> 16), byte(bits2 >> 24), 0x10}},
 			[]MeasuredValueFloatInfo{
-				{0x000001, 100, QDSBlocked, time.Time{}},
-				{0x000002, 101, QDSBlocked, time.Time{}}},
+				{0x000001, 100, QDSBlocked, time.Time{}, false},
+				{0x000002, 101, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ME_NC_1 seq = true Number = 2",
@@ -2125,8 +2136,8 @@ func TestASDU_GetMeasuredValueFloat(t *testing.T) {
 					0x01, 0x00, 0x00, byte(bits1), byte(bits1 >> 8), byte(bits1 >> 16), byte(bits1 >> 24), 0x10,
 					byte(bits2), byte(bits2 >> 8), byte(bits2 >> 16), byte(bits2 >> 24), 0x10}},
 			[]MeasuredValueFloatInfo{
-				{0x000001, 100, QDSBlocked, time.Time{}},
-				{0x000002, 101, QDSBlocked, time.Time{}}},
+				{0x000001, 100, QDSBlocked, time.Time{}, false},
+				{0x000002, 101, QDSBlocked, time.Time{}, false}},
 		},
 		{
 			"M_ME_TF_1 CP56Time2a  Number = 2",
@@ -2138,8 +2149,8 @@ func TestASDU_GetMeasuredValueFloat(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, byte(bits1), byte(bits1 >> 8), byte(bits1 >> 16), byte(bits1 >> 24), 0x10}, tm0CP56Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, byte(bits2), byte(bits2 >> 8), byte(bits2 >> 16), byte(bits2 >> 24), 0x10}, tm0CP56Time2aBytes...)...)},
 			[]MeasuredValueFloatInfo{
-				{0x000001, 100, QDSBlocked, tm0},
-				{0x000002, 101, QDSBlocked, tm0}},
+				{0x000001, 100, QDSBlocked, tm0, false},
+				{0x000002, 101, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -2179,8 +2190,8 @@ func TestASDU_GetMeasuredValueFloatCP24Time2a(t *testing.T) {
 				append(append([]byte{0x01, 0x00, 0x00, byte(bits1), byte(bits1 >> 8), byte(bits1 >> 16), byte(bits1 >> 24), 0x10}, tm0CP24Time2aBytes...),
 					append([]byte{0x02, 0x00, 0x00, byte(bits2), byte(bits2 >> 8), byte(bits2 >> 16), byte(bits2 >> 24), 0x10}, tm0CP24Time2aBytes...)...)},
 			[]MeasuredValueFloatInfo{
-				{0x000001, 100, QDSBlocked, tm0},
-				{0x000002, 101, QDSBlocked, tm0}},
+				{0x000001, 100, QDSBlocked, tm0, false},
+				{0x000002, 101, QDSBlocked, tm0, false}},
 		},
 	}
 	for _, tt := range tests {
@@ -2215,3 +2226,108 @@ func TestASDU_GetMeasuredValueFloatCP24Time2a(t *testing.T) {
 		})
 	}
 }
+
+func Test_autoSequence(t *testing.T) {
+	p := *ParamsWide
+	p.AutoSequence = true
+	c := &batchConn{p: &p}
+	send := func(isSequence bool, ioas ...InfoObjAddr) *ASDU {
+		infos := make([]MeasuredValueScaledInfo, 0, len(ioas))
+		for _, ioa := range ioas {
+			infos = append(infos, MeasuredValueScaledInfo{Ioa: ioa, Value: int16(ioa)})
+		}
+		if err := MeasuredValueScaled(c, isSequence, CauseOfTransmission{Cause: Spontaneous}, 1, infos...); err != nil {
+			t.Fatal(err)
+		}
+		return c.sent[len(c.sent)-1]
+	}
+	if a := send(false, 10, 11, 12); !a.Variable.IsSequence || len(a.infoObj) != 3+3*3 {
+		t.Errorf("contiguous IsSequence %v, size %d", a.Variable.IsSequence, len(a.infoObj))
+	}
+	if a := send(true, 10, 12, 13); a.Variable.IsSequence {
+		t.Errorf("not contiguous sent in sequence")
+	} else if got := a.GetMeasuredValueScaled(); got[1].Ioa != 12 {
+		t.Errorf("GetMeasuredValueScaled() = %+v", got)
+	}
+	if a := send(true, 10); a.Variable.IsSequence {
+		t.Errorf("single object sent in sequence")
+	}
+}
+
+func TestStepPosition_RoundTrip(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	infos := []StepPositionInfo{
+		{Ioa: 1, Value: StepPosition{Val: -64, HasTransient: true}, Qds: QDSBlocked, Time: tm},
+		{Ioa: 2, Value: StepPosition{Val: 63}, Qds: QDSGood, Time: tm},
+	}
+	tests := []struct {
+		name   string
+		typeID TypeID
+		send   func(c Connect) error
+	}{
+		{"M_ST_NA_1", M_ST_NA_1, func(c Connect) error { return Step(c, false, CauseOfTransmission{Cause: Spontaneous}, 1, infos...) }},
+		{"M_ST_TA_1", M_ST_TA_1, func(c Connect) error { return StepCP24Time2a(c, CauseOfTransmission{Cause: Spontaneous}, 1, infos...) }},
+		{"M_ST_TB_1", M_ST_TB_1, func(c Connect) error { return StepCP56Time2a(c, CauseOfTransmission{Cause: Spontaneous}, 1, infos...) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &lastConn{}
+			if err := tt.send(c); err != nil {
+				t.Fatal(err)
+			}
+			raw, err := c.a.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := NewEmptyASDU(ParamsWide)
+			if err = a.UnmarshalBinary(raw); err != nil {
+				t.Fatal(err)
+			}
+			if a.Type != tt.typeID {
+				t.Fatalf("type = %v, want %v", a.Type, tt.typeID)
+			}
+			got, err := a.DecodeStepPosition()
+			if err != nil || len(got) != len(infos) {
+				t.Fatalf("DecodeStepPosition() = %v, %v", got, err)
+			}
+			for i, v := range got {
+				want := infos[i]
+				switch tt.typeID {
+				case M_ST_NA_1:
+					want.Time = time.Time{}
+				case M_ST_TA_1: // minutes and milliseconds only
+					if v.Time.Minute() == tm.Minute() && v.Time.Second() == tm.Second() && v.Time.Nanosecond() == tm.Nanosecond() {
+						want.Time = v.Time
+					}
+				}
+				if v.Ioa != want.Ioa || v.Value != want.Value || v.Qds != want.Qds || !v.Time.Equal(want.Time) {
+					t.Errorf("DecodeStepPosition() = %+v, want %+v", v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestASDU_DecodeStepPosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      Identifier
+		infoObj []byte
+		wantErr error
+	}{
+		{"not a step position", Identifier{Type: M_SP_NA_1, Variable: VariableStruct{Number: 1}}, []byte{0x01, 0x00, 0x00, 0x01}, ErrTypeIDNotMatch},
+		{"truncated", Identifier{Type: M_ST_TB_1, Variable: VariableStruct{Number: 1}}, []byte{0x01, 0x00, 0x00, 0x01, 0x10}, io.ErrUnexpectedEOF},
+		{"truncated sequence", Identifier{Type: M_ST_NA_1, Variable: VariableStruct{IsSequence: true, Number: 3}}, []byte{0x01, 0x00, 0x00, 0x01, 0x10, 0x02, 0x10}, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &ASDU{Params: ParamsWide, Identifier: tt.id, infoObj: tt.infoObj}
+			if _, err := a.DecodeStepPosition(); err != tt.wantErr {
+				t.Errorf("DecodeStepPosition() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(a.infoObj) != len(tt.infoObj) {
+				t.Errorf("DecodeStepPosition() consumed the information objects on error")
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// paramsJSON the serialized form of Params
+type paramsJSON struct {
+	CauseSize        int           `json:"causeSize"`
+	OrigAddress      OriginAddr    `json:"origAddress,omitempty"`
+	CommonAddrSize   int           `json:"commonAddrSize"`
+	InfoObjAddrSize  int           `json:"infoObjAddrSize"`
+	TimeZone         string        `json:"timeZone,omitempty"`
+	AutoSequence     bool          `json:"autoSequence,omitempty"`
+	TimeTagPolicy    TimeTagPolicy `json:"timeTagPolicy,omitempty"`
+	TimeTagTolerance string        `json:"timeTagTolerance,omitempty"`
+	MaxSize          int           `json:"maxSize,omitempty"`
+}
+
+// MarshalJSON implement json.Marshaler. The time zone is given by its name, such as "UTC"
+// or "Europe/Paris", the tolerance as a duration string, the max size is omitted if none.
+// Profile and Causes are not serialized.
+func (sf Params) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sf.toJSON())
+}
+
+// UnmarshalJSON implement json.Unmarshaler, the fields absent keep their value, the unknown
+// ones are an error, and the time zone defaults to UTC. Profile and Causes are kept as they are.
+func (sf *Params) UnmarshalJSON(data []byte) error {
+	v := sf.toJSON()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	loc := time.UTC
+	if v.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(v.TimeZone); err != nil {
+			return err
+		}
+	}
+	var tolerance time.Duration
+	if v.TimeTagTolerance != "" {
+		var err error
+		if tolerance, err = time.ParseDuration(v.TimeTagTolerance); err != nil {
+			return err
+		}
+	}
+	sf.CauseSize = v.CauseSize
+	sf.OrigAddress = v.OrigAddress
+	sf.CommonAddrSize = v.CommonAddrSize
+	sf.InfoObjAddrSize = v.InfoObjAddrSize
+	sf.InfoObjTimeZone = loc
+	sf.AutoSequence = v.AutoSequence
+	sf.TimeTagPolicy = v.TimeTagPolicy
+	sf.TimeTagTolerance = tolerance
+	sf.MaxSize = v.MaxSize
+	return nil
+}
+
+func (sf Params) toJSON() paramsJSON {
+	v := paramsJSON{
+		CauseSize:       sf.CauseSize,
+		OrigAddress:     sf.OrigAddress,
+		CommonAddrSize:  sf.CommonAddrSize,
+		InfoObjAddrSize: sf.InfoObjAddrSize,
+		AutoSequence:    sf.AutoSequence,
+		TimeTagPolicy:   sf.TimeTagPolicy,
+		MaxSize:         sf.MaxSize,
+	}
+	if sf.InfoObjTimeZone != nil {
+		v.TimeZone = sf.InfoObjTimeZone.String()
+	}
+	if sf.TimeTagTolerance != 0 {
+		v.TimeTagTolerance = sf.TimeTagTolerance.String()
+	}
+	return v
+}
+
+// Name returns the name of the type identification, such as "M_SP_NA_1", see ParseTypeID.
+func (sf TypeID) Name() string {
+	return strings.TrimSuffix(strings.TrimPrefix(sf.String(), "TID<"), ">")
+}
+
+// ParseTypeID returns the type identification of the name, such as "M_SP_NA_1".
+func ParseTypeID(name string) (TypeID, error) {
+	for t := TypeID(1); t != 0; t++ {
+		if t.Name() == name {
+			return t, nil
+		}
+	}
+	return 0, ErrTypeIdentifier
+}
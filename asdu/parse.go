@@ -0,0 +1,103 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+// ParseASDU decodes the raw data unit, the information objects included,
+// with the decoder of its type identification. It never panics, which
+// makes it the entry point for fuzzing.
+func ParseASDU(p *Params, raw []byte) error {
+	if p == nil {
+		return ErrParam
+	}
+	if err := p.Valid(); err != nil {
+		return err
+	}
+	a := NewEmptyASDU(p)
+	if err := a.UnmarshalBinary(raw); err != nil {
+		return err
+	}
+	return a.decodeInfoObj()
+}
+
+// decodeInfoObj decodes the information objects of a data unit successfully unmarshaled.
+func (sf *ASDU) decodeInfoObj() error {
+	switch sf.Type {
+	case M_SP_NA_1, M_SP_TA_1, M_SP_TB_1:
+		sf.GetSinglePoint()
+	case M_DP_NA_1, M_DP_TA_1, M_DP_TB_1:
+		sf.GetDoublePoint()
+	case M_ST_NA_1, M_ST_TA_1, M_ST_TB_1:
+		sf.GetStepPosition()
+	case M_BO_NA_1, M_BO_TA_1, M_BO_TB_1:
+		sf.GetBitString32()
+	case M_ME_NA_1, M_ME_TA_1, M_ME_TD_1, M_ME_ND_1:
+		sf.GetMeasuredValueNormal()
+	case M_ME_NB_1, M_ME_TB_1, M_ME_TE_1:
+		sf.GetMeasuredValueScaled()
+	case M_ME_NC_1, M_ME_TC_1, M_ME_TF_1:
+		sf.GetMeasuredValueFloat()
+	case M_IT_NA_1, M_IT_TA_1, M_IT_TB_1:
+		sf.GetIntegratedTotals()
+	case M_EP_TA_1, M_EP_TD_1:
+		sf.GetEventOfProtectionEquipment()
+	case M_EP_TB_1, M_EP_TE_1:
+		sf.GetPackedStartEventsOfProtectionEquipment()
+	case M_EP_TC_1, M_EP_TF_1:
+		sf.GetPackedOutputCircuitInfo()
+	case M_PS_NA_1:
+		sf.GetPackedSinglePointWithSCD()
+	case M_EI_NA_1:
+		sf.GetEndOfInitialization()
+
+	case C_SC_NA_1, C_SC_TA_1:
+		sf.GetSingleCmd()
+	case C_DC_NA_1, C_DC_TA_1:
+		sf.GetDoubleCmd()
+	case C_RC_NA_1, C_RC_TA_1:
+		sf.GetStepCmd()
+	case C_SE_NA_1, C_SE_TA_1:
+		sf.GetSetpointNormalCmd()
+	case C_SE_NB_1, C_SE_TB_1:
+		sf.GetSetpointCmdScaled()
+	case C_SE_NC_1, C_SE_TC_1:
+		sf.GetSetpointFloatCmd()
+	case C_BO_NA_1, C_BO_TA_1:
+		sf.GetBitsString32Cmd()
+
+	case C_IC_NA_1:
+		sf.GetInterrogationCmd()
+	case C_CI_NA_1:
+		sf.GetCounterInterrogationCmd()
+	case C_RD_NA_1:
+		sf.GetReadCmd()
+	case C_CS_NA_1:
+		sf.GetClockSynchronizationCmd()
+	case C_TS_NA_1:
+		sf.GetTestCommand()
+	case C_RP_NA_1:
+		sf.GetResetProcessCmd()
+	case C_CD_NA_1:
+		sf.GetDelayAcquireCommand()
+	case C_TS_TA_1:
+		sf.GetTestCommandCP56Time2a()
+
+	case P_ME_NA_1:
+		sf.GetParameterNormal()
+	case P_ME_NB_1:
+		sf.GetParameterScaled()
+	case P_ME_NC_1:
+		sf.GetParameterFloat()
+	case P_AC_NA_1:
+		sf.GetParameterActivation()
+
+	default:
+		if _, ok := LookupPrivateType(sf.Type); ok {
+			_, err := sf.GetPrivate()
+			return err
+		}
+		// no decoder, the size is checked by UnmarshalBinary only
+	}
+	return nil
+}
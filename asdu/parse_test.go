@@ -0,0 +1,53 @@
+package asdu
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseASDU(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       *Params
+		raw     []byte
+		wantErr bool
+	}{
+		{"nil params", nil, []byte{0x01, 0x01, 0x03, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01}, true},
+		{"single point", ParamsWide, []byte{0x01, 0x01, 0x03, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01}, false},
+		{"short information object", ParamsWide, []byte{0x01, 0x02, 0x03, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01}, true},
+		{"short identifier", ParamsWide, []byte{0x01, 0x01, 0x03}, true},
+		{"unknown type", ParamsWide, []byte{0x7f, 0x01, 0x03, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01}, true},
+		{"interrogation", ParamsNarrow, []byte{0x64, 0x01, 0x06, 0x01, 0x00, 0x14}, false},
+		{"no information object", ParamsNarrow, []byte{0x64, 0x00, 0x06, 0x01, 0x00, 0x14}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ParseASDU(tt.p, tt.raw); (err != nil) != tt.wantErr {
+				t.Errorf("ParseASDU() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestASDU_UnmarshalBinary_infoObjAddrSize(t *testing.T) {
+	a := NewEmptyASDU(&Params{CauseSize: 2, CommonAddrSize: 2, InfoObjAddrSize: 4, InfoObjTimeZone: time.UTC})
+	if err := a.UnmarshalBinary([]byte{0x01, 0x01, 0x03, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}); err != ErrParam {
+		t.Errorf("UnmarshalBinary() error = %v, want %v", err, ErrParam)
+	}
+}
+
+func FuzzParseASDU(f *testing.F) {
+	f.Add(false, []byte{0x01, 0x01, 0x03, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01})
+	f.Add(false, []byte{0x1e, 0x81, 0x03, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x14})
+	f.Add(false, []byte{0x2d, 0x01, 0x06, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x81})
+	f.Add(false, []byte{0x67, 0x01, 0x06, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x14})
+	f.Add(true, []byte{0x64, 0x01, 0x06, 0x01, 0x00, 0x14})
+	f.Add(true, []byte{0x26, 0x01, 0x03, 0x01, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x14})
+	f.Fuzz(func(t *testing.T, narrow bool, raw []byte) {
+		p := ParamsWide
+		if narrow {
+			p = ParamsNarrow
+		}
+		_ = ParseASDU(p, raw)
+	})
+}
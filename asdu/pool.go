@@ -0,0 +1,46 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"sync"
+)
+
+var asduPool = sync.Pool{
+	New: func() interface{} { return new(ASDU) },
+}
+
+// AcquireASDU returns an asdu from the pool with special params and identifier, same as NewASDU.
+// Release it with ReleaseASDU once sent, that is possible only if the connection does not keep it
+// after Send, as the cs104 Client and SrvSession which copy the marshaled asdu.
+func AcquireASDU(p *Params, identifier Identifier) *ASDU {
+	a := asduPool.Get().(*ASDU)
+	a.Params = p
+	a.Identifier = identifier
+	lenDUI := a.IdentifierSize()
+	a.infoObj = a.bootstrap[lenDUI:lenDUI]
+	return a
+}
+
+// ReleaseASDU put the asdu acquired by AcquireASDU back to the pool, it must not be used any more.
+func ReleaseASDU(a *ASDU) {
+	if a == nil {
+		return
+	}
+	a.Params = nil
+	a.Identifier = Identifier{}
+	a.infoObj = nil
+	asduPool.Put(a)
+}
+
+// AppendBinary append the marshaled asdu to b and returns the extended buffer,
+// so that the caller can encode into its own preallocated buffer.
+func (sf *ASDU) AppendBinary(b []byte) ([]byte, error) {
+	data, err := sf.MarshalBinary()
+	if err != nil {
+		return b, err
+	}
+	return append(b, data...), nil
+}
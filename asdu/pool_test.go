@@ -0,0 +1,66 @@
+package asdu
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func encodeSingle(a *ASDU, buf []byte, tm time.Time) ([]byte, error) {
+	for i := 0; i < 10; i++ {
+		if err := a.AppendInfoObjAddr(InfoObjAddr(i + 1)); err != nil {
+			return buf, err
+		}
+		a.AppendBytes(0x01).AppendCP56Time2a(tm, a.InfoObjTimeZone)
+	}
+	if err := a.SetVariableNumber(10); err != nil {
+		return buf, err
+	}
+	return a.AppendBinary(buf[:0])
+}
+
+func TestAcquireASDU(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6e6, time.UTC)
+	id := Identifier{Type: M_SP_TB_1, Coa: CauseOfTransmission{Cause: Spontaneous}, CommonAddr: 1}
+
+	want, err := encodeSingle(NewASDU(ParamsWide, id), nil, tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ { // reused ones start empty
+		a := AcquireASDU(ParamsWide, id)
+		got, err := encodeSingle(a, make([]byte, 0, ASDUSizeMax), tm)
+		ReleaseASDU(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("AppendBinary() = % x, want % x", got, want)
+		}
+	}
+}
+
+func BenchmarkNewASDU(b *testing.B) {
+	tm := time.Now()
+	id := Identifier{Type: M_SP_TB_1, Coa: CauseOfTransmission{Cause: Spontaneous}, CommonAddr: 1}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := encodeSingle(NewASDU(ParamsWide, id), nil, tm); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAcquireASDU(b *testing.B) {
+	tm := time.Now()
+	id := Identifier{Type: M_SP_TB_1, Coa: CauseOfTransmission{Cause: Spontaneous}, CommonAddr: 1}
+	buf := make([]byte, 0, ASDUSizeMax)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a := AcquireASDU(ParamsWide, id)
+		if _, err := encodeSingle(a, buf, tm); err != nil {
+			b.Fatal(err)
+		}
+		ReleaseASDU(a)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"sync"
+)
+
+// PrivateTypeIDMin the first type identification of the private range <128..255>
+const PrivateTypeIDMin TypeID = 128
+
+// PrivateType a vendor specific type identification of the private range,
+// its information object is the address followed by Size octets of information element.
+type PrivateType struct {
+	ID   TypeID
+	Name string
+	// Size the serial octet size of the information element
+	Size int
+	// Encode append the information element of the value to b
+	Encode func(b []byte, value interface{}) ([]byte, error)
+	// Decode returns the value of the information element b, which has Size octets
+	Decode func(b []byte) (interface{}, error)
+}
+
+// PrivateInfo the information object of a private type identification
+type PrivateInfo struct {
+	Ioa   InfoObjAddr
+	Value interface{}
+}
+
+var privateTypes = struct {
+	sync.RWMutex
+	m map[TypeID]PrivateType
+}{m: make(map[TypeID]PrivateType)}
+
+// RegisterPrivateType register the private type identification, replacing the one with the same ID,
+// so that the asdu of the type can be encoded, decoded and dispatched like the standard ones.
+func RegisterPrivateType(t PrivateType) error {
+	if t.ID < PrivateTypeIDMin || t.Size <= 0 || t.Encode == nil || t.Decode == nil {
+		return ErrPrivateType
+	}
+	privateTypes.Lock()
+	privateTypes.m[t.ID] = t
+	privateTypes.Unlock()
+	return nil
+}
+
+// LookupPrivateType returns the registered private type identification
+func LookupPrivateType(id TypeID) (PrivateType, bool) {
+	privateTypes.RLock()
+	defer privateTypes.RUnlock()
+	t, ok := privateTypes.m[id]
+	return t, ok
+}
+
+// Private sends the information objects of a registered private type identification
+func Private(c Connect, typeID TypeID, isSequence bool, coa CauseOfTransmission, ca CommonAddr, infos ...PrivateInfo) error {
+	t, ok := LookupPrivateType(typeID)
+	if !ok {
+		return ErrTypeIdentifier
+	}
+	if err := checkValid(c, typeID, isSequence, len(infos)); err != nil {
+		return err
+	}
+
+	u := NewASDU(c.Params(), Identifier{
+		typeID,
+		VariableStruct{IsSequence: isSequence},
+		coa,
+		0,
+		ca,
+	})
+	if err := u.SetVariableNumber(len(infos)); err != nil {
+		return err
+	}
+	once := false
+	for _, v := range infos {
+		if !isSequence || !once {
+			once = true
+			if err := u.AppendInfoObjAddr(v.Ioa); err != nil {
+				return err
+			}
+		}
+		n := len(u.infoObj)
+		b, err := t.Encode(u.infoObj, v.Value)
+		if err != nil {
+			return err
+		}
+		if len(b)-n != t.Size {
+			return ErrPrivateType
+		}
+		u.infoObj = b
+	}
+	return c.Send(u)
+}
+
+// GetPrivate get the information objects of a registered private type identification
+func (sf *ASDU) GetPrivate() ([]PrivateInfo, error) {
+	t, ok := LookupPrivateType(sf.Type)
+	if !ok {
+		return nil, ErrTypeIdentifier
+	}
+	info := make([]PrivateInfo, 0, sf.Variable.Number)
+	infoObjAddr := InfoObjAddr(0)
+	for i, once := 0, false; i < int(sf.Variable.Number); i++ {
+		if !sf.Variable.IsSequence || !once {
+			once = true
+			infoObjAddr = sf.DecodeInfoObjAddr()
+		} else {
+			infoObjAddr++
+		}
+		value, err := t.Decode(sf.infoObj[:t.Size])
+		if err != nil {
+			return nil, err
+		}
+		sf.infoObj = sf.infoObj[t.Size:]
+		info = append(info, PrivateInfo{infoObjAddr, value})
+	}
+	return info, nil
+}
@@ -0,0 +1,54 @@
+package asdu
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestRegisterPrivateType(t *testing.T) {
+	const vendorCounter TypeID = 140
+	err := RegisterPrivateType(PrivateType{
+		ID:   vendorCounter,
+		Name: "VENDOR_COUNTER",
+		Size: 2,
+		Encode: func(b []byte, value interface{}) ([]byte, error) {
+			return binary.LittleEndian.AppendUint16(b, value.(uint16)), nil
+		},
+		Decode: func(b []byte) (interface{}, error) {
+			return binary.LittleEndian.Uint16(b), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = RegisterPrivateType(PrivateType{ID: M_SP_NA_1, Size: 1}); err != ErrPrivateType {
+		t.Errorf("RegisterPrivateType() of a standard type error = %v, want %v", err, ErrPrivateType)
+	}
+	if size, err := GetInfoObjSize(vendorCounter); err != nil || size != 2 {
+		t.Errorf("GetInfoObjSize() = %d, %v, want 2", size, err)
+	}
+	if got := vendorCounter.String(); got != "TID<VENDOR_COUNTER>" {
+		t.Errorf("String() = %v", got)
+	}
+
+	c := &lastConn{}
+	want := []PrivateInfo{{Ioa: 10, Value: uint16(1)}, {Ioa: 11, Value: uint16(0x1234)}}
+	for _, isSeq := range []bool{false, true} {
+		if err = Private(c, vendorCounter, isSeq, CauseOfTransmission{Cause: Spontaneous}, 1, want...); err != nil {
+			t.Fatal(err)
+		}
+		data, err := c.a.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := NewEmptyASDU(ParamsWide)
+		if err = a.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		got, err := a.GetPrivate()
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("GetPrivate() = %v, %v, want %v", got, err, want)
+		}
+	}
+}
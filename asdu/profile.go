@@ -0,0 +1,124 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"time"
+)
+
+// Profile the interoperability list a station implements, see companion standard 101, clause 8
+// and companion standard 104, clause 9. The params with a profile are not valid outside
+// its system parameters, and the asdu of type identifications not selected can not be marshaled.
+type Profile struct {
+	Name string
+	// the selected sizes of the system parameters, empty selects all the standard ones.
+	CauseSizes       []int
+	CommonAddrSizes  []int
+	InfoObjAddrSizes []int
+	// TypeIDs the selected type identifications, nil selects all.
+	TypeIDs map[TypeID]bool
+}
+
+// Validate returns the validation result of the system parameters against the profile.
+func (sf *Profile) Validate(p Params) error {
+	if !selectedSize(sf.CauseSizes, p.CauseSize) ||
+		!selectedSize(sf.CommonAddrSizes, p.CommonAddrSize) ||
+		!selectedSize(sf.InfoObjAddrSizes, p.InfoObjAddrSize) {
+		return ErrProfileParam
+	}
+	return nil
+}
+
+// Allows whether the type identification is selected by the profile.
+func (sf *Profile) Allows(t TypeID) bool {
+	return sf.TypeIDs == nil || sf.TypeIDs[t]
+}
+
+// Restrict returns a copy of the profile selecting only the type identifications ids it allows,
+// like the checked boxes of the interoperability list of a device. A nil profile allows all.
+func (sf *Profile) Restrict(ids ...TypeID) *Profile {
+	r := &Profile{TypeIDs: make(map[TypeID]bool, len(ids))}
+	if sf != nil {
+		*r = *sf
+		r.TypeIDs = make(map[TypeID]bool, len(ids))
+	}
+	for _, t := range ids {
+		if sf.allows(t) {
+			r.TypeIDs[t] = true
+		}
+	}
+	return r
+}
+
+func (sf *Profile) allows(t TypeID) bool {
+	return sf == nil || sf.Allows(t)
+}
+
+func selectedSize(sizes []int, size int) bool {
+	if len(sizes) == 0 {
+		return true
+	}
+	for _, v := range sizes {
+		if v == size {
+			return true
+		}
+	}
+	return false
+}
+
+func typeIDSet(ranges ...[2]TypeID) map[TypeID]bool {
+	m := make(map[TypeID]bool)
+	for _, r := range ranges {
+		for t := r[0]; t <= r[1]; t++ {
+			m[t] = true
+		}
+	}
+	return m
+}
+
+// Profile defined
+var (
+	// ProfileCS101 the standard type identifications of companion standard 101,
+	// with any of the standard system parameter sizes.
+	ProfileCS101 = &Profile{
+		Name: "IEC 60870-5-101",
+		TypeIDs: typeIDSet(
+			[2]TypeID{M_SP_NA_1, M_ME_ND_1}, [2]TypeID{M_SP_TB_1, M_EP_TF_1},
+			[2]TypeID{C_SC_NA_1, C_BO_NA_1}, [2]TypeID{M_EI_NA_1, M_EI_NA_1},
+			[2]TypeID{C_IC_NA_1, C_CD_NA_1}, [2]TypeID{P_ME_NA_1, P_AC_NA_1},
+			[2]TypeID{F_FR_NA_1, F_DR_TA_1},
+		),
+	}
+	// ProfileCS104 the standard type identifications of companion standard 104, it excludes
+	// the time tag CP24Time2a and adds the commands with time tag CP56Time2a.
+	// The cause of transmission, common address and information object address
+	// are fixed to 2, 2 and 3 octets.
+	ProfileCS104 = &Profile{
+		Name:             "IEC 60870-5-104",
+		CauseSizes:       []int{2},
+		CommonAddrSizes:  []int{2},
+		InfoObjAddrSizes: []int{3},
+		TypeIDs: typeIDSet(
+			[2]TypeID{M_SP_NA_1, M_SP_NA_1}, [2]TypeID{M_DP_NA_1, M_DP_NA_1},
+			[2]TypeID{M_ST_NA_1, M_ST_NA_1}, [2]TypeID{M_BO_NA_1, M_BO_NA_1},
+			[2]TypeID{M_ME_NA_1, M_ME_NA_1}, [2]TypeID{M_ME_NB_1, M_ME_NB_1},
+			[2]TypeID{M_ME_NC_1, M_ME_NC_1}, [2]TypeID{M_IT_NA_1, M_IT_NA_1},
+			[2]TypeID{M_PS_NA_1, M_ME_ND_1}, [2]TypeID{M_SP_TB_1, M_EP_TF_1},
+			[2]TypeID{C_SC_NA_1, C_BO_NA_1}, [2]TypeID{C_SC_TA_1, C_BO_TA_1},
+			[2]TypeID{M_EI_NA_1, M_EI_NA_1}, [2]TypeID{C_IC_NA_1, C_TS_TA_1},
+			[2]TypeID{P_ME_NA_1, P_AC_NA_1}, [2]TypeID{F_FR_NA_1, F_SC_NB_1},
+		),
+	}
+)
+
+// Params presets of the interoperability lists
+var (
+	// ParamsCS101Unbalanced the usual configuration of the companion standard 101 unbalanced transmission.
+	ParamsCS101Unbalanced = &Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 2, InfoObjTimeZone: time.UTC, Profile: ProfileCS101}
+	// ParamsCS101Balanced the usual configuration of the companion standard 101 balanced transmission.
+	ParamsCS101Balanced = &Params{CauseSize: 1, CommonAddrSize: 2, InfoObjAddrSize: 2, InfoObjTimeZone: time.UTC, Profile: ProfileCS101}
+	// ParamsCS104 the fixed configuration of the companion standard 104.
+	ParamsCS104 = &Params{CauseSize: 2, CommonAddrSize: 2, InfoObjAddrSize: 3, InfoObjTimeZone: time.UTC, Profile: ProfileCS104}
+)
@@ -0,0 +1,77 @@
+package asdu
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   error
+	}{
+		{"cs104 preset", *ParamsCS104, nil},
+		{"cs101 preset", *ParamsCS101Unbalanced, nil},
+		{"cs101 wide", Params{CauseSize: 2, CommonAddrSize: 2, InfoObjAddrSize: 3, InfoObjTimeZone: time.UTC, Profile: ProfileCS101}, nil},
+		{"cs104 narrow", Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 1, InfoObjTimeZone: time.UTC, Profile: ProfileCS104}, ErrProfileParam},
+		{"out of standard", Params{CauseSize: 3, CommonAddrSize: 2, InfoObjAddrSize: 3, InfoObjTimeZone: time.UTC, Profile: ProfileCS104}, ErrParam},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.params.Valid(); got != tt.want {
+				t.Errorf("Valid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfile_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *Profile
+		typeID  TypeID
+		want    bool
+	}{
+		{"cs104 CP24Time2a", ProfileCS104, M_SP_TA_1, false},
+		{"cs104 CP56Time2a", ProfileCS104, M_SP_TB_1, true},
+		{"cs104 time tagged command", ProfileCS104, C_SC_TA_1, true},
+		{"cs101 CP24Time2a", ProfileCS101, M_ME_TA_1, true},
+		{"cs101 time tagged command", ProfileCS101, C_SC_TA_1, false},
+		{"cs101 test command with time tag", ProfileCS101, C_TS_TA_1, false},
+		{"no type list", &Profile{}, S_CH_NA_1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.Allows(tt.typeID); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestASDU_MarshalBinary_profile(t *testing.T) {
+	a := NewASDU(ParamsCS104, Identifier{Type: M_SP_TA_1, Variable: VariableStruct{Number: 1},
+		Coa: CauseOfTransmission{Cause: Spontaneous}, CommonAddr: 1})
+	if _, err := a.MarshalBinary(); !errors.Is(err, ErrProfileTypeID) {
+		t.Errorf("MarshalBinary() error = %v, want %v", err, ErrProfileTypeID)
+	}
+	a.Type = M_SP_NA_1
+	if _, err := a.MarshalBinary(); err != nil {
+		t.Errorf("MarshalBinary() error = %v, want nil", err)
+	}
+}
+
+func TestProfile_Restrict(t *testing.T) {
+	p := ProfileCS104.Restrict(M_SP_NA_1, M_SP_TA_1, C_IC_NA_1)
+	if !p.Allows(M_SP_NA_1) || !p.Allows(C_IC_NA_1) || p.Allows(M_SP_TA_1) || p.Allows(M_DP_NA_1) {
+		t.Errorf("Restrict() = %v, want M_SP_NA_1 and C_IC_NA_1", p.TypeIDs)
+	}
+	if p.Name != ProfileCS104.Name || len(p.InfoObjAddrSizes) != 1 {
+		t.Errorf("Restrict() lost the system parameters of the profile")
+	}
+	if (*Profile)(nil).Restrict(M_SP_TA_1).Allows(M_SP_TA_1) != true {
+		t.Errorf("Restrict() of nil profile does not allow M_SP_TA_1")
+	}
+}
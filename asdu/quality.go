@@ -0,0 +1,201 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the defined bits, the others are reserved
+const (
+	qdsMask = QDSOverflow | QDSBlocked | QDSSubstituted | QDSNotTopical | QDSInvalid
+	qdpMask = QDPElapsedTimeInvalid | QDPBlocked | QDPSubstituted | QDPNotTopical | QDPInvalid
+)
+
+// the flag names, from the most significant
+var qualityNames = [...]struct {
+	name string
+	qds  QualityDescriptor
+	qdp  QualityDescriptorProtection
+}{
+	{"IV", QDSInvalid, QDPInvalid},
+	{"NT", QDSNotTopical, QDPNotTopical},
+	{"SB", QDSSubstituted, QDPSubstituted},
+	{"BL", QDSBlocked, QDPBlocked},
+	{"EI", 0, QDPElapsedTimeInvalid},
+	{"OV", QDSOverflow, 0},
+}
+
+// qualityGood the name of no flags
+const qualityGood = "OK"
+
+// NewQualityDescriptor returns the quality descriptor of the flags, the reserved bits are never set
+func NewQualityDescriptor(flags ...QualityDescriptor) QualityDescriptor {
+	var q QualityDescriptor
+	for _, f := range flags {
+		q |= f
+	}
+	return q & qdsMask
+}
+
+// IsGood whether no flags are set, the reserved bits are ignored
+func (sf QualityDescriptor) IsGood() bool { return sf&qdsMask == 0 }
+
+// IsInvalid whether the invalid flag IV is set
+func (sf QualityDescriptor) IsInvalid() bool { return sf&QDSInvalid != 0 }
+
+// IsNotTopical whether the not topical flag NT is set
+func (sf QualityDescriptor) IsNotTopical() bool { return sf&QDSNotTopical != 0 }
+
+// IsSubstituted whether the substituted flag SB is set
+func (sf QualityDescriptor) IsSubstituted() bool { return sf&QDSSubstituted != 0 }
+
+// IsBlocked whether the blocked flag BL is set
+func (sf QualityDescriptor) IsBlocked() bool { return sf&QDSBlocked != 0 }
+
+// IsOverflow whether the overflow flag OV is set
+func (sf QualityDescriptor) IsOverflow() bool { return sf&QDSOverflow != 0 }
+
+// With returns the quality descriptor with the flag set or cleared, the reserved bits are cleared
+func (sf QualityDescriptor) With(flag QualityDescriptor, set bool) QualityDescriptor {
+	if set {
+		return (sf | flag) & qdsMask
+	}
+	return sf &^ flag & qdsMask
+}
+
+// WithInvalid returns the quality descriptor with the invalid flag IV set or cleared
+func (sf QualityDescriptor) WithInvalid(set bool) QualityDescriptor { return sf.With(QDSInvalid, set) }
+
+// WithNotTopical returns the quality descriptor with the not topical flag NT set or cleared
+func (sf QualityDescriptor) WithNotTopical(set bool) QualityDescriptor {
+	return sf.With(QDSNotTopical, set)
+}
+
+// WithSubstituted returns the quality descriptor with the substituted flag SB set or cleared
+func (sf QualityDescriptor) WithSubstituted(set bool) QualityDescriptor {
+	return sf.With(QDSSubstituted, set)
+}
+
+// WithBlocked returns the quality descriptor with the blocked flag BL set or cleared
+func (sf QualityDescriptor) WithBlocked(set bool) QualityDescriptor { return sf.With(QDSBlocked, set) }
+
+// WithOverflow returns the quality descriptor with the overflow flag OV set or cleared
+func (sf QualityDescriptor) WithOverflow(set bool) QualityDescriptor {
+	return sf.With(QDSOverflow, set)
+}
+
+// String returns the flags set, for example "IV,NT,SB", or "OK" if none
+func (sf QualityDescriptor) String() string {
+	var names []string
+	for _, v := range qualityNames {
+		if v.qds != 0 && sf&v.qds != 0 {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) == 0 {
+		return qualityGood
+	}
+	return strings.Join(names, ",")
+}
+
+// MarshalText honors the encoding.TextMarshaler interface, same as String.
+func (sf QualityDescriptor) MarshalText() ([]byte, error) {
+	return []byte(sf.String()), nil
+}
+
+// UnmarshalText honors the encoding.TextUnmarshaler interface, it parses the String format.
+func (sf *QualityDescriptor) UnmarshalText(text []byte) error {
+	var q QualityDescriptor
+	err := parseQuality(string(text), func(qds QualityDescriptor, _ QualityDescriptorProtection) bool {
+		q |= qds
+		return qds != 0
+	})
+	if err != nil {
+		return err
+	}
+	*sf = q
+	return nil
+}
+
+// NewQualityDescriptorProtection returns the quality descriptor of the flags, the reserved bits are never set
+func NewQualityDescriptorProtection(flags ...QualityDescriptorProtection) QualityDescriptorProtection {
+	var q QualityDescriptorProtection
+	for _, f := range flags {
+		q |= f
+	}
+	return q & qdpMask
+}
+
+// IsGood whether no flags are set, the reserved bits are ignored
+func (sf QualityDescriptorProtection) IsGood() bool { return sf&qdpMask == 0 }
+
+// IsInvalid whether the invalid flag IV is set
+func (sf QualityDescriptorProtection) IsInvalid() bool { return sf&QDPInvalid != 0 }
+
+// IsElapsedTimeInvalid whether the elapsed time invalid flag EI is set
+func (sf QualityDescriptorProtection) IsElapsedTimeInvalid() bool {
+	return sf&QDPElapsedTimeInvalid != 0
+}
+
+// With returns the quality descriptor with the flag set or cleared, the reserved bits are cleared
+func (sf QualityDescriptorProtection) With(flag QualityDescriptorProtection, set bool) QualityDescriptorProtection {
+	if set {
+		return (sf | flag) & qdpMask
+	}
+	return sf &^ flag & qdpMask
+}
+
+// String returns the flags set, for example "IV,NT,EI", or "OK" if none
+func (sf QualityDescriptorProtection) String() string {
+	var names []string
+	for _, v := range qualityNames {
+		if v.qdp != 0 && sf&v.qdp != 0 {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) == 0 {
+		return qualityGood
+	}
+	return strings.Join(names, ",")
+}
+
+// MarshalText honors the encoding.TextMarshaler interface, same as String.
+func (sf QualityDescriptorProtection) MarshalText() ([]byte, error) {
+	return []byte(sf.String()), nil
+}
+
+// UnmarshalText honors the encoding.TextUnmarshaler interface, it parses the String format.
+func (sf *QualityDescriptorProtection) UnmarshalText(text []byte) error {
+	var q QualityDescriptorProtection
+	err := parseQuality(string(text), func(_ QualityDescriptor, qdp QualityDescriptorProtection) bool {
+		q |= qdp
+		return qdp != 0
+	})
+	if err != nil {
+		return err
+	}
+	*sf = q
+	return nil
+}
+
+// parseQuality parse the comma separated flag names, set returns false if the flag is not defined
+func parseQuality(s string, set func(QualityDescriptor, QualityDescriptorProtection) bool) error {
+	if s == "" || s == qualityGood {
+		return nil
+	}
+next:
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		for _, v := range qualityNames {
+			if strings.EqualFold(name, v.name) && set(v.qds, v.qdp) {
+				continue next
+			}
+		}
+		return fmt.Errorf("asdu: unknown quality flag %q", name)
+	}
+	return nil
+}
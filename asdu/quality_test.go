@@ -0,0 +1,48 @@
+package asdu
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestQualityDescriptor(t *testing.T) {
+	q := NewQualityDescriptor(QDSInvalid, QDSBlocked, 0x0e) // reserved bits dropped
+	if q != QDSInvalid|QDSBlocked {
+		t.Fatalf("NewQualityDescriptor() = %#x", byte(q))
+	}
+	if q.IsGood() || !q.IsInvalid() || !q.IsBlocked() || q.IsOverflow() {
+		t.Errorf("flags of %v", q)
+	}
+	if q = q.WithBlocked(false).WithNotTopical(true).WithSubstituted(true); q.String() != "IV,NT,SB" {
+		t.Errorf("String() = %q, want IV,NT,SB", q)
+	}
+	if QualityDescriptor(0x0e).String() != "OK" || !QualityDescriptor(0x0e).IsGood() {
+		t.Errorf("reserved bits not ignored")
+	}
+
+	b, err := json.Marshal(struct{ Qds QualityDescriptor }{q.WithOverflow(true)})
+	if err != nil || string(b) != `{"Qds":"IV,NT,SB,OV"}` {
+		t.Fatalf("json.Marshal() = %s, %v", b, err)
+	}
+	var v struct{ Qds QualityDescriptor }
+	if err = json.Unmarshal(b, &v); err != nil || v.Qds != q|QDSOverflow {
+		t.Errorf("json.Unmarshal() = %v, %v", v.Qds, err)
+	}
+	if err = v.Qds.UnmarshalText([]byte("EI")); err == nil {
+		t.Errorf("UnmarshalText() want error of the flag of protection")
+	}
+}
+
+func TestQualityDescriptorProtection(t *testing.T) {
+	q := NewQualityDescriptorProtection(QDPElapsedTimeInvalid, QDPInvalid, 0x01)
+	if q.String() != "IV,EI" || !q.IsElapsedTimeInvalid() || q.IsGood() {
+		t.Errorf("String() = %q", q)
+	}
+	var p QualityDescriptorProtection
+	if err := p.UnmarshalText([]byte("iv, ei")); err != nil || p != q {
+		t.Errorf("UnmarshalText() = %v, %v", p, err)
+	}
+	if q.With(QDPInvalid, false) != QDPElapsedTimeInvalid {
+		t.Errorf("With() = %v", q.With(QDPInvalid, false))
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"fmt"
+)
+
+// IOAMap the mapping table of the information object addresses, from the old address to the new one.
+// The addresses not in the mapping are kept.
+type IOAMap map[InfoObjAddr]InfoObjAddr
+
+// IOACollisionError the re-addressing makes two information objects share the same address
+type IOACollisionError struct {
+	Addr InfoObjAddr
+}
+
+func (sf IOACollisionError) Error() string {
+	return fmt.Sprintf("asdu: information object address %d collision after re-addressing", sf.Addr)
+}
+
+// Map returns the new address of the information object address
+func (sf IOAMap) Map(addr InfoObjAddr) InfoObjAddr {
+	if v, ok := sf[addr]; ok {
+		return v
+	}
+	return addr
+}
+
+// Validate check that no two addresses are mapped to the same new address
+func (sf IOAMap) Validate() error {
+	seen := make(map[InfoObjAddr]struct{}, len(sf))
+	for _, v := range sf {
+		if _, ok := seen[v]; ok {
+			return IOACollisionError{v}
+		}
+		seen[v] = struct{}{}
+	}
+	return nil
+}
+
+// Readdress rewrite the information object addresses of the asdu in place by the mapping,
+// the asdu is not changed if it fails.
+// With SQ = 1 only the address of the first element is mapped, the others follow it.
+// The information object address irrelevant (0) is never mapped.
+func (sf *ASDU) Readdress(m IOAMap) error {
+	if sf.InfoObjAddrSize < 1 || sf.InfoObjAddrSize > 3 {
+		return ErrParam
+	}
+	objSize, err := GetInfoObjSize(sf.Type)
+	if err != nil {
+		return err
+	}
+
+	step := sf.InfoObjAddrSize + objSize
+	if sf.Variable.IsSequence {
+		step = len(sf.infoObj) // only one address
+	}
+	var offs []int
+	var addrs []InfoObjAddr
+	seen := make(map[InfoObjAddr]struct{})
+	for off := 0; off+sf.InfoObjAddrSize <= len(sf.infoObj); off += step {
+		addr := sf.infoObjAddrAt(off)
+		if addr == InfoObjAddrIrrelevant {
+			continue
+		}
+		addr = m.Map(addr)
+		if _, ok := seen[addr]; ok {
+			return IOACollisionError{addr}
+		}
+		if addr >= 1<<(8*uint(sf.InfoObjAddrSize)) {
+			return ErrInfoObjAddrFit
+		}
+		seen[addr] = struct{}{}
+		offs, addrs = append(offs, off), append(addrs, addr)
+	}
+	// nothing is changed until all the addresses are checked
+	for i, off := range offs {
+		for j := 0; j < sf.InfoObjAddrSize; j++ {
+			sf.infoObj[off+j] = byte(addrs[i] >> (8 * uint(j)))
+		}
+	}
+	return nil
+}
+
+func (sf *ASDU) infoObjAddrAt(off int) InfoObjAddr {
+	var addr InfoObjAddr
+	for i := sf.InfoObjAddrSize - 1; i >= 0; i-- {
+		addr = addr<<8 | InfoObjAddr(sf.infoObj[off+i])
+	}
+	return addr
+}
+
+// readdressConn a Connect rewriting the information object addresses of every sent asdu
+type readdressConn struct {
+	Connect
+	m IOAMap
+}
+
+// ReaddressConnect returns a Connect which rewrites the information object addresses
+// of every asdu by the mapping before sending it with c.
+func ReaddressConnect(c Connect, m IOAMap) (Connect, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &readdressConn{c, m}, nil
+}
+
+// Send rewrite the information object addresses of the asdu, then send it
+func (sf *readdressConn) Send(a *ASDU) error {
+	a = a.Clone()
+	if err := a.Readdress(sf.m); err != nil {
+		return err
+	}
+	return sf.Connect.Send(a)
+}
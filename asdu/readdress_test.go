@@ -0,0 +1,93 @@
+package asdu
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// lastConn keeps the last sent asdu
+type lastConn struct {
+	a *ASDU
+}
+
+func (sf *lastConn) Params() *Params          { return ParamsWide }
+func (sf *lastConn) UnderlyingConn() net.Conn { return nil }
+func (sf *lastConn) Send(u *ASDU) error {
+	sf.a = u.Clone()
+	return nil
+}
+
+func TestASDU_Readdress(t *testing.T) {
+	m := IOAMap{1: 1001, 2: 1002, 3: 2}
+	tests := []struct {
+		name     string
+		isSeq    bool
+		infos    []SinglePointInfo
+		wantIoas []InfoObjAddr
+		wantErr  bool
+	}{
+		{"mapped and kept", false, []SinglePointInfo{{Ioa: 1}, {Ioa: 2}, {Ioa: 9}}, []InfoObjAddr{1001, 1002, 9}, false},
+		{"sequence", true, []SinglePointInfo{{Ioa: 1}, {Ioa: 2}}, []InfoObjAddr{1001, 1002}, false},
+		{"collision", false, []SinglePointInfo{{Ioa: 1}, {Ioa: 1001}}, []InfoObjAddr{1, 1001}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &lastConn{}
+			if err := Single(c, tt.isSeq, CauseOfTransmission{Cause: Spontaneous}, 1, tt.infos...); err != nil {
+				t.Fatal(err)
+			}
+			a := c.a
+			if err := a.Readdress(m); (err != nil) != tt.wantErr {
+				t.Fatalf("Readdress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []InfoObjAddr
+			for _, v := range a.GetSinglePoint() {
+				got = append(got, v.Ioa)
+			}
+			if !reflect.DeepEqual(got, tt.wantIoas) {
+				t.Errorf("Readdress() ioas = %v, want %v", got, tt.wantIoas)
+			}
+		})
+	}
+}
+
+func TestIOAMap_Validate(t *testing.T) {
+	if err := (IOAMap{1: 10, 2: 10}).Validate(); err != (IOACollisionError{10}) {
+		t.Errorf("Validate() error = %v, want %v", err, IOACollisionError{10})
+	}
+	if err := (IOAMap{1: 2, 2: 1}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestASDU_Reencode(t *testing.T) {
+	narrow := &Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 2, InfoObjTimeZone: ParamsWide.InfoObjTimeZone}
+	c := &lastConn{}
+	infos := []MeasuredValueScaledInfo{{Ioa: 10, Value: 1}, {Ioa: 11, Value: -2}}
+	for _, seq := range []bool{false, true} {
+		if err := MeasuredValueScaled(c, seq, CauseOfTransmission{Cause: Spontaneous}, 12, infos...); err != nil {
+			t.Fatal(err)
+		}
+		c.a.OrigAddr = 3
+		r, err := c.a.Reencode(narrow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.OrigAddr != 0 || r.CommonAddr != 12 || r.Variable != c.a.Variable {
+			t.Errorf("Reencode() = %v", r.Identifier)
+		}
+		if got := r.GetMeasuredValueScaled(); !reflect.DeepEqual(got, infos) {
+			t.Errorf("Reencode() information = %+v, want %+v", got, infos)
+		}
+	}
+
+	_ = Single(c, false, CauseOfTransmission{Cause: Spontaneous}, 12, SinglePointInfo{Ioa: 70000})
+	if _, err := c.a.Reencode(narrow); err != ErrInfoObjAddrFit {
+		t.Errorf("Reencode() error = %v, want %v", err, ErrInfoObjAddrFit)
+	}
+	_ = Single(c, false, CauseOfTransmission{Cause: Spontaneous}, 255, SinglePointInfo{Ioa: 1})
+	if _, err := c.a.Reencode(narrow); err != ErrCommonAddrFit {
+		t.Errorf("Reencode() error = %v, want %v", err, ErrCommonAddrFit)
+	}
+}
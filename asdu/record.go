@@ -0,0 +1,99 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"time"
+)
+
+// recordVersion the version of the record format
+const recordVersion = 1
+
+// Record an asdu self-contained with its params, so that it can be stored, put into a message queue
+// or replayed without knowing the connection it was sent or received on. The ASDU UnmarshalBinary needs
+// the params in advance, the Record one restores them, except the Profile and Causes which are policies
+// of the connection rather than encoding parameters.
+//
+// record format
+//
+//	      | version | cause size | common address size | object address size | zone length | zone name | asdu |
+//	bytes |    1    |     1      |          1          |          1          |      1      |   [0,255] |      |
+type Record struct {
+	*ASDU
+}
+
+var (
+	_ encoding.BinaryMarshaler   = Record{}
+	_ encoding.BinaryUnmarshaler = (*Record)(nil)
+)
+
+// MarshalBinary honors the encoding.BinaryMarshaler interface.
+func (sf Record) MarshalBinary() ([]byte, error) {
+	if sf.ASDU == nil {
+		return nil, ErrParam
+	}
+	data, err := sf.ASDU.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	zone := time.UTC.String()
+	if sf.InfoObjTimeZone != nil {
+		zone = sf.InfoObjTimeZone.String()
+	}
+	if len(zone) > 255 {
+		return nil, fmt.Errorf("asdu: record time zone name %q too long", zone)
+	}
+	b := make([]byte, 0, 5+len(zone)+len(data))
+	b = append(b, recordVersion, byte(sf.CauseSize), byte(sf.CommonAddrSize), byte(sf.InfoObjAddrSize), byte(len(zone)))
+	b = append(b, zone...)
+	return append(b, data...), nil
+}
+
+// UnmarshalBinary honors the encoding.BinaryUnmarshaler interface.
+// A new asdu is always allocated with the params of the record.
+func (sf *Record) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 || len(data) < 5+int(data[4]) {
+		return io.EOF
+	}
+	if data[0] != recordVersion {
+		return fmt.Errorf("asdu: unknown record version %d", data[0])
+	}
+	loc, err := loadRecordZone(string(data[5 : 5+int(data[4])]))
+	if err != nil {
+		return err
+	}
+	p := &Params{
+		CauseSize:       int(data[1]),
+		CommonAddrSize:  int(data[2]),
+		InfoObjAddrSize: int(data[3]),
+		InfoObjTimeZone: loc,
+	}
+	if err = p.Valid(); err != nil {
+		return err
+	}
+	a := NewEmptyASDU(p)
+	if err = a.UnmarshalBinary(data[5+int(data[4]):]); err != nil {
+		return err
+	}
+	sf.ASDU = a
+	return nil
+}
+
+func loadRecordZone(name string) (*time.Location, error) {
+	switch name {
+	case "", "UTC":
+		return time.UTC, nil
+	case "Local":
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("asdu: record time zone: %w", err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,75 @@
+package asdu
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestASDU_MarshalBinary_decoded(t *testing.T) {
+	c := &lastConn{}
+	infos := []MeasuredValueScaledInfo{{Ioa: 1, Value: 10}, {Ioa: 2, Value: 20}}
+	if err := MeasuredValueScaled(c, false, CauseOfTransmission{Cause: Spontaneous}, 1, infos...); err != nil {
+		t.Fatal(err)
+	}
+	want, err := c.a.Clone().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append([]byte(nil), want...)
+
+	a := NewEmptyASDU(ParamsWide)
+	if err = a.UnmarshalBinary(want); err != nil {
+		t.Fatal(err)
+	}
+	a.DecodeInfoObjAddr() // partially decoded
+	got, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("MarshalBinary() = % x, want % x", got, want)
+	}
+
+	if err = (&ASDU{}).UnmarshalBinary(want); err != ErrParam {
+		t.Errorf("UnmarshalBinary() without params error %v, want %v", err, ErrParam)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	p := &Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 2, InfoObjTimeZone: time.UTC}
+	a := NewASDU(p, Identifier{
+		Type:       M_SP_TB_1,
+		Variable:   VariableStruct{Number: 1},
+		Coa:        CauseOfTransmission{Cause: Spontaneous},
+		CommonAddr: 7,
+	})
+	tm := time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)
+	_ = a.AppendInfoObjAddr(300)
+	a.AppendBytes(0x01).AppendCP56Time2a(tm, p.InfoObjTimeZone)
+
+	data, err := Record{a}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Record
+	if err = r.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if r.CauseSize != 1 || r.CommonAddrSize != 1 || r.InfoObjAddrSize != 2 || r.InfoObjTimeZone != time.UTC {
+		t.Errorf("UnmarshalBinary() params %+v", *r.Params)
+	}
+	if r.Identifier != a.Identifier {
+		t.Errorf("UnmarshalBinary() identifier %v, want %v", r.Identifier, a.Identifier)
+	}
+	got := r.GetSinglePoint()
+	if len(got) != 1 || got[0].Ioa != 300 || !got[0].Value || !got[0].Time.Equal(tm) {
+		t.Errorf("GetSinglePoint() = %+v", got)
+	}
+
+	for _, bad := range [][]byte{nil, data[:3], append([]byte{9}, data[1:]...)} {
+		if err = r.UnmarshalBinary(bad); err == nil {
+			t.Errorf("UnmarshalBinary(% x) want error", bad)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"io"
+)
+
+// Reencode returns a copy of the asdu encoded with the params p, for example in a gateway between
+// a system of 104 params and one of 101 params: the information object addresses are re-encoded
+// with the address size of p, the originator address is dropped if p has no room for it.
+// It fails with ErrCommonAddrFit or ErrInfoObjAddrFit if an address does not fit p, with
+// io.ErrUnexpectedEOF if the information objects are truncated.
+// The information objects decoded yet are included, like Mirror.
+func (sf *ASDU) Reencode(p *Params) (*ASDU, error) {
+	if err := p.Valid(); err != nil {
+		return nil, err
+	}
+	if sf.CommonAddr != GlobalCommonAddr && sf.CommonAddr != InvalidCommonAddr {
+		if err := p.ValidCommonAddr(sf.CommonAddr); err != nil || p.CommonAddrSize == 1 && sf.CommonAddr == 255 {
+			return nil, ErrCommonAddrFit
+		}
+	}
+	objSize, err := GetInfoObjSize(sf.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewASDU(p, sf.Identifier)
+	if p.CauseSize < 2 {
+		r.OrigAddr = 0
+	}
+	infoObj := sf.wholeInfoObj()
+	step := sf.InfoObjAddrSize + objSize
+	if sf.Variable.IsSequence {
+		step = len(infoObj) // only one address
+	}
+	for off := 0; off < len(infoObj); off += step {
+		if off+sf.InfoObjAddrSize > len(infoObj) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		var addr InfoObjAddr
+		for i := sf.InfoObjAddrSize - 1; i >= 0; i-- {
+			addr = addr<<8 | InfoObjAddr(infoObj[off+i])
+		}
+		if err = r.AppendInfoObjAddr(addr); err != nil {
+			return nil, err
+		}
+		r.infoObj = append(r.infoObj, infoObj[off+sf.InfoObjAddrSize:min(off+step, len(infoObj))]...)
+	}
+	return r, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+// StatusAndStatusChangeDetection layout, the status bits ST1 to ST16 in the low 16 bits,
+// the change detection bits CD1 to CD16 in the high 16 bits.
+// The bit n of the accessors is 0 for ST1 and CD1 to 15 for ST16 and CD16,
+// the out of range ones are always false and never set.
+
+// NewStatusAndStatusChangeDetection returns the status and status change detection of the status
+// and change detection masks, bit 0 of them for ST1 and CD1.
+func NewStatusAndStatusChangeDetection(status, changed uint16) StatusAndStatusChangeDetection {
+	return StatusAndStatusChangeDetection(uint32(changed)<<16 | uint32(status))
+}
+
+// StatusBits returns the status mask ST16..ST1
+func (sf StatusAndStatusChangeDetection) StatusBits() uint16 { return uint16(sf) }
+
+// ChangedBits returns the change detection mask CD16..CD1
+func (sf StatusAndStatusChangeDetection) ChangedBits() uint16 { return uint16(sf >> 16) }
+
+// Status returns the status bit n, ST(n+1)
+func (sf StatusAndStatusChangeDetection) Status(n int) bool {
+	return n >= 0 && n < 16 && sf&(1<<uint(n)) != 0
+}
+
+// Changed returns the change detection bit n, CD(n+1), which is set if the status bit changed
+// since the last report.
+func (sf StatusAndStatusChangeDetection) Changed(n int) bool {
+	return n >= 0 && n < 16 && sf&(1<<uint(n+16)) != 0
+}
+
+// SetStatus set or clear the status bit n
+func (sf *StatusAndStatusChangeDetection) SetStatus(n int, v bool) {
+	if n >= 0 && n < 16 {
+		sf.set(n, v)
+	}
+}
+
+// SetChanged set or clear the change detection bit n
+func (sf *StatusAndStatusChangeDetection) SetChanged(n int, v bool) {
+	if n >= 0 && n < 16 {
+		sf.set(n+16, v)
+	}
+}
+
+func (sf *StatusAndStatusChangeDetection) set(bit int, v bool) {
+	if v {
+		*sf |= 1 << uint(bit)
+	} else {
+		*sf &^= 1 << uint(bit)
+	}
+}
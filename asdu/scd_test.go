@@ -0,0 +1,33 @@
+package asdu
+
+import (
+	"testing"
+)
+
+func TestStatusAndStatusChangeDetection(t *testing.T) {
+	scd := NewStatusAndStatusChangeDetection(0x8001, 0x0002)
+	if !scd.Status(0) || !scd.Status(15) || scd.Status(1) || !scd.Changed(1) || scd.Changed(0) {
+		t.Errorf("bits of %#08x", uint32(scd))
+	}
+	if scd.Status(16) || scd.Changed(-1) {
+		t.Errorf("out of range bits set")
+	}
+
+	scd.SetStatus(1, true)
+	scd.SetStatus(15, false)
+	scd.SetChanged(1, false)
+	scd.SetChanged(15, true)
+	scd.SetStatus(16, true) // ignored, not CD1
+	if scd.StatusBits() != 0x0003 || scd.ChangedBits() != 0x8000 {
+		t.Errorf("StatusBits() %#04x ChangedBits() %#04x", scd.StatusBits(), scd.ChangedBits())
+	}
+
+	c := &lastConn{}
+	if err := PackedSinglePointWithSCD(c, false, CauseOfTransmission{Cause: Spontaneous}, 1,
+		PackedSinglePointWithSCDInfo{Ioa: 1, Scd: scd}); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.a.GetPackedSinglePointWithSCD(); got[0].Scd != scd {
+		t.Errorf("GetPackedSinglePointWithSCD() %#08x, want %#08x", uint32(got[0].Scd), uint32(scd))
+	}
+}
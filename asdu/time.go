@@ -20,13 +20,18 @@ import (
 
 // CP56Time2a time to CP56Time2a
 func CP56Time2a(t time.Time, loc *time.Location) []byte {
+	return appendCP56Time2a(make([]byte, 0, 7), t, loc)
+}
+
+// appendCP56Time2a append the 7 octets binary time to b
+func appendCP56Time2a(b []byte, t time.Time, loc *time.Location) []byte {
 	if loc == nil {
 		loc = time.UTC
 	}
 	ts := t.In(loc)
 	msec := ts.Nanosecond()/int(time.Millisecond) + ts.Second()*1000
-	return []byte{byte(msec), byte(msec >> 8), byte(ts.Minute()), byte(ts.Hour()),
-		byte(ts.Weekday()<<5) | byte(ts.Day()), byte(ts.Month()), byte(ts.Year() - 2000)}
+	return append(b, byte(msec), byte(msec>>8), byte(ts.Minute()), byte(ts.Hour()),
+		byte(ts.Weekday()<<5)|byte(ts.Day()), byte(ts.Month()), byte(ts.Year()-2000))
 }
 
 // ParseCP56Time2a 7 octets binary time, it is recommended to use UTC for all time stamps, read 7 bytes, return time
@@ -56,17 +61,27 @@ func ParseCP56Time2a(bytes []byte, loc *time.Location) time.Time {
 // CP24Time2a time to CP56Time2a 3 octets binary time, UTC is recommended for all time scales
 // See companion standard 101, subclass 7.2.6.19.
 func CP24Time2a(t time.Time, loc *time.Location) []byte {
+	return appendCP24Time2a(make([]byte, 0, 3), t, loc)
+}
+
+// appendCP24Time2a append the 3 octets binary time to b
+func appendCP24Time2a(b []byte, t time.Time, loc *time.Location) []byte {
 	if loc == nil {
 		loc = time.UTC
 	}
 	ts := t.In(loc)
 	msec := ts.Nanosecond()/int(time.Millisecond) + ts.Second()*1000
-	return []byte{byte(msec), byte(msec >> 8), byte(ts.Minute())}
+	return append(b, byte(msec), byte(msec>>8), byte(ts.Minute()))
 }
 
 // ParseCP24Time2a 3 octets binary time, it is recommended that all time scales use UTC, read 3 bytes, and return a time
 // See companion standard 101, subclass 7.2.6.19.
 func ParseCP24Time2a(bytes []byte, loc *time.Location) time.Time {
+	return parseCP24Time2aAt(bytes, loc, time.Now())
+}
+
+// parseCP24Time2aAt is like ParseCP24Time2a, the date and the hour are the ones of now
+func parseCP24Time2aAt(bytes []byte, loc *time.Location, now time.Time) time.Time {
 	if len(bytes) < 3 || bytes[2]&0x80 == 0x80 {
 		return time.Time{}
 	}
@@ -74,7 +89,6 @@ func ParseCP24Time2a(bytes []byte, loc *time.Location) time.Time {
 	msec := x % 1000
 	sec := (x / 1000)
 	min := int(bytes[2] & 0x3f)
-	now := time.Now()
 	year, month, day := now.Date()
 	hour, _, _ := now.Clock()
 
@@ -0,0 +1,132 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Time2a the binary time CP56Time2a or CP24Time2a field by field. Unlike time.Time it keeps the
+// invalid flag IV, the summer time flag SU and the day of week, so that a gateway forwards them unchanged.
+// The CP24Time2a only has the milliseconds, the minutes and the invalid flag.
+// See companion standard 101, subclass 7.2.6.18 and 7.2.6.19.
+type Time2a struct {
+	Msec    uint16 // milliseconds of the minute, 0-59999
+	Minute  byte   // 0-59
+	Invalid bool   // IV
+	Hour    byte   // 0-23
+	Summer  bool   // SU
+	Day     byte   // day of month, 1-31
+	Weekday byte   // day of week, 1-7 from Monday, 0 if not used
+	Month   byte   // 1-12
+	Year    byte   // 0-99
+}
+
+// NewTime2a returns the binary time of t in the location, the summer time flag is set
+// if the location is in daylight saving time at t.
+func NewTime2a(t time.Time, loc *time.Location) Time2a {
+	if loc == nil {
+		loc = time.UTC
+	}
+	ts := t.In(loc)
+	weekday := byte(ts.Weekday())
+	if weekday == 0 {
+		weekday = 7 // Sunday
+	}
+	return Time2a{
+		Msec:    uint16(ts.Nanosecond()/int(time.Millisecond) + ts.Second()*1000),
+		Minute:  byte(ts.Minute()),
+		Hour:    byte(ts.Hour()),
+		Summer:  ts.IsDST(),
+		Day:     byte(ts.Day()),
+		Weekday: weekday,
+		Month:   byte(ts.Month()),
+		Year:    byte(ts.Year() % 100),
+	}
+}
+
+// ParseTime2a parse the 7 octets CP56Time2a, or the 3 octets CP24Time2a if b is shorter,
+// it returns the zero value if b is shorter than 3 octets.
+func ParseTime2a(b []byte) Time2a {
+	if len(b) < 3 {
+		return Time2a{}
+	}
+	t := Time2a{
+		Msec:    binary.LittleEndian.Uint16(b),
+		Minute:  b[2] & 0x3f,
+		Invalid: b[2]&0x80 == 0x80,
+	}
+	if len(b) >= 7 {
+		t.Hour = b[3] & 0x1f
+		t.Summer = b[3]&0x80 == 0x80
+		t.Day = b[4] & 0x1f
+		t.Weekday = b[4] >> 5
+		t.Month = b[5] & 0x0f
+		t.Year = b[6] & 0x7f
+	}
+	return t
+}
+
+// CP56Time2a returns the 7 octets binary time
+func (sf Time2a) CP56Time2a() []byte {
+	return sf.appendCP56Time2a(make([]byte, 0, 7))
+}
+
+func (sf Time2a) appendCP56Time2a(b []byte) []byte {
+	b = sf.appendCP24Time2a(b)
+	hour := sf.Hour & 0x1f
+	if sf.Summer {
+		hour |= 0x80
+	}
+	return append(b, hour, sf.Weekday<<5|sf.Day&0x1f, sf.Month&0x0f, sf.Year&0x7f)
+}
+
+// CP24Time2a returns the 3 octets binary time
+func (sf Time2a) CP24Time2a() []byte {
+	return sf.appendCP24Time2a(make([]byte, 0, 3))
+}
+
+func (sf Time2a) appendCP24Time2a(b []byte) []byte {
+	minute := sf.Minute & 0x3f
+	if sf.Invalid {
+		minute |= 0x80
+	}
+	return append(b, byte(sf.Msec), byte(sf.Msec>>8), minute)
+}
+
+// Time returns the time in the location whatever the invalid flag is, the year is assumed to be in
+// the 21st century. The date and the hour of a CP24Time2a, which has no month, are the current ones.
+func (sf Time2a) Time(loc *time.Location) time.Time {
+	return sf.TimeAt(loc, time.Now())
+}
+
+// TimeAt is like Time, the date and the hour of a CP24Time2a are the ones of now,
+// such as Params.Now of the received asdu.
+func (sf Time2a) TimeAt(loc *time.Location, now time.Time) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	nsec := int(sf.Msec%1000) * int(time.Millisecond)
+	if sf.Month == 0 {
+		now = now.In(loc)
+		year, month, day := now.Date()
+		return time.Date(year, month, day, now.Hour(), int(sf.Minute), int(sf.Msec/1000), nsec, loc)
+	}
+	return time.Date(2000+int(sf.Year), time.Month(sf.Month), int(sf.Day), int(sf.Hour), int(sf.Minute), int(sf.Msec/1000), nsec, loc)
+}
+
+// AppendTime2a append the binary time as CP56Time2a to info object
+func (sf *ASDU) AppendTime2a(t Time2a) *ASDU {
+	sf.infoObj = t.appendCP56Time2a(sf.infoObj)
+	return sf
+}
+
+// DecodeTime2a decode info object byte to the binary time CP56Time2a field by field
+func (sf *ASDU) DecodeTime2a() Time2a {
+	t := ParseTime2a(sf.infoObj[:7])
+	sf.infoObj = sf.infoObj[7:]
+	return t
+}
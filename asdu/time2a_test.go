@@ -0,0 +1,38 @@
+package asdu
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTime2a(t *testing.T) {
+	// Sunday 2021-03-28 10:20:30.400 summer time, invalid
+	raw := []byte{0xc0, 0x76, 0x80 | 20, 0x80 | 10, 7<<5 | 28, 3, 21}
+	tm := ParseTime2a(raw)
+	want := Time2a{Msec: 30400, Minute: 20, Invalid: true, Hour: 10, Summer: true, Day: 28, Weekday: 7, Month: 3, Year: 21}
+	if tm != want {
+		t.Fatalf("ParseTime2a() = %+v, want %+v", tm, want)
+	}
+	if got := tm.CP56Time2a(); !bytes.Equal(got, raw) {
+		t.Errorf("CP56Time2a() = % x, want % x", got, raw)
+	}
+	if got := tm.CP24Time2a(); !bytes.Equal(got, raw[:3]) {
+		t.Errorf("CP24Time2a() = % x, want % x", got, raw[:3])
+	}
+	if got := ParseTime2a(raw[:3]); got != (Time2a{Msec: 30400, Minute: 20, Invalid: true}) {
+		t.Errorf("ParseTime2a() CP24Time2a = %+v", got)
+	}
+	if got := tm.Time(time.UTC); !got.Equal(time.Date(2021, 3, 28, 10, 20, 30, 4e8, time.UTC)) {
+		t.Errorf("Time() = %v", got)
+	}
+	if got := NewTime2a(tm.Time(time.UTC), time.UTC); got.Weekday != 7 || got.Invalid || got.Summer {
+		t.Errorf("NewTime2a() = %+v", got)
+	}
+
+	a := NewEmptyASDU(ParamsWide)
+	a.AppendTime2a(tm)
+	if got := a.DecodeTime2a(); got != tm {
+		t.Errorf("DecodeTime2a() = %+v, want %+v", got, tm)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package asdu
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeTagPolicy how the decoders treat the received time tags CP24Time2a and CP56Time2a which are flagged invalid,
+// or out of Params.TimeTagTolerance from the receive time.
+type TimeTagPolicy byte
+
+// time tag policy defined
+const (
+	// TimeTagZero the invalid time tags decode to the zero time, the default
+	TimeTagZero TimeTagPolicy = iota
+	// TimeTagPassThrough the time tags decode as they are whatever the invalid flag is
+	TimeTagPassThrough
+	// TimeTagSubstitute the invalid time tags are replaced by the receive time
+	TimeTagSubstitute
+	// TimeTagReject the asdu with any invalid time tag is rejected, see ASDU.CheckTimeTags
+	TimeTagReject
+)
+
+// TimeTagSize returns the size of the time tag of the type identification, 3 for CP24Time2a,
+// 7 for CP56Time2a, 0 for none.
+func TimeTagSize(t TypeID) int {
+	switch t {
+	case M_SP_TA_1, M_DP_TA_1, M_ST_TA_1, M_BO_TA_1, M_ME_TA_1, M_ME_TB_1, M_ME_TC_1, M_IT_TA_1,
+		M_EP_TA_1, M_EP_TB_1, M_EP_TC_1:
+		return 3
+	case M_SP_TB_1, M_DP_TB_1, M_ST_TB_1, M_BO_TB_1, M_ME_TD_1, M_ME_TE_1, M_ME_TF_1, M_IT_TB_1,
+		M_EP_TD_1, M_EP_TE_1, M_EP_TF_1,
+		C_SC_TA_1, C_DC_TA_1, C_RC_TA_1, C_SE_TA_1, C_SE_TB_1, C_SE_TC_1, C_BO_TA_1,
+		C_CS_NA_1, C_TS_TA_1:
+		return 7
+	}
+	return 0
+}
+
+// timeTagInvalid whether the time tag is flagged invalid or, if the tolerance is set, out of it from now
+func (sf *ASDU) timeTagInvalid(raw []byte, now time.Time) bool {
+	if raw[2]&0x80 == 0x80 {
+		return true
+	}
+	if sf.TimeTagTolerance <= 0 {
+		return false
+	}
+	d := ParseTime2a(raw).TimeAt(sf.InfoObjTimeZone, now).Sub(now)
+	return d > sf.TimeTagTolerance || d < -sf.TimeTagTolerance
+}
+
+// decodeTimeTag decode the time tag of size 3 (CP24Time2a) or 7 (CP56Time2a) under the TimeTagPolicy,
+// it returns the time and the invalid flag IV as received.
+func (sf *ASDU) decodeTimeTag(size int) (time.Time, bool) {
+	raw := sf.infoObj[:size]
+	sf.infoObj = sf.infoObj[size:]
+
+	iv := raw[2]&0x80 == 0x80
+	now := sf.Params.Now()
+	if sf.timeTagInvalid(raw, now) {
+		switch sf.TimeTagPolicy {
+		case TimeTagZero:
+			return time.Time{}, iv
+		case TimeTagSubstitute:
+			if sf.InfoObjTimeZone != nil {
+				now = now.In(sf.InfoObjTimeZone)
+			}
+			return now, iv
+		}
+	}
+	return ParseTime2a(raw).TimeAt(sf.InfoObjTimeZone, now), iv
+}
+
+// TimeTags returns the time tags of the information objects as received, with their invalid flag IV,
+// summer time flag SU and day of week. It does not consume the information objects, whether they
+// are decoded yet or not. It returns nil for the type identification without time tag.
+func (sf *ASDU) TimeTags() []Time2a {
+	size := TimeTagSize(sf.Type)
+	objSize, err := GetInfoObjSize(sf.Type)
+	if size == 0 || err != nil {
+		return nil
+	}
+	tags := make([]Time2a, 0, sf.Variable.Number)
+	for i, b := 0, sf.wholeInfoObj(); i < int(sf.Variable.Number); i++ {
+		if !sf.Variable.IsSequence || i == 0 {
+			b = b[min(sf.InfoObjAddrSize, len(b)):]
+		}
+		if len(b) < objSize {
+			break
+		}
+		tags = append(tags, ParseTime2a(b[objSize-size:objSize]))
+		b = b[objSize:]
+	}
+	return tags
+}
+
+// CheckTimeTags returns ErrInvalidTimeTag if the policy is TimeTagReject and any time tag of the information objects is
+// flagged invalid or out of the tolerance, nil otherwise.
+func (sf *ASDU) CheckTimeTags() error {
+	size := TimeTagSize(sf.Type)
+	if sf.TimeTagPolicy != TimeTagReject || size == 0 {
+		return nil
+	}
+	objSize, err := GetInfoObjSize(sf.Type)
+	if err != nil {
+		return err
+	}
+	now := sf.Params.Now()
+	for i, b := 0, sf.wholeInfoObj(); i < int(sf.Variable.Number); i++ {
+		if !sf.Variable.IsSequence || i == 0 {
+			b = b[min(sf.InfoObjAddrSize, len(b)):]
+		}
+		if len(b) < objSize {
+			return ErrLengthOutOfRange
+		}
+		if sf.timeTagInvalid(b[objSize-size:objSize], now) {
+			return fmt.Errorf("%w: %s object %d", ErrInvalidTimeTag, sf.Type, i)
+		}
+		b = b[objSize:]
+	}
+	return nil
+}
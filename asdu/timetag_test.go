@@ -0,0 +1,138 @@
+package asdu
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeTagPolicy(t *testing.T) {
+	tm := time.Now().Add(-time.Hour).Truncate(time.Millisecond).UTC()
+	data := func() []byte {
+		c := &lastConn{}
+		if err := SingleCP56Time2a(c, CauseOfTransmission{Cause: Spontaneous}, 1,
+			SinglePointInfo{Ioa: 1, Value: true, Time: tm}, SinglePointInfo{Ioa: 2, Time: tm}); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := c.a.MarshalBinary()
+		b = append([]byte(nil), b...)
+		b[ParamsWide.IdentifierSize()+ParamsWide.InfoObjAddrSize+1+2] |= 0x80 // first time tag invalid
+		return b
+	}()
+	decode := func(policy TimeTagPolicy, tolerance time.Duration) *ASDU {
+		p := *ParamsWide
+		p.TimeTagPolicy, p.TimeTagTolerance = policy, tolerance
+		a := NewEmptyASDU(&p)
+		if err := a.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	if got := decode(TimeTagZero, 0).GetSinglePoint(); !got[0].Time.IsZero() || !got[1].Time.Equal(tm) {
+		t.Errorf("TimeTagZero times %v, %v", got[0].Time, got[1].Time)
+	}
+	if got := decode(TimeTagPassThrough, 0).GetSinglePoint(); !got[0].Time.Equal(tm) || !got[1].Time.Equal(tm) {
+		t.Errorf("TimeTagPassThrough times %v, %v", got[0].Time, got[1].Time)
+	}
+	if got := decode(TimeTagSubstitute, 0).GetSinglePoint(); time.Since(got[0].Time) > time.Minute || !got[1].Time.Equal(tm) {
+		t.Errorf("TimeTagSubstitute times %v, %v", got[0].Time, got[1].Time)
+	}
+	if got := decode(TimeTagSubstitute, time.Minute).GetSinglePoint(); time.Since(got[1].Time) > time.Minute {
+		t.Errorf("TimeTagSubstitute out of tolerance time %v", got[1].Time)
+	}
+	// the invalid flag is the one received whatever the policy and the tolerance
+	for _, policy := range []TimeTagPolicy{TimeTagZero, TimeTagPassThrough, TimeTagSubstitute, TimeTagReject} {
+		if got := decode(policy, time.Minute).GetSinglePoint(); !got[0].TimeInvalid || got[1].TimeInvalid {
+			t.Errorf("policy %d TimeInvalid %v, %v, want true, false", policy, got[0].TimeInvalid, got[1].TimeInvalid)
+		}
+	}
+
+	if err := decode(TimeTagPassThrough, 0).CheckTimeTags(); err != nil {
+		t.Errorf("CheckTimeTags() error %v", err)
+	}
+	if err := decode(TimeTagReject, 0).CheckTimeTags(); !errors.Is(err, ErrInvalidTimeTag) {
+		t.Errorf("CheckTimeTags() error %v, want %v", err, ErrInvalidTimeTag)
+	}
+	c := &lastConn{}
+	if err := SingleCP56Time2a(c, CauseOfTransmission{Cause: Spontaneous}, 1, SinglePointInfo{Ioa: 1, Time: tm}); err != nil {
+		t.Fatal(err)
+	}
+	short := decode(TimeTagReject, 0)
+	short.Variable.Number = 2 // the second object truncated in its address
+	short.infoObj = append(c.a.infoObj, 0x02)
+	if err := short.CheckTimeTags(); !errors.Is(err, ErrLengthOutOfRange) {
+		t.Errorf("CheckTimeTags() error %v, want %v", err, ErrLengthOutOfRange)
+	}
+
+	a := decode(TimeTagZero, 0)
+	a.GetSinglePoint()
+	if tags := a.TimeTags(); len(tags) != 2 || !tags[0].Invalid || tags[1].Invalid || !tags[1].Time(time.UTC).Equal(tm) {
+		t.Errorf("TimeTags() = %+v", tags)
+	}
+}
+
+// fixedClock a Clock stopped at the time
+type fixedClock time.Time
+
+func (sf fixedClock) Now() time.Time               { return time.Time(sf) }
+func (sf fixedClock) NewTimer(time.Duration) Timer { return nil }
+
+func TestParams_Clock(t *testing.T) {
+	tm := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := &lastConn{}
+	if err := SingleCP56Time2a(c, CauseOfTransmission{Cause: Spontaneous}, 1,
+		SinglePointInfo{Ioa: 1, Time: tm}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := c.a.MarshalBinary()
+
+	now := tm.Add(30 * time.Second)
+	p := *ParamsWide
+	p.TimeTagPolicy, p.TimeTagTolerance, p.Clock = TimeTagSubstitute, time.Minute, fixedClock(now)
+	if !p.Now().Equal(now) {
+		t.Errorf("Now() = %v, want %v", p.Now(), now)
+	}
+	a := NewEmptyASDU(&p)
+	if err := a.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.GetSinglePoint(); !got[0].Time.Equal(tm) {
+		t.Errorf("time within the tolerance of the clock = %v, want %v", got[0].Time, tm)
+	}
+
+	p.Clock = fixedClock(tm.Add(time.Hour))
+	a = NewEmptyASDU(&p)
+	if err := a.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.GetSinglePoint(); !got[0].Time.Equal(tm.Add(time.Hour)) {
+		t.Errorf("time substituted = %v, want the clock time", got[0].Time)
+	}
+}
+
+func TestParams_ClockCP24Time2a(t *testing.T) {
+	tm := time.Date(2001, 2, 3, 4, 5, 6, 7e8, time.UTC) // far from the wall time
+	c := &lastConn{}
+	if err := SingleCP24Time2a(c, CauseOfTransmission{Cause: Spontaneous}, 1,
+		SinglePointInfo{Ioa: 1, Time: tm}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := c.a.MarshalBinary()
+
+	p := *ParamsWide
+	p.TimeTagPolicy, p.TimeTagTolerance, p.Clock = TimeTagReject, time.Minute, fixedClock(tm)
+	a := NewEmptyASDU(&p)
+	if err := a.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CheckTimeTags(); err != nil {
+		t.Errorf("CheckTimeTags() error %v, want the tag of the clock time accepted", err)
+	}
+	if got := a.GetSinglePoint(); !got[0].Time.Equal(tm) {
+		t.Errorf("time = %v, want %v", got[0].Time, tm)
+	}
+	if got := NewTime2a(tm, time.UTC); !ParseTime2a(got.CP24Time2a()).TimeAt(time.UTC, tm).Equal(tm) {
+		t.Errorf("TimeAt() = %v, want %v", ParseTime2a(got.CP24Time2a()).TimeAt(time.UTC, tm), tm)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package bridge maps monitor direction asdu to MQTT topics and command
+// topics back to control direction asdu. It depends on no MQTT library,
+// any client is plugged in through the Client interface. The payloads are
+// JSON, a Sparkplug B encoding is out of the scope of the package, it may be
+// given by Config.Encode.
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// DefaultTopic is the default template of the topic a point is published to.
+const DefaultTopic = "iec104/{{.CA}}/{{.Type}}/{{.IOA}}"
+
+// error defined
+var (
+	ErrNoCommand   = errors.New("bridge: no command mapped to the topic")
+	ErrCommandType = errors.New("bridge: command type not supported")
+)
+
+// Client is the MQTT client the bridge publishes and subscribes with.
+type Client interface {
+	Publish(topic string, payload []byte) error
+	Subscribe(topic string, handler func(topic string, payload []byte)) error
+}
+
+// Key identifies an information object.
+type Key struct {
+	CA  asdu.CommonAddr
+	IOA asdu.InfoObjAddr
+}
+
+// Command maps a command topic to a control direction asdu.
+type Command struct {
+	Topic string
+	CA    asdu.CommonAddr
+	IOA   asdu.InfoObjAddr
+	Type  asdu.TypeID // C_SC_NA_1, C_DC_NA_1, C_RC_NA_1, C_SE_NA_1, C_SE_NB_1, C_SE_NC_1 or C_BO_NA_1
+}
+
+// CommandPayload is the JSON payload of a command topic.
+type CommandPayload struct {
+	Value     json.RawMessage `json:"value"`
+	Select    bool            `json:"select,omitempty"`
+	Qualifier byte            `json:"qualifier,omitempty"` // QOC qualifier or QOS qualifier
+}
+
+// Config the bridge configuration.
+type Config struct {
+	// Topic is the text/template of the topic a point is published to, with
+	// the fields CA, IOA, Type (the name) and Cause, default DefaultTopic.
+	Topic string
+	// Topics overrides Topic for the information objects given.
+	Topics map[Key]string
+	// Encode encodes the payload of a point, default JSON.
+	Encode func(Point) ([]byte, error)
+	// Commands are the command topics subscribed.
+	Commands []Command
+}
+
+// Bridge between an IEC 60870-5-104 connection and an MQTT client.
+type Bridge struct {
+	client   Client
+	encode   func(Point) ([]byte, error)
+	topic    *template.Template
+	topics   map[Key]*template.Template
+	commands map[string]Command
+
+	mux   sync.Mutex
+	names map[topicKey]string // cache of the rendered topics
+}
+
+// New new a bridge publishing and subscribing with the client.
+func New(client Client, cfg Config) (*Bridge, error) {
+	if cfg.Topic == "" {
+		cfg.Topic = DefaultTopic
+	}
+	if cfg.Encode == nil {
+		cfg.Encode = func(p Point) ([]byte, error) { return json.Marshal(p) }
+	}
+	topic, err := template.New("topic").Parse(cfg.Topic)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bridge{
+		client:   client,
+		encode:   cfg.Encode,
+		topic:    topic,
+		topics:   make(map[Key]*template.Template, len(cfg.Topics)),
+		commands: make(map[string]Command, len(cfg.Commands)),
+		names:    make(map[topicKey]string),
+	}
+	for k, v := range cfg.Topics {
+		if b.topics[k], err = template.New("topic").Parse(v); err != nil {
+			return nil, err
+		}
+	}
+	for _, cmd := range cfg.Commands {
+		switch cmd.Type {
+		case asdu.C_SC_NA_1, asdu.C_DC_NA_1, asdu.C_RC_NA_1,
+			asdu.C_SE_NA_1, asdu.C_SE_NB_1, asdu.C_SE_NC_1, asdu.C_BO_NA_1:
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrCommandType, cmd.Type)
+		}
+		b.commands[cmd.Topic] = cmd
+	}
+	return b, nil
+}
+
+// Publish publishes every information object of the monitor direction asdu,
+// the asdu of other types are ignored.
+func (sf *Bridge) Publish(a *asdu.ASDU) error {
+	for _, p := range Points(a) {
+		topic, err := sf.topicOf(p)
+		if err != nil {
+			return err
+		}
+		payload, err := sf.encode(p)
+		if err != nil {
+			return err
+		}
+		if err = sf.client.Publish(topic, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ASDUHandler publishes the asdu, it fits the ASDUHandler of the client handlers.
+func (sf *Bridge) ASDUHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.Publish(a)
+}
+
+// topicKey the template data of a topic, the cache key of the rendered topics
+type topicKey struct {
+	Key
+	asdu.TypeID
+	asdu.Cause
+}
+
+func (sf *Bridge) topicOf(p Point) (string, error) {
+	k := topicKey{Key{p.CA, p.IOA}, p.Type, p.Cause}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if name, ok := sf.names[k]; ok {
+		return name, nil
+	}
+	t, ok := sf.topics[k.Key]
+	if !ok {
+		t = sf.topic
+	}
+	var sb strings.Builder
+	data := struct {
+		CA    asdu.CommonAddr
+		IOA   asdu.InfoObjAddr
+		Type  string
+		Cause asdu.Cause
+	}{p.CA, p.IOA, p.Type.Name(), p.Cause}
+	if err := t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	// the template data are the whole key, the topic does not depend on the value
+	sf.names[k] = sb.String()
+	return sb.String(), nil
+}
+
+// Subscribe subscribes the command topics, a message received is sent as
+// an activation over c. Errors of the messages are reported to onError if not nil.
+func (sf *Bridge) Subscribe(c asdu.Connect, onError func(topic string, err error)) error {
+	for topic := range sf.commands {
+		err := sf.client.Subscribe(topic, func(topic string, payload []byte) {
+			if err := sf.Command(c, topic, payload); err != nil && onError != nil {
+				onError(topic, err)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Command sends the command mapped to the topic with the payload over c.
+func (sf *Bridge) Command(c asdu.Connect, topic string, payload []byte) error {
+	cmd, ok := sf.commands[topic]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoCommand, topic)
+	}
+	var p CommandPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	coa := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	qoc := asdu.QualifierOfCommand{Qual: asdu.QOCQual(p.Qualifier), InSelect: p.Select}
+	qos := asdu.QualifierOfSetpointCmd{Qual: asdu.QOSQual(p.Qualifier), InSelect: p.Select}
+
+	switch cmd.Type {
+	case asdu.C_SC_NA_1:
+		var v bool
+		if err := json.Unmarshal(p.Value, &v); err != nil {
+			return err
+		}
+		return asdu.SingleCmd(c, cmd.Type, coa, cmd.CA, asdu.SingleCommandInfo{Ioa: cmd.IOA, Value: v, Qoc: qoc})
+	case asdu.C_DC_NA_1:
+		var v asdu.DoubleCommand
+		if err := json.Unmarshal(p.Value, &v); err != nil {
+			return err
+		}
+		return asdu.DoubleCmd(c, cmd.Type, coa, cmd.CA, asdu.DoubleCommandInfo{Ioa: cmd.IOA, Value: v, Qoc: qoc})
+	case asdu.C_RC_NA_1:
+		var v asdu.StepCommand
+		if err := json.Unmarshal(p.Value, &v); err != nil {
+			return err
+		}
+		return asdu.StepCmd(c, cmd.Type, coa, cmd.CA, asdu.StepCommandInfo{Ioa: cmd.IOA, Value: v, Qoc: qoc})
+	case asdu.C_SE_NA_1:
+		var v float64
+		if err := json.Unmarshal(p.Value, &v); err != nil {
+			return err
+		}
+		return asdu.SetpointCmdNormal(c, cmd.Type, coa, cmd.CA,
+			asdu.SetpointCommandNormalInfo{Ioa: cmd.IOA, Value: asdu.Normalize(math.Max(-32768, math.Min(32767, v*32768))), Qos: qos})
+	case asdu.C_SE_NB_1:
+		var v int16
+		if err := json.Unmarshal(p.Value, &v); err != nil {
+			return err
+		}
+		return asdu.SetpointCmdScaled(c, cmd.Type, coa, cmd.CA, asdu.SetpointCommandScaledInfo{Ioa: cmd.IOA, Value: v, Qos: qos})
+	case asdu.C_SE_NC_1:
+		var v float32
+		if err := json.Unmarshal(p.Value, &v); err != nil {
+			return err
+		}
+		return asdu.SetpointCmdFloat(c, cmd.Type, coa, cmd.CA, asdu.SetpointCommandFloatInfo{Ioa: cmd.IOA, Value: v, Qos: qos})
+	default: // C_BO_NA_1
+		var v uint32
+		if err := json.Unmarshal(p.Value, &v); err != nil {
+			return err
+		}
+		return asdu.BitsString32Cmd(c, cmd.Type, coa, cmd.CA, asdu.BitsString32CommandInfo{Ioa: cmd.IOA, Value: v})
+	}
+}
+
+// Point is a monitored information object, the JSON payload published.
+type Point struct {
+	CA      asdu.CommonAddr        `json:"ca"`
+	IOA     asdu.InfoObjAddr       `json:"ioa"`
+	Type    asdu.TypeID            `json:"-"`
+	Cause   asdu.Cause             `json:"cause"`
+	Value   interface{}            `json:"value"`
+	Quality asdu.QualityDescriptor `json:"quality"`
+	Time    time.Time              `json:"time,omitempty"`
+}
+
+// MarshalJSON the type identification is given by name, the time is omitted if zero.
+func (sf Point) MarshalJSON() ([]byte, error) {
+	type point Point
+	var t *time.Time
+	if !sf.Time.IsZero() {
+		t = &sf.Time
+	}
+	return json.Marshal(struct {
+		point
+		Type string     `json:"type"`
+		Time *time.Time `json:"time,omitempty"`
+	}{point(sf), sf.Type.Name(), t})
+}
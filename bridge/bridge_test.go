@@ -0,0 +1,170 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type message struct {
+	topic   string
+	payload []byte
+}
+
+type mockClient struct {
+	published []message
+	handlers  map[string]func(string, []byte)
+}
+
+func (sf *mockClient) Publish(topic string, payload []byte) error {
+	sf.published = append(sf.published, message{topic, payload})
+	return nil
+}
+
+func (sf *mockClient) Subscribe(topic string, handler func(string, []byte)) error {
+	if sf.handlers == nil {
+		sf.handlers = make(map[string]func(string, []byte))
+	}
+	sf.handlers[topic] = handler
+	return nil
+}
+
+// conn keeps the asdu sent
+type conn struct {
+	sent []*asdu.ASDU
+}
+
+func (sf *conn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *conn) UnderlyingConn() net.Conn { return nil }
+func (sf *conn) Send(a *asdu.ASDU) error {
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	r := asdu.NewEmptyASDU(asdu.ParamsWide)
+	if err = r.UnmarshalBinary(append([]byte(nil), raw...)); err != nil {
+		return err
+	}
+	sf.sent = append(sf.sent, r)
+	return nil
+}
+
+func TestBridge_Publish(t *testing.T) {
+	mc := &mockClient{}
+	b, err := New(mc, Config{Topics: map[Key]string{{1, 2}: "plant/breaker/{{.IOA}}"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &conn{}
+	if err = asdu.SingleCP56Time2a(c, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+		asdu.SinglePointInfo{Ioa: 1, Value: true, Time: tm},
+		asdu.SinglePointInfo{Ioa: 2, Qds: asdu.QDSInvalid, Time: tm}); err != nil {
+		t.Fatal(err)
+	}
+	a := c.sent[0]
+	if err = b.ASDUHandler(c, a); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.GetSinglePoint()) != 2 {
+		t.Error("Publish() consumed the information objects")
+	}
+
+	if len(mc.published) != 2 {
+		t.Fatalf("published %d messages, want 2", len(mc.published))
+	}
+	if got := mc.published[0].topic; got != "iec104/1/M_SP_TB_1/1" {
+		t.Errorf("topic = %s", got)
+	}
+	if got := mc.published[1].topic; got != "plant/breaker/2" {
+		t.Errorf("topic = %s", got)
+	}
+	var p map[string]interface{}
+	if err = json.Unmarshal(mc.published[1].payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p["type"] != "M_SP_TB_1" || p["value"] != false || p["quality"] != "IV" ||
+		p["cause"] != float64(asdu.Spontaneous) || p["time"] != "2020-01-01T00:00:00Z" {
+		t.Errorf("payload = %s", mc.published[1].payload)
+	}
+
+	// measured values are published without time
+	c.sent = nil
+	_ = asdu.MeasuredValueFloat(c, false, asdu.CauseOfTransmission{Cause: asdu.Periodic}, 1,
+		asdu.MeasuredValueFloatInfo{Ioa: 10, Value: 1.5})
+	if err = b.Publish(c.sent[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(mc.published[2].payload); got != `{"ca":1,"ioa":10,"cause":1,"value":1.5,"quality":"OK","type":"M_ME_NC_1"}` {
+		t.Errorf("payload = %s", got)
+	}
+}
+
+func TestBridge_TopicCause(t *testing.T) {
+	mc := &mockClient{}
+	b, err := New(mc, Config{Topic: "iec104/{{.CA}}/{{.IOA}}/{{.Cause}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &conn{}
+	for _, cause := range []asdu.Cause{asdu.Spontaneous, asdu.InterrogatedByStation, asdu.Spontaneous} {
+		_ = asdu.Single(c, false, asdu.CauseOfTransmission{Cause: cause}, 1, asdu.SinglePointInfo{Ioa: 1})
+	}
+	for _, a := range c.sent {
+		if err = b.Publish(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"iec104/1/1/3", "iec104/1/1/20", "iec104/1/1/3"}
+	for i, m := range mc.published {
+		if m.topic != want[i] {
+			t.Errorf("topic %d = %s, want %s", i, m.topic, want[i])
+		}
+	}
+}
+
+func TestBridge_Command(t *testing.T) {
+	mc := &mockClient{}
+	b, err := New(mc, Config{Commands: []Command{
+		{Topic: "cmd/breaker", CA: 1, IOA: 100, Type: asdu.C_SC_NA_1},
+		{Topic: "cmd/setpoint", CA: 1, IOA: 200, Type: asdu.C_SE_NA_1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &conn{}
+	var errs []error
+	if err = b.Subscribe(c, func(_ string, err error) { errs = append(errs, err) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(mc.handlers) != 2 {
+		t.Fatalf("subscribed %d topics, want 2", len(mc.handlers))
+	}
+
+	mc.handlers["cmd/breaker"]("cmd/breaker", []byte(`{"value":true,"select":true}`))
+	mc.handlers["cmd/setpoint"]("cmd/setpoint", []byte(`{"value":1}`))
+	mc.handlers["cmd/setpoint"]("cmd/setpoint", []byte(`{"value":"on"}`))
+	if len(c.sent) != 2 || len(errs) != 1 {
+		t.Fatalf("sent %d asdu with %d errors, want 2 and 1", len(c.sent), len(errs))
+	}
+	if a := c.sent[0]; a.Type != asdu.C_SC_NA_1 || a.Coa.Cause != asdu.Activation {
+		t.Errorf("sent %v", a.Identifier)
+	} else if cmd := a.GetSingleCmd(); cmd.Ioa != 100 || !cmd.Value || !cmd.Qoc.InSelect {
+		t.Errorf("sent %+v", cmd)
+	}
+	if cmd := c.sent[1].GetSetpointNormalCmd(); cmd.Ioa != 200 || cmd.Value != 32767 {
+		t.Errorf("sent %+v, want the normalized value clamped", cmd)
+	}
+
+	if err = b.Command(c, "cmd/unknown", []byte(`{}`)); !errors.Is(err, ErrNoCommand) {
+		t.Errorf("Command() error = %v, want %v", err, ErrNoCommand)
+	}
+	if _, err = New(mc, Config{Commands: []Command{{Topic: "x", Type: asdu.M_SP_NA_1}}}); !errors.Is(err, ErrCommandType) {
+		t.Errorf("New() error = %v, want %v", err, ErrCommandType)
+	}
+}
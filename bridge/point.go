@@ -0,0 +1,69 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package bridge
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Points returns the information objects of a monitor direction asdu,
+// nothing for the other types. The asdu is left unchanged.
+func Points(a *asdu.ASDU) []Point {
+	u := a.Clone()
+	id := Point{CA: u.CommonAddr, Type: u.Type, Cause: u.Coa.Cause}
+	var points []Point
+	add := func(ioa asdu.InfoObjAddr, value interface{}, qds asdu.QualityDescriptor, info Point) {
+		info.IOA, info.Value, info.Quality = ioa, value, qds
+		points = append(points, info)
+	}
+
+	switch u.Type {
+	case asdu.M_SP_NA_1, asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		for _, v := range u.GetSinglePoint() {
+			id.Time = v.Time
+			add(v.Ioa, v.Value, v.Qds, id)
+		}
+	case asdu.M_DP_NA_1, asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		for _, v := range u.GetDoublePoint() {
+			id.Time = v.Time
+			add(v.Ioa, byte(v.Value), v.Qds, id)
+		}
+	case asdu.M_ST_NA_1, asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		for _, v := range u.GetStepPosition() {
+			id.Time = v.Time
+			add(v.Ioa, v.Value.Val, v.Qds, id)
+		}
+	case asdu.M_BO_NA_1, asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		for _, v := range u.GetBitString32() {
+			id.Time = v.Time
+			add(v.Ioa, v.Value, v.Qds, id)
+		}
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1:
+		for _, v := range u.GetMeasuredValueNormal() {
+			id.Time = v.Time
+			add(v.Ioa, v.Value.Float64(), v.Qds, id)
+		}
+	case asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		for _, v := range u.GetMeasuredValueScaled() {
+			id.Time = v.Time
+			add(v.Ioa, v.Value, v.Qds, id)
+		}
+	case asdu.M_ME_NC_1, asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		for _, v := range u.GetMeasuredValueFloat() {
+			id.Time = v.Time
+			add(v.Ioa, v.Value, v.Qds, id)
+		}
+	case asdu.M_IT_NA_1, asdu.M_IT_TA_1, asdu.M_IT_TB_1:
+		for _, v := range u.GetIntegratedTotals() {
+			id.Time = v.Time
+			qds := asdu.QDSGood
+			if v.Value.IsInvalid {
+				qds = asdu.QDSInvalid
+			}
+			add(v.Ioa, v.Value.CounterReading, qds, id)
+		}
+	}
+	return points
+}
@@ -23,6 +23,8 @@ type Clog struct {
 	provider LogProvider
 	// is log output enabled,1: enable, 0: disable
 	has uint32
+	// language of the descriptions in the log messages, empty means default
+	lang string
 }
 
 // NewLogger Create a new log with the specified prefix
@@ -32,6 +34,7 @@ func NewLogger(prefix string) Clog {
 			log.New(os.Stdout, prefix, log.LstdFlags|log.Lmicroseconds),
 		},
 		0,
+		"",
 	}
 }
 
@@ -51,6 +54,17 @@ func (sf *Clog) SetLogProvider(p LogProvider) {
 	}
 }
 
+// SetLanguage set the language of the descriptions in the log messages, for example "en", "zh".
+// see asdu.LookupCatalog
+func (sf *Clog) SetLanguage(lang string) {
+	sf.lang = lang
+}
+
+// Language returns the language of the descriptions in the log messages
+func (sf Clog) Language() string {
+	return sf.lang
+}
+
 // Critical Log CRITICAL level message.
 func (sf Clog) Critical(format string, v ...interface{}) {
 	if atomic.LoadUint32(&sf.has) == 1 {
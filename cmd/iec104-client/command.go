@@ -0,0 +1,142 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+// parse the command line into the function sending it
+func parse(ca asdu.CommonAddr, args []string) (func(c *cs104.Client) error, error) {
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	name, args := args[0], args[1:]
+	sel := len(args) > 0 && args[len(args)-1] == "select"
+	if sel {
+		args = args[:len(args)-1]
+	}
+
+	switch name {
+	case "gi":
+		qoi := asdu.QOIStation
+		if len(args) > 0 {
+			v, err := strconv.ParseUint(args[0], 0, 8)
+			if err != nil {
+				return nil, fmt.Errorf("gi: qoi %w", err)
+			}
+			qoi = asdu.QualifierOfInterrogation(v)
+		}
+		return func(c *cs104.Client) error {
+			return c.InterrogationCmd(act, ca, qoi)
+		}, nil
+
+	case "ci":
+		return func(c *cs104.Client) error {
+			return c.CounterInterrogationCmd(act, ca, asdu.QualifierCountCall{Request: asdu.QCCTotal, Freeze: asdu.QCCFrzRead})
+		}, nil
+
+	case "sync":
+		return func(c *cs104.Client) error {
+			return c.ClockSynchronizationCmd(act, ca, time.Now())
+		}, nil
+
+	case "read", "sc", "dc", "se":
+	default:
+		return nil, fmt.Errorf("unknown command %q, see help", name)
+	}
+
+	if len(args) < 1 {
+		return nil, fmt.Errorf("%s: missing ioa, see help", name)
+	}
+	v, err := strconv.ParseUint(args[0], 0, 24)
+	if err != nil {
+		return nil, fmt.Errorf("%s: ioa %w", name, err)
+	}
+	ioa := asdu.InfoObjAddr(v)
+	qoc := asdu.QualifierOfCommand{InSelect: sel}
+	qos := asdu.QualifierOfSetpointCmd{InSelect: sel}
+
+	switch name {
+	case "sc", "dc":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return nil, fmt.Errorf("%s: want <ioa> on|off [select]", name)
+		}
+		on := args[1] == "on"
+		if name == "sc" {
+			cmd := asdu.SingleCommandInfo{Ioa: ioa, Value: on, Qoc: qoc}
+			return func(c *cs104.Client) error {
+				return asdu.SingleCmd(c, asdu.C_SC_NA_1, act, ca, cmd)
+			}, nil
+		}
+		cmd := asdu.DoubleCommandInfo{Ioa: ioa, Value: asdu.DCOOff, Qoc: qoc}
+		if on {
+			cmd.Value = asdu.DCOOn
+		}
+		return func(c *cs104.Client) error {
+			return asdu.DoubleCmd(c, asdu.C_DC_NA_1, act, ca, cmd)
+		}, nil
+
+	case "se":
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("se: want <ioa> <value> [normal|scaled|float] [select]")
+		}
+		f, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("se: value %w", err)
+		}
+		kind := "float"
+		if len(args) == 3 {
+			kind = args[2]
+		}
+		switch kind {
+		case "normal":
+			if f < -1 || f >= 1 {
+				return nil, fmt.Errorf("se: normalized value %v out of [-1, 1)", f)
+			}
+			cmd := asdu.SetpointCommandNormalInfo{Ioa: ioa, Value: asdu.Normalize(math.Round(f * 32768)), Qos: qos}
+			return func(c *cs104.Client) error {
+				return asdu.SetpointCmdNormal(c, asdu.C_SE_NA_1, act, ca, cmd)
+			}, nil
+		case "scaled":
+			if f < math.MinInt16 || f > math.MaxInt16 {
+				return nil, fmt.Errorf("se: scaled value %v out of range", f)
+			}
+			cmd := asdu.SetpointCommandScaledInfo{Ioa: ioa, Value: int16(math.Round(f)), Qos: qos}
+			return func(c *cs104.Client) error {
+				return asdu.SetpointCmdScaled(c, asdu.C_SE_NB_1, act, ca, cmd)
+			}, nil
+		case "float":
+			cmd := asdu.SetpointCommandFloatInfo{Ioa: ioa, Value: float32(f), Qos: qos}
+			return func(c *cs104.Client) error {
+				return asdu.SetpointCmdFloat(c, asdu.C_SE_NC_1, act, ca, cmd)
+			}, nil
+		}
+		return nil, fmt.Errorf("se: unknown setpoint kind %q", kind)
+	}
+	return func(c *cs104.Client) error {
+		return c.ReadCmd(asdu.CauseOfTransmission{Cause: asdu.Request}, ca, ioa)
+	}, nil
+}
+
+// terminated reports whether the asdu ends the command
+func terminated(name string, a *asdu.ASDU) bool {
+	if a.Coa.IsNegative {
+		return true
+	}
+	switch name {
+	case "gi", "ci", "sc", "dc", "se":
+		return a.Coa.Cause == asdu.ActivationTerm
+	case "read":
+		return a.Coa.Cause == asdu.Request
+	case "sync":
+		return a.Type == asdu.C_CS_NA_1 && a.Coa.Cause == asdu.ActivationCon
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Command iec104-client is an IEC 60870-5-104 controlling station for
+// interrogation and commanding from the command line.
+//
+// Usage:
+//
+//	iec104-client [flags] [command [args...]]
+//
+// With a command it runs the command, prints the responses and exits once
+// the command is terminated or -wait elapses. Without a command it reads
+// the commands from the standard input, one per line, until quit.
+//
+// The commands:
+//
+//	gi [qoi]                              general interrogation, qoi 20 by default
+//	ci                                    counter interrogation
+//	read <ioa>                            read command
+//	sc <ioa> on|off [select]              single command
+//	dc <ioa> on|off [select]              double command
+//	se <ioa> <value> [normal|scaled|float] [select]
+//	                                      setpoint command, float by default
+//	sync                                  clock synchronization
+//	help                                  print the commands
+//	quit                                  exit
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2404", "address of the controlled station")
+	ca := flag.Uint("ca", 1, "common address of the station")
+	wait := flag.Duration("wait", 5*time.Second, "time to wait for the responses of a command given in the arguments")
+	verbose := flag.Bool("v", false, "log the protocol")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [command [args...]]\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, *addr, asdu.CommonAddr(*ca), *wait, *verbose, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, addr string, ca asdu.CommonAddr, wait time.Duration, verbose bool, args []string) error {
+	out := newTable(os.Stdout)
+	option := cs104.NewOption()
+	if err := option.AddRemoteServer(addr); err != nil {
+		return err
+	}
+	client := cs104.NewClient(&handler{out}, option)
+	client.LogMode(verbose)
+	client.SetOnConnectHandler(func(c *cs104.Client) {
+		c.SendStartDt()
+	})
+	if err := client.StartContext(ctx); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if len(args) > 0 {
+		done := out.expect(args[0])
+		cctx, cancel := context.WithTimeout(ctx, wait)
+		defer cancel()
+		if err := execute(cctx, client, ca, args); err != nil {
+			return err
+		}
+		select {
+		case <-done:
+		case <-cctx.Done():
+		}
+		return nil
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			args := strings.Fields(line)
+			if len(args) == 0 {
+				continue
+			}
+			switch args[0] {
+			case "quit", "exit":
+				return nil
+			case "help":
+				fmt.Print(usage)
+				continue
+			}
+			cctx, cancel := context.WithTimeout(ctx, wait)
+			err := execute(cctx, client, ca, args)
+			cancel()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	}
+}
+
+const usage = `commands:
+  gi [qoi]                               general interrogation, qoi 20 by default
+  ci                                     counter interrogation
+  read <ioa>                             read command
+  sc <ioa> on|off [select]               single command
+  dc <ioa> on|off [select]               double command
+  se <ioa> <value> [normal|scaled|float] [select]
+                                         setpoint command, float by default
+  sync                                   clock synchronization
+  help                                   print the commands
+  quit                                   exit
+`
+
+// execute sends the command, retrying while the data transfer is not started yet.
+func execute(ctx context.Context, c *cs104.Client, ca asdu.CommonAddr, args []string) error {
+	send, err := parse(ca, args)
+	if err != nil {
+		return err
+	}
+	for {
+		err = send(c)
+		if !errors.Is(err, cs104.ErrNotActive) && !errors.Is(err, cs104.ErrUseClosedConnection) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", args[0], err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
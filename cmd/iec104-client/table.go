@@ -0,0 +1,140 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+	"github.com/rob-gra/go-iecp5/model"
+)
+
+// table prints the asdu received, a row per information object
+type table struct {
+	mux    sync.Mutex
+	w      *tabwriter.Writer
+	header bool
+	name   string // of the command expected
+	done   chan struct{}
+}
+
+func newTable(w io.Writer) *table {
+	return &table{w: tabwriter.NewWriter(w, 8, 0, 2, ' ', 0)}
+}
+
+// expect returns the channel closed once the command named is terminated
+func (sf *table) expect(name string) <-chan struct{} {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	sf.name, sf.done = name, make(chan struct{})
+	return sf.done
+}
+
+func (sf *table) print(a *asdu.ASDU) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if !sf.header {
+		sf.header = true
+		fmt.Fprintln(sf.w, "CA\tIOA\tTYPE\tCAUSE\tVALUE\tQUALITY\tTIME")
+	}
+	points := model.FromASDU(a)
+	for _, p := range points {
+		tm := ""
+		if !p.Time.IsZero() {
+			tm = p.Time.Format(time.RFC3339Nano)
+		}
+		fmt.Fprintf(sf.w, "%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			p.Station, p.Index, a.Type.Name(), causeName(a.Coa), value(p), p.Quality, tm)
+	}
+	if len(points) == 0 { // control direction, the confirmations
+		fmt.Fprintf(sf.w, "%d\t-\t%s\t%s\t\t\t\n", a.CommonAddr, a.Type.Name(), causeName(a.Coa))
+	}
+	_ = sf.w.Flush()
+
+	if sf.done != nil && terminated(sf.name, a) {
+		close(sf.done)
+		sf.done = nil
+	}
+}
+
+// causeName returns the cause of transmission, such as ActivationCon,neg
+func causeName(coa asdu.CauseOfTransmission) string {
+	return strings.TrimSuffix(strings.TrimPrefix(coa.String(), "COT<"), ">")
+}
+
+var doubleStates = [...]string{"intermediate", "off", "on", "indeterminate"}
+
+func value(p model.Point) string {
+	switch p.Kind {
+	case model.Binary:
+		if p.Bool() {
+			return "on"
+		}
+		return "off"
+	case model.DoubleBinary:
+		return doubleStates[p.DoubleState()]
+	case model.BitString:
+		return fmt.Sprintf("0x%08x", uint32(p.Value))
+	}
+	return fmt.Sprint(p.Value)
+}
+
+// handler prints every asdu received
+type handler struct {
+	out *table
+}
+
+var _ cs104.ClientHandlerInterface = (*handler)(nil)
+
+func (sf *handler) InterrogationHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) CounterInterrogationHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) ReadHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) TestCommandHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) ClockSyncHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) ResetProcessHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) DelayAcquisitionHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) ASDUHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.out.print(a)
+	return nil
+}
+
+func (sf *handler) ASDUHandlerAll(_ asdu.Connect, a *asdu.ASDU, _ *cs104.Server, _ int) error {
+	sf.out.print(a)
+	return nil
+}
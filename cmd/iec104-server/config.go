@@ -0,0 +1,149 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Config the configuration of the simulated outstation.
+type Config struct {
+	Addr       string          `json:"addr"` // listen address, default ":2404"
+	CommonAddr asdu.CommonAddr `json:"ca"`   // default 1
+	Points     []PointConfig   `json:"points"`
+	Commands   []CommandConfig `json:"commands"`
+}
+
+// PointConfig a monitored point.
+type PointConfig struct {
+	IOA     asdu.InfoObjAddr       `json:"ioa"`
+	Type    TypeName               `json:"type"`
+	Value   float64                `json:"value"` // initial value
+	Quality asdu.QualityDescriptor `json:"quality,omitempty"`
+	Change  *Change                `json:"change,omitempty"`
+}
+
+// Change how the value of a point changes by itself.
+type Change struct {
+	Period Duration `json:"period"`
+	// Mode is one of:
+	//	toggle: a binary point is inverted, a double point switches on and off
+	//	random: a random value in [Min, Max]
+	//	ramp: Step is added, back to Min once over Max
+	Mode     string  `json:"mode"`
+	Min      float64 `json:"min,omitempty"`
+	Max      float64 `json:"max,omitempty"`
+	Step     float64 `json:"step,omitempty"`
+	Periodic bool    `json:"periodic,omitempty"` // sent with the cause periodic instead of spontaneous
+}
+
+// CommandConfig a command accepted by the outstation.
+type CommandConfig struct {
+	IOA  asdu.InfoObjAddr `json:"ioa"`
+	Type TypeName         `json:"type"`
+	// Response is one of:
+	//	accept: positive confirmation then termination, the default
+	//	reject: negative confirmation
+	//	delay: positive confirmation then termination after Delay
+	Response string   `json:"response,omitempty"`
+	Delay    Duration `json:"delay,omitempty"`
+	// Feedback is the address of the point the value of an executed command
+	// is written to and sent spontaneously, zero if none.
+	Feedback asdu.InfoObjAddr `json:"feedback,omitempty"`
+}
+
+// TypeName a type identification given by name, such as M_SP_NA_1.
+type TypeName asdu.TypeID
+
+// UnmarshalText implement encoding.TextUnmarshaler
+func (sf *TypeName) UnmarshalText(text []byte) error {
+	id, err := asdu.ParseTypeID(string(text))
+	if err != nil {
+		return fmt.Errorf("unknown type identification %q", text)
+	}
+	*sf = TypeName(id)
+	return nil
+}
+
+// Duration a time.Duration given as a string, such as 1.5s.
+type Duration time.Duration
+
+// UnmarshalText implement encoding.TextUnmarshaler
+func (sf *Duration) UnmarshalText(text []byte) error {
+	d, err := time.ParseDuration(string(text))
+	*sf = Duration(d)
+	return err
+}
+
+// LoadConfig loads the JSON configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{Addr: ":2404", CommonAddr: 1}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err = cfg.Valid(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Valid checks the configuration.
+func (sf *Config) Valid() error {
+	points := make(map[asdu.InfoObjAddr]bool, len(sf.Points))
+	for _, p := range sf.Points {
+		if kindOf(asdu.TypeID(p.Type)) == 0 {
+			return fmt.Errorf("point %d: %s is not a monitored point type", p.IOA, asdu.TypeID(p.Type).Name())
+		}
+		if points[p.IOA] {
+			return fmt.Errorf("point %d: duplicate address", p.IOA)
+		}
+		points[p.IOA] = true
+		if c := p.Change; c != nil {
+			if c.Period <= 0 {
+				return fmt.Errorf("point %d: change period must be positive", p.IOA)
+			}
+			switch c.Mode {
+			case "toggle", "random", "ramp":
+			default:
+				return fmt.Errorf("point %d: unknown change mode %q", p.IOA, c.Mode)
+			}
+			switch asdu.TypeID(p.Type) {
+			case asdu.M_ME_NA_1, asdu.M_ME_NB_1, asdu.M_ME_NC_1, asdu.M_ME_ND_1:
+			default:
+				if c.Periodic {
+					return fmt.Errorf("point %d: periodic is for the measured values without time tag", p.IOA)
+				}
+			}
+		}
+	}
+	for _, c := range sf.Commands {
+		switch asdu.TypeID(c.Type) {
+		case asdu.C_SC_NA_1, asdu.C_DC_NA_1, asdu.C_RC_NA_1, asdu.C_SE_NA_1, asdu.C_SE_NB_1, asdu.C_SE_NC_1, asdu.C_BO_NA_1:
+		default:
+			return fmt.Errorf("command %d: %s is not a command type", c.IOA, asdu.TypeID(c.Type).Name())
+		}
+		switch c.Response {
+		case "", "accept", "reject", "delay":
+		default:
+			return fmt.Errorf("command %d: unknown response %q", c.IOA, c.Response)
+		}
+		if c.Feedback != 0 && !points[c.Feedback] {
+			return fmt.Errorf("command %d: feedback point %d not configured", c.IOA, c.Feedback)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Command iec104-server simulates an IEC 60870-5-104 controlled station
+// after a JSON configuration of its points and commands, see example.json.
+//
+// Usage:
+//
+//	iec104-server -config points.json [-addr :2404] [-pprof localhost:6060] [-v]
+//
+// The simulator answers the station and counter interrogations, the read
+// and the clock synchronization commands. The points with a change rate
+// change by themselves and are sent spontaneously. The commands configured
+// are confirmed, rejected or terminated after a delay, and write their
+// value to a feedback point which is sent as return information.
+//
+// With -pprof the runtime profiles are served over HTTP at /debug/pprof/,
+// for example go tool pprof http://localhost:6060/debug/pprof/profile.
+//
+// The configuration is JSON only, the module has no YAML dependency.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"os/signal"
+
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+func main() {
+	path := flag.String("config", "", "JSON configuration file of the points and commands")
+	addr := flag.String("addr", "", "listen address, overrides the configuration")
+	profile := flag.String("pprof", "", "address serving the runtime profiles, none by default")
+	verbose := flag.Bool("v", false, "log the protocol")
+	flag.Parse()
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := LoadConfig(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *addr != "" {
+		cfg.Addr = *addr
+	}
+
+	if *profile != "" {
+		go func() {
+			if err := http.ListenAndServe(*profile, nil); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sim := newSimulator(cfg)
+	srv := cs104.NewServer(sim)
+	sim.conn = srv
+	srv.SetCommonAddrs(cfg.CommonAddr).SetPointStore(sim.store).SetCommandHandler(sim)
+	srv.LogMode(*verbose)
+
+	go sim.run(ctx)
+	if err = srv.ListenAndServerContext(ctx, cfg.Addr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
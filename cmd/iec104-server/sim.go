@@ -0,0 +1,386 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+	"github.com/rob-gra/go-iecp5/model"
+)
+
+// point a simulated monitored point
+type point struct {
+	typeID asdu.TypeID
+	change *Change
+	model.Point
+}
+
+// simulator the process image of the outstation, it answers the
+// interrogations and the commands, and changes the points by itself.
+type simulator struct {
+	ca       asdu.CommonAddr
+	conn     asdu.Connect // the changes are sent to, the server broadcasting them
+	store    *cs104.MemPointStore
+	commands map[asdu.InfoObjAddr]CommandConfig
+
+	mux    sync.Mutex
+	points []*point // in the configured order
+	index  map[asdu.InfoObjAddr]*point
+	rand   *rand.Rand
+}
+
+var (
+	_ cs104.ServerHandlerInterface = (*simulator)(nil)
+	_ cs104.ServerCommandHandler   = (*simulator)(nil)
+)
+
+func newSimulator(cfg *Config) *simulator {
+	sf := &simulator{
+		ca:       cfg.CommonAddr,
+		store:    cs104.NewMemPointStore(),
+		commands: make(map[asdu.InfoObjAddr]CommandConfig, len(cfg.Commands)),
+		index:    make(map[asdu.InfoObjAddr]*point, len(cfg.Points)),
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	now := time.Now()
+	for _, pc := range cfg.Points {
+		p := &point{asdu.TypeID(pc.Type), pc.Change, model.Point{
+			Station: uint16(cfg.CommonAddr),
+			Index:   uint32(pc.IOA),
+			Kind:    kindOf(asdu.TypeID(pc.Type)),
+			Value:   pc.Value,
+			Quality: model.FromQDS(pc.Quality),
+			Time:    now,
+		}}
+		sf.points = append(sf.points, p)
+		sf.index[pc.IOA] = p
+		sf.save(p)
+	}
+	for _, c := range cfg.Commands {
+		sf.commands[c.IOA] = c
+	}
+	return sf
+}
+
+// kindOf returns the kind of the point of the monitor type identification, zero if none
+func kindOf(t asdu.TypeID) model.Kind {
+	switch t {
+	case asdu.M_SP_NA_1, asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		return model.Binary
+	case asdu.M_DP_NA_1, asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		return model.DoubleBinary
+	case asdu.M_ST_NA_1, asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		return model.Step
+	case asdu.M_BO_NA_1, asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		return model.BitString
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1,
+		asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1,
+		asdu.M_ME_NC_1, asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		return model.Analog
+	case asdu.M_IT_NA_1, asdu.M_IT_TA_1, asdu.M_IT_TB_1:
+		return model.Counter
+	}
+	return 0
+}
+
+// untimed returns the type identification without time tag of the monitor type identification
+func untimed(t asdu.TypeID) asdu.TypeID {
+	switch t {
+	case asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		return asdu.M_SP_NA_1
+	case asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		return asdu.M_DP_NA_1
+	case asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		return asdu.M_ST_NA_1
+	case asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		return asdu.M_BO_NA_1
+	case asdu.M_ME_TA_1, asdu.M_ME_TD_1:
+		return asdu.M_ME_NA_1
+	case asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		return asdu.M_ME_NB_1
+	case asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		return asdu.M_ME_NC_1
+	case asdu.M_IT_TA_1, asdu.M_IT_TB_1:
+		return asdu.M_IT_NA_1
+	}
+	return t
+}
+
+// save the point in the point store answering the read command, the
+// counters are not readable.
+func (sf *simulator) save(p *point) {
+	var info interface{}
+	switch p.typeID {
+	case asdu.M_SP_NA_1, asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		info = model.ToSinglePoint(p.Point)
+	case asdu.M_DP_NA_1, asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		info = model.ToDoublePoint(p.Point)
+	case asdu.M_ST_NA_1, asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		info = model.ToStepPosition(p.Point)
+	case asdu.M_BO_NA_1, asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		info = model.ToBitString32(p.Point)
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1:
+		info = model.ToMeasuredValueNormal(p.Point)
+	case asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		info = model.ToMeasuredValueScaled(p.Point)
+	case asdu.M_ME_NC_1, asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		info = model.ToMeasuredValueFloat(p.Point)
+	default:
+		return
+	}
+	_ = sf.store.Set(sf.ca, cs104.Point{Type: p.typeID, Info: info})
+}
+
+// run changes the points by themselves until ctx is done.
+func (sf *simulator) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range sf.points {
+		if p.change == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(p *point) {
+			defer wg.Done()
+			t := time.NewTicker(time.Duration(p.change.Period))
+			defer t.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					sf.step(p)
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+// step changes the point once and sends it
+func (sf *simulator) step(p *point) {
+	c := p.change
+	sf.mux.Lock()
+	v := p.Value
+	switch c.Mode {
+	case "toggle":
+		switch {
+		case p.Kind == model.Binary:
+			v = 1 - v
+		case p.Kind == model.DoubleBinary && p.DoubleState() == model.On:
+			v = float64(model.Off)
+		case p.Kind == model.DoubleBinary:
+			v = float64(model.On)
+		case v == c.Max:
+			v = c.Min
+		default:
+			v = c.Max
+		}
+	case "random":
+		v = c.Min + sf.rand.Float64()*(c.Max-c.Min)
+		if p.Kind != model.Analog {
+			v = float64(int64(v + 0.5))
+		}
+	case "ramp":
+		step := c.Step
+		if step == 0 {
+			step = 1
+		}
+		if v += step; v > c.Max {
+			v = c.Min
+		}
+	}
+	pt := sf.set(p, v)
+	sf.mux.Unlock()
+
+	coa := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	if c.Periodic {
+		coa.Cause = asdu.Periodic
+	}
+	_ = model.Send(sf.conn, p.typeID, coa, pt)
+}
+
+// set the value of the point, with the lock held, returns the point to send
+func (sf *simulator) set(p *point, v float64) model.Point {
+	p.Value, p.Time = v, time.Now()
+	sf.save(p)
+	return p.Point
+}
+
+// send the points of the kinds over c, grouped by type identification
+func (sf *simulator) send(c asdu.Connect, coa asdu.CauseOfTransmission, counters bool) error {
+	sf.mux.Lock()
+	var order []asdu.TypeID
+	groups := make(map[asdu.TypeID][]model.Point)
+	for _, p := range sf.points {
+		if (p.Kind == model.Counter) != counters {
+			continue
+		}
+		t := untimed(p.typeID) // the interrogated points carry no time tag
+		if _, ok := groups[t]; !ok {
+			order = append(order, t)
+		}
+		groups[t] = append(groups[t], p.Point)
+	}
+	sf.mux.Unlock()
+
+	for _, t := range order {
+		if err := model.Send(c, t, coa, groups[t]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InterrogationHandler answers the station interrogation with every point
+// but the counters, the group interrogations with no point.
+func (sf *simulator) InterrogationHandler(c asdu.Connect, a *asdu.ASDU, qoi asdu.QualifierOfInterrogation) error {
+	if a.Coa.Cause == asdu.Deactivation {
+		return c.Send(a.Mirror(asdu.DeactivationCon, false))
+	}
+	if err := c.Send(a.Mirror(asdu.ActivationCon, false)); err != nil {
+		return err
+	}
+	if qoi == asdu.QOIStation {
+		if err := sf.send(c, asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}, false); err != nil {
+			return err
+		}
+	}
+	return c.Send(a.Mirror(asdu.ActivationTerm, false))
+}
+
+// CounterInterrogationHandler answers with every counter
+func (sf *simulator) CounterInterrogationHandler(c asdu.Connect, a *asdu.ASDU, _ asdu.QualifierCountCall) error {
+	if err := c.Send(a.Mirror(asdu.ActivationCon, false)); err != nil {
+		return err
+	}
+	if err := sf.send(c, asdu.CauseOfTransmission{Cause: asdu.RequestByGeneralCounter}, true); err != nil {
+		return err
+	}
+	return c.Send(a.Mirror(asdu.ActivationTerm, false))
+}
+
+// ReadHandler never called, the point store answers the read command
+func (sf *simulator) ReadHandler(asdu.Connect, *asdu.ASDU, asdu.InfoObjAddr) error { return nil }
+
+// ClockSyncHandler confirms
+func (sf *simulator) ClockSyncHandler(c asdu.Connect, a *asdu.ASDU, _ time.Time) error {
+	return c.Send(a.Mirror(asdu.ActivationCon, false))
+}
+
+// ResetProcessHandler confirms
+func (sf *simulator) ResetProcessHandler(c asdu.Connect, a *asdu.ASDU, _ asdu.QualifierOfResetProcessCmd) error {
+	return c.Send(a.Mirror(asdu.ActivationCon, false))
+}
+
+// DelayAcquisitionHandler confirms
+func (sf *simulator) DelayAcquisitionHandler(c asdu.Connect, a *asdu.ASDU, _ uint16) error {
+	return c.Send(a.Mirror(asdu.ActivationCon, false))
+}
+
+// ASDUHandler ignores
+func (sf *simulator) ASDUHandler(asdu.Connect, *asdu.ASDU) error { return nil }
+
+// command handles the command of the address, the value is written to the
+// feedback point, added to it if relative.
+func (sf *simulator) command(c asdu.Connect, id asdu.Identifier, ioa asdu.InfoObjAddr, inSelect bool, v float64, relative bool) error {
+	cfg, ok := sf.commands[ioa]
+	// the time tagged command type is 13 after the one without
+	if !ok || (id.Type != asdu.TypeID(cfg.Type) && id.Type != asdu.TypeID(cfg.Type)+13) {
+		return cs104.ErrReject{Cause: asdu.UnknownIOA}
+	}
+	if id.Coa.Cause == asdu.Deactivation || inSelect {
+		return nil
+	}
+	if cfg.Response == "reject" {
+		return cs104.ErrReject{Cause: asdu.ActivationCon}
+	}
+
+	// the feedback of an immediate command is sent on the connection of the
+	// command after its confirmation, the delayed one to every connection.
+	feedback := func(c asdu.Connect) {
+		sf.mux.Lock()
+		p, ok := sf.index[cfg.Feedback]
+		if !ok {
+			sf.mux.Unlock()
+			return
+		}
+		if relative {
+			v += p.Value
+		}
+		pt := sf.set(p, v)
+		sf.mux.Unlock()
+		// the return information cause is for the status points only
+		coa := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+		if p.Kind == model.Binary || p.Kind == model.DoubleBinary || p.Kind == model.Step {
+			coa.Cause = asdu.ReturnInfoRemote
+		}
+		_ = model.Send(c, p.typeID, coa, pt)
+	}
+	if cfg.Response != "delay" {
+		feedback(c)
+		return nil
+	}
+	terminate := cs104.Termination(c)
+	go func() {
+		time.Sleep(time.Duration(cfg.Delay))
+		feedback(sf.conn)
+		if terminate != nil {
+			_ = terminate()
+		}
+	}()
+	return cs104.ErrPending
+}
+
+// OnSingleCommand writes 1 or 0 to the feedback point
+func (sf *simulator) OnSingleCommand(c asdu.Connect, id asdu.Identifier, cmd asdu.SingleCommandInfo) error {
+	v := 0.0
+	if cmd.Value {
+		v = 1
+	}
+	return sf.command(c, id, cmd.Ioa, cmd.Qoc.InSelect, v, false)
+}
+
+// OnDoubleCommand writes on or off to the feedback point
+func (sf *simulator) OnDoubleCommand(c asdu.Connect, id asdu.Identifier, cmd asdu.DoubleCommandInfo) error {
+	v := float64(model.Off)
+	if cmd.Value == asdu.DCOOn {
+		v = float64(model.On)
+	}
+	return sf.command(c, id, cmd.Ioa, cmd.Qoc.InSelect, v, false)
+}
+
+// OnStepCommand steps the feedback point up or down by one
+func (sf *simulator) OnStepCommand(c asdu.Connect, id asdu.Identifier, cmd asdu.StepCommandInfo) error {
+	v := -1.0
+	if cmd.Value == asdu.SCOStepUP {
+		v = 1
+	}
+	return sf.command(c, id, cmd.Ioa, cmd.Qoc.InSelect, v, true)
+}
+
+// OnSetpointNormal writes the value in [-1, 1) to the feedback point
+func (sf *simulator) OnSetpointNormal(c asdu.Connect, id asdu.Identifier, cmd asdu.SetpointCommandNormalInfo) error {
+	return sf.command(c, id, cmd.Ioa, cmd.Qos.InSelect, cmd.Value.Float64(), false)
+}
+
+// OnSetpointScaled writes the value to the feedback point
+func (sf *simulator) OnSetpointScaled(c asdu.Connect, id asdu.Identifier, cmd asdu.SetpointCommandScaledInfo) error {
+	return sf.command(c, id, cmd.Ioa, cmd.Qos.InSelect, float64(cmd.Value), false)
+}
+
+// OnSetpointFloat writes the value to the feedback point
+func (sf *simulator) OnSetpointFloat(c asdu.Connect, id asdu.Identifier, cmd asdu.SetpointCommandFloatInfo) error {
+	return sf.command(c, id, cmd.Ioa, cmd.Qos.InSelect, float64(cmd.Value), false)
+}
+
+// OnBitString32Command writes the bits to the feedback point
+func (sf *simulator) OnBitString32Command(c asdu.Connect, id asdu.Identifier, cmd asdu.BitsString32CommandInfo) error {
+	return sf.command(c, id, cmd.Ioa, false, float64(cmd.Value), false)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+// conn keeps the asdu sent
+type conn struct {
+	sent []*asdu.ASDU
+}
+
+func (sf *conn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *conn) UnderlyingConn() net.Conn { return nil }
+func (sf *conn) Send(a *asdu.ASDU) error {
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	r := asdu.NewEmptyASDU(asdu.ParamsWide)
+	if err = r.UnmarshalBinary(append([]byte(nil), raw...)); err != nil {
+		return err
+	}
+	sf.sent = append(sf.sent, r)
+	return nil
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig("example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":2404" || len(cfg.Points) == 0 || len(cfg.Commands) == 0 {
+		t.Errorf("LoadConfig() = %+v", cfg)
+	}
+
+	bad := &Config{Points: []PointConfig{{IOA: 1, Type: TypeName(asdu.M_SP_TB_1), Change: &Change{Period: 1, Mode: "ramp", Periodic: true}}}}
+	if err = bad.Valid(); err == nil {
+		t.Error("Valid() accepts a periodic single point")
+	}
+}
+
+func TestSimulator(t *testing.T) {
+	c := &conn{}
+	sim := newSimulator(&Config{
+		CommonAddr: 1,
+		Points: []PointConfig{
+			{IOA: 1, Type: TypeName(asdu.M_SP_TB_1), Value: 1},
+			{IOA: 2, Type: TypeName(asdu.M_ME_NC_1), Value: 1.5},
+			{IOA: 3, Type: TypeName(asdu.M_IT_NA_1), Value: 7},
+		},
+		Commands: []CommandConfig{
+			{IOA: 10, Type: TypeName(asdu.C_SE_NC_1), Feedback: 2},
+			{IOA: 11, Type: TypeName(asdu.C_SC_NA_1), Response: "reject"},
+		},
+	})
+	sim.conn = c
+
+	if err := asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation); err != nil {
+		t.Fatal(err)
+	}
+	gi := c.sent[0]
+	c.sent = nil
+	if err := sim.InterrogationHandler(c, gi, asdu.QOIStation); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 4 || c.sent[0].Coa.Cause != asdu.ActivationCon || c.sent[3].Coa.Cause != asdu.ActivationTerm {
+		t.Fatalf("sent %d asdu, want the confirmation, 2 points and the termination", len(c.sent))
+	}
+	if a := c.sent[1]; a.Type != asdu.M_SP_NA_1 || a.Coa.Cause != asdu.InterrogatedByStation {
+		t.Errorf("sent %v, want the point without time tag", a.Identifier)
+	}
+
+	id := asdu.Identifier{Type: asdu.C_SE_NC_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1}
+	c.sent = nil
+	if err := sim.OnSetpointFloat(c, id, asdu.SetpointCommandFloatInfo{Ioa: 10, Value: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 1 || c.sent[0].GetMeasuredValueFloat()[0].Value != 4 {
+		t.Errorf("sent %d asdu, want the feedback", len(c.sent))
+	}
+	if p, ok := sim.store.Point(1, 2); !ok || p.Info.(asdu.MeasuredValueFloatInfo).Value != 4 {
+		t.Errorf("Point() = %+v, want the value commanded", p)
+	}
+
+	var rej cs104.ErrReject
+	id.Type = asdu.C_SC_NA_1
+	if err := sim.OnSingleCommand(c, id, asdu.SingleCommandInfo{Ioa: 11}); !errors.As(err, &rej) {
+		t.Errorf("OnSingleCommand() error = %v, want rejected", err)
+	}
+	if err := sim.OnSingleCommand(c, id, asdu.SingleCommandInfo{Ioa: 12}); !errors.As(err, &rej) || rej.Cause != asdu.UnknownIOA {
+		t.Errorf("OnSingleCommand() error = %v, want unknown address", err)
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs101
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// default link supervision
+const (
+	// DefaultTestInterval the idle time after which a test link frame is sent, like t3 of cs104
+	DefaultTestInterval = 20 * time.Second
+	// DefaultResponseTimeout the time to wait for the response of the remote station
+	DefaultResponseTimeout = time.Second
+	// DefaultRetries the number of repetitions of a frame not answered
+	DefaultRetries = 3
+)
+
+// error defined
+var (
+	ErrLinkDown     = asdu.NewError(asdu.ErrTransport, "link is down")
+	ErrLinkTimeout  = asdu.NewError(asdu.ErrPeer, "link response timeout")
+	ErrLinkNegative = asdu.NewError(asdu.ErrPeer, "link negative acknowledgement")
+)
+
+// Balanced a station of a balanced link, primary and secondary at once. Run resets the remote link
+// on startup, supervises the idle link with test link frames and resets it again once a request
+// stays unanswered after the retries; the link up and down are reported to the handlers.
+// It implements asdu.Connect, the asdu sent are user data confirmed by the remote station.
+// See IEC 60870-5-101, subclass 6.1 and IEC 60870-5-2.
+type Balanced struct {
+	cfg     Config
+	params  *asdu.Params
+	tr      Transport
+	handler func(c asdu.Connect, a *asdu.ASDU) error
+
+	dir          byte
+	testInterval time.Duration
+	timeout      time.Duration
+	retries      int
+	onUp         func(c asdu.Connect)
+	onDown       func(c asdu.Connect)
+
+	up     uint32 // 1: link up
+	lastRx int64  // unix nano of the last frame received
+	resp   chan Ft12
+	rcv    chan *asdu.ASDU
+	wmux   sync.Mutex // frame write
+
+	txMux sync.Mutex // one primary transaction at a time
+	fcb   byte       // frame count bit of the next new primary frame
+
+	rfcb  byte   // frame count bit expected of the next new remote primary frame
+	rlast []byte // last secondary response, repeated if the remote frame count bit is not toggled
+}
+
+var _ asdu.Station = (*Balanced)(nil)
+
+// NewBalanced new a station of a balanced link on the transport, the user data received
+// are passed to handler
+func NewBalanced(tr Transport, cfg Config, p *asdu.Params, handler func(c asdu.Connect, a *asdu.ASDU) error) (*Balanced, error) {
+	if err := cfg.Valid(p); err != nil {
+		return nil, err
+	}
+	if !cfg.Balanced {
+		return nil, asdu.NewError(asdu.ErrConfig, "station of a balanced link")
+	}
+	return &Balanced{
+		cfg:          cfg,
+		params:       p,
+		tr:           tr,
+		handler:      handler,
+		testInterval: DefaultTestInterval,
+		timeout:      DefaultResponseTimeout,
+		retries:      DefaultRetries,
+		onUp:         func(asdu.Connect) {},
+		onDown:       func(asdu.Connect) {},
+		resp:         make(chan Ft12, 1),
+		rcv:          make(chan *asdu.ASDU, DefaultQueueSize),
+		fcb:          FCB,
+		rfcb:         FCB,
+	}, nil
+}
+
+// SetControllingStation set the DIR bit of the frames sent, set by the controlling station, before Run
+func (sf *Balanced) SetControllingStation(b bool) *Balanced {
+	sf.dir = 0
+	if b {
+		sf.dir = RES_DIR
+	}
+	return sf
+}
+
+// SetTestInterval set the idle time after which a test link frame is sent, before Run
+func (sf *Balanced) SetTestInterval(t time.Duration) *Balanced {
+	if t > 0 {
+		sf.testInterval = t
+	}
+	return sf
+}
+
+// SetResponseTimeout set the time to wait for the response of the remote station, before Run
+func (sf *Balanced) SetResponseTimeout(t time.Duration) *Balanced {
+	if t > 0 {
+		sf.timeout = t
+	}
+	return sf
+}
+
+// SetRetries set the number of repetitions of a frame not answered, before Run
+func (sf *Balanced) SetRetries(n int) *Balanced {
+	if n >= 0 {
+		sf.retries = n
+	}
+	return sf
+}
+
+// SetOnLinkUpHandler set the handler called once the remote link is reset, before Run
+func (sf *Balanced) SetOnLinkUpHandler(f func(c asdu.Connect)) *Balanced {
+	if f != nil {
+		sf.onUp = f
+	}
+	return sf
+}
+
+// SetOnLinkDownHandler set the handler called once the link is lost, before Run
+func (sf *Balanced) SetOnLinkDownHandler(f func(c asdu.Connect)) *Balanced {
+	if f != nil {
+		sf.onDown = f
+	}
+	return sf
+}
+
+// LinkUp returns whether the remote link is reset and answers
+func (sf *Balanced) LinkUp() bool { return atomic.LoadUint32(&sf.up) == 1 }
+
+// IsConnected imp interface asdu.Station, same as LinkUp
+func (sf *Balanced) IsConnected() bool { return sf.LinkUp() }
+
+// Params imp interface asdu.Connect
+func (sf *Balanced) Params() *asdu.Params { return sf.params }
+
+// UnderlyingConn imp interface asdu.Connect, the net.Conn of the transport if any
+func (sf *Balanced) UnderlyingConn() net.Conn {
+	conn, _ := sf.tr.(net.Conn)
+	return conn
+}
+
+// Send imp interface asdu.Connect, send the user data and wait for the confirmation of the remote station
+func (sf *Balanced) Send(a *asdu.ASDU) error {
+	if !sf.LinkUp() {
+		return ErrLinkDown
+	}
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = sf.request(FccUserDataWithConfirmed, raw)
+	return err
+}
+
+// Run supervise the link until ctx is done or the transport fails, close the transport to stop the receiving
+func (sf *Balanced) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() { errc <- sf.recvLoop() }()
+	go sf.handleLoop(ctx)
+	defer sf.setLinkUp(false)
+
+	tick := time.NewTicker(min(sf.testInterval, sf.timeout*time.Duration(sf.retries+1)))
+	defer tick.Stop()
+	for {
+		switch {
+		case !sf.LinkUp():
+			if _, err := sf.request(FccResetRemoteLink, nil); err == nil {
+				sf.setLinkUp(true)
+			}
+		case time.Since(time.Unix(0, atomic.LoadInt64(&sf.lastRx))) >= sf.testInterval:
+			if _, err := sf.request(FccBalanceTestLink, nil); err != nil {
+				sf.setLinkUp(false)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errc:
+			return err
+		case <-tick.C:
+		}
+	}
+}
+
+func (sf *Balanced) setLinkUp(up bool) {
+	var v uint32
+	if up {
+		v = 1
+	}
+	if atomic.SwapUint32(&sf.up, v) == v {
+		return
+	}
+	if up {
+		sf.onUp(sf)
+	} else {
+		sf.onDown(sf)
+	}
+}
+
+// request send the primary frame, repeated up to retries times until answered
+func (sf *Balanced) request(fc byte, data []byte) (Ft12, error) {
+	sf.txMux.Lock()
+	defer sf.txMux.Unlock()
+
+	f := Ft12{Start: startFixFrame, Ctrl: RPM | sf.dir | fc, Address: sf.cfg.LinkAddr, ASDU: data}
+	if data != nil {
+		f.Start = startVarFrame
+	}
+	fcv := fc == FccBalanceTestLink || fc == FccUserDataWithConfirmed
+	if fcv {
+		f.Ctrl |= FCV | sf.fcb
+	}
+	b, err := sf.cfg.AppendFrame(nil, f)
+	if err != nil {
+		return Ft12{}, err
+	}
+
+	timer := time.NewTimer(sf.timeout)
+	defer timer.Stop()
+	for i := 0; i <= sf.retries; i++ {
+		select { // discard a late response
+		case <-sf.resp:
+		default:
+		}
+		if err = sf.write(b); err != nil {
+			return Ft12{}, err
+		}
+		timer.Reset(sf.timeout)
+		select {
+		case r := <-sf.resp:
+			if fc == FccResetRemoteLink {
+				sf.fcb = FCB
+			} else if fcv {
+				sf.fcb ^= FCB
+			}
+			if r.Start != SingleCharAck && r.Function() != FcsConfirmed && r.Function() != FcsStatus {
+				return r, ErrLinkNegative
+			}
+			return r, nil
+		case <-timer.C:
+		}
+	}
+	if fc != FccResetRemoteLink {
+		sf.setLinkUp(false)
+	}
+	return Ft12{}, ErrLinkTimeout
+}
+
+func (sf *Balanced) write(b []byte) error {
+	sf.wmux.Lock()
+	defer sf.wmux.Unlock()
+	_, err := sf.tr.Write(b)
+	return err
+}
+
+// recvLoop dispatch the frames received: the responses to the primary requests,
+// the remote primary requests answered at once.
+func (sf *Balanced) recvLoop() error {
+	dec := sf.cfg.NewDecoder(sf.tr)
+	for {
+		f, err := dec.Next()
+		if err != nil {
+			return err
+		}
+		atomic.StoreInt64(&sf.lastRx, time.Now().UnixNano())
+		if f.Start == SingleCharAck || f.Ctrl&RPM == 0 {
+			select {
+			case sf.resp <- f:
+			default:
+			}
+			continue
+		}
+		if resp := sf.respond(f); resp != nil {
+			if err = sf.write(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// respond returns the encoded response to the remote primary frame
+func (sf *Balanced) respond(f Ft12) []byte {
+	if f.Ctrl&FCV != 0 {
+		if f.Ctrl&FCB != sf.rfcb && sf.rlast != nil {
+			return sf.rlast // repetition
+		}
+		sf.rfcb = f.Ctrl&FCB ^ FCB
+	}
+
+	resp := Ft12{Start: startFixFrame, Ctrl: sf.dir | FcsConfirmed, Address: sf.cfg.LinkAddr}
+	switch f.Function() {
+	case FccResetRemoteLink:
+		sf.rfcb = FCB
+	case FccResetUserProcess, FccBalanceTestLink:
+	case FccUserDataWithConfirmed, FccUserDataWithUnconfirmed:
+		a := asdu.NewEmptyASDU(sf.params)
+		if err := a.UnmarshalBinary(f.ASDU); err == nil {
+			select {
+			case sf.rcv <- a:
+			default: // handler overrun
+			}
+		}
+		if f.Function() == FccUserDataWithUnconfirmed {
+			return nil
+		}
+	case FccLinkStatus:
+		resp.Ctrl = sf.dir | FcsStatus
+	default:
+		resp.Ctrl = sf.dir | 15 // link service not implemented
+	}
+	b, err := sf.cfg.AppendFrame(nil, resp)
+	if err != nil {
+		return nil
+	}
+	sf.rlast = b
+	return b
+}
+
+// handleLoop pass the user data received to the handler, apart from the receiving
+// so that the handler may Send.
+func (sf *Balanced) handleLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-sf.rcv:
+			if sf.handler != nil {
+				_ = sf.handler(sf, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package cs101
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func newTestBalanced(t *testing.T, tr Transport, h func(c asdu.Connect, a *asdu.ASDU) error) *Balanced {
+	t.Helper()
+	b, err := NewBalanced(tr, Config{Balanced: true, LinkAddrSize: 1, LinkAddr: 1}, params101, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.SetTestInterval(20 * time.Millisecond).SetResponseTimeout(20 * time.Millisecond).SetRetries(1)
+}
+
+func waitSignal(t *testing.T, c <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-c:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s not signaled", what)
+	}
+}
+
+// tcpPipe a buffered connection pair, both balanced stations may write at once
+func tcpPipe(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c1, c2
+}
+
+func TestNewBalanced(t *testing.T) {
+	if _, err := NewBalanced(nil, DefaultConfig(), params101, nil); err == nil {
+		t.Error("NewBalanced() unbalanced, want error")
+	}
+}
+
+func TestBalanced_Send(t *testing.T) {
+	c1, c2 := tcpPipe(t)
+	defer c1.Close()
+	defer c2.Close()
+
+	received := make(chan *asdu.ASDU, 1)
+	up := make(chan struct{}, 2)
+	ctrl := newTestBalanced(t, c1, nil).SetControllingStation(true).
+		SetOnLinkUpHandler(func(asdu.Connect) { up <- struct{}{} })
+	ctld := newTestBalanced(t, c2, func(c asdu.Connect, a *asdu.ASDU) error {
+		received <- a
+		return nil
+	}).SetOnLinkUpHandler(func(asdu.Connect) { up <- struct{}{} })
+
+	a := asdu.NewASDU(params101, asdu.Identifier{Type: asdu.C_IC_NA_1, Variable: asdu.VariableStruct{Number: 1},
+		Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1})
+	if err := a.AppendInfoObjAddr(0); err != nil {
+		t.Fatal(err)
+	}
+	a.AppendBytes(byte(asdu.QOIStation))
+	if err := ctrl.Send(a); err != ErrLinkDown {
+		t.Errorf("Send() before link up error = %v, want %v", err, ErrLinkDown)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+	go ctld.Run(ctx)
+	waitSignal(t, up, "first link up")
+	waitSignal(t, up, "second link up")
+
+	if err := ctrl.Send(a); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	select {
+	case got := <-received:
+		if got.Type != asdu.C_IC_NA_1 {
+			t.Errorf("received type = %v", got.Type)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("user data not received")
+	}
+	// the idle link keeps up with the test link frames
+	time.Sleep(100 * time.Millisecond)
+	if !ctrl.LinkUp() || !ctld.LinkUp() {
+		t.Errorf("LinkUp() = %v, %v after idle", ctrl.LinkUp(), ctld.LinkUp())
+	}
+}
+
+func TestBalanced_LinkDown(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	up, down := make(chan struct{}, 1), make(chan struct{}, 1)
+	b := newTestBalanced(t, c1, nil).
+		SetOnLinkUpHandler(func(asdu.Connect) { up <- struct{}{} }).
+		SetOnLinkDownHandler(func(asdu.Connect) { down <- struct{}{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+
+	// remote station answers the reset of the remote link only
+	cfg := Config{Balanced: true, LinkAddrSize: 1, LinkAddr: 1}
+	go func() {
+		dec := cfg.NewDecoder(c2)
+		for {
+			f, err := dec.Next()
+			if err != nil {
+				return
+			}
+			if f.Function() == FccResetRemoteLink {
+				ack, _ := cfg.AppendFrame(nil, Ft12{Start: startFixFrame, Ctrl: FcsConfirmed, Address: 1})
+				if _, err = c2.Write(ack); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	waitSignal(t, up, "link up")
+	waitSignal(t, down, "link down")
+	waitSignal(t, up, "link up again")
+}
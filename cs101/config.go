@@ -0,0 +1,74 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs101
+
+import (
+	"io"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Config defines an IEC 60870-5-101 link layer configuration.
+type Config struct {
+	// Balanced the balanced transmission procedure, else the unbalanced one.
+	// See IEC 60870-5-101, subclass 6.1.
+	Balanced bool
+
+	// LinkAddrSize the size of the link address field in octets,
+	// range [0, 2], 0 is no address and only allowed in balanced mode.
+	// See IEC 60870-5-101, subclass 7.1.
+	LinkAddrSize int
+
+	// LinkAddr the link address of the secondary station, the broadcast address
+	// is not allowed. It is 0 if the link has no address.
+	LinkAddr uint16
+}
+
+// DefaultConfig default config, unbalanced with a link address of one octet
+func DefaultConfig() Config {
+	return Config{
+		Balanced:     false,
+		LinkAddrSize: 1,
+		LinkAddr:     1,
+	}
+}
+
+// Valid check the configuration against the asdu params of the link
+func (sf *Config) Valid(p *asdu.Params) error {
+	if sf == nil || p == nil {
+		return asdu.NewError(asdu.ErrConfig, "invalid pointer")
+	}
+	if err := p.Valid(); err != nil {
+		return err
+	}
+	if sf.LinkAddrSize < 0 || sf.LinkAddrSize > linkAddrSizeMax {
+		return ErrLinkAddrSize
+	}
+	if sf.LinkAddrSize == 0 && !sf.Balanced {
+		return asdu.NewError(asdu.ErrConfig, "LinkAddrSize 0 only allowed in balanced mode")
+	}
+	if int(sf.LinkAddr) >= 1<<(8*sf.LinkAddrSize) {
+		return ErrLinkAddrFit
+	}
+	if sf.LinkAddrSize > 0 && sf.LinkAddr == sf.BroadcastAddr() {
+		return asdu.NewError(asdu.ErrConfig, "LinkAddr is the broadcast address")
+	}
+	return nil
+}
+
+// BroadcastAddr returns the broadcast link address of the link address size, 0 for no address
+func (sf Config) BroadcastAddr() uint16 {
+	return uint16(1<<(8*sf.LinkAddrSize) - 1)
+}
+
+// NewDecoder new a decoder of the frames of the link on the stream r, see NewDecoder
+func (sf Config) NewDecoder(r io.Reader) *Decoder {
+	return NewDecoder(r, sf.LinkAddrSize)
+}
+
+// AppendFrame append the frame encoded with the link address size to b, see Ft12.AppendBinary
+func (sf Config) AppendFrame(b []byte, f Ft12) ([]byte, error) {
+	return f.AppendBinary(b, sf.LinkAddrSize)
+}
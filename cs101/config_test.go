@@ -0,0 +1,52 @@
+package cs101
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestConfig_Valid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		p       *asdu.Params
+		wantErr bool
+	}{
+		{"default", DefaultConfig(), params101, false},
+		{"balanced no address", Config{Balanced: true}, params101, false},
+		{"unbalanced no address", Config{}, params101, true},
+		{"two octets", Config{LinkAddrSize: 2, LinkAddr: 300}, params101, false},
+		{"address fit", Config{LinkAddrSize: 1, LinkAddr: 300}, params101, true},
+		{"broadcast", Config{LinkAddrSize: 1, LinkAddr: 255}, params101, true},
+		{"size", Config{LinkAddrSize: 3}, params101, true},
+		{"params", DefaultConfig(), &asdu.Params{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Valid(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Valid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && asdu.Category(err) == nil {
+				t.Errorf("Valid() error %v without category", err)
+			}
+		})
+	}
+}
+
+func TestConfig_AppendFrame(t *testing.T) {
+	cfg := Config{Balanced: true}
+	f := Ft12{Start: startVarFrame, Ctrl: RPM | FccUserDataWithConfirmed, ASDU: []byte{0x64, 0x01}}
+	b, err := cfg.AppendFrame(nil, f)
+	if err != nil || len(b) != 4+1+2+2 {
+		t.Fatalf("AppendFrame() = % x, %v", b, err)
+	}
+	if got, err := cfg.NewDecoder(bytes.NewReader(b)).Next(); err != nil || !bytes.Equal(got.ASDU, f.ASDU) {
+		t.Errorf("Next() = %+v, %v", got, err)
+	}
+	if a := (Config{LinkAddrSize: 2}).BroadcastAddr(); a != 0xffff {
+		t.Errorf("BroadcastAddr() = %#x", a)
+	}
+}
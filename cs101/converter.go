@@ -0,0 +1,166 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs101
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+// origin the cs104 master waiting for the confirmations of a request
+type origin struct {
+	conn     asdu.Connect
+	origAddr asdu.OriginAddr
+}
+
+// originKey the request a confirmation answers
+type originKey struct {
+	typ asdu.TypeID
+	ca  asdu.CommonAddr
+	ioa asdu.InfoObjAddr
+}
+
+// Converter an IEC 104 to IEC 101 protocol converter: the asdu the cs104 masters send to the server
+// are forwarded to the cs101 stations through the serial master, the asdu the stations send are
+// forwarded back to the cs104 masters, every asdu re-encoded with the params of the other side.
+// The serial master owns the link layer, it sends the asdu as user data confirmed by the station,
+// an asdu it fails to send is negative confirmed to the cs104 master.
+// The confirmations and terminations of the stations go to the master which sent the request,
+// with its originator address, the other asdu to all the masters.
+type Converter struct {
+	serial asdu.Connect
+	server *cs104.Server
+
+	mux     sync.Mutex
+	origins map[originKey]origin
+}
+
+var _ cs104.ServerHandlerInterface = (*Converter)(nil)
+
+// NewConverter new a converter forwarding to the cs101 stations with the serial master,
+// the params of the serial master are the ones of the cs101 side.
+func NewConverter(serial asdu.Connect) *Converter {
+	sf := &Converter{
+		serial:  serial,
+		origins: make(map[originKey]origin),
+	}
+	sf.server = cs104.NewServer(sf)
+	return sf
+}
+
+// Server returns the cs104 server of the masters, to configure and serve it
+func (sf *Converter) Server() *cs104.Server {
+	return sf.server
+}
+
+// HandleASDU forward an asdu received by the serial master from a station to the cs104 masters
+func (sf *Converter) HandleASDU(a *asdu.ASDU) error {
+	r, err := a.Reencode(sf.server.Params())
+	if err != nil {
+		return err
+	}
+	if isConfirmation(r.Coa.Cause) {
+		key := originKey{r.Type, r.CommonAddr, firstInfoObjAddr(r)}
+		sf.mux.Lock()
+		o, ok := sf.origins[key]
+		if ok && (r.Coa.IsNegative || r.Coa.Cause != asdu.ActivationCon) {
+			delete(sf.origins, key) // nothing follows
+		}
+		sf.mux.Unlock()
+		if ok {
+			if r.CauseSize == 2 {
+				r.OrigAddr = o.origAddr
+			}
+			return o.conn.Send(r)
+		}
+	}
+	return sf.server.Send(r)
+}
+
+// forward the request of a cs104 master to the stations
+func (sf *Converter) forward(c asdu.Connect, pack *asdu.ASDU) error {
+	pack.Reset() // decoded by the server session
+	r, err := pack.Reencode(sf.serial.Params())
+	if err != nil {
+		cause := asdu.UnknownIOA
+		if err == asdu.ErrCommonAddrFit {
+			cause = asdu.UnknownCA
+		}
+		return c.Send(pack.Mirror(cause, true))
+	}
+
+	key := originKey{pack.Type, pack.CommonAddr, firstInfoObjAddr(pack)}
+	if pack.Type != asdu.C_RD_NA_1 { // answered by the data requested
+		sf.mux.Lock()
+		sf.origins[key] = origin{c, pack.OrigAddr}
+		sf.mux.Unlock()
+	}
+	if err = sf.serial.Send(r); err != nil {
+		sf.mux.Lock()
+		delete(sf.origins, key)
+		sf.mux.Unlock()
+		_ = c.Send(pack.Mirror(asdu.Unused, true))
+		return err
+	}
+	return nil
+}
+
+// InterrogationHandler imp interface cs104.ServerHandlerInterface
+func (sf *Converter) InterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfInterrogation) error {
+	return sf.forward(c, pack)
+}
+
+// CounterInterrogationHandler imp interface cs104.ServerHandlerInterface
+func (sf *Converter) CounterInterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierCountCall) error {
+	return sf.forward(c, pack)
+}
+
+// ReadHandler imp interface cs104.ServerHandlerInterface
+func (sf *Converter) ReadHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.InfoObjAddr) error {
+	return sf.forward(c, pack)
+}
+
+// ClockSyncHandler imp interface cs104.ServerHandlerInterface
+func (sf *Converter) ClockSyncHandler(c asdu.Connect, pack *asdu.ASDU, _ time.Time) error {
+	return sf.forward(c, pack)
+}
+
+// ResetProcessHandler imp interface cs104.ServerHandlerInterface
+func (sf *Converter) ResetProcessHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfResetProcessCmd) error {
+	return sf.forward(c, pack)
+}
+
+// DelayAcquisitionHandler imp interface cs104.ServerHandlerInterface
+func (sf *Converter) DelayAcquisitionHandler(c asdu.Connect, pack *asdu.ASDU, _ uint16) error {
+	return sf.forward(c, pack)
+}
+
+// ASDUHandler imp interface cs104.ServerHandlerInterface
+func (sf *Converter) ASDUHandler(c asdu.Connect, pack *asdu.ASDU) error {
+	return sf.forward(c, pack)
+}
+
+// isConfirmation the cause of the answers to a request
+func isConfirmation(cause asdu.Cause) bool {
+	switch cause {
+	case asdu.ActivationCon, asdu.DeactivationCon, asdu.ActivationTerm,
+		asdu.UnknownTypeID, asdu.UnknownCOT, asdu.UnknownCA, asdu.UnknownIOA:
+		return true
+	}
+	return false
+}
+
+// firstInfoObjAddr the address of the first information object, the asdu is left unchanged
+func firstInfoObjAddr(a *asdu.ASDU) asdu.InfoObjAddr {
+	m := a.Mark()
+	defer a.Rewind(m)
+	if a.Remaining() < a.InfoObjAddrSize {
+		return asdu.InfoObjAddrIrrelevant
+	}
+	return a.DecodeInfoObjAddr()
+}
@@ -0,0 +1,93 @@
+package cs101
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// params101 a usual cs101 configuration
+var params101 = &asdu.Params{CauseSize: 1, CommonAddrSize: 1, InfoObjAddrSize: 2, InfoObjTimeZone: time.UTC}
+
+// recordConn keeps the asdu sent, err fails the sending
+type recordConn struct {
+	p    *asdu.Params
+	sent []*asdu.ASDU
+	err  error
+}
+
+func (sf *recordConn) Params() *asdu.Params     { return sf.p }
+func (sf *recordConn) UnderlyingConn() net.Conn { return nil }
+func (sf *recordConn) Send(a *asdu.ASDU) error {
+	if sf.err != nil {
+		return sf.err
+	}
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	r := asdu.NewEmptyASDU(sf.p)
+	if err = r.UnmarshalBinary(append([]byte(nil), raw...)); err != nil {
+		return err
+	}
+	sf.sent = append(sf.sent, r)
+	return nil
+}
+
+func (sf *recordConn) take() []*asdu.ASDU {
+	r := sf.sent
+	sf.sent = nil
+	return r
+}
+
+func TestConverter(t *testing.T) {
+	serial := &recordConn{p: params101}
+	master := &recordConn{p: asdu.ParamsWide}
+	conv := NewConverter(serial)
+
+	// the command of the master forwarded with the cs101 params
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	cmd := asdu.SingleCommandInfo{Ioa: 1000, Value: true}
+	_ = asdu.SingleCmd(master, asdu.C_SC_NA_1, act, 5, cmd)
+	req := master.take()[0]
+	req.OrigAddr = 7
+	req.GetSingleCmd() // decoded by the session
+	if err := conv.ASDUHandler(master, req); err != nil {
+		t.Fatal(err)
+	}
+	sent := serial.take()
+	if len(sent) != 1 || sent[0].CommonAddr != 5 || sent[0].GetSingleCmd() != cmd {
+		t.Fatalf("forwarded %v", sent)
+	}
+
+	// its confirmation back to the master with the originator address
+	if err := conv.HandleASDU(sent[0].Mirror(asdu.ActivationCon, false)); err != nil {
+		t.Fatal(err)
+	}
+	got := master.take()
+	if len(got) != 1 || got[0].Coa.Cause != asdu.ActivationCon || got[0].OrigAddr != 7 || got[0].GetSingleCmd() != cmd {
+		t.Fatalf("confirmed %v", got)
+	}
+
+	// the common address does not fit the cs101 params
+	_ = asdu.SingleCmd(master, asdu.C_SC_NA_1, act, 300, cmd)
+	if err := conv.ASDUHandler(master, master.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got = master.take(); len(got) != 1 || got[0].Coa != (asdu.CauseOfTransmission{Cause: asdu.UnknownCA, IsNegative: true}) {
+		t.Errorf("confirmed %v, want unknown common address", got)
+	}
+
+	// the link layer fails
+	serial.err = errors.New("no acknowledgement")
+	_ = asdu.InterrogationCmd(master, act, 5, asdu.QOIStation)
+	if err := conv.InterrogationHandler(master, master.take()[0], asdu.QOIStation); err != serial.err {
+		t.Errorf("InterrogationHandler() error = %v, want %v", err, serial.err)
+	}
+	if got = master.take(); len(got) != 1 || got[0].Coa != (asdu.CauseOfTransmission{Cause: asdu.ActivationCon, IsNegative: true}) {
+		t.Errorf("confirmed %v, want negative", got)
+	}
+}
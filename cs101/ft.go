@@ -4,11 +4,22 @@
 
 package cs101
 
+import (
+	"bufio"
+	"io"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
 // Using FT1.2 frame format
 const (
 	startVarFrame byte = 0x68 // variable length frame start character
 	startFixFrame byte = 0x10 // fixed length frame start character
 	endFrame      byte = 0x16
+
+	// SingleCharAck the single character acknowledgement E5H, replaces the fixed length frame of
+	// the positive acknowledgement, or of no requested data, used by the companion standards 101 and 102
+	SingleCharAck byte = 0xe5
 )
 
 // Control domain definition
@@ -25,8 +36,10 @@ const (
 	// PRM = 1, Transmission of telegrams from the master station to the slave station
 	RPM     = 1 << 6
 	RES_DIR = 1 << 7 //Non-equilibrium is preserved, balance is the direction
+)
 
-	// The function code of the control field in the message transmitted from the initiator station to the slave station(PRM = 1)
+// The function code of the control field in the message transmitted from the initiator station to the slave station(PRM = 1)
+const (
 	FccResetRemoteLink                 = iota // reset remote link
 	FccResetUserProcess                       // reset user process
 	FccBalanceTestLink                        // Link test function
@@ -41,8 +54,10 @@ const (
 	FccUnbalanceLevel2UserData                // Request Level 2 User Data
 	// 12-13: spare
 	// 14-15: Manufacturer and user agreement definition
+)
 
-	// The function code of the control field in the message transmitted from the slave station to the initiator station(PRM = 0)
+// The function code of the control field in the message transmitted from the slave station to the initiator station(PRM = 0)
+const (
 	FcsConfirmed                 = iota // Recognized: affirmatively recognized
 	FcsNConfirmed                       // Negative acknowledgment: no message received, link busy
 	_                                   // reserve
@@ -61,12 +76,167 @@ const (
 	// 15: Link service not completed
 )
 
-// Ft12 ...
+// frame sizes defined
+const (
+	linkAddrSizeMax = 2
+	// FrameSizeMax the size of the longest variable length frame: start, length twice, start, user data, checksum and end
+	FrameSizeMax = 4 + 255 + 2
+)
+
+// error defined
+var (
+	ErrLinkAddrSize = asdu.NewError(asdu.ErrConfig, "link address size not in [0, 2]")
+	ErrLinkAddrFit  = asdu.NewError(asdu.ErrProtocol, "link address exceeds the link address size")
+	ErrFrameLength  = asdu.NewError(asdu.ErrProtocol, "user data exceeds the frame length")
+	ErrFrameStart   = asdu.NewError(asdu.ErrProtocol, "unknown frame start character")
+)
+
+// Ft12 an FT1.2 frame: the single character acknowledgement, a fixed length frame,
+// or a variable length frame carrying the asdu.
 type Ft12 struct {
-	start        byte
-	apduFiledLen byte
-	ctrl         byte
-	address      uint16
-	checksum     byte
-	end          byte
+	Start   byte   // SingleCharAck, the start character of the fixed or the variable length frame
+	Ctrl    byte   // control field
+	Address uint16 // link address, its size is the one of the link
+	ASDU    []byte // user data of the variable length frame
+}
+
+// Function returns the function code of the control field
+func (sf Ft12) Function() byte {
+	return sf.Ctrl & 0x0f
+}
+
+// AppendBinary append the frame encoded with a link address of addrSize octets to b
+func (sf Ft12) AppendBinary(b []byte, addrSize int) ([]byte, error) {
+	if addrSize < 0 || addrSize > linkAddrSizeMax {
+		return b, ErrLinkAddrSize
+	}
+	if int(sf.Address) >= 1<<(8*addrSize) {
+		return b, ErrLinkAddrFit
+	}
+	switch sf.Start {
+	case SingleCharAck:
+		return append(b, SingleCharAck), nil
+	case startFixFrame:
+		b = append(b, startFixFrame)
+	case startVarFrame:
+		n := 1 + addrSize + len(sf.ASDU)
+		if n > 255 {
+			return b, ErrFrameLength
+		}
+		b = append(b, startVarFrame, byte(n), byte(n), startVarFrame)
+	default:
+		return b, ErrFrameStart
+	}
+	start := len(b)
+	b = append(b, sf.Ctrl)
+	for i := 0; i < addrSize; i++ {
+		b = append(b, byte(sf.Address>>(8*i)))
+	}
+	if sf.Start == startVarFrame {
+		b = append(b, sf.ASDU...)
+	}
+	return append(b, checksum(b[start:]), endFrame), nil
+}
+
+// checksum the arithmetic sum modulo 256 of the octets
+func checksum(b []byte) byte {
+	var cs byte
+	for _, v := range b {
+		cs += v
+	}
+	return cs
+}
+
+// DecoderStats the statistics of a decoder
+type DecoderStats struct {
+	Frames    uint64 // frames decoded
+	Discarded uint64 // octets discarded hunting for the next frame
+	Checksum  uint64 // frames discarded for a checksum error
+}
+
+// Decoder decodes the FT1.2 frames of a byte stream, the octets which do not start a valid frame,
+// the line noise or the corrupted frames, are discarded one by one hunting for the next start character.
+type Decoder struct {
+	r        *bufio.Reader
+	addrSize int
+	stats    DecoderStats
+}
+
+// NewDecoder new a decoder of the stream r, with a link address of addrSize octets
+func NewDecoder(r io.Reader, addrSize int) *Decoder {
+	return &Decoder{r: bufio.NewReaderSize(r, 2*FrameSizeMax), addrSize: addrSize}
+}
+
+// Stats returns the statistics of the decoder
+func (sf *Decoder) Stats() DecoderStats {
+	return sf.stats
+}
+
+// Next returns the next valid frame, or the error of the stream
+func (sf *Decoder) Next() (Ft12, error) {
+	if sf.addrSize < 0 || sf.addrSize > linkAddrSizeMax {
+		return Ft12{}, ErrLinkAddrSize
+	}
+	for {
+		b, err := sf.r.Peek(1)
+		if err != nil {
+			return Ft12{}, err
+		}
+		var frame []byte
+		switch b[0] {
+		case SingleCharAck:
+			frame = b
+		case startFixFrame:
+			if frame, err = sf.r.Peek(4 + sf.addrSize); err != nil {
+				return Ft12{}, err
+			}
+		case startVarFrame:
+			hdr, err := sf.r.Peek(4)
+			if err != nil {
+				return Ft12{}, err
+			}
+			if hdr[1] == hdr[2] && hdr[3] == startVarFrame && int(hdr[1]) >= 1+sf.addrSize {
+				if frame, err = sf.r.Peek(4 + int(hdr[1]) + 2); err != nil {
+					return Ft12{}, err
+				}
+			}
+		}
+		if f, ok := sf.decode(frame); ok {
+			_, _ = sf.r.Discard(len(frame))
+			sf.stats.Frames++
+			return f, nil
+		}
+		_, _ = sf.r.Discard(1)
+		sf.stats.Discarded++
+	}
+}
+
+// decode the frame candidate, ok if valid
+func (sf *Decoder) decode(frame []byte) (Ft12, bool) {
+	if len(frame) == 0 {
+		return Ft12{}, false
+	}
+	f := Ft12{Start: frame[0]}
+	if f.Start == SingleCharAck {
+		return f, true
+	}
+	body := frame[1 : len(frame)-2] // control field, address and user data
+	if f.Start == startVarFrame {
+		body = frame[4 : len(frame)-2]
+	}
+	if frame[len(frame)-1] != endFrame {
+		return Ft12{}, false
+	}
+	if checksum(body) != frame[len(frame)-2] {
+		sf.stats.Checksum++
+		return Ft12{}, false
+	}
+	f.Ctrl = body[0]
+	for i := 0; i < sf.addrSize; i++ {
+		f.Address |= uint16(body[1+i]) << (8 * i)
+	}
+	if f.Start == startVarFrame {
+		f.ASDU = append([]byte(nil), body[1+sf.addrSize:]...)
+	}
+	return f, true
 }
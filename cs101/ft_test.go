@@ -0,0 +1,72 @@
+package cs101
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFt12_AppendBinary(t *testing.T) {
+	tests := []struct {
+		name     string
+		frame    Ft12
+		addrSize int
+		want     []byte
+		wantErr  error
+	}{
+		{"ack", Ft12{Start: SingleCharAck}, 1, []byte{0xe5}, nil},
+		{"fixed", Ft12{Start: startFixFrame, Ctrl: RPM | FccLinkStatus, Address: 1}, 1, []byte{0x10, 0x49, 0x01, 0x4a, 0x16}, nil},
+		{"fixed no address", Ft12{Start: startFixFrame, Ctrl: RPM | FccBalanceTestLink}, 0, []byte{0x10, 0x42, 0x42, 0x16}, nil},
+		{"variable", Ft12{Start: startVarFrame, Ctrl: RPM | FccUserDataWithConfirmed, Address: 0x0102, ASDU: []byte{0x64, 0x01}}, 2,
+			[]byte{0x68, 0x05, 0x05, 0x68, 0x43, 0x02, 0x01, 0x64, 0x01, 0xab, 0x16}, nil},
+		{"address fit", Ft12{Start: startFixFrame, Address: 256}, 1, nil, ErrLinkAddrFit},
+		{"address size", Ft12{Start: startFixFrame}, 3, nil, ErrLinkAddrSize},
+		{"too long", Ft12{Start: startVarFrame, ASDU: make([]byte, 254)}, 1, nil, ErrFrameLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.frame.AppendBinary(nil, tt.addrSize)
+			if err != tt.wantErr || !bytes.Equal(got, tt.want) {
+				t.Fatalf("AppendBinary() = % x, %v, want % x, %v", got, err, tt.want, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			f, err := NewDecoder(bytes.NewReader(got), tt.addrSize).Next()
+			if err != nil || !reflect.DeepEqual(f, tt.frame) {
+				t.Errorf("Next() = %+v, %v, want %+v", f, err, tt.frame)
+			}
+		})
+	}
+}
+
+func TestDecoder_Next(t *testing.T) {
+	data := Ft12{Start: startVarFrame, Ctrl: FcsUnbalanceResponse, Address: 3, ASDU: []byte{0x01, 0x01, 0x03, 0x03}}
+	good, _ := data.AppendBinary(nil, 1)
+	corrupt := append([]byte(nil), good...)
+	corrupt[6]++ // checksum error
+
+	var stream []byte
+	stream = append(stream, 0x00, 0xff)             // noise
+	stream = append(stream, corrupt...)             // discarded
+	stream = append(stream, 0x68, 0x09, 0x08, 0x68) // length mismatch
+	stream = append(stream, good...)                // decoded
+	stream = append(stream, SingleCharAck)          // decoded
+	stream = append(stream, 0x10, 0x00, 0x03, 0x03) // truncated fixed frame, missing end
+	d := NewDecoder(bytes.NewReader(stream), 1)
+
+	for _, want := range []Ft12{data, {Start: SingleCharAck}} {
+		f, err := d.Next()
+		if err != nil || !reflect.DeepEqual(f, want) {
+			t.Fatalf("Next() = %+v, %v, want %+v", f, err, want)
+		}
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("Next() error = %v, want %v", err, io.EOF)
+	}
+	want := DecoderStats{Frames: 2, Discarded: 2 + uint64(len(corrupt)) + 4, Checksum: 1}
+	if got := d.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs101
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Class the class of the user data polled in the unbalanced transmission
+type Class byte
+
+// class defined
+const (
+	// Class1 the events and the answers to the commands, polled first, signaled by the ACD bit
+	Class1 Class = 1
+	// Class2 the cyclic and the background data
+	Class2 Class = 2
+)
+
+// DefaultQueueSize the default size of the queue of every class
+const DefaultQueueSize = 1024
+
+// ErrQueueFull the queue of the class is full
+var ErrQueueFull = asdu.NewError(asdu.ErrTransport, "class queue is full")
+
+// DefaultClassifier the class of the asdu: Class2 for the periodic, background and interrogated data,
+// Class1 for all the others, the spontaneous events and the answers to the commands.
+func DefaultClassifier(a *asdu.ASDU) Class {
+	switch c := a.Coa.Cause; {
+	case c == asdu.Periodic, c == asdu.Background,
+		c >= asdu.InterrogatedByStation && c <= asdu.InterrogatedByGroup16:
+		return Class2
+	}
+	return Class1
+}
+
+// Outstation the secondary station of an unbalanced link: it answers the polls of the primary station with
+// the user data queued in class 1 or class 2, the class 1 pending is signaled by the ACD bit of every
+// response, and passes the user data received to the handler.
+// It implements asdu.Connect, the asdu sent are queued in the class given by the classifier.
+// See IEC 60870-5-101, subclass 6.1 and IEC 60870-5-2.
+type Outstation struct {
+	cfg     Config
+	params  *asdu.Params
+	tr      Transport
+	handler func(c asdu.Connect, a *asdu.ASDU) error
+
+	mux        sync.Mutex
+	classifier func(a *asdu.ASDU) Class
+	queues     [2][][]byte // class 1 and class 2 asdu
+	queueSize  int
+	fcb        byte   // frame count bit expected of the next new frame
+	reset      bool   // link reset by the primary station
+	last       []byte // last response, repeated if the frame count bit is not toggled
+}
+
+var _ asdu.Station = (*Outstation)(nil)
+
+// NewOutstation new an unbalanced secondary station on the transport, the user data received
+// are passed to handler
+func NewOutstation(tr Transport, cfg Config, p *asdu.Params, handler func(c asdu.Connect, a *asdu.ASDU) error) (*Outstation, error) {
+	if err := cfg.Valid(p); err != nil {
+		return nil, err
+	}
+	if cfg.Balanced {
+		return nil, asdu.NewError(asdu.ErrConfig, "outstation of an unbalanced link")
+	}
+	return &Outstation{
+		cfg:        cfg,
+		params:     p,
+		tr:         tr,
+		handler:    handler,
+		classifier: DefaultClassifier,
+		queueSize:  DefaultQueueSize,
+		fcb:        FCB,
+	}, nil
+}
+
+// SetClassifier set the classifier of the asdu sent with Send, nil is DefaultClassifier
+func (sf *Outstation) SetClassifier(f func(a *asdu.ASDU) Class) *Outstation {
+	if f == nil {
+		f = DefaultClassifier
+	}
+	sf.mux.Lock()
+	sf.classifier = f
+	sf.mux.Unlock()
+	return sf
+}
+
+// SetQueueSize set the size of the queue of every class
+func (sf *Outstation) SetQueueSize(n int) *Outstation {
+	if n > 0 {
+		sf.mux.Lock()
+		sf.queueSize = n
+		sf.mux.Unlock()
+	}
+	return sf
+}
+
+// IsConnected imp interface asdu.Station, whether the primary station reset the link
+func (sf *Outstation) IsConnected() bool {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.reset
+}
+
+// Params imp interface asdu.Connect
+func (sf *Outstation) Params() *asdu.Params { return sf.params }
+
+// UnderlyingConn imp interface asdu.Connect, the net.Conn of the transport if any
+func (sf *Outstation) UnderlyingConn() net.Conn {
+	conn, _ := sf.tr.(net.Conn)
+	return conn
+}
+
+// Send imp interface asdu.Connect, queue the asdu in the class of the classifier
+func (sf *Outstation) Send(a *asdu.ASDU) error {
+	sf.mux.Lock()
+	class := sf.classifier(a)
+	sf.mux.Unlock()
+	return sf.SendClass(a, class)
+}
+
+// SendClass queue the asdu in the class, until polled by the primary station
+func (sf *Outstation) SendClass(a *asdu.ASDU, class Class) error {
+	if class != Class1 && class != Class2 {
+		return errors.New("class not 1 or 2")
+	}
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	q := &sf.queues[class-1]
+	if len(*q) >= sf.queueSize {
+		return ErrQueueFull
+	}
+	*q = append(*q, append([]byte(nil), raw...))
+	return nil
+}
+
+// Pending returns the number of asdu queued in the class
+func (sf *Outstation) Pending(class Class) int {
+	if class != Class1 && class != Class2 {
+		return 0
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return len(sf.queues[class-1])
+}
+
+// Serve answer the frames of the primary station until the transport fails
+func (sf *Outstation) Serve() error {
+	dec := sf.cfg.NewDecoder(sf.tr)
+	for {
+		f, err := dec.Next()
+		if err != nil {
+			return err
+		}
+		resp, a := sf.respond(f)
+		if a != nil && sf.handler != nil {
+			_ = sf.handler(sf, a)
+		}
+		if resp != nil {
+			if _, err = sf.tr.Write(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// respond returns the encoded response to the frame, nil for none, and the asdu of the user data received
+func (sf *Outstation) respond(f Ft12) ([]byte, *asdu.ASDU) {
+	broadcast := sf.cfg.LinkAddrSize > 0 && f.Address == sf.cfg.BroadcastAddr()
+	if f.Start == SingleCharAck || f.Ctrl&RPM == 0 || f.Address != sf.cfg.LinkAddr && !broadcast {
+		return nil, nil // not a request to this station
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+
+	if f.Ctrl&FCV != 0 {
+		if f.Ctrl&FCB != sf.fcb && sf.last != nil {
+			return sf.last, nil // repetition
+		}
+		sf.fcb = f.Ctrl&FCB ^ FCB
+	}
+
+	var a *asdu.ASDU
+	resp := Ft12{Start: startFixFrame, Address: sf.cfg.LinkAddr}
+	switch f.Function() {
+	case FccResetRemoteLink:
+		sf.fcb, sf.reset = FCB, true
+		resp.Ctrl = FcsConfirmed
+	case FccResetUserProcess:
+		sf.queues = [2][][]byte{}
+		resp.Ctrl = FcsConfirmed
+	case FccUserDataWithConfirmed, FccUserDataWithUnconfirmed:
+		a = asdu.NewEmptyASDU(sf.params)
+		if err := a.UnmarshalBinary(f.ASDU); err != nil {
+			a = nil
+		}
+		if f.Function() == FccUserDataWithUnconfirmed || broadcast {
+			return nil, a
+		}
+		resp.Ctrl = FcsConfirmed
+	case FccLinkStatus:
+		resp.Ctrl = FcsStatus
+	case FccUnbalanceLevel1UserData, FccUnbalanceLevel2UserData:
+		q := &sf.queues[0]
+		if f.Function() == FccUnbalanceLevel2UserData {
+			q = &sf.queues[1]
+		}
+		resp.Ctrl = FcsUnbalanceNegativeResponse
+		if len(*q) > 0 {
+			resp.Start, resp.Ctrl, resp.ASDU = startVarFrame, FcsUnbalanceResponse, (*q)[0]
+			*q = (*q)[1:]
+		}
+	default:
+		resp.Ctrl = 15 // link service not implemented
+	}
+	if len(sf.queues[0]) > 0 {
+		resp.Ctrl |= ACD_RES
+	}
+	b, err := sf.cfg.AppendFrame(nil, resp)
+	if err != nil {
+		return nil, a
+	}
+	sf.last = b
+	return b, a
+}
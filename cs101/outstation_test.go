@@ -0,0 +1,231 @@
+package cs101
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+func newTestOutstation(t *testing.T, tr Transport, h func(c asdu.Connect, a *asdu.ASDU) error) *Outstation {
+	t.Helper()
+	o, err := NewOutstation(tr, DefaultConfig(), params101, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func request(fc byte, fcb byte) Ft12 {
+	ctrl := RPM | fc | fcb
+	if fc == FccUserDataWithConfirmed || fc == FccUnbalanceLevel1UserData || fc == FccUnbalanceLevel2UserData {
+		ctrl |= FCV
+	}
+	return Ft12{Start: startFixFrame, Ctrl: ctrl, Address: 1}
+}
+
+func decodeResponse(t *testing.T, b []byte) Ft12 {
+	t.Helper()
+	f, err := DefaultConfig().NewDecoder(bytes.NewReader(b)).Next()
+	if err != nil {
+		t.Fatalf("response % x: %v", b, err)
+	}
+	return f
+}
+
+func TestNewOutstation(t *testing.T) {
+	if _, err := NewOutstation(nil, Config{Balanced: true}, params101, nil); err == nil {
+		t.Error("NewOutstation() balanced, want error")
+	}
+	if _, err := NewOutstation(nil, Config{}, params101, nil); err == nil {
+		t.Error("NewOutstation() invalid config, want error")
+	}
+}
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		cause asdu.Cause
+		want  Class
+	}{
+		{asdu.Spontaneous, Class1},
+		{asdu.ActivationCon, Class1},
+		{asdu.Periodic, Class2},
+		{asdu.Background, Class2},
+		{asdu.InterrogatedByStation, Class2},
+		{asdu.InterrogatedByGroup16, Class2},
+	}
+	for _, tt := range tests {
+		a := asdu.NewASDU(params101, asdu.Identifier{Type: asdu.M_SP_NA_1, Coa: asdu.CauseOfTransmission{Cause: tt.cause}})
+		if got := DefaultClassifier(a); got != tt.want {
+			t.Errorf("DefaultClassifier(%v) = %v, want %v", tt.cause, got, tt.want)
+		}
+	}
+}
+
+func TestOutstation_respond(t *testing.T) {
+	o := newTestOutstation(t, nil, nil)
+	spont := asdu.NewASDU(params101, asdu.Identifier{Type: asdu.M_SP_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, CommonAddr: 1})
+	cyclic := asdu.NewASDU(params101, asdu.Identifier{Type: asdu.M_ME_NB_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Periodic}, CommonAddr: 1})
+	if err := o.Send(cyclic); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Send(spont); err != nil {
+		t.Fatal(err)
+	}
+	if o.Pending(Class1) != 1 || o.Pending(Class2) != 1 {
+		t.Fatalf("Pending() = %d, %d", o.Pending(Class1), o.Pending(Class2))
+	}
+
+	resp, _ := o.respond(request(FccResetRemoteLink, 0))
+	if f := decodeResponse(t, resp); f.Function() != FcsConfirmed || f.Ctrl&ACD_RES == 0 {
+		t.Errorf("reset response ctrl = %#x, want confirmed with ACD", f.Ctrl)
+	}
+
+	// class 2 polled first, the class 1 still pending
+	resp, _ = o.respond(request(FccUnbalanceLevel2UserData, FCB))
+	f := decodeResponse(t, resp)
+	if f.Function() != FcsUnbalanceResponse || f.Ctrl&ACD_RES == 0 || f.ASDU[0] != byte(asdu.M_ME_NB_1) {
+		t.Errorf("class 2 response = %+v", f)
+	}
+	// repetition, the frame count bit not toggled
+	if again, _ := o.respond(request(FccUnbalanceLevel2UserData, FCB)); !bytes.Equal(again, resp) {
+		t.Errorf("repeated response = % x, want % x", again, resp)
+	}
+
+	resp, _ = o.respond(request(FccUnbalanceLevel1UserData, 0))
+	f = decodeResponse(t, resp)
+	if f.Function() != FcsUnbalanceResponse || f.Ctrl&ACD_RES != 0 || f.ASDU[0] != byte(asdu.M_SP_NA_1) {
+		t.Errorf("class 1 response = %+v", f)
+	}
+
+	resp, _ = o.respond(request(FccUnbalanceLevel1UserData, FCB))
+	if f := decodeResponse(t, resp); f.Function() != FcsUnbalanceNegativeResponse {
+		t.Errorf("empty class 1 response ctrl = %#x, want no data", f.Ctrl)
+	}
+
+	resp, _ = o.respond(request(FccLinkStatus, 0))
+	if f := decodeResponse(t, resp); f.Function() != FcsStatus {
+		t.Errorf("link status response ctrl = %#x", f.Ctrl)
+	}
+
+	other := request(FccLinkStatus, 0)
+	other.Address = 2
+	if resp, _ = o.respond(other); resp != nil {
+		t.Errorf("response to other station = % x", resp)
+	}
+}
+
+func TestOutstation_SendClass(t *testing.T) {
+	o := newTestOutstation(t, nil, nil).SetQueueSize(1)
+	a := asdu.NewASDU(params101, asdu.Identifier{Type: asdu.M_SP_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, CommonAddr: 1})
+	if err := o.SendClass(a, 3); err == nil {
+		t.Error("SendClass() class 3, want error")
+	}
+	if err := o.SendClass(a, Class2); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.SendClass(a, Class2); err != ErrQueueFull {
+		t.Errorf("SendClass() error = %v, want %v", err, ErrQueueFull)
+	}
+	o.respond(request(FccResetUserProcess, 0))
+	if o.Pending(Class2) != 0 {
+		t.Errorf("Pending() after reset user process = %d", o.Pending(Class2))
+	}
+}
+
+func TestOutstation_Serve(t *testing.T) {
+	primary, secondary := net.Pipe()
+	defer primary.Close()
+	received := make(chan *asdu.ASDU, 1)
+	o := newTestOutstation(t, secondary, func(c asdu.Connect, a *asdu.ASDU) error {
+		received <- a
+		return nil
+	})
+	done := make(chan error, 1)
+	go func() { done <- o.Serve() }()
+
+	cmd := asdu.NewASDU(params101, asdu.Identifier{Type: asdu.C_IC_NA_1, Variable: asdu.VariableStruct{Number: 1}, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1})
+	if err := cmd.AppendInfoObjAddr(0); err != nil {
+		t.Fatal(err)
+	}
+	cmd.AppendBytes(byte(asdu.QOIStation))
+	raw, err := cmd.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := Ft12{Start: startVarFrame, Ctrl: RPM | FCV | FCB | FccUserDataWithConfirmed, Address: 1, ASDU: raw}
+	b, err := DefaultConfig().AppendFrame(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = primary.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	f, err := DefaultConfig().NewDecoder(primary).Next()
+	if err != nil || f.Function() != FcsConfirmed {
+		t.Fatalf("response = %+v, %v", f, err)
+	}
+	select {
+	case a := <-received:
+		if a.Type != asdu.C_IC_NA_1 {
+			t.Errorf("received type = %v", a.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("user data not received")
+	}
+	primary.Close()
+	if err := <-done; err == nil {
+		t.Error("Serve() returned nil after the transport closed")
+	}
+}
+
+func TestOutstation_ServerHandler(t *testing.T) {
+	var got []asdu.QualifierOfInterrogation
+	h := &interrogationHandler{qois: &got}
+	o := newTestOutstation(t, nil, ServerHandler(h))
+	if o.IsConnected() {
+		t.Error("IsConnected() before reset")
+	}
+	o.respond(request(FccResetRemoteLink, 0))
+	if !o.IsConnected() {
+		t.Error("IsConnected() after reset = false")
+	}
+
+	cmd := asdu.NewASDU(params101, asdu.Identifier{Type: asdu.C_IC_NA_1, Variable: asdu.VariableStruct{Number: 1},
+		Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1})
+	if err := cmd.AppendInfoObjAddr(0); err != nil {
+		t.Fatal(err)
+	}
+	cmd.AppendBytes(byte(asdu.QOIStation))
+	raw, err := cmd.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := Ft12{Start: startVarFrame, Ctrl: RPM | FCV | FCB | FccUserDataWithConfirmed, Address: 1, ASDU: raw}
+	_, a := o.respond(req)
+	if a == nil {
+		t.Fatal("user data not decoded")
+	}
+	if err = ServerHandler(h)(o, a); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != asdu.QOIStation {
+		t.Errorf("interrogations = %v", got)
+	}
+	if o.Pending(Class1) != 1 {
+		t.Errorf("Pending(Class1) = %d, want the activation confirmation", o.Pending(Class1))
+	}
+}
+
+type interrogationHandler struct {
+	cs104.ServerHandlerInterface
+	qois *[]asdu.QualifierOfInterrogation
+}
+
+func (sf *interrogationHandler) InterrogationHandler(c asdu.Connect, a *asdu.ASDU, qoi asdu.QualifierOfInterrogation) error {
+	*sf.qois = append(*sf.qois, qoi)
+	return a.SendReplyMirror(c, asdu.ActivationCon)
+}
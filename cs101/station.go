@@ -0,0 +1,18 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs101
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+// ServerHandler the user data handler of the Outstation and the Balanced station passing the system
+// commands to the cs104 server handler, so that the same handler serves both the transports.
+func ServerHandler(h cs104.ServerHandlerInterface) func(c asdu.Connect, a *asdu.ASDU) error {
+	return func(c asdu.Connect, a *asdu.ASDU) error {
+		return cs104.Dispatch(c, h, a)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs101
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrTransportClosed the transport is closed
+var ErrTransportClosed = asdu.NewError(asdu.ErrTransport, "transport closed")
+
+// transport defaults defined
+const (
+	DefaultReconnectInterval = 5 * time.Second
+	DefaultDialTimeout       = 10 * time.Second
+	DefaultTCPKeepalive      = 30 * time.Second
+)
+
+// Transport the byte stream the FT1.2 link layer runs on, a local serial port or the raw socket of a
+// serial device server. A read or write error breaks the stream, the link layer resets the link then.
+type Transport interface {
+	io.ReadWriteCloser
+}
+
+// TCPTransport the serial line exposed over tcp by a serial device server (terminal server), for example
+// a moxa NPort in tcp server mode, the link layer runs over the raw socket as over the serial port.
+// The broken connection is reconnected: the read failing returns its error once, so that the link layer
+// resets the link, the next read connects again, waiting the reconnect interval between the attempts.
+// The dead connections are detected by the tcp keepalive and, if set, the idle timeout.
+type TCPTransport struct {
+	addr      string
+	dialer    net.Dialer
+	reconnect time.Duration
+	idle      time.Duration
+	onConnect func(conn net.Conn)
+
+	mux    sync.Mutex
+	conn   net.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var _ Transport = (*TCPTransport)(nil)
+
+// NewTCPTransport new a transport to the serial device server at the tcp address addr
+func NewTCPTransport(addr string) *TCPTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TCPTransport{
+		addr:      addr,
+		dialer:    net.Dialer{Timeout: DefaultDialTimeout, KeepAlive: DefaultTCPKeepalive},
+		reconnect: DefaultReconnectInterval,
+		onConnect: func(net.Conn) {},
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// SetReconnectInterval set the interval between the connection attempts
+func (sf *TCPTransport) SetReconnectInterval(t time.Duration) *TCPTransport {
+	if t > 0 {
+		sf.reconnect = t
+	}
+	return sf
+}
+
+// SetDialTimeout set the timeout of a connection attempt
+func (sf *TCPTransport) SetDialTimeout(t time.Duration) *TCPTransport {
+	if t > 0 {
+		sf.dialer.Timeout = t
+	}
+	return sf
+}
+
+// SetKeepalive set the period of the tcp keepalive probes, negative disables
+func (sf *TCPTransport) SetKeepalive(t time.Duration) *TCPTransport {
+	sf.dialer.KeepAlive = t
+	return sf
+}
+
+// SetIdleTimeout set the time without any byte received after which the connection is closed
+// as dead, for example more than the test period of the link on a balanced line, zero disables.
+func (sf *TCPTransport) SetIdleTimeout(t time.Duration) *TCPTransport {
+	sf.idle = t
+	return sf
+}
+
+// SetOnConnectHandler set the handler called on every connection established
+func (sf *TCPTransport) SetOnConnectHandler(f func(conn net.Conn)) *TCPTransport {
+	if f != nil {
+		sf.onConnect = f
+	}
+	return sf
+}
+
+// Read imp interface io.Reader, it blocks until connected
+func (sf *TCPTransport) Read(p []byte) (int, error) {
+	conn, err := sf.connect(true)
+	if err != nil {
+		return 0, err
+	}
+	if sf.idle > 0 {
+		_ = conn.SetReadDeadline(time.Now().Add(sf.idle))
+	}
+	n, err := conn.Read(p)
+	if err != nil {
+		return n, sf.drop(conn, err)
+	}
+	return n, nil
+}
+
+// Write imp interface io.Writer, it makes one connection attempt if not connected
+func (sf *TCPTransport) Write(p []byte) (int, error) {
+	conn, err := sf.connect(false)
+	if err != nil {
+		return 0, err
+	}
+	n, err := conn.Write(p)
+	if err != nil {
+		return n, sf.drop(conn, err)
+	}
+	return n, nil
+}
+
+// Close imp interface io.Closer, the reads and writes blocked return ErrTransportClosed
+func (sf *TCPTransport) Close() error {
+	sf.cancel()
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.conn == nil {
+		return nil
+	}
+	err := sf.conn.Close()
+	sf.conn = nil
+	return err
+}
+
+// connect returns the current connection or connects, until connected if retry
+func (sf *TCPTransport) connect(retry bool) (net.Conn, error) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	for {
+		if sf.ctx.Err() != nil {
+			return nil, ErrTransportClosed
+		}
+		if sf.conn != nil {
+			return sf.conn, nil
+		}
+		conn, err := sf.dialer.DialContext(sf.ctx, "tcp", sf.addr)
+		if err == nil {
+			sf.conn = conn
+			sf.onConnect(conn)
+			return conn, nil
+		}
+		if !retry {
+			return nil, err
+		}
+		sf.mux.Unlock()
+		select {
+		case <-sf.ctx.Done():
+		case <-time.After(sf.reconnect):
+		}
+		sf.mux.Lock()
+	}
+}
+
+// drop close the broken connection, returns the error of the operation
+func (sf *TCPTransport) drop(conn net.Conn, err error) error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.ctx.Err() != nil {
+		return ErrTransportClosed
+	}
+	if sf.conn == conn {
+		_ = conn.Close()
+		sf.conn = nil
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package cs101
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPTransport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	connects := 0
+	tr := NewTCPTransport(ln.Addr().String()).SetReconnectInterval(10 * time.Millisecond).
+		SetOnConnectHandler(func(net.Conn) { connects++ })
+	defer tr.Close()
+
+	if _, err = tr.Write([]byte{SingleCharAck}); err != nil {
+		t.Fatal(err)
+	}
+	server := <-accepted
+	b := make([]byte, 1)
+	if _, err = io.ReadFull(server, b); err != nil || b[0] != SingleCharAck {
+		t.Fatalf("server read %x, %v", b, err)
+	}
+
+	// the server drops the connection, the read fails once then reconnects
+	server.Close()
+	if _, err = tr.Read(b); err == nil {
+		t.Fatal("Read() on a broken connection succeeded")
+	}
+	go func() {
+		c := <-accepted
+		_, _ = c.Write([]byte{startFixFrame})
+		accepted <- c
+	}()
+	if _, err = tr.Read(b); err != nil || b[0] != startFixFrame {
+		t.Fatalf("Read() = %x, %v after reconnection", b, err)
+	}
+	if connects != 2 {
+		t.Errorf("connected %d times, want 2", connects)
+	}
+	server = <-accepted
+	defer server.Close()
+
+	// no byte within the idle timeout
+	tr.SetIdleTimeout(20 * time.Millisecond)
+	var ne net.Error
+	if _, err = tr.Read(b); !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Read() error = %v, want a timeout", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := tr.Read(b)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	tr.Close()
+	if err = <-done; err != ErrTransportClosed {
+		t.Errorf("Read() error = %v, want %v", err, ErrTransportClosed)
+	}
+}
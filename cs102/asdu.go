@@ -0,0 +1,145 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package cs102 the application service data units of the companion standard IEC 60870-5-102,
+// transmission of integrated totals in electric power systems (metering).
+// The link layer is the FT1.2 of the companion standard 101, see package cs101.
+package cs102
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// error defined
+var (
+	ErrTypeIdentifier = errors.New("cs102: type identification unknown")
+	ErrLength         = errors.New("cs102: asdu length invalid")
+	ErrTypeIDNotMatch = errors.New("cs102: type identification not match")
+	ErrTooManyObjects = errors.New("cs102: too many information objects")
+)
+
+// ASDUSizeMax asdu max size, the same as the companion standard 101
+const ASDUSizeMax = asdu.ASDUSizeMax
+
+// identifierSize type(1) + variable structure(1) + cause(1) + address(2) + record address(1)
+const identifierSize = 6
+
+// TypeID the type identification of the companion standard 102
+type TypeID uint8
+
+// TypeID defined, see IEC 60870-5-102, subclass 7.2.1.1
+const (
+	// process information in monitor direction
+	M_SP_TA_2 TypeID = 1  // single-point information with time tag
+	M_IT_TA_2 TypeID = 2  // accounting integrated totals, 4 octets each
+	M_IT_TB_2 TypeID = 3  // accounting integrated totals, 3 octets each
+	M_IT_TC_2 TypeID = 4  // accounting integrated totals, 2 octets each
+	M_IT_TD_2 TypeID = 5  // periodically reset accounting integrated totals, 4 octets each
+	M_IT_TE_2 TypeID = 6  // periodically reset accounting integrated totals, 3 octets each
+	M_IT_TF_2 TypeID = 7  // periodically reset accounting integrated totals, 2 octets each
+	M_IT_TG_2 TypeID = 8  // operational integrated totals, 4 octets each
+	M_IT_TH_2 TypeID = 9  // operational integrated totals, 3 octets each
+	M_IT_TI_2 TypeID = 10 // operational integrated totals, 2 octets each
+	M_IT_TK_2 TypeID = 11 // periodically reset operational integrated totals, 4 octets each
+	M_IT_TL_2 TypeID = 12 // periodically reset operational integrated totals, 3 octets each
+	M_IT_TM_2 TypeID = 13 // periodically reset operational integrated totals, 2 octets each
+
+	// system information in monitor direction
+	M_EI_NA_2 TypeID = 70 // end of initialization
+	P_MP_NA_2 TypeID = 71 // manufacturer and product specification of integrated total data terminal equipment
+	M_TI_TA_2 TypeID = 72 // current system time of integrated total data terminal equipment
+
+	// system information in control direction
+	C_RD_NA_2 TypeID = 100 // read manufacturer and product specification
+	C_SP_NA_2 TypeID = 101 // read record of single-point information with time tag
+	C_SP_NB_2 TypeID = 102 // read record of single-point information with time tag of a selected time range
+	C_TI_NA_2 TypeID = 103 // read current system time of integrated total data terminal equipment
+	C_CI_NR_2 TypeID = 104 // read accounting integrated totals of the oldest integration period
+	C_CI_NS_2 TypeID = 105 // read periodically reset accounting integrated totals of the oldest integration period
+)
+
+var typeIDNames = map[TypeID]string{
+	M_SP_TA_2: "M_SP_TA_2", M_IT_TA_2: "M_IT_TA_2", M_IT_TB_2: "M_IT_TB_2", M_IT_TC_2: "M_IT_TC_2",
+	M_IT_TD_2: "M_IT_TD_2", M_IT_TE_2: "M_IT_TE_2", M_IT_TF_2: "M_IT_TF_2", M_IT_TG_2: "M_IT_TG_2",
+	M_IT_TH_2: "M_IT_TH_2", M_IT_TI_2: "M_IT_TI_2", M_IT_TK_2: "M_IT_TK_2", M_IT_TL_2: "M_IT_TL_2",
+	M_IT_TM_2: "M_IT_TM_2", M_EI_NA_2: "M_EI_NA_2", P_MP_NA_2: "P_MP_NA_2", M_TI_TA_2: "M_TI_TA_2",
+	C_RD_NA_2: "C_RD_NA_2", C_SP_NA_2: "C_SP_NA_2", C_SP_NB_2: "C_SP_NB_2", C_TI_NA_2: "C_TI_NA_2",
+	C_CI_NR_2: "C_CI_NR_2", C_CI_NS_2: "C_CI_NS_2",
+}
+
+func (sf TypeID) String() string {
+	if s, ok := typeIDNames[sf]; ok {
+		return "TID<" + s + ">"
+	}
+	return fmt.Sprintf("TID<%d>", uint8(sf))
+}
+
+// Cause of transmission specific to the companion standard 102, the others are the same as asdu.Cause
+const (
+	NoRecord            asdu.Cause = 13 // requested data record not available
+	NoASDUType          asdu.Cause = 14 // requested asdu type not available
+	UnknownRecord       asdu.Cause = 15 // record number in the asdu sent by the master unknown
+	UnknownAddrSpec     asdu.Cause = 16 // address specification in the asdu sent by the master unknown
+	NoInfoObj           asdu.Cause = 17 // requested information object not available
+	NoIntegrationPeriod asdu.Cause = 18 // requested integration period not available
+)
+
+// RecordAddr the record address (RAD) selecting the data record, see IEC 60870-5-102, subclass 7.2.2.
+// 0 is the default record address.
+type RecordAddr byte
+
+// Identifier the data unit identifier of the asdu
+type Identifier struct {
+	Type     TypeID
+	Variable asdu.VariableStruct
+	Coa      asdu.CauseOfTransmission
+	Addr     uint16     // address of the integrated total data terminal equipment
+	Record   RecordAddr // record address
+}
+
+func (sf Identifier) String() string {
+	return fmt.Sprintf("%s %s %s @%d/%d", sf.Type, sf.Variable, sf.Coa, sf.Addr, sf.Record)
+}
+
+// ASDU the application service data unit of the companion standard 102
+type ASDU struct {
+	Identifier
+	infoObj []byte
+}
+
+// NewASDU new an asdu with the identifier, the information objects are appended by the builders
+func NewASDU(id Identifier) *ASDU {
+	return &ASDU{Identifier: id}
+}
+
+// MarshalBinary honors the encoding.BinaryMarshaler interface.
+func (sf *ASDU) MarshalBinary() ([]byte, error) {
+	if identifierSize+len(sf.infoObj) > ASDUSizeMax {
+		return nil, ErrLength
+	}
+	raw := make([]byte, 0, identifierSize+len(sf.infoObj))
+	raw = append(raw, byte(sf.Type), sf.Variable.Value(), sf.Coa.Value(),
+		byte(sf.Addr), byte(sf.Addr>>8), byte(sf.Record))
+	return append(raw, sf.infoObj...), nil
+}
+
+// UnmarshalBinary honors the encoding.BinaryUnmarshaler interface.
+func (sf *ASDU) UnmarshalBinary(data []byte) error {
+	if len(data) < identifierSize || len(data) > ASDUSizeMax {
+		return ErrLength
+	}
+	sf.Type = TypeID(data[0])
+	sf.Variable = asdu.ParseVariableStruct(data[1])
+	sf.Coa = asdu.ParseCauseOfTransmission(data[2])
+	sf.Addr = uint16(data[3]) | uint16(data[4])<<8
+	sf.Record = RecordAddr(data[5])
+	sf.infoObj = append(sf.infoObj[:0], data[identifierSize:]...)
+	if _, ok := typeIDNames[sf.Type]; !ok {
+		return ErrTypeIdentifier
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs102
+
+import (
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// TimeA the time information a, 5 octets binary time with the minute resolution
+// | IV(D7)   TIS(D6)    Minutes(D5--D0)  | Minutes = 0-59, TIS = tariff information switch
+// | SU(D7)   RES1(D6-D5)  Hours(D4--D0)  | Hours = 0-23, SU = summer time
+// | DayOfWeek(D7--D5) DayOfMonth(D4--D0)| DayOfMonth = 1-31  DayOfWeek = 1-7
+// | PTI(D7-D6) ETI(D5-D4) Months(D3--D0)| Months = 1-12, ETI, PTI = energy and power tariff information
+// | RES2(D7)            Year(D6--D0)    | Year = 0-99
+// See IEC 60870-5-102, subclass 7.2.6.2.
+func TimeA(t time.Time, loc *time.Location) []byte {
+	if loc == nil {
+		loc = time.UTC
+	}
+	ts := t.In(loc)
+	return []byte{byte(ts.Minute()), byte(ts.Hour()), byte(ts.Weekday()<<5) | byte(ts.Day()),
+		byte(ts.Month()), byte(ts.Year() - 2000)}
+}
+
+// ParseTimeA parse the 5 octets time information a, the zero time if invalid
+func ParseTimeA(b []byte, loc *time.Location) time.Time {
+	if len(b) < 5 || b[0]&0x80 == 0x80 {
+		return time.Time{}
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(2000+int(b[4]&0x7f), time.Month(b[3]&0x0f), int(b[2]&0x1f),
+		int(b[1]&0x1f), int(b[0]&0x3f), 0, 0, loc)
+}
+
+// IntegratedTotal an integrated total with its sequence number and qualifiers,
+// the value is truncated to the size of the type identification.
+type IntegratedTotal struct {
+	Ioa        byte
+	Value      uint32
+	SeqNumber  byte // [0, 31]
+	HasCarry   bool
+	IsAdjusted bool
+	IsInvalid  bool
+}
+
+// totalSize the size of the integrated total of the type identification, 0 if not an integrated total
+func totalSize(id TypeID) int {
+	if id < M_IT_TA_2 || id > M_IT_TM_2 {
+		return 0
+	}
+	return 4 - int(id-M_IT_TA_2)%3
+}
+
+// IntegratedTotals new an asdu of integrated totals [M_IT_TA_2] - [M_IT_TM_2] of the integration period
+// ending at t, the time information a is common to all the integrated totals.
+func IntegratedTotals(id Identifier, t time.Time, loc *time.Location, totals ...IntegratedTotal) (*ASDU, error) {
+	size := totalSize(id.Type)
+	if size == 0 {
+		return nil, ErrTypeIDNotMatch
+	}
+	if len(totals) == 0 || identifierSize+len(totals)*(size+2)+5 > ASDUSizeMax {
+		return nil, ErrTooManyObjects
+	}
+	id.Variable = asdu.VariableStruct{Number: byte(len(totals))}
+	u := NewASDU(id)
+	for _, v := range totals {
+		u.infoObj = append(u.infoObj, v.Ioa)
+		for i := 0; i < size; i++ {
+			u.infoObj = append(u.infoObj, byte(v.Value>>(8*uint(i))))
+		}
+		q := v.SeqNumber & 0x1f
+		if v.HasCarry {
+			q |= 0x20
+		}
+		if v.IsAdjusted {
+			q |= 0x40
+		}
+		if v.IsInvalid {
+			q |= 0x80
+		}
+		u.infoObj = append(u.infoObj, q)
+	}
+	u.infoObj = append(u.infoObj, TimeA(t, loc)...)
+	return u, nil
+}
+
+// GetIntegratedTotals [M_IT_TA_2] - [M_IT_TM_2] get the integrated totals and the end of the integration period
+func (sf *ASDU) GetIntegratedTotals(loc *time.Location) ([]IntegratedTotal, time.Time, error) {
+	size := totalSize(sf.Type)
+	if size == 0 {
+		return nil, time.Time{}, ErrTypeIDNotMatch
+	}
+	n := int(sf.Variable.Number)
+	if len(sf.infoObj) != n*(size+2)+5 {
+		return nil, time.Time{}, ErrLength
+	}
+	totals := make([]IntegratedTotal, 0, n)
+	for off := 0; len(totals) < n; off += size + 2 {
+		v := IntegratedTotal{Ioa: sf.infoObj[off]}
+		for i := 0; i < size; i++ {
+			v.Value |= uint32(sf.infoObj[off+1+i]) << (8 * uint(i))
+		}
+		q := sf.infoObj[off+1+size]
+		v.SeqNumber = q & 0x1f
+		v.HasCarry = q&0x20 == 0x20
+		v.IsAdjusted = q&0x40 == 0x40
+		v.IsInvalid = q&0x80 == 0x80
+		totals = append(totals, v)
+	}
+	return totals, ParseTimeA(sf.infoObj[len(sf.infoObj)-5:], loc), nil
+}
+
+// ReadIntegratedTotals new a read command [C_CI_NR_2] or [C_CI_NS_2] of the integrated totals of
+// the oldest integration period in the range of the information object addresses [first, last]
+func ReadIntegratedTotals(id Identifier, first, last byte) (*ASDU, error) {
+	if id.Type != C_CI_NR_2 && id.Type != C_CI_NS_2 {
+		return nil, ErrTypeIDNotMatch
+	}
+	id.Variable = asdu.VariableStruct{Number: 1}
+	u := NewASDU(id)
+	u.infoObj = append(u.infoObj, first, last)
+	return u, nil
+}
+
+// GetReadIntegratedTotals [C_CI_NR_2] or [C_CI_NS_2] get the range of the information object addresses
+func (sf *ASDU) GetReadIntegratedTotals() (first, last byte, err error) {
+	if sf.Type != C_CI_NR_2 && sf.Type != C_CI_NS_2 {
+		return 0, 0, ErrTypeIDNotMatch
+	}
+	if len(sf.infoObj) != 2 {
+		return 0, 0, ErrLength
+	}
+	return sf.infoObj[0], sf.infoObj[1], nil
+}
+
+// SystemTime new the current system time [M_TI_TA_2] answering the read [C_TI_NA_2],
+// the time information b is the 7 octets binary time asdu.CP56Time2a.
+func SystemTime(id Identifier, t time.Time, loc *time.Location) *ASDU {
+	id.Type = M_TI_TA_2
+	id.Variable = asdu.VariableStruct{Number: 1}
+	u := NewASDU(id)
+	u.infoObj = append(u.infoObj, asdu.CP56Time2a(t, loc)...)
+	return u
+}
+
+// GetSystemTime [M_TI_TA_2] get the current system time
+func (sf *ASDU) GetSystemTime(loc *time.Location) (time.Time, error) {
+	if sf.Type != M_TI_TA_2 {
+		return time.Time{}, ErrTypeIDNotMatch
+	}
+	if len(sf.infoObj) != 7 {
+		return time.Time{}, ErrLength
+	}
+	return asdu.ParseCP56Time2a(sf.infoObj, loc), nil
+}
@@ -0,0 +1,73 @@
+package cs102
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestIntegratedTotals(t *testing.T) {
+	end := time.Date(2020, 3, 1, 15, 0, 0, 0, time.UTC)
+	totals := []IntegratedTotal{
+		{Ioa: 1, Value: 0x123456, SeqNumber: 3},
+		{Ioa: 2, Value: 0xffffffff, SeqNumber: 3, HasCarry: true, IsInvalid: true},
+	}
+	tests := []struct {
+		typeID TypeID
+		size   int
+	}{
+		{M_IT_TA_2, 4},
+		{M_IT_TH_2, 3},
+		{M_IT_TM_2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typeID.String(), func(t *testing.T) {
+			id := Identifier{Type: tt.typeID, Coa: asdu.CauseOfTransmission{Cause: asdu.Request}, Addr: 0x102, Record: 11}
+			u, err := IntegratedTotals(id, end, time.UTC, totals...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := u.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := identifierSize + 2*(tt.size+2) + 5; len(data) != want {
+				t.Fatalf("MarshalBinary() length %d, want %d", len(data), want)
+			}
+
+			got := &ASDU{}
+			if err = got.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			if got.Addr != 0x102 || got.Record != 11 {
+				t.Errorf("UnmarshalBinary() identifier %v", got.Identifier)
+			}
+			values, tm, err := got.GetIntegratedTotals(time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := append([]IntegratedTotal(nil), totals...)
+			for i := range want {
+				want[i].Value &= 1<<(8*uint(tt.size)) - 1
+			}
+			if !reflect.DeepEqual(values, want) || !tm.Equal(end) {
+				t.Errorf("GetIntegratedTotals() = %v %v, want %v %v", values, tm, want, end)
+			}
+		})
+	}
+}
+
+func TestReadIntegratedTotals(t *testing.T) {
+	u, err := ReadIntegratedTotals(Identifier{Type: C_CI_NR_2, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}}, 1, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first, last, err := u.GetReadIntegratedTotals(); err != nil || first != 1 || last != 8 {
+		t.Errorf("GetReadIntegratedTotals() = %d %d %v", first, last, err)
+	}
+	if _, err = ReadIntegratedTotals(Identifier{Type: M_IT_TA_2}, 1, 8); err != ErrTypeIDNotMatch {
+		t.Errorf("ReadIntegratedTotals() error = %v, want %v", err, ErrTypeIDNotMatch)
+	}
+}
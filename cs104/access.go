@@ -0,0 +1,106 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Access the rights of a peer granted by the Authorizer
+type Access uint32
+
+// access defined
+const (
+	// AccessFull the peer may send any asdu, the default
+	AccessFull Access = iota
+	// AccessReadOnly the peer may interrogate, read and test, the process commands, parameter loading,
+	// clock synchronization and reset process commands are negative confirmed.
+	AccessReadOnly
+	// AccessDeny the connection is closed
+	AccessDeny
+)
+
+// String returns the name of the access
+func (sf Access) String() string {
+	switch sf {
+	case AccessFull:
+		return "full"
+	case AccessReadOnly:
+		return "read-only"
+	case AccessDeny:
+		return "deny"
+	}
+	return "unknown"
+}
+
+// Peer the remote end of a connection submitted to the Authorizer
+type Peer struct {
+	Addr net.Addr
+	// Certificates the certificate chain presented by the peer, the leaf first, nil without tls.
+	Certificates []*x509.Certificate
+	// StartDT false when the connection is accepted, true when the peer starts the data transfer
+	StartDT bool
+}
+
+// Authorizer decides the access of the peer, it is called when the connection is accepted
+// and again on every STARTDT, which may restrict or deny a peer already connected.
+type Authorizer func(peer Peer) Access
+
+// SetAuthorizer set the authorizer of the connections, nil grants full access to all.
+// With the TLSConfig set, the handshake completes before the authorizer is called at accept time,
+// a failed handshake is denied.
+func (sf *Server) SetAuthorizer(f Authorizer) *Server {
+	sf.authorizer = f
+	return sf
+}
+
+// authorize returns the access of the peer of the connection
+func (sf *Server) authorize(conn net.Conn, startDT bool) Access {
+	peer := Peer{Addr: conn.RemoteAddr(), StartDT: startDT}
+	if tc, ok := conn.(*tls.Conn); ok {
+		if !tc.ConnectionState().HandshakeComplete {
+			_ = tc.SetDeadline(time.Now().Add(sf.config.ConnectTimeout0))
+			err := tc.Handshake()
+			_ = tc.SetDeadline(time.Time{})
+			if err != nil {
+				sf.Warn("peer %v denied, tls handshake %v", peer.Addr, err)
+				return AccessDeny
+			}
+		}
+		peer.Certificates = tc.ConnectionState().PeerCertificates
+	}
+	access := sf.authorizer(peer)
+	if sf.readOnly && access == AccessFull {
+		access = AccessReadOnly
+	}
+	if access == AccessDeny {
+		sf.Warn("peer %v denied", peer.Addr)
+	} else {
+		sf.Debug("peer %v granted %v access", peer.Addr, access)
+	}
+	return access
+}
+
+// setAccess set the access of the session
+func (sf *SrvSession) setAccess(access Access) {
+	atomic.StoreUint32(&sf.access, uint32(access))
+}
+
+// readOnly whether the session has read-only access
+func (sf *SrvSession) readOnly() bool {
+	return Access(atomic.LoadUint32(&sf.access)) == AccessReadOnly
+}
+
+// isControl whether the asdu changes the state of the controlled station, denied to the read-only peers
+func isControl(id asdu.TypeID) bool {
+	return isProcessCommand(id) || id == asdu.C_CS_NA_1 || id == asdu.C_RP_NA_1 ||
+		(id >= asdu.P_ME_NA_1 && id <= asdu.P_AC_NA_1)
+}
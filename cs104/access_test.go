@@ -0,0 +1,78 @@
+package cs104
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSrvSession_readOnly(t *testing.T) {
+	h := &mockServerHandler{}
+	sess := newTestSession(h)
+	sess.setAccess(AccessReadOnly)
+	rc := &recordConn{}
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+
+	_ = asdu.SingleCmd(rc, asdu.C_SC_NA_1, act, 1, asdu.SingleCommandInfo{Ioa: 1, Value: true})
+	_ = asdu.InterrogationCmd(rc, act, 1, asdu.QOIStation)
+	for _, a := range rc.take() {
+		if err := sess.serverHandler(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sent := sess.sent(t)
+	if len(sent) != 1 || sent[0].Type != asdu.C_SC_NA_1 || sent[0].Coa.Cause != asdu.ActivationCon || !sent[0].Coa.IsNegative {
+		t.Fatalf("sent %d asdu, want the command negative confirmed", len(sent))
+	}
+	if len(h.cas) != 1 {
+		t.Errorf("handled %d asdu, want the interrogation", len(h.cas))
+	}
+}
+
+func TestServer_SetAuthorizer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	peers := make(chan Peer, 4)
+	srv := NewServer(&mockServerHandler{})
+	srv.SetAuthorizer(func(peer Peer) Access {
+		peers <- peer
+		if peer.StartDT {
+			return AccessDeny
+		}
+		return AccessReadOnly
+	})
+	go srv.ListenAndServer(addr)
+	defer srv.Close()
+
+	var conn net.Conn
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if p := <-peers; p.StartDT || p.Addr.String() != conn.LocalAddr().String() {
+		t.Errorf("authorizer got %+v at accept, want the peer address", p)
+	}
+
+	if _, err = conn.Write(newUFrame(uStartDtActive)); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := readTestAPDU(t, conn); head != nil {
+		t.Errorf("got %v, want the connection closed on STARTDT", head)
+	}
+	if p := <-peers; !p.StartDT {
+		t.Errorf("authorizer got %+v, want the STARTDT", p)
+	}
+}
@@ -86,7 +86,7 @@ func newIFrame(sendSN, RcvSN uint16, asdus []byte) ([]byte, error) {
 		return nil, fmt.Errorf("ASDU filed large than max %d", asdu.ASDUSizeMax)
 	}
 
-	b := make([]byte, len(asdus)+6)
+	b := getFrame(len(asdus) + 6)
 
 	b[0] = startFrame
 	b[1] = byte(len(asdus) + 4)
@@ -116,6 +116,41 @@ type APCI struct {
 	ctr1, ctr2, ctr3, ctr4 byte
 }
 
+// ParseAPDU validates a whole apdu, start character and length included,
+// and its control field. It never panics, which makes it the entry point for fuzzing.
+func ParseAPDU(apdu []byte) error {
+	switch {
+	case len(apdu) < APCICtlFiledSize+2 || len(apdu) > APDUSizeMax:
+		return fmt.Errorf("%w: length %d out of range", ErrAPDU, len(apdu))
+	case apdu[0] != startFrame:
+		return fmt.Errorf("%w: start character 0x%02x", ErrAPDU, apdu[0])
+	case int(apdu[1])+2 != len(apdu):
+		return fmt.Errorf("%w: apdu length %d, got %d bytes", ErrAPDU, apdu[1], len(apdu)-2)
+	}
+
+	apci, asdus := parse(apdu)
+	switch head := apci.(type) {
+	case iAPCI:
+		if len(asdus) == 0 {
+			return fmt.Errorf("%w: %v without asdu", ErrAPDU, head)
+		}
+	case sAPCI:
+		if len(asdus) != 0 {
+			return fmt.Errorf("%w: %v with asdu", ErrAPDU, head)
+		}
+	case uAPCI:
+		switch head.function {
+		case uStartDtActive, uStartDtConfirm, uStopDtActive, uStopDtConfirm, uTestFrActive, uTestFrConfirm:
+		default:
+			return fmt.Errorf("%w: U frame function 0x%02x", ErrAPDU, head.function)
+		}
+		if len(asdus) != 0 {
+			return fmt.Errorf("%w: %v with asdu", ErrAPDU, head)
+		}
+	}
+	return nil
+}
+
 // return frame type , APCI, remain data
 func parse(apdu []byte) (interface{}, []byte) {
 	apci := APCI{apdu[0], apdu[1], apdu[2], apdu[3], apdu[4], apdu[5]}
@@ -1,6 +1,7 @@
 package cs104
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -174,3 +175,64 @@ func Test_parse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAPDU(t *testing.T) {
+	tests := []struct {
+		name    string
+		apdu    []byte
+		wantErr bool
+	}{
+		{"I frame", []byte{startFrame, 0x05, 0x02, 0x00, 0x03, 0x00, 0x01}, false},
+		{"I frame without asdu", []byte{startFrame, 0x04, 0x02, 0x00, 0x03, 0x00}, true},
+		{"S frame", []byte{startFrame, 0x04, 0x01, 0x00, 0x02, 0x00}, false},
+		{"U frame", []byte{startFrame, 0x04, 0x43, 0x00, 0x00, 0x00}, false},
+		{"U frame two functions", []byte{startFrame, 0x04, 0x0f, 0x00, 0x00, 0x00}, true},
+		{"bad start", []byte{0x67, 0x04, 0x07, 0x00, 0x00, 0x00}, true},
+		{"bad length", []byte{startFrame, 0x05, 0x07, 0x00, 0x00, 0x00}, true},
+		{"short", []byte{startFrame, 0x04, 0x07}, true},
+		{"empty", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseAPDU(tt.apdu)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAPDU() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrAPDU) {
+				t.Errorf("ParseAPDU() error = %v, want %v", err, ErrAPDU)
+			}
+		})
+	}
+}
+
+func FuzzParseAPDU(f *testing.F) {
+	f.Add([]byte{startFrame, 0x05, 0x02, 0x00, 0x03, 0x00, 0x01})
+	f.Add([]byte{startFrame, 0x04, 0x01, 0x00, 0x02, 0x00})
+	f.Add([]byte{startFrame, 0x04, 0x07, 0x00, 0x00, 0x00})
+	f.Fuzz(func(t *testing.T, apdu []byte) {
+		_ = ParseAPDU(apdu)
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	iframe, err := newIFrame(1, 2, []byte{0x0d, 0x01, 0x03, 0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x00, 0x00, 0xc0, 0x3f, 0x00})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bb := range []struct {
+		name string
+		apdu []byte
+	}{
+		{"I", iframe},
+		{"S", newSFrame(3)},
+		{"U", newUFrame(uTestFrActive)},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bb.apdu)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parse(bb.apdu)
+			}
+		})
+	}
+}
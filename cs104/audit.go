@@ -0,0 +1,214 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// AuditResult the stage of a control direction asdu recorded by the audit trail
+type AuditResult byte
+
+// audit result defined
+const (
+	// AuditRequest the activation or deactivation is received by the server or sent by the client
+	AuditRequest AuditResult = iota
+	// AuditAccepted the positive confirmation
+	AuditAccepted
+	// AuditRejected the negative confirmation or the unknown type, cause, common address or object address
+	AuditRejected
+	// AuditTerminated the activation termination
+	AuditTerminated
+)
+
+// String returns the name of the result
+func (sf AuditResult) String() string {
+	switch sf {
+	case AuditRequest:
+		return "request"
+	case AuditAccepted:
+		return "accepted"
+	case AuditRejected:
+		return "rejected"
+	case AuditTerminated:
+		return "terminated"
+	}
+	return "unknown"
+}
+
+// MarshalText implement encoding.TextMarshaler
+func (sf AuditResult) MarshalText() ([]byte, error) { return []byte(sf.String()), nil }
+
+// AuditRecord the audit record of a control direction asdu: the commands, the parameter loading
+// and the system commands except the read command.
+type AuditRecord struct {
+	Time   time.Time        `json:"time"`
+	Local  string           `json:"local,omitempty"`  // local address of the connection
+	Remote string           `json:"remote,omitempty"` // remote address of the connection
+	CA     asdu.CommonAddr  `json:"ca"`
+	IOA    asdu.InfoObjAddr `json:"ioa"`
+	Type   asdu.TypeID      `json:"-"`
+	Cause  asdu.Cause       `json:"-"`
+	// Value the commanded value: the state of the single and double commands, the step, the setpoint,
+	// the bit string or the parameter, else the qualifier of the system command.
+	Value  float64     `json:"value"`
+	Select bool        `json:"select,omitempty"` // select, else execute
+	Result AuditResult `json:"result"`
+}
+
+// MarshalJSON implement json.Marshaler, the type and cause are given by name
+func (sf AuditRecord) MarshalJSON() ([]byte, error) {
+	type record AuditRecord
+	return json.Marshal(struct {
+		record
+		Type  string `json:"type"`
+		Cause string `json:"cause"`
+	}{
+		record(sf),
+		sf.Type.Name(),
+		strings.TrimSuffix(strings.TrimPrefix(asdu.CauseOfTransmission{Cause: sf.Cause}.String(), "COT<"), ">"),
+	})
+}
+
+// AuditSink receives the audit records, it must be safe for concurrent use
+type AuditSink interface {
+	Audit(r AuditRecord)
+}
+
+// AuditSinkFunc an ordinary function used as AuditSink
+type AuditSinkFunc func(r AuditRecord)
+
+// Audit imp interface AuditSink
+func (sf AuditSinkFunc) Audit(r AuditRecord) { sf(r) }
+
+// JSONAuditSink writes each audit record as a line of JSON
+type JSONAuditSink struct {
+	mux sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONAuditSink new an audit sink writing JSON lines to w
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{enc: json.NewEncoder(w)}
+}
+
+// Audit imp interface AuditSink
+func (sf *JSONAuditSink) Audit(r AuditRecord) {
+	sf.mux.Lock()
+	_ = sf.enc.Encode(r)
+	sf.mux.Unlock()
+}
+
+// SetAuditSink set the sink of the audit trail of the control direction asdu the sessions receive
+// and the confirmations and terminations they send, nil for none.
+func (sf *Server) SetAuditSink(s AuditSink) *Server {
+	sf.audit = s
+	return sf
+}
+
+// SetAuditSink set the sink of the audit trail of the control direction asdu the client sends
+// and the confirmations and terminations it receives, nil for none.
+func (sf *Client) SetAuditSink(s AuditSink) *Client {
+	sf.audit = s
+	return sf
+}
+
+// audit send the record of the asdu to the sink if it is a control direction request,
+// confirmation or termination
+func audit(sink AuditSink, conn net.Conn, a *asdu.ASDU) {
+	if sink == nil || !isConfirmable(a.Type) {
+		return
+	}
+	r := AuditRecord{Time: time.Now(), CA: a.CommonAddr, Type: a.Type, Cause: a.Coa.Cause}
+	switch a.Coa.Cause {
+	case asdu.Activation, asdu.Deactivation:
+		r.Result = AuditRequest
+	case asdu.ActivationCon, asdu.DeactivationCon:
+		r.Result = AuditAccepted
+		if a.Coa.IsNegative {
+			r.Result = AuditRejected
+		}
+	case asdu.ActivationTerm:
+		r.Result = AuditTerminated
+	case asdu.UnknownTypeID, asdu.UnknownCOT, asdu.UnknownCA, asdu.UnknownIOA:
+		r.Result = AuditRejected
+	default:
+		return
+	}
+	if conn != nil {
+		r.Local, r.Remote = conn.LocalAddr().String(), conn.RemoteAddr().String()
+	}
+	auditValue(&r, a.Clone())
+	sink.Audit(r)
+}
+
+// auditValue decode the address, value and select of the information object
+func auditValue(r *AuditRecord, a *asdu.ASDU) {
+	defer func() { _ = recover() }() // a malformed asdu keeps the identifier only
+
+	switch a.Type {
+	case asdu.C_SC_NA_1, asdu.C_SC_TA_1:
+		v := a.GetSingleCmd()
+		r.IOA, r.Select = v.Ioa, v.Qoc.InSelect
+		if v.Value {
+			r.Value = 1
+		}
+	case asdu.C_DC_NA_1, asdu.C_DC_TA_1:
+		v := a.GetDoubleCmd()
+		r.IOA, r.Value, r.Select = v.Ioa, float64(v.Value), v.Qoc.InSelect
+	case asdu.C_RC_NA_1, asdu.C_RC_TA_1:
+		v := a.GetStepCmd()
+		r.IOA, r.Value, r.Select = v.Ioa, float64(v.Value), v.Qoc.InSelect
+	case asdu.C_SE_NA_1, asdu.C_SE_TA_1:
+		v := a.GetSetpointNormalCmd()
+		r.IOA, r.Value, r.Select = v.Ioa, v.Value.Float64(), v.Qos.InSelect
+	case asdu.C_SE_NB_1, asdu.C_SE_TB_1:
+		v := a.GetSetpointCmdScaled()
+		r.IOA, r.Value, r.Select = v.Ioa, float64(v.Value), v.Qos.InSelect
+	case asdu.C_SE_NC_1, asdu.C_SE_TC_1:
+		v := a.GetSetpointFloatCmd()
+		r.IOA, r.Value, r.Select = v.Ioa, float64(v.Value), v.Qos.InSelect
+	case asdu.C_BO_NA_1, asdu.C_BO_TA_1:
+		v := a.GetBitsString32Cmd()
+		r.IOA, r.Value = v.Ioa, float64(v.Value)
+	case asdu.P_ME_NA_1:
+		v := a.GetParameterNormal()
+		r.IOA, r.Value = v.Ioa, v.Value.Float64()
+	case asdu.P_ME_NB_1:
+		v := a.GetParameterScaled()
+		r.IOA, r.Value = v.Ioa, float64(v.Value)
+	case asdu.P_ME_NC_1:
+		v := a.GetParameterFloat()
+		r.IOA, r.Value = v.Ioa, float64(v.Value)
+	case asdu.P_AC_NA_1:
+		v := a.GetParameterActivation()
+		r.IOA, r.Value = v.Ioa, float64(v.Qpa)
+	case asdu.C_IC_NA_1:
+		ioa, qoi := a.GetInterrogationCmd()
+		r.IOA, r.Value = ioa, float64(qoi)
+	case asdu.C_CI_NA_1:
+		ioa, qcc := a.GetCounterInterrogationCmd()
+		r.IOA, r.Value = ioa, float64(qcc.Value())
+	case asdu.C_CS_NA_1:
+		r.IOA, _ = a.GetClockSynchronizationCmd()
+	case asdu.C_RP_NA_1:
+		ioa, qrp := a.GetResetProcessCmd()
+		r.IOA, r.Value = ioa, float64(qrp)
+	case asdu.C_CD_NA_1:
+		ioa, msec := a.GetDelayAcquireCommand()
+		r.IOA, r.Value = ioa, float64(msec)
+	case asdu.C_TS_NA_1:
+		r.IOA, _ = a.GetTestCommand()
+	case asdu.C_TS_TA_1:
+		r.IOA, _, _ = a.GetTestCommandCP56Time2a()
+	}
+}
@@ -0,0 +1,61 @@
+package cs104
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSrvSession_audit(t *testing.T) {
+	ch := make(chan AuditRecord, 4)
+	sess := newTestSession(&mockServerHandler{})
+	sess.negConfirm = true
+	sess.audit = AuditSinkFunc(func(r AuditRecord) { ch <- r })
+	rc := &recordConn{}
+	cmd := asdu.DoubleCommandInfo{Ioa: 7, Value: asdu.DCOOn, Qoc: asdu.QualifierOfCommand{InSelect: true}}
+	_ = asdu.DoubleCmd(rc, asdu.C_DC_NA_1, asdu.CauseOfTransmission{Cause: asdu.Activation}, 3, cmd)
+	raw, _ := rc.take()[0].MarshalBinary()
+	sess.rcvASDU = make(chan []byte, 1)
+	sess.rcvASDU <- raw
+	var cancel context.CancelFunc
+	sess.ctx, cancel = context.WithCancel(context.Background())
+	sess.rx.open(sess.ctx, sess.rcvASDU, sess.handle)
+	defer func() {
+		cancel()
+		sess.rx.close()
+	}()
+
+	want := []AuditRecord{
+		{CA: 3, IOA: 7, Type: asdu.C_DC_NA_1, Cause: asdu.Activation, Value: float64(asdu.DCOOn), Select: true, Result: AuditRequest},
+		{CA: 3, IOA: 7, Type: asdu.C_DC_NA_1, Cause: asdu.UnknownTypeID, Value: float64(asdu.DCOOn), Select: true, Result: AuditRejected},
+	}
+	records := make([]AuditRecord, 0, len(want))
+	for range want {
+		select {
+		case r := <-ch:
+			records = append(records, r)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("got %d records, want %d", len(records), len(want))
+		}
+	}
+	for i, r := range records {
+		if r.Time.IsZero() {
+			t.Errorf("record %d without time", i)
+		}
+		r.Time = want[i].Time
+		if r != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, r, want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	NewJSONAuditSink(&buf).Audit(records[1])
+	if s := buf.String(); !strings.Contains(s, `"type":"C_DC_NA_1"`) || !strings.Contains(s, `"cause":"UnknownTypeID"`) ||
+		!strings.Contains(s, `"result":"rejected"`) || !strings.HasSuffix(s, "}\n") {
+		t.Errorf("JSONAuditSink wrote %s", s)
+	}
+}
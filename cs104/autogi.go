@@ -0,0 +1,128 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// AutoInterrogation the station initialization the client performs after each STARTDT con:
+// the clock synchronization, the general interrogation then the counter interrogation, each
+// request is sent once the previous one is confirmed, or terminated for the interrogations.
+type AutoInterrogation struct {
+	// CommonAddr the common address requested, asdu.GlobalCommonAddr for every station
+	CommonAddr asdu.CommonAddr
+	// ClockSync synchronize the clock of the station before the general interrogation
+	ClockSync bool
+	// Counter request the counters by the general counter interrogation after the general interrogation
+	Counter bool
+	// OnComplete called once the last request is terminated, err is ErrNegativeConfirm if a request
+	// is refused or the error of the request which could not be sent, the sequence stops then.
+	OnComplete func(c *Client, err error)
+}
+
+// SetAutoInterrogation issue the requests of the station initialization after each STARTDT con,
+// see AutoInterrogation
+func (sf *ClientOption) SetAutoInterrogation(a AutoInterrogation) *ClientOption {
+	sf.autoGI = &a
+	return sf
+}
+
+// autoInterrogation the progress of the station initialization of a connection
+type autoInterrogation struct {
+	AutoInterrogation
+	steps []asdu.TypeID
+
+	mux  sync.Mutex
+	step int // the pending request of steps, len(steps) once done
+}
+
+func newAutoInterrogation(a *AutoInterrogation) *autoInterrogation {
+	if a == nil {
+		return nil
+	}
+	sf := &autoInterrogation{AutoInterrogation: *a}
+	if a.ClockSync {
+		sf.steps = append(sf.steps, asdu.C_CS_NA_1)
+	}
+	sf.steps = append(sf.steps, asdu.C_IC_NA_1)
+	if a.Counter {
+		sf.steps = append(sf.steps, asdu.C_CI_NA_1)
+	}
+	sf.step = len(sf.steps)
+	return sf
+}
+
+// start the sequence from the first request
+func (sf *autoInterrogation) start(c *Client) {
+	sf.mux.Lock()
+	sf.step = 0
+	sf.mux.Unlock()
+	sf.request(c)
+}
+
+// request send the pending request
+func (sf *autoInterrogation) request(c *Client) {
+	sf.mux.Lock()
+	step := sf.step
+	sf.mux.Unlock()
+
+	var err error
+	switch sf.steps[step] {
+	case asdu.C_CS_NA_1:
+		err = c.ClockSynchronizationCmd(asdu.CauseOfTransmission{Cause: asdu.Activation}, sf.CommonAddr, c.option.params.Now())
+	case asdu.C_IC_NA_1:
+		err = c.InterrogationCmd(asdu.CauseOfTransmission{Cause: asdu.Activation}, sf.CommonAddr, asdu.QOIStation)
+	case asdu.C_CI_NA_1:
+		err = c.CounterInterrogationCmd(asdu.CauseOfTransmission{Cause: asdu.Activation}, sf.CommonAddr,
+			asdu.QualifierCountCall{Request: asdu.QCCTotal, Freeze: asdu.QCCFrzRead})
+	}
+	if err != nil {
+		sf.complete(c, err)
+	}
+}
+
+// observe advance the sequence by the confirmation or the termination of the pending request
+func (sf *autoInterrogation) observe(c *Client, a *asdu.ASDU) {
+	sf.mux.Lock()
+	if sf.step == len(sf.steps) || a.Type != sf.steps[sf.step] ||
+		(sf.CommonAddr != asdu.GlobalCommonAddr && a.CommonAddr != sf.CommonAddr) {
+		sf.mux.Unlock()
+		return
+	}
+	var err error
+	switch {
+	case a.Coa.IsNegative && a.Coa.Cause == asdu.ActivationCon:
+		err = ErrNegativeConfirm
+	case a.Coa.Cause == asdu.ActivationTerm,
+		a.Coa.Cause == asdu.ActivationCon && a.Type == asdu.C_CS_NA_1: // no termination of the clock synchronization
+		sf.step++
+		if sf.step < len(sf.steps) {
+			sf.mux.Unlock()
+			sf.request(c)
+			return
+		}
+	default:
+		sf.mux.Unlock()
+		return
+	}
+	sf.mux.Unlock()
+	sf.complete(c, err)
+}
+
+// complete stop the sequence and notify its result
+func (sf *autoInterrogation) complete(c *Client, err error) {
+	sf.mux.Lock()
+	sf.step = len(sf.steps)
+	sf.mux.Unlock()
+	if err != nil {
+		c.Warn("auto interrogation of common address %d: %v", sf.CommonAddr, err)
+	}
+	if sf.OnComplete != nil {
+		sf.OnComplete(c, err)
+	}
+}
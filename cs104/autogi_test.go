@@ -0,0 +1,97 @@
+package cs104
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type autoServerHandler struct {
+	mockServerHandler
+	refuse asdu.TypeID // the request confirmed negatively
+
+	mux      sync.Mutex
+	requests []asdu.TypeID
+}
+
+func (sf *autoServerHandler) answer(c asdu.Connect, pack *asdu.ASDU, term bool) error {
+	sf.mux.Lock()
+	sf.requests = append(sf.requests, pack.Type)
+	sf.mux.Unlock()
+	if pack.Type == sf.refuse {
+		return c.Send(pack.Mirror(asdu.ActivationCon, true))
+	}
+	if err := c.Send(pack.Mirror(asdu.ActivationCon, false)); err != nil || !term {
+		return err
+	}
+	return c.Send(pack.Mirror(asdu.ActivationTerm, false))
+}
+
+func (sf *autoServerHandler) InterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfInterrogation) error {
+	return sf.answer(c, pack, true)
+}
+func (sf *autoServerHandler) CounterInterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierCountCall) error {
+	return sf.answer(c, pack, true)
+}
+func (sf *autoServerHandler) ClockSyncHandler(c asdu.Connect, pack *asdu.ASDU, _ time.Time) error {
+	return sf.answer(c, pack, false)
+}
+
+func (sf *autoServerHandler) received() []asdu.TypeID {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return append([]asdu.TypeID(nil), sf.requests...)
+}
+
+func TestClient_AutoInterrogation(t *testing.T) {
+	tests := []struct {
+		name   string
+		refuse asdu.TypeID
+		want   []asdu.TypeID
+		err    error
+	}{
+		{"complete", 0, []asdu.TypeID{asdu.C_CS_NA_1, asdu.C_IC_NA_1, asdu.C_CI_NA_1}, nil},
+		{"refused", asdu.C_IC_NA_1, []asdu.TypeID{asdu.C_CS_NA_1, asdu.C_IC_NA_1}, ErrNegativeConfirm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sh := &autoServerHandler{refuse: tt.refuse}
+			done := make(chan error, 1)
+			o := NewOption().SetAutoInterrogation(AutoInterrogation{
+				CommonAddr: 1,
+				ClockSync:  true,
+				Counter:    true,
+				OnComplete: func(_ *Client, err error) { done <- err },
+			})
+			p := NewPipe(NewServer(sh), NewTypedClientHandler(&ClientHandlerBase{}), o)
+			defer p.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := p.Start(ctx); err != nil {
+				t.Fatal(err)
+			}
+			p.Client.SendStartDt()
+			select {
+			case err := <-done:
+				if err != tt.err {
+					t.Errorf("OnComplete() error = %v, want %v", err, tt.err)
+				}
+			case <-ctx.Done():
+				t.Fatal("auto interrogation not completed")
+			}
+			got := sh.received()
+			if len(got) != len(tt.want) {
+				t.Fatalf("requests = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("request %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
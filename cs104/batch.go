@@ -0,0 +1,75 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"time"
+)
+
+// maxBatchSize the bytes of apdu coalesced into a single write at most
+const maxBatchSize = 16 * APDUSizeMax
+
+// writeBatch coalesces the I-frames and S-frames queued for the conn into a single write,
+// it waits up to the flush delay after the first frame for more frames while the batch is not full.
+type writeBatch struct {
+	delay time.Duration
+	buf   []byte
+	timer *time.Timer
+}
+
+// newWriteBatch returns a write batch of the flush delay, nil if the delay is not positive
+func newWriteBatch(delay time.Duration) *writeBatch {
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	t.Stop()
+	return &writeBatch{delay: delay, buf: make([]byte, 0, maxBatchSize), timer: t}
+}
+
+// collect returns the first apdu followed by the ones received from ch within the flush delay,
+// the apdu are put back to the pool.
+func (sf *writeBatch) collect(ctx context.Context, first []byte, ch chan []byte) []byte {
+	sf.buf = append(sf.buf[:0], first...)
+	putFrame(first)
+	sf.timer.Reset(sf.delay)
+	defer func() {
+		if !sf.timer.Stop() {
+			select {
+			case <-sf.timer.C:
+			default:
+			}
+		}
+	}()
+	for len(sf.buf)+APDUSizeMax <= maxBatchSize {
+		select {
+		case apdu := <-ch:
+			sf.buf = append(sf.buf, apdu...)
+			putFrame(apdu)
+		case <-sf.timer.C:
+			return sf.buf
+		case <-ctx.Done():
+			return sf.buf
+		}
+	}
+	return sf.buf
+}
+
+// SetWriteCoalescing coalesce the frames of a connection queued within the flush delay after the first one
+// into a single write, bounded to 16 frames of maximum size, to cut the syscalls and packets when many
+// small asdu are streaming, 0 disables (default). The TCP no delay option stays enabled,
+// the delay must be well below t₂.
+func (sf *Server) SetWriteCoalescing(delay time.Duration) *Server {
+	sf.writeDelay = delay
+	return sf
+}
+
+// SetWriteCoalescing coalesce the frames queued within the flush delay after the first one into a single write,
+// see Server.SetWriteCoalescing, 0 disables (default).
+func (sf *ClientOption) SetWriteCoalescing(delay time.Duration) *ClientOption {
+	sf.writeDelay = delay
+	return sf
+}
@@ -0,0 +1,36 @@
+package cs104
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriteBatch_collect(t *testing.T) {
+	if newWriteBatch(0) != nil {
+		t.Fatal("write batch without flush delay")
+	}
+	b := newWriteBatch(50 * time.Millisecond)
+	ch := make(chan []byte, 4)
+	s1, s2 := newSFrame(1), newSFrame(2)
+	want := append(append([]byte{}, s1...), s2...)
+	ch <- s2
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ch <- newUFrame(uTestFrActive)
+	}()
+	want = append(want, newUFrame(uTestFrActive)...)
+	if got := b.collect(context.Background(), s1, ch); !bytes.Equal(got, want) {
+		t.Errorf("collected % x, want % x", got, want)
+	}
+
+	// the flush delay bounds the wait
+	start := time.Now()
+	if got := b.collect(context.Background(), newSFrame(3), ch); !bytes.Equal(got, newSFrame(3)) {
+		t.Errorf("collected % x, want a single frame", got)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("flushed after %v", d)
+	}
+}
@@ -6,7 +6,6 @@ package cs104
 
 import (
 	"context"
-	"errors"
 	"io"
 	"math/rand"
 	"net"
@@ -36,7 +35,17 @@ type Client struct {
 	rcvASDU  chan []byte // for received asdu
 	sendASDU chan []byte // for send asdu
 	rcvRaw   chan []byte // for recvLoop raw cs104 frame
-	sendRaw  chan []byte // for sendLoop raw cs104 frame
+	sendRaw  chan []byte // for tx raw cs104 frame
+
+	// the goroutines started on demand, an idle connection keeps none
+	tx drainer[[]byte] // writes sendRaw to the conn
+	rx drainer[[]byte] // handles rcvASDU
+
+	notify chan struct{}      // wakes run for the asdu or events queued
+	testFr chan struct{}      // see SendTestFr
+	batch  *writeBatch        // coalesces the writes, nil if disabled
+	dedup  *dedup             // suppresses the re-delivered events, nil if disabled
+	auto   *autoInterrogation // see ClientOption.SetAutoInterrogation, nil if disabled
 
 	// I frame send and receive sequence number
 	seqNoSend uint16 // sequence number of next outbound I-frame
@@ -46,9 +55,13 @@ type Client struct {
 
 	// maps sendTime I-frames to their respective sequence number
 	pending []seqPending
+	win     window     // the sequence numbers published to the other goroutines
+	rtt     rttMeter   // the round trip time of the test frames
+	delay   delayMeter // the transmission delay of the delay acquisition
 
 	startDtActiveSendSince atomic.Value // The timeout interval to wait for an acknowledgment reply when sending startDtActive
 	stopDtActiveSendSince  atomic.Value // Timeout waiting for confirmation reply when stopDtActive is initiated
+	timeout                timeout      // armed at the earliest t1, t2 or t3 deadline
 
 	// Connection Status
 	status   uint32
@@ -62,9 +75,16 @@ type Client struct {
 	ctx         context.Context
 	cancel      context.CancelFunc
 	closeCancel context.CancelFunc
+	life        context.Context // done once the client is closed, bounds the waits of the senders
 
 	onConnect        func(c *Client)
 	onConnectionLost func(c *Client)
+	onViolation      func(c asdu.Connect, e *ProtocolError)
+	readOnly         bool // see SetReadOnly
+	onSecurity       func(SecurityEvent)
+	audit            AuditSink
+	sendChain        []Interceptor // see UseSend
+	rcvChain         []Interceptor // see UseReceive
 }
 
 // NewClient returns an IEC104 master,default config and default asdu.ParamsWide params
@@ -74,8 +94,14 @@ func NewClient(handler ClientHandlerInterface, o *ClientOption) *Client {
 		handler:          handler,
 		rcvASDU:          make(chan []byte, o.config.RecvUnAckLimitW<<4),
 		sendASDU:         make(chan []byte, o.config.SendUnAckLimitK<<4),
-		rcvRaw:           make(chan []byte, o.config.RecvUnAckLimitW<<5),
+		rcvRaw:           make(chan []byte, o.config.RecvUnAckLimitW),
 		sendRaw:          make(chan []byte, o.config.SendUnAckLimitK<<5), // may not block!
+		notify:           make(chan struct{}, 1),
+		testFr:           make(chan struct{}, 1),
+		batch:            newWriteBatch(o.writeDelay),
+		dedup:            newDedup(o.dedupWindow),
+		auto:             newAutoInterrogation(o.autoGI),
+		timeout:          newTimeout(o.params.Clock),
 		Clog:             clog.NewLogger("cs104 client => "),
 		onConnect:        func(*Client) {},
 		onConnectionLost: func(*Client) {},
@@ -100,16 +126,22 @@ func (sf *Client) SetConnectionLostHandler(f func(c *Client)) *Client {
 
 // Start start the server,and return quickly,if it nil,the server will disconnected background,other failed
 func (sf *Client) Start() error {
-	if sf.option.server == nil {
-		return errors.New("empty remote server")
+	return sf.StartContext(context.Background())
+}
+
+// StartContext is like Start, the connection and the reconnection are
+// stopped when ctx is done.
+func (sf *Client) StartContext(ctx context.Context) error {
+	if sf.option.server == nil && sf.option.dial == nil {
+		return ErrRemoteServer
 	}
 
-	go sf.running()
+	go sf.running(ctx)
 	return nil
 }
 
 // Connect is
-func (sf *Client) running() {
+func (sf *Client) running(parent context.Context) {
 	var ctx context.Context
 
 	sf.rwMux.Lock()
@@ -117,7 +149,8 @@ func (sf *Client) running() {
 		sf.rwMux.Unlock()
 		return
 	}
-	ctx, sf.closeCancel = context.WithCancel(context.Background())
+	ctx, sf.closeCancel = context.WithCancel(parent)
+	sf.life = ctx
 	sf.rwMux.Unlock()
 	defer sf.setConnectStatus(initial)
 
@@ -129,13 +162,12 @@ func (sf *Client) running() {
 		}
 
 		sf.Debug("connecting server %+v", sf.option.server)
-		conn, err := openConnection(sf.option.server, sf.option.TLSConfig, sf.option.config.ConnectTimeout0)
+		conn, err := sf.dial(ctx)
 		if err != nil {
 			sf.Error("connect failed, %v", err)
-			if !sf.option.autoReconnect {
+			if !sf.option.autoReconnect || !sleepContext(ctx, sf.option.reconnectInterval) {
 				return
 			}
-			time.Sleep(sf.option.reconnectInterval)
 			continue
 		}
 		sf.Debug("connect success")
@@ -143,16 +175,23 @@ func (sf *Client) running() {
 		sf.run(ctx)
 
 		sf.Debug("disconnected server %+v", sf.option.server)
-		select {
-		case <-ctx.Done():
+		// Random 500ms-1s retry to avoid fast retry causing many invalid connections to the server
+		if !sleepContext(ctx, time.Millisecond*time.Duration(500+rand.Intn(500))) {
 			return
-		default:
-			// Random 500ms-1s retry to avoid fast retry causing many invalid connections to the server
-			time.Sleep(time.Millisecond * time.Duration(500+rand.Intn(500)))
 		}
 	}
 }
 
+// dial the connection with the dialer of the option, else to the server
+func (sf *Client) dial(ctx context.Context) (net.Conn, error) {
+	if sf.option.dial != nil {
+		ctx, cancel := context.WithTimeout(ctx, sf.option.config.ConnectTimeout0)
+		defer cancel()
+		return sf.option.dial(ctx)
+	}
+	return openConnection(ctx, sf.option.server, sf.option.TLSConfig, sf.option.config.ConnectTimeout0)
+}
+
 func (sf *Client) recvLoop() {
 	sf.Debug("recvLoop started")
 	defer func() {
@@ -197,6 +236,7 @@ func (sf *Client) recvLoop() {
 				}
 				length = int(rawData[1]) + 2
 				if length < APCICtlFiledSize+2 || length > APDUSizeMax {
+					violation(sf, sf.onViolation, lengthViolation(rawData[:2]))
 					rdCnt, length = 0, 2
 					continue
 				}
@@ -210,38 +250,33 @@ func (sf *Client) recvLoop() {
 	}
 }
 
-func (sf *Client) sendLoop() {
-	sf.Debug("sendLoop started")
-	defer func() {
-		sf.cancel()
-		sf.wg.Done()
-		sf.Debug("sendLoop stopped")
-	}()
-	for {
-		select {
-		case <-sf.ctx.Done():
-			return
-		case apdu := <-sf.sendRaw:
-			sf.Debug("TX Raw[% x]", apdu)
-			for wrCnt := 0; len(apdu) > wrCnt; {
-				byteCount, err := sf.conn.Write(apdu[wrCnt:])
-				if err != nil {
-					// See: https://github.com/golang/go/issues/4373
-					if err != io.EOF && err != io.ErrClosedPipe ||
-						strings.Contains(err.Error(), "use of closed network connection") {
-						sf.Error("sendRaw failed, %v", err)
-						return
-					}
-					if e, ok := err.(net.Error); !ok || !e.Temporary() {
-						sf.Error("sendRaw failed, %v", err)
-						return
-					}
-					// temporary error may be recoverable
-				}
-				wrCnt += byteCount
+// write the apdu to the conn, false if it failed and the connection is canceled
+func (sf *Client) write(apdu []byte) bool {
+	if sf.batch != nil {
+		apdu = sf.batch.collect(sf.ctx, apdu, sf.sendRaw)
+	}
+	sf.Debug("TX Raw[% x]", apdu)
+	for wrCnt := 0; len(apdu) > wrCnt; {
+		byteCount, err := sf.conn.Write(apdu[wrCnt:])
+		if err != nil {
+			// See: https://github.com/golang/go/issues/4373
+			if err != io.EOF && err != io.ErrClosedPipe ||
+				strings.Contains(err.Error(), "use of closed network connection") {
+				sf.Error("sendRaw failed, %v", err)
+				sf.cancel()
+				return false
+			}
+			if e, ok := err.(net.Error); !ok || !e.Temporary() {
+				sf.Error("sendRaw failed, %v", err)
+				sf.cancel()
+				return false
 			}
+			// temporary error may be recoverable
 		}
+		wrCnt += byteCount
 	}
+	putFrame(apdu)
+	return true
 }
 
 // run is the big fat state machine.
@@ -252,19 +287,20 @@ func (sf *Client) run(ctx context.Context) {
 
 	sf.ctx, sf.cancel = context.WithCancel(ctx)
 	sf.setConnectStatus(connected)
-	sf.wg.Add(3)
+	sf.wg.Add(1)
 	go sf.recvLoop()
-	go sf.sendLoop()
-	go sf.handlerLoop()
+	sf.tx.open(sf.ctx, sf.sendRaw, sf.write)
+	sf.rx.open(sf.ctx, sf.rcvASDU, sf.handle)
 
-	var checkTicker = time.NewTicker(timeoutResolution)
+	var armed time.Time // the deadline the timeout is armed at, zero if fired
 
 	// transmission timestamps for timeout calculation
-	var willNotTimeout = time.Now().Add(time.Hour * 24 * 365 * 100)
+	var willNotTimeout = sf.option.params.Now().Add(time.Hour * 24 * 365 * 100)
 
 	var unAckRcvSince = willNotTimeout
-	var idleTimeout3Sine = time.Now()         // idle interval initiated testFrAlive
-	var testFrAliveSendSince = willNotTimeout // When testFrAlive is initiated, the timeout interval for waiting for a confirmation reply
+	var idleTimeout3Sine = sf.option.params.Now() // idle interval initiated testFrAlive
+	var testFrAliveSendSince = willNotTimeout     // When testFrAlive is initiated, the timeout interval for waiting for a confirmation reply
+	var testFrLastSend = sf.option.params.Now()   // the last test frame sent, see Keepalive.Interval
 
 	sf.startDtActiveSendSince.Store(willNotTimeout)
 	sf.stopDtActiveSendSince.Store(willNotTimeout)
@@ -272,56 +308,91 @@ func (sf *Client) run(ctx context.Context) {
 	sendSFrame := func(rcvSN uint16) {
 		sf.Debug("TX sFrame %v", sAPCI{rcvSN})
 		sf.sendRaw <- newSFrame(rcvSN)
+		sf.tx.kick()
 	}
 
 	sendIFrame := func(asdu1 []byte) {
 		seqNo := sf.seqNoSend
 
 		iframe, err := newIFrame(seqNo, sf.seqNoRcv, asdu1)
+		putASDUBuffer(asdu1)
 		if err != nil {
 			return
 		}
 		sf.ackNoRcv = sf.seqNoRcv
 		sf.seqNoSend = (seqNo + 1) & 32767
-		sf.pending = append(sf.pending, seqPending{seqNo & 32767, time.Now()})
+		sf.pending = append(sf.pending, seqPending{seqNo & 32767, sf.option.params.Now()})
 
 		sf.Debug("TX iFrame %v", iAPCI{seqNo, sf.seqNoRcv})
 		sf.sendRaw <- iframe
+		sf.tx.kick()
 	}
 
 	defer func() {
 		// default: STOPDT, when connected establish and not enable "data transfer" yet
 		atomic.StoreUint32(&sf.isActive, inactive)
 		sf.setConnectStatus(disconnected)
-		checkTicker.Stop()
+		sf.timeout.stop()
 		_ = sf.conn.Close() // chain trigger cancel
 		sf.wg.Wait()
+		sf.cancel()
+		sf.tx.close()
+		sf.rx.close()
+		sf.win.wakeup()
 		sf.onConnectionLost(sf)
 		sf.Debug("run stopped!")
 	}()
 
 	sf.onConnect(sf)
 	for {
+		sf.win.update(sf.seqNoSend, sf.ackNoSend, sf.seqNoRcv, sf.ackNoRcv)
 		if atomic.LoadUint32(&sf.isActive) == active && seqNoCount(sf.ackNoSend, sf.seqNoSend) <= sf.option.config.SendUnAckLimitK {
 			select {
 			case o := <-sf.sendASDU:
 				sendIFrame(o)
-				idleTimeout3Sine = time.Now()
+				idleTimeout3Sine = sf.option.params.Now()
 				continue
 			case <-sf.ctx.Done():
 				return
 			default: // make no block
 			}
 		}
+		// arm the timeout at the earliest deadline, the checks tell which one elapsed
+		next := earliest(idleTimeout3Sine.Add(sf.option.config.IdleTimeout3),
+			testFrAliveSendSince.Add(sf.option.config.SendUnAckTimeout1),
+			sf.startDtActiveSendSince.Load().(time.Time).Add(sf.option.config.SendUnAckTimeout1),
+			sf.stopDtActiveSendSince.Load().(time.Time).Add(sf.option.config.SendUnAckTimeout1))
+		if sf.ackNoSend != sf.seqNoSend {
+			next = earliest(next, sf.pending[0].sendTime.Add(sf.option.config.SendUnAckTimeout1))
+		}
+		if sf.ackNoRcv != sf.seqNoRcv {
+			next = earliest(next, unAckRcvSince.Add(sf.option.config.RecvUnAckTimeout2), idleTimeout3Sine.Add(timeoutResolution))
+		}
+		if sf.option.keepalive.Interval > 0 && testFrAliveSendSince == willNotTimeout {
+			next = earliest(next, testFrLastSend.Add(sf.option.keepalive.Interval))
+		}
+		if armed.IsZero() || next.Before(armed) {
+			sf.timeout.earlier(next)
+			armed = next
+		}
 		select {
 		case <-sf.ctx.Done():
 			return
-		case now := <-checkTicker.C:
+		case <-sf.notify:
+		case <-sf.testFr:
+			if testFrAliveSendSince == willNotTimeout {
+				sf.sendUFrame(uTestFrActive)
+				testFrAliveSendSince = sf.option.params.Now()
+				testFrLastSend = testFrAliveSendSince
+			}
+		case now := <-sf.timeout.fired():
+			armed = time.Time{}
 			// check all timeouts
 			if now.Sub(testFrAliveSendSince) >= sf.option.config.SendUnAckTimeout1 ||
 				now.Sub(sf.startDtActiveSendSince.Load().(time.Time)) >= sf.option.config.SendUnAckTimeout1 ||
 				now.Sub(sf.stopDtActiveSendSince.Load().(time.Time)) >= sf.option.config.SendUnAckTimeout1 {
 				sf.Error("test frame alive confirm timeout t₁")
+				violation(sf, sf.onViolation, timeout1Violation(sf.option.config.SendUnAckTimeout1, "U frame"))
 				return
 			}
 			// check oldest unacknowledged outbound
@@ -330,6 +401,7 @@ func (sf *Client) run(ctx context.Context) {
 				now.Sub(sf.pending[0].sendTime) >= sf.option.config.SendUnAckTimeout1 {
 				sf.ackNoSend++
 				sf.Error("fatal transmission timeout t₁")
+				violation(sf, sf.onViolation, timeout1Violation(sf.option.config.SendUnAckTimeout1, "I frame"))
 				return
 			}
 
@@ -342,20 +414,26 @@ func (sf *Client) run(ctx context.Context) {
 			}
 
 			// When the idle time is up, send a TestFrActive frame to keep alive
-			if now.Sub(idleTimeout3Sine) >= sf.option.config.IdleTimeout3 {
+			// or every keepalive interval, one test frame outstanding at most
+			if now.Sub(idleTimeout3Sine) >= sf.option.config.IdleTimeout3 ||
+				(sf.option.keepalive.Interval > 0 && testFrAliveSendSince == willNotTimeout &&
+					now.Sub(testFrLastSend) >= sf.option.keepalive.Interval) {
 				sf.sendUFrame(uTestFrActive)
-				testFrAliveSendSince = time.Now()
+				testFrAliveSendSince = sf.option.params.Now()
+				testFrLastSend = testFrAliveSendSince
 				idleTimeout3Sine = testFrAliveSendSince
 			}
 
 		case apdu := <-sf.rcvRaw:
-			idleTimeout3Sine = time.Now() // Every time an i frame, S frame, U frame is received, the idle timer is reset, t3
+			idleTimeout3Sine = sf.option.params.Now() // Every time an i frame, S frame, U frame is received, the idle timer is reset, t3
 			apci, asduVal := parse(apdu)
 			switch head := apci.(type) {
 			case sAPCI:
 				sf.Debug("RX sFrame %v", head)
 				if !sf.updateAckNoOut(head.rcvSN) {
-					sf.Error("fatal incoming acknowledge either earlier than previous or later than sendTime")
+					sf.win.fail(ErrSeqNoAck)
+					sf.Error("fatal incoming acknowledge N(R) %d outside the window [%d, %d]", head.rcvSN, sf.ackNoSend, sf.seqNoSend)
+					violation(sf, sf.onViolation, seqNoAckViolation(apdu, head.rcvSN, sf.ackNoSend, sf.seqNoSend))
 					return
 				}
 
@@ -365,14 +443,23 @@ func (sf *Client) run(ctx context.Context) {
 					sf.Warn("station not active")
 					break // not active, discard apdu
 				}
-				if !sf.updateAckNoOut(head.rcvSN) || head.sendSN != sf.seqNoRcv {
-					sf.Error("fatal incoming acknowledge either earlier than previous or later than sendTime")
+				if !sf.updateAckNoOut(head.rcvSN) {
+					sf.win.fail(ErrSeqNoAck)
+					sf.Error("fatal incoming acknowledge N(R) %d outside the window [%d, %d]", head.rcvSN, sf.ackNoSend, sf.seqNoSend)
+					violation(sf, sf.onViolation, seqNoAckViolation(apdu, head.rcvSN, sf.ackNoSend, sf.seqNoSend))
+					return
+				}
+				if head.sendSN != sf.seqNoRcv {
+					sf.win.fail(ErrSeqNoSend)
+					sf.Error("fatal incoming sequence number N(S) %d, expected %d", head.sendSN, sf.seqNoRcv)
+					violation(sf, sf.onViolation, seqNoSendViolation(apdu, head.sendSN, sf.seqNoRcv))
 					return
 				}
 
 				sf.rcvASDU <- asduVal
+				sf.rx.kick()
 				if sf.ackNoRcv == sf.seqNoRcv { // first unacked
-					unAckRcvSince = time.Now()
+					unAckRcvSince = sf.option.params.Now()
 				}
 
 				sf.seqNoRcv = (sf.seqNoRcv + 1) & 32767
@@ -390,6 +477,9 @@ func (sf *Client) run(ctx context.Context) {
 				case uStartDtConfirm:
 					atomic.StoreUint32(&sf.isActive, active)
 					sf.startDtActiveSendSince.Store(willNotTimeout)
+					if sf.auto != nil {
+						sf.auto.start(sf)
+					}
 				//case uStopDtActive:
 				//	sf.sendUFrame(uStopDtConfirm)
 				//	atomic.StoreUint32(&sf.isActive, inactive)
@@ -399,6 +489,11 @@ func (sf *Client) run(ctx context.Context) {
 				case uTestFrActive:
 					sf.sendUFrame(uTestFrConfirm)
 				case uTestFrConfirm:
+					if testFrAliveSendSince != willNotTimeout &&
+						sf.rtt.record(sf.option.params.Now().Sub(testFrAliveSendSince), sf.option.keepalive) {
+						sf.Error("test frame round trip time exceeds %v repeatedly", sf.option.keepalive.MaxRTT)
+						return
+					}
 					testFrAliveSendSince = willNotTimeout
 				default:
 					sf.Error("illegal U-Frame functions[0x%02x] ignored", head.function)
@@ -408,28 +503,28 @@ func (sf *Client) run(ctx context.Context) {
 	}
 }
 
-func (sf *Client) handlerLoop() {
-	sf.Debug("handlerLoop started")
-	defer func() {
-		sf.wg.Done()
-		sf.Debug("handlerLoop stopped")
-	}()
-
-	for {
-		select {
-		case <-sf.ctx.Done():
-			return
-		case rawAsdu := <-sf.rcvASDU:
-			asduPack := asdu.NewEmptyASDU(&sf.option.params)
-			if err := asduPack.UnmarshalBinary(rawAsdu); err != nil {
-				sf.Warn("asdu UnmarshalBinary failed,%+v", err)
-				continue
-			}
-			if err := sf.clientHandler(asduPack); err != nil {
-				sf.Warn("Falied handling I frame, error: %v", err)
-			}
+// handle handler iFrame asdu
+func (sf *Client) handle(rawAsdu []byte) bool {
+	if sf.dedup != nil {
+		if rawAsdu = sf.dedup.filter(&sf.option.params, rawAsdu, sf.option.params.Now()); rawAsdu == nil {
+			sf.Debug("duplicated asdu suppressed")
+			return true
 		}
 	}
+	asduPack := asdu.NewEmptyASDU(&sf.option.params)
+	if err := asduPack.UnmarshalBinary(rawAsdu); err != nil {
+		sf.Warn("asdu UnmarshalBinary failed,%+v", err)
+		return true
+	}
+	if err := intercept(sf.rcvChain, sf.receive)(sf, asduPack); err != nil {
+		sf.Warn("Falied handling I frame, error: %v", err)
+	}
+	return true
+}
+
+// receive the asdu passed by the receive interceptors
+func (sf *Client) receive(_ asdu.Connect, asduPack *asdu.ASDU) error {
+	return sf.clientHandler(asduPack)
 }
 
 func (sf *Client) setConnectStatus(status uint32) {
@@ -468,6 +563,7 @@ loop:
 func (sf *Client) sendUFrame(which byte) {
 	sf.Debug("TX uFrame %v", uAPCI{which})
 	sf.sendRaw <- newUFrame(which)
+	sf.tx.kick()
 }
 
 func (sf *Client) updateAckNoOut(ackNo uint16) (ok bool) {
@@ -481,7 +577,7 @@ func (sf *Client) updateAckNoOut(ackNo uint16) (ok bool) {
 
 	// confirm reception
 	for i, v := range sf.pending {
-		if v.seq == (ackNo - 1) {
+		if v.seq == (ackNo-1)&32767 { // rollover 32767 -> 0
 			sf.pending = sf.pending[i+1:]
 			break
 		}
@@ -504,7 +600,23 @@ func (sf *Client) clientHandler(asduPack *asdu.ASDU) error {
 		}
 	}()
 
-	sf.Debug("ASDU %+v", asduPack)
+	sf.Debug("ASDU %v", describeASDU(sf.Clog, asduPack))
+	if p := sf.option.params.Profile; p != nil && !p.Allows(asduPack.Identifier.Type) {
+		sf.Warn("drop asdu of unsupported type %v", asduPack.Identifier.Type)
+		return nil
+	}
+	if err := asduPack.CheckTimeTags(); err != nil {
+		sf.Warn("drop asdu, %v", err)
+		return nil
+	}
+	audit(sf.audit, sf.conn, asduPack)
+	if sf.readOnly && isControlDirection(asduPack) {
+		securityEvent(sf.Clog, sf.onSecurity, sf.conn, asduPack.Identifier, "read-only")
+		return sf.Send(asduPack.Mirror(asdu.Unused, true))
+	}
+	if sf.auto != nil {
+		sf.auto.observe(sf, asduPack)
+	}
 
 	switch asduPack.Identifier.Type {
 	case asdu.C_IC_NA_1: // InterrogationCmd
@@ -526,6 +638,9 @@ func (sf *Client) clientHandler(asduPack *asdu.ASDU) error {
 		return sf.handler.ResetProcessHandler(sf, asduPack)
 
 	case asdu.C_CD_NA_1: // DelayAcquireCommand
+		if asduPack.Coa.Cause == asdu.ActivationCon {
+			sf.delay.confirm(asduPack)
+		}
 		return sf.handler.DelayAcquisitionHandler(sf, asduPack)
 	}
 
@@ -539,24 +654,97 @@ func (sf *Client) Params() *asdu.Params {
 
 // Send send asdu
 func (sf *Client) Send(a *asdu.ASDU) error {
+	if len(sf.sendChain) == 0 {
+		return sf.transmit(sf, a)
+	}
+	return intercept(sf.sendChain, sf.transmit)(sf, a)
+}
+
+// transmit the asdu passed by the send interceptors
+func (sf *Client) transmit(_ asdu.Connect, a *asdu.ASDU) error {
 	if !sf.IsConnected() {
 		return ErrUseClosedConnection
 	}
 	if atomic.LoadUint32(&sf.isActive) == inactive {
 		return ErrNotActive
 	}
+	if sf.option.listenOnly {
+		return ErrListenOnly
+	}
+	if sf.readOnly && isControlDirection(a) {
+		securityEvent(sf.Clog, sf.onSecurity, nil, a.Identifier, "read-only")
+		return ErrReadOnly
+	}
 	data, err := a.MarshalBinary()
 	if err != nil {
 		return err
 	}
+	if sf.option.limiter != nil {
+		sf.rwMux.RLock()
+		life := sf.life
+		sf.rwMux.RUnlock()
+		if life == nil {
+			life = context.Background()
+		}
+		if err = sf.option.limiter.Wait(life, a, len(data)); err != nil {
+			return err
+		}
+	}
+	if err = sf.enqueue(data); err != nil {
+		return err
+	}
+	audit(sf.audit, nil, a)
+	return nil
+}
+
+// enqueue queue the encoded asdu to be sent in an I-frame
+func (sf *Client) enqueue(data []byte) error {
+	// a pooled copy, so that the asdu may be released once sent, see asdu.ReleaseASDU
+	buf := append(getASDUBuffer(), data...)
 	select {
-	case sf.sendASDU <- data:
+	case sf.sendASDU <- buf:
 	default:
+		putASDUBuffer(buf)
 		return ErrBufferFulled
 	}
+	select {
+	case sf.notify <- struct{}{}:
+	default:
+	}
 	return nil
 }
 
+// SendContext send the asdu, blocks while the k window is full of unacknowledged and queued asdu,
+// so that the upper layer applies backpressure instead of buffering unboundedly.
+func (sf *Client) SendContext(ctx context.Context, a *asdu.ASDU) error {
+	return sendContext(ctx, sf, a)
+}
+
+// TrySend send the asdu, returns ErrWindowFull at once if the k window is full
+func (sf *Client) TrySend(a *asdu.ASDU) error {
+	return trySend(sf, a)
+}
+
+// Err returns the sequence number error the last connection was closed for, nil if none
+func (sf *Client) Err() error {
+	return sf.win.err()
+}
+
+// RTTStats returns the round trip time statistics of the test frames
+func (sf *Client) RTTStats() RTTStats {
+	return sf.rtt.get()
+}
+
+// WindowStats returns the current k/w flow control state
+func (sf *Client) WindowStats() WindowStats {
+	stats, _ := sf.windowStats()
+	return stats
+}
+
+func (sf *Client) windowStats() (WindowStats, <-chan struct{}) {
+	return sf.win.stats(&sf.option.config, len(sf.sendASDU))
+}
+
 // UnderlyingConn returns underlying conn of client
 func (sf *Client) UnderlyingConn() net.Conn {
 	return sf.conn
@@ -574,13 +762,26 @@ func (sf *Client) Close() error {
 
 // SendStartDt start data transmission on this connection
 func (sf *Client) SendStartDt() {
-	sf.startDtActiveSendSince.Store(time.Now())
+	now := sf.option.params.Now()
+	sf.startDtActiveSendSince.Store(now)
+	sf.timeout.earlier(now.Add(sf.option.config.SendUnAckTimeout1))
 	sf.sendUFrame(uStartDtActive)
 }
 
+// SendTestFr send a test frame on this connection, its confirmation is supervised by t1 like the
+// test frames of the idle timeout t3, nothing is sent while a test frame waits for its confirmation
+func (sf *Client) SendTestFr() {
+	select {
+	case sf.testFr <- struct{}{}:
+	default:
+	}
+}
+
 // SendStopDt stop data transmission on this connection
 func (sf *Client) SendStopDt() {
-	sf.stopDtActiveSendSince.Store(time.Now())
+	now := sf.option.params.Now()
+	sf.stopDtActiveSendSince.Store(now)
+	sf.timeout.earlier(now.Add(sf.option.config.SendUnAckTimeout1))
 	sf.sendUFrame(uStopDtActive)
 }
 
@@ -5,7 +5,9 @@
 package cs104
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
 	"net/url"
 	"strings"
 	"time"
@@ -21,8 +23,18 @@ type ClientOption struct {
 	autoReconnect     bool          // Whether to start reconnection
 	reconnectInterval time.Duration // reconnection interval
 	TLSConfig         *tls.Config   // tls configuration
+	limiter           *RateLimiter  // rate limiter of the outgoing asdu
+	keepalive         Keepalive     // test frame keepalive strategy
+	listenOnly        bool          // never transmits asdu
+	writeDelay        time.Duration // flush delay of the write coalescing, 0 disables
+	dedupWindow       time.Duration // rolling window of the duplicate suppression, 0 disables
+	dial              Dialer        // dial the connection instead of the server
+	autoGI            *AutoInterrogation
 }
 
+// Dialer dial the connection of the client
+type Dialer func(ctx context.Context) (net.Conn, error)
+
 // NewOption with default config and default asdu.ParamsWide params
 func NewOption() *ClientOption {
 	return &ClientOption{
@@ -32,6 +44,13 @@ func NewOption() *ClientOption {
 		true,
 		DefaultReconnectInterval,
 		nil,
+		nil,
+		Keepalive{},
+		false,
+		0,
+		0,
+		nil,
+		nil,
 	}
 }
 
@@ -55,6 +74,14 @@ func (sf *ClientOption) SetParams(p *asdu.Params) *ClientOption {
 	return sf
 }
 
+// SetTypeIDs set the type identifications the client supports, like the interoperability list of a device,
+// it restricts the profile of the params, so call it after SetParams. An asdu of any other type received
+// is dropped, sending one fails with asdu.ErrProfileTypeID.
+func (sf *ClientOption) SetTypeIDs(ids ...asdu.TypeID) *ClientOption {
+	sf.params.Profile = sf.params.Profile.Restrict(ids...)
+	return sf
+}
+
 // SetReconnectInterval set tcp  reconnect the host interval when connect failed after try
 func (sf *ClientOption) SetReconnectInterval(t time.Duration) *ClientOption {
 	if t > 0 {
@@ -75,6 +102,40 @@ func (sf *ClientOption) SetTLSConfig(t *tls.Config) *ClientOption {
 	return sf
 }
 
+// SetDialer set the function dialing the connection instead of the remote server, like an in-memory
+// connection, see NewPipe. The tls config is not applied.
+func (sf *ClientOption) SetDialer(dial Dialer) *ClientOption {
+	sf.dial = dial
+	return sf
+}
+
+// SetRateLimit set the rate limit of the outgoing monitor data and commands,
+// the sending blocks until allowed. Zero is unlimited.
+func (sf *ClientOption) SetRateLimit(monitor, command RateLimit) *ClientOption {
+	sf.limiter = NewRateLimiter(monitor, command)
+	return sf
+}
+
+// SetRateLimiter set the rate limiter, which may be shared by several clients to limit their total rate
+func (sf *ClientOption) SetRateLimiter(l *RateLimiter) *ClientOption {
+	sf.limiter = l
+	return sf
+}
+
+// SetKeepalive set the test frame keepalive strategy, see Keepalive
+func (sf *ClientOption) SetKeepalive(ka Keepalive) *ClientOption {
+	sf.keepalive = ka
+	return sf
+}
+
+// SetListenOnly enable the listen only mode, the client connects and starts the data transfer, but
+// never transmits any asdu, for audit and historian applications that must not influence the process.
+// See Tap for a pure passive tap.
+func (sf *ClientOption) SetListenOnly(b bool) *ClientOption {
+	sf.listenOnly = b
+	return sf
+}
+
 // AddRemoteServer adds a broker URI to the list of brokers to be used.
 // The format should be scheme://host:port
 // Default values for hostname is "127.0.0.1", for schema is "tcp://".
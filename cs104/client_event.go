@@ -0,0 +1,138 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Event a received asdu, the payload is decoded like for the ClientHandler, by data category:
+//
+//	[]asdu.SinglePointInfo, []asdu.DoublePointInfo, []asdu.StepPositionInfo, []asdu.BitString32Info,
+//	[]asdu.MeasuredValueNormalInfo, []asdu.MeasuredValueScaledInfo, []asdu.MeasuredValueFloatInfo,
+//	[]asdu.BinaryCounterReadingInfo, []asdu.EventOfProtectionEquipmentInfo,
+//	asdu.PackedStartEventsOfProtectionEquipmentInfo, asdu.PackedOutputCircuitInfoInfo,
+//	[]asdu.PackedSinglePointWithSCDInfo, EndOfInitializationInfo
+//
+// and *asdu.ASDU for any other asdu, for example the command confirmations.
+type Event struct {
+	asdu.Identifier
+	Payload interface{}
+	RxTime  time.Time // the time the asdu is handled
+}
+
+// EndOfInitializationInfo the payload of the end of initialization [M_EI_NA_1]
+type EndOfInitializationInfo struct {
+	Ioa asdu.InfoObjAddr
+	Coi asdu.CauseOfInitial
+}
+
+// EventStream delivers the asdu received by a client as a channel of Event, an alternative to the
+// callbacks of ClientHandler for a select based consumption. The handler blocks while the channel is
+// full, which withholds the acknowledgement of the received I-frames and so throttles the outstation.
+// Close the stream when it is not consumed anymore.
+type EventStream struct {
+	mux    sync.RWMutex
+	ch     chan Event
+	done   chan struct{}
+	once   sync.Once
+	closed bool
+}
+
+// NewEventStream new an event stream with a channel buffer of size events
+func NewEventStream(size int) *EventStream {
+	return &EventStream{
+		ch:   make(chan Event, size),
+		done: make(chan struct{}),
+	}
+}
+
+// Events returns the channel of the events, it is closed by Close
+func (sf *EventStream) Events() <-chan Event {
+	return sf.ch
+}
+
+// Handler returns the ClientHandlerInterface posting the events to the stream, use it with NewClient
+func (sf *EventStream) Handler() ClientHandlerInterface {
+	return NewTypedClientHandler(eventHandler{sf})
+}
+
+// Close stop the stream and close the channel of the events, the events not posted yet are dropped
+func (sf *EventStream) Close() error {
+	sf.once.Do(func() {
+		close(sf.done)
+		sf.mux.Lock()
+		sf.closed = true
+		close(sf.ch)
+		sf.mux.Unlock()
+	})
+	return nil
+}
+
+// post the event, it blocks while the channel is full unless the stream is closed
+func (sf *EventStream) post(id asdu.Identifier, payload interface{}) error {
+	sf.mux.RLock()
+	defer sf.mux.RUnlock()
+	if sf.closed {
+		return ErrUseClosedConnection
+	}
+	select {
+	case sf.ch <- Event{id, payload, time.Now()}:
+		return nil
+	case <-sf.done:
+		return ErrUseClosedConnection
+	}
+}
+
+// eventHandler the ClientHandler of the event stream
+type eventHandler struct {
+	s *EventStream
+}
+
+func (sf eventHandler) OnSinglePoint(v []asdu.SinglePointInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnDoublePoint(v []asdu.DoublePointInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnStepPosition(v []asdu.StepPositionInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnBitString32(v []asdu.BitString32Info, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnMeasuredNormal(v []asdu.MeasuredValueNormalInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnMeasuredScaled(v []asdu.MeasuredValueScaledInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnMeasuredFloat(v []asdu.MeasuredValueFloatInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnIntegratedTotals(v []asdu.BinaryCounterReadingInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnProtectionEvent(v []asdu.EventOfProtectionEquipmentInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnProtectionStartEvents(v asdu.PackedStartEventsOfProtectionEquipmentInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnProtectionOutputCircuit(v asdu.PackedOutputCircuitInfoInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnPackedSinglePoint(v []asdu.PackedSinglePointWithSCDInfo, id asdu.Identifier) error {
+	return sf.s.post(id, v)
+}
+func (sf eventHandler) OnEndOfInitialization(ioa asdu.InfoObjAddr, coi asdu.CauseOfInitial, id asdu.Identifier) error {
+	return sf.s.post(id, EndOfInitializationInfo{ioa, coi})
+}
+func (sf eventHandler) OnOther(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.s.post(a.Identifier, a)
+}
@@ -0,0 +1,60 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestEventStream(t *testing.T) {
+	c := &recordConn{}
+	_ = asdu.MeasuredValueFloat(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+		asdu.MeasuredValueFloatInfo{Ioa: 1, Value: 1.5})
+	_ = asdu.EndOfInitialization(c, asdu.CauseOfTransmission{}, 1, asdu.InfoObjAddrIrrelevant,
+		asdu.CauseOfInitial{Cause: asdu.COIRemoteReset})
+	_ = asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation)
+
+	s := NewEventStream(2)
+	h := s.Handler()
+	sent := c.take()
+	sent[2].Coa.Cause = asdu.ActivationCon
+	for _, a := range sent[:2] {
+		if err := h.ASDUHandler(c, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the handler blocks while the channel is full
+	posted := make(chan error)
+	go func() { posted <- h.InterrogationHandler(c, sent[2]) }()
+	select {
+	case <-posted:
+		t.Fatal("handler did not block on the full channel")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ev := <-s.Events()
+	if v, ok := ev.Payload.([]asdu.MeasuredValueFloatInfo); !ok || v[0].Value != 1.5 || ev.Type != asdu.M_ME_NC_1 || ev.RxTime.IsZero() {
+		t.Errorf("event = %+v", ev)
+	}
+	if err := <-posted; err != nil {
+		t.Fatal(err)
+	}
+	ev = <-s.Events()
+	if v, ok := ev.Payload.(EndOfInitializationInfo); !ok || v.Coi.Cause != asdu.COIRemoteReset {
+		t.Errorf("event = %+v", ev)
+	}
+	ev = <-s.Events()
+	if a, ok := ev.Payload.(*asdu.ASDU); !ok || a.Coa.Cause != asdu.ActivationCon {
+		t.Errorf("event = %+v", ev)
+	}
+
+	_ = s.Close()
+	if _, ok := <-s.Events(); ok {
+		t.Error("events channel not closed")
+	}
+	if err := h.ASDUHandler(c, sent[2]); err != ErrUseClosedConnection {
+		t.Errorf("ASDUHandler() = %v, want %v", err, ErrUseClosedConnection)
+	}
+}
@@ -0,0 +1,119 @@
+package cs104
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/asdu/asdutest"
+)
+
+func TestClient_StartContext(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	opt := NewOption().SetAutoReconnect(true).SetReconnectInterval(10 * time.Millisecond)
+	if err = opt.AddRemoteServer(l.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := NewClient(NewTypedClientHandler(&ClientHandlerBase{}), opt)
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	cancel()
+	if head, _ := readTestAPDU(t, conn); head != nil {
+		t.Errorf("got %v, want the connection closed", head)
+	}
+	_ = l.(*net.TCPListener).SetDeadline(time.Now().Add(time.Second))
+	if c, err := l.Accept(); err == nil {
+		c.Close()
+		t.Error("client reconnected after the context is done")
+	}
+}
+
+func TestClient_Clock(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	clock := asdutest.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := *asdu.ParamsWide
+	p.Clock = clock
+	opt := NewOption().SetParams(&p).SetAutoReconnect(false)
+	if err = opt.AddRemoteServer(l.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	violations := make(chan *ProtocolError, 1)
+	client := NewClient(NewTypedClientHandler(&ClientHandlerBase{}), opt).
+		SetViolationHandler(func(_ asdu.Connect, e *ProtocolError) { violations <- e })
+	if err = client.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// t3 elapsed on the clock: test frame, once the connection samples the clock
+	b := make([]byte, 1)
+	for i := 0; ; i++ {
+		clock.Advance(DefaultConfig().IdleTimeout3)
+		_ = conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+		if _, err = conn.Read(b); err == nil || i == 100 {
+			break
+		}
+	}
+	if err != nil || b[0] != startFrame {
+		t.Fatalf("read %x, %v, want the start of the test frame", b, err)
+	}
+	if head, _ := readTestAPDU(t, &prefixConn{conn, b}); head != (uAPCI{uTestFrActive}) {
+		t.Fatalf("got %v, want the test frame after t3", head)
+	}
+	// t1 elapsed without the confirmation: connection closed
+	clock.Advance(DefaultConfig().SendUnAckTimeout1)
+	if head, _ := readTestAPDU(t, conn); head != nil {
+		t.Errorf("got %v, want the connection closed after t1", head)
+	}
+	select {
+	case e := <-violations:
+		if !errors.Is(e, ErrTimeout1) || e.Timeout != DefaultConfig().SendUnAckTimeout1 {
+			t.Errorf("violation = %+v, want t1", e)
+		}
+	case <-time.After(time.Second):
+		t.Error("t1 violation not reported")
+	}
+}
+
+// prefixConn a connection with the bytes read already
+type prefixConn struct {
+	net.Conn
+	prefix []byte
+}
+
+func (sf *prefixConn) Read(b []byte) (int, error) {
+	if len(sf.prefix) > 0 {
+		n := copy(b, sf.prefix)
+		sf.prefix = sf.prefix[n:]
+		return n, nil
+	}
+	return sf.Conn.Read(b)
+}
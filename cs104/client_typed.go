@@ -0,0 +1,182 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ClientHandler the typed client handler, the received monitor direction asdu are decoded
+// and dispatched by data category, so the application only deals with typed data.
+// Any other asdu, for example the command confirmations, goes to OnOther.
+// Embed ClientHandlerBase to implement only the categories concerned.
+type ClientHandler interface {
+	// [M_SP_NA_1], [M_SP_TA_1], [M_SP_TB_1]
+	OnSinglePoint([]asdu.SinglePointInfo, asdu.Identifier) error
+	// [M_DP_NA_1], [M_DP_TA_1], [M_DP_TB_1]
+	OnDoublePoint([]asdu.DoublePointInfo, asdu.Identifier) error
+	// [M_ST_NA_1], [M_ST_TA_1], [M_ST_TB_1]
+	OnStepPosition([]asdu.StepPositionInfo, asdu.Identifier) error
+	// [M_BO_NA_1], [M_BO_TA_1], [M_BO_TB_1]
+	OnBitString32([]asdu.BitString32Info, asdu.Identifier) error
+	// [M_ME_NA_1], [M_ME_TA_1], [M_ME_TD_1], [M_ME_ND_1]
+	OnMeasuredNormal([]asdu.MeasuredValueNormalInfo, asdu.Identifier) error
+	// [M_ME_NB_1], [M_ME_TB_1], [M_ME_TE_1]
+	OnMeasuredScaled([]asdu.MeasuredValueScaledInfo, asdu.Identifier) error
+	// [M_ME_NC_1], [M_ME_TC_1], [M_ME_TF_1]
+	OnMeasuredFloat([]asdu.MeasuredValueFloatInfo, asdu.Identifier) error
+	// [M_IT_NA_1], [M_IT_TA_1], [M_IT_TB_1]
+	OnIntegratedTotals([]asdu.BinaryCounterReadingInfo, asdu.Identifier) error
+	// [M_EP_TA_1], [M_EP_TD_1]
+	OnProtectionEvent([]asdu.EventOfProtectionEquipmentInfo, asdu.Identifier) error
+	// [M_EP_TB_1], [M_EP_TE_1]
+	OnProtectionStartEvents(asdu.PackedStartEventsOfProtectionEquipmentInfo, asdu.Identifier) error
+	// [M_EP_TC_1], [M_EP_TF_1]
+	OnProtectionOutputCircuit(asdu.PackedOutputCircuitInfoInfo, asdu.Identifier) error
+	// [M_PS_NA_1]
+	OnPackedSinglePoint([]asdu.PackedSinglePointWithSCDInfo, asdu.Identifier) error
+	// [M_EI_NA_1]
+	OnEndOfInitialization(asdu.InfoObjAddr, asdu.CauseOfInitial, asdu.Identifier) error
+	// any other asdu
+	OnOther(asdu.Connect, *asdu.ASDU) error
+}
+
+// ClientHandlerBase implements ClientHandler ignoring everything
+type ClientHandlerBase struct{}
+
+var _ ClientHandler = ClientHandlerBase{}
+
+// OnSinglePoint ignore
+func (ClientHandlerBase) OnSinglePoint([]asdu.SinglePointInfo, asdu.Identifier) error { return nil }
+
+// OnDoublePoint ignore
+func (ClientHandlerBase) OnDoublePoint([]asdu.DoublePointInfo, asdu.Identifier) error { return nil }
+
+// OnStepPosition ignore
+func (ClientHandlerBase) OnStepPosition([]asdu.StepPositionInfo, asdu.Identifier) error { return nil }
+
+// OnBitString32 ignore
+func (ClientHandlerBase) OnBitString32([]asdu.BitString32Info, asdu.Identifier) error { return nil }
+
+// OnMeasuredNormal ignore
+func (ClientHandlerBase) OnMeasuredNormal([]asdu.MeasuredValueNormalInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnMeasuredScaled ignore
+func (ClientHandlerBase) OnMeasuredScaled([]asdu.MeasuredValueScaledInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnMeasuredFloat ignore
+func (ClientHandlerBase) OnMeasuredFloat([]asdu.MeasuredValueFloatInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnIntegratedTotals ignore
+func (ClientHandlerBase) OnIntegratedTotals([]asdu.BinaryCounterReadingInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnProtectionEvent ignore
+func (ClientHandlerBase) OnProtectionEvent([]asdu.EventOfProtectionEquipmentInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnProtectionStartEvents ignore
+func (ClientHandlerBase) OnProtectionStartEvents(asdu.PackedStartEventsOfProtectionEquipmentInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnProtectionOutputCircuit ignore
+func (ClientHandlerBase) OnProtectionOutputCircuit(asdu.PackedOutputCircuitInfoInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnPackedSinglePoint ignore
+func (ClientHandlerBase) OnPackedSinglePoint([]asdu.PackedSinglePointWithSCDInfo, asdu.Identifier) error {
+	return nil
+}
+
+// OnEndOfInitialization ignore
+func (ClientHandlerBase) OnEndOfInitialization(asdu.InfoObjAddr, asdu.CauseOfInitial, asdu.Identifier) error {
+	return nil
+}
+
+// OnOther ignore
+func (ClientHandlerBase) OnOther(asdu.Connect, *asdu.ASDU) error { return nil }
+
+// typedClientHandler adapts a ClientHandler to the ClientHandlerInterface
+type typedClientHandler struct {
+	h ClientHandler
+}
+
+var _ ClientHandlerInterface = typedClientHandler{}
+
+// NewTypedClientHandler returns the ClientHandlerInterface dispatching to the typed client handler,
+// use it with NewClient.
+func NewTypedClientHandler(h ClientHandler) ClientHandlerInterface {
+	return typedClientHandler{h}
+}
+
+func (sf typedClientHandler) InterrogationHandler(c asdu.Connect, a *asdu.ASDU) error {
+	return sf.h.OnOther(c, a)
+}
+func (sf typedClientHandler) CounterInterrogationHandler(c asdu.Connect, a *asdu.ASDU) error {
+	return sf.h.OnOther(c, a)
+}
+func (sf typedClientHandler) ReadHandler(c asdu.Connect, a *asdu.ASDU) error {
+	return sf.h.OnOther(c, a)
+}
+func (sf typedClientHandler) TestCommandHandler(c asdu.Connect, a *asdu.ASDU) error {
+	return sf.h.OnOther(c, a)
+}
+func (sf typedClientHandler) ClockSyncHandler(c asdu.Connect, a *asdu.ASDU) error {
+	return sf.h.OnOther(c, a)
+}
+func (sf typedClientHandler) ResetProcessHandler(c asdu.Connect, a *asdu.ASDU) error {
+	return sf.h.OnOther(c, a)
+}
+func (sf typedClientHandler) DelayAcquisitionHandler(c asdu.Connect, a *asdu.ASDU) error {
+	return sf.h.OnOther(c, a)
+}
+func (sf typedClientHandler) ASDUHandlerAll(c asdu.Connect, a *asdu.ASDU, _ *Server, _ int) error {
+	return sf.ASDUHandler(c, a)
+}
+
+// ASDUHandler decode the monitor direction asdu and dispatch it by data category
+func (sf typedClientHandler) ASDUHandler(c asdu.Connect, a *asdu.ASDU) error {
+	id := a.Identifier
+	switch id.Type {
+	case asdu.M_SP_NA_1, asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		return sf.h.OnSinglePoint(a.GetSinglePoint(), id)
+	case asdu.M_DP_NA_1, asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		return sf.h.OnDoublePoint(a.GetDoublePoint(), id)
+	case asdu.M_ST_NA_1, asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		return sf.h.OnStepPosition(a.GetStepPosition(), id)
+	case asdu.M_BO_NA_1, asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		return sf.h.OnBitString32(a.GetBitString32(), id)
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1:
+		return sf.h.OnMeasuredNormal(a.GetMeasuredValueNormal(), id)
+	case asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		return sf.h.OnMeasuredScaled(a.GetMeasuredValueScaled(), id)
+	case asdu.M_ME_NC_1, asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		return sf.h.OnMeasuredFloat(a.GetMeasuredValueFloat(), id)
+	case asdu.M_IT_NA_1, asdu.M_IT_TA_1, asdu.M_IT_TB_1:
+		return sf.h.OnIntegratedTotals(a.GetIntegratedTotals(), id)
+	case asdu.M_EP_TA_1, asdu.M_EP_TD_1:
+		return sf.h.OnProtectionEvent(a.GetEventOfProtectionEquipment(), id)
+	case asdu.M_EP_TB_1, asdu.M_EP_TE_1:
+		return sf.h.OnProtectionStartEvents(a.GetPackedStartEventsOfProtectionEquipment(), id)
+	case asdu.M_EP_TC_1, asdu.M_EP_TF_1:
+		return sf.h.OnProtectionOutputCircuit(a.GetPackedOutputCircuitInfo(), id)
+	case asdu.M_PS_NA_1:
+		return sf.h.OnPackedSinglePoint(a.GetPackedSinglePointWithSCD(), id)
+	case asdu.M_EI_NA_1:
+		ioa, coi := a.GetEndOfInitialization()
+		return sf.h.OnEndOfInitialization(ioa, coi, id)
+	}
+	return sf.h.OnOther(c, a)
+}
@@ -0,0 +1,48 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type floatHandler struct {
+	ClientHandlerBase
+	values []float32
+	others int
+}
+
+func (sf *floatHandler) OnMeasuredFloat(infos []asdu.MeasuredValueFloatInfo, _ asdu.Identifier) error {
+	for _, v := range infos {
+		sf.values = append(sf.values, v.Value)
+	}
+	return nil
+}
+
+func (sf *floatHandler) OnOther(asdu.Connect, *asdu.ASDU) error {
+	sf.others++
+	return nil
+}
+
+func TestTypedClientHandler(t *testing.T) {
+	c := &recordConn{}
+	_ = asdu.MeasuredValueFloat(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+		asdu.MeasuredValueFloatInfo{Ioa: 1, Value: 1.5}, asdu.MeasuredValueFloatInfo{Ioa: 2, Value: 2.5})
+	_ = asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.SinglePointInfo{Ioa: 3})
+	_ = asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation)
+
+	h := &floatHandler{}
+	th := NewTypedClientHandler(h)
+	sent := c.take()
+	for _, a := range sent[:2] {
+		if err := th.ASDUHandlerAll(c, a, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := th.InterrogationHandler(c, sent[2]); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.values) != 2 || h.values[0] != 1.5 || h.values[1] != 2.5 || h.others != 1 {
+		t.Errorf("typed handler got values %v, others %d", h.values, h.others)
+	}
+}
@@ -0,0 +1,390 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/clog"
+)
+
+// pool error defined
+var (
+	ErrEndpointExists  = errors.New("endpoint already exists")
+	ErrEndpointUnknown = errors.New("unknown endpoint")
+)
+
+// DefaultPoolQueueSize default number of events a pool worker queues before the endpoints block
+const DefaultPoolQueueSize = 1024
+
+// EndpointState the health of an endpoint of a ClientPool
+type EndpointState uint32
+
+// endpoint state defined
+const (
+	// EndpointIdle the endpoint is added but the pool is not started
+	EndpointIdle EndpointState = iota
+	// EndpointConnecting the connection is being established
+	EndpointConnecting
+	// EndpointConnected the connection is established and the data transfer started
+	EndpointConnected
+	// EndpointLost the connection is lost, it is being reconnected if the option allows
+	EndpointLost
+	// EndpointClosed the endpoint is removed or the pool closed
+	EndpointClosed
+)
+
+// String returns the name of the state
+func (sf EndpointState) String() string {
+	switch sf {
+	case EndpointIdle:
+		return "idle"
+	case EndpointConnecting:
+		return "connecting"
+	case EndpointConnected:
+		return "connected"
+	case EndpointLost:
+		return "lost"
+	case EndpointClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// EndpointHealth the health of an endpoint
+type EndpointHealth struct {
+	Name       string
+	State      EndpointState
+	Since      time.Time // time of the last state change
+	Reconnects int       // the connections lost so far
+}
+
+// PoolEvent an event of the aggregated stream of a ClientPool, either a state change
+// of the endpoint, ASDU nil, or an asdu received from it.
+type PoolEvent struct {
+	Endpoint string
+	Client   *Client // the client of the endpoint, to reply or send commands
+	Time     time.Time
+	State    EndpointState
+	ASDU     *asdu.ASDU
+}
+
+// ClientPool maintains the connections to many controlled stations, such as the RTUs of a data concentrator.
+// Each endpoint is a Client with its own option, the data transfer is started on connect.
+// The events of all the endpoints are delivered to one handler by a fixed set of worker goroutines
+// shared by the pool, the events of an endpoint always go to the same worker so that they keep their order.
+type ClientPool struct {
+	handler   func(ev PoolEvent)
+	workers   int
+	queueSize int
+
+	mux       sync.Mutex
+	endpoints map[string]*poolEndpoint
+	queues    []chan PoolEvent
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	clog.Clog
+}
+
+// poolEndpoint an endpoint of the pool
+type poolEndpoint struct {
+	name   string
+	client *Client
+
+	mux        sync.Mutex
+	ctx        context.Context
+	queue      chan PoolEvent // the queue of the worker of the endpoint
+	state      EndpointState
+	since      time.Time
+	reconnects int
+}
+
+// NewClientPool new a client pool delivering the events of all the endpoints to the handler,
+// which must not block for long as the endpoints sharing its worker wait meanwhile.
+func NewClientPool(handler func(ev PoolEvent)) *ClientPool {
+	return &ClientPool{
+		handler:   handler,
+		workers:   runtime.NumCPU(),
+		queueSize: DefaultPoolQueueSize,
+		endpoints: make(map[string]*poolEndpoint),
+		Clog:      clog.NewLogger("cs104 client pool => "),
+	}
+}
+
+// SetWorkers set the number of the worker goroutines, default runtime.NumCPU(), before Start
+func (sf *ClientPool) SetWorkers(n int) *ClientPool {
+	if n > 0 {
+		sf.workers = n
+	}
+	return sf
+}
+
+// SetQueueSize set the number of events each worker queues, default DefaultPoolQueueSize, before Start
+func (sf *ClientPool) SetQueueSize(n int) *ClientPool {
+	if n > 0 {
+		sf.queueSize = n
+	}
+	return sf
+}
+
+// Add add the endpoint of the name with its option, which must have the remote server set.
+// The endpoint is connected at once if the pool is started.
+func (sf *ClientPool) Add(name string, o *ClientOption) error {
+	if o.server == nil {
+		return fmt.Errorf("endpoint %q: empty remote server", name)
+	}
+	sf.mux.Lock()
+	if _, ok := sf.endpoints[name]; ok {
+		sf.mux.Unlock()
+		return fmt.Errorf("%w %q", ErrEndpointExists, name)
+	}
+	ep := &poolEndpoint{name: name, since: time.Now()}
+	ep.client = NewClient(&poolHandler{sf, ep}, o)
+	ep.client.SetOnConnectHandler(func(c *Client) {
+		c.SendStartDt()
+		sf.setState(ep, EndpointConnected)
+	})
+	ep.client.SetConnectionLostHandler(func(*Client) {
+		sf.setState(ep, EndpointLost)
+	})
+	sf.endpoints[name] = ep
+	started := sf.ctx != nil
+	sf.mux.Unlock()
+	if started {
+		sf.start(ep)
+	}
+	return nil
+}
+
+// Remove close and remove the endpoint of the name
+func (sf *ClientPool) Remove(name string) error {
+	sf.mux.Lock()
+	ep, ok := sf.endpoints[name]
+	delete(sf.endpoints, name)
+	sf.mux.Unlock()
+	if !ok {
+		return fmt.Errorf("%w %q", ErrEndpointUnknown, name)
+	}
+	_ = ep.client.Close()
+	sf.setState(ep, EndpointClosed)
+	return nil
+}
+
+// Client returns the client of the endpoint of the name
+func (sf *ClientPool) Client(name string) (*Client, bool) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if ep, ok := sf.endpoints[name]; ok {
+		return ep.client, true
+	}
+	return nil, false
+}
+
+// Health returns the health of all the endpoints sorted by name
+func (sf *ClientPool) Health() []EndpointHealth {
+	sf.mux.Lock()
+	eps := make([]*poolEndpoint, 0, len(sf.endpoints))
+	for _, ep := range sf.endpoints {
+		eps = append(eps, ep)
+	}
+	sf.mux.Unlock()
+
+	hs := make([]EndpointHealth, 0, len(eps))
+	for _, ep := range eps {
+		hs = append(hs, ep.health())
+	}
+	sort.Slice(hs, func(i, j int) bool { return hs[i].Name < hs[j].Name })
+	return hs
+}
+
+// Start start the workers and connect all the endpoints in background
+func (sf *ClientPool) Start() error {
+	return sf.StartContext(context.Background())
+}
+
+// StartContext is like Start, the pool is closed when ctx is done.
+func (sf *ClientPool) StartContext(ctx context.Context) error {
+	sf.mux.Lock()
+	if sf.ctx != nil {
+		sf.mux.Unlock()
+		return errors.New("client pool already started")
+	}
+	sf.ctx, sf.cancel = context.WithCancel(ctx)
+	sf.queues = make([]chan PoolEvent, sf.workers)
+	for i := range sf.queues {
+		sf.queues[i] = make(chan PoolEvent, sf.queueSize)
+		sf.wg.Add(1)
+		go sf.worker(sf.queues[i])
+	}
+	eps := make([]*poolEndpoint, 0, len(sf.endpoints))
+	for _, ep := range sf.endpoints {
+		eps = append(eps, ep)
+	}
+	sf.mux.Unlock()
+
+	for _, ep := range eps {
+		sf.start(ep)
+	}
+	return nil
+}
+
+// Close close all the connections and stop the workers, the queued events are dropped
+func (sf *ClientPool) Close() error {
+	sf.mux.Lock()
+	eps := sf.endpoints
+	sf.endpoints = make(map[string]*poolEndpoint)
+	cancel := sf.cancel
+	sf.mux.Unlock()
+
+	for _, ep := range eps {
+		_ = ep.client.Close()
+		ep.setState(EndpointClosed)
+	}
+	if cancel != nil {
+		cancel()
+		sf.wg.Wait()
+	}
+	return nil
+}
+
+// start assign the endpoint a worker and connect it
+func (sf *ClientPool) start(ep *poolEndpoint) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(ep.name))
+	sf.mux.Lock()
+	ctx, queue := sf.ctx, sf.queues[h.Sum32()%uint32(len(sf.queues))]
+	sf.mux.Unlock()
+
+	ep.mux.Lock()
+	ep.ctx, ep.queue = ctx, queue
+	ep.mux.Unlock()
+	sf.setState(ep, EndpointConnecting)
+	if err := ep.client.StartContext(ctx); err != nil {
+		sf.Error("endpoint %q start failed, %v", ep.name, err)
+	}
+}
+
+// worker deliver the events of its queue to the handler
+func (sf *ClientPool) worker(queue chan PoolEvent) {
+	defer sf.wg.Done()
+	for {
+		select {
+		case <-sf.ctx.Done():
+			return
+		case ev := <-queue:
+			sf.deliver(ev)
+		}
+	}
+}
+
+// deliver call the handler with the event, a panic is logged
+func (sf *ClientPool) deliver(ev PoolEvent) {
+	defer func() {
+		if err := recover(); err != nil {
+			sf.Critical("endpoint %q event handler %+v", ev.Endpoint, err)
+		}
+	}()
+	if sf.handler != nil {
+		sf.handler(ev)
+	}
+}
+
+// setState change the state of the endpoint and post the event if it changed
+func (sf *ClientPool) setState(ep *poolEndpoint, state EndpointState) {
+	if ep.setState(state) {
+		sf.Debug("endpoint %q %v", ep.name, state)
+		sf.post(ep, PoolEvent{Endpoint: ep.name, Client: ep.client, Time: time.Now(), State: state})
+	}
+}
+
+// post queue the event to the worker of the endpoint, it blocks while the queue is full
+func (sf *ClientPool) post(ep *poolEndpoint, ev PoolEvent) {
+	ep.mux.Lock()
+	ctx, queue := ep.ctx, ep.queue
+	ep.mux.Unlock()
+	if ctx == nil || queue == nil {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case queue <- ev:
+	}
+}
+
+// setState returns whether the state changed
+func (sf *poolEndpoint) setState(state EndpointState) bool {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.state == state {
+		return false
+	}
+	if state == EndpointLost {
+		sf.reconnects++
+	}
+	sf.state, sf.since = state, time.Now()
+	return true
+}
+
+func (sf *poolEndpoint) health() EndpointHealth {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return EndpointHealth{sf.name, sf.state, sf.since, sf.reconnects}
+}
+
+// poolHandler the client handler of an endpoint posting the asdu to the aggregated stream
+type poolHandler struct {
+	pool *ClientPool
+	ep   *poolEndpoint
+}
+
+func (sf *poolHandler) post(a *asdu.ASDU) error {
+	sf.ep.mux.Lock()
+	state := sf.ep.state
+	sf.ep.mux.Unlock()
+	sf.pool.post(sf.ep, PoolEvent{Endpoint: sf.ep.name, Client: sf.ep.client, Time: time.Now(), State: state, ASDU: a})
+	return nil
+}
+
+// InterrogationHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) InterrogationHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.post(a) }
+
+// CounterInterrogationHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) CounterInterrogationHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.post(a)
+}
+
+// ReadHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) ReadHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.post(a) }
+
+// TestCommandHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) TestCommandHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.post(a) }
+
+// ClockSyncHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) ClockSyncHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.post(a) }
+
+// ResetProcessHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) ResetProcessHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.post(a) }
+
+// DelayAcquisitionHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) DelayAcquisitionHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.post(a)
+}
+
+// ASDUHandler imp interface ClientHandlerInterface
+func (sf *poolHandler) ASDUHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.post(a) }
+
+// ASDUHandlerAll imp interface ClientHandlerInterface
+func (sf *poolHandler) ASDUHandlerAll(_ asdu.Connect, a *asdu.ASDU, _ *Server, _ int) error {
+	return sf.post(a)
+}
@@ -0,0 +1,87 @@
+package cs104
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestClientPool(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	srv := NewServer(&mockServerHandler{})
+	go srv.ListenAndServer(addr)
+	defer srv.Close()
+
+	events := make(chan PoolEvent, 16)
+	pool := NewClientPool(func(ev PoolEvent) { events <- ev }).SetWorkers(2)
+	o := NewOption().SetReconnectInterval(10 * time.Millisecond)
+	if err = o.AddRemoteServer(addr); err != nil {
+		t.Fatal(err)
+	}
+	if err = pool.Add("rtu1", o); err != nil {
+		t.Fatal(err)
+	}
+	if err = pool.Add("rtu1", o); !errors.Is(err, ErrEndpointExists) {
+		t.Errorf("duplicate endpoint error %v, want ErrEndpointExists", err)
+	}
+	if err = pool.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	next := func() PoolEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event")
+		}
+		return PoolEvent{}
+	}
+	if ev := next(); ev.Endpoint != "rtu1" || ev.State != EndpointConnecting {
+		t.Fatalf("event %+v, want rtu1 connecting", ev)
+	}
+	if ev := next(); ev.State != EndpointConnected || ev.ASDU != nil {
+		t.Fatalf("event %+v, want connected", ev)
+	}
+
+	// the server sends once the data transfer is started
+	c, ok := pool.Client("rtu1")
+	if !ok {
+		t.Fatal("no client of rtu1")
+	}
+	for i := 0; atomic.LoadUint32(&c.isActive) != active; i++ {
+		if i == 500 {
+			t.Fatal("data transfer not started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	info := asdu.SinglePointInfo{Ioa: 100, Value: true}
+	if err = asdu.Single(srv, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, info); err != nil {
+		t.Fatal(err)
+	}
+	ev := next()
+	if ev.Endpoint != "rtu1" || ev.ASDU == nil || ev.ASDU.Type != asdu.M_SP_NA_1 || ev.Client == nil {
+		t.Fatalf("event %+v, want M_SP_NA_1 of rtu1", ev)
+	}
+
+	if hs := pool.Health(); len(hs) != 1 || hs[0].Name != "rtu1" || hs[0].State != EndpointConnected {
+		t.Errorf("health %+v, want rtu1 connected", hs)
+	}
+	if err = pool.Remove("rtu1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = pool.Remove("rtu1"); !errors.Is(err, ErrEndpointUnknown) {
+		t.Errorf("remove error %v, want ErrEndpointUnknown", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// DefaultCoalesceWindow default coalescing window
+const DefaultCoalesceWindow = 500 * time.Millisecond
+
+type coalesceKey struct {
+	typeID asdu.TypeID
+	ca     asdu.CommonAddr
+	coa    asdu.CauseOfTransmission
+}
+
+type coalesceGroup struct {
+	order []asdu.InfoObjAddr // first change order
+	infos map[asdu.InfoObjAddr]interface{}
+}
+
+// Coalescer a asdu.Connect which coalesces the rapid consecutive spontaneous changes of the
+// same measured value within the window into a single asdu carrying the latest value.
+// Only the measured values without time tag [M_ME_NA_1], [M_ME_NB_1], [M_ME_NC_1] and [M_ME_ND_1]
+// are coalesced, any other asdu, include all the time tagged ones, is sent at once.
+type Coalescer struct {
+	asdu.Connect
+	window time.Duration
+
+	mux     sync.Mutex
+	keys    []coalesceKey // first change order
+	pending map[coalesceKey]*coalesceGroup
+	timer   *time.Timer
+}
+
+// NewCoalescer new a coalescer sending with c, if window <= 0 use DefaultCoalesceWindow
+func NewCoalescer(c asdu.Connect, window time.Duration) *Coalescer {
+	if window <= 0 {
+		window = DefaultCoalesceWindow
+	}
+	return &Coalescer{
+		Connect: c,
+		window:  window,
+		pending: make(map[coalesceKey]*coalesceGroup),
+	}
+}
+
+// Send the asdu, the measured values are kept until the end of the window
+func (sf *Coalescer) Send(a *asdu.ASDU) error {
+	if a.Coa.Cause != asdu.Spontaneous {
+		return sf.Connect.Send(a)
+	}
+
+	var infos []interface{}
+	var ioas []asdu.InfoObjAddr
+	switch pack := a.Clone(); pack.Type {
+	case asdu.M_ME_NA_1, asdu.M_ME_ND_1:
+		for _, v := range pack.GetMeasuredValueNormal() {
+			infos, ioas = append(infos, v), append(ioas, v.Ioa)
+		}
+	case asdu.M_ME_NB_1:
+		for _, v := range pack.GetMeasuredValueScaled() {
+			infos, ioas = append(infos, v), append(ioas, v.Ioa)
+		}
+	case asdu.M_ME_NC_1:
+		for _, v := range pack.GetMeasuredValueFloat() {
+			infos, ioas = append(infos, v), append(ioas, v.Ioa)
+		}
+	default:
+		return sf.Connect.Send(a)
+	}
+
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	key := coalesceKey{a.Type, a.CommonAddr, a.Coa}
+	g, ok := sf.pending[key]
+	if !ok {
+		g = &coalesceGroup{infos: make(map[asdu.InfoObjAddr]interface{})}
+		sf.pending[key] = g
+		sf.keys = append(sf.keys, key)
+	}
+	for i, ioa := range ioas {
+		if _, ok := g.infos[ioa]; !ok {
+			g.order = append(g.order, ioa)
+		}
+		g.infos[ioa] = infos[i]
+	}
+	if sf.timer == nil {
+		sf.timer = time.AfterFunc(sf.window, func() { _ = sf.Flush() })
+	}
+	return nil
+}
+
+// Flush send all the kept measured values at once
+func (sf *Coalescer) Flush() error {
+	sf.mux.Lock()
+	keys, pending := sf.keys, sf.pending
+	sf.keys, sf.pending = nil, make(map[coalesceKey]*coalesceGroup)
+	if sf.timer != nil {
+		sf.timer.Stop()
+		sf.timer = nil
+	}
+	sf.mux.Unlock()
+
+	var err error
+	for _, key := range keys {
+		if e := sf.send(key, pending[key]); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (sf *Coalescer) send(key coalesceKey, g *coalesceGroup) error {
+	p := sf.Params()
+	objSize, err := asdu.GetInfoObjSize(key.typeID)
+	if err != nil {
+		return err
+	}
+	n := (p.MaxASDUSize() - p.IdentifierSize()) / (p.InfoObjAddrSize + objSize)
+	for ioas := g.order; len(ioas) > 0; {
+		chunk := ioas
+		if len(chunk) > n {
+			chunk = chunk[:n]
+		}
+		ioas = ioas[len(chunk):]
+
+		switch key.typeID {
+		case asdu.M_ME_NA_1, asdu.M_ME_ND_1:
+			infos := make([]asdu.MeasuredValueNormalInfo, 0, len(chunk))
+			for _, ioa := range chunk {
+				infos = append(infos, g.infos[ioa].(asdu.MeasuredValueNormalInfo))
+			}
+			if key.typeID == asdu.M_ME_ND_1 {
+				err = asdu.MeasuredValueNormalNoQuality(sf.Connect, false, key.coa, key.ca, infos...)
+			} else {
+				err = asdu.MeasuredValueNormal(sf.Connect, false, key.coa, key.ca, infos...)
+			}
+		case asdu.M_ME_NB_1:
+			infos := make([]asdu.MeasuredValueScaledInfo, 0, len(chunk))
+			for _, ioa := range chunk {
+				infos = append(infos, g.infos[ioa].(asdu.MeasuredValueScaledInfo))
+			}
+			err = asdu.MeasuredValueScaled(sf.Connect, false, key.coa, key.ca, infos...)
+		case asdu.M_ME_NC_1:
+			infos := make([]asdu.MeasuredValueFloatInfo, 0, len(chunk))
+			for _, ioa := range chunk {
+				infos = append(infos, g.infos[ioa].(asdu.MeasuredValueFloatInfo))
+			}
+			err = asdu.MeasuredValueFloat(sf.Connect, false, key.coa, key.ca, infos...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestCoalescer(t *testing.T) {
+	c := &recordConn{}
+	co := NewCoalescer(c, time.Hour)
+	spont := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+
+	for _, v := range []float32{1, 2, 3} {
+		if err := asdu.MeasuredValueFloat(co, false, spont, 1,
+			asdu.MeasuredValueFloatInfo{Ioa: 100, Value: v}, asdu.MeasuredValueFloatInfo{Ioa: 101, Value: v * 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := asdu.SingleCP56Time2a(co, spont, 1, asdu.SinglePointInfo{Ioa: 1, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if sent := c.take(); len(sent) != 1 || sent[0].Type != asdu.M_SP_TB_1 {
+		t.Fatalf("Send() time tagged point sent %v", sent)
+	}
+
+	if err := co.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	sent := c.take()
+	if len(sent) != 1 {
+		t.Fatalf("Flush() sent %v", sent)
+	}
+	info := sent[0].GetMeasuredValueFloat()
+	if len(info) != 2 || info[0].Ioa != 100 || info[0].Value != 3 || info[1].Ioa != 101 || info[1].Value != 30 {
+		t.Errorf("Flush() sent %v, want the latest values", info)
+	}
+}
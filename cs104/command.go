@@ -0,0 +1,69 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ServerCommandHandler the typed handler of the process commands, the command is decoded before calling,
+// and the return value drives the confirmations the same as ConfirmHandler:
+//
+//	nil: positive confirmation, followed by the activation termination of an execute command
+//	ErrPending: positive confirmation, the activation termination is sent later with Termination(c)
+//	ErrReject: negative confirmation with the cause
+//	other error: negative confirmation
+//
+// The identifier tells the common address, the cause (activation or deactivation) and
+// whether it is the time tagged command.
+type ServerCommandHandler interface {
+	// [C_SC_NA_1], [C_SC_TA_1]
+	OnSingleCommand(asdu.Connect, asdu.Identifier, asdu.SingleCommandInfo) error
+	// [C_DC_NA_1], [C_DC_TA_1]
+	OnDoubleCommand(asdu.Connect, asdu.Identifier, asdu.DoubleCommandInfo) error
+	// [C_RC_NA_1], [C_RC_TA_1]
+	OnStepCommand(asdu.Connect, asdu.Identifier, asdu.StepCommandInfo) error
+	// [C_SE_NA_1], [C_SE_TA_1]
+	OnSetpointNormal(asdu.Connect, asdu.Identifier, asdu.SetpointCommandNormalInfo) error
+	// [C_SE_NB_1], [C_SE_TB_1]
+	OnSetpointScaled(asdu.Connect, asdu.Identifier, asdu.SetpointCommandScaledInfo) error
+	// [C_SE_NC_1], [C_SE_TC_1]
+	OnSetpointFloat(asdu.Connect, asdu.Identifier, asdu.SetpointCommandFloatInfo) error
+	// [C_BO_NA_1], [C_BO_TA_1]
+	OnBitString32Command(asdu.Connect, asdu.Identifier, asdu.BitsString32CommandInfo) error
+}
+
+// commandConfirmHandler adapts a ServerCommandHandler to the ConfirmHandler
+type commandConfirmHandler struct {
+	h ServerCommandHandler
+}
+
+// Handle decode the process command and dispatch it
+func (sf commandConfirmHandler) Handle(c asdu.Connect, pack *asdu.ASDU) error {
+	id := pack.Identifier
+	switch id.Type {
+	case asdu.C_SC_NA_1, asdu.C_SC_TA_1:
+		return sf.h.OnSingleCommand(c, id, pack.GetSingleCmd())
+	case asdu.C_DC_NA_1, asdu.C_DC_TA_1:
+		return sf.h.OnDoubleCommand(c, id, pack.GetDoubleCmd())
+	case asdu.C_RC_NA_1, asdu.C_RC_TA_1:
+		return sf.h.OnStepCommand(c, id, pack.GetStepCmd())
+	case asdu.C_SE_NA_1, asdu.C_SE_TA_1:
+		return sf.h.OnSetpointNormal(c, id, pack.GetSetpointNormalCmd())
+	case asdu.C_SE_NB_1, asdu.C_SE_TB_1:
+		return sf.h.OnSetpointScaled(c, id, pack.GetSetpointCmdScaled())
+	case asdu.C_SE_NC_1, asdu.C_SE_TC_1:
+		return sf.h.OnSetpointFloat(c, id, pack.GetSetpointFloatCmd())
+	case asdu.C_BO_NA_1, asdu.C_BO_TA_1:
+		return sf.h.OnBitString32Command(c, id, pack.GetBitsString32Cmd())
+	}
+	return ErrReject{asdu.UnknownTypeID}
+}
+
+// isProcessCommand whether the type identification is a process command
+func isProcessCommand(id asdu.TypeID) bool {
+	return (id >= asdu.C_SC_NA_1 && id <= asdu.C_BO_NA_1) ||
+		(id >= asdu.C_SC_TA_1 && id <= asdu.C_BO_TA_1)
+}
@@ -0,0 +1,65 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type mockCommandHandler struct {
+	ServerCommandHandler // only the single command and the float setpoint are used
+	setpoint             float32
+}
+
+func (sf *mockCommandHandler) OnSingleCommand(_ asdu.Connect, _ asdu.Identifier, cmd asdu.SingleCommandInfo) error {
+	if cmd.Ioa != 1 {
+		return ErrReject{asdu.UnknownIOA}
+	}
+	return nil
+}
+
+func (sf *mockCommandHandler) OnSetpointFloat(_ asdu.Connect, _ asdu.Identifier, cmd asdu.SetpointCommandFloatInfo) error {
+	sf.setpoint = cmd.Value
+	return nil
+}
+
+func TestSrvSession_commandHandler(t *testing.T) {
+	h := &mockCommandHandler{}
+	sess := newTestSession(&mockServerHandler{})
+	sess.cmdHandler = h
+
+	c := &recordConn{}
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	_ = asdu.SingleCmd(c, asdu.C_SC_NA_1, act, 1, asdu.SingleCommandInfo{Ioa: 1, Value: true})
+	_ = asdu.SingleCmd(c, asdu.C_SC_NA_1, act, 1, asdu.SingleCommandInfo{Ioa: 2, Value: true})
+	_ = asdu.SetpointCmdFloat(c, asdu.C_SE_NC_1, act, 1, asdu.SetpointCommandFloatInfo{Ioa: 3, Value: 1.5})
+
+	tests := []struct {
+		name string
+		want []asdu.CauseOfTransmission
+	}{
+		{"accept", []asdu.CauseOfTransmission{{Cause: asdu.ActivationCon}, {Cause: asdu.ActivationTerm}}},
+		{"reject", []asdu.CauseOfTransmission{{Cause: asdu.UnknownIOA, IsNegative: true}}},
+		{"setpoint", []asdu.CauseOfTransmission{{Cause: asdu.ActivationCon}, {Cause: asdu.ActivationTerm}}},
+	}
+	for i, req := range c.take() {
+		tt := tests[i]
+		t.Run(tt.name, func(t *testing.T) {
+			if err := sess.serverHandler(req); err != nil {
+				t.Fatal(err)
+			}
+			sent := sess.sent(t)
+			if len(sent) != len(tt.want) {
+				t.Fatalf("serverHandler() sent %v, want %v", sent, tt.want)
+			}
+			for j, a := range sent {
+				if a.Coa != tt.want[j] {
+					t.Errorf("serverHandler() sent %v, want %v", a.Identifier, tt.want[j])
+				}
+			}
+		})
+	}
+	if h.setpoint != 1.5 {
+		t.Errorf("OnSetpointFloat() got %v, want 1.5", h.setpoint)
+	}
+}
@@ -5,11 +5,15 @@
 package cs104
 
 import (
+	"context"
 	"crypto/tls"
-	"errors"
+	"fmt"
 	"net"
 	"net/url"
 	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/clog"
 )
 
 // DefaultReconnectInterval defined default value
@@ -20,16 +24,46 @@ type seqPending struct {
 	sendTime time.Time
 }
 
-func openConnection(uri *url.URL, tlsc *tls.Config, timeout time.Duration) (net.Conn, error) {
+func openConnection(ctx context.Context, uri *url.URL, tlsc *tls.Config, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
 	switch uri.Scheme {
 	case "tcp":
-		return net.DialTimeout("tcp", uri.Host, timeout)
+		return dialer.DialContext(ctx, "tcp", uri.Host)
 	case "ssl":
 		fallthrough
 	case "tls":
 		fallthrough
 	case "tcps":
-		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", uri.Host, tlsc)
+		return (&tls.Dialer{NetDialer: dialer, Config: tlsc}).DialContext(ctx, "tcp", uri.Host)
+	}
+	return nil, asdu.NewError(asdu.ErrConfig, "unknown protocol")
+}
+
+// sleepContext pauses for d, it reports false if ctx is done meanwhile.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// describedASDU formats the asdu with the catalog of the logger language only when it is logged
+type describedASDU struct {
+	lang string
+	a    *asdu.ASDU
+}
+
+func describeASDU(l clog.Clog, a *asdu.ASDU) describedASDU {
+	return describedASDU{l.Language(), a}
+}
+
+func (sf describedASDU) String() string {
+	if c, ok := asdu.LookupCatalog(asdu.Language(sf.lang)); ok {
+		return c.DescribeASDU(sf.a)
 	}
-	return nil, errors.New("unknown protocol")
+	return fmt.Sprintf("%+v", sf.a)
 }
@@ -5,8 +5,11 @@
 package cs104
 
 import (
-	"errors"
+	"bytes"
+	"encoding/json"
 	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
 )
 
 const (
@@ -80,43 +83,43 @@ type Config struct {
 // Valid applies the default (defined by IEC) for each unspecified value.
 func (sf *Config) Valid() error {
 	if sf == nil {
-		return errors.New("invalid pointer")
+		return asdu.NewError(asdu.ErrConfig, "invalid pointer")
 	}
 
 	if sf.ConnectTimeout0 == 0 {
 		sf.ConnectTimeout0 = 30 * time.Second
 	} else if sf.ConnectTimeout0 < ConnectTimeout0Min || sf.ConnectTimeout0 > ConnectTimeout0Max {
-		return errors.New(`ConnectTimeout0 "t₀" not in [1, 255]s`)
+		return asdu.NewError(asdu.ErrConfig, `ConnectTimeout0 "t₀" not in [1, 255]s`)
 	}
 
 	if sf.SendUnAckLimitK == 0 {
 		sf.SendUnAckLimitK = 12
 	} else if sf.SendUnAckLimitK < SendUnAckLimitKMin || sf.SendUnAckLimitK > SendUnAckLimitKMax {
-		return errors.New(`SendUnAckLimitK "k" not in [1, 32767]`)
+		return asdu.NewError(asdu.ErrConfig, `SendUnAckLimitK "k" not in [1, 32767]`)
 	}
 
 	if sf.SendUnAckTimeout1 == 0 {
 		sf.SendUnAckTimeout1 = 15 * time.Second
 	} else if sf.SendUnAckTimeout1 < SendUnAckTimeout1Min || sf.SendUnAckTimeout1 > SendUnAckTimeout1Max {
-		return errors.New(`SendUnAckTimeout1 "t₁" not in [1, 255]s`)
+		return asdu.NewError(asdu.ErrConfig, `SendUnAckTimeout1 "t₁" not in [1, 255]s`)
 	}
 
 	if sf.RecvUnAckLimitW == 0 {
 		sf.RecvUnAckLimitW = 8
 	} else if sf.RecvUnAckLimitW < RecvUnAckLimitWMin || sf.RecvUnAckLimitW > RecvUnAckLimitWMax {
-		return errors.New(`RecvUnAckLimitW "w" not in [1, 32767]`)
+		return asdu.NewError(asdu.ErrConfig, `RecvUnAckLimitW "w" not in [1, 32767]`)
 	}
 
 	if sf.RecvUnAckTimeout2 == 0 {
 		sf.RecvUnAckTimeout2 = 10 * time.Second
 	} else if sf.RecvUnAckTimeout2 < RecvUnAckTimeout2Min || sf.RecvUnAckTimeout2 > RecvUnAckTimeout2Max {
-		return errors.New(`RecvUnAckTimeout2 "t₂" not in [1, 255]s`)
+		return asdu.NewError(asdu.ErrConfig, `RecvUnAckTimeout2 "t₂" not in [1, 255]s`)
 	}
 
 	if sf.IdleTimeout3 == 0 {
 		sf.IdleTimeout3 = 20 * time.Second
 	} else if sf.IdleTimeout3 < IdleTimeout3Min || sf.IdleTimeout3 > IdleTimeout3Max {
-		return errors.New(`IdleTimeout3 "t₃" not in [1 second, 48 hours]`)
+		return asdu.NewError(asdu.ErrConfig, `IdleTimeout3 "t₃" not in [1 second, 48 hours]`)
 	}
 
 	return nil
@@ -133,3 +136,69 @@ func DefaultConfig() Config {
 		20 * time.Second,
 	}
 }
+
+// configJSON the serialized form of Config, zero is the default
+type configJSON struct {
+	ConnectTimeout0   duration `json:"t0,omitempty"`
+	SendUnAckLimitK   uint16   `json:"k,omitempty"`
+	SendUnAckTimeout1 duration `json:"t1,omitempty"`
+	RecvUnAckLimitW   uint16   `json:"w,omitempty"`
+	RecvUnAckTimeout2 duration `json:"t2,omitempty"`
+	IdleTimeout3      duration `json:"t3,omitempty"`
+}
+
+// MarshalJSON implement json.Marshaler, the timeouts are duration strings such as "15s".
+func (sf Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configJSON{
+		duration(sf.ConnectTimeout0),
+		sf.SendUnAckLimitK,
+		duration(sf.SendUnAckTimeout1),
+		sf.RecvUnAckLimitW,
+		duration(sf.RecvUnAckTimeout2),
+		duration(sf.IdleTimeout3),
+	})
+}
+
+// UnmarshalJSON implement json.Unmarshaler, the fields absent keep their value, the unknown ones are an error.
+func (sf *Config) UnmarshalJSON(data []byte) error {
+	v := configJSON{
+		duration(sf.ConnectTimeout0),
+		sf.SendUnAckLimitK,
+		duration(sf.SendUnAckTimeout1),
+		sf.RecvUnAckLimitW,
+		duration(sf.RecvUnAckTimeout2),
+		duration(sf.IdleTimeout3),
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	*sf = Config{
+		time.Duration(v.ConnectTimeout0),
+		v.SendUnAckLimitK,
+		time.Duration(v.SendUnAckTimeout1),
+		v.RecvUnAckLimitW,
+		time.Duration(v.RecvUnAckTimeout2),
+		time.Duration(v.IdleTimeout3),
+	}
+	return nil
+}
+
+// duration a time.Duration serialized as a string, such as "1.5s"
+type duration time.Duration
+
+// MarshalText implement encoding.TextMarshaler
+func (sf duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(sf).String()), nil
+}
+
+// UnmarshalText implement encoding.TextUnmarshaler
+func (sf *duration) UnmarshalText(text []byte) error {
+	d, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*sf = duration(d)
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrPending returned by a ConfirmHandler when the command is accepted but terminates later,
+// the handler sends the activation termination with the function got from Termination.
+var ErrPending = errors.New("command pending")
+
+// ErrReject returned by a ConfirmHandler to reject the command with a negative confirmation of the cause
+type ErrReject struct {
+	Cause asdu.Cause
+}
+
+func (sf ErrReject) Error() string {
+	return fmt.Sprintf("command rejected, %s", asdu.CauseOfTransmission{Cause: sf.Cause})
+}
+
+// ConfirmHandler the alternative server handler whose return value drives the confirmations:
+//
+//	nil: positive confirmation, followed by the activation termination if the command has one
+//	ErrPending: positive confirmation, the activation termination is deferred, see Termination
+//	ErrReject: negative confirmation with the cause
+//	other error: negative confirmation
+//
+// The positive confirmation is always sent before the first asdu the handler sends on the connection,
+// so the handler of an interrogation only sends the interrogated data.
+type ConfirmHandler interface {
+	Handle(asdu.Connect, *asdu.ASDU) error
+}
+
+// ConfirmHandlerFunc an adapter to allow the use of ordinary functions as ConfirmHandler
+type ConfirmHandlerFunc func(asdu.Connect, *asdu.ASDU) error
+
+// Handle calls f(c, pack)
+func (f ConfirmHandlerFunc) Handle(c asdu.Connect, pack *asdu.ASDU) error {
+	return f(c, pack)
+}
+
+// confirmConn a connection which sends the confirmation of the command before any other asdu
+type confirmConn struct {
+	asdu.Connect
+	req       *asdu.ASDU
+	confirmed bool
+}
+
+// Send the confirmation if not yet, then the asdu
+func (sf *confirmConn) Send(a *asdu.ASDU) error {
+	if err := sf.confirm(); err != nil {
+		return err
+	}
+	return sf.Connect.Send(a)
+}
+
+func (sf *confirmConn) confirm() error {
+	if sf.confirmed {
+		return nil
+	}
+	sf.confirmed = true
+	return sf.req.SendReplyMirror(sf.Connect, confirmCause(sf.req.Coa.Cause))
+}
+
+func (sf *confirmConn) reject(cause asdu.Cause) error {
+	reply := sf.req.Clone()
+	reply.Coa.IsNegative = true
+	return reply.SendReplyMirror(sf.Connect, cause)
+}
+
+func (sf *confirmConn) terminate() error {
+	return sf.req.SendReplyMirror(sf.Connect, asdu.ActivationTerm)
+}
+
+// Termination returns the function sending the activation termination of the command
+// being handled by a ConfirmHandler on the connection c, used when the handler returns ErrPending.
+// it returns nil if c is not a connection given to a ConfirmHandler.
+func Termination(c asdu.Connect) func() error {
+	cc, ok := c.(*confirmConn)
+	if !ok {
+		return nil
+	}
+	return cc.terminate
+}
+
+// confirmCause the cause of the confirmation for the cause of the command
+func confirmCause(cause asdu.Cause) asdu.Cause {
+	switch cause {
+	case asdu.Activation:
+		return asdu.ActivationCon
+	case asdu.Deactivation:
+		return asdu.DeactivationCon
+	}
+	return cause
+}
+
+// isConfirmable whether the type identification is a command confirmed by the outstation
+func isConfirmable(id asdu.TypeID) bool {
+	return (id >= asdu.C_SC_NA_1 && id <= asdu.C_BO_NA_1) ||
+		(id >= asdu.C_SC_TA_1 && id <= asdu.C_BO_TA_1) ||
+		(id >= asdu.C_IC_NA_1 && id <= asdu.C_TS_TA_1 && id != asdu.C_RD_NA_1) ||
+		(id >= asdu.P_ME_NA_1 && id <= asdu.P_AC_NA_1)
+}
+
+// hasTermination whether the command is terminated by an activation termination,
+// they are the interrogations and the execution of the process commands.
+func hasTermination(req *asdu.ASDU) bool {
+	if req.Coa.Cause != asdu.Activation {
+		return false
+	}
+	pack := req.Clone()
+	switch pack.Type {
+	case asdu.C_IC_NA_1, asdu.C_CI_NA_1, asdu.C_BO_NA_1, asdu.C_BO_TA_1:
+		return true
+	case asdu.C_SC_NA_1, asdu.C_SC_TA_1:
+		return !pack.GetSingleCmd().Qoc.InSelect
+	case asdu.C_DC_NA_1, asdu.C_DC_TA_1:
+		return !pack.GetDoubleCmd().Qoc.InSelect
+	case asdu.C_RC_NA_1, asdu.C_RC_TA_1:
+		return !pack.GetStepCmd().Qoc.InSelect
+	case asdu.C_SE_NA_1, asdu.C_SE_TA_1:
+		return !pack.GetSetpointNormalCmd().Qos.InSelect
+	case asdu.C_SE_NB_1, asdu.C_SE_TB_1:
+		return !pack.GetSetpointCmdScaled().Qos.InSelect
+	case asdu.C_SE_NC_1, asdu.C_SE_TC_1:
+		return !pack.GetSetpointFloatCmd().Qos.InSelect
+	}
+	return false
+}
+
+// confirmDispatch dispatch the command to the handler and send the confirmations driven by its return value
+func confirmDispatch(c asdu.Connect, h ConfirmHandler, pack *asdu.ASDU) error {
+	if pack.Type == asdu.C_CD_NA_1 && pack.Coa.Cause == asdu.Spontaneous {
+		return h.Handle(c, pack) // transmission delay, never confirmed
+	}
+
+	cc := &confirmConn{Connect: c, req: pack.Clone()}
+	if !(cc.req.Coa.Cause == asdu.Activation || cc.req.Coa.Cause == asdu.Deactivation) {
+		return cc.reject(asdu.UnknownCOT)
+	}
+	if cc.req.CommonAddr == asdu.InvalidCommonAddr {
+		return cc.reject(asdu.UnknownCA)
+	}
+
+	err := h.Handle(cc, pack)
+	switch {
+	case err == nil:
+		if err = cc.confirm(); err != nil {
+			return err
+		}
+		if hasTermination(cc.req) {
+			return cc.terminate()
+		}
+		return nil
+	case errors.Is(err, ErrPending):
+		return cc.confirm()
+	case cc.confirmed:
+		return err // already confirmed positive
+	}
+
+	var reject ErrReject
+	if errors.As(err, &reject) {
+		return cc.reject(reject.Cause)
+	}
+	if e := cc.reject(confirmCause(cc.req.Coa.Cause)); e != nil {
+		return e
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package cs104
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestConfirmDispatch(t *testing.T) {
+	var terminate func() error
+	h := ConfirmHandlerFunc(func(c asdu.Connect, pack *asdu.ASDU) error {
+		switch pack.Type {
+		case asdu.C_IC_NA_1:
+			return asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}, pack.CommonAddr,
+				asdu.SinglePointInfo{Ioa: 1, Value: true})
+		case asdu.C_DC_NA_1:
+			if pack.GetDoubleCmd().Ioa == 2 {
+				return ErrReject{asdu.UnknownIOA}
+			}
+			terminate = Termination(c)
+			return ErrPending
+		}
+		return errors.New("not support")
+	})
+
+	c := &recordConn{}
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	_ = asdu.InterrogationCmd(c, act, 1, asdu.QOIStation)
+	_ = asdu.SingleCmd(c, asdu.C_SC_NA_1, act, 1, asdu.SingleCommandInfo{Ioa: 1, Qoc: asdu.QualifierOfCommand{InSelect: true}})
+	_ = asdu.DoubleCmd(c, asdu.C_DC_NA_1, act, 1, asdu.DoubleCommandInfo{Ioa: 1, Value: asdu.DCOOn})
+	_ = asdu.DoubleCmd(c, asdu.C_DC_NA_1, act, 1, asdu.DoubleCommandInfo{Ioa: 2, Value: asdu.DCOOn})
+	_ = asdu.InterrogationCmd(c, act, 1, asdu.QOIStation)
+	reqs := c.take()
+	reqs[4].Coa.Cause = asdu.Spontaneous
+
+	tests := []struct {
+		name    string
+		wantErr bool
+		want    []asdu.CauseOfTransmission
+	}{
+		{"interrogation", false, []asdu.CauseOfTransmission{
+			{Cause: asdu.ActivationCon}, {Cause: asdu.InterrogatedByStation}, {Cause: asdu.ActivationTerm}}},
+		{"failed select", true, []asdu.CauseOfTransmission{{Cause: asdu.ActivationCon, IsNegative: true}}},
+		{"pending", false, []asdu.CauseOfTransmission{{Cause: asdu.ActivationCon}}},
+		{"reject", false, []asdu.CauseOfTransmission{{Cause: asdu.UnknownIOA, IsNegative: true}}},
+		{"unknown cause", false, []asdu.CauseOfTransmission{{Cause: asdu.UnknownCOT, IsNegative: true}}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := confirmDispatch(c, h, reqs[i]); (err != nil) != tt.wantErr {
+				t.Fatalf("confirmDispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			sent := c.take()
+			if len(sent) != len(tt.want) {
+				t.Fatalf("confirmDispatch() sent %v, want %v", sent, tt.want)
+			}
+			for j, a := range sent {
+				if a.Coa != tt.want[j] {
+					t.Errorf("confirmDispatch() sent %v, want %v", a.Identifier, tt.want[j])
+				}
+			}
+		})
+	}
+
+	if err := terminate(); err != nil {
+		t.Fatal(err)
+	}
+	if sent := c.take(); len(sent) != 1 || sent[0].Type != asdu.C_DC_NA_1 || sent[0].Coa.Cause != asdu.ActivationTerm {
+		t.Errorf("Termination() sent %v", sent)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package conformance runs IEC 60870-5-104 transport conformance scenarios,
+// after the test procedures of IEC 60870-5-604, against a device under test
+// of either role and reports the outcome in a machine readable form.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+// Role is the role of the device under test.
+type Role byte
+
+// defined roles
+const (
+	// ControlledStation the device under test is a server, the runner dials it.
+	ControlledStation Role = iota
+	// ControllingStation the device under test is a client, the runner listens for it.
+	ControllingStation
+)
+
+func (sf Role) String() string {
+	if sf == ControllingStation {
+		return "controlling"
+	}
+	return "controlled"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (sf Role) MarshalText() ([]byte, error) { return []byte(sf.String()), nil }
+
+// defaultTolerance is the slack granted on every timer by default.
+const defaultTolerance = time.Second
+
+// Target describes the device under test.
+type Target struct {
+	Role Role
+	// Addr is dialed for a controlled station and listened on for a controlling station.
+	Addr string
+	// Config holds the timers the device under test is parameterized with,
+	// the default is applied for each unspecified value.
+	Config cs104.Config
+	// Params of the device under test, default asdu.ParamsWide.
+	Params *asdu.Params
+	// CommonAddr the probing asdu is sent to, default 1.
+	CommonAddr asdu.CommonAddr
+	// Tolerance is the slack granted on every timer, default 1s.
+	Tolerance time.Duration
+}
+
+func (sf *Target) t1() time.Duration { return sf.Config.SendUnAckTimeout1 + sf.Tolerance }
+func (sf *Target) t2() time.Duration { return sf.Config.RecvUnAckTimeout2 + sf.Tolerance }
+func (sf *Target) t3() time.Duration { return sf.Config.IdleTimeout3 + sf.Tolerance }
+
+// Scenario is one executable conformance test case.
+type Scenario struct {
+	Name   string
+	Clause string // clause of IEC 60870-5-104 the scenario checks
+	Run    func(p *Peer) error
+}
+
+// Result is the outcome of one scenario.
+type Result struct {
+	Name    string        `json:"name"`
+	Clause  string        `json:"clause"`
+	Pass    bool          `json:"pass"`
+	Error   string        `json:"error,omitempty"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Role    Role      `json:"role"`
+	Addr    string    `json:"addr"`
+	Start   time.Time `json:"start"`
+	Passed  int       `json:"passed"`
+	Failed  int       `json:"failed"`
+	Results []Result  `json:"results"`
+}
+
+// WriteJSON writes the report as indented JSON.
+func (sf *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sf)
+}
+
+// Run executes the scenarios in order against the target, each over its own
+// connection. Without scenarios the built-in set for the role is run.
+func Run(target Target, scenarios ...Scenario) (*Report, error) {
+	if err := target.Config.Valid(); err != nil {
+		return nil, err
+	}
+	if target.Params == nil {
+		target.Params = asdu.ParamsWide
+	}
+	if err := target.Params.Valid(); err != nil {
+		return nil, err
+	}
+	if target.CommonAddr == asdu.InvalidCommonAddr {
+		target.CommonAddr = 1
+	}
+	if target.Tolerance == 0 {
+		target.Tolerance = defaultTolerance
+	}
+	if len(scenarios) == 0 {
+		scenarios = Scenarios(target.Role)
+	}
+
+	var listener net.Listener
+	if target.Role == ControllingStation {
+		l, err := net.Listen("tcp", target.Addr)
+		if err != nil {
+			return nil, err
+		}
+		defer l.Close()
+		listener = l
+	}
+
+	report := &Report{Role: target.Role, Addr: target.Addr, Start: time.Now()}
+	for _, s := range scenarios {
+		start := time.Now()
+		err := run(&target, listener, s)
+		r := Result{Name: s.Name, Clause: s.Clause, Pass: err == nil, Elapsed: time.Since(start)}
+		if err != nil {
+			r.Error = err.Error()
+			report.Failed++
+		} else {
+			report.Passed++
+		}
+		report.Results = append(report.Results, r)
+	}
+	return report, nil
+}
+
+func run(target *Target, listener net.Listener, s Scenario) error {
+	conn, err := connect(target, listener)
+	if err != nil {
+		return fmt.Errorf("conformance: connect, %w", err)
+	}
+	defer conn.Close()
+	return s.Run(&Peer{conn: conn, target: target})
+}
+
+func connect(target *Target, listener net.Listener) (net.Conn, error) {
+	if listener == nil {
+		return net.DialTimeout("tcp", target.Addr, target.Config.ConnectTimeout0)
+	}
+	type accepted struct {
+		conn net.Conn
+		err  error
+	}
+	ch := make(chan accepted, 1)
+	go func() {
+		conn, err := listener.Accept()
+		ch <- accepted{conn, err}
+	}()
+	select {
+	case a := <-ch:
+		return a.conn, a.err
+	case <-time.After(target.Config.ConnectTimeout0):
+		// unblock the pending accept, the listener is no longer usable
+		listener.Close()
+		return nil, fmt.Errorf("no connection within t₀ %v", target.Config.ConnectTimeout0)
+	}
+}
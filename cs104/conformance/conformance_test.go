@@ -0,0 +1,149 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+type serverHandler struct{}
+
+func (serverHandler) InterrogationHandler(asdu.Connect, *asdu.ASDU, asdu.QualifierOfInterrogation) error {
+	return nil
+}
+func (serverHandler) CounterInterrogationHandler(asdu.Connect, *asdu.ASDU, asdu.QualifierCountCall) error {
+	return nil
+}
+func (serverHandler) ReadHandler(asdu.Connect, *asdu.ASDU, asdu.InfoObjAddr) error { return nil }
+func (serverHandler) ClockSyncHandler(asdu.Connect, *asdu.ASDU, time.Time) error   { return nil }
+func (serverHandler) ResetProcessHandler(asdu.Connect, *asdu.ASDU, asdu.QualifierOfResetProcessCmd) error {
+	return nil
+}
+func (serverHandler) DelayAcquisitionHandler(asdu.Connect, *asdu.ASDU, uint16) error { return nil }
+func (serverHandler) ASDUHandler(asdu.Connect, *asdu.ASDU) error                     { return nil }
+
+type clientHandler struct{}
+
+func (clientHandler) InterrogationHandler(asdu.Connect, *asdu.ASDU) error        { return nil }
+func (clientHandler) CounterInterrogationHandler(asdu.Connect, *asdu.ASDU) error { return nil }
+func (clientHandler) ReadHandler(asdu.Connect, *asdu.ASDU) error                 { return nil }
+func (clientHandler) TestCommandHandler(asdu.Connect, *asdu.ASDU) error          { return nil }
+func (clientHandler) ClockSyncHandler(asdu.Connect, *asdu.ASDU) error            { return nil }
+func (clientHandler) ResetProcessHandler(asdu.Connect, *asdu.ASDU) error         { return nil }
+func (clientHandler) DelayAcquisitionHandler(asdu.Connect, *asdu.ASDU) error     { return nil }
+func (clientHandler) ASDUHandler(asdu.Connect, *asdu.ASDU) error                 { return nil }
+func (clientHandler) ASDUHandlerAll(asdu.Connect, *asdu.ASDU, *cs104.Server, int) error {
+	return nil
+}
+
+var testConfig = cs104.Config{
+	ConnectTimeout0:   5 * time.Second,
+	SendUnAckTimeout1: time.Second,
+	RecvUnAckTimeout2: time.Second,
+	IdleTimeout3:      time.Second,
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func checkReport(t *testing.T, r *Report, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range r.Results {
+		if !res.Pass {
+			t.Errorf("%s (%s): %s", res.Name, res.Clause, res.Error)
+		}
+	}
+	if r.Passed != len(r.Results) || r.Failed != 0 {
+		t.Errorf("report passed %d failed %d of %d", r.Passed, r.Failed, len(r.Results))
+	}
+
+	var buf bytes.Buffer
+	if err = r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err = json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["role"] != r.Role.String() {
+		t.Errorf("json role = %v, want %v", got["role"], r.Role)
+	}
+}
+
+func TestRun_controlledStation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timer scenarios")
+	}
+	addr := freeAddr(t)
+	srv := cs104.NewServer(serverHandler{}).SetConfig(testConfig)
+	go srv.ListenAndServer(addr)
+	time.Sleep(100 * time.Millisecond)
+
+	r, err := Run(Target{Role: ControlledStation, Addr: addr, Config: testConfig})
+	checkReport(t, r, err)
+	if len(r.Results) != len(Scenarios(ControlledStation)) {
+		t.Errorf("ran %d scenarios, want %d", len(r.Results), len(Scenarios(ControlledStation)))
+	}
+}
+
+func TestRun_controllingStation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timer scenarios")
+	}
+	addr := freeAddr(t)
+	opt := cs104.NewOption().SetConfig(testConfig).
+		SetAutoReconnect(true).SetReconnectInterval(100 * time.Millisecond)
+	if err := opt.AddRemoteServer(addr); err != nil {
+		t.Fatal(err)
+	}
+	client := cs104.NewClient(clientHandler{}, opt).
+		SetOnConnectHandler(func(c *cs104.Client) { c.SendStartDt() })
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	r, err := Run(Target{Role: ControllingStation, Addr: addr, Config: testConfig})
+	checkReport(t, r, err)
+}
+
+func TestRun_failure(t *testing.T) {
+	addr := freeAddr(t)
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close() // a device which hangs up at once
+		}
+	}()
+
+	r, err := Run(Target{Role: ControlledStation, Addr: addr, Config: testConfig, Tolerance: 100 * time.Millisecond},
+		Scenario{"startdt", "5.3", startDt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Failed != 1 || r.Results[0].Pass || r.Results[0].Error == "" {
+		t.Errorf("Run() = %+v, want the scenario failed", r.Results)
+	}
+}
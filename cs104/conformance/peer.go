@@ -0,0 +1,240 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const startFrame byte = 0x68 // start character
+
+// U frame control domain function
+const (
+	StartDtActive  byte = 0x04 // start activation
+	StartDtConfirm byte = 0x08 // start confirmation
+	StopDtActive   byte = 0x10 // stop activation
+	StopDtConfirm  byte = 0x20 // stop confirmation
+	TestFrActive   byte = 0x40 // test activation
+	TestFrConfirm  byte = 0x80 // test confirmation
+)
+
+// FrameKind is the format of an APCI control field.
+type FrameKind byte
+
+// defined frame formats
+const (
+	IFrame FrameKind = iota // numbered information transfer
+	SFrame                  // numbered supervisory function
+	UFrame                  // unnumbered control function
+)
+
+// Frame is a received APDU.
+type Frame struct {
+	Kind     FrameKind
+	Function byte   // U frame function
+	SendSN   uint16 // I frame N(S)
+	RcvSN    uint16 // I and S frame N(R)
+	ASDU     []byte // I frame asdu
+}
+
+func (sf Frame) String() string {
+	switch sf.Kind {
+	case IFrame:
+		return fmt.Sprintf("I[sendNO: %d, recvNO: %d]", sf.SendSN, sf.RcvSN)
+	case SFrame:
+		return fmt.Sprintf("S[recvNO: %d]", sf.RcvSN)
+	}
+	return fmt.Sprintf("U[function: 0x%02x]", sf.Function)
+}
+
+// ErrClosed the device under test closed the connection.
+var ErrClosed = errors.New("conformance: connection closed by peer")
+
+// Peer drives the device under test at the APCI level. Every I frame
+// received is acknowledged with an S frame immediately, so the device
+// under test never runs into its own t₁ unless a scenario wants it to.
+type Peer struct {
+	conn   net.Conn
+	target *Target
+	sendSN uint16 // N(S) of the next outbound I frame
+	rcvSN  uint16 // N(S) of the next inbound I frame
+}
+
+// Target returns the target under test.
+func (sf *Peer) Target() *Target { return sf.target }
+
+// SendU sends a U frame.
+func (sf *Peer) SendU(function byte) error {
+	return sf.write([]byte{startFrame, 4, function | 0x03, 0x00, 0x00, 0x00})
+}
+
+// SendS sends an S frame acknowledging every I frame received.
+func (sf *Peer) SendS() error {
+	return sf.write([]byte{startFrame, 4, 0x01, 0x00, byte(sf.rcvSN << 1), byte(sf.rcvSN >> 7)})
+}
+
+// SendI sends an I frame with the next send sequence number.
+func (sf *Peer) SendI(asdu []byte) error {
+	if err := sf.SendIWith(sf.sendSN, asdu); err != nil {
+		return err
+	}
+	sf.sendSN = (sf.sendSN + 1) & 32767
+	return nil
+}
+
+// SendIWith sends an I frame with the send sequence number given,
+// without advancing the sequence of the peer.
+func (sf *Peer) SendIWith(sendSN uint16, asdu []byte) error {
+	if len(asdu) > 249 {
+		return fmt.Errorf("conformance: asdu length %d out of range", len(asdu))
+	}
+	b := make([]byte, 0, len(asdu)+6)
+	b = append(b, startFrame, byte(len(asdu)+4),
+		byte(sendSN<<1), byte(sendSN>>7), byte(sf.rcvSN<<1), byte(sf.rcvSN>>7))
+	return sf.write(append(b, asdu...))
+}
+
+// Next returns the next frame received within timeout.
+func (sf *Peer) Next(timeout time.Duration) (Frame, error) {
+	if err := sf.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return Frame{}, err
+	}
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(sf.conn, head); err != nil {
+		return Frame{}, sf.readErr(err)
+	}
+	if head[0] != startFrame || head[1] < 4 || head[1] > 253 {
+		return Frame{}, fmt.Errorf("conformance: invalid apdu header % x", head)
+	}
+	apdu := make([]byte, head[1])
+	if _, err := io.ReadFull(sf.conn, apdu); err != nil {
+		return Frame{}, sf.readErr(err)
+	}
+
+	switch {
+	case apdu[0]&0x01 == 0:
+		f := Frame{
+			Kind:   IFrame,
+			SendSN: uint16(apdu[0])>>1 + uint16(apdu[1])<<7,
+			RcvSN:  uint16(apdu[2])>>1 + uint16(apdu[3])<<7,
+			ASDU:   apdu[4:],
+		}
+		if f.SendSN != sf.rcvSN {
+			return f, fmt.Errorf("conformance: received N(S) %d, expected %d", f.SendSN, sf.rcvSN)
+		}
+		sf.rcvSN = (sf.rcvSN + 1) & 32767
+		return f, sf.SendS()
+	case apdu[0]&0x03 == 0x01:
+		return Frame{Kind: SFrame, RcvSN: uint16(apdu[2])>>1 + uint16(apdu[3])<<7}, nil
+	}
+	return Frame{Kind: UFrame, Function: apdu[0] & 0xfc}, nil
+}
+
+// Expect waits for the U frame function within timeout, I and S frames
+// received meanwhile are skipped.
+func (sf *Peer) Expect(function byte, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := sf.Next(time.Until(deadline))
+		if err != nil {
+			return err
+		}
+		if f.Kind != UFrame {
+			continue
+		}
+		if f.Function != function {
+			return fmt.Errorf("conformance: received %v, expected U[function: 0x%02x]", f, function)
+		}
+		return nil
+	}
+}
+
+// ExpectAck waits within timeout for an I or S frame acknowledging every
+// I frame sent.
+func (sf *Peer) ExpectAck(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := sf.Next(time.Until(deadline))
+		if err != nil {
+			return err
+		}
+		if f.Kind != UFrame && f.RcvSN == sf.sendSN {
+			return nil
+		}
+	}
+}
+
+// ExpectClose waits for the device under test to close the connection
+// within timeout, frames received meanwhile are skipped.
+func (sf *Peer) ExpectClose(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := sf.Next(time.Until(deadline))
+		if errors.Is(err, ErrClosed) {
+			return nil
+		}
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return fmt.Errorf("conformance: connection still open after %v", timeout)
+		}
+	}
+}
+
+// ExpectSilence asserts that no U frame arrives for the duration d.
+func (sf *Peer) ExpectSilence(d time.Duration) error {
+	deadline := time.Now().Add(d)
+	for {
+		f, err := sf.Next(time.Until(deadline))
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				return nil
+			}
+			return err
+		}
+		if f.Kind == UFrame {
+			return fmt.Errorf("conformance: unexpected %v", f)
+		}
+	}
+}
+
+// Start brings the connection to the started state, on behalf of the role
+// the peer plays.
+func (sf *Peer) Start() error {
+	if sf.target.Role == ControlledStation {
+		if err := sf.SendU(StartDtActive); err != nil {
+			return err
+		}
+		return sf.Expect(StartDtConfirm, sf.target.t1())
+	}
+	if err := sf.Expect(StartDtActive, sf.target.t1()); err != nil {
+		return err
+	}
+	return sf.SendU(StartDtConfirm)
+}
+
+func (sf *Peer) write(b []byte) error {
+	if err := sf.conn.SetWriteDeadline(time.Now().Add(sf.target.t1())); err != nil {
+		return err
+	}
+	_, err := sf.conn.Write(b)
+	return err
+}
+
+func (sf *Peer) readErr(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return ErrClosed
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return err
+	}
+	// connection reset and alike
+	return fmt.Errorf("%w: %v", ErrClosed, err)
+}
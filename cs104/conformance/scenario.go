@@ -0,0 +1,141 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Scenarios returns the built-in scenarios for the role of the device under test.
+func Scenarios(role Role) []Scenario {
+	ss := []Scenario{
+		{"startdt", "5.3", startDt},
+		{"testfr", "5.2", testFr},
+		{"t3 idle test frame", "5.2", idleTestFrame},
+		{"t1 test frame timeout", "5.2", testFrameTimeout},
+		{"t2 acknowledge", "5.5", acknowledge},
+		{"sequence error", "5.1", sequenceError},
+	}
+	if role == ControlledStation {
+		ss = append(ss, Scenario{"stopdt", "5.3", stopDt})
+	}
+	return ss
+}
+
+// startDt the device under test confirms or issues STARTDT.
+func startDt(p *Peer) error {
+	return p.Start()
+}
+
+// stopDt the device under test confirms STOPDT and may be started again.
+func stopDt(p *Peer) error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	if err := p.SendU(StopDtActive); err != nil {
+		return err
+	}
+	if err := p.Expect(StopDtConfirm, p.target.t1()); err != nil {
+		return err
+	}
+	return p.Start()
+}
+
+// testFr the device under test confirms TESTFR.
+func testFr(p *Peer) error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	if err := p.SendU(TestFrActive); err != nil {
+		return err
+	}
+	return p.Expect(TestFrConfirm, p.target.t1())
+}
+
+// idleTestFrame the device under test issues TESTFR after t₃ idle.
+func idleTestFrame(p *Peer) error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	if err := p.Expect(TestFrActive, p.target.t3()); err != nil {
+		return err
+	}
+	return p.SendU(TestFrConfirm)
+}
+
+// testFrameTimeout the device under test closes the connection when TESTFR
+// is not confirmed within t₁.
+func testFrameTimeout(p *Peer) error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	if err := p.Expect(TestFrActive, p.target.t3()); err != nil {
+		return err
+	}
+	return p.ExpectClose(p.target.t1())
+}
+
+// acknowledge the device under test acknowledges an I frame within t₂.
+func acknowledge(p *Peer) error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	a, err := probe(p.target)
+	if err != nil {
+		return err
+	}
+	if err = p.SendI(a); err != nil {
+		return err
+	}
+	return p.ExpectAck(p.target.t2())
+}
+
+// sequenceError the device under test closes the connection on an I frame
+// out of sequence.
+func sequenceError(p *Peer) error {
+	if err := p.Start(); err != nil {
+		return err
+	}
+	a, err := probe(p.target)
+	if err != nil {
+		return err
+	}
+	if err = p.SendIWith(p.sendSN+5, a); err != nil {
+		return err
+	}
+	if err = p.ExpectClose(p.target.t1()); err != nil {
+		return fmt.Errorf("sequence error not detected, %w", err)
+	}
+	return nil
+}
+
+// probe returns the asdu sent in the scenarios, an interrogation command
+// to a controlled station, an end of initialization to a controlling station.
+func probe(target *Target) ([]byte, error) {
+	id := asdu.Identifier{
+		Type:       asdu.C_IC_NA_1,
+		Variable:   asdu.VariableStruct{Number: 1},
+		Coa:        asdu.CauseOfTransmission{Cause: asdu.Activation},
+		CommonAddr: target.CommonAddr,
+	}
+	value := byte(asdu.QOIStation)
+	if target.Role == ControllingStation {
+		id.Type = asdu.M_EI_NA_1
+		id.Coa.Cause = asdu.Initialized
+		value = 0 // local power on
+	}
+	a := asdu.NewASDU(target.Params, id)
+	if err := a.AppendInfoObjAddr(asdu.InfoObjAddrIrrelevant); err != nil {
+		return nil, err
+	}
+	a.AppendBytes(value)
+	b, err := a.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("conformance: probe, %w", err)
+	}
+	return b, nil
+}
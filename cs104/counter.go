@@ -0,0 +1,205 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// counter error defined
+var (
+	ErrCounterGroup   = errors.New("counter: group must be QCCUnused or QCCGroup1 to QCCGroup4")
+	ErrCounterUnknown = errors.New("counter: unknown information object address")
+)
+
+const (
+	counterSeqMax   = 32             // the sequence number of the binary counter reading is 5 bits
+	counterGroupMax = asdu.QCCGroup4 // the last counter group
+)
+
+type counterPoint struct {
+	group    asdu.QCCRequest
+	value    int32
+	carry    bool
+	adjusted bool
+	invalid  bool
+	frozen   asdu.BinaryCounterReading
+	frozenAt time.Time // the time of the freeze, the time tag of [M_IT_TB_1]
+}
+
+// CounterAccumulator the integrated totals of a controlled station. It counts the running values,
+// freezes them into the readings on the counter interrogation [C_CI_NA_1] and numbers the freezes of
+// each counter group, the readings carry the carry CY and adjusted CA flags of the period since the
+// previous freeze. See companion standard 101, subclass 7.2.6.9 and 7.4.8.
+type CounterAccumulator struct {
+	mux      sync.Mutex
+	counters map[asdu.InfoObjAddr]*counterPoint
+	seq      [counterGroupMax + 1]byte // the sequence number of each group, 0 for the counters without group
+	mode     CounterMode
+}
+
+// NewCounterAccumulator new a counter accumulator
+func NewCounterAccumulator() *CounterAccumulator {
+	return &CounterAccumulator{counters: make(map[asdu.InfoObjAddr]*counterPoint)}
+}
+
+// Add add a counter of the group, QCCUnused for the counter which is only requested by the general request
+func (sf *CounterAccumulator) Add(ioa asdu.InfoObjAddr, group asdu.QCCRequest) error {
+	if group > counterGroupMax {
+		return ErrCounterGroup
+	}
+	sf.mux.Lock()
+	sf.counters[ioa] = &counterPoint{group: group}
+	sf.mux.Unlock()
+	return nil
+}
+
+// Count add delta to the running value, the overflow wraps around and sets the carry of the next reading
+func (sf *CounterAccumulator) Count(ioa asdu.InfoObjAddr, delta int32) error {
+	return sf.update(ioa, func(p *counterPoint) {
+		v := int64(p.value) + int64(delta)
+		if v > math.MaxInt32 || v < math.MinInt32 {
+			p.carry = true
+		}
+		p.value = int32(v)
+	})
+}
+
+// Set set the running value, which sets the adjusted flag of the next reading
+func (sf *CounterAccumulator) Set(ioa asdu.InfoObjAddr, value int32) error {
+	return sf.update(ioa, func(p *counterPoint) {
+		p.value, p.adjusted = value, true
+	})
+}
+
+// SetInvalid set or clear the invalid flag of the readings
+func (sf *CounterAccumulator) SetInvalid(ioa asdu.InfoObjAddr, invalid bool) error {
+	return sf.update(ioa, func(p *counterPoint) {
+		p.invalid = invalid
+	})
+}
+
+func (sf *CounterAccumulator) update(ioa asdu.InfoObjAddr, f func(p *counterPoint)) error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	p, ok := sf.counters[ioa]
+	if !ok {
+		return ErrCounterUnknown
+	}
+	f(p)
+	return nil
+}
+
+// Freeze freeze the running values of the group, QCCTotal for all, into the readings with the next
+// sequence number of their group, the running values are reset to zero if reset. The readings are
+// time tagged with the clock of the connect c, which they are transmitted on. It returns the readings.
+func (sf *CounterAccumulator) Freeze(c asdu.Connect, group asdu.QCCRequest, reset bool) []asdu.BinaryCounterReadingInfo {
+	return sf.freeze(group, reset, c.Params().Now())
+}
+
+// freeze freeze the running values of the group at the time t, see Freeze
+func (sf *CounterAccumulator) freeze(group asdu.QCCRequest, reset bool, t time.Time) []asdu.BinaryCounterReadingInfo {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	for g := range sf.seq {
+		if inCounterGroup(asdu.QCCRequest(g), group) {
+			sf.seq[g] = (sf.seq[g] + 1) % counterSeqMax
+		}
+	}
+	return sf.readings(group, func(p *counterPoint) {
+		p.frozen = asdu.BinaryCounterReading{
+			CounterReading: p.value,
+			SeqNumber:      sf.seq[p.group],
+			HasCarry:       p.carry,
+			IsAdjusted:     p.adjusted,
+			IsInvalid:      p.invalid,
+		}
+		p.frozenAt = t
+		p.carry, p.adjusted = false, false
+		if reset {
+			p.value = 0
+		}
+	})
+}
+
+// Reset reset the running values of the group, QCCTotal for all, to zero without freezing them
+func (sf *CounterAccumulator) Reset(group asdu.QCCRequest) {
+	sf.mux.Lock()
+	sf.readings(group, func(p *counterPoint) { p.value = 0 })
+	sf.mux.Unlock()
+}
+
+// Readings returns the frozen readings of the group, QCCTotal for all, in the order of the address
+func (sf *CounterAccumulator) Readings(group asdu.QCCRequest) []asdu.BinaryCounterReadingInfo {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.readings(group, nil)
+}
+
+// readings apply f to the counters of the group then returns their readings
+func (sf *CounterAccumulator) readings(group asdu.QCCRequest, f func(p *counterPoint)) []asdu.BinaryCounterReadingInfo {
+	infos := make([]asdu.BinaryCounterReadingInfo, 0, len(sf.counters))
+	for ioa, p := range sf.counters {
+		if !inCounterGroup(p.group, group) {
+			continue
+		}
+		if f != nil {
+			f(p)
+		}
+		infos = append(infos, asdu.BinaryCounterReadingInfo{Ioa: ioa, Value: p.frozen, Time: p.frozenAt})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Ioa < infos[j].Ioa })
+	return infos
+}
+
+// inCounterGroup whether the counter group g is requested by the request
+func inCounterGroup(g, request asdu.QCCRequest) bool {
+	return request == asdu.QCCTotal || (g != asdu.QCCUnused && g == request)
+}
+
+// CounterInterrogationHandler answer the counter interrogation [C_CI_NA_1] with the activation confirmation,
+// then freezes, resets or sends the readings as the qualifier requests, then terminates the activation.
+// The readings are sent with the cause requested by general or group counter request.
+// In the CounterModeD the frozen readings are then transmitted spontaneously, see Transmit.
+func (sf *CounterAccumulator) CounterInterrogationHandler(c asdu.Connect, pack *asdu.ASDU, qcc asdu.QualifierCountCall) error {
+	if qcc.Request < asdu.QCCGroup1 || qcc.Request > asdu.QCCTotal {
+		return c.Send(pack.Mirror(asdu.ActivationCon, true))
+	}
+	if err := c.Send(pack.Mirror(asdu.ActivationCon, false)); err != nil {
+		return err
+	}
+	switch qcc.Freeze {
+	case asdu.QCCFrzRead:
+		cause := asdu.RequestByGeneralCounter
+		if qcc.Request != asdu.QCCTotal {
+			cause = asdu.RequestByGroup1Counter + asdu.Cause(qcc.Request-asdu.QCCGroup1)
+		}
+		if infos := sf.Readings(qcc.Request); len(infos) > 0 {
+			err := asdu.IntegratedTotalsBatch(c, asdu.M_IT_NA_1, asdu.CauseOfTransmission{Cause: cause}, pack.CommonAddr, infos...)
+			if err != nil {
+				return err
+			}
+		}
+	case asdu.QCCFrzFreezeNoReset:
+		sf.freeze(qcc.Request, false, c.Params().Now())
+	case asdu.QCCFrzFreezeReset:
+		sf.freeze(qcc.Request, true, c.Params().Now())
+	case asdu.QCCFrzReset:
+		sf.Reset(qcc.Request)
+	}
+	if err := c.Send(pack.Mirror(asdu.ActivationTerm, false)); err != nil {
+		return err
+	}
+	if sf.Mode() == CounterModeD && (qcc.Freeze == asdu.QCCFrzFreezeNoReset || qcc.Freeze == asdu.QCCFrzFreezeReset) {
+		return sf.Transmit(c, pack.CommonAddr, qcc.Request)
+	}
+	return nil
+}
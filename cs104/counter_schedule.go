@@ -0,0 +1,114 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// CounterMode the acquisition mode of the integrated totals, see companion standard 101, subclass 7.4.8
+type CounterMode int
+
+// counter mode defined
+const (
+	// CounterModeC freeze and read by the counter interrogation commands, the default
+	CounterModeC CounterMode = iota
+	// CounterModeA local freeze by the CounterScheduler, the readings are transmitted spontaneously
+	CounterModeA
+	// CounterModeB local freeze by the CounterScheduler, the readings are read by the counter interrogation
+	CounterModeB
+	// CounterModeD freeze by the counter interrogation command, the readings are transmitted spontaneously
+	CounterModeD
+)
+
+// SetMode set the acquisition mode of the integrated totals
+func (sf *CounterAccumulator) SetMode(mode CounterMode) *CounterAccumulator {
+	sf.mux.Lock()
+	sf.mode = mode
+	sf.mux.Unlock()
+	return sf
+}
+
+// Mode returns the acquisition mode of the integrated totals
+func (sf *CounterAccumulator) Mode() CounterMode {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.mode
+}
+
+// Transmit send the frozen readings of the group, QCCTotal for all, spontaneously with the time tag
+// of their freeze [M_IT_TB_1], nothing is sent if the group has no counter.
+func (sf *CounterAccumulator) Transmit(c asdu.Connect, ca asdu.CommonAddr, group asdu.QCCRequest) error {
+	infos := sf.Readings(group)
+	if len(infos) == 0 {
+		return nil
+	}
+	return asdu.IntegratedTotalsBatch(c, asdu.M_IT_TB_1, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, ca, infos...)
+}
+
+// CounterSchedule the local freeze of the integrated totals
+type CounterSchedule struct {
+	// Period the counters are frozen on the boundaries of the period since the local midnight,
+	// such as every 15 minutes on the quarter hour
+	Period time.Duration
+	// Group the counter group frozen, QCCTotal for all
+	Group asdu.QCCRequest
+	// Reset the counters are frozen with reset, the readings are the increments of the period
+	Reset bool
+	// OnFreeze called after each freeze with the readings, and the error of their transmission if any
+	OnFreeze func(infos []asdu.BinaryCounterReadingInfo, err error)
+}
+
+// CounterScheduler freeze the counters of an accumulator of the CounterModeA or CounterModeB on schedule,
+// the sequence numbers are incremented and the carry flags are of the period since the previous freeze.
+// In the CounterModeA the readings are then transmitted spontaneously on the connect, which may be the
+// Server to broadcast them.
+type CounterScheduler struct {
+	CounterSchedule
+	acc *CounterAccumulator
+	c   asdu.Connect
+	ca  asdu.CommonAddr
+}
+
+// NewCounterScheduler new the freeze scheduler of the counters of the common address
+func NewCounterScheduler(acc *CounterAccumulator, c asdu.Connect, ca asdu.CommonAddr, s CounterSchedule) *CounterScheduler {
+	return &CounterScheduler{CounterSchedule: s, acc: acc, c: c, ca: ca}
+}
+
+// Run freeze the counters on schedule until ctx is done. It returns ErrParam if the period is not positive.
+func (sf *CounterScheduler) Run(ctx context.Context) error {
+	if sf.Period <= 0 {
+		return asdu.ErrParam
+	}
+	clock := sf.c.Params().Clock
+	if clock == nil {
+		clock = asdu.SystemClock
+	}
+	now := clock.Now()
+	next := nextBoundary(now, sf.Period)
+	timer := clock.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C():
+		}
+		infos := sf.acc.freeze(sf.Group, sf.Reset, next)
+		var err error
+		if sf.acc.Mode() == CounterModeA {
+			err = sf.acc.Transmit(sf.c, sf.ca, sf.Group)
+		}
+		if sf.OnFreeze != nil {
+			sf.OnFreeze(infos, err)
+		}
+		now = clock.Now()
+		next = nextBoundary(now, sf.Period)
+		timer.Reset(next.Sub(now))
+	}
+}
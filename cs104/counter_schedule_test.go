@@ -0,0 +1,111 @@
+package cs104
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/asdu/asdutest"
+)
+
+// runCounterScheduler advance the clock by the period until a freeze is done
+func runCounterScheduler(t *testing.T, acc *CounterAccumulator, s CounterSchedule) (*asdutest.Conn, []asdu.BinaryCounterReadingInfo) {
+	t.Helper()
+	clock := asdutest.NewClock(time.Date(2024, 5, 1, 10, 7, 30, 0, time.Local))
+	p := *asdu.ParamsWide
+	p.Clock = clock
+	conn := asdutest.NewConn(&p)
+
+	frozen := make(chan []asdu.BinaryCounterReadingInfo, 1)
+	s.OnFreeze = func(infos []asdu.BinaryCounterReadingInfo, err error) {
+		if err != nil {
+			t.Errorf("OnFreeze() error = %v", err)
+		}
+		frozen <- infos
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- NewCounterScheduler(acc, conn, 1, s).Run(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Run() error = %v", err)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		clock.Advance(s.Period)
+		select {
+		case infos := <-frozen:
+			return conn, infos
+		case <-time.After(time.Millisecond):
+		}
+	}
+	t.Fatal("counters not frozen")
+	return nil, nil
+}
+
+func TestCounterScheduler_ModeA(t *testing.T) {
+	acc := NewCounterAccumulator().SetMode(CounterModeA)
+	_ = acc.Add(1, asdu.QCCGroup1)
+	_ = acc.Add(2, asdu.QCCGroup2)
+	_ = acc.Count(1, 100)
+
+	conn, infos := runCounterScheduler(t, acc, CounterSchedule{Period: 15 * time.Minute, Group: asdu.QCCGroup1, Reset: true})
+	if len(infos) != 1 || infos[0].Ioa != 1 || infos[0].Value.CounterReading != 100 || infos[0].Value.SeqNumber != 1 {
+		t.Fatalf("frozen readings %+v", infos)
+	}
+	if at := infos[0].Time; at.Minute()%15 != 0 || at.Second() != 0 {
+		t.Errorf("frozen at %v, want on a quarter hour", at)
+	}
+	sent := conn.Expect(t, asdu.Identifier{Type: asdu.M_IT_TB_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, CommonAddr: 1})
+	if got := sent[0].GetIntegratedTotals(); len(got) != 1 || !got[0].Time.Equal(infos[0].Time) {
+		t.Errorf("transmitted readings %+v, want time tag %v", got, infos[0].Time)
+	}
+	if got := acc.Freeze(conn, asdu.QCCGroup1, false); got[0].Value.CounterReading != 0 {
+		t.Errorf("running value %d after freeze with reset, want 0", got[0].Value.CounterReading)
+	}
+}
+
+func TestCounterScheduler_ModeB(t *testing.T) {
+	acc := NewCounterAccumulator().SetMode(CounterModeB)
+	_ = acc.Add(1, asdu.QCCUnused)
+	_ = acc.Count(1, 5)
+
+	conn, infos := runCounterScheduler(t, acc, CounterSchedule{Period: time.Hour, Group: asdu.QCCTotal})
+	if len(infos) != 1 || infos[0].Value.CounterReading != 5 {
+		t.Fatalf("frozen readings %+v", infos)
+	}
+	conn.ExpectNothing(t)
+
+	if err := NewCounterScheduler(acc, conn, 1, CounterSchedule{}).Run(context.Background()); err != asdu.ErrParam {
+		t.Errorf("Run() without period error = %v, want %v", err, asdu.ErrParam)
+	}
+}
+
+func TestCounterAccumulator_ModeD(t *testing.T) {
+	acc := NewCounterAccumulator().SetMode(CounterModeD)
+	_ = acc.Add(1, asdu.QCCGroup1)
+	_ = acc.Count(1, 7)
+	sess := newTestSession(&mockServerHandler{})
+	sess.conf = new(atomic.Pointer[Configuration])
+	sess.conf.Store(&Configuration{Counters: acc})
+	rc := &recordConn{}
+
+	qcc := asdu.QualifierCountCall{Request: asdu.QCCGroup1, Freeze: asdu.QCCFrzFreezeNoReset}
+	if err := asdu.CounterInterrogationCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, qcc); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.serverHandler(rc.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	sent := sess.sent(t)
+	if len(sent) != 3 || sent[1].Coa.Cause != asdu.ActivationTerm ||
+		sent[2].Type != asdu.M_IT_TB_1 || sent[2].Coa.Cause != asdu.Spontaneous {
+		t.Fatalf("freeze sent %v, want confirmation, termination then spontaneous readings", sent)
+	}
+	if infos := sent[2].GetIntegratedTotals(); len(infos) != 1 || infos[0].Value.CounterReading != 7 {
+		t.Errorf("readings %+v", infos)
+	}
+}
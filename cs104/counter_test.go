@@ -0,0 +1,100 @@
+package cs104
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/asdu/asdutest"
+)
+
+func TestCounterAccumulator(t *testing.T) {
+	acc := NewCounterAccumulator()
+	if err := acc.Add(1, asdu.QCCGroup1); err != nil {
+		t.Fatal(err)
+	}
+	_ = acc.Add(2, asdu.QCCGroup2)
+	_ = acc.Add(3, asdu.QCCUnused)
+	if err := acc.Add(4, asdu.QCCTotal); err != ErrCounterGroup {
+		t.Errorf("Add() error %v, want %v", err, ErrCounterGroup)
+	}
+	if err := acc.Count(9, 1); err != ErrCounterUnknown {
+		t.Errorf("Count() error %v, want %v", err, ErrCounterUnknown)
+	}
+
+	_ = acc.Count(1, 10)
+	_ = acc.Set(2, math.MaxInt32)
+	_ = acc.Count(2, 1) // carry
+	_ = acc.Count(3, 5)
+
+	tm := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	p := *asdu.ParamsWide
+	p.Clock = asdutest.NewClock(tm)
+	rc := asdutest.NewConn(&p)
+	got := acc.Freeze(rc, asdu.QCCGroup1, true)
+	if len(got) != 1 || got[0].Ioa != 1 || got[0].Value != (asdu.BinaryCounterReading{CounterReading: 10, SeqNumber: 1}) {
+		t.Fatalf("Freeze(group 1) = %+v", got)
+	}
+	if !got[0].Time.Equal(tm) {
+		t.Errorf("Freeze(group 1) time %v, want the clock of the connect %v", got[0].Time, tm)
+	}
+	got = acc.Freeze(rc, asdu.QCCTotal, false)
+	want := []asdu.BinaryCounterReading{
+		{CounterReading: 0, SeqNumber: 2},
+		{CounterReading: math.MinInt32, SeqNumber: 1, HasCarry: true, IsAdjusted: true},
+		{CounterReading: 5, SeqNumber: 1},
+	}
+	for i := range want {
+		if got[i].Value != want[i] {
+			t.Errorf("Freeze(total) reading %d = %+v, want %+v", i, got[i].Value, want[i])
+		}
+	}
+	// the flags are of the period since the previous freeze
+	if got = acc.Freeze(rc, asdu.QCCGroup2, false); got[0].Value.HasCarry || got[0].Value.IsAdjusted {
+		t.Errorf("Freeze(group 2) = %+v, want flags cleared", got)
+	}
+	for i := 0; i < counterSeqMax; i++ {
+		acc.Freeze(rc, asdu.QCCGroup1, false)
+	}
+	if got = acc.Readings(asdu.QCCGroup1); got[0].Value.SeqNumber != 2 {
+		t.Errorf("sequence number %d, want 2 wrapped", got[0].Value.SeqNumber)
+	}
+}
+
+func TestCounterAccumulator_CounterInterrogationHandler(t *testing.T) {
+	acc := NewCounterAccumulator()
+	for ioa := asdu.InfoObjAddr(1); ioa <= 3; ioa++ {
+		_ = acc.Add(ioa, asdu.QCCGroup1)
+		_ = acc.Count(ioa, int32(ioa)*100)
+	}
+	sess := newTestSession(&mockServerHandler{})
+	sess.conf = new(atomic.Pointer[Configuration])
+	sess.conf.Store(&Configuration{Counters: acc})
+	rc := &recordConn{}
+	interrogate := func(qcc asdu.QualifierCountCall) []*asdu.ASDU {
+		if err := asdu.CounterInterrogationCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, qcc); err != nil {
+			t.Fatal(err)
+		}
+		if err := sess.serverHandler(rc.take()[0]); err != nil {
+			t.Fatal(err)
+		}
+		return sess.sent(t)
+	}
+
+	if sent := interrogate(asdu.QualifierCountCall{Request: asdu.QCCGroup1, Freeze: asdu.QCCFrzFreezeReset}); len(sent) != 2 ||
+		sent[0].Coa.Cause != asdu.ActivationCon || sent[1].Coa.Cause != asdu.ActivationTerm {
+		t.Fatalf("freeze sent %v, want activation confirmation and termination", sent)
+	}
+	sent := interrogate(asdu.QualifierCountCall{Request: asdu.QCCGroup1, Freeze: asdu.QCCFrzRead})
+	if len(sent) != 3 || sent[1].Type != asdu.M_IT_NA_1 || sent[1].Coa.Cause != asdu.RequestByGroup1Counter {
+		t.Fatalf("read sent %v", sent)
+	}
+	if infos := sent[1].GetIntegratedTotals(); len(infos) != 3 || infos[2].Value.CounterReading != 300 || infos[2].Value.SeqNumber != 1 {
+		t.Errorf("readings %+v", infos)
+	}
+	if sent = interrogate(asdu.QualifierCountCall{Request: 6}); len(sent) != 1 || !sent[0].Coa.IsNegative {
+		t.Errorf("unknown request sent %v, want negative confirmation", sent)
+	}
+}
@@ -0,0 +1,522 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// DefaultCyclicPeriod default period of the cyclic transmission
+const DefaultCyclicPeriod = 10 * time.Second
+
+// deadband error defined
+var (
+	ErrDeadbandType    = errors.New("deadband: point type must be M_ME_NA_1, M_ME_NB_1 or M_ME_NC_1")
+	ErrDeadbandUnknown = errors.New("deadband: unknown information object address")
+)
+
+// DeadbandParam the reporting parameters of a measured value.
+// The values use the raw unit of the point type, that is the normalized or scaled
+// integer for M_ME_NA_1 and M_ME_NB_1, the short floating point value for M_ME_NC_1.
+// See companion standard 101, subclass 7.2.6.24.
+type DeadbandParam struct {
+	// Threshold the minimum change since the last report that triggers a spontaneous report,
+	// zero reports every change.
+	Threshold float64
+	// Smoothing smoothing factor (filter time constant), kept for the application.
+	Smoothing float64
+	// LowLimit low limit for transmission of measured values, used when HasLowLimit.
+	// crossing the limit in either direction triggers a spontaneous report.
+	LowLimit    float64
+	HasLowLimit bool
+	// HighLimit high limit for transmission of measured values, used when HasHighLimit.
+	// crossing the limit in either direction triggers a spontaneous report.
+	HighLimit    float64
+	HasHighLimit bool
+	// NotInOperation the parameters are loaded but not applied, every change is reported.
+	NotInOperation bool
+	// Cyclic the point takes part in the cyclic transmission.
+	Cyclic bool
+}
+
+// DeadbandStore persists the deadband parameters so that values loaded by the
+// controlling station survive a restart.
+type DeadbandStore interface {
+	Load() (map[asdu.InfoObjAddr]DeadbandParam, error)
+	Save(map[asdu.InfoObjAddr]DeadbandParam) error
+}
+
+type deadbandPoint struct {
+	typeID      asdu.TypeID
+	value       float64
+	qds         asdu.QualityDescriptor
+	reported    float64
+	reportedQds asdu.QualityDescriptor
+	hasReported bool
+	param       DeadbandParam
+}
+
+// Deadband the deadband reporting engine and cyclic scheduler of measured values.
+// Updated values are reported spontaneously when they exceed the configured threshold
+// or cross a limit, and points marked cyclic are sent periodically.
+// The parameters can be loaded remotely by P_ME_NA_1, P_ME_NB_1, P_ME_NC_1 and P_AC_NA_1,
+// the cyclic period too once its address is set by SetPeriodAddr.
+type Deadband struct {
+	conn   asdu.Connect
+	ca     asdu.CommonAddr
+	store  DeadbandStore
+	period time.Duration
+
+	mux       sync.Mutex
+	points    map[asdu.InfoObjAddr]*deadbandPoint
+	loaded    map[asdu.InfoObjAddr]DeadbandParam // parameters of the store
+	periodIOA asdu.InfoObjAddr                   // address of the cyclic period parameter, used when hasPeriod
+	hasPeriod bool
+	ticker    *time.Ticker // the ticker of the running cyclic transmission
+	cancel    context.CancelFunc
+}
+
+// NewDeadband new a deadband engine which reports through c with the common address ca
+func NewDeadband(c asdu.Connect, ca asdu.CommonAddr) *Deadband {
+	return &Deadband{
+		conn:   c,
+		ca:     ca,
+		period: DefaultCyclicPeriod,
+		points: make(map[asdu.InfoObjAddr]*deadbandPoint),
+	}
+}
+
+// SetStore set the parameter store, parameters of the store override the configured one.
+func (sf *Deadband) SetStore(s DeadbandStore) error {
+	params, err := s.Load()
+	if err != nil {
+		return err
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	sf.store = s
+	sf.loaded = params
+	for ioa, p := range params {
+		if pt, ok := sf.points[ioa]; ok {
+			pt.param = p
+		}
+	}
+	sf.loadPeriod()
+	return nil
+}
+
+// SetCyclicPeriod set the period of the cyclic transmission, a running transmission is rescheduled.
+func (sf *Deadband) SetCyclicPeriod(d time.Duration) *Deadband {
+	if d > 0 {
+		sf.mux.Lock()
+		sf.setPeriod(d)
+		sf.mux.Unlock()
+	}
+	return sf
+}
+
+// SetPeriodAddr set the information object address of the cyclic period. A parameter of measured value
+// [P_ME_NA_1], [P_ME_NB_1] or [P_ME_NC_1] of kind threshold sent to it sets the period in milliseconds,
+// it is persisted along the parameters of the points as the threshold of that address.
+func (sf *Deadband) SetPeriodAddr(ioa asdu.InfoObjAddr) *Deadband {
+	sf.mux.Lock()
+	sf.periodIOA, sf.hasPeriod = ioa, true
+	sf.loadPeriod()
+	sf.mux.Unlock()
+	return sf
+}
+
+// loadPeriod apply the period of the store, must hold the lock
+func (sf *Deadband) loadPeriod() {
+	if p, ok := sf.loaded[sf.periodIOA]; sf.hasPeriod && ok && p.Threshold > 0 {
+		sf.setPeriod(time.Duration(p.Threshold) * time.Millisecond)
+	}
+}
+
+// setPeriod set the period and reschedule the running transmission, must hold the lock
+func (sf *Deadband) setPeriod(d time.Duration) {
+	sf.period = d
+	if sf.ticker != nil {
+		sf.ticker.Reset(d)
+	}
+}
+
+// Add add a measured value point of type M_ME_NA_1, M_ME_NB_1 or M_ME_NC_1,
+// the persisted parameters of the store take precedence over p.
+func (sf *Deadband) Add(ioa asdu.InfoObjAddr, typeID asdu.TypeID, p DeadbandParam) error {
+	switch typeID {
+	case asdu.M_ME_NA_1, asdu.M_ME_NB_1, asdu.M_ME_NC_1:
+	default:
+		return ErrDeadbandType
+	}
+	sf.mux.Lock()
+	if lp, ok := sf.loaded[ioa]; ok {
+		p = lp
+	}
+	sf.points[ioa] = &deadbandPoint{typeID: typeID, param: p}
+	sf.mux.Unlock()
+	return nil
+}
+
+// Param get the parameters of the point
+func (sf *Deadband) Param(ioa asdu.InfoObjAddr) (DeadbandParam, bool) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	pt, ok := sf.points[ioa]
+	if !ok {
+		return DeadbandParam{}, false
+	}
+	return pt.param, true
+}
+
+// SetParam set the parameters of the point and persist them when a store is set
+func (sf *Deadband) SetParam(ioa asdu.InfoObjAddr, p DeadbandParam) error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	pt, ok := sf.points[ioa]
+	if !ok {
+		return ErrDeadbandUnknown
+	}
+	pt.param = p
+	return sf.save()
+}
+
+// Update update the value of the point, it is reported spontaneously when the
+// change exceeds the threshold, a limit is crossed or the quality changed.
+func (sf *Deadband) Update(ioa asdu.InfoObjAddr, value float64, qds asdu.QualityDescriptor) error {
+	sf.mux.Lock()
+	pt, ok := sf.points[ioa]
+	if !ok {
+		sf.mux.Unlock()
+		return ErrDeadbandUnknown
+	}
+	pt.value, pt.qds = value, qds
+	if !pt.exceeded() {
+		sf.mux.Unlock()
+		return nil
+	}
+	pt.reported, pt.reportedQds, pt.hasReported = value, qds, true
+	typeID := pt.typeID
+	sf.mux.Unlock()
+
+	return sf.send(asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, typeID, []deadbandValue{{ioa, value, qds}})
+}
+
+func (sf *deadbandPoint) exceeded() bool {
+	if !sf.hasReported || sf.param.NotInOperation || sf.qds != sf.reportedQds {
+		return true
+	}
+	if math.Abs(sf.value-sf.reported) > sf.param.Threshold {
+		return true
+	}
+	if sf.param.HasLowLimit && (sf.value < sf.param.LowLimit) != (sf.reported < sf.param.LowLimit) {
+		return true
+	}
+	if sf.param.HasHighLimit && (sf.value > sf.param.HighLimit) != (sf.reported > sf.param.HighLimit) {
+		return true
+	}
+	return false
+}
+
+// Start start the cyclic transmission in background
+func (sf *Deadband) Start() {
+	sf.mux.Lock()
+	if sf.cancel != nil {
+		sf.mux.Unlock()
+		return
+	}
+	var ctx context.Context
+	ctx, sf.cancel = context.WithCancel(context.Background())
+	ticker := time.NewTicker(sf.period)
+	sf.ticker = ticker
+	sf.mux.Unlock()
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = sf.SendCyclic()
+			}
+		}
+	}()
+}
+
+// Close stop the cyclic transmission
+func (sf *Deadband) Close() error {
+	sf.mux.Lock()
+	if sf.cancel != nil {
+		sf.cancel()
+		sf.cancel = nil
+		sf.ticker = nil
+	}
+	sf.mux.Unlock()
+	return nil
+}
+
+// SendCyclic send all points marked cyclic with cause of transmission periodic,
+// in order of type identification then information object address so that every scan is alike.
+func (sf *Deadband) SendCyclic() error {
+	type cyclicValue struct {
+		typeID asdu.TypeID
+		deadbandValue
+	}
+	var values []cyclicValue
+	sf.mux.Lock()
+	for ioa, pt := range sf.points {
+		if pt.param.Cyclic {
+			values = append(values, cyclicValue{pt.typeID, deadbandValue{ioa, pt.value, pt.qds}})
+		}
+	}
+	sf.mux.Unlock()
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].typeID != values[j].typeID {
+			return values[i].typeID < values[j].typeID
+		}
+		return values[i].ioa < values[j].ioa
+	})
+	for len(values) > 0 {
+		typeID := values[0].typeID
+		n := 1
+		for n < len(values) && values[n].typeID == typeID {
+			n++
+		}
+		vs := make([]deadbandValue, 0, n)
+		for _, v := range values[:n] {
+			vs = append(vs, v.deadbandValue)
+		}
+		values = values[n:]
+		if err := sf.send(asdu.CauseOfTransmission{Cause: asdu.Periodic}, typeID, vs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type deadbandValue struct {
+	ioa   asdu.InfoObjAddr
+	value float64
+	qds   asdu.QualityDescriptor
+}
+
+// send the values as many asdu as needed
+func (sf *Deadband) send(coa asdu.CauseOfTransmission, typeID asdu.TypeID, vs []deadbandValue) error {
+	p := sf.conn.Params()
+	objSize, err := asdu.GetInfoObjSize(typeID)
+	if err != nil {
+		return err
+	}
+	n := (p.MaxASDUSize() - p.IdentifierSize()) / (p.InfoObjAddrSize + objSize)
+	for len(vs) > 0 {
+		chunk := vs
+		if len(chunk) > n {
+			chunk = chunk[:n]
+		}
+		vs = vs[len(chunk):]
+
+		switch typeID {
+		case asdu.M_ME_NA_1:
+			infos := make([]asdu.MeasuredValueNormalInfo, 0, len(chunk))
+			for _, v := range chunk {
+				infos = append(infos, asdu.MeasuredValueNormalInfo{Ioa: v.ioa, Value: asdu.Normalize(v.value), Qds: v.qds})
+			}
+			err = asdu.MeasuredValueNormal(sf.conn, false, coa, sf.ca, infos...)
+		case asdu.M_ME_NB_1:
+			infos := make([]asdu.MeasuredValueScaledInfo, 0, len(chunk))
+			for _, v := range chunk {
+				infos = append(infos, asdu.MeasuredValueScaledInfo{Ioa: v.ioa, Value: int16(v.value), Qds: v.qds})
+			}
+			err = asdu.MeasuredValueScaled(sf.conn, false, coa, sf.ca, infos...)
+		case asdu.M_ME_NC_1:
+			infos := make([]asdu.MeasuredValueFloatInfo, 0, len(chunk))
+			for _, v := range chunk {
+				infos = append(infos, asdu.MeasuredValueFloatInfo{Ioa: v.ioa, Value: float32(v.value), Qds: v.qds})
+			}
+			err = asdu.MeasuredValueFloat(sf.conn, false, coa, sf.ca, infos...)
+		default:
+			err = ErrDeadbandType
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParameterHandler handle the parameter commands [P_ME_NA_1], [P_ME_NB_1], [P_ME_NC_1] and [P_AC_NA_1],
+// apply them to the addressed point and reply the activation confirmation.
+// A parameter of unknown address is negative confirmed with cause UnknownIOA.
+func (sf *Deadband) ParameterHandler(c asdu.Connect, pack *asdu.ASDU) error {
+	reply := pack.Clone()
+	if !(pack.Coa.Cause == asdu.Activation ||
+		(pack.Type == asdu.P_AC_NA_1 && pack.Coa.Cause == asdu.Deactivation)) {
+		return negativeMirror(c, reply, asdu.UnknownCOT)
+	}
+
+	var ioa asdu.InfoObjAddr
+	var value float64
+	var qpm asdu.QualifierOfParameterMV
+	switch pack.Type {
+	case asdu.P_ME_NA_1:
+		p := pack.GetParameterNormal()
+		ioa, value, qpm = p.Ioa, float64(p.Value), p.Qpm
+	case asdu.P_ME_NB_1:
+		p := pack.GetParameterScaled()
+		ioa, value, qpm = p.Ioa, float64(p.Value), p.Qpm
+	case asdu.P_ME_NC_1:
+		p := pack.GetParameterFloat()
+		ioa, value, qpm = p.Ioa, float64(p.Value), p.Qpm
+	case asdu.P_AC_NA_1:
+		return sf.activation(c, reply, pack.GetParameterActivation(), pack.Coa.Cause == asdu.Activation)
+	default:
+		return negativeMirror(c, reply, asdu.UnknownTypeID)
+	}
+
+	sf.mux.Lock()
+	if sf.hasPeriod && ioa == sf.periodIOA {
+		return sf.periodParameter(c, reply, value, qpm)
+	}
+	pt, ok := sf.points[ioa]
+	if !ok {
+		sf.mux.Unlock()
+		return negativeMirror(c, reply, asdu.UnknownIOA)
+	}
+	switch qpm.Category {
+	case asdu.QPMThreshold:
+		pt.param.Threshold = value
+	case asdu.QPMSmoothing:
+		pt.param.Smoothing = value
+	case asdu.QPMLowLimit:
+		pt.param.LowLimit, pt.param.HasLowLimit = value, true
+	case asdu.QPMHighLimit:
+		pt.param.HighLimit, pt.param.HasHighLimit = value, true
+	default:
+		sf.mux.Unlock()
+		return negativeMirror(c, reply, asdu.ActivationCon)
+	}
+	// POP bit set means parameter not in operation
+	pt.param.NotInOperation = qpm.IsInOperation
+	err := sf.save()
+	sf.mux.Unlock()
+	if err != nil {
+		return negativeMirror(c, reply, asdu.ActivationCon)
+	}
+	return reply.SendReplyMirror(c, asdu.ActivationCon)
+}
+
+// periodParameter apply the parameter of the cyclic period in milliseconds, must hold the lock which it releases
+func (sf *Deadband) periodParameter(c asdu.Connect, reply *asdu.ASDU, value float64, qpm asdu.QualifierOfParameterMV) error {
+	d := time.Duration(value) * time.Millisecond
+	if qpm.Category != asdu.QPMThreshold || d <= 0 {
+		sf.mux.Unlock()
+		return negativeMirror(c, reply, asdu.ActivationCon)
+	}
+	sf.setPeriod(d)
+	err := sf.save()
+	sf.mux.Unlock()
+	if err != nil {
+		return negativeMirror(c, reply, asdu.ActivationCon)
+	}
+	return reply.SendReplyMirror(c, asdu.ActivationCon)
+}
+
+func (sf *Deadband) activation(c asdu.Connect, reply *asdu.ASDU, p asdu.ParameterActivationInfo, act bool) error {
+	con := asdu.ActivationCon
+	if !act {
+		con = asdu.DeactivationCon
+	}
+
+	sf.mux.Lock()
+	switch p.Qpa {
+	case asdu.QPADeActPrevLoadedParameter:
+		for _, pt := range sf.points {
+			pt.param.NotInOperation = !act
+		}
+	case asdu.QPADeActObjectParameter, asdu.QPADeActObjectTransmission:
+		pt, ok := sf.points[p.Ioa]
+		if !ok {
+			sf.mux.Unlock()
+			return negativeMirror(c, reply, asdu.UnknownIOA)
+		}
+		if p.Qpa == asdu.QPADeActObjectParameter {
+			pt.param.NotInOperation = !act
+		} else {
+			pt.param.Cyclic = act
+		}
+	default:
+		sf.mux.Unlock()
+		return negativeMirror(c, reply, con)
+	}
+	err := sf.save()
+	sf.mux.Unlock()
+	if err != nil {
+		return negativeMirror(c, reply, con)
+	}
+	return reply.SendReplyMirror(c, con)
+}
+
+// save persist the parameters, must hold the lock
+func (sf *Deadband) save() error {
+	if sf.store == nil {
+		return nil
+	}
+	params := make(map[asdu.InfoObjAddr]DeadbandParam, len(sf.points))
+	for ioa, pt := range sf.points {
+		params[ioa] = pt.param
+	}
+	if sf.hasPeriod {
+		params[sf.periodIOA] = DeadbandParam{Threshold: float64(sf.period / time.Millisecond)}
+	}
+	return sf.store.Save(params)
+}
+
+// negativeMirror reply the mirror of the request with negative confirm
+func negativeMirror(c asdu.Connect, reply *asdu.ASDU, cause asdu.Cause) error {
+	return c.Send(reply.Mirror(cause, true))
+}
+
+// FileDeadbandStore a DeadbandStore persisted as json file
+type FileDeadbandStore struct {
+	Path string
+}
+
+var _ DeadbandStore = FileDeadbandStore{}
+
+// Load imp interface DeadbandStore, a not existing file loads nothing
+func (sf FileDeadbandStore) Load() (map[asdu.InfoObjAddr]DeadbandParam, error) {
+	b, err := os.ReadFile(sf.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	params := make(map[asdu.InfoObjAddr]DeadbandParam)
+	if err = json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// Save imp interface DeadbandStore
+func (sf FileDeadbandStore) Save(params map[asdu.InfoObjAddr]DeadbandParam) error {
+	b, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sf.Path, b, 0644)
+}
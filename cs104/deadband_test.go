@@ -0,0 +1,228 @@
+package cs104
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// recordConn record the sent asdu
+type recordConn struct {
+	mux  sync.Mutex
+	sent []*asdu.ASDU
+}
+
+func (sf *recordConn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *recordConn) UnderlyingConn() net.Conn { return nil }
+func (sf *recordConn) Send(a *asdu.ASDU) error {
+	if _, err := a.MarshalBinary(); err != nil {
+		return err
+	}
+	sf.mux.Lock()
+	sf.sent = append(sf.sent, a.Clone())
+	sf.mux.Unlock()
+	return nil
+}
+
+func (sf *recordConn) take() []*asdu.ASDU {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	s := sf.sent
+	sf.sent = nil
+	return s
+}
+
+func TestDeadband_Update(t *testing.T) {
+	c := &recordConn{}
+	d := NewDeadband(c, 1)
+	if err := d.Add(100, asdu.M_ME_NC_1, DeadbandParam{Threshold: 1, HasHighLimit: true, HighLimit: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(101, asdu.M_SP_NA_1, DeadbandParam{}); err != ErrDeadbandType {
+		t.Errorf("Add() error = %v, want %v", err, ErrDeadbandType)
+	}
+
+	tests := []struct {
+		name  string
+		value float64
+		qds   asdu.QualityDescriptor
+		want  bool
+	}{
+		{"first value", 10, asdu.QDSGood, true},
+		{"below threshold", 10.5, asdu.QDSGood, false},
+		{"above threshold", 11.5, asdu.QDSGood, true},
+		{"quality changed", 11.5, asdu.QDSInvalid, true},
+		{"below threshold again", 12, asdu.QDSInvalid, false},
+		{"cross high limit", 20.2, asdu.QDSInvalid, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.Update(100, tt.value, tt.qds); err != nil {
+				t.Fatal(err)
+			}
+			sent := c.take()
+			if got := len(sent) == 1; got != tt.want {
+				t.Fatalf("Update() reported = %v, want %v", got, tt.want)
+			}
+			if tt.want {
+				info := sent[0].GetMeasuredValueFloat()
+				if sent[0].Coa.Cause != asdu.Spontaneous || float64(info[0].Value) != float64(float32(tt.value)) {
+					t.Errorf("Update() sent %v %v", sent[0].Identifier, info)
+				}
+			}
+		})
+	}
+	if err := d.Update(200, 1, asdu.QDSGood); err != ErrDeadbandUnknown {
+		t.Errorf("Update() error = %v, want %v", err, ErrDeadbandUnknown)
+	}
+}
+
+func TestDeadband_ParameterHandler(t *testing.T) {
+	c := &recordConn{}
+	store := FileDeadbandStore{filepath.Join(t.TempDir(), "deadband.json")}
+	d := NewDeadband(c, 1)
+	if err := d.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(100, asdu.M_ME_NB_1, DeadbandParam{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// threshold loaded by P_ME_NB_1
+	if err := asdu.ParameterScaled(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1,
+		asdu.ParameterScaledInfo{Ioa: 100, Value: 50, Qpm: asdu.QualifierOfParameterMV{Category: asdu.QPMThreshold}}); err != nil {
+		t.Fatal(err)
+	}
+	// cyclic transmission activated by P_AC_NA_1
+	if err := asdu.ParameterActivation(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1,
+		asdu.ParameterActivationInfo{Ioa: 100, Qpa: asdu.QPADeActObjectTransmission}); err != nil {
+		t.Fatal(err)
+	}
+	// unknown information object address
+	if err := asdu.ParameterScaled(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1,
+		asdu.ParameterScaledInfo{Ioa: 200, Value: 50, Qpm: asdu.QualifierOfParameterMV{Category: asdu.QPMThreshold}}); err != nil {
+		t.Fatal(err)
+	}
+	want := []asdu.CauseOfTransmission{
+		{Cause: asdu.ActivationCon},
+		{Cause: asdu.ActivationCon},
+		{Cause: asdu.UnknownIOA, IsNegative: true},
+	}
+	for i, req := range c.take() {
+		if err := d.ParameterHandler(c, req); err != nil {
+			t.Fatal(err)
+		}
+		replies := c.take()
+		if len(replies) != 1 || replies[0].Coa != want[i] {
+			t.Fatalf("ParameterHandler() reply %v, want %v", replies, want[i])
+		}
+	}
+
+	wantParam := DeadbandParam{Threshold: 50, Cyclic: true}
+	if got, _ := d.Param(100); !reflect.DeepEqual(got, wantParam) {
+		t.Errorf("Param() = %+v, want %+v", got, wantParam)
+	}
+
+	// persisted parameters survive a restart
+	d = NewDeadband(c, 1)
+	if err := d.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(100, asdu.M_ME_NB_1, DeadbandParam{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.Param(100); !reflect.DeepEqual(got, wantParam) {
+		t.Errorf("Param() after restart = %+v, want %+v", got, wantParam)
+	}
+
+	if err := d.SendCyclic(); err != nil {
+		t.Fatal(err)
+	}
+	if sent := c.take(); len(sent) != 1 || sent[0].Type != asdu.M_ME_NB_1 || sent[0].Coa.Cause != asdu.Periodic {
+		t.Errorf("SendCyclic() sent %v", sent)
+	}
+}
+
+func TestDeadband_SendCyclic(t *testing.T) {
+	c := &recordConn{}
+	d := NewDeadband(c, 1)
+	for _, ioa := range []asdu.InfoObjAddr{5, 3, 9, 1, 7} {
+		typeID := asdu.M_ME_NC_1
+		if ioa > 5 {
+			typeID = asdu.M_ME_NB_1
+		}
+		if err := d.Add(ioa, typeID, DeadbandParam{Cyclic: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := d.SendCyclic(); err != nil {
+			t.Fatal(err)
+		}
+		sent := c.take()
+		if len(sent) != 2 || sent[0].Type != asdu.M_ME_NB_1 || sent[1].Type != asdu.M_ME_NC_1 {
+			t.Fatalf("SendCyclic() sent %v", sent)
+		}
+		var got []asdu.InfoObjAddr
+		for _, v := range sent[0].GetMeasuredValueScaled() {
+			got = append(got, v.Ioa)
+		}
+		for _, v := range sent[1].GetMeasuredValueFloat() {
+			got = append(got, v.Ioa)
+		}
+		if want := []asdu.InfoObjAddr{7, 9, 1, 3, 5}; !reflect.DeepEqual(got, want) {
+			t.Errorf("SendCyclic() addresses = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDeadband_PeriodParameter(t *testing.T) {
+	c := &recordConn{}
+	store := FileDeadbandStore{filepath.Join(t.TempDir(), "deadband.json")}
+	d := NewDeadband(c, 1).SetPeriodAddr(1000)
+	if err := d.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []asdu.ParameterScaledInfo{
+		{Ioa: 1000, Value: 2500, Qpm: asdu.QualifierOfParameterMV{Category: asdu.QPMThreshold}},
+		{Ioa: 1000, Value: -1, Qpm: asdu.QualifierOfParameterMV{Category: asdu.QPMThreshold}},
+		{Ioa: 1000, Value: 100, Qpm: asdu.QualifierOfParameterMV{Category: asdu.QPMLowLimit}},
+	} {
+		if err := asdu.ParameterScaled(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []asdu.CauseOfTransmission{
+		{Cause: asdu.ActivationCon},
+		{Cause: asdu.ActivationCon, IsNegative: true},
+		{Cause: asdu.ActivationCon, IsNegative: true},
+	}
+	for i, req := range c.take() {
+		if err := d.ParameterHandler(c, req); err != nil {
+			t.Fatal(err)
+		}
+		replies := c.take()
+		if len(replies) != 1 || replies[0].Coa != want[i] {
+			t.Fatalf("ParameterHandler() reply %v, want %v", replies, want[i])
+		}
+	}
+	if d.period != 2500*time.Millisecond {
+		t.Errorf("period = %v, want %v", d.period, 2500*time.Millisecond)
+	}
+
+	// persisted period survives a restart
+	d = NewDeadband(c, 1)
+	if err := d.SetStore(store); err != nil {
+		t.Fatal(err)
+	}
+	d.SetPeriodAddr(1000)
+	if d.period != 2500*time.Millisecond {
+		t.Errorf("period after restart = %v, want %v", d.period, 2500*time.Millisecond)
+	}
+}
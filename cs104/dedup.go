@@ -0,0 +1,170 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// dedupMaxEntries the events remembered at most, the oldest are forgotten first
+const dedupMaxEntries = 1 << 16
+
+// DedupStats the statistics of the duplicate suppression
+type DedupStats struct {
+	Checked    uint64 // number of time tagged events checked
+	Suppressed uint64 // number of duplicated events suppressed
+	Entries    int    // number of events remembered in the window
+}
+
+type dedupEntry struct {
+	key string
+	at  time.Time
+}
+
+// dedup suppresses the time tagged monitor events received again within the rolling window,
+// an event is keyed by its common address, type, information object address, value, quality
+// and time tag, so that only an exact re-delivery is suppressed. The events without time tag
+// are never suppressed, a repeated value of them is a valid report.
+type dedup struct {
+	mux    sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+	order  []dedupEntry // in the order seen, for the expiry
+	stats  DedupStats
+}
+
+// newDedup returns a dedup of the rolling window, nil if the window is not positive
+func newDedup(window time.Duration) *dedup {
+	if window <= 0 {
+		return nil
+	}
+	return &dedup{window: window, seen: make(map[string]time.Time)}
+}
+
+// filter returns the raw asdu without the information objects seen within the window,
+// nil if all of them are. A sequence of information objects is suppressed only as a whole.
+func (sf *dedup) filter(p *asdu.Params, raw []byte, now time.Time) []byte {
+	lenDUI := p.IdentifierSize()
+	if len(raw) <= lenDUI {
+		return raw
+	}
+	typeID := asdu.TypeID(raw[0])
+	if typeID >= asdu.C_SC_NA_1 || asdu.TimeTagSize(typeID) == 0 {
+		return raw
+	}
+	objSize, err := asdu.GetInfoObjSize(typeID)
+	if err != nil {
+		return raw
+	}
+	variable := asdu.ParseVariableStruct(raw[1])
+	n := int(variable.Number)
+	size := n * (p.InfoObjAddrSize + objSize)
+	if variable.IsSequence {
+		size = p.InfoObjAddrSize + n*objSize
+	}
+	if n == 0 || len(raw) < lenDUI+size {
+		return raw // rejected by the unmarshal
+	}
+
+	// the key prefix: type and common address
+	prefix := make([]byte, 0, 1+p.CommonAddrSize+p.InfoObjAddrSize+objSize)
+	prefix = append(append(prefix, raw[0]), raw[lenDUI-p.CommonAddrSize:lenDUI]...)
+
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	sf.expire(now)
+	sf.stats.Checked += uint64(n)
+
+	infoObj := raw[lenDUI : lenDUI+size]
+	if variable.IsSequence {
+		key := string(append(prefix, infoObj...))
+		if sf.seenOrAdd(key, now) {
+			sf.stats.Suppressed += uint64(n)
+			return nil
+		}
+		return raw
+	}
+
+	kept := make([]byte, 0, len(raw))
+	kept = append(kept, raw[:lenDUI]...)
+	var keep int
+	for i := 0; i < n; i++ {
+		obj := infoObj[i*(p.InfoObjAddrSize+objSize) : (i+1)*(p.InfoObjAddrSize+objSize)]
+		if sf.seenOrAdd(string(append(prefix, obj...)), now) {
+			sf.stats.Suppressed++
+			continue
+		}
+		kept = append(kept, obj...)
+		keep++
+	}
+	switch keep {
+	case 0:
+		return nil
+	case n:
+		return raw
+	}
+	variable.Number = byte(keep)
+	kept[1] = variable.Value()
+	return kept
+}
+
+// seenOrAdd returns true if the key is seen within the window, remembers it otherwise
+func (sf *dedup) seenOrAdd(key string, now time.Time) bool {
+	if _, ok := sf.seen[key]; ok {
+		return true
+	}
+	if len(sf.order) >= dedupMaxEntries {
+		sf.forget()
+	}
+	sf.seen[key] = now
+	sf.order = append(sf.order, dedupEntry{key, now})
+	return false
+}
+
+// expire forget the events seen before the window
+func (sf *dedup) expire(now time.Time) {
+	for len(sf.order) > 0 && now.Sub(sf.order[0].at) > sf.window {
+		sf.forget()
+	}
+}
+
+// forget the oldest event
+func (sf *dedup) forget() {
+	delete(sf.seen, sf.order[0].key)
+	sf.order[0] = dedupEntry{}
+	sf.order = sf.order[1:]
+	if len(sf.order) == 0 {
+		sf.order = nil
+	}
+}
+
+func (sf *dedup) get() DedupStats {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	s := sf.stats
+	s.Entries = len(sf.seen)
+	return s
+}
+
+// SetDuplicateSuppression suppress the time tagged monitor events received again within the rolling window,
+// like the events an outstation resends from its buffer after a reconnect which the client has handled already.
+// An event is a duplicate if its common address, type, information object address, value, quality and
+// time tag are all equal, the events without time tag are never suppressed. 0 disables (default).
+func (sf *ClientOption) SetDuplicateSuppression(window time.Duration) *ClientOption {
+	sf.dedupWindow = window
+	return sf
+}
+
+// DedupStats returns the statistics of the duplicate suppression, zero if disabled,
+// see ClientOption.SetDuplicateSuppression
+func (sf *Client) DedupStats() DedupStats {
+	if sf.dedup == nil {
+		return DedupStats{}
+	}
+	return sf.dedup.get()
+}
@@ -0,0 +1,75 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func dedupRaw(t *testing.T, send func(c asdu.Connect) error) []byte {
+	t.Helper()
+	c := &recordConn{}
+	if err := send(c); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := c.take()[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestDedup_filter(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := func(infos ...asdu.SinglePointInfo) []byte {
+		return dedupRaw(t, func(c asdu.Connect) error {
+			return asdu.SingleCP56Time2a(c, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, infos...)
+		})
+	}
+	p := asdu.ParamsWide
+	now := time.Now()
+	d := newDedup(time.Minute)
+
+	first := events(asdu.SinglePointInfo{Ioa: 100, Value: true, Time: tm}, asdu.SinglePointInfo{Ioa: 101, Time: tm})
+	if got := d.filter(p, first, now); string(got) != string(first) {
+		t.Fatalf("filter() first = % x, want unchanged", got)
+	}
+	if got := d.filter(p, first, now); got != nil {
+		t.Fatalf("filter() re-delivered = % x, want nil", got)
+	}
+
+	// one new event among the re-delivered, the value of the same time tag differs
+	mixed := events(asdu.SinglePointInfo{Ioa: 100, Value: true, Time: tm}, asdu.SinglePointInfo{Ioa: 101, Value: true, Time: tm})
+	got := d.filter(p, mixed, now)
+	a := asdu.NewEmptyASDU(p)
+	if err := a.UnmarshalBinary(got); err != nil {
+		t.Fatal(err)
+	}
+	if infos := a.GetSinglePoint(); len(infos) != 1 || infos[0].Ioa != 101 || !infos[0].Value {
+		t.Errorf("filter() mixed = %+v, want the event of ioa 101 only", infos)
+	}
+
+	// events without time tag are never suppressed
+	plain := dedupRaw(t, func(c asdu.Connect) error {
+		return asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.SinglePointInfo{Ioa: 100})
+	})
+	for i := 0; i < 2; i++ {
+		if got := d.filter(p, plain, now); got == nil {
+			t.Errorf("filter() without time tag suppressed")
+		}
+	}
+
+	want := DedupStats{Checked: 6, Suppressed: 3, Entries: 3}
+	if s := d.get(); s != want {
+		t.Errorf("get() = %+v, want %+v", s, want)
+	}
+
+	// out of the window the event is delivered again
+	if got := d.filter(p, first, now.Add(2*time.Minute)); got == nil {
+		t.Errorf("filter() out of the window suppressed")
+	}
+	if s := d.get(); s.Entries != 2 {
+		t.Errorf("get() entries = %d, want 2", s.Entries)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// the delay acquisition procedure, see companion standard 101, subclass 7.3.4.7 and 7.4.4:
+// the controlling station sends [C_CD_NA_1] act carrying its sending time SDT as milliseconds of the minute,
+// the controlled station confirms with SDT advanced by its own processing delay,
+// the controlling station takes half of the round trip as the transmission delay and sends it spontaneous,
+// the controlled station adds it to the time of the following clock synchronization [C_CS_NA_1].
+
+const msecPerMinute = 60000
+
+// delayMeter the delay acquisition state of a connection
+type delayMeter struct {
+	mux   sync.Mutex
+	wait  chan *asdu.ASDU // the pending activation waiting for its confirmation
+	delay time.Duration
+}
+
+// begin start waiting for the confirmation of an activation
+func (sf *delayMeter) begin() <-chan *asdu.ASDU {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	sf.wait = make(chan *asdu.ASDU, 1)
+	return sf.wait
+}
+
+// end stop waiting for the confirmation
+func (sf *delayMeter) end() {
+	sf.mux.Lock()
+	sf.wait = nil
+	sf.mux.Unlock()
+}
+
+// confirm take the activation confirmation of the pending activation, if any
+func (sf *delayMeter) confirm(a *asdu.ASDU) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.wait != nil {
+		sf.wait <- a.Clone()
+		sf.wait = nil
+	}
+}
+
+func (sf *delayMeter) set(d time.Duration) {
+	sf.mux.Lock()
+	sf.delay = d
+	sf.mux.Unlock()
+}
+
+func (sf *delayMeter) get() time.Duration {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.delay
+}
+
+// msecOfMinute returns the milliseconds of the minute of t, the SDT of [C_CD_NA_1]
+func msecOfMinute(t time.Time) uint16 {
+	return uint16(t.Second()*1000 + t.Nanosecond()/int(time.Millisecond))
+}
+
+// transmissionDelay returns the half of the round trip from the returned SDT to now
+func transmissionDelay(sdt uint16, now time.Time) time.Duration {
+	rtt := (int(msecOfMinute(now)) - int(sdt) + msecPerMinute) % msecPerMinute
+	return time.Duration(rtt) * time.Millisecond / 2
+}
+
+// AcquireDelay perform the delay acquisition with the controlled station of the common address,
+// it measures the transmission delay and sends it to the controlled station, which corrects the
+// following clock synchronization with it.
+func (sf *Client) AcquireDelay(ctx context.Context, ca asdu.CommonAddr) (time.Duration, error) {
+	wait := sf.delay.begin()
+	defer sf.delay.end()
+
+	if err := sf.DelayAcquireCommand(asdu.CauseOfTransmission{Cause: asdu.Activation}, ca, msecOfMinute(sf.Params().Now())); err != nil {
+		return 0, err
+	}
+	timer := time.NewTimer(sf.option.config.SendUnAckTimeout1)
+	defer timer.Stop()
+	var con *asdu.ASDU
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+		return 0, ErrConfirmTimeout
+	case con = <-wait:
+	}
+	if con.Coa.IsNegative {
+		return 0, ErrReject{con.Coa.Cause}
+	}
+	_, sdt := con.GetDelayAcquireCommand()
+	delay := transmissionDelay(sdt, sf.Params().Now())
+	sf.delay.set(delay)
+
+	err := sf.DelayAcquireCommand(asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, ca, uint16(delay/time.Millisecond))
+	return delay, err
+}
+
+// TransmissionDelay returns the transmission delay measured by the last delay acquisition
+func (sf *Client) TransmissionDelay() time.Duration {
+	return sf.delay.get()
+}
+
+// TransmissionDelay returns the transmission delay given by the controlling station, see Server.SetDelayAcquisition
+func (sf *SrvSession) TransmissionDelay() time.Duration {
+	return sf.delay.get()
+}
+
+// delayHandler the controlled station side of the delay acquisition
+func (sf *SrvSession) delayHandler(origin, asduPack *asdu.ASDU) error {
+	rcv := sf.Params().Now()
+	if !(asduPack.Coa.Cause == asdu.Activation || asduPack.Coa.Cause == asdu.Spontaneous) {
+		return sf.reject(origin, asdu.UnknownCOT)
+	}
+	if asduPack.CommonAddr == asdu.InvalidCommonAddr {
+		return sf.reject(origin, asdu.UnknownCA)
+	}
+	ioa, msec := asduPack.GetDelayAcquireCommand()
+	if ioa != asdu.InfoObjAddrIrrelevant {
+		return sf.reject(origin, asdu.UnknownIOA)
+	}
+	if asduPack.Coa.Cause == asdu.Spontaneous {
+		sf.delay.set(time.Duration(msec) * time.Millisecond)
+		return nil
+	}
+
+	reply := asdu.NewASDU(sf.params, asdu.Identifier{
+		Type:       asdu.C_CD_NA_1,
+		Variable:   asdu.VariableStruct{Number: 1},
+		Coa:        asdu.CauseOfTransmission{Cause: asdu.ActivationCon},
+		OrigAddr:   asduPack.OrigAddr,
+		CommonAddr: asduPack.CommonAddr,
+	})
+	if err := reply.AppendInfoObjAddr(asdu.InfoObjAddrIrrelevant); err != nil {
+		return err
+	}
+	reply.AppendCP16Time2a(uint16((int(msec) + int(sf.Params().Now().Sub(rcv)/time.Millisecond)) % msecPerMinute))
+	return sf.Send(reply)
+}
@@ -0,0 +1,93 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func Test_transmissionDelay(t *testing.T) {
+	tests := []struct {
+		name string
+		sdt  uint16
+		now  time.Time
+		want time.Duration
+	}{
+		{"same minute", 990, time.Date(2026, 1, 1, 0, 0, 1, int(30*time.Millisecond), time.UTC), 20 * time.Millisecond},
+		{"minute rollover", 59970, time.Date(2026, 1, 1, 0, 1, 0, int(10*time.Millisecond), time.UTC), 20 * time.Millisecond},
+		{"no delay", 1030, time.Date(2026, 1, 1, 0, 0, 1, int(30*time.Millisecond), time.UTC), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transmissionDelay(tt.sdt, tt.now); got != tt.want {
+				t.Errorf("transmissionDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type clockSyncHandler struct {
+	mockServerHandler
+	tm time.Time
+}
+
+func (sf *clockSyncHandler) ClockSyncHandler(_ asdu.Connect, _ *asdu.ASDU, tm time.Time) error {
+	sf.tm = tm
+	return nil
+}
+
+func TestSrvSession_delayAcquisition(t *testing.T) {
+	h := &clockSyncHandler{}
+	sess := newTestSession(h)
+	sess.delayAcq = true
+	rc := &recordConn{}
+
+	if err := asdu.DelayAcquireCommand(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, 59999); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.serverHandler(rc.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	sent := sess.sent(t)
+	if len(sent) != 1 || sent[0].Coa.Cause != asdu.ActivationCon {
+		t.Fatalf("sent %v, want activation confirmation", sent)
+	}
+	if _, sdt := sent[0].GetDelayAcquireCommand(); sdt != 59999 && sdt > 100 {
+		t.Errorf("SDT = %v, want 59999 advanced by the processing delay", sdt)
+	}
+
+	if err := asdu.DelayAcquireCommand(rc, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, 25); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.serverHandler(rc.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := sess.TransmissionDelay(); got != 25*time.Millisecond {
+		t.Errorf("TransmissionDelay() = %v, want %v", got, 25*time.Millisecond)
+	}
+
+	tm := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	if err := asdu.ClockSynchronizationCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, tm); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.serverHandler(rc.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if want := tm.Add(25 * time.Millisecond); !h.tm.Equal(want) {
+		t.Errorf("clock synchronization time = %v, want %v", h.tm, want)
+	}
+}
+
+func Test_delayMeter_confirm(t *testing.T) {
+	var d delayMeter
+	d.confirm(asdu.NewEmptyASDU(asdu.ParamsWide)) // none pending, dropped
+	wait := d.begin()
+	a := asdu.NewEmptyASDU(asdu.ParamsWide)
+	a.Type = asdu.C_CD_NA_1
+	d.confirm(a)
+	d.confirm(a) // only once
+	if got := <-wait; got.Type != asdu.C_CD_NA_1 {
+		t.Errorf("confirm() = %v, want %v", got.Type, asdu.C_CD_NA_1)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+var (
+	_ asdu.Station = (*Client)(nil)
+	_ asdu.Station = (*SrvSession)(nil)
+)
+
+// Dispatch decode the system command of the controlling station and pass it to the handler,
+// as the server sessions do without their policies, for the stations of any transport.
+// The command not valid is mirrored back negative, the other asdu passed to ASDUHandler.
+func Dispatch(c asdu.Connect, h ServerHandlerInterface, a *asdu.ASDU) error {
+	origin := a.Clone() // decoding consumes the information object, keep it for the mirror
+
+	check := func(causes ...asdu.Cause) (asdu.Cause, bool) {
+		ok := false
+		for _, cause := range causes {
+			ok = ok || a.Coa.Cause == cause
+		}
+		switch {
+		case !ok:
+			return asdu.UnknownCOT, false
+		case a.CommonAddr == asdu.InvalidCommonAddr:
+			return asdu.UnknownCA, false
+		}
+		return 0, true
+	}
+	reject := func(cause asdu.Cause) error { return negativeMirror(c, origin, cause) }
+
+	switch a.Type {
+	case asdu.C_IC_NA_1:
+		if cause, ok := check(asdu.Activation, asdu.Deactivation); !ok {
+			return reject(cause)
+		}
+		ioa, qoi := a.GetInterrogationCmd()
+		if ioa != asdu.InfoObjAddrIrrelevant {
+			return reject(asdu.UnknownIOA)
+		}
+		return h.InterrogationHandler(c, a, qoi)
+
+	case asdu.C_CI_NA_1:
+		if cause, ok := check(asdu.Activation); !ok {
+			return reject(cause)
+		}
+		ioa, qcc := a.GetCounterInterrogationCmd()
+		if ioa != asdu.InfoObjAddrIrrelevant {
+			return reject(asdu.UnknownIOA)
+		}
+		return h.CounterInterrogationHandler(c, a, qcc)
+
+	case asdu.C_RD_NA_1:
+		if cause, ok := check(asdu.Request); !ok {
+			return reject(cause)
+		}
+		return h.ReadHandler(c, a, a.GetReadCmd())
+
+	case asdu.C_CS_NA_1:
+		if cause, ok := check(asdu.Activation); !ok {
+			return reject(cause)
+		}
+		ioa, tm := a.GetClockSynchronizationCmd()
+		if ioa != asdu.InfoObjAddrIrrelevant {
+			return reject(asdu.UnknownIOA)
+		}
+		return h.ClockSyncHandler(c, a, tm)
+
+	case asdu.C_TS_NA_1:
+		if cause, ok := check(asdu.Activation); !ok {
+			return reject(cause)
+		}
+		if ioa, _ := a.GetTestCommand(); ioa != asdu.InfoObjAddrIrrelevant {
+			return reject(asdu.UnknownIOA)
+		}
+		return origin.SendReplyMirror(c, asdu.ActivationCon)
+
+	case asdu.C_RP_NA_1:
+		if cause, ok := check(asdu.Activation); !ok {
+			return reject(cause)
+		}
+		ioa, qrp := a.GetResetProcessCmd()
+		if ioa != asdu.InfoObjAddrIrrelevant {
+			return reject(asdu.UnknownIOA)
+		}
+		return h.ResetProcessHandler(c, a, qrp)
+
+	case asdu.C_CD_NA_1:
+		if cause, ok := check(asdu.Activation, asdu.Spontaneous); !ok {
+			return reject(cause)
+		}
+		ioa, msec := a.GetDelayAcquireCommand()
+		if ioa != asdu.InfoObjAddrIrrelevant {
+			return reject(asdu.UnknownIOA)
+		}
+		return h.DelayAcquisitionHandler(c, a, msec)
+	}
+
+	if err := h.ASDUHandler(c, a); err != nil {
+		return reject(asdu.UnknownTypeID)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestDispatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        asdu.Identifier
+		ioa       asdu.InfoObjAddr
+		wantCall  bool
+		wantCause asdu.Cause // of the mirror, 0 for none
+	}{
+		{"interrogation", asdu.Identifier{Type: asdu.C_IC_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1}, 0, true, 0},
+		{"interrogation cause", asdu.Identifier{Type: asdu.C_IC_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, CommonAddr: 1}, 0, false, asdu.UnknownCOT},
+		{"interrogation ioa", asdu.Identifier{Type: asdu.C_IC_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1}, 7, false, asdu.UnknownIOA},
+		{"unknown type", asdu.Identifier{Type: asdu.C_SC_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1}, 1, false, asdu.UnknownTypeID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, h := &recordConn{}, &mockServerHandler{}
+			tt.id.Variable.Number = 1
+			a := asdu.NewASDU(asdu.ParamsWide, tt.id)
+			if err := a.AppendInfoObjAddr(tt.ioa); err != nil {
+				t.Fatal(err)
+			}
+			a.AppendBytes(byte(asdu.QOIStation))
+			_ = Dispatch(c, h, a)
+			if got := len(h.cas) == 1; got != tt.wantCall {
+				t.Errorf("handler called = %v, want %v", got, tt.wantCall)
+			}
+			sent := c.take()
+			if tt.wantCause == 0 {
+				if len(sent) != 0 {
+					t.Errorf("sent %d asdu, want none", len(sent))
+				}
+				return
+			}
+			if len(sent) != 1 || sent[0].Coa.Cause != tt.wantCause || !sent[0].Coa.IsNegative {
+				t.Fatalf("sent = %v, want negative %v", sent, tt.wantCause)
+			}
+		})
+	}
+}
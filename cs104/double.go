@@ -0,0 +1,169 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type doubleKey struct {
+	ca  asdu.CommonAddr
+	ioa asdu.InfoObjAddr
+}
+
+// DoubleTransmitter a asdu.Connect which applies the double transmission, see companion standard 101,
+// subclass 7.2.1.1: the spontaneous change of a configured point without time tag is sent as it is,
+// then once more with time tag CP56Time2a, stamped with the time of sending.
+// Only the points configured by SetDoubleTransmission are sent twice, any other asdu is sent at once.
+type DoubleTransmitter struct {
+	asdu.Connect
+
+	mux    sync.RWMutex
+	points map[doubleKey]bool
+}
+
+// NewDoubleTransmitter new a double transmitter sending with c
+func NewDoubleTransmitter(c asdu.Connect) *DoubleTransmitter {
+	return &DoubleTransmitter{
+		Connect: c,
+		points:  make(map[doubleKey]bool),
+	}
+}
+
+// SetDoubleTransmission enable or disable the double transmission of the points of the common address
+func (sf *DoubleTransmitter) SetDoubleTransmission(ca asdu.CommonAddr, enable bool, ioas ...asdu.InfoObjAddr) *DoubleTransmitter {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	for _, ioa := range ioas {
+		if enable {
+			sf.points[doubleKey{ca, ioa}] = true
+		} else {
+			delete(sf.points, doubleKey{ca, ioa})
+		}
+	}
+	return sf
+}
+
+func (sf *DoubleTransmitter) enabled(ca asdu.CommonAddr, ioa asdu.InfoObjAddr) bool {
+	sf.mux.RLock()
+	defer sf.mux.RUnlock()
+	return sf.points[doubleKey{ca, ioa}]
+}
+
+// Send the asdu, followed by the time tagged one of the configured points if it is a spontaneous change
+func (sf *DoubleTransmitter) Send(a *asdu.ASDU) error {
+	if a.Coa.Cause != asdu.Spontaneous {
+		return sf.Connect.Send(a)
+	}
+	pack := a.Clone() // decoding consumes the information object
+	if err := sf.Connect.Send(a); err != nil {
+		return err
+	}
+
+	now := sf.Params().Now()
+	coa, ca := pack.Coa, pack.CommonAddr
+	switch pack.Type {
+	case asdu.M_SP_NA_1:
+		var infos []asdu.SinglePointInfo
+		for _, v := range pack.GetSinglePoint() {
+			if sf.enabled(ca, v.Ioa) {
+				v.Time = now
+				infos = append(infos, v)
+			}
+		}
+		return sf.chunk(asdu.M_SP_TB_1, len(infos), func(i, j int) error {
+			return asdu.SingleCP56Time2a(sf.Connect, coa, ca, infos[i:j]...)
+		})
+	case asdu.M_DP_NA_1:
+		var infos []asdu.DoublePointInfo
+		for _, v := range pack.GetDoublePoint() {
+			if sf.enabled(ca, v.Ioa) {
+				v.Time = now
+				infos = append(infos, v)
+			}
+		}
+		return sf.chunk(asdu.M_DP_TB_1, len(infos), func(i, j int) error {
+			return asdu.DoubleCP56Time2a(sf.Connect, coa, ca, infos[i:j]...)
+		})
+	case asdu.M_ST_NA_1:
+		var infos []asdu.StepPositionInfo
+		for _, v := range pack.GetStepPosition() {
+			if sf.enabled(ca, v.Ioa) {
+				v.Time = now
+				infos = append(infos, v)
+			}
+		}
+		return sf.chunk(asdu.M_ST_TB_1, len(infos), func(i, j int) error {
+			return asdu.StepCP56Time2a(sf.Connect, coa, ca, infos[i:j]...)
+		})
+	case asdu.M_BO_NA_1:
+		var infos []asdu.BitString32Info
+		for _, v := range pack.GetBitString32() {
+			if sf.enabled(ca, v.Ioa) {
+				v.Time = now
+				infos = append(infos, v)
+			}
+		}
+		return sf.chunk(asdu.M_BO_TB_1, len(infos), func(i, j int) error {
+			return asdu.BitString32CP56Time2a(sf.Connect, coa, ca, infos[i:j]...)
+		})
+	case asdu.M_ME_NA_1, asdu.M_ME_ND_1:
+		var infos []asdu.MeasuredValueNormalInfo
+		for _, v := range pack.GetMeasuredValueNormal() {
+			if sf.enabled(ca, v.Ioa) {
+				v.Time = now
+				infos = append(infos, v)
+			}
+		}
+		return sf.chunk(asdu.M_ME_TD_1, len(infos), func(i, j int) error {
+			return asdu.MeasuredValueNormalCP56Time2a(sf.Connect, coa, ca, infos[i:j]...)
+		})
+	case asdu.M_ME_NB_1:
+		var infos []asdu.MeasuredValueScaledInfo
+		for _, v := range pack.GetMeasuredValueScaled() {
+			if sf.enabled(ca, v.Ioa) {
+				v.Time = now
+				infos = append(infos, v)
+			}
+		}
+		return sf.chunk(asdu.M_ME_TE_1, len(infos), func(i, j int) error {
+			return asdu.MeasuredValueScaledCP56Time2a(sf.Connect, coa, ca, infos[i:j]...)
+		})
+	case asdu.M_ME_NC_1:
+		var infos []asdu.MeasuredValueFloatInfo
+		for _, v := range pack.GetMeasuredValueFloat() {
+			if sf.enabled(ca, v.Ioa) {
+				v.Time = now
+				infos = append(infos, v)
+			}
+		}
+		return sf.chunk(asdu.M_ME_TF_1, len(infos), func(i, j int) error {
+			return asdu.MeasuredValueFloatCP56Time2a(sf.Connect, coa, ca, infos[i:j]...)
+		})
+	}
+	return nil
+}
+
+// chunk send the count information objects of the type identification in as few asdu as possible
+func (sf *DoubleTransmitter) chunk(typeID asdu.TypeID, count int, send func(i, j int) error) error {
+	p := sf.Params()
+	objSize, err := asdu.GetInfoObjSize(typeID)
+	if err != nil {
+		return err
+	}
+	n := (p.MaxASDUSize() - p.IdentifierSize()) / (p.InfoObjAddrSize + objSize)
+	for i := 0; i < count; i += n {
+		j := i + n
+		if j > count {
+			j = count
+		}
+		if err = send(i, j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
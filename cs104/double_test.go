@@ -0,0 +1,53 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestDoubleTransmitter(t *testing.T) {
+	rc := &recordConn{}
+	d := NewDoubleTransmitter(rc).SetDoubleTransmission(1, true, 10, 12)
+	spont := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+
+	err := asdu.Single(d, false, spont, 1,
+		asdu.SinglePointInfo{Ioa: 10, Value: true}, asdu.SinglePointInfo{Ioa: 11}, asdu.SinglePointInfo{Ioa: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := rc.take()
+	if len(sent) != 2 || sent[0].Type != asdu.M_SP_NA_1 || sent[1].Type != asdu.M_SP_TB_1 {
+		t.Fatalf("sent %d asdu, want M_SP_NA_1 followed by M_SP_TB_1", len(sent))
+	}
+	infos := sent[1].GetSinglePoint()
+	if len(infos) != 2 || infos[0].Ioa != 10 || !infos[0].Value || infos[1].Ioa != 12 || infos[0].Time.IsZero() {
+		t.Errorf("time tagged %+v, want the points 10 and 12 with time tag", infos)
+	}
+
+	// not configured common address, not spontaneous
+	if err = asdu.MeasuredValueFloat(d, false, spont, 2, asdu.MeasuredValueFloatInfo{Ioa: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err = asdu.MeasuredValueFloat(d, false, asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}, 1,
+		asdu.MeasuredValueFloatInfo{Ioa: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if sent = rc.take(); len(sent) != 2 {
+		t.Errorf("sent %d asdu, want 2 sent once", len(sent))
+	}
+
+	// more points than a time tagged asdu can carry, 11 not configured
+	d.SetDoubleTransmission(1, true, 1, 2, 3, 4, 5, 6, 7, 8, 9, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25)
+	var many []asdu.MeasuredValueFloatInfo
+	for ioa := asdu.InfoObjAddr(1); ioa <= 25; ioa++ {
+		many = append(many, asdu.MeasuredValueFloatInfo{Ioa: ioa})
+	}
+	if err = asdu.MeasuredValueFloat(d, false, spont, 1, many...); err != nil {
+		t.Fatal(err)
+	}
+	sent = rc.take()
+	if len(sent) != 3 || sent[1].Variable.Number+sent[2].Variable.Number != 24 {
+		t.Errorf("sent %d asdu, want the 24 time tagged ones split in 2", len(sent))
+	}
+}
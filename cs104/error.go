@@ -5,12 +5,22 @@
 package cs104
 
 import (
-	"errors"
+	"github.com/rob-gra/go-iecp5/asdu"
 )
 
-// error defined
+// error defined, of the categories of asdu.Category
 var (
-	ErrUseClosedConnection = errors.New("use of closed connection")
-	ErrBufferFulled        = errors.New("buffer is full")
-	ErrNotActive           = errors.New("server is not active")
+	ErrUseClosedConnection = asdu.NewError(asdu.ErrTransport, "use of closed connection")
+	ErrBufferFulled        = asdu.NewError(asdu.ErrTransport, "buffer is full")
+	ErrNotActive           = asdu.NewError(asdu.ErrTransport, "server is not active")
+	ErrWindowFull          = asdu.NewError(asdu.ErrTransport, "send window is full")
+	ErrSeqNoAck            = asdu.NewError(asdu.ErrProtocol, "receive sequence number N(R) outside the send window")
+	ErrSeqNoSend           = asdu.NewError(asdu.ErrProtocol, "send sequence number N(S) out of order")
+	ErrListenOnly          = asdu.NewError(asdu.ErrConfig, "listen only client never transmits")
+	ErrReadOnly            = asdu.NewError(asdu.ErrConfig, "read-only enforcement refuses control direction asdu")
+	ErrCommandStale        = asdu.NewError(asdu.ErrPeer, "command time tag out of the freshness window")
+	ErrConfirmTimeout      = asdu.NewError(asdu.ErrPeer, "confirmation timeout")
+	ErrNegativeConfirm     = asdu.NewError(asdu.ErrPeer, "negative activation confirmation")
+	ErrAPDU                = asdu.NewError(asdu.ErrProtocol, "invalid apdu")
+	ErrRemoteServer        = asdu.NewError(asdu.ErrConfig, "empty remote server")
 )
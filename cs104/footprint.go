@@ -0,0 +1,76 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"sync"
+)
+
+// drainer handles the items of a channel one at a time in order, in a goroutine started on demand
+// which exits as soon as the channel is empty, so that an idle connection keeps no goroutine for it.
+// The producer kicks it after each item sent to the channel.
+type drainer[T any] struct {
+	mux     sync.Mutex
+	ctx     context.Context
+	ch      chan T
+	handle  func(T) bool // false stops the drainer until opened again
+	opened  bool
+	running bool
+	wg      sync.WaitGroup
+}
+
+// open start handling the items of ch by handle until ctx is done or closed
+func (sf *drainer[T]) open(ctx context.Context, ch chan T, handle func(T) bool) {
+	sf.mux.Lock()
+	sf.ctx, sf.ch, sf.handle, sf.opened = ctx, ch, handle, true
+	sf.mux.Unlock()
+	sf.kick()
+}
+
+// kick start the goroutine if the channel is not empty and none is running
+func (sf *drainer[T]) kick() {
+	sf.mux.Lock()
+	if sf.opened && !sf.running && len(sf.ch) > 0 {
+		sf.running = true
+		sf.wg.Add(1)
+		go sf.drain()
+	}
+	sf.mux.Unlock()
+}
+
+// close stop handling and wait for the item being handled, the items left stay in the channel
+func (sf *drainer[T]) close() {
+	sf.mux.Lock()
+	sf.opened = false
+	sf.mux.Unlock()
+	sf.wg.Wait()
+}
+
+func (sf *drainer[T]) drain() {
+	defer sf.wg.Done()
+	for {
+		if sf.ctx.Err() == nil {
+			select {
+			case v := <-sf.ch:
+				if sf.handle(v) {
+					continue
+				}
+				sf.mux.Lock()
+				sf.opened = false
+				sf.mux.Unlock()
+			default:
+			}
+		}
+		sf.mux.Lock()
+		if sf.opened && sf.ctx.Err() == nil && len(sf.ch) > 0 {
+			sf.mux.Unlock()
+			continue
+		}
+		sf.running = false
+		sf.mux.Unlock()
+		return
+	}
+}
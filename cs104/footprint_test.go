@@ -0,0 +1,133 @@
+package cs104
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDrainer(t *testing.T) {
+	var d drainer[int]
+	ch := make(chan int, 8)
+	got := make(chan int, 8)
+	ch <- 1
+	d.kick() // not opened
+	if len(ch) != 1 {
+		t.Fatal("drained before open")
+	}
+	d.open(context.Background(), ch, func(v int) bool { got <- v; return v != 3 })
+	for _, v := range []int{2, 3, 4} {
+		ch <- v
+		d.kick()
+	}
+	for want := 1; want <= 3; want++ {
+		select {
+		case v := <-got:
+			if v != want {
+				t.Fatalf("handled %d, want %d", v, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%d not handled", want)
+		}
+	}
+	d.close()
+	if len(ch) != 1 {
+		t.Errorf("%d items left, want 1 after the handler stopped", len(ch))
+	}
+	if d.running {
+		t.Error("goroutine left running")
+	}
+}
+
+// rss returns the resident set size of the process, 0 if unknown
+func rss() uint64 {
+	b, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	f := bytes.Fields(b)
+	if len(f) < 2 {
+		return 0
+	}
+	pages, _ := strconv.ParseUint(string(f[1]), 10, 64)
+	return pages * uint64(os.Getpagesize())
+}
+
+// BenchmarkFootprint reports the goroutines and memory of an idle link, both the client and the server session,
+// with the data transfer started.
+func BenchmarkFootprint(b *testing.B) {
+	const links = 200
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	srv := NewServer(&mockServerHandler{})
+	srv.LogMode(false)
+	go srv.ListenAndServer(addr)
+	defer srv.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		var before runtime.MemStats
+		runtime.ReadMemStats(&before)
+		goroutines, rss0 := runtime.NumGoroutine(), rss()
+
+		clients := make([]*Client, links)
+		for j := range clients {
+			o := NewOption()
+			if err = o.AddRemoteServer(addr); err != nil {
+				b.Fatal(err)
+			}
+			clients[j] = NewClient(NewTypedClientHandler(ClientHandlerBase{}), o)
+			clients[j].LogMode(false)
+			clients[j].SetOnConnectHandler(func(c *Client) {
+				c.SendStartDt()
+			})
+			if err = clients[j].Start(); err != nil {
+				b.Fatal(err)
+			}
+		}
+		for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			n := 0
+			for _, c := range clients {
+				if atomic.LoadUint32(&c.isActive) == active {
+					n++
+				}
+			}
+			if n == links {
+				break
+			}
+			if time.Now().After(deadline) {
+				b.Fatalf("%d links active, want %d", n, links)
+			}
+		}
+		time.Sleep(200 * time.Millisecond) // let the writers and handlers go idle
+
+		runtime.GC()
+		var after runtime.MemStats
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(runtime.NumGoroutine()-goroutines)/links, "goroutines/link")
+		b.ReportMetric(float64(after.HeapInuse+after.StackInuse-before.HeapInuse-before.StackInuse)/links, "bytes/link")
+		if r := rss(); r > rss0 {
+			b.ReportMetric(float64(r-rss0)/links, "rss/link")
+		}
+
+		for _, c := range clients {
+			_ = c.Close()
+		}
+		for _, c := range clients {
+			for c.connectStatus() != initial {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+}
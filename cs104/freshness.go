@@ -0,0 +1,40 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// SetCommandFreshness set the freshness window of the commands with time tag [C_SC_TA_1] to [C_BO_TA_1],
+// 0 disable the check, the default. A command whose time tag is flagged invalid or deviates from the local
+// time by more than the window is negative confirmed and never executed, which protects against the replay
+// of recorded commands as the IEC 60870-5-104 amendment requires of the commands with time tag.
+func (sf *Server) SetCommandFreshness(window time.Duration) *Server {
+	sf.freshness = window
+	return sf
+}
+
+// isTimeTaggedCommand whether the type is a process command with time tag CP56Time2a
+func isTimeTaggedCommand(id asdu.TypeID) bool {
+	return id >= asdu.C_SC_TA_1 && id <= asdu.C_BO_TA_1
+}
+
+// checkFreshness returns ErrCommandStale if a time tag of the command is flagged invalid
+// or deviates from now by more than the window
+func checkFreshness(a *asdu.ASDU, window time.Duration, now time.Time) error {
+	for _, tag := range a.TimeTags() {
+		if tag.Invalid {
+			return fmt.Errorf("%w: time tag invalid", ErrCommandStale)
+		}
+		if d := tag.TimeAt(a.InfoObjTimeZone, now).Sub(now); d > window || d < -window {
+			return fmt.Errorf("%w: time tag deviates %v", ErrCommandStale, d)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package cs104
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSrvSession_freshness(t *testing.T) {
+	h := &mockServerHandler{}
+	sess := newTestSession(h)
+	sess.freshness = 10 * time.Second
+	rc := &recordConn{}
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+
+	for _, tm := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(time.Minute)} {
+		_ = asdu.SingleCmd(rc, asdu.C_SC_TA_1, act, 1, asdu.SingleCommandInfo{Ioa: 1, Value: true, Time: tm})
+		if err := sess.serverHandler(rc.take()[0]); err != nil {
+			t.Fatal(err)
+		}
+		if sent := sess.sent(t); len(sent) != 1 || sent[0].Coa.Cause != asdu.ActivationCon || !sent[0].Coa.IsNegative {
+			t.Errorf("sent %d asdu, want the stale command negative confirmed", len(sent))
+		}
+	}
+
+	_ = asdu.SingleCmd(rc, asdu.C_SC_TA_1, act, 1, asdu.SingleCommandInfo{Ioa: 1, Value: true, Time: time.Now()})
+	a := rc.take()[0]
+	if err := checkFreshness(a, sess.freshness, time.Now()); err != nil {
+		t.Errorf("checkFreshness() error = %v, want fresh", err)
+	}
+	if err := checkFreshness(a, sess.freshness, time.Now().Add(time.Hour)); !errors.Is(err, ErrCommandStale) {
+		t.Errorf("checkFreshness() error = %v, want %v", err, ErrCommandStale)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// interrogation group error defined
+var (
+	ErrInterrogationGroup = errors.New("interrogation: group must be QOIUnused or QOIGroup1 to QOIGroup16")
+	ErrGroupAssigned      = errors.New("interrogation: point already belongs to another group")
+)
+
+// SetGroup assign the point of the common address to the interrogation group, QOIGroup1 to QOIGroup16,
+// or remove it from its group with QOIUnused. A point belongs to at most one group, it must be removed
+// from its group before it is assigned to another one.
+// The counter groups are assigned by CounterAccumulator.Add.
+func (sf *MemPointStore) SetGroup(ca asdu.CommonAddr, ioa asdu.InfoObjAddr, group asdu.QualifierOfInterrogation) error {
+	if group != asdu.QOIUnused && (group < asdu.QOIGroup1 || group > asdu.QOIGroup16) {
+		return ErrInterrogationGroup
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if group == asdu.QOIUnused {
+		delete(sf.groups[ca], ioa)
+		return nil
+	}
+	groups, ok := sf.groups[ca]
+	if !ok {
+		groups = make(map[asdu.InfoObjAddr]asdu.QualifierOfInterrogation)
+		sf.groups[ca] = groups
+	}
+	if g, ok := groups[ioa]; ok && g != group {
+		return ErrGroupAssigned
+	}
+	groups[ioa] = group
+	return nil
+}
+
+// Group returns the interrogation group of the point of the common address, QOIUnused if none
+func (sf *MemPointStore) Group(ca asdu.CommonAddr, ioa asdu.InfoObjAddr) asdu.QualifierOfInterrogation {
+	sf.mux.RLock()
+	defer sf.mux.RUnlock()
+	return sf.groups[ca][ioa]
+}
+
+// Interrogated returns the points of the common address interrogated by the qualifier in order of
+// their address, all of them by QOIStation, the points of the group by QOIGroup1 to QOIGroup16.
+func (sf *MemPointStore) Interrogated(ca asdu.CommonAddr, qoi asdu.QualifierOfInterrogation) []Point {
+	sf.mux.RLock()
+	defer sf.mux.RUnlock()
+	ioas := make([]asdu.InfoObjAddr, 0, len(sf.points[ca]))
+	for ioa := range sf.points[ca] {
+		if qoi == asdu.QOIStation || (qoi != asdu.QOIUnused && sf.groups[ca][ioa] == qoi) {
+			ioas = append(ioas, ioa)
+		}
+	}
+	sort.Slice(ioas, func(i, j int) bool { return ioas[i] < ioas[j] })
+	points := make([]Point, 0, len(ioas))
+	for _, ioa := range ioas {
+		points = append(points, sf.points[ca][ioa])
+	}
+	return points
+}
+
+// InterrogationHandler answer the interrogation [C_IC_NA_1] from the points with the activation confirmation,
+// the points interrogated by the qualifier, see Interrogated, then the activation termination.
+// The points with a time tag are sent with the type identification without time tag.
+// A ServerHandlerInterface may delegate its InterrogationHandler to it.
+func (sf *MemPointStore) InterrogationHandler(c asdu.Connect, pack *asdu.ASDU, qoi asdu.QualifierOfInterrogation) error {
+	if pack.Coa.Cause == asdu.Deactivation {
+		return c.Send(pack.Mirror(asdu.DeactivationCon, false))
+	}
+	if qoi < asdu.QOIStation || qoi > asdu.QOIGroup16 {
+		return c.Send(pack.Mirror(asdu.ActivationCon, true))
+	}
+	if err := c.Send(pack.Mirror(asdu.ActivationCon, false)); err != nil {
+		return err
+	}
+	coa := asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation + asdu.Cause(qoi-asdu.QOIStation)}
+	points := sf.Interrogated(pack.CommonAddr, qoi)
+	for i := 0; i < len(points); {
+		typeID := interrogatedType(points[i].Type)
+		j := i + 1
+		for j < len(points) && interrogatedType(points[j].Type) == typeID {
+			j++
+		}
+		if err := sendPoints(c, typeID, coa, pack.CommonAddr, points[i:j]); err != nil {
+			return err
+		}
+		i = j
+	}
+	return c.Send(pack.Mirror(asdu.ActivationTerm, false))
+}
+
+// interrogatedType returns the type identification without time tag of the monitor type identification
+func interrogatedType(t asdu.TypeID) asdu.TypeID {
+	switch t {
+	case asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		return asdu.M_SP_NA_1
+	case asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		return asdu.M_DP_NA_1
+	case asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		return asdu.M_ST_NA_1
+	case asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		return asdu.M_BO_NA_1
+	case asdu.M_ME_TA_1, asdu.M_ME_TD_1:
+		return asdu.M_ME_NA_1
+	case asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		return asdu.M_ME_NB_1
+	case asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		return asdu.M_ME_NC_1
+	}
+	return t
+}
+
+// sendPoints send the points, whose information all match the type identification, in as few asdu as needed
+func sendPoints(c asdu.Connect, typeID asdu.TypeID, coa asdu.CauseOfTransmission, ca asdu.CommonAddr, points []Point) error {
+	switch points[0].Info.(type) {
+	case asdu.SinglePointInfo:
+		return asdu.SingleBatch(c, typeID, coa, ca, pointInfos[asdu.SinglePointInfo](points)...)
+	case asdu.DoublePointInfo:
+		return asdu.DoubleBatch(c, typeID, coa, ca, pointInfos[asdu.DoublePointInfo](points)...)
+	case asdu.StepPositionInfo:
+		return asdu.StepBatch(c, typeID, coa, ca, pointInfos[asdu.StepPositionInfo](points)...)
+	case asdu.BitString32Info:
+		return asdu.BitString32Batch(c, typeID, coa, ca, pointInfos[asdu.BitString32Info](points)...)
+	case asdu.MeasuredValueNormalInfo:
+		return asdu.MeasuredValueNormalBatch(c, typeID, coa, ca, pointInfos[asdu.MeasuredValueNormalInfo](points)...)
+	case asdu.MeasuredValueScaledInfo:
+		return asdu.MeasuredValueScaledBatch(c, typeID, coa, ca, pointInfos[asdu.MeasuredValueScaledInfo](points)...)
+	case asdu.MeasuredValueFloatInfo:
+		return asdu.MeasuredValueFloatBatch(c, typeID, coa, ca, pointInfos[asdu.MeasuredValueFloatInfo](points)...)
+	}
+	return ErrPointType
+}
+
+// pointInfos returns the information of the points
+func pointInfos[T any](points []Point) []T {
+	infos := make([]T, len(points))
+	for i, p := range points {
+		infos[i] = p.Info.(T)
+	}
+	return infos
+}
@@ -0,0 +1,95 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/asdu/asdutest"
+)
+
+func TestMemPointStore_SetGroup(t *testing.T) {
+	store := NewMemPointStore()
+	if err := store.SetGroup(1, 1, asdu.QOIStation); err != ErrInterrogationGroup {
+		t.Errorf("SetGroup(station) error = %v, want %v", err, ErrInterrogationGroup)
+	}
+	if err := store.SetGroup(1, 1, asdu.QOIGroup1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetGroup(1, 1, asdu.QOIGroup1); err != nil {
+		t.Errorf("SetGroup(same group) error = %v", err)
+	}
+	if err := store.SetGroup(1, 1, asdu.QOIGroup2); err != ErrGroupAssigned {
+		t.Errorf("SetGroup(another group) error = %v, want %v", err, ErrGroupAssigned)
+	}
+	if err := store.SetGroup(1, 1, asdu.QOIUnused); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetGroup(1, 1, asdu.QOIGroup2); err != nil {
+		t.Errorf("SetGroup(after removal) error = %v", err)
+	}
+	if g := store.Group(1, 1); g != asdu.QOIGroup2 {
+		t.Errorf("Group() = %d, want %d", g, asdu.QOIGroup2)
+	}
+
+	_ = store.Set(1, Point{asdu.M_SP_NA_1, asdu.SinglePointInfo{Ioa: 1}})
+	if err := store.Readdress(1, asdu.IOAMap{1: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if g := store.Group(1, 10); g != asdu.QOIGroup2 {
+		t.Errorf("Group() after Readdress = %d, want %d", g, asdu.QOIGroup2)
+	}
+	store.Remove(1, 10)
+	if g := store.Group(1, 10); g != asdu.QOIUnused {
+		t.Errorf("Group() after Remove = %d, want none", g)
+	}
+}
+
+func TestMemPointStore_InterrogationHandler(t *testing.T) {
+	store := NewMemPointStore()
+	_ = store.Set(1, Point{asdu.M_SP_NA_1, asdu.SinglePointInfo{Ioa: 1}})
+	_ = store.Set(1, Point{asdu.M_SP_TB_1, asdu.SinglePointInfo{Ioa: 2, Value: true}})
+	_ = store.Set(1, Point{asdu.M_ME_NC_1, asdu.MeasuredValueFloatInfo{Ioa: 3, Value: 1.5}})
+	_ = store.Set(2, Point{asdu.M_SP_NA_1, asdu.SinglePointInfo{Ioa: 1}})
+	_ = store.SetGroup(1, 2, asdu.QOIGroup1)
+	_ = store.SetGroup(1, 3, asdu.QOIGroup2)
+
+	rc := &recordConn{}
+	conn := asdutest.NewConn(asdu.ParamsWide)
+	interrogate := func(qoi asdu.QualifierOfInterrogation) []*asdu.ASDU {
+		if err := asdu.InterrogationCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, qoi); err != nil {
+			t.Fatal(err)
+		}
+		pack := rc.take()[0]
+		_, qoi = pack.GetInterrogationCmd()
+		if err := store.InterrogationHandler(conn, pack, qoi); err != nil {
+			t.Fatal(err)
+		}
+		return conn.Take()
+	}
+
+	sent := interrogate(asdu.QOIStation)
+	if len(sent) != 4 || sent[0].Coa.Cause != asdu.ActivationCon || sent[3].Coa.Cause != asdu.ActivationTerm {
+		t.Fatalf("station sent %v", sent)
+	}
+	if sent[1].Type != asdu.M_SP_NA_1 || sent[1].Coa.Cause != asdu.InterrogatedByStation || len(sent[1].GetSinglePoint()) != 2 {
+		t.Errorf("station single points %v, want 2 without time tag", sent[1])
+	}
+	if sent[2].Type != asdu.M_ME_NC_1 {
+		t.Errorf("station measured value %v", sent[2])
+	}
+
+	sent = interrogate(asdu.QOIGroup1)
+	if len(sent) != 3 || sent[1].Coa.Cause != asdu.InterrogatedByGroup1 {
+		t.Fatalf("group 1 sent %v", sent)
+	}
+	if infos := sent[1].GetSinglePoint(); len(infos) != 1 || infos[0].Ioa != 2 || !infos[0].Value {
+		t.Errorf("group 1 points %+v", infos)
+	}
+
+	if sent = interrogate(asdu.QOIGroup3); len(sent) != 2 {
+		t.Errorf("empty group 3 sent %v, want confirmation and termination", sent)
+	}
+	if sent = interrogate(asdu.QOIUnused); len(sent) != 1 || !sent[0].Coa.IsNegative {
+		t.Errorf("unused qualifier sent %v, want negative confirmation", sent)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// DefaultHeartbeatPeriod default period of the heartbeat point
+const DefaultHeartbeatPeriod = 10 * time.Second
+
+// HeartbeatKind the kind of the heartbeat point
+type HeartbeatKind int
+
+// HeartbeatKind defined
+const (
+	// HeartbeatToggle a single point [M_SP_NA_1] toggled on every beat
+	HeartbeatToggle HeartbeatKind = iota
+	// HeartbeatCounter integrated totals [M_IT_NA_1] incremented on every beat
+	HeartbeatCounter
+)
+
+// Heartbeat an application level heartbeat point of a common address, emitted spontaneously
+// every period so that the master detects a frozen application layer even though
+// the link keeps alive with the test frames.
+type Heartbeat struct {
+	conn   asdu.Connect
+	ca     asdu.CommonAddr
+	ioa    asdu.InfoObjAddr
+	kind   HeartbeatKind
+	period time.Duration
+
+	mux     sync.Mutex
+	state   bool
+	counter asdu.BinaryCounterReading
+	cancel  context.CancelFunc
+}
+
+// NewHeartbeat new a heartbeat point of the information object address in the common address
+func NewHeartbeat(c asdu.Connect, ca asdu.CommonAddr, ioa asdu.InfoObjAddr, kind HeartbeatKind) *Heartbeat {
+	return &Heartbeat{
+		conn:   c,
+		ca:     ca,
+		ioa:    ioa,
+		kind:   kind,
+		period: DefaultHeartbeatPeriod,
+	}
+}
+
+// SetPeriod set the period of the heartbeat, effective on next Start
+func (sf *Heartbeat) SetPeriod(d time.Duration) *Heartbeat {
+	if d > 0 {
+		sf.mux.Lock()
+		sf.period = d
+		sf.mux.Unlock()
+	}
+	return sf
+}
+
+// Beat toggle or increment the heartbeat point and send it
+func (sf *Heartbeat) Beat() error {
+	coa := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	sf.mux.Lock()
+	if sf.kind == HeartbeatCounter {
+		if sf.counter.CounterReading == math.MaxInt32 {
+			sf.counter.CounterReading = 0
+			sf.counter.HasCarry = true
+		} else {
+			sf.counter.CounterReading++
+			sf.counter.HasCarry = false
+		}
+		sf.counter.SeqNumber = (sf.counter.SeqNumber + 1) & 0x1f
+		v := sf.counter
+		sf.mux.Unlock()
+		return asdu.IntegratedTotals(sf.conn, false, coa, sf.ca, asdu.BinaryCounterReadingInfo{Ioa: sf.ioa, Value: v})
+	}
+	sf.state = !sf.state
+	v := sf.state
+	sf.mux.Unlock()
+	return asdu.Single(sf.conn, false, coa, sf.ca, asdu.SinglePointInfo{Ioa: sf.ioa, Value: v})
+}
+
+// Start emit the heartbeat every period in background
+func (sf *Heartbeat) Start() {
+	sf.mux.Lock()
+	if sf.cancel != nil {
+		sf.mux.Unlock()
+		return
+	}
+	var ctx context.Context
+	ctx, sf.cancel = context.WithCancel(context.Background())
+	period := sf.period
+	sf.mux.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = sf.Beat()
+			}
+		}
+	}()
+}
+
+// Close stop the heartbeat
+func (sf *Heartbeat) Close() error {
+	sf.mux.Lock()
+	if sf.cancel != nil {
+		sf.cancel()
+		sf.cancel = nil
+	}
+	sf.mux.Unlock()
+	return nil
+}
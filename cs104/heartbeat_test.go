@@ -0,0 +1,35 @@
+package cs104
+
+import (
+	"testing"
+)
+
+func TestHeartbeat_Beat(t *testing.T) {
+	c := &recordConn{}
+	toggle := NewHeartbeat(c, 1, 10, HeartbeatToggle)
+	counter := NewHeartbeat(c, 2, 20, HeartbeatCounter)
+	for i := 0; i < 2; i++ {
+		if err := toggle.Beat(); err != nil {
+			t.Fatal(err)
+		}
+		if err := counter.Beat(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sent := c.take()
+	if len(sent) != 4 {
+		t.Fatalf("Beat() sent %v", sent)
+	}
+	for i, want := range []bool{true, false} {
+		a := sent[2*i]
+		if info := a.GetSinglePoint(); a.CommonAddr != 1 || info[0].Ioa != 10 || info[0].Value != want {
+			t.Errorf("toggle beat %d sent %v %v", i, a.Identifier, info)
+		}
+	}
+	for i, want := range []int32{1, 2} {
+		a := sent[2*i+1]
+		if info := a.GetIntegratedTotals(); a.CommonAddr != 2 || info[0].Ioa != 20 || info[0].Value.CounterReading != want {
+			t.Errorf("counter beat %d sent %v %v", i, a.Identifier, info)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// endOfInit the end of initialization [M_EI_NA_1] pending since the process start or reset,
+// it is sent by the first connection reaching STARTDT active, see Server.SetEndOfInitialization
+type endOfInit struct {
+	mux     sync.Mutex
+	cas     []asdu.CommonAddr
+	coi     asdu.CauseOfInitial
+	pending bool
+}
+
+// set mark the end of initialization pending with the cause
+func (sf *endOfInit) set(coi asdu.CauseOfInitial) {
+	sf.mux.Lock()
+	sf.coi, sf.pending = coi, true
+	sf.mux.Unlock()
+}
+
+// startDt send the pending end of initialization for every common address on the active session
+func (sf *endOfInit) startDt(s *SrvSession) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if !sf.pending || s.mirror {
+		return
+	}
+	cas := sf.cas
+	if len(cas) == 0 {
+		cas = s.commonAddrs
+	}
+	if len(cas) == 0 {
+		s.Warn("no common address to send the end of initialization")
+	}
+	for _, ca := range cas {
+		if err := asdu.EndOfInitialization(s, asdu.CauseOfTransmission{}, ca, asdu.InfoObjAddrIrrelevant, sf.coi); err != nil {
+			s.Error("send end of initialization of %d failed, %v", ca, err)
+			return
+		}
+	}
+	sf.pending = false
+}
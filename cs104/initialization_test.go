@@ -0,0 +1,42 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestServer_SetEndOfInitialization(t *testing.T) {
+	srv := NewServer(&mockServerHandler{}).SetCommonAddrs(1, 2).
+		SetEndOfInitialization(asdu.CauseOfInitial{Cause: asdu.COILocalPowerOn})
+	sess := newTestSession(srv.handler)
+	sess.commonAddrs = srv.commonAddrs
+
+	srv.endOfInit.startDt(sess)
+	sent := sess.sent(t)
+	if len(sent) != 2 {
+		t.Fatalf("sent %d asdu, want one end of initialization for each common address", len(sent))
+	}
+	for i, a := range sent {
+		_, coi := a.GetEndOfInitialization()
+		if a.Type != asdu.M_EI_NA_1 || a.Coa.Cause != asdu.Initialized ||
+			a.CommonAddr != srv.commonAddrs[i] || coi.Cause != asdu.COILocalPowerOn {
+			t.Errorf("sent %v %v, want end of initialization of %d", a.Identifier, coi, srv.commonAddrs[i])
+		}
+	}
+
+	srv.endOfInit.startDt(sess)
+	if sent = sess.sent(t); len(sent) != 0 {
+		t.Errorf("sent %d asdu on the next STARTDT, want none", len(sent))
+	}
+
+	srv.Reinitialize(asdu.CauseOfInitial{Cause: asdu.COILocalHandReset})
+	srv.endOfInit.startDt(sess)
+	sent = sess.sent(t)
+	if len(sent) != 2 {
+		t.Fatalf("sent %d asdu after reinitialize, want 2", len(sent))
+	}
+	if _, coi := sent[0].GetEndOfInitialization(); coi.Cause != asdu.COILocalHandReset {
+		t.Errorf("cause of initialization = %v, want %v", coi.Cause, asdu.COILocalHandReset)
+	}
+}
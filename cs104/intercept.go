@@ -0,0 +1,55 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ASDUHandlerFunc handles an asdu of the connection c
+type ASDUHandlerFunc func(c asdu.Connect, a *asdu.ASDU) error
+
+// Interceptor a middleware of the asdu sent or received by a connection, like the http middleware
+// it returns the handler wrapping next: it may observe or modify the asdu before passing it to next,
+// pass another asdu, drop it by not calling next, or fail with an error.
+// For example tagging the test cause of transmission, or rewriting the common address in a gateway.
+type Interceptor func(next ASDUHandlerFunc) ASDUHandlerFunc
+
+// intercept returns h wrapped by the interceptors, the first one the outermost
+func intercept(chain []Interceptor, h ASDUHandlerFunc) ASDUHandlerFunc {
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](h)
+	}
+	return h
+}
+
+// UseSend append the interceptors of the asdu the sessions send with Send, before they are
+// transmitted. An asdu broadcast by the server is intercepted on a copy for every session.
+// It must be called before serving.
+func (sf *Server) UseSend(chain ...Interceptor) *Server {
+	sf.sendChain = append(sf.sendChain, chain...)
+	return sf
+}
+
+// UseReceive append the interceptors of the asdu the sessions receive, before they are handled.
+// It must be called before serving.
+func (sf *Server) UseReceive(chain ...Interceptor) *Server {
+	sf.rcvChain = append(sf.rcvChain, chain...)
+	return sf
+}
+
+// UseSend append the interceptors of the asdu the client sends with Send, before they are
+// transmitted. It must be called before Start.
+func (sf *Client) UseSend(chain ...Interceptor) *Client {
+	sf.sendChain = append(sf.sendChain, chain...)
+	return sf
+}
+
+// UseReceive append the interceptors of the asdu the client receives, before they are handled.
+// It must be called before Start.
+func (sf *Client) UseReceive(chain ...Interceptor) *Client {
+	sf.rcvChain = append(sf.rcvChain, chain...)
+	return sf
+}
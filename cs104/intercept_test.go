@@ -0,0 +1,71 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// gateway maps the common address from to to
+func gateway(from, to asdu.CommonAddr) Interceptor {
+	return func(next ASDUHandlerFunc) ASDUHandlerFunc {
+		return func(c asdu.Connect, a *asdu.ASDU) error {
+			if a.CommonAddr == from {
+				a.CommonAddr = to
+			}
+			return next(c, a)
+		}
+	}
+}
+
+func TestSrvSession_intercept(t *testing.T) {
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(next ASDUHandlerFunc) ASDUHandlerFunc {
+			return func(c asdu.Connect, a *asdu.ASDU) error {
+				order = append(order, name)
+				return next(c, a)
+			}
+		}
+	}
+	h := &mockServerHandler{}
+	sess := newTestSession(h)
+	sess.rcvChain = []Interceptor{trace("first"), trace("second"), gateway(10, 1)}
+	sess.sendChain = []Interceptor{
+		gateway(1, 10),
+		func(next ASDUHandlerFunc) ASDUHandlerFunc { // tag test, drop the single points
+			return func(c asdu.Connect, a *asdu.ASDU) error {
+				if a.Type == asdu.M_SP_NA_1 {
+					return nil
+				}
+				a.Coa.IsTest = true
+				return next(c, a)
+			}
+		},
+	}
+
+	c := &recordConn{}
+	_ = asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 10, asdu.QOIStation)
+	raw, err := c.take()[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess.handle(raw)
+	if len(h.cas) != 1 || h.cas[0] != 1 {
+		t.Errorf("handled common address %v, want [1]", h.cas)
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("interceptors called in order %v", order)
+	}
+
+	spont := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	_ = asdu.Single(sess, false, spont, 1, asdu.SinglePointInfo{Ioa: 1, Value: true})
+	_ = asdu.MeasuredValueFloat(sess, false, spont, 1, asdu.MeasuredValueFloatInfo{Ioa: 2, Value: 1.5})
+	sent := sess.sent(t)
+	if len(sent) != 1 {
+		t.Fatalf("sent %d asdu, want the single point dropped", len(sent))
+	}
+	if sent[0].Type != asdu.M_ME_NC_1 || sent[0].CommonAddr != 10 || !sent[0].Coa.IsTest {
+		t.Errorf("sent %v", sent[0].Identifier)
+	}
+}
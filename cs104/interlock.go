@@ -0,0 +1,191 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrInterlocked a command refused by an interlock
+var ErrInterlocked = errors.New("command interlocked")
+
+// InterlockCommand the process command validated by the interlocks
+type InterlockCommand struct {
+	asdu.Identifier
+	Ioa asdu.InfoObjAddr
+	// Value the value commanded: bool [C_SC_NA_1], asdu.DoubleCommand [C_DC_NA_1], asdu.StepCommand [C_RC_NA_1],
+	// asdu.Normalize [C_SE_NA_1], int16 [C_SE_NB_1], float32 [C_SE_NC_1], uint32 [C_BO_NA_1] and their time tagged types
+	Value  interface{}
+	Select bool
+}
+
+// Float returns the value commanded as a float64, a normalized value in [-1, 1), a bool 0 or 1
+func (sf InterlockCommand) Float() float64 {
+	switch v := sf.Value.(type) {
+	case bool:
+		if v {
+			return 1
+		}
+	case asdu.DoubleCommand:
+		return float64(v)
+	case asdu.StepCommand:
+		return float64(v)
+	case asdu.Normalize:
+		return v.Float64()
+	case int16:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	}
+	return 0
+}
+
+// Interlock validates a process command before its execution, like the local/remote switch state,
+// the tag-out conditions or the value range. An error refuses the command, ErrReject with the cause
+// of the negative confirmation, any other error with the cause of the Interlocking.
+type Interlock interface {
+	Check(c asdu.Connect, cmd InterlockCommand) error
+}
+
+// InterlockFunc an adapter to allow the use of ordinary functions as Interlock
+type InterlockFunc func(c asdu.Connect, cmd InterlockCommand) error
+
+// Check calls f(c, cmd)
+func (f InterlockFunc) Check(c asdu.Connect, cmd InterlockCommand) error {
+	return f(c, cmd)
+}
+
+// ValueRange returns the interlock refusing the commands on the object whose value is out of [min, max],
+// see InterlockCommand.Float
+func ValueRange(ca asdu.CommonAddr, ioa asdu.InfoObjAddr, min, max float64) Interlock {
+	return InterlockFunc(func(_ asdu.Connect, cmd InterlockCommand) error {
+		if cmd.CommonAddr != ca || cmd.Ioa != ioa {
+			return nil
+		}
+		if v := cmd.Float(); v < min || v > max {
+			return fmt.Errorf("value %v out of [%v, %v]", v, min, max)
+		}
+		return nil
+	})
+}
+
+// Interlocking validates the activations of the process commands with a chain of interlocks, in the order
+// they are added, before passing them to the next handler. A refused command is negative confirmed with
+// the cause of the rejection, ActivationCon by default. The deactivations are never interlocked.
+type Interlocking struct {
+	ServerCommandHandler
+
+	mux    sync.RWMutex
+	checks []Interlock
+	cause  asdu.Cause
+}
+
+var _ ServerCommandHandler = (*Interlocking)(nil)
+
+// NewInterlocking new an interlocking passing the commands validated to next
+func NewInterlocking(next ServerCommandHandler) *Interlocking {
+	return &Interlocking{ServerCommandHandler: next, cause: asdu.ActivationCon}
+}
+
+// Use append the interlocks to the chain
+func (sf *Interlocking) Use(checks ...Interlock) *Interlocking {
+	sf.mux.Lock()
+	sf.checks = append(sf.checks, checks...)
+	sf.mux.Unlock()
+	return sf
+}
+
+// SetRejectCause set the cause of the negative confirmation of the commands refused by an error
+// other than ErrReject, for example asdu.UnknownIOA for the objects tagged out.
+func (sf *Interlocking) SetRejectCause(cause asdu.Cause) *Interlocking {
+	sf.mux.Lock()
+	sf.cause = cause
+	sf.mux.Unlock()
+	return sf
+}
+
+// check the command by the chain of interlocks
+func (sf *Interlocking) check(c asdu.Connect, cmd InterlockCommand) error {
+	if cmd.Coa.Cause != asdu.Activation {
+		return nil
+	}
+	sf.mux.RLock()
+	checks, cause := sf.checks, sf.cause
+	sf.mux.RUnlock()
+	for _, ck := range checks {
+		err := ck.Check(c, cmd)
+		if err == nil {
+			continue
+		}
+		var reject ErrReject
+		if errors.As(err, &reject) {
+			return fmt.Errorf("%w: %w", ErrInterlocked, err)
+		}
+		return fmt.Errorf("%w: %w: %w", ErrInterlocked, ErrReject{cause}, err)
+	}
+	return nil
+}
+
+// OnSingleCommand imp interface ServerCommandHandler
+func (sf *Interlocking) OnSingleCommand(c asdu.Connect, id asdu.Identifier, v asdu.SingleCommandInfo) error {
+	if err := sf.check(c, InterlockCommand{id, v.Ioa, v.Value, v.Qoc.InSelect}); err != nil {
+		return err
+	}
+	return sf.ServerCommandHandler.OnSingleCommand(c, id, v)
+}
+
+// OnDoubleCommand imp interface ServerCommandHandler
+func (sf *Interlocking) OnDoubleCommand(c asdu.Connect, id asdu.Identifier, v asdu.DoubleCommandInfo) error {
+	if err := sf.check(c, InterlockCommand{id, v.Ioa, v.Value, v.Qoc.InSelect}); err != nil {
+		return err
+	}
+	return sf.ServerCommandHandler.OnDoubleCommand(c, id, v)
+}
+
+// OnStepCommand imp interface ServerCommandHandler
+func (sf *Interlocking) OnStepCommand(c asdu.Connect, id asdu.Identifier, v asdu.StepCommandInfo) error {
+	if err := sf.check(c, InterlockCommand{id, v.Ioa, v.Value, v.Qoc.InSelect}); err != nil {
+		return err
+	}
+	return sf.ServerCommandHandler.OnStepCommand(c, id, v)
+}
+
+// OnSetpointNormal imp interface ServerCommandHandler
+func (sf *Interlocking) OnSetpointNormal(c asdu.Connect, id asdu.Identifier, v asdu.SetpointCommandNormalInfo) error {
+	if err := sf.check(c, InterlockCommand{id, v.Ioa, v.Value, v.Qos.InSelect}); err != nil {
+		return err
+	}
+	return sf.ServerCommandHandler.OnSetpointNormal(c, id, v)
+}
+
+// OnSetpointScaled imp interface ServerCommandHandler
+func (sf *Interlocking) OnSetpointScaled(c asdu.Connect, id asdu.Identifier, v asdu.SetpointCommandScaledInfo) error {
+	if err := sf.check(c, InterlockCommand{id, v.Ioa, v.Value, v.Qos.InSelect}); err != nil {
+		return err
+	}
+	return sf.ServerCommandHandler.OnSetpointScaled(c, id, v)
+}
+
+// OnSetpointFloat imp interface ServerCommandHandler
+func (sf *Interlocking) OnSetpointFloat(c asdu.Connect, id asdu.Identifier, v asdu.SetpointCommandFloatInfo) error {
+	if err := sf.check(c, InterlockCommand{id, v.Ioa, v.Value, v.Qos.InSelect}); err != nil {
+		return err
+	}
+	return sf.ServerCommandHandler.OnSetpointFloat(c, id, v)
+}
+
+// OnBitString32Command imp interface ServerCommandHandler
+func (sf *Interlocking) OnBitString32Command(c asdu.Connect, id asdu.Identifier, v asdu.BitsString32CommandInfo) error {
+	if err := sf.check(c, InterlockCommand{id, v.Ioa, v.Value, false}); err != nil {
+		return err
+	}
+	return sf.ServerCommandHandler.OnBitString32Command(c, id, v)
+}
@@ -0,0 +1,60 @@
+package cs104
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestInterlocking(t *testing.T) {
+	h := &mockCommandHandler{}
+	remote := true
+	il := NewInterlocking(h).SetRejectCause(asdu.UnknownIOA).Use(
+		InterlockFunc(func(_ asdu.Connect, cmd InterlockCommand) error {
+			if !remote {
+				return ErrReject{asdu.ActivationCon}
+			}
+			return nil
+		}),
+		ValueRange(1, 3, 0, 10),
+	)
+	cmd := commandConfirmHandler{il}
+
+	c := &recordConn{}
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	_ = asdu.SetpointCmdFloat(c, asdu.C_SE_NC_1, act, 1, asdu.SetpointCommandFloatInfo{Ioa: 3, Value: 5})
+	_ = asdu.SetpointCmdFloat(c, asdu.C_SE_NC_1, act, 1, asdu.SetpointCommandFloatInfo{Ioa: 3, Value: 11})
+	_ = asdu.SingleCmd(c, asdu.C_SC_NA_1, act, 1, asdu.SingleCommandInfo{Ioa: 1, Value: true})
+	reqs := c.take()
+
+	tests := []struct {
+		name   string
+		req    *asdu.ASDU
+		remote bool
+		want   asdu.CauseOfTransmission
+	}{
+		{"in range", reqs[0], true, asdu.CauseOfTransmission{Cause: asdu.ActivationCon}},
+		{"out of range", reqs[1], true, asdu.CauseOfTransmission{Cause: asdu.UnknownIOA, IsNegative: true}},
+		{"local", reqs[2], false, asdu.CauseOfTransmission{Cause: asdu.ActivationCon, IsNegative: true}},
+		{"remote", reqs[2].Clone(), true, asdu.CauseOfTransmission{Cause: asdu.ActivationCon}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote = tt.remote
+			if err := confirmDispatch(c, cmd, tt.req.Clone()); err != nil {
+				t.Fatal(err)
+			}
+			if sent := c.take(); len(sent) == 0 || sent[0].Coa != tt.want {
+				t.Errorf("confirmDispatch() sent %v, want %v", sent, tt.want)
+			}
+		})
+	}
+	err := il.OnSetpointFloat(c, reqs[1].Identifier, asdu.SetpointCommandFloatInfo{Ioa: 3, Value: 11})
+	if !errors.Is(err, ErrInterlocked) {
+		t.Errorf("OnSetpointFloat() error = %v, want %v", err, ErrInterlocked)
+	}
+	if h.setpoint != 5 {
+		t.Errorf("setpoint executed %v, want 5", h.setpoint)
+	}
+}
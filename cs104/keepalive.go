@@ -0,0 +1,66 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+	"time"
+)
+
+// Keepalive the test frame keepalive strategy in addition to the t₃ idle timer
+type Keepalive struct {
+	// Interval send the test frame TESTFR act every interval, even if the connection is not idle,
+	// the round trip time is measured continuously then. zero disables.
+	Interval time.Duration
+	// MaxRTT the round trip time threshold of TESTFR act to TESTFR con, zero disables.
+	MaxRTT time.Duration
+	// MaxSlow close the connection after MaxSlow consecutive round trips exceed MaxRTT, at least 1.
+	MaxSlow int
+}
+
+// RTTStats the round trip time statistics of the test frames
+type RTTStats struct {
+	Count uint64 // number of round trips measured
+	Last  time.Duration
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	Slow  int // consecutive round trips exceeding Keepalive.MaxRTT
+}
+
+// rttMeter measure the round trip time of the test frames
+type rttMeter struct {
+	mux   sync.Mutex
+	stats RTTStats
+}
+
+// record the round trip time, returns true if the connection should be closed by the keepalive strategy
+func (sf *rttMeter) record(rtt time.Duration, ka Keepalive) bool {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	s := &sf.stats
+	s.Count++
+	s.Last = rtt
+	if s.Count == 1 || rtt < s.Min {
+		s.Min = rtt
+	}
+	if rtt > s.Max {
+		s.Max = rtt
+	}
+	s.Mean += (rtt - s.Mean) / time.Duration(s.Count)
+
+	if ka.MaxRTT <= 0 || rtt <= ka.MaxRTT {
+		s.Slow = 0
+		return false
+	}
+	s.Slow++
+	return s.Slow >= ka.MaxSlow
+}
+
+func (sf *rttMeter) get() RTTStats {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.stats
+}
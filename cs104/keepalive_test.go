@@ -0,0 +1,32 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRTTMeter(t *testing.T) {
+	var m rttMeter
+	ka := Keepalive{MaxRTT: 100 * time.Millisecond, MaxSlow: 2}
+
+	if m.record(10*time.Millisecond, ka) || m.record(200*time.Millisecond, ka) {
+		t.Fatal("record() closes before MaxSlow")
+	}
+	if m.record(30*time.Millisecond, ka) || m.record(200*time.Millisecond, ka) {
+		t.Fatal("record() closes after a fast round trip reset")
+	}
+	if !m.record(300*time.Millisecond, ka) {
+		t.Fatal("record() want close after MaxSlow slow round trips")
+	}
+	want := RTTStats{
+		Count: 5,
+		Last:  300 * time.Millisecond,
+		Min:   10 * time.Millisecond,
+		Max:   300 * time.Millisecond,
+		Mean:  148 * time.Millisecond,
+		Slow:  2,
+	}
+	if got := m.get(); got != want {
+		t.Errorf("get() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"fmt"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Severity the severity of a configuration issue
+type Severity int
+
+// Severity defined
+const (
+	// SeverityWarning the configuration works, but probably not as intended
+	SeverityWarning Severity = iota
+	// SeverityError the configuration does not work
+	SeverityError
+)
+
+func (sf Severity) String() string {
+	if sf == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue a configuration issue found by Validate
+type Issue struct {
+	Severity Severity
+	Field    string // the configuration item concerned
+	Message  string
+}
+
+func (sf Issue) Error() string {
+	return fmt.Sprintf("%s: %s: %s", sf.Severity, sf.Field, sf.Message)
+}
+
+// Issues the issues found by Validate
+type Issues []Issue
+
+// Err returns the first error issue, nil if none
+func (sf Issues) Err() error {
+	for _, v := range sf {
+		if v.Severity == SeverityError {
+			return v
+		}
+	}
+	return nil
+}
+
+func (sf *Issues) add(severity Severity, field, format string, args ...interface{}) {
+	*sf = append(*sf, Issue{severity, field, fmt.Sprintf(format, args...)})
+}
+
+// Validate cross check the assembled server configuration, call it before ListenAndServer
+func (sf *Server) Validate() Issues {
+	var issues Issues
+	lintConfig(&issues, sf.config)
+	if err := sf.params.Valid(); err != nil {
+		issues.add(SeverityError, "Params", "%v", err)
+		return issues
+	}
+
+	if sf.handler == nil {
+		issues.add(SeverityError, "Handler", "no server handler")
+	}
+	if sf.confirmHandler != nil && sf.cmdHandler != nil {
+		issues.add(SeverityWarning, "ConfirmHandler", "the process commands are taken over by the command handler")
+	}
+
+	seen := make(map[asdu.CommonAddr]struct{}, len(sf.commonAddrs))
+	for _, ca := range sf.commonAddrs {
+		if ca == asdu.GlobalCommonAddr {
+			issues.add(SeverityError, "CommonAddrs", "global common address %d is not a station", ca)
+		} else if err := sf.params.ValidCommonAddr(ca); err != nil {
+			issues.add(SeverityError, "CommonAddrs", "common address %d: %v", ca, err)
+		}
+		if _, ok := seen[ca]; ok {
+			issues.add(SeverityWarning, "CommonAddrs", "common address %d duplicated", ca)
+		}
+		seen[ca] = struct{}{}
+	}
+	for ca, sector := range sf.sectors {
+		if sector.Handler == nil && sector.PointStore == nil {
+			issues.add(SeverityWarning, "Sectors", "sector %d falls back to the server entirely", ca)
+		}
+		if store, ok := sector.PointStore.(*MemPointStore); ok {
+			sf.lintPointStore(&issues, fmt.Sprintf("Sectors[%d].PointStore", ca), store)
+		}
+	}
+	if store, ok := sf.Configuration().PointStore.(*MemPointStore); ok {
+		sf.lintPointStore(&issues, "PointStore", store)
+	}
+	return issues
+}
+
+func (sf *Server) lintPointStore(issues *Issues, field string, store *MemPointStore) {
+	store.mux.RLock()
+	defer store.mux.RUnlock()
+	for ca, points := range store.points {
+		if len(sf.commonAddrs) > 0 && !hasCommonAddr(sf.commonAddrs, ca) {
+			issues.add(SeverityWarning, field, "common address %d is not served", ca)
+		}
+		if err := sf.params.ValidCommonAddr(ca); err != nil {
+			issues.add(SeverityError, field, "common address %d: %v", ca, err)
+		}
+		for ioa, p := range points {
+			if err := sf.params.ValidInfoObjAddr(ioa); err != nil {
+				issues.add(SeverityError, field, "information object address %d of common address %d: %v", ioa, ca, err)
+			}
+			if isCP24Time2a(p.Type) {
+				issues.add(SeverityWarning, field, "point %d/%d: time tag CP24Time2a of %s is not allowed by 104, use CP56Time2a",
+					ca, ioa, p.Type)
+			}
+		}
+	}
+	for ca, groups := range store.groups {
+		for ioa, g := range groups {
+			if _, ok := store.points[ca][ioa]; !ok {
+				issues.add(SeverityWarning, field, "point %d/%d of interrogation group %d does not exist",
+					ca, ioa, g-asdu.QOIStation)
+			}
+		}
+	}
+}
+
+// Validate cross check the assembled client configuration, call it before Start
+func (sf *ClientOption) Validate() Issues {
+	var issues Issues
+	lintConfig(&issues, sf.config)
+	if err := sf.params.Valid(); err != nil {
+		issues.add(SeverityError, "Params", "%v", err)
+	}
+	if sf.server == nil {
+		issues.add(SeverityError, "Server", "no remote server, see AddRemoteServer")
+	} else {
+		switch sf.server.Scheme {
+		case "tcp":
+			if sf.TLSConfig != nil {
+				issues.add(SeverityWarning, "TLSConfig", "ignored by the tcp scheme, use tls://")
+			}
+		case "ssl", "tls", "tcps":
+		default:
+			issues.add(SeverityError, "Server", "unknown scheme %q", sf.server.Scheme)
+		}
+	}
+	return issues
+}
+
+// lintConfig check the config, including the recommendations of IEC 60870-5-104, subclass 5.5 and 6.9
+func lintConfig(issues *Issues, cfg Config) {
+	if err := cfg.Valid(); err != nil { // valid on the copy, the defaults applied
+		issues.add(SeverityError, "Config", "%v", err)
+		return
+	}
+	if uint(cfg.RecvUnAckLimitW)*3 > uint(cfg.SendUnAckLimitK)*2 {
+		issues.add(SeverityWarning, "Config", `"w" %d exceeds 2/3 of "k" %d`, cfg.RecvUnAckLimitW, cfg.SendUnAckLimitK)
+	}
+	if cfg.RecvUnAckTimeout2 >= cfg.SendUnAckTimeout1 {
+		issues.add(SeverityWarning, "Config", `"t₂" %v is not less than "t₁" %v`, cfg.RecvUnAckTimeout2, cfg.SendUnAckTimeout1)
+	}
+	if cfg.IdleTimeout3 <= cfg.SendUnAckTimeout1 {
+		issues.add(SeverityWarning, "Config", `"t₃" %v is not greater than "t₁" %v`, cfg.IdleTimeout3, cfg.SendUnAckTimeout1)
+	}
+}
+
+// isCP24Time2a whether the type identification carries a CP24Time2a time tag
+func isCP24Time2a(id asdu.TypeID) bool {
+	switch id {
+	case asdu.M_SP_TA_1, asdu.M_DP_TA_1, asdu.M_ST_TA_1, asdu.M_BO_TA_1,
+		asdu.M_ME_TA_1, asdu.M_ME_TB_1, asdu.M_ME_TC_1, asdu.M_IT_TA_1,
+		asdu.M_EP_TA_1, asdu.M_EP_TB_1, asdu.M_EP_TC_1:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestServer_Validate(t *testing.T) {
+	srv := NewServer(&mockServerHandler{})
+	if issues := srv.Validate(); len(issues) != 0 {
+		t.Fatalf("Validate() default issues %v", issues)
+	}
+
+	store := NewMemPointStore()
+	_ = store.Set(3, Point{asdu.M_SP_TA_1, asdu.SinglePointInfo{Ioa: 1}})
+	_ = store.SetGroup(3, 2, asdu.QOIGroup1)
+	srv.SetConfig(Config{SendUnAckLimitK: 6, RecvUnAckLimitW: 6}).
+		SetCommonAddrs(1, 2, 2).
+		SetPointStore(store)
+	issues := srv.Validate()
+	if issues.Err() != nil {
+		t.Fatalf("Validate() error %v", issues.Err())
+	}
+	if len(issues) != 5 { // w > 2/3 k, duplicated, not served, CP24Time2a, group of no point
+		t.Errorf("Validate() issues %v, want 5", issues)
+	}
+
+	srv.SetCommonAddrs(asdu.GlobalCommonAddr)
+	if srv.Validate().Err() == nil {
+		t.Errorf("Validate() want error of the global common address")
+	}
+}
+
+func TestClientOption_Validate(t *testing.T) {
+	opt := NewOption()
+	if opt.Validate().Err() == nil {
+		t.Fatalf("Validate() want error of no remote server")
+	}
+	if err := opt.AddRemoteServer("127.0.0.1:2404"); err != nil {
+		t.Fatal(err)
+	}
+	if issues := opt.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() issues %v", issues)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrCommandLocked the object is selected or being executed by another controlling station
+var ErrCommandLocked = errors.New("command object locked by another controlling station")
+
+// DefaultSelectTimeout default time an object stays reserved to the controlling station which selected it
+const DefaultSelectTimeout = 30 * time.Second
+
+// commandKey the object a command addresses
+type commandKey struct {
+	ca  asdu.CommonAddr
+	ioa asdu.InfoObjAddr
+}
+
+// commandLock the controlling station an object is reserved to
+type commandLock struct {
+	owner interface{}
+	until time.Time
+}
+
+// Multiplexer serves one outstation data source to several independent controlling stations.
+// Each master is a Server of its own, listening for its controlling station with its own config,
+// so that k and w windows, send queues and interrogations are independent, and the multiplexer
+// gives each its own sequence of events queue.
+//
+// The data source sends the monitor direction asdu through the multiplexer, which implements asdu.Connect,
+// and enqueues the events with Enqueue. The process commands of all the masters are arbitrated before
+// reaching the command handler of the data source: an object selected, or executed with a pending
+// termination, by a controlling station is reserved to it until executed, deselected or the select
+// timeout elapsed, the commands of the other controlling stations on it are negative confirmed.
+type Multiplexer struct {
+	handler  ServerCommandHandler
+	capacity int
+
+	mux     sync.Mutex
+	masters []*Server
+	queues  []*SOE
+	locks   map[commandKey]commandLock
+	timeout time.Duration
+}
+
+var _ asdu.Connect = (*Multiplexer)(nil)
+
+// NewMultiplexer new a multiplexer executing the arbitrated commands with the handler of the data source
+func NewMultiplexer(handler ServerCommandHandler) *Multiplexer {
+	return &Multiplexer{
+		handler: handler,
+		locks:   make(map[commandKey]commandLock),
+		timeout: DefaultSelectTimeout,
+	}
+}
+
+// SetSelectTimeout set the time an object stays reserved after a select or a pending execution
+func (sf *Multiplexer) SetSelectTimeout(d time.Duration) *Multiplexer {
+	if d > 0 {
+		sf.mux.Lock()
+		sf.timeout = d
+		sf.mux.Unlock()
+	}
+	return sf
+}
+
+// SetSOECapacity set the capacity of the event queue of the masters added later, see NewSOE
+func (sf *Multiplexer) SetSOECapacity(capacity int) *Multiplexer {
+	sf.mux.Lock()
+	sf.capacity = capacity
+	sf.mux.Unlock()
+	return sf
+}
+
+// AddMaster add the server of a controlling station, it sets its event queue and command handler.
+func (sf *Multiplexer) AddMaster(srv *Server) *Multiplexer {
+	sf.mux.Lock()
+	q := NewSOE(sf.capacity)
+	sf.masters = append(sf.masters, srv)
+	sf.queues = append(sf.queues, q)
+	sf.mux.Unlock()
+	srv.SetSOE(q).SetCommandHandler(&masterCommandHandler{sf, srv})
+	return sf
+}
+
+// Masters returns the servers of the controlling stations
+func (sf *Multiplexer) Masters() []*Server {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return append([]*Server(nil), sf.masters...)
+}
+
+// Enqueue call f with the event queue of every master, for example
+//
+//	m.Enqueue(func(q *SOE) error { return q.EnqueueSingle(ca, info) })
+func (sf *Multiplexer) Enqueue(f func(q *SOE) error) error {
+	sf.mux.Lock()
+	queues := append([]*SOE(nil), sf.queues...)
+	sf.mux.Unlock()
+	var errs []error
+	for _, q := range queues {
+		errs = append(errs, f(q))
+	}
+	return errors.Join(errs...)
+}
+
+// Params imp interface Connect, the params of the first master, asdu.ParamsWide if none
+func (sf *Multiplexer) Params() *asdu.Params {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if len(sf.masters) == 0 {
+		return asdu.ParamsWide
+	}
+	return sf.masters[0].Params()
+}
+
+// Send imp interface Connect, send the asdu to the controlling stations of all the masters
+func (sf *Multiplexer) Send(a *asdu.ASDU) error {
+	var errs []error
+	for _, srv := range sf.Masters() {
+		errs = append(errs, srv.Send(a))
+	}
+	return errors.Join(errs...)
+}
+
+// UnderlyingConn imp interface Connect
+func (sf *Multiplexer) UnderlyingConn() net.Conn { return nil }
+
+// arbitrate the command of the owner on the object, then execute it by f.
+// A select reserves the object until the timeout, an execution releases it once confirmed
+// or keeps it until the timeout if its termination is pending, a deactivation releases it.
+func (sf *Multiplexer) arbitrate(owner interface{}, id asdu.Identifier, ioa asdu.InfoObjAddr, sel bool, f func() error) error {
+	key := commandKey{id.CommonAddr, ioa}
+	now := time.Now()
+
+	sf.mux.Lock()
+	if l, ok := sf.locks[key]; ok && l.owner != owner && now.Before(l.until) {
+		sf.mux.Unlock()
+		return ErrCommandLocked
+	}
+	if id.Coa.Cause == asdu.Activation {
+		sf.locks[key] = commandLock{owner, now.Add(sf.timeout)}
+	} else {
+		delete(sf.locks, key)
+	}
+	sf.mux.Unlock()
+
+	err := f()
+	if id.Coa.Cause == asdu.Activation && (err == nil && !sel || err != nil && !errors.Is(err, ErrPending)) {
+		sf.mux.Lock()
+		if l, ok := sf.locks[key]; ok && l.owner == owner {
+			delete(sf.locks, key)
+		}
+		sf.mux.Unlock()
+	}
+	return err
+}
+
+// masterCommandHandler the command handler of a master, arbitrating before the data source
+type masterCommandHandler struct {
+	m   *Multiplexer
+	srv *Server
+}
+
+// owner the controlling station of the connection, the master if the connection is unknown
+func (sf *masterCommandHandler) owner(c asdu.Connect) interface{} {
+	if conn := c.UnderlyingConn(); conn != nil {
+		return conn
+	}
+	return sf.srv
+}
+
+// OnSingleCommand imp interface ServerCommandHandler
+func (sf *masterCommandHandler) OnSingleCommand(c asdu.Connect, id asdu.Identifier, v asdu.SingleCommandInfo) error {
+	return sf.m.arbitrate(sf.owner(c), id, v.Ioa, v.Qoc.InSelect, func() error { return sf.m.handler.OnSingleCommand(c, id, v) })
+}
+
+// OnDoubleCommand imp interface ServerCommandHandler
+func (sf *masterCommandHandler) OnDoubleCommand(c asdu.Connect, id asdu.Identifier, v asdu.DoubleCommandInfo) error {
+	return sf.m.arbitrate(sf.owner(c), id, v.Ioa, v.Qoc.InSelect, func() error { return sf.m.handler.OnDoubleCommand(c, id, v) })
+}
+
+// OnStepCommand imp interface ServerCommandHandler
+func (sf *masterCommandHandler) OnStepCommand(c asdu.Connect, id asdu.Identifier, v asdu.StepCommandInfo) error {
+	return sf.m.arbitrate(sf.owner(c), id, v.Ioa, v.Qoc.InSelect, func() error { return sf.m.handler.OnStepCommand(c, id, v) })
+}
+
+// OnSetpointNormal imp interface ServerCommandHandler
+func (sf *masterCommandHandler) OnSetpointNormal(c asdu.Connect, id asdu.Identifier, v asdu.SetpointCommandNormalInfo) error {
+	return sf.m.arbitrate(sf.owner(c), id, v.Ioa, v.Qos.InSelect, func() error { return sf.m.handler.OnSetpointNormal(c, id, v) })
+}
+
+// OnSetpointScaled imp interface ServerCommandHandler
+func (sf *masterCommandHandler) OnSetpointScaled(c asdu.Connect, id asdu.Identifier, v asdu.SetpointCommandScaledInfo) error {
+	return sf.m.arbitrate(sf.owner(c), id, v.Ioa, v.Qos.InSelect, func() error { return sf.m.handler.OnSetpointScaled(c, id, v) })
+}
+
+// OnSetpointFloat imp interface ServerCommandHandler
+func (sf *masterCommandHandler) OnSetpointFloat(c asdu.Connect, id asdu.Identifier, v asdu.SetpointCommandFloatInfo) error {
+	return sf.m.arbitrate(sf.owner(c), id, v.Ioa, v.Qos.InSelect, func() error { return sf.m.handler.OnSetpointFloat(c, id, v) })
+}
+
+// OnBitString32Command imp interface ServerCommandHandler
+func (sf *masterCommandHandler) OnBitString32Command(c asdu.Connect, id asdu.Identifier, v asdu.BitsString32CommandInfo) error {
+	return sf.m.arbitrate(sf.owner(c), id, v.Ioa, false, func() error { return sf.m.handler.OnBitString32Command(c, id, v) })
+}
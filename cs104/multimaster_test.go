@@ -0,0 +1,62 @@
+package cs104
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestMultiplexer_arbitrate(t *testing.T) {
+	m := NewMultiplexer(&mockCommandHandler{})
+	a, b := NewServer(&mockServerHandler{}), NewServer(&mockServerHandler{})
+	m.AddMaster(a).AddMaster(b)
+	ha := a.cmdHandler.(*masterCommandHandler)
+	hb := b.cmdHandler.(*masterCommandHandler)
+
+	c := &recordConn{}
+	act := asdu.Identifier{Type: asdu.C_SC_NA_1, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1}
+	deact := act
+	deact.Coa.Cause = asdu.Deactivation
+	sel := asdu.SingleCommandInfo{Ioa: 1, Value: true, Qoc: asdu.QualifierOfCommand{InSelect: true}}
+	exe := asdu.SingleCommandInfo{Ioa: 1, Value: true}
+
+	steps := []struct {
+		name string
+		h    *masterCommandHandler
+		id   asdu.Identifier
+		cmd  asdu.SingleCommandInfo
+		want error
+	}{
+		{"a selects", ha, act, sel, nil},
+		{"b executes the object selected", hb, act, exe, ErrCommandLocked},
+		{"b deselects the object of a", hb, deact, sel, ErrCommandLocked},
+		{"a executes", ha, act, exe, nil},
+		{"b executes once released", hb, act, exe, nil},
+		{"b selects", hb, act, sel, nil},
+		{"b deselects", hb, deact, sel, nil},
+		{"a selects once deselected", ha, act, sel, nil},
+	}
+	for _, s := range steps {
+		if err := s.h.OnSingleCommand(c, s.id, s.cmd); !errors.Is(err, s.want) {
+			t.Errorf("%s: error = %v, want %v", s.name, err, s.want)
+		}
+	}
+}
+
+func TestMultiplexer_Enqueue(t *testing.T) {
+	m := NewMultiplexer(&mockCommandHandler{})
+	a, b := NewServer(&mockServerHandler{}), NewServer(&mockServerHandler{})
+	m.AddMaster(a).AddMaster(b)
+
+	err := m.Enqueue(func(q *SOE) error { return q.EnqueueSingle(1, asdu.SinglePointInfo{Ioa: 1, Value: true}) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.soe == b.soe || a.soe.Len() != 1 || b.soe.Len() != 1 {
+		t.Errorf("the masters do not have their own queue with the event")
+	}
+	if len(m.Masters()) != 2 || m.Params() != a.Params() {
+		t.Errorf("Masters() = %d, want 2", len(m.Masters()))
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrCommonAddrMapped the common address is translated already
+var ErrCommonAddrMapped = errors.New("common address translated already")
+
+// natEntry the translation of a common address in one direction
+type natEntry struct {
+	ca  asdu.CommonAddr
+	ioa asdu.IOAMap
+}
+
+// NAT the address translation of a gateway between the upstream masters and the downstream stations,
+// for example an IEC104 proxy: every upstream common address is translated to the common address of
+// its station, with its information object addresses. The asdu from the masters, the commands, are
+// translated downstream, those from the stations, the data and the confirmations of the commands,
+// are translated back upstream. The common addresses not in the table and the global address are kept.
+// With SQ = 1 only the address of the first information object is translated, see asdu.Readdress.
+type NAT struct {
+	mux  sync.RWMutex
+	down map[asdu.CommonAddr]natEntry // upstream common address to the downstream one
+	up   map[asdu.CommonAddr]natEntry // downstream common address to the upstream one
+}
+
+// NewNAT new an empty address translation table
+func NewNAT() *NAT {
+	return &NAT{
+		down: make(map[asdu.CommonAddr]natEntry),
+		up:   make(map[asdu.CommonAddr]natEntry),
+	}
+}
+
+// Add translate the upstream common address to the downstream one, and the upstream information object
+// addresses of it by the mapping m to the downstream ones, the addresses not in m are kept.
+func (sf *NAT) Add(upstream, downstream asdu.CommonAddr, m asdu.IOAMap) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	inverse := make(asdu.IOAMap, len(m))
+	for k, v := range m {
+		inverse[v] = k
+	}
+
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if _, ok := sf.down[upstream]; ok {
+		return ErrCommonAddrMapped
+	}
+	if _, ok := sf.up[downstream]; ok {
+		return ErrCommonAddrMapped
+	}
+	sf.down[upstream] = natEntry{downstream, m}
+	sf.up[downstream] = natEntry{upstream, inverse}
+	return nil
+}
+
+// Downstream translate in place the asdu of an upstream master to the addresses of the station,
+// the asdu is not changed if it fails.
+func (sf *NAT) Downstream(a *asdu.ASDU) error {
+	return sf.translate(sf.down, a)
+}
+
+// Upstream translate in place the asdu of a station to the addresses of the masters,
+// the asdu is not changed if it fails.
+func (sf *NAT) Upstream(a *asdu.ASDU) error {
+	return sf.translate(sf.up, a)
+}
+
+func (sf *NAT) translate(table map[asdu.CommonAddr]natEntry, a *asdu.ASDU) error {
+	sf.mux.RLock()
+	e, ok := table[a.CommonAddr]
+	sf.mux.RUnlock()
+	if !ok {
+		return nil
+	}
+	if len(e.ioa) > 0 {
+		if err := a.Readdress(e.ioa); err != nil {
+			return err
+		}
+	}
+	a.CommonAddr = e.ca
+	return nil
+}
+
+// ToDownstream an Interceptor translating the asdu downstream before passing it to next,
+// for example received by the server of the masters, or sent by the client of the stations.
+func (sf *NAT) ToDownstream(next ASDUHandlerFunc) ASDUHandlerFunc {
+	return func(c asdu.Connect, a *asdu.ASDU) error {
+		if err := sf.Downstream(a); err != nil {
+			return err
+		}
+		return next(c, a)
+	}
+}
+
+// ToUpstream an Interceptor translating the asdu upstream before passing it to next,
+// for example received by the client of the stations, or sent by the server of the masters.
+func (sf *NAT) ToUpstream(next ASDUHandlerFunc) ASDUHandlerFunc {
+	return func(c asdu.Connect, a *asdu.ASDU) error {
+		if err := sf.Upstream(a); err != nil {
+			return err
+		}
+		return next(c, a)
+	}
+}
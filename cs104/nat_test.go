@@ -0,0 +1,58 @@
+package cs104
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestNAT(t *testing.T) {
+	nat := NewNAT()
+	if err := nat.Add(100, 1, asdu.IOAMap{5000: 1, 5001: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := nat.Add(101, 1, nil); err != ErrCommonAddrMapped {
+		t.Errorf("Add() error = %v, want %v", err, ErrCommonAddrMapped)
+	}
+	var collision asdu.IOACollisionError
+	if err := nat.Add(102, 2, asdu.IOAMap{1: 3, 2: 3}); !errors.As(err, &collision) {
+		t.Errorf("Add() error = %v, want %T", err, collision)
+	}
+
+	// the command of the master to the station, its confirmation back
+	c := &recordConn{}
+	_ = asdu.SingleCmd(c, asdu.C_SC_NA_1, asdu.CauseOfTransmission{Cause: asdu.Activation}, 100,
+		asdu.SingleCommandInfo{Ioa: 5001, Value: true})
+	cmd := c.take()[0]
+
+	var forwarded *asdu.ASDU
+	toStation := nat.ToDownstream(func(_ asdu.Connect, a *asdu.ASDU) error {
+		forwarded = a.Clone()
+		return nil
+	})
+	if err := toStation(c, cmd); err != nil {
+		t.Fatal(err)
+	}
+	if v := forwarded.Clone().GetSingleCmd(); forwarded.CommonAddr != 1 || v.Ioa != 2 {
+		t.Errorf("downstream command %v ioa %d, want common address 1 ioa 2", forwarded.Identifier, v.Ioa)
+	}
+
+	con := forwarded.Mirror(asdu.ActivationCon, false)
+	if err := nat.Upstream(con); err != nil {
+		t.Fatal(err)
+	}
+	if v := con.GetSingleCmd(); con.CommonAddr != 100 || v.Ioa != 5001 {
+		t.Errorf("upstream confirmation %v ioa %d, want common address 100 ioa 5001", con.Identifier, v.Ioa)
+	}
+
+	// the station data not mapped is kept
+	_ = asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.SinglePointInfo{Ioa: 7})
+	data := c.take()[0]
+	if err := nat.Upstream(data); err != nil {
+		t.Fatal(err)
+	}
+	if v := data.GetSinglePoint(); data.CommonAddr != 100 || v[0].Ioa != 7 {
+		t.Errorf("upstream data %v ioa %d, want common address 100 ioa 7", data.Identifier, v[0].Ioa)
+	}
+}
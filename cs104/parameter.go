@@ -0,0 +1,153 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// DefaultConfirmTimeout default timeout waiting for the confirmation of a parameter command
+const DefaultConfirmTimeout = 15 * time.Second
+
+type parameterKey struct {
+	typeID asdu.TypeID
+	ca     asdu.CommonAddr
+	ioa    asdu.InfoObjAddr
+}
+
+// ParameterManager the controlling side of the parameter loading, it sequences the parameter writes
+// [P_ME_NA_1], [P_ME_NB_1], [P_ME_NC_1] of a measured value and their activation [P_AC_NA_1],
+// each step waiting for the activation confirmation of the controlled station.
+// The outstation side is the Deadband engine, see Server.SetDeadband.
+// The received confirmations must be given to Handle, usually from the ASDUHandler of the client.
+type ParameterManager struct {
+	conn    asdu.Connect
+	timeout time.Duration
+
+	mux     sync.Mutex
+	waiting map[parameterKey]chan asdu.CauseOfTransmission
+}
+
+// NewParameterManager new a parameter manager sending with c
+func NewParameterManager(c asdu.Connect) *ParameterManager {
+	return &ParameterManager{
+		conn:    c,
+		timeout: DefaultConfirmTimeout,
+		waiting: make(map[parameterKey]chan asdu.CauseOfTransmission),
+	}
+}
+
+// SetTimeout set the timeout waiting for each confirmation
+func (sf *ParameterManager) SetTimeout(d time.Duration) *ParameterManager {
+	if d > 0 {
+		sf.timeout = d
+	}
+	return sf
+}
+
+// Load write the parameters of the measured value with the parameter type [P_ME_NA_1], [P_ME_NB_1] or [P_ME_NC_1],
+// then activate them. The threshold is always written, the smoothing factor if not zero,
+// the limits if they are used. It returns ErrReject if any step is negative confirmed.
+func (sf *ParameterManager) Load(ctx context.Context, ca asdu.CommonAddr, ioa asdu.InfoObjAddr, typeID asdu.TypeID, p DeadbandParam) error {
+	type step struct {
+		category asdu.QPMCategory
+		value    float64
+	}
+	steps := []step{{asdu.QPMThreshold, p.Threshold}}
+	if p.Smoothing != 0 {
+		steps = append(steps, step{asdu.QPMSmoothing, p.Smoothing})
+	}
+	if p.HasLowLimit {
+		steps = append(steps, step{asdu.QPMLowLimit, p.LowLimit})
+	}
+	if p.HasHighLimit {
+		steps = append(steps, step{asdu.QPMHighLimit, p.HighLimit})
+	}
+
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	for _, s := range steps {
+		qpm := asdu.QualifierOfParameterMV{Category: s.category}
+		err := sf.request(ctx, parameterKey{typeID, ca, ioa}, func() error {
+			switch typeID {
+			case asdu.P_ME_NA_1:
+				return asdu.ParameterNormal(sf.conn, act, ca, asdu.ParameterNormalInfo{Ioa: ioa, Value: asdu.Normalize(s.value), Qpm: qpm})
+			case asdu.P_ME_NB_1:
+				return asdu.ParameterScaled(sf.conn, act, ca, asdu.ParameterScaledInfo{Ioa: ioa, Value: int16(s.value), Qpm: qpm})
+			case asdu.P_ME_NC_1:
+				return asdu.ParameterFloat(sf.conn, act, ca, asdu.ParameterFloatInfo{Ioa: ioa, Value: float32(s.value), Qpm: qpm})
+			}
+			return asdu.ErrTypeIDNotMatch
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return sf.Activate(ctx, ca, ioa, asdu.QPADeActObjectParameter, true)
+}
+
+// Activate activate or deactivate the loaded parameters [P_AC_NA_1] and wait for the confirmation
+func (sf *ParameterManager) Activate(ctx context.Context, ca asdu.CommonAddr, ioa asdu.InfoObjAddr, qpa asdu.QualifierOfParameterAct, act bool) error {
+	coa := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	if !act {
+		coa.Cause = asdu.Deactivation
+	}
+	return sf.request(ctx, parameterKey{asdu.P_AC_NA_1, ca, ioa}, func() error {
+		return asdu.ParameterActivation(sf.conn, coa, ca, asdu.ParameterActivationInfo{Ioa: ioa, Qpa: qpa})
+	})
+}
+
+// request send the command and wait for its confirmation
+func (sf *ParameterManager) request(ctx context.Context, key parameterKey, send func() error) error {
+	ch := make(chan asdu.CauseOfTransmission, 1)
+	sf.mux.Lock()
+	sf.waiting[key] = ch
+	sf.mux.Unlock()
+	defer func() {
+		sf.mux.Lock()
+		delete(sf.waiting, key)
+		sf.mux.Unlock()
+	}()
+
+	if err := send(); err != nil {
+		return err
+	}
+	timer := time.NewTimer(sf.timeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return ErrConfirmTimeout
+	case coa := <-ch:
+		if coa.IsNegative {
+			return ErrReject{coa.Cause}
+		}
+		return nil
+	}
+}
+
+// Handle take the confirmation of a pending parameter command, it returns false if the asdu is not
+// the confirmation of any pending one.
+func (sf *ParameterManager) Handle(pack *asdu.ASDU) bool {
+	if pack.Type < asdu.P_ME_NA_1 || pack.Type > asdu.P_AC_NA_1 ||
+		pack.Coa.Cause == asdu.Activation || pack.Coa.Cause == asdu.Deactivation {
+		return false
+	}
+	key := parameterKey{pack.Type, pack.CommonAddr, pack.Clone().DecodeInfoObjAddr()}
+	sf.mux.Lock()
+	ch, ok := sf.waiting[key]
+	if ok {
+		delete(sf.waiting, key)
+	}
+	sf.mux.Unlock()
+	if ok {
+		ch <- pack.Coa
+	}
+	return ok
+}
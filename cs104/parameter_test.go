@@ -0,0 +1,65 @@
+package cs104
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// loopConn dispatch the sent asdu to the handler, whose replies go to the reply function
+type loopConn struct {
+	handler func(c asdu.Connect, a *asdu.ASDU) error
+	reply   func(a *asdu.ASDU)
+}
+
+func (sf *loopConn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *loopConn) UnderlyingConn() net.Conn { return nil }
+func (sf *loopConn) Send(a *asdu.ASDU) error {
+	return sf.handler(replyConn(sf.reply), a.Clone())
+}
+
+type replyConn func(a *asdu.ASDU)
+
+func (sf replyConn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf replyConn) UnderlyingConn() net.Conn { return nil }
+func (sf replyConn) Send(a *asdu.ASDU) error {
+	data, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	b := asdu.NewEmptyASDU(asdu.ParamsWide)
+	if err = b.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	sf(b)
+	return nil
+}
+
+func TestParameterManager(t *testing.T) {
+	d := NewDeadband(&recordConn{}, 1)
+	if err := d.Add(100, asdu.M_ME_NC_1, DeadbandParam{NotInOperation: true}); err != nil {
+		t.Fatal(err)
+	}
+	var m *ParameterManager
+	c := &loopConn{handler: d.ParameterHandler, reply: func(a *asdu.ASDU) {
+		if !m.Handle(a) {
+			t.Errorf("Handle() not a pending confirmation %v", a.Identifier)
+		}
+	}}
+	m = NewParameterManager(c)
+
+	want := DeadbandParam{Threshold: 0.5, LowLimit: -10, HasLowLimit: true, HighLimit: 10, HasHighLimit: true}
+	if err := m.Load(context.Background(), 1, 100, asdu.P_ME_NC_1, want); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := d.Param(100); got != want {
+		t.Errorf("Param() = %+v, want %+v", got, want)
+	}
+
+	err := m.Load(context.Background(), 1, 101, asdu.P_ME_NC_1, want)
+	if reject, ok := err.(ErrReject); !ok || reject.Cause != asdu.UnknownIOA {
+		t.Errorf("Load() error = %v, want %v", err, ErrReject{asdu.UnknownIOA})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Pipe a server and a client connected in memory, running the full APCI state machine
+// without binding a TCP port, for the integration tests and the examples.
+type Pipe struct {
+	Server *Server
+	Client *Client
+	listen *pipeListener
+}
+
+// NewPipe new a pipe of the server and a client with the handler and the option, nil is NewOption().
+// The remote server and the tls config of the option are not used.
+func NewPipe(srv *Server, handler ClientHandlerInterface, o *ClientOption) *Pipe {
+	if o == nil {
+		o = NewOption()
+	}
+	listen := &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+	opt := *o
+	opt.dial = listen.dial
+	return &Pipe{
+		Server: srv,
+		Client: NewClient(handler, &opt),
+		listen: listen,
+	}
+}
+
+// Start serve the server, start the client and wait until it is connected or ctx is done.
+// The server and the client run until Close or ctx is done.
+func (sf *Pipe) Start(ctx context.Context) error {
+	go func() { _ = sf.Server.Serve(ctx, sf.listen) }()
+	if err := sf.Client.StartContext(ctx); err != nil {
+		return err
+	}
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+	for !sf.Client.IsConnected() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
+// Close close the client and the server
+func (sf *Pipe) Close() error {
+	_ = sf.Client.Close()
+	return sf.Server.Close()
+}
+
+// pipeListener a net.Listener of the in-memory connections dialed
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+var _ net.Listener = (*pipeListener)(nil)
+
+func (sf *pipeListener) dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case sf.conns <- pipeConn{server}:
+		return pipeConn{client}, nil
+	case <-sf.done:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (sf *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-sf.conns:
+		return conn, nil
+	case <-sf.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (sf *pipeListener) Close() error {
+	sf.once.Do(func() { close(sf.done) })
+	return nil
+}
+
+func (sf *pipeListener) Addr() net.Addr { return pipeAddr{} }
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
+
+// pipeConn a net.Pipe end failing like a closed network connection once closed,
+// the receiving loops tolerate io.ErrClosedPipe.
+type pipeConn struct {
+	net.Conn
+}
+
+func (sf pipeConn) Read(b []byte) (int, error) {
+	n, err := sf.Conn.Read(b)
+	return n, closedErr(err)
+}
+
+func (sf pipeConn) Write(b []byte) (int, error) {
+	n, err := sf.Conn.Write(b)
+	return n, closedErr(err)
+}
+
+func closedErr(err error) error {
+	if errors.Is(err, io.ErrClosedPipe) {
+		return net.ErrClosed
+	}
+	return err
+}
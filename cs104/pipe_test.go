@@ -0,0 +1,75 @@
+package cs104
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type pipeServerHandler struct {
+	mockServerHandler
+}
+
+func (sf *pipeServerHandler) InterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfInterrogation) error {
+	_ = c.Send(pack.Mirror(asdu.ActivationCon, false))
+	_ = asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}, pack.CommonAddr,
+		asdu.SinglePointInfo{Ioa: 100, Value: true})
+	return c.Send(pack.Mirror(asdu.ActivationTerm, false))
+}
+
+type pipeClientHandler struct {
+	ClientHandlerBase
+	points chan asdu.SinglePointInfo
+}
+
+func (sf *pipeClientHandler) OnSinglePoint(infos []asdu.SinglePointInfo, _ asdu.Identifier) error {
+	for _, info := range infos {
+		sf.points <- info
+	}
+	return nil
+}
+
+func TestPipe(t *testing.T) {
+	ch := &pipeClientHandler{points: make(chan asdu.SinglePointInfo, 1)}
+	p := NewPipe(NewServer(&pipeServerHandler{}), NewTypedClientHandler(ch), nil)
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	p.Client.SendStartDt()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		err := p.Client.InterrogationCmd(asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("InterrogationCmd() error = %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case info := <-ch.points:
+		if info.Ioa != 100 || !info.Value {
+			t.Errorf("point = %+v", info)
+		}
+	case <-ctx.Done():
+		t.Fatal("interrogated point not received")
+	}
+	if n := p.Server.GetSessionsLen(); n != 1 {
+		t.Errorf("GetSessionsLen() = %d, want 1", n)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if p.Client.IsConnected() {
+		t.Error("IsConnected() after Close")
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrPointType the type identification does not match the point information or can not be read
+var ErrPointType = errors.New("point type identification not support")
+
+// Point a monitored information object.
+// Info must be the information of the type identification:
+// asdu.SinglePointInfo for [M_SP_NA_1], [M_SP_TA_1], [M_SP_TB_1]
+// asdu.DoublePointInfo for [M_DP_NA_1], [M_DP_TA_1], [M_DP_TB_1]
+// asdu.StepPositionInfo for [M_ST_NA_1], [M_ST_TA_1], [M_ST_TB_1]
+// asdu.BitString32Info for [M_BO_NA_1], [M_BO_TA_1], [M_BO_TB_1]
+// asdu.MeasuredValueNormalInfo for [M_ME_NA_1], [M_ME_TA_1], [M_ME_TD_1], [M_ME_ND_1]
+// asdu.MeasuredValueScaledInfo for [M_ME_NB_1], [M_ME_TB_1], [M_ME_TE_1]
+// asdu.MeasuredValueFloatInfo for [M_ME_NC_1], [M_ME_TC_1], [M_ME_TF_1]
+type Point struct {
+	Type asdu.TypeID
+	Info interface{}
+}
+
+// PointStore the process image of the outstation, used to answer the read command [C_RD_NA_1]
+type PointStore interface {
+	// Point returns the point of the information object address in the common address
+	Point(ca asdu.CommonAddr, ioa asdu.InfoObjAddr) (Point, bool)
+}
+
+// MemPointStore a PointStore kept in memory
+type MemPointStore struct {
+	mux    sync.RWMutex
+	points map[asdu.CommonAddr]map[asdu.InfoObjAddr]Point
+	groups map[asdu.CommonAddr]map[asdu.InfoObjAddr]asdu.QualifierOfInterrogation // see SetGroup
+}
+
+var _ PointStore = (*MemPointStore)(nil)
+
+// NewMemPointStore new a point store kept in memory
+func NewMemPointStore() *MemPointStore {
+	return &MemPointStore{
+		points: make(map[asdu.CommonAddr]map[asdu.InfoObjAddr]Point),
+		groups: make(map[asdu.CommonAddr]map[asdu.InfoObjAddr]asdu.QualifierOfInterrogation),
+	}
+}
+
+// Set add or update the point of the common address
+func (sf *MemPointStore) Set(ca asdu.CommonAddr, p Point) error {
+	ioa, ok := pointIoa(p)
+	if !ok {
+		return ErrPointType
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	points, ok := sf.points[ca]
+	if !ok {
+		points = make(map[asdu.InfoObjAddr]Point)
+		sf.points[ca] = points
+	}
+	points[ioa] = p
+	return nil
+}
+
+// Remove the point of the information object address in the common address, and its interrogation group
+func (sf *MemPointStore) Remove(ca asdu.CommonAddr, ioa asdu.InfoObjAddr) {
+	sf.mux.Lock()
+	delete(sf.points[ca], ioa)
+	delete(sf.groups[ca], ioa)
+	sf.mux.Unlock()
+}
+
+// Point returns the point of the information object address in the common address
+func (sf *MemPointStore) Point(ca asdu.CommonAddr, ioa asdu.InfoObjAddr) (Point, bool) {
+	sf.mux.RLock()
+	defer sf.mux.RUnlock()
+	p, ok := sf.points[ca][ioa]
+	return p, ok
+}
+
+// Readdress rewrite the information object addresses of the points in the common address, and of their
+// interrogation groups, by the mapping, nothing is changed if two points would share the same address.
+func (sf *MemPointStore) Readdress(ca asdu.CommonAddr, m asdu.IOAMap) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	points := make(map[asdu.InfoObjAddr]Point, len(sf.points[ca]))
+	for ioa, p := range sf.points[ca] {
+		ioa = m.Map(ioa)
+		if _, ok := points[ioa]; ok {
+			return asdu.IOACollisionError{Addr: ioa}
+		}
+		points[ioa] = setPointIoa(p, ioa)
+	}
+	if len(points) > 0 {
+		sf.points[ca] = points
+	}
+	if len(sf.groups[ca]) > 0 {
+		groups := make(map[asdu.InfoObjAddr]asdu.QualifierOfInterrogation, len(sf.groups[ca]))
+		for ioa, g := range sf.groups[ca] {
+			groups[m.Map(ioa)] = g
+		}
+		sf.groups[ca] = groups
+	}
+	return nil
+}
+
+// setPointIoa returns the point with the information object address
+func setPointIoa(p Point, ioa asdu.InfoObjAddr) Point {
+	switch v := p.Info.(type) {
+	case asdu.SinglePointInfo:
+		v.Ioa = ioa
+		p.Info = v
+	case asdu.DoublePointInfo:
+		v.Ioa = ioa
+		p.Info = v
+	case asdu.StepPositionInfo:
+		v.Ioa = ioa
+		p.Info = v
+	case asdu.BitString32Info:
+		v.Ioa = ioa
+		p.Info = v
+	case asdu.MeasuredValueNormalInfo:
+		v.Ioa = ioa
+		p.Info = v
+	case asdu.MeasuredValueScaledInfo:
+		v.Ioa = ioa
+		p.Info = v
+	case asdu.MeasuredValueFloatInfo:
+		v.Ioa = ioa
+		p.Info = v
+	}
+	return p
+}
+
+// pointIoa returns the information object address of the point,
+// false if the information does not match the type identification.
+func pointIoa(p Point) (asdu.InfoObjAddr, bool) {
+	switch v := p.Info.(type) {
+	case asdu.SinglePointInfo:
+		return v.Ioa, p.Type == asdu.M_SP_NA_1 || p.Type == asdu.M_SP_TA_1 || p.Type == asdu.M_SP_TB_1
+	case asdu.DoublePointInfo:
+		return v.Ioa, p.Type == asdu.M_DP_NA_1 || p.Type == asdu.M_DP_TA_1 || p.Type == asdu.M_DP_TB_1
+	case asdu.StepPositionInfo:
+		return v.Ioa, p.Type == asdu.M_ST_NA_1 || p.Type == asdu.M_ST_TA_1 || p.Type == asdu.M_ST_TB_1
+	case asdu.BitString32Info:
+		return v.Ioa, p.Type == asdu.M_BO_NA_1 || p.Type == asdu.M_BO_TA_1 || p.Type == asdu.M_BO_TB_1
+	case asdu.MeasuredValueNormalInfo:
+		return v.Ioa, p.Type == asdu.M_ME_NA_1 || p.Type == asdu.M_ME_TA_1 ||
+			p.Type == asdu.M_ME_TD_1 || p.Type == asdu.M_ME_ND_1
+	case asdu.MeasuredValueScaledInfo:
+		return v.Ioa, p.Type == asdu.M_ME_NB_1 || p.Type == asdu.M_ME_TB_1 || p.Type == asdu.M_ME_TE_1
+	case asdu.MeasuredValueFloatInfo:
+		return v.Ioa, p.Type == asdu.M_ME_NC_1 || p.Type == asdu.M_ME_TC_1 || p.Type == asdu.M_ME_TF_1
+	}
+	return 0, false
+}
+
+// SendPoint send the point with the monitor asdu of its type identification
+func SendPoint(c asdu.Connect, coa asdu.CauseOfTransmission, ca asdu.CommonAddr, p Point) error {
+	switch v := p.Info.(type) {
+	case asdu.SinglePointInfo:
+		switch p.Type {
+		case asdu.M_SP_NA_1:
+			return asdu.Single(c, false, coa, ca, v)
+		case asdu.M_SP_TA_1:
+			return asdu.SingleCP24Time2a(c, coa, ca, v)
+		case asdu.M_SP_TB_1:
+			return asdu.SingleCP56Time2a(c, coa, ca, v)
+		}
+	case asdu.DoublePointInfo:
+		switch p.Type {
+		case asdu.M_DP_NA_1:
+			return asdu.Double(c, false, coa, ca, v)
+		case asdu.M_DP_TA_1:
+			return asdu.DoubleCP24Time2a(c, coa, ca, v)
+		case asdu.M_DP_TB_1:
+			return asdu.DoubleCP56Time2a(c, coa, ca, v)
+		}
+	case asdu.StepPositionInfo:
+		switch p.Type {
+		case asdu.M_ST_NA_1:
+			return asdu.Step(c, false, coa, ca, v)
+		case asdu.M_ST_TA_1:
+			return asdu.StepCP24Time2a(c, coa, ca, v)
+		case asdu.M_ST_TB_1:
+			return asdu.StepCP56Time2a(c, coa, ca, v)
+		}
+	case asdu.BitString32Info:
+		switch p.Type {
+		case asdu.M_BO_NA_1:
+			return asdu.BitString32(c, false, coa, ca, v)
+		case asdu.M_BO_TA_1:
+			return asdu.BitString32CP24Time2a(c, coa, ca, v)
+		case asdu.M_BO_TB_1:
+			return asdu.BitString32CP56Time2a(c, coa, ca, v)
+		}
+	case asdu.MeasuredValueNormalInfo:
+		switch p.Type {
+		case asdu.M_ME_NA_1:
+			return asdu.MeasuredValueNormal(c, false, coa, ca, v)
+		case asdu.M_ME_TA_1:
+			return asdu.MeasuredValueNormalCP24Time2a(c, coa, ca, v)
+		case asdu.M_ME_TD_1:
+			return asdu.MeasuredValueNormalCP56Time2a(c, coa, ca, v)
+		case asdu.M_ME_ND_1:
+			return asdu.MeasuredValueNormalNoQuality(c, false, coa, ca, v)
+		}
+	case asdu.MeasuredValueScaledInfo:
+		switch p.Type {
+		case asdu.M_ME_NB_1:
+			return asdu.MeasuredValueScaled(c, false, coa, ca, v)
+		case asdu.M_ME_TB_1:
+			return asdu.MeasuredValueScaledCP24Time2a(c, coa, ca, v)
+		case asdu.M_ME_TE_1:
+			return asdu.MeasuredValueScaledCP56Time2a(c, coa, ca, v)
+		}
+	case asdu.MeasuredValueFloatInfo:
+		switch p.Type {
+		case asdu.M_ME_NC_1:
+			return asdu.MeasuredValueFloat(c, false, coa, ca, v)
+		case asdu.M_ME_TC_1:
+			return asdu.MeasuredValueFloatCP24Time2a(c, coa, ca, v)
+		case asdu.M_ME_TF_1:
+			return asdu.MeasuredValueFloatCP56Time2a(c, coa, ca, v)
+		}
+	}
+	return ErrPointType
+}
+
+// readHandler answer the read command [C_RD_NA_1] from the point store
+func readHandler(c asdu.Connect, pack *asdu.ASDU, store PointStore) error {
+	reply := pack.Clone() // decoding consumes the information object
+	p, ok := store.Point(pack.CommonAddr, pack.GetReadCmd())
+	if !ok {
+		return negativeMirror(c, reply, asdu.UnknownIOA)
+	}
+	return SendPoint(c, asdu.CauseOfTransmission{Cause: asdu.Request}, pack.CommonAddr, p)
+}
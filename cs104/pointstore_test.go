@@ -0,0 +1,81 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestMemPointStore_Set(t *testing.T) {
+	store := NewMemPointStore()
+	if err := store.Set(1, Point{asdu.M_ME_NC_1, asdu.SinglePointInfo{Ioa: 1}}); err != ErrPointType {
+		t.Errorf("Set() error = %v, want %v", err, ErrPointType)
+	}
+	if err := store.Set(1, Point{asdu.M_SP_NA_1, asdu.SinglePointInfo{Ioa: 1, Value: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Point(2, 1); ok {
+		t.Error("Point() found a point of another common address")
+	}
+	store.Remove(1, 1)
+	if _, ok := store.Point(1, 1); ok {
+		t.Error("Point() found a removed point")
+	}
+}
+
+func TestMemPointStore_Readdress(t *testing.T) {
+	store := NewMemPointStore()
+	_ = store.Set(1, Point{asdu.M_SP_NA_1, asdu.SinglePointInfo{Ioa: 1}})
+	_ = store.Set(1, Point{asdu.M_SP_NA_1, asdu.SinglePointInfo{Ioa: 2}})
+
+	if err := store.Readdress(1, asdu.IOAMap{1: 2}); err != (asdu.IOACollisionError{Addr: 2}) {
+		t.Fatalf("Readdress() error = %v, want collision", err)
+	}
+	if err := store.Readdress(1, asdu.IOAMap{1: 2, 2: 101}); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := store.Point(1, 101); !ok || p.Info.(asdu.SinglePointInfo).Ioa != 101 {
+		t.Errorf("Point(101) = %v, %v", p, ok)
+	}
+	if p, ok := store.Point(1, 2); !ok || p.Info.(asdu.SinglePointInfo).Ioa != 2 {
+		t.Errorf("Point(2) = %v, %v", p, ok)
+	}
+	if _, ok := store.Point(1, 1); ok {
+		t.Error("Point(1) found after re-addressing")
+	}
+}
+
+func TestReadHandler(t *testing.T) {
+	c := &recordConn{}
+	store := NewMemPointStore()
+	if err := store.Set(1, Point{asdu.M_ME_NC_1, asdu.MeasuredValueFloatInfo{Ioa: 100, Value: 1.5}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ioa := range []asdu.InfoObjAddr{100, 200} {
+		if err := asdu.ReadCmd(c, asdu.CauseOfTransmission{Cause: asdu.Request}, 1, ioa); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reqs := c.take()
+
+	if err := readHandler(c, reqs[0], store); err != nil {
+		t.Fatal(err)
+	}
+	sent := c.take()
+	if len(sent) != 1 || sent[0].Type != asdu.M_ME_NC_1 || sent[0].Coa.Cause != asdu.Request {
+		t.Fatalf("readHandler() sent %v", sent)
+	}
+	if info := sent[0].GetMeasuredValueFloat(); info[0].Ioa != 100 || info[0].Value != 1.5 {
+		t.Errorf("readHandler() sent %v", info)
+	}
+
+	if err := readHandler(c, reqs[1], store); err != nil {
+		t.Fatal(err)
+	}
+	want := asdu.CauseOfTransmission{Cause: asdu.UnknownIOA, IsNegative: true}
+	if sent = c.take(); len(sent) != 1 || sent[0].Type != asdu.C_RD_NA_1 || sent[0].Coa != want {
+		t.Errorf("readHandler() sent %v, want %v", sent, want)
+	} else if ioa := sent[0].GetReadCmd(); ioa != 200 {
+		t.Errorf("readHandler() negative reply ioa = %v, want 200", ioa)
+	}
+}
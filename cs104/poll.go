@@ -0,0 +1,248 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// DefaultPollTimeout the default time a poll waits for its termination
+const DefaultPollTimeout = time.Minute
+
+// ErrPollOverlap the poll is skipped since the previous poll of its type is not terminated
+var ErrPollOverlap = asdu.NewError(asdu.ErrPeer, "poll: previous poll not terminated")
+
+// IntegrityPoll the schedule of the integrity polls of a controlling station, see NewIntegrityPoller
+type IntegrityPoll struct {
+	// CommonAddr the common address polled, asdu.GlobalCommonAddr for every station
+	CommonAddr asdu.CommonAddr
+	// Interrogation period of the general interrogation, 0 disables
+	Interrogation time.Duration
+	// Counter period of the counter interrogation, the polls fall on the boundaries of the period
+	// since the local midnight, such as every hour on the hour, 0 disables.
+	// The counters are frozen first, then read once the freeze is terminated.
+	Counter time.Duration
+	// Reset the counters are frozen with reset, the readings are the increments of the period
+	Reset bool
+	// Jitter maximum random delay added to each poll, spreads the polls of the stations
+	Jitter time.Duration
+	// Timeout the time a poll waits for its termination, 0 for DefaultPollTimeout
+	Timeout time.Duration
+	// OnPoll called once a poll of the type identification [C_IC_NA_1] or [C_CI_NA_1] is done,
+	// err is ErrPollOverlap if it is skipped, ErrNegativeConfirm if it is refused,
+	// ErrConfirmTimeout if it is not terminated in time, or the error of the request not sent.
+	OnPoll func(c *Client, typeID asdu.TypeID, err error)
+}
+
+// pollRequest a poll queued or in progress
+type pollRequest struct {
+	typeID asdu.TypeID
+	freeze asdu.QCCFreeze
+}
+
+// IntegrityPoller the scheduler of the integrity polls of a client, replacing the application timers.
+// One poll is in progress at a time, the polls falling due meanwhile wait for its termination,
+// a poll is skipped if the previous poll of its type is still waiting.
+type IntegrityPoller struct {
+	IntegrityPoll
+	client *Client
+	clock  asdu.Clock
+	done   chan asdu.Identifier // the terminations received
+
+	running  *pollRequest
+	deadline time.Time
+	queue    []pollRequest
+}
+
+// NewIntegrityPoller new the poller of the client, it must be called before the client Start
+func NewIntegrityPoller(c *Client, p IntegrityPoll) *IntegrityPoller {
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultPollTimeout
+	}
+	sf := &IntegrityPoller{
+		IntegrityPoll: p,
+		client:        c,
+		clock:         c.option.params.Clock,
+		done:          make(chan asdu.Identifier, 16),
+	}
+	if sf.clock == nil {
+		sf.clock = asdu.SystemClock
+	}
+	c.UseReceive(sf.observe)
+	return sf
+}
+
+// observe the receive interceptor passing the terminations of the polls to Run
+func (sf *IntegrityPoller) observe(next ASDUHandlerFunc) ASDUHandlerFunc {
+	return func(c asdu.Connect, a *asdu.ASDU) error {
+		if (a.Type == asdu.C_IC_NA_1 || a.Type == asdu.C_CI_NA_1) &&
+			(sf.CommonAddr == asdu.GlobalCommonAddr || a.CommonAddr == sf.CommonAddr) &&
+			(a.Coa.Cause == asdu.ActivationTerm || (a.Coa.Cause == asdu.ActivationCon && a.Coa.IsNegative)) {
+			select {
+			case sf.done <- a.Identifier:
+			default:
+			}
+		}
+		return next(c, a)
+	}
+}
+
+// Run schedule the polls until ctx is done
+func (sf *IntegrityPoller) Run(ctx context.Context) error {
+	var nextGI, nextCI time.Time
+	now := sf.clock.Now()
+	giBase, ciBase := now, now
+	if sf.Interrogation > 0 {
+		giBase = now.Add(sf.Interrogation)
+		nextGI = giBase.Add(sf.jitter())
+	}
+	if sf.Counter > 0 {
+		ciBase = nextBoundary(now, sf.Counter)
+		nextCI = ciBase.Add(sf.jitter())
+	}
+	timer := sf.clock.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		now = sf.clock.Now()
+		if !nextGI.IsZero() && !now.Before(nextGI) {
+			sf.due(pollRequest{asdu.C_IC_NA_1, asdu.QCCFrzRead})
+			for !giBase.After(now) {
+				giBase = giBase.Add(sf.Interrogation)
+			}
+			nextGI = giBase.Add(sf.jitter())
+		}
+		if !nextCI.IsZero() && !now.Before(nextCI) {
+			freeze := asdu.QCCFrzFreezeNoReset
+			if sf.Reset {
+				freeze = asdu.QCCFrzFreezeReset
+			}
+			sf.due(pollRequest{asdu.C_CI_NA_1, freeze})
+			ciBase = nextBoundary(now, sf.Counter)
+			nextCI = ciBase.Add(sf.jitter())
+		}
+		if sf.running != nil && !now.Before(sf.deadline) {
+			sf.finish(ErrConfirmTimeout)
+		}
+
+		wake := now.Add(time.Hour)
+		for _, t := range []time.Time{nextGI, nextCI} {
+			if !t.IsZero() && t.Before(wake) {
+				wake = t
+			}
+		}
+		if sf.running != nil && sf.deadline.Before(wake) {
+			wake = sf.deadline
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(wake.Sub(now))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case id := <-sf.done:
+			sf.terminate(id)
+		case <-timer.C():
+		}
+	}
+}
+
+// due queue the poll, skipped if a poll of its type is waiting
+func (sf *IntegrityPoller) due(req pollRequest) {
+	overlap := sf.running != nil && sf.running.typeID == req.typeID
+	for _, q := range sf.queue {
+		overlap = overlap || q.typeID == req.typeID
+	}
+	if overlap {
+		sf.report(req.typeID, ErrPollOverlap)
+		return
+	}
+	sf.queue = append(sf.queue, req)
+	sf.next()
+}
+
+// next start the first poll queued if none is in progress
+func (sf *IntegrityPoller) next() {
+	for sf.running == nil && len(sf.queue) > 0 {
+		req := sf.queue[0]
+		sf.queue = sf.queue[1:]
+		sf.start(req)
+	}
+}
+
+// start send the request of the poll
+func (sf *IntegrityPoller) start(req pollRequest) {
+	coa := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	var err error
+	if req.typeID == asdu.C_IC_NA_1 {
+		err = sf.client.InterrogationCmd(coa, sf.CommonAddr, asdu.QOIStation)
+	} else {
+		err = sf.client.CounterInterrogationCmd(coa, sf.CommonAddr,
+			asdu.QualifierCountCall{Request: asdu.QCCTotal, Freeze: req.freeze})
+	}
+	if err != nil {
+		sf.report(req.typeID, err)
+		return
+	}
+	sf.running = &req
+	sf.deadline = sf.clock.Now().Add(sf.Timeout)
+}
+
+// terminate handle the termination of the poll in progress, the counters frozen are read next
+func (sf *IntegrityPoller) terminate(id asdu.Identifier) {
+	if sf.running == nil || sf.running.typeID != id.Type {
+		return
+	}
+	if id.Coa.IsNegative {
+		sf.finish(ErrNegativeConfirm)
+		return
+	}
+	if sf.running.freeze != asdu.QCCFrzRead {
+		sf.running = nil
+		sf.start(pollRequest{asdu.C_CI_NA_1, asdu.QCCFrzRead})
+		sf.next()
+		return
+	}
+	sf.finish(nil)
+}
+
+// finish the poll in progress and start the next one
+func (sf *IntegrityPoller) finish(err error) {
+	typeID := sf.running.typeID
+	sf.running = nil
+	sf.report(typeID, err)
+	sf.next()
+}
+
+func (sf *IntegrityPoller) report(typeID asdu.TypeID, err error) {
+	if err != nil {
+		sf.client.Warn("integrity poll %v of common address %d: %v", typeID, sf.CommonAddr, err)
+	}
+	if sf.OnPoll != nil {
+		sf.OnPoll(sf.client, typeID, err)
+	}
+}
+
+func (sf *IntegrityPoller) jitter() time.Duration {
+	if sf.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(sf.Jitter)))
+}
+
+// nextBoundary returns the first boundary of the period since the local midnight after t
+func nextBoundary(t time.Time, period time.Duration) time.Time {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	return midnight.Add((t.Sub(midnight)/period + 1) * period)
+}
@@ -0,0 +1,125 @@
+package cs104
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type pollServerHandler struct {
+	autoServerHandler
+	hold    bool // never terminate the general interrogation
+	freezes chan asdu.QCCFreeze
+}
+
+func (sf *pollServerHandler) InterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfInterrogation) error {
+	return sf.answer(c, pack, !sf.hold)
+}
+
+func (sf *pollServerHandler) CounterInterrogationHandler(c asdu.Connect, pack *asdu.ASDU, qcc asdu.QualifierCountCall) error {
+	sf.freezes <- qcc.Freeze
+	return sf.answer(c, pack, true)
+}
+
+type pollEvent struct {
+	typeID asdu.TypeID
+	err    error
+}
+
+func startPoller(t *testing.T, sh *pollServerHandler, p IntegrityPoll) (<-chan pollEvent, context.Context) {
+	events := make(chan pollEvent, 64)
+	p.CommonAddr = 1
+	p.OnPoll = func(_ *Client, typeID asdu.TypeID, err error) { events <- pollEvent{typeID, err} }
+	pipe := NewPipe(NewServer(sh), NewTypedClientHandler(&ClientHandlerBase{}), nil)
+	poller := NewIntegrityPoller(pipe.Client, p)
+	t.Cleanup(func() { _ = pipe.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	if err := pipe.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pipe.Client.SendStartDt()
+	for atomic.LoadUint32(&pipe.Client.isActive) != active {
+		if ctx.Err() != nil {
+			t.Fatal("STARTDT not confirmed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	go func() { _ = poller.Run(ctx) }()
+	return events, ctx
+}
+
+func TestIntegrityPoller(t *testing.T) {
+	sh := &pollServerHandler{freezes: make(chan asdu.QCCFreeze, 64)}
+	events, ctx := startPoller(t, sh, IntegrityPoll{
+		Interrogation: 20 * time.Millisecond,
+		Counter:       50 * time.Millisecond,
+		Jitter:        5 * time.Millisecond,
+	})
+
+	var gi, ci bool
+	for !gi || !ci {
+		select {
+		case e := <-events:
+			if e.err != nil {
+				t.Fatalf("poll %v error = %v", e.typeID, e.err)
+			}
+			gi = gi || e.typeID == asdu.C_IC_NA_1
+			ci = ci || e.typeID == asdu.C_CI_NA_1
+		case <-ctx.Done():
+			t.Fatalf("polls not done, interrogation %v, counter %v", gi, ci)
+		}
+	}
+	if f := <-sh.freezes; f != asdu.QCCFrzFreezeNoReset {
+		t.Errorf("first counter interrogation freeze = %#x, want freeze without reset", f)
+	}
+	if f := <-sh.freezes; f != asdu.QCCFrzRead {
+		t.Errorf("second counter interrogation freeze = %#x, want read", f)
+	}
+}
+
+func TestIntegrityPoller_Overlap(t *testing.T) {
+	sh := &pollServerHandler{hold: true}
+	events, ctx := startPoller(t, sh, IntegrityPoll{
+		Interrogation: 20 * time.Millisecond,
+		Timeout:       70 * time.Millisecond,
+	})
+
+	var overlap bool
+	for {
+		select {
+		case e := <-events:
+			switch e.err {
+			case ErrPollOverlap:
+				overlap = true
+			case ErrConfirmTimeout:
+				if !overlap {
+					t.Error("poll timed out without overlapping poll skipped")
+				}
+				return
+			default:
+				t.Fatalf("poll %v error = %v", e.typeID, e.err)
+			}
+		case <-ctx.Done():
+			t.Fatal("poll not timed out")
+		}
+	}
+}
+
+func TestNextBoundary(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 7, 30, 0, time.Local)
+	if got, want := nextBoundary(at, 15*time.Minute), time.Date(2024, 5, 1, 10, 15, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("nextBoundary(15m) = %v, want %v", got, want)
+	}
+	if got, want := nextBoundary(at, time.Hour), time.Date(2024, 5, 1, 11, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("nextBoundary(1h) = %v, want %v", got, want)
+	}
+	on := time.Date(2024, 5, 1, 11, 0, 0, 0, time.Local)
+	if got, want := nextBoundary(on, time.Hour), time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("nextBoundary(on the hour) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// asduBufferSize the capacity of the pooled asdu buffers, one more than asdu.ASDUSizeMax
+// so that they are told from the asdu data which are not pooled.
+const asduBufferSize = asdu.ASDUSizeMax + 1
+
+var (
+	asduBufferPool = sync.Pool{New: func() interface{} { return new([asduBufferSize]byte) }}
+	framePool      = sync.Pool{New: func() interface{} { return new([APDUSizeMax]byte) }}
+)
+
+// getASDUBuffer returns an empty pooled buffer for a marshaled asdu
+func getASDUBuffer() []byte {
+	return asduBufferPool.Get().(*[asduBufferSize]byte)[:0]
+}
+
+// putASDUBuffer put the buffer back to the pool if it is a pooled one
+func putASDUBuffer(b []byte) {
+	if cap(b) == asduBufferSize {
+		asduBufferPool.Put((*[asduBufferSize]byte)(b[:asduBufferSize]))
+	}
+}
+
+// getFrame returns a pooled buffer of n bytes for an apdu
+func getFrame(n int) []byte {
+	return framePool.Get().(*[APDUSizeMax]byte)[:n]
+}
+
+// putFrame put the apdu back to the pool if it is a pooled one
+func putFrame(b []byte) {
+	if cap(b) == APDUSizeMax {
+		framePool.Put((*[APDUSizeMax]byte)(b[:APDUSizeMax]))
+	}
+}
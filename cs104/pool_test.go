@@ -0,0 +1,46 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func Test_putASDUBuffer(t *testing.T) {
+	b := append(getASDUBuffer(), 1, 2, 3)
+	if cap(b) != asduBufferSize {
+		t.Fatalf("getASDUBuffer() cap %d, want %d", cap(b), asduBufferSize)
+	}
+	putASDUBuffer(b)
+	putASDUBuffer(make([]byte, 3)) // not pooled, ignored
+	putFrame(newSFrame(1))         // not pooled, ignored
+	if f := getFrame(6); len(f) != 6 || cap(f) != APDUSizeMax {
+		t.Errorf("getFrame() len %d cap %d", len(f), cap(f))
+	}
+}
+
+func BenchmarkIFrame(b *testing.B) {
+	id := asdu.Identifier{
+		Type:       asdu.M_ME_NC_1,
+		Variable:   asdu.VariableStruct{Number: 1},
+		Coa:        asdu.CauseOfTransmission{Cause: asdu.Spontaneous},
+		CommonAddr: 1,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a := asdu.AcquireASDU(asdu.ParamsWide, id)
+		_ = a.AppendInfoObjAddr(100)
+		a.AppendFloat32(1.5).AppendBytes(0)
+		data, err := a.AppendBinary(getASDUBuffer())
+		asdu.ReleaseASDU(a)
+		if err != nil {
+			b.Fatal(err)
+		}
+		frame, err := newIFrame(uint16(i), 0, data)
+		putASDUBuffer(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		putFrame(frame)
+	}
+}
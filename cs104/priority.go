@@ -0,0 +1,55 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Priority the transmit priority of the outgoing asdu, the queued asdu of higher priority
+// preempt those of lower priority. The sequence of events always goes first.
+type Priority int
+
+// Priority defined
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// DefaultPriority the default transmit priority:
+//
+//	high: the commands and their confirmations, include the clock synchronization
+//	low: the interrogated, periodic and background data
+//	normal: any other, for example the spontaneous data
+func DefaultPriority(a *asdu.ASDU) Priority {
+	if a.Type >= asdu.C_SC_NA_1 {
+		return PriorityHigh
+	}
+	switch cause := a.Coa.Cause; {
+	case cause == asdu.Periodic || cause == asdu.Background,
+		cause >= asdu.InterrogatedByStation && cause <= asdu.RequestByGroup4Counter:
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// PriorityTable the transmit priority configured per type identification or per cause of transmission,
+// the type identification takes precedence, neither configured falls back to DefaultPriority.
+type PriorityTable struct {
+	TypeID map[asdu.TypeID]Priority
+	Cause  map[asdu.Cause]Priority
+}
+
+// Priority returns the transmit priority of the asdu
+func (sf PriorityTable) Priority(a *asdu.ASDU) Priority {
+	if p, ok := sf.TypeID[a.Type]; ok {
+		return p
+	}
+	if p, ok := sf.Cause[a.Coa.Cause]; ok {
+		return p
+	}
+	return DefaultPriority(a)
+}
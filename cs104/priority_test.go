@@ -0,0 +1,35 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSrvSession_priority(t *testing.T) {
+	sess := newTestSession(&mockServerHandler{})
+	sess.priority = PriorityTable{}.Priority
+	sess.sendHigh = make(chan []byte, 16)
+	sess.sendLow = make(chan []byte, 16)
+
+	_ = asdu.Single(sess, false, asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}, 1, asdu.SinglePointInfo{Ioa: 1})
+	_ = asdu.Single(sess, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.SinglePointInfo{Ioa: 2})
+	_ = asdu.ClockSynchronizationCmd(sess, asdu.CauseOfTransmission{}, 1, time.Now())
+
+	want := []asdu.TypeID{asdu.C_CS_NA_1, asdu.M_SP_NA_1, asdu.M_SP_NA_1}
+	wantCause := []asdu.Cause{asdu.Activation, asdu.Spontaneous, asdu.InterrogatedByStation}
+	for i := range want {
+		data, ok := sess.nextASDU()
+		if !ok {
+			t.Fatalf("nextASDU() %d empty", i)
+		}
+		a := asdu.NewEmptyASDU(sess.params)
+		if err := a.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if a.Type != want[i] || a.Coa.Cause != wantCause[i] {
+			t.Errorf("nextASDU() %d got %v, want %v %v", i, a.Identifier, want[i], wantCause[i])
+		}
+	}
+}
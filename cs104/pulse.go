@@ -0,0 +1,149 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrPulseActive the object is executing a pulse already
+var ErrPulseActive = errors.New("pulse output of the object active")
+
+// pulse durations defined
+const (
+	DefaultShortPulse = 500 * time.Millisecond
+	DefaultLongPulse  = 2 * time.Second
+)
+
+// PulseOutput the outputs of the controlled station driven by the pulse commands
+type PulseOutput interface {
+	// SetOutput energize (on) or release the output of the object commanded to the state, the states are
+	// byte(asdu.DCOOn) and byte(asdu.DCOOff) of a double command, byte(asdu.SCOStepDown) and byte(asdu.SCOStepUP)
+	// of a regulating step command. An error energizing is a negative confirmation.
+	SetOutput(ca asdu.CommonAddr, ioa asdu.InfoObjAddr, state byte, on bool) error
+}
+
+// PulseOutputFunc an adapter to allow the use of ordinary functions as PulseOutput
+type PulseOutputFunc func(ca asdu.CommonAddr, ioa asdu.InfoObjAddr, state byte, on bool) error
+
+// SetOutput calls f(ca, ioa, state, on)
+func (f PulseOutputFunc) SetOutput(ca asdu.CommonAddr, ioa asdu.InfoObjAddr, state byte, on bool) error {
+	return f(ca, ioa, state, on)
+}
+
+// PulseExecutor executes the double commands [C_DC_NA_1] and the regulating step commands [C_RC_NA_1]
+// qualified short pulse or long pulse: it energizes the output for the pulse duration configured, then
+// releases it and sends the activation termination, the positive confirmation is sent at once.
+// The selects, the deactivations, the other qualifiers and the other commands go to the next handler.
+type PulseExecutor struct {
+	ServerCommandHandler
+	output PulseOutput
+
+	mux    sync.Mutex
+	short  time.Duration
+	long   time.Duration
+	active map[commandKey]struct{}
+	wg     sync.WaitGroup
+}
+
+var _ ServerCommandHandler = (*PulseExecutor)(nil)
+
+// NewPulseExecutor new a pulse executor driving the output, the commands it does not execute go to next
+func NewPulseExecutor(output PulseOutput, next ServerCommandHandler) *PulseExecutor {
+	return &PulseExecutor{
+		ServerCommandHandler: next,
+		output:               output,
+		short:                DefaultShortPulse,
+		long:                 DefaultLongPulse,
+		active:               make(map[commandKey]struct{}),
+	}
+}
+
+// SetPulseDurations set the durations of the short pulse and the long pulse, a zero keeps the current one
+func (sf *PulseExecutor) SetPulseDurations(short, long time.Duration) *PulseExecutor {
+	sf.mux.Lock()
+	if short > 0 {
+		sf.short = short
+	}
+	if long > 0 {
+		sf.long = long
+	}
+	sf.mux.Unlock()
+	return sf
+}
+
+// Wait for the pulses running to complete
+func (sf *PulseExecutor) Wait() {
+	sf.wg.Wait()
+}
+
+// OnDoubleCommand imp interface ServerCommandHandler
+func (sf *PulseExecutor) OnDoubleCommand(c asdu.Connect, id asdu.Identifier, v asdu.DoubleCommandInfo) error {
+	if v.Value != asdu.DCOOn && v.Value != asdu.DCOOff {
+		return sf.ServerCommandHandler.OnDoubleCommand(c, id, v)
+	}
+	return sf.pulse(c, id, v.Ioa, byte(v.Value), v.Qoc, func() error {
+		return sf.ServerCommandHandler.OnDoubleCommand(c, id, v)
+	})
+}
+
+// OnStepCommand imp interface ServerCommandHandler
+func (sf *PulseExecutor) OnStepCommand(c asdu.Connect, id asdu.Identifier, v asdu.StepCommandInfo) error {
+	if v.Value != asdu.SCOStepDown && v.Value != asdu.SCOStepUP {
+		return sf.ServerCommandHandler.OnStepCommand(c, id, v)
+	}
+	return sf.pulse(c, id, v.Ioa, byte(v.Value), v.Qoc, func() error {
+		return sf.ServerCommandHandler.OnStepCommand(c, id, v)
+	})
+}
+
+// pulse energize the output and release it after the pulse duration in the background,
+// next handles the command which is not a pulse execution
+func (sf *PulseExecutor) pulse(c asdu.Connect, id asdu.Identifier, ioa asdu.InfoObjAddr, state byte,
+	qoc asdu.QualifierOfCommand, next func() error) error {
+	if qoc.InSelect || id.Coa.Cause != asdu.Activation ||
+		qoc.Qual != asdu.QOCShortPulseDuration && qoc.Qual != asdu.QOCLongPulseDuration {
+		return next()
+	}
+	term := Termination(c)
+	key := commandKey{id.CommonAddr, ioa}
+
+	sf.mux.Lock()
+	if _, ok := sf.active[key]; ok {
+		sf.mux.Unlock()
+		return ErrPulseActive
+	}
+	d := sf.short
+	if qoc.Qual == asdu.QOCLongPulseDuration {
+		d = sf.long
+	}
+	sf.active[key] = struct{}{}
+	sf.mux.Unlock()
+
+	if err := sf.output.SetOutput(id.CommonAddr, ioa, state, true); err != nil {
+		sf.release(key)
+		return err
+	}
+	sf.wg.Add(1)
+	time.AfterFunc(d, func() {
+		defer sf.wg.Done()
+		_ = sf.output.SetOutput(id.CommonAddr, ioa, state, false)
+		sf.release(key)
+		if term != nil {
+			_ = term()
+		}
+	})
+	return ErrPending
+}
+
+func (sf *PulseExecutor) release(key commandKey) {
+	sf.mux.Lock()
+	delete(sf.active, key)
+	sf.mux.Unlock()
+}
@@ -0,0 +1,85 @@
+package cs104
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+type pulseEvent struct {
+	ioa   asdu.InfoObjAddr
+	state byte
+	on    bool
+	at    time.Time
+}
+
+type recordOutput struct {
+	mux    sync.Mutex
+	events []pulseEvent
+}
+
+func (sf *recordOutput) SetOutput(_ asdu.CommonAddr, ioa asdu.InfoObjAddr, state byte, on bool) error {
+	sf.mux.Lock()
+	sf.events = append(sf.events, pulseEvent{ioa, state, on, time.Now()})
+	sf.mux.Unlock()
+	return nil
+}
+
+func TestPulseExecutor(t *testing.T) {
+	out := &recordOutput{}
+	pe := NewPulseExecutor(out, &mockCommandHandler{}).SetPulseDurations(20*time.Millisecond, 50*time.Millisecond)
+	h := commandConfirmHandler{pe}
+
+	c := &recordConn{}
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	long := asdu.QualifierOfCommand{Qual: asdu.QOCLongPulseDuration}
+	_ = asdu.DoubleCmd(c, asdu.C_DC_NA_1, act, 1, asdu.DoubleCommandInfo{Ioa: 1, Value: asdu.DCOOn, Qoc: long})
+	_ = asdu.DoubleCmd(c, asdu.C_DC_NA_1, act, 1, asdu.DoubleCommandInfo{Ioa: 1, Value: asdu.DCOOff, Qoc: long})
+	_ = asdu.StepCmd(c, asdu.C_RC_NA_1, act, 1, asdu.StepCommandInfo{Ioa: 2, Value: asdu.SCOStepUP,
+		Qoc: asdu.QualifierOfCommand{Qual: asdu.QOCShortPulseDuration}})
+	reqs := c.take()
+
+	start := time.Now()
+	for i, req := range reqs {
+		if err := confirmDispatch(c, h, req); (i == 1) != (err == ErrPulseActive) {
+			t.Fatalf("confirmDispatch() %d error = %v", i, err)
+		}
+	}
+	// confirmed at once, the second pulse on the same object refused
+	want := []asdu.CauseOfTransmission{{Cause: asdu.ActivationCon}, {Cause: asdu.ActivationCon, IsNegative: true}, {Cause: asdu.ActivationCon}}
+	if sent := c.take(); len(sent) != len(want) {
+		t.Fatalf("sent %d asdu, want %d", len(sent), len(want))
+	} else {
+		for i, a := range sent {
+			if a.Coa != want[i] {
+				t.Errorf("sent %v, want %v", a.Coa, want[i])
+			}
+		}
+	}
+
+	pe.Wait()
+	for _, a := range c.take() {
+		if a.Coa.Cause != asdu.ActivationTerm {
+			t.Errorf("sent %v after the pulse, want %v", a.Coa, asdu.ActivationTerm)
+		}
+	}
+	out.mux.Lock()
+	defer out.mux.Unlock()
+	if len(out.events) != 4 {
+		t.Fatalf("output events %+v", out.events)
+	}
+	for _, e := range out.events {
+		if e.on {
+			continue
+		}
+		d, min := e.at.Sub(start), 20*time.Millisecond
+		if e.ioa == 1 {
+			min = 50 * time.Millisecond
+		}
+		if d < min {
+			t.Errorf("output %d released after %v, want at least %v", e.ioa, d, min)
+		}
+	}
+}
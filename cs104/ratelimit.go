@@ -0,0 +1,120 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// RateLimit the rate of the outgoing asdu, zero is unlimited.
+// The burst allowed is one second of the rate, and at least one asdu of the maximum size of its params.
+type RateLimit struct {
+	ASDUs float64 // asdu per second
+	Bytes float64 // bytes per second
+}
+
+// rate class of the asdu
+const (
+	classMonitor = iota // process information in monitor direction
+	classCommand        // control and system information, type identification from [C_SC_NA_1]
+)
+
+// RateLimiter token bucket rate limiter of the outgoing asdu, with separate classes for the commands
+// and the monitor data. A RateLimiter shared by several connections limits their total rate.
+type RateLimiter struct {
+	buckets [2][2]*tokenBucket // [class][asdu, bytes]
+}
+
+// NewRateLimiter new a rate limiter with the limit of the monitor data and of the commands
+func NewRateLimiter(monitor, command RateLimit) *RateLimiter {
+	return &RateLimiter{
+		buckets: [2][2]*tokenBucket{
+			{newTokenBucket(monitor.ASDUs), newTokenBucket(monitor.Bytes)},
+			{newTokenBucket(command.ASDUs), newTokenBucket(command.Bytes)},
+		},
+	}
+}
+
+// Wait block until the asdu of size bytes is allowed to be sent, or the ctx is done
+func (sf *RateLimiter) Wait(ctx context.Context, a *asdu.ASDU, size int) error {
+	class := classMonitor
+	if a.Type >= asdu.C_SC_NA_1 {
+		class = classCommand
+	}
+	// a burst smaller than the asdu could never be satisfied
+	max := asdu.ASDUSizeMax
+	if a.Params != nil {
+		max = a.MaxASDUSize()
+	}
+	if size > max {
+		max = size
+	}
+	if err := sf.buckets[class][0].wait(ctx, 1, 1); err != nil {
+		return err
+	}
+	return sf.buckets[class][1].wait(ctx, float64(size), float64(max))
+}
+
+// tokenBucket a token bucket, the tokens are reserved in advance so the waiters are served in order
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mux    sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket new a full token bucket of the rate per second, nil if the rate is unlimited.
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{rate: rate, burst: rate, tokens: rate, last: time.Now()}
+}
+
+// wait reserve n tokens and block until they are available, the burst is raised to at least min.
+// The tokens are given back if ctx is done before.
+func (sf *tokenBucket) wait(ctx context.Context, n, min float64) error {
+	if sf == nil {
+		return nil
+	}
+	sf.mux.Lock()
+	if sf.burst < min {
+		sf.tokens += min - sf.burst
+		sf.burst = min
+	}
+	now := time.Now()
+	sf.tokens += now.Sub(sf.last).Seconds() * sf.rate
+	if sf.tokens > sf.burst {
+		sf.tokens = sf.burst
+	}
+	sf.last = now
+	sf.tokens -= n
+	deficit := -sf.tokens
+	sf.mux.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(deficit / sf.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		sf.mux.Lock()
+		sf.tokens += n
+		if sf.tokens > sf.burst {
+			sf.tokens = sf.burst
+		}
+		sf.mux.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
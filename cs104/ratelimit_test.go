@@ -0,0 +1,122 @@
+package cs104
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(RateLimit{ASDUs: 20}, RateLimit{})
+	monitor := &asdu.ASDU{Identifier: asdu.Identifier{Type: asdu.M_SP_NA_1}}
+	command := &asdu.ASDU{Identifier: asdu.Identifier{Type: asdu.C_SC_NA_1}}
+
+	start := time.Now()
+	for i := 0; i < 100; i++ { // commands are unlimited
+		if err := l.Wait(context.Background(), command, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 20; i++ { // the burst
+		if err := l.Wait(context.Background(), monitor, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("burst waited %v", d)
+	}
+	if err := l.Wait(context.Background(), monitor, 10); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("over the burst waited %v, want about 50ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, monitor, 10); err != context.Canceled {
+		t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRateLimiter_burstFitsASDU(t *testing.T) {
+	l := NewRateLimiter(RateLimit{Bytes: 10}, RateLimit{})
+	a := asdu.NewEmptyASDU(asdu.ParamsWide)
+	a.Type = asdu.M_SP_NA_1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, a, asdu.ASDUSizeMax); err != nil {
+		t.Fatalf("Wait() asdu of the maximum size error = %v", err)
+	}
+	if err := l.Wait(ctx, a, asdu.ASDUSizeMax); err != context.DeadlineExceeded {
+		t.Errorf("Wait() over the burst error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestTokenBucket_refundCanceled(t *testing.T) {
+	b := newTokenBucket(10)
+	if err := b.wait(context.Background(), 10, 0); err != nil { // the burst
+		t.Fatal(err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 5; i++ {
+		if err := b.wait(canceled, 10, 0); err != context.Canceled {
+			t.Fatalf("wait() error = %v, want %v", err, context.Canceled)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.wait(ctx, 1, 0); err != nil {
+		t.Errorf("wait() error = %v, want the tokens of the canceled waits given back", err)
+	}
+}
+
+func TestClient_rateLimitedSendCanceled(t *testing.T) {
+	cliEnd, srvEnd := net.Pipe()
+	defer srvEnd.Close()
+	o := NewOption().SetAutoReconnect(false).SetRateLimit(RateLimit{}, RateLimit{ASDUs: 1}).
+		SetDialer(func(context.Context) (net.Conn, error) { return cliEnd, nil })
+	c := NewClient(NewTypedClientHandler(ClientHandlerBase{}), o)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for !c.IsConnected() {
+		time.Sleep(time.Millisecond)
+	}
+	c.SendStartDt()
+	readTestAPDU(t, srvEnd)
+	if _, err := srvEnd.Write(newUFrame(uStartDtConfirm)); err != nil {
+		t.Fatal(err)
+	}
+	for atomic.LoadUint32(&c.isActive) != active {
+		time.Sleep(time.Millisecond)
+	}
+
+	send := func() error {
+		return asdu.SingleCmd(c, asdu.C_SC_NA_1, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1,
+			asdu.SingleCommandInfo{Ioa: 1, Value: true})
+	}
+	if err := send(); err != nil { // the burst
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- send() }()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Send() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("rate limited Send() not canceled")
+	}
+}
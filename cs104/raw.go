@@ -0,0 +1,70 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// SendRaw send the encoded asdu, such as an asdu of a private type identification, in an I-frame
+// numbered by the client like the asdu of Send. The send interceptors, the rate limit and the audit
+// are bypassed, the read-only enforcement is not.
+func (sf *Client) SendRaw(data []byte) error {
+	id, err := rawIdentifier(&sf.option.params, data)
+	if err != nil {
+		return err
+	}
+	if !sf.IsConnected() {
+		return ErrUseClosedConnection
+	}
+	if atomic.LoadUint32(&sf.isActive) == inactive {
+		return ErrNotActive
+	}
+	if sf.option.listenOnly {
+		return ErrListenOnly
+	}
+	if sf.readOnly && isControlDirection(&asdu.ASDU{Identifier: id}) {
+		securityEvent(sf.Clog, sf.onSecurity, nil, id, "read-only")
+		return ErrReadOnly
+	}
+	return sf.enqueue(data)
+}
+
+// SendRaw send the encoded asdu, such as an asdu of a private type identification, in an I-frame
+// numbered by the session like the asdu of Send. The send interceptors, the priority, the rate limit
+// and the audit are bypassed.
+func (sf *SrvSession) SendRaw(data []byte) error {
+	if _, err := rawIdentifier(sf.params, data); err != nil {
+		return err
+	}
+	if !sf.IsConnected() {
+		return ErrUseClosedConnection
+	}
+	buf := append(getASDUBuffer(), data...)
+	if err := sf.sendData(buf); err != nil {
+		putASDUBuffer(buf)
+		return err
+	}
+	return nil
+}
+
+// rawIdentifier returns the type identification and the cause of transmission of the encoded asdu,
+// ErrAPDU if it is shorter than the data unit identifier, asdu.ErrLengthOutOfRange if it is longer
+// than the maximum asdu size of the params.
+func rawIdentifier(p *asdu.Params, data []byte) (asdu.Identifier, error) {
+	if len(data) <= p.IdentifierSize() {
+		return asdu.Identifier{}, ErrAPDU
+	}
+	if max := p.MaxASDUSize(); len(data) > max {
+		return asdu.Identifier{}, fmt.Errorf("%w: %d", asdu.ErrLengthOutOfRange, max)
+	}
+	return asdu.Identifier{
+		Type: asdu.TypeID(data[0]),
+		Coa:  asdu.ParseCauseOfTransmission(data[2]),
+	}, nil
+}
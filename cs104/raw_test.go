@@ -0,0 +1,93 @@
+package cs104
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// privateASDU a spontaneous asdu of the private type identification 200 for the asdu.ParamsWide
+var privateASDU = []byte{200, 0x01, byte(asdu.Spontaneous), 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x2a}
+
+func TestClient_SendRaw(t *testing.T) {
+	c := NewClient(NewTypedClientHandler(ClientHandlerBase{}), NewOption())
+	if err := c.SendRaw(privateASDU); err != ErrUseClosedConnection {
+		t.Errorf("SendRaw() not connected error = %v, want %v", err, ErrUseClosedConnection)
+	}
+	c.setConnectStatus(connected)
+	atomic.StoreUint32(&c.isActive, active)
+
+	if err := c.SendRaw(privateASDU[:6]); err != ErrAPDU {
+		t.Errorf("SendRaw() short error = %v, want %v", err, ErrAPDU)
+	}
+	if err := c.SendRaw(make([]byte, asdu.ASDUSizeMax+1)); !errors.Is(err, asdu.ErrLengthOutOfRange) {
+		t.Errorf("SendRaw() long error = %v, want %v", err, asdu.ErrLengthOutOfRange)
+	}
+	if err := c.SendRaw(privateASDU); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-c.sendASDU; !bytes.Equal(got, privateASDU) {
+		t.Errorf("queued % x, want % x", got, privateASDU)
+	}
+
+	c.SetReadOnly(true)
+	cmd := []byte{byte(asdu.C_SC_NA_1), 0x01, byte(asdu.Activation), 0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01}
+	if err := c.SendRaw(cmd); err != ErrReadOnly {
+		t.Errorf("SendRaw() command error = %v, want %v", err, ErrReadOnly)
+	}
+}
+
+func TestSrvSession_SendRaw(t *testing.T) {
+	sess := newTestSession(&mockServerHandler{})
+	if err := sess.SendRaw(privateASDU); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-sess.sendASDU; !bytes.Equal(got, privateASDU) {
+		t.Errorf("queued % x, want % x", got, privateASDU)
+	}
+}
+
+func TestClient_SendTestFr(t *testing.T) {
+	cliEnd, srvEnd := net.Pipe()
+	defer srvEnd.Close()
+	o := NewOption().SetAutoReconnect(false).
+		SetDialer(func(context.Context) (net.Conn, error) { return cliEnd, nil })
+	c := NewClient(NewTypedClientHandler(ClientHandlerBase{}), o)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.StartContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for !c.IsConnected() {
+		time.Sleep(time.Millisecond)
+	}
+
+	c.SendStartDt()
+	if apci, _ := readTestAPDU(t, srvEnd); apci != (uAPCI{uStartDtActive}) {
+		t.Fatalf("received %v, want STARTDT act", apci)
+	}
+	if _, err := srvEnd.Write(newUFrame(uStartDtConfirm)); err != nil {
+		t.Fatal(err)
+	}
+	c.SendTestFr()
+	if apci, _ := readTestAPDU(t, srvEnd); apci != (uAPCI{uTestFrActive}) {
+		t.Fatalf("received %v, want TESTFR act", apci)
+	}
+
+	for atomic.LoadUint32(&c.isActive) != active {
+		time.Sleep(time.Millisecond)
+	}
+	if err := c.SendRaw(privateASDU); err != nil {
+		t.Fatal(err)
+	}
+	apci, data := readTestAPDU(t, srvEnd)
+	if i, ok := apci.(iAPCI); !ok || i.sendSN != 0 || !bytes.Equal(data, privateASDU) {
+		t.Errorf("received %v % x, want the raw asdu in I-frame 0", apci, data)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"net"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/clog"
+)
+
+// SecurityEvent a control direction asdu refused by the read-only enforcement
+type SecurityEvent struct {
+	Time       time.Time
+	Peer       net.Addr // remote address of the connection, nil if unknown
+	Identifier asdu.Identifier
+	Reason     string
+}
+
+// SetReadOnly enable or disable the read-only enforcement, for the data diode style gateways of the monitor direction.
+// When enabled, the process commands, parameter loading, clock synchronization and reset process commands of every peer
+// are negative confirmed and never handled, whatever the Authorizer grants, and the security handler is called.
+func (sf *Server) SetReadOnly(enable bool) *Server {
+	sf.readOnly = enable
+	return sf
+}
+
+// SetSecurityHandler set the handler called on every asdu refused by the read-only enforcement
+func (sf *Server) SetSecurityHandler(f func(e SecurityEvent)) *Server {
+	sf.onSecurity = f
+	return sf
+}
+
+// SetReadOnly enable or disable the read-only enforcement. When enabled, sending a process command,
+// parameter loading, clock synchronization or reset process command fails with ErrReadOnly,
+// and the ones received in activation are negative confirmed instead of handled.
+// The security handler is called on each.
+func (sf *Client) SetReadOnly(enable bool) *Client {
+	sf.readOnly = enable
+	return sf
+}
+
+// SetSecurityHandler set the handler called on every asdu refused by the read-only enforcement
+func (sf *Client) SetSecurityHandler(f func(e SecurityEvent)) *Client {
+	sf.onSecurity = f
+	return sf
+}
+
+// securityEvent log the refused asdu and call the handler f if any
+func securityEvent(log clog.Clog, f func(SecurityEvent), conn net.Conn, id asdu.Identifier, reason string) {
+	e := SecurityEvent{Time: time.Now(), Identifier: id, Reason: reason}
+	if conn != nil {
+		e.Peer = conn.RemoteAddr()
+	}
+	log.Warn("security: %s refused %v from %v", reason, id, e.Peer)
+	if f != nil {
+		f(e)
+	}
+}
+
+// isControlDirection whether the asdu is a control direction request which changes
+// the state of the controlled station, see isControl
+func isControlDirection(a *asdu.ASDU) bool {
+	return isControl(a.Type) && (a.Coa.Cause == asdu.Activation || a.Coa.Cause == asdu.Deactivation)
+}
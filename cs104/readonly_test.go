@@ -0,0 +1,58 @@
+package cs104
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestServer_SetReadOnly(t *testing.T) {
+	var events []SecurityEvent
+	srv := NewServer(&mockServerHandler{}).SetReadOnly(true).SetSecurityHandler(func(e SecurityEvent) {
+		events = append(events, e)
+	})
+	srv.SetAuthorizer(func(Peer) Access { return AccessFull })
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if access := srv.authorize(a, true); access != AccessReadOnly {
+		t.Errorf("authorize() = %v, want the full access restricted to read-only", access)
+	}
+
+	sess := newTestSession(&mockServerHandler{})
+	sess.setAccess(AccessReadOnly)
+	sess.onSecurity = srv.onSecurity
+	rc := &recordConn{}
+	_ = asdu.SetpointCmdFloat(rc, asdu.C_SE_NC_1, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1,
+		asdu.SetpointCommandFloatInfo{Ioa: 1, Value: 1})
+	if err := sess.serverHandler(rc.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	if sent := sess.sent(t); len(sent) != 1 || !sent[0].Coa.IsNegative {
+		t.Errorf("sent %d asdu, want the setpoint negative confirmed", len(sent))
+	}
+	if len(events) != 1 || events[0].Identifier.Type != asdu.C_SE_NC_1 {
+		t.Errorf("security events %+v, want the setpoint", events)
+	}
+}
+
+func TestClient_SetReadOnly(t *testing.T) {
+	var events []SecurityEvent
+	c := NewClient(NewTypedClientHandler(ClientHandlerBase{}), NewOption())
+	c.SetReadOnly(true).SetSecurityHandler(func(e SecurityEvent) { events = append(events, e) })
+	c.setConnectStatus(connected)
+	atomic.StoreUint32(&c.isActive, active)
+
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	if err := asdu.SingleCmd(c, asdu.C_SC_NA_1, act, 1, asdu.SingleCommandInfo{Ioa: 1, Value: true}); err != ErrReadOnly {
+		t.Errorf("SingleCmd() error = %v, want %v", err, ErrReadOnly)
+	}
+	if err := c.InterrogationCmd(act, 1, asdu.QOIStation); err != nil {
+		t.Errorf("InterrogationCmd() error = %v, want sent", err)
+	}
+	if len(events) != 1 || events[0].Identifier.Type != asdu.C_SC_NA_1 {
+		t.Errorf("security events %+v, want the command", events)
+	}
+}
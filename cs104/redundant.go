@@ -0,0 +1,161 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/clog"
+)
+
+// RedundantClient a master of a redundancy group, it keeps a connection to each controlled station endpoint,
+// only one of them is started (STARTDT) and carries the data, the others stay stopped (STOPDT) as standby.
+// The first connected endpoint, preferably the primary, becomes active. On loss of the active connection
+// it fails over to a connected standby, starts it and performs a general interrogation.
+type RedundantClient struct {
+	clients  []*Client
+	ca       asdu.CommonAddr
+	onSwitch func(c *Client)
+
+	mux    sync.Mutex
+	active *Client
+	clog.Clog
+}
+
+// NewRedundantClient new a redundant client with the option of every endpoint,
+// the first server is the primary, the others are the backups in order.
+func NewRedundantClient(handler ClientHandlerInterface, o *ClientOption, servers ...string) (*RedundantClient, error) {
+	if len(servers) == 0 {
+		return nil, ErrRemoteServer
+	}
+	sf := &RedundantClient{
+		ca:       asdu.GlobalCommonAddr,
+		onSwitch: func(*Client) {},
+		Clog:     clog.NewLogger("cs104 redundant client => "),
+	}
+	for _, server := range servers {
+		opt := *o
+		if err := opt.AddRemoteServer(server); err != nil {
+			return nil, err
+		}
+		c := NewClient(handler, &opt)
+		c.SetOnConnectHandler(sf.connected)
+		c.SetConnectionLostHandler(sf.lost)
+		sf.clients = append(sf.clients, c)
+	}
+	return sf, nil
+}
+
+// SetInterrogationCommonAddr set the common address of the general interrogation performed on switchover,
+// default the global common address
+func (sf *RedundantClient) SetInterrogationCommonAddr(ca asdu.CommonAddr) *RedundantClient {
+	sf.ca = ca
+	return sf
+}
+
+// SetOnSwitchoverHandler set the handler called when a connection becomes active
+func (sf *RedundantClient) SetOnSwitchoverHandler(f func(c *Client)) *RedundantClient {
+	if f != nil {
+		sf.onSwitch = f
+	}
+	return sf
+}
+
+// Start connect all the endpoints in background
+func (sf *RedundantClient) Start() error {
+	for _, c := range sf.clients {
+		if err := c.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close close all the connections
+func (sf *RedundantClient) Close() error {
+	for _, c := range sf.clients {
+		_ = c.Close()
+	}
+	return nil
+}
+
+// Active returns the active connection, nil if none
+func (sf *RedundantClient) Active() *Client {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.active
+}
+
+// Params imp interface Connect
+func (sf *RedundantClient) Params() *asdu.Params {
+	return sf.clients[0].Params()
+}
+
+// Send imp interface Connect, send the asdu on the active connection
+func (sf *RedundantClient) Send(a *asdu.ASDU) error {
+	c := sf.Active()
+	if c == nil {
+		return ErrNotActive
+	}
+	return c.Send(a)
+}
+
+// UnderlyingConn imp interface Connect, the underlying conn of the active connection
+func (sf *RedundantClient) UnderlyingConn() net.Conn {
+	if c := sf.Active(); c != nil {
+		return c.UnderlyingConn()
+	}
+	return nil
+}
+
+// connected the new connection is activated if none is active, or kept stopped as standby
+func (sf *RedundantClient) connected(c *Client) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.active == nil {
+		sf.activate(c)
+	}
+}
+
+// lost fail over to a connected standby if the active connection is lost, the primary preferred
+func (sf *RedundantClient) lost(c *Client) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.active != c {
+		return
+	}
+	sf.active = nil
+	for _, v := range sf.clients {
+		if v != c && v.IsConnected() {
+			sf.Warn("failover from %v to %v", c.option.server, v.option.server)
+			sf.activate(v)
+			return
+		}
+	}
+	sf.Error("no standby connection to fail over")
+}
+
+// activate start the data transfer of the connection, then perform the general interrogation
+func (sf *RedundantClient) activate(c *Client) {
+	sf.active = c
+	c.SendStartDt()
+	go func() {
+		deadline := time.Now().Add(c.option.config.SendUnAckTimeout1)
+		for atomic.LoadUint32(&c.isActive) != active {
+			if !c.IsConnected() || time.Now().After(deadline) {
+				return // the connection lost handler fails over
+			}
+			time.Sleep(timeoutResolution)
+		}
+		sf.onSwitch(c)
+		if err := c.InterrogationCmd(asdu.CauseOfTransmission{Cause: asdu.Activation}, sf.ca, asdu.QOIStation); err != nil {
+			sf.Error("general interrogation on switchover failed, %v", err)
+		}
+	}()
+}
@@ -0,0 +1,55 @@
+package cs104
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestRedundantClient_failover(t *testing.T) {
+	r, err := NewRedundantClient(NewTypedClientHandler(ClientHandlerBase{}), NewOption(), "127.0.0.1:2404", "127.0.0.1:2405")
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary, backup := r.clients[0], r.clients[1]
+	primary.setConnectStatus(connected)
+	backup.setConnectStatus(connected)
+
+	r.connected(primary)
+	r.connected(backup)
+	if r.Active() != primary || len(primary.sendRaw) != 1 || len(backup.sendRaw) != 0 {
+		t.Fatalf("active %p, want primary started and backup stopped", r.Active())
+	}
+
+	switched := make(chan *Client, 1)
+	r.SetOnSwitchoverHandler(func(c *Client) { switched <- c })
+	primary.setConnectStatus(disconnected)
+	r.lost(primary)
+	if r.Active() != backup || len(backup.sendRaw) != 1 {
+		t.Fatalf("active %p, want backup started", r.Active())
+	}
+	atomic.StoreUint32(&backup.isActive, active) // STARTDT con
+	select {
+	case c := <-switched:
+		if c != backup {
+			t.Fatalf("switched to %p, want backup", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no switchover")
+	}
+
+	select {
+	case data := <-backup.sendASDU:
+		a := asdu.NewEmptyASDU(backup.Params())
+		if err := a.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if a.Type != asdu.C_IC_NA_1 || a.CommonAddr != asdu.GlobalCommonAddr {
+			t.Errorf("sent %v, want general interrogation", a.Identifier)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no general interrogation on switchover")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Configuration the point database and reporting rules of a server, the nil ones are not used.
+// It is replaced at runtime by Server.ReplaceConfiguration without dropping the connections.
+type Configuration struct {
+	// PointStore answers the read command [C_RD_NA_1], see Server.SetPointStore
+	PointStore PointStore
+	// Deadband reports the measured values and handles the parameter commands, see Server.SetDeadband
+	Deadband *Deadband
+	// Counters answers the counter interrogation [C_CI_NA_1], see Server.SetCounterAccumulator
+	Counters *CounterAccumulator
+}
+
+// Configuration returns the current point database and reporting rules of the server
+func (sf *Server) Configuration() Configuration {
+	if c := sf.conf.Load(); c != nil {
+		return *c
+	}
+	return Configuration{}
+}
+
+// ReplaceConfiguration swap the point database and reporting rules atomically, the sessions handle
+// the next asdu with the new ones. The new tables are rebased on the old ones:
+// the measured values and the last reported ones of the deadband points kept, with the same type, carry over
+// and the cyclic transmission moves to the new deadband engine if the old one was started;
+// the running values and frozen readings of the counters kept carry over, as do the sequence numbers
+// of the counter interrogation groups.
+func (sf *Server) ReplaceConfiguration(c Configuration) {
+	sf.confMux.Lock()
+	defer sf.confMux.Unlock()
+	old := sf.Configuration()
+	if c.Deadband != nil && old.Deadband != nil && c.Deadband != old.Deadband {
+		c.Deadband.rebase(old.Deadband)
+	}
+	if c.Counters != nil && old.Counters != nil && c.Counters != old.Counters {
+		c.Counters.rebase(old.Counters)
+	}
+	sf.conf.Store(&c)
+}
+
+// update replaces the configuration by a copy changed by f
+func (sf *Server) update(f func(c *Configuration)) {
+	sf.confMux.Lock()
+	c := sf.Configuration()
+	f(&c)
+	sf.conf.Store(&c)
+	sf.confMux.Unlock()
+}
+
+// configuration returns the point database and reporting rules shared with the server
+func (sf *SrvSession) configuration() Configuration {
+	if sf.conf != nil {
+		if c := sf.conf.Load(); c != nil {
+			return *c
+		}
+	}
+	return Configuration{}
+}
+
+// rebase carry over the values of the points of old with the same type,
+// and take over its cyclic transmission if started.
+func (sf *Deadband) rebase(old *Deadband) {
+	old.mux.Lock()
+	points := make(map[asdu.InfoObjAddr]deadbandPoint, len(old.points))
+	for ioa, pt := range old.points {
+		points[ioa] = *pt
+	}
+	started := old.cancel != nil
+	old.mux.Unlock()
+
+	sf.mux.Lock()
+	for ioa, pt := range sf.points {
+		if op, ok := points[ioa]; ok && op.typeID == pt.typeID {
+			pt.value, pt.qds = op.value, op.qds
+			pt.reported, pt.reportedQds, pt.hasReported = op.reported, op.reportedQds, op.hasReported
+		}
+	}
+	sf.mux.Unlock()
+	if started {
+		_ = old.Close()
+		sf.Start()
+	}
+}
+
+// rebase carry over the running values and frozen readings of the counters of old
+// and the sequence numbers of the counter groups.
+func (sf *CounterAccumulator) rebase(old *CounterAccumulator) {
+	old.mux.Lock()
+	counters := make(map[asdu.InfoObjAddr]counterPoint, len(old.counters))
+	for ioa, p := range old.counters {
+		counters[ioa] = *p
+	}
+	seq := old.seq
+	old.mux.Unlock()
+
+	sf.mux.Lock()
+	for ioa, p := range sf.counters {
+		if op, ok := counters[ioa]; ok {
+			op.group = p.group
+			*p = op
+		}
+	}
+	sf.seq = seq
+	sf.mux.Unlock()
+}
@@ -0,0 +1,60 @@
+package cs104
+
+import (
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestServer_ReplaceConfiguration(t *testing.T) {
+	srv := NewServer(&mockServerHandler{})
+	old := NewMemPointStore()
+	_ = old.Set(1, Point{asdu.M_SP_NA_1, asdu.SinglePointInfo{Ioa: 10, Value: true}})
+	rc := &recordConn{}
+	oldDB := NewDeadband(rc, 1)
+	_ = oldDB.Add(20, asdu.M_ME_NC_1, DeadbandParam{Threshold: 5})
+	_ = oldDB.Update(20, 100, asdu.QDSGood)
+	oldAcc := NewCounterAccumulator()
+	_ = oldAcc.Add(30, asdu.QCCGroup1)
+	_ = oldAcc.Count(30, 7)
+	oldAcc.Freeze(rc, asdu.QCCGroup1, false)
+	srv.SetPointStore(old).SetDeadband(oldDB).SetCounterAccumulator(oldAcc)
+	rc.take()
+
+	sess := newTestSession(&mockServerHandler{})
+	sess.conf = &srv.conf
+	read := func(ioa asdu.InfoObjAddr) *asdu.ASDU {
+		if err := asdu.ReadCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Request}, 1, ioa); err != nil {
+			t.Fatal(err)
+		}
+		if err := sess.serverHandler(rc.take()[0]); err != nil {
+			t.Fatal(err)
+		}
+		return sess.sent(t)[0]
+	}
+	if a := read(10); a.Type != asdu.M_SP_NA_1 {
+		t.Fatalf("read %v, want the point of the old store", a.Identifier)
+	}
+
+	store := NewMemPointStore()
+	_ = store.Set(1, Point{asdu.M_ME_NC_1, asdu.MeasuredValueFloatInfo{Ioa: 10, Value: 1}})
+	db := NewDeadband(rc, 1)
+	_ = db.Add(20, asdu.M_ME_NC_1, DeadbandParam{Threshold: 5})
+	acc := NewCounterAccumulator()
+	_ = acc.Add(30, asdu.QCCGroup2)
+	srv.ReplaceConfiguration(Configuration{PointStore: store, Deadband: db, Counters: acc})
+
+	if a := read(10); a.Type != asdu.M_ME_NC_1 || a.Coa.IsNegative {
+		t.Errorf("read %v, want the point of the new store", a.Identifier)
+	}
+	_ = db.Update(20, 102, asdu.QDSGood)
+	if len(rc.take()) != 0 {
+		t.Error("Update() reported a change within the threshold of the value reported before the replacement")
+	}
+	if r := acc.Readings(asdu.QCCGroup2); len(r) != 1 || r[0].Value.CounterReading != 7 || r[0].Value.SeqNumber != 1 {
+		t.Errorf("Readings() = %+v, want the reading frozen before the replacement", r)
+	}
+	if got := srv.Configuration(); got.PointStore != store || got.Deadband != db || got.Counters != acc {
+		t.Errorf("Configuration() = %+v, want the new one", got)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ResetHook the application reset of the controlled station with the common address on a reset process command,
+// qrp is the qualifier of the command, the activation is negative confirmed if it returns an error.
+type ResetHook func(ca asdu.CommonAddr, qrp asdu.QualifierOfResetProcessCmd) error
+
+// resetHandler process the reset process command [C_RP_NA_1], see companion standard 101, subclass 7.4.5:
+// the reset of the event buffer clears the sequence of events queue, the general reset is ended by
+// the end of initialization [M_EI_NA_1] with the remote reset cause.
+func (sf *SrvSession) resetHandler(origin, asduPack *asdu.ASDU) error {
+	if asduPack.Coa.Cause != asdu.Activation {
+		return sf.reject(origin, asdu.UnknownCOT)
+	}
+	if asduPack.CommonAddr == asdu.InvalidCommonAddr {
+		return sf.reject(origin, asdu.UnknownCA)
+	}
+	ioa, qrp := asduPack.GetResetProcessCmd()
+	if ioa != asdu.InfoObjAddrIrrelevant {
+		return sf.reject(origin, asdu.UnknownIOA)
+	}
+
+	if qrp == asdu.QPRResetPendingInfoWithTimeTag && sf.soe != nil {
+		sf.soe.Clear()
+	}
+	if err := sf.resetHook(asduPack.CommonAddr, qrp); err != nil {
+		sf.Warn("reset process %d of %d failed, %v", qrp, asduPack.CommonAddr, err)
+		return negativeMirror(sf, origin, asdu.ActivationCon)
+	}
+	if err := origin.SendReplyMirror(sf, asdu.ActivationCon); err != nil {
+		return err
+	}
+	if qrp != asdu.QPRGeneralRest {
+		return nil
+	}
+	return asdu.EndOfInitialization(sf, asdu.CauseOfTransmission{}, asduPack.CommonAddr,
+		asdu.InfoObjAddrIrrelevant, asdu.CauseOfInitial{Cause: asdu.COIRemoteReset})
+}
@@ -0,0 +1,61 @@
+package cs104
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSrvSession_resetHook(t *testing.T) {
+	var resets []asdu.QualifierOfResetProcessCmd
+	fail := false
+	sess := newTestSession(&mockServerHandler{})
+	sess.soe = NewSOE(0)
+	sess.resetHook = func(ca asdu.CommonAddr, qrp asdu.QualifierOfResetProcessCmd) error {
+		if fail {
+			return errors.New("reset failed")
+		}
+		resets = append(resets, qrp)
+		return nil
+	}
+	rc := &recordConn{}
+	reset := func(qrp asdu.QualifierOfResetProcessCmd) []*asdu.ASDU {
+		if err := asdu.ResetProcessCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, qrp); err != nil {
+			t.Fatal(err)
+		}
+		if err := sess.serverHandler(rc.take()[0]); err != nil {
+			t.Fatal(err)
+		}
+		return sess.sent(t)
+	}
+
+	if err := sess.soe.EnqueueSingle(1, asdu.SinglePointInfo{Ioa: 1, Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	sent := reset(asdu.QPRResetPendingInfoWithTimeTag)
+	if len(sent) != 1 || sent[0].Coa.Cause != asdu.ActivationCon || sent[0].Coa.IsNegative {
+		t.Errorf("event buffer reset sent %v, want activation confirmation", sent)
+	}
+	if sess.soe.Len() != 0 {
+		t.Errorf("SOE Len() = %d, want 0", sess.soe.Len())
+	}
+
+	sent = reset(asdu.QPRGeneralRest)
+	if len(sent) != 2 || sent[0].Coa.Cause != asdu.ActivationCon || sent[1].Type != asdu.M_EI_NA_1 {
+		t.Fatalf("general reset sent %v, want activation confirmation and end of initialization", sent)
+	}
+	if _, coi := sent[1].GetEndOfInitialization(); coi.Cause != asdu.COIRemoteReset || sent[1].Coa.Cause != asdu.Initialized {
+		t.Errorf("end of initialization %v %v, want %v", sent[1].Coa, coi, asdu.COIRemoteReset)
+	}
+	if len(resets) != 2 {
+		t.Errorf("hook called %v, want both resets", resets)
+	}
+
+	fail = true
+	sent = reset(asdu.QPRGeneralRest)
+	if len(sent) != 1 || !sent[0].Coa.IsNegative {
+		t.Errorf("failed reset sent %v, want negative activation confirmation", sent)
+	}
+}
@@ -0,0 +1,55 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeqNoCount(t *testing.T) {
+	tests := []struct {
+		ack, seq uint16
+		want     uint16
+	}{
+		{0, 0, 0},
+		{0, 12, 12},
+		{32760, 32767, 7},
+		{32766, 1, 3},
+		{32767, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := seqNoCount(tt.ack, tt.seq); got != tt.want {
+			t.Errorf("seqNoCount(%d, %d) = %d, want %d", tt.ack, tt.seq, got, tt.want)
+		}
+	}
+}
+
+func TestSrvSession_updateAckNoOut_rollover(t *testing.T) {
+	sess := newTestSession(&mockServerHandler{})
+	now := time.Now()
+	sess.ackNoSend, sess.seqNoSend = 32766, 1
+	sess.pending = []seqPending{{32766, now}, {32767, now}, {0, now}}
+
+	for _, ack := range []uint16{2, 32765} { // outside the window
+		if sess.updateAckNoOut(ack) {
+			t.Errorf("updateAckNoOut(%d) accepted outside the window [32766, 1]", ack)
+		}
+	}
+	if !sess.updateAckNoOut(0) { // acknowledges 32766 and 32767
+		t.Fatal("updateAckNoOut(0) rejected")
+	}
+	if sess.ackNoSend != 0 || len(sess.pending) != 1 || sess.pending[0].seq != 0 {
+		t.Errorf("updateAckNoOut(0) ackNoSend %d pending %v, want 0 [0]", sess.ackNoSend, sess.pending)
+	}
+	if !sess.updateAckNoOut(1) || len(sess.pending) != 0 {
+		t.Errorf("updateAckNoOut(1) pending %v, want none", sess.pending)
+	}
+}
+
+func TestWindow_fail(t *testing.T) {
+	var w window
+	w.fail(ErrSeqNoAck)
+	w.fail(ErrSeqNoSend)
+	if stats, _ := w.stats(&Config{}, 0); stats.SeqErrors != 2 || w.err() != ErrSeqNoSend {
+		t.Errorf("stats %+v err %v", stats, w.err())
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rob-gra/go-iecp5/asdu"
@@ -30,8 +31,37 @@ type Server struct {
 	mux            sync.Mutex
 	sessions       map[*SrvSession]struct{}
 	listen         net.Listener
+	closing        bool // Shutdown or Close called, stop accepting
 	onConnection   func(asdu.Connect)
 	connectionLost func(asdu.Connect)
+	conf           atomic.Pointer[Configuration] // point database and reporting rules, see ReplaceConfiguration
+	confMux        sync.Mutex                    // serializes ReplaceConfiguration
+	soe            *SOE
+	commonAddrs    []asdu.CommonAddr
+	sectors        map[asdu.CommonAddr]Sector
+	confirmHandler ConfirmHandler
+	cmdHandler     ServerCommandHandler
+	negConfirm     bool
+	isMirror       func(net.Conn) bool
+	peerBinding    func(net.Conn) []asdu.CommonAddr
+	authorizer     Authorizer
+	readOnly       bool
+	onSecurity     func(SecurityEvent)
+	onViolation    func(asdu.Connect, *ProtocolError)
+	shaping        *Shaping
+	stopped        StoppedPolicy
+	audit          AuditSink
+	freshness      time.Duration
+	writeDelay     time.Duration
+	connRate       [2]RateLimit // monitor and command rate limit of every connection
+	limiter        *RateLimiter
+	priority       func(*asdu.ASDU) Priority
+	keepalive      Keepalive
+	delayAcq       bool
+	resetHook      ResetHook
+	endOfInit      *endOfInit
+	sendChain      []Interceptor // see UseSend
+	rcvChain       []Interceptor // see UseReceive
 	clog.Clog
 	wg sync.WaitGroup
 }
@@ -67,19 +97,49 @@ func (sf *Server) SetParams(p *asdu.Params) *Server {
 	return sf
 }
 
+// SetTypeIDs set the type identifications the server supports, like the interoperability list of a device,
+// it restricts the profile of the params, so call it after SetParams. An asdu of any other type received
+// is negative confirmed with UnknownTypeID, sending one fails with asdu.ErrProfileTypeID.
+func (sf *Server) SetTypeIDs(ids ...asdu.TypeID) *Server {
+	sf.params.Profile = sf.params.Profile.Restrict(ids...)
+	return sf
+}
+
 // ListenAndServer run the server
 func (sf *Server) ListenAndServer(addr string) {
-	listen, err := net.Listen("tcp", addr)
-	if err != nil {
+	if err := sf.ListenAndServerContext(context.Background(), addr); err != nil {
 		sf.Critical("server run failed, %v", err)
 		os.Exit(1)
-		return
 	}
+}
+
+// ListenAndServerContext run the server until it is closed or ctx is done,
+// the connections are closed along. It returns nil when stopped by Close
+// or Shutdown, the ctx error when ctx is done.
+func (sf *Server) ListenAndServerContext(ctx context.Context, addr string) error {
+	listen, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if sf.TLSConfig != nil {
+		listen = tls.NewListener(listen, sf.TLSConfig)
+	}
+	return sf.Serve(ctx, listen)
+}
+
+// Serve run the server on the connections accepted by listen, like ListenAndServerContext,
+// listen is closed along.
+func (sf *Server) Serve(ctx context.Context, listen net.Listener) error {
 	sf.mux.Lock()
 	sf.listen = listen
 	sf.mux.Unlock()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		<-ctx.Done()
+		_ = sf.Close()
+	}()
 	defer func() {
 		cancel()
 		_ = sf.Close()
@@ -89,29 +149,103 @@ func (sf *Server) ListenAndServer(addr string) {
 	for {
 		conn, err := listen.Accept()
 		if err != nil {
-			sf.Critical("server run failed, %v", err)
-			os.Exit(1)
-			return
+			sf.mux.Lock()
+			closing := sf.closing
+			sf.mux.Unlock()
+			if !closing {
+				return err
+			}
+			sf.wg.Wait() // let the sessions drain, see Shutdown
+			return parent.Err()
 		}
 
+		// add to wg under mux so that Close, waiting once closing is set, never races it
+		sf.mux.Lock()
+		if sf.closing {
+			sf.mux.Unlock()
+			_ = conn.Close()
+			continue
+		}
 		sf.wg.Add(1)
+		sf.mux.Unlock()
 		go func() {
+			life, stop := context.WithCancel(ctx)
+			defer stop()
 			sess := &SrvSession{
+				life:     life,
 				config:   &sf.config,
 				params:   &sf.params,
 				handler:  sf.handler,
 				conn:     conn,
 				rcvASDU:  make(chan []byte, sf.config.RecvUnAckLimitW<<4),
 				sendASDU: make(chan []byte, sf.config.SendUnAckLimitK<<4),
-				rcvRaw:   make(chan []byte, sf.config.RecvUnAckLimitW<<5),
+				rcvRaw:   make(chan []byte, sf.config.RecvUnAckLimitW),
 				sendRaw:  make(chan []byte, sf.config.SendUnAckLimitK<<5), // may not block!
+				notify:   make(chan struct{}, 1),
+				interro:  make(chan shapedInterrogation, shapedInterrogationMax),
 
 				onConnection:   sf.onConnection,
 				connectionLost: sf.connectionLost,
+				conf:           &sf.conf,
+				soe:            sf.soe,
+				commonAddrs:    sf.commonAddrs,
+				sectors:        sf.sectors,
+				confirmHandler: sf.confirmHandler,
+				cmdHandler:     sf.cmdHandler,
+				negConfirm:     sf.negConfirm,
+				keepalive:      sf.keepalive,
+				delayAcq:       sf.delayAcq,
+				resetHook:      sf.resetHook,
+				endOfInit:      sf.endOfInit,
+				onSecurity:     sf.onSecurity,
+				onViolation:    sf.onViolation,
+				shaping:        sf.shaping,
+				stopped:        sf.stopped,
+				audit:          sf.audit,
+				sendChain:      sf.sendChain,
+				rcvChain:       sf.rcvChain,
+				freshness:      sf.freshness,
+				batch:          newWriteBatch(sf.writeDelay),
+				drain:          make(chan struct{}),
 				Clog:           sf.Clog,
 			}
+			if sf.priority != nil {
+				sess.priority = sf.priority
+				sess.sendHigh = make(chan []byte, sf.config.SendUnAckLimitK<<4)
+				sess.sendLow = make(chan []byte, sf.config.SendUnAckLimitK<<4)
+			}
+			if sf.connRate != [2]RateLimit{} {
+				sess.limiters = append(sess.limiters, NewRateLimiter(sf.connRate[0], sf.connRate[1]))
+			}
+			if sf.limiter != nil {
+				sess.limiters = append(sess.limiters, sf.limiter)
+			}
+			if sf.peerBinding != nil {
+				sess.peerCAs = sf.peerBinding(conn)
+			}
+			if sf.isMirror != nil && sf.isMirror(conn) {
+				sess.mirror = true
+			} else {
+				sess.forward = sf.forwardToMirrors
+			}
+			if sf.readOnly {
+				sess.setAccess(AccessReadOnly)
+			}
+			if sf.authorizer != nil {
+				access := sf.authorize(conn, false)
+				if access == AccessDeny {
+					_ = conn.Close()
+					sf.wg.Done()
+					return
+				}
+				sess.setAccess(access)
+				sess.authorize = sf.authorize
+			}
 			sf.mux.Lock()
 			sf.sessions[sess] = struct{}{}
+			if sf.closing {
+				close(sess.drain)
+			}
 			sf.mux.Unlock()
 			sess.run(ctx)
 			sf.mux.Lock()
@@ -122,28 +256,86 @@ func (sf *Server) ListenAndServer(addr string) {
 	}
 }
 
-// Close close the server
+// Close close the server and all the connections at once, the asdu queued are lost.
 func (sf *Server) Close() error {
+	err := sf.stopListen()
+	sf.mux.Lock()
+	for sess := range sf.sessions {
+		_ = sess.conn.Close()
+	}
+	sf.mux.Unlock()
+	sf.wg.Wait()
+	return err
+}
+
+// Shutdown gracefully shuts down the server: it stops accepting connections,
+// sends the asdu queued, then STOPDT act to every peer and waits for the
+// I-frames sent to be acknowledged and STOPDT to be confirmed before closing
+// each connection. If ctx expires first the remaining connections are closed
+// and the ctx error is returned.
+func (sf *Server) Shutdown(ctx context.Context) error {
+	err := sf.stopListen()
+
+	done := make(chan struct{})
+	go func() {
+		sf.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		_ = sf.Close()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// stopListen stops accepting connections and starts draining the sessions.
+func (sf *Server) stopListen() error {
 	var err error
 
 	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.closing {
+		return nil
+	}
+	sf.closing = true
 	if sf.listen != nil {
 		err = sf.listen.Close()
 		sf.listen = nil
 	}
-	sf.mux.Unlock()
-	sf.wg.Wait()
+	for sess := range sf.sessions {
+		close(sess.drain)
+	}
 	return err
 }
 
 // Send imp interface Connect
 func (sf *Server) Send(a *asdu.ASDU) error {
+	// sent without the lock, a rate limited session or an interceptor must not block the others
+	for _, k := range sf.snapshot() {
+		if len(k.sendChain) == 0 {
+			_, _ = k.send(a)
+			continue
+		}
+		_ = intercept(k.sendChain, func(_ asdu.Connect, a *asdu.ASDU) error {
+			_, err := k.send(a)
+			return err
+		})(k, a.Clone())
+	}
+	return nil
+}
+
+// snapshot returns the sessions
+func (sf *Server) snapshot() []*SrvSession {
 	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	sessions := make([]*SrvSession, 0, len(sf.sessions))
 	for k := range sf.sessions {
-		_ = k.Send(a.Clone())
+		sessions = append(sessions, k)
 	}
-	sf.mux.Unlock()
-	return nil
+	return sessions
 }
 
 // Params imp interface Connect
@@ -167,7 +359,173 @@ func (sf *Server) SetConnectionLostHandler(f func(asdu.Connect)) {
 	sf.connectionLost = f
 }
 
+// SetDeadband set the deadband engine which handles the parameter commands
+// [P_ME_NA_1], [P_ME_NB_1], [P_ME_NC_1] and [P_AC_NA_1] instead of the ASDUHandler
+func (sf *Server) SetDeadband(d *Deadband) *Server {
+	sf.update(func(c *Configuration) { c.Deadband = d })
+	return sf
+}
+
+// SetCounterAccumulator set the integrated totals which answer the counter interrogation [C_CI_NA_1]
+// instead of the CounterInterrogationHandler
+func (sf *Server) SetCounterAccumulator(a *CounterAccumulator) *Server {
+	sf.update(func(c *Configuration) { c.Counters = a })
+	return sf
+}
+
+// SetSOE set the sequence of events queue, an active session drains it before any other data
+func (sf *Server) SetSOE(q *SOE) *Server {
+	sf.soe = q
+	return sf
+}
+
+// SetPointStore set the point store which answers the read command [C_RD_NA_1]
+// instead of the ReadHandler
+func (sf *Server) SetPointStore(store PointStore) *Server {
+	sf.update(func(c *Configuration) { c.PointStore = store })
+	return sf
+}
+
+// SetCommonAddrs set the common addresses of the logical stations served by the server.
+// when set, the asdu addressed to any other common address is rejected with a negative UnknownCA,
+// the commands [C_IC_NA_1], [C_CI_NA_1], [C_CS_NA_1] and [C_RP_NA_1] with the
+// global common address are handled once for each station, any other type with
+// the global common address is rejected with a negative UnknownCA too.
+func (sf *Server) SetCommonAddrs(cas ...asdu.CommonAddr) *Server {
+	sf.commonAddrs = cas
+	return sf
+}
+
+// Sector a logical station (sector) hosted by the server with its own handler and point store,
+// the nil ones fall back to those of the server.
+type Sector struct {
+	Handler    ServerHandlerInterface
+	PointStore PointStore
+}
+
+// AddSector add a logical station of the common address to the server, see SetCommonAddrs
+func (sf *Server) AddSector(ca asdu.CommonAddr, sector Sector) *Server {
+	if sf.sectors == nil {
+		sf.sectors = make(map[asdu.CommonAddr]Sector)
+	}
+	if _, ok := sf.sectors[ca]; !ok {
+		sf.commonAddrs = append(sf.commonAddrs, ca)
+	}
+	sf.sectors[ca] = sector
+	return sf
+}
+
+// SetConfirmHandler set the handler of the commands whose return value drives the confirmations,
+// it takes over the commands from the ServerHandlerInterface, except the parameter commands
+// handled by the deadband engine. see ConfirmHandler
+func (sf *Server) SetConfirmHandler(h ConfirmHandler) *Server {
+	sf.confirmHandler = h
+	return sf
+}
+
+// SetCommandHandler set the typed handler of the process commands whose return value drives
+// the confirmations, it takes precedence over the ConfirmHandler for the process commands. see ServerCommandHandler
+func (sf *Server) SetCommandHandler(h ServerCommandHandler) *Server {
+	sf.cmdHandler = h
+	return sf
+}
+
+// SetNegativeConfirm enable or disable the negative confirmation, when enabled the asdu which can not be
+// handled is mirrored back with IsNegative set and the cause UnknownTypeID, UnknownCOT, UnknownCA or UnknownIOA,
+// including the asdu of an unknown type identification which is dropped otherwise.
+func (sf *Server) SetNegativeConfirm(enable bool) *Server {
+	sf.negConfirm = enable
+	return sf
+}
+
+// SetMirrorFilter set the filter designating the read-only mirror connections, for historians or testing.
+// A mirror connection receives all the monitor direction asdu sent to the other connections,
+// but its control direction asdu are always negative confirmed and never handled.
+func (sf *Server) SetMirrorFilter(f func(conn net.Conn) bool) *Server {
+	sf.isMirror = f
+	return sf
+}
+
+// SetPeerBinding set the function binding the peer of a connection, identified by its remote address
+// or its tls state, to the common addresses it may address. The commands and interrogations of the peer
+// to any other common address are negative confirmed with UnknownCA, and the global common address only
+// reaches the bound ones. Returning nil leaves the peer unrestricted, an empty slice denies all.
+func (sf *Server) SetPeerBinding(f func(conn net.Conn) []asdu.CommonAddr) *Server {
+	sf.peerBinding = f
+	return sf
+}
+
+// forwardToMirrors send the asdu data to all the mirror connections
+func (sf *Server) forwardToMirrors(data []byte) {
+	for _, k := range sf.snapshot() {
+		if k.mirror && k.IsConnected() {
+			_ = k.sendData(append([]byte(nil), data...))
+		}
+	}
+}
+
 // Get the number of sessions
 func (sf *Server) GetSessionsLen() int {
 	return len(sf.sessions)
 }
+
+// SetRateLimit set the rate limit of the outgoing monitor data and commands of every connection,
+// the sending blocks until allowed. Zero is unlimited.
+func (sf *Server) SetRateLimit(monitor, command RateLimit) *Server {
+	sf.connRate = [2]RateLimit{monitor, command}
+	return sf
+}
+
+// SetRateLimiter set the rate limiter shared by all the connections, limiting their total rate
+func (sf *Server) SetRateLimiter(l *RateLimiter) *Server {
+	sf.limiter = l
+	return sf
+}
+
+// SetPriority set the transmit priority of the outgoing asdu, the queued asdu of higher priority preempt
+// those of lower priority, see DefaultPriority and PriorityTable. Without it the asdu are sent in order.
+func (sf *Server) SetPriority(f func(a *asdu.ASDU) Priority) *Server {
+	sf.priority = f
+	return sf
+}
+
+// SetKeepalive set the test frame keepalive strategy of every connection, see Keepalive
+func (sf *Server) SetKeepalive(ka Keepalive) *Server {
+	sf.keepalive = ka
+	return sf
+}
+
+// SetDelayAcquisition enable the delay acquisition [C_CD_NA_1] answered by the server itself,
+// the activation is confirmed and the transmission delay sent spontaneous by the controlling station
+// is added to the time of the following clock synchronization given to the ClockSyncHandler.
+// The DelayAcquisitionHandler is not called then.
+func (sf *Server) SetDelayAcquisition(b bool) *Server {
+	sf.delayAcq = b
+	return sf
+}
+
+// SetResetHook set the hook processing the reset process command [C_RP_NA_1] instead of the ResetProcessHandler,
+// the reset of the event buffer clears the sequence of events queue before the hook is called,
+// the activation confirmation and, after a general reset, the end of initialization are sent automatically.
+func (sf *Server) SetResetHook(f ResetHook) *Server {
+	sf.resetHook = f
+	return sf
+}
+
+// SetEndOfInitialization send the end of initialization [M_EI_NA_1] with the cause of initialization coi
+// for the common addresses cas, default the logical stations of SetCommonAddrs,
+// as soon as the first connection reaches STARTDT active after the process start, see Reinitialize.
+func (sf *Server) SetEndOfInitialization(coi asdu.CauseOfInitial, cas ...asdu.CommonAddr) *Server {
+	sf.endOfInit = &endOfInit{cas: cas}
+	sf.endOfInit.set(coi)
+	return sf
+}
+
+// Reinitialize mark the end of initialization pending again with the cause of initialization coi,
+// after the process was reset or its local parameters changed, it is sent on the next connection
+// reaching STARTDT active. It has no effect without SetEndOfInitialization.
+func (sf *Server) Reinitialize(coi asdu.CauseOfInitial) {
+	if sf.endOfInit != nil {
+		sf.endOfInit.set(coi)
+	}
+}
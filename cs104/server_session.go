@@ -30,10 +30,21 @@ type SrvSession struct {
 	conn    net.Conn
 	handler ServerHandlerInterface
 
-	rcvASDU  chan []byte // for received asdu
-	sendASDU chan []byte // for send asdu
-	rcvRaw   chan []byte // for recvLoop raw cs104 frame
-	sendRaw  chan []byte // for sendLoop raw cs104 frame
+	rcvASDU  chan []byte              // for received asdu
+	sendASDU chan []byte              // for send asdu
+	sendHigh chan []byte              // for send asdu of high priority, see Server.SetPriority
+	sendLow  chan []byte              // for send asdu of low priority
+	rcvRaw   chan []byte              // for recvLoop raw cs104 frame
+	sendRaw  chan []byte              // for tx raw cs104 frame
+	interro  chan shapedInterrogation // for the interrogations answered with shaping
+
+	// the goroutines started on demand, an idle connection keeps none
+	tx drainer[[]byte]              // writes sendRaw to the conn
+	rx drainer[[]byte]              // handles rcvASDU
+	gi drainer[shapedInterrogation] // answers interro, see Shaping
+
+	notify chan struct{} // wakes run for the asdu or events queued
+	batch  *writeBatch   // coalesces the writes, nil if disabled
 
 	// see subclass 5.1 — Protection against loss and duplication of messages
 	seqNoSend uint16 // sequence number of next outbound I-frame
@@ -42,19 +53,52 @@ type SrvSession struct {
 	ackNoRcv  uint16 // inbound sequence number yet to be confirmed
 	// maps sendTime I-frames to their respective sequence number
 	pending []seqPending
+	win     window     // the sequence numbers published to the other goroutines
+	rtt     rttMeter   // the round trip time of the test frames
+	delay   delayMeter // the transmission delay given by the delay acquisition
 	//seqManage
 
 	status uint32
+	active uint32 // data transfer started, see StoppedPolicy
 	rwMux  sync.RWMutex
 
 	clog.Clog
 
 	onConnection   func(asdu.Connect)
 	connectionLost func(asdu.Connect)
+	conf           *atomic.Pointer[Configuration] // shared with the server, nil for none
+	soe            *SOE
+	commonAddrs    []asdu.CommonAddr // logical stations served by the global common address
+	peerCAs        []asdu.CommonAddr // common addresses the peer may address, nil if not bound
+	limiters       []*RateLimiter    // rate limiters of the connection and of the server
+	priority       func(*asdu.ASDU) Priority
+	keepalive      Keepalive
+	delayAcq       bool // answer the delay acquisition and correct the clock synchronization
+	resetHook      ResetHook
+	endOfInit      *endOfInit
+	onSecurity     func(SecurityEvent)
+	onViolation    func(asdu.Connect, *ProtocolError)
+	shaping        *Shaping // traffic shaping of the interrogation responses
+	stopped        StoppedPolicy
+	audit          AuditSink
+	sendChain      []Interceptor // see Server.UseSend
+	rcvChain       []Interceptor // see Server.UseReceive
+	freshness      time.Duration // freshness window of the commands with time tag, 0 for none
+	sectors        map[asdu.CommonAddr]Sector
+	confirmHandler ConfirmHandler
+	cmdHandler     ServerCommandHandler
+	negConfirm     bool
+	mirror         bool                                     // read-only mirror connection, see Server.SetMirrorFilter
+	access         uint32                                   // Access of the peer, see Server.SetAuthorizer
+	authorize      func(conn net.Conn, startDT bool) Access // authorize the peer again on STARTDT, nil for none
+	forward        func([]byte)                             // forward the sent asdu to the mirror connections
+	soePending     []soePending                             // I-frames carrying sequence of events not acknowledged yet
+	drain          chan struct{}                            // closed by Server.Shutdown to stop the data transfer gracefully
 
 	wg     sync.WaitGroup
 	cancel context.CancelFunc
 	ctx    context.Context
+	life   context.Context // done once the session is served, bounds the waits of the senders
 }
 
 // RecvLoop feeds t.rcvRaw.
@@ -104,6 +148,7 @@ func (sf *SrvSession) recvLoop() {
 				}
 				length = int(rawData[1]) + 2
 				if length < APCICtlFiledSize+2 || length > APDUSizeMax {
+					violation(sf, sf.onViolation, lengthViolation(rawData[:2]))
 					rdCnt, length = 0, 2
 					continue
 				}
@@ -117,40 +162,33 @@ func (sf *SrvSession) recvLoop() {
 	}
 }
 
-// sendLoop drains t.sendTime.
-func (sf *SrvSession) sendLoop() {
-	sf.Debug("sendLoop started!")
-	defer func() {
-		sf.cancel()
-		sf.wg.Done()
-		sf.Debug("sendLoop stopped!")
-	}()
-
-	for {
-		select {
-		case <-sf.ctx.Done():
-			return
-		case apdu := <-sf.sendRaw:
-			sf.Debug("TX Raw[% x]", apdu)
-			for wrCnt := 0; len(apdu) > wrCnt; {
-				byteCount, err := sf.conn.Write(apdu[wrCnt:])
-				if err != nil {
-					// See: https://github.com/golang/go/issues/4373
-					if err != io.EOF && err != io.ErrClosedPipe ||
-						strings.Contains(err.Error(), "use of closed network connection") {
-						sf.Error("sendRaw failed, %v", err)
-						return
-					}
-					if e, ok := err.(net.Error); !ok || !e.Temporary() {
-						sf.Error("sendRaw failed, %v", err)
-						return
-					}
-					// temporary error may be recoverable
-				}
-				wrCnt += byteCount
+// write the apdu to the conn, false if it failed and the connection is canceled
+func (sf *SrvSession) write(apdu []byte) bool {
+	if sf.batch != nil {
+		apdu = sf.batch.collect(sf.ctx, apdu, sf.sendRaw)
+	}
+	sf.Debug("TX Raw[% x]", apdu)
+	for wrCnt := 0; len(apdu) > wrCnt; {
+		byteCount, err := sf.conn.Write(apdu[wrCnt:])
+		if err != nil {
+			// See: https://github.com/golang/go/issues/4373
+			if err != io.EOF && err != io.ErrClosedPipe ||
+				strings.Contains(err.Error(), "use of closed network connection") {
+				sf.Error("sendRaw failed, %v", err)
+				sf.cancel()
+				return false
+			}
+			if e, ok := err.(net.Error); !ok || !e.Temporary() {
+				sf.Error("sendRaw failed, %v", err)
+				sf.cancel()
+				return false
 			}
+			// temporary error may be recoverable
 		}
+		wrCnt += byteCount
 	}
+	putFrame(apdu)
+	return true
 }
 
 // run is the big fat state machine.
@@ -161,56 +199,92 @@ func (sf *SrvSession) run(ctx context.Context) {
 
 	sf.ctx, sf.cancel = context.WithCancel(ctx)
 	sf.setConnectStatus(connected)
-	sf.wg.Add(3)
+	sf.wg.Add(1)
 	go sf.recvLoop()
-	go sf.sendLoop()
-	go sf.handlerLoop()
+	sf.tx.open(sf.ctx, sf.sendRaw, sf.write)
+	sf.rx.open(sf.ctx, sf.rcvASDU, sf.handle)
+	sf.gi.open(sf.ctx, sf.interro, sf.interrogate)
 
 	// default: STOPDT, when connected establish and not enable "data transfer" yet
 	var isActive = false
-	var checkTicker = time.NewTicker(timeoutResolution)
+	var stopDtPending = false // STOPDT act received, confirmed once all I-frames are acknowledged
+	setActive := func(b bool) {
+		isActive = b
+		sf.setActive(b)
+	}
+	setActive(false)
+	var timeout = newTimeout(sf.params.Clock)
+	var armed time.Time // the deadline the timeout is armed at, zero if fired
 
 	// transmission timestamps for timeout calculation
-	var willNotTimeout = time.Now().Add(time.Hour * 24 * 365 * 100)
+	var willNotTimeout = sf.params.Now().Add(time.Hour * 24 * 365 * 100)
 
 	var unAckRcvSince = willNotTimeout
-	var idleTimeout3Sine = time.Now()         // Initiate testFrAlive in idle interval
+	var idleTimeout3Sine = sf.params.Now()    // Initiate testFrAlive in idle interval
 	var testFrAliveSendSince = willNotTimeout // When testFrAlive is initiated, the timeout interval for waiting for a confirmation reply
+	var testFrLastSend = sf.params.Now()      // the last test frame sent, see Keepalive.Interval
 	// For the server side, there is no need for a corresponding U-Frame, no need to judge
 	// var startDtActiveSendSince = willNotTimeout
-	// var stopDtActiveSendSince = willNotTimeout
+	var stopDtActiveSendSince = willNotTimeout // only sent when draining, see Server.Shutdown
+	var draining = false
+	var drain = sf.drain
 
 	sendSFrame := func(rcvSN uint16) {
 		sf.Debug("TX sFrame %v", sAPCI{rcvSN})
 		sf.sendRaw <- newSFrame(rcvSN)
+		sf.tx.kick()
 	}
 	sendUFrame := func(which byte) {
 		sf.Debug("TX uFrame %v", uAPCI{which})
 		sf.sendRaw <- newUFrame(which)
+		sf.tx.kick()
 	}
 
 	sendIFrame := func(asdu1 []byte) {
 		seqNo := sf.seqNoSend
 
 		iframe, err := newIFrame(seqNo, sf.seqNoRcv, asdu1)
+		putASDUBuffer(asdu1)
 		if err != nil {
 			return
 		}
 		sf.ackNoRcv = sf.seqNoRcv
 		sf.seqNoSend = (seqNo + 1) & 32767
-		sf.pending = append(sf.pending, seqPending{seqNo & 32767, time.Now()})
+		sf.pending = append(sf.pending, seqPending{seqNo & 32767, sf.params.Now()})
 
 		sf.Debug("TX iFrame %v", iAPCI{seqNo, sf.seqNoRcv})
 		sf.sendRaw <- iframe
+		sf.tx.kick()
+	}
+	// sendSOE send the next sequence of events, they are always sent before any fresh data.
+	sendSOE := func() bool {
+		if sf.soe == nil || sf.mirror {
+			return false
+		}
+		data, n := sf.soe.next(sf)
+		if data == nil {
+			return false
+		}
+		sf.soePending = append(sf.soePending, soePending{sf.seqNoSend, n})
+		sendIFrame(data)
+		return true
 	}
 	if sf.onConnection != nil {
 		sf.onConnection(sf)
 	}
 	defer func() {
 		sf.setConnectStatus(disconnected)
-		checkTicker.Stop()
+		timeout.stop()
 		_ = sf.conn.Close() // chain trigger cancel
 		sf.wg.Wait()
+		sf.cancel()
+		sf.tx.close()
+		sf.rx.close()
+		sf.gi.close()
+		if sf.soe != nil { // not acknowledged events will be sent again on next connection
+			sf.soe.release(sf)
+		}
+		sf.win.wakeup()
 		if sf.connectionLost != nil {
 			sf.connectionLost(sf)
 		}
@@ -218,26 +292,71 @@ func (sf *SrvSession) run(ctx context.Context) {
 	}()
 
 	for {
+		sf.win.update(sf.seqNoSend, sf.ackNoSend, sf.seqNoRcv, sf.ackNoRcv)
+		if draining && !isActive && stopDtActiveSendSince == willNotTimeout && sf.ackNoSend == sf.seqNoSend {
+			sf.Debug("data transfer stopped, all I-frames acknowledged")
+			return
+		}
+		if stopDtPending && sf.ackNoSend == sf.seqNoSend {
+			if sf.ackNoRcv != sf.seqNoRcv {
+				sendSFrame(sf.seqNoRcv)
+				sf.ackNoRcv = sf.seqNoRcv
+			}
+			sendUFrame(uStopDtConfirm)
+			stopDtPending = false
+		}
 		if isActive && seqNoCount(sf.ackNoSend, sf.seqNoSend) <= sf.config.SendUnAckLimitK {
-			select {
-			case o := <-sf.sendASDU:
+			if sendSOE() {
+				idleTimeout3Sine = sf.params.Now()
+				continue
+			}
+			if o, ok := sf.nextASDU(); ok {
 				sendIFrame(o)
-				idleTimeout3Sine = time.Now()
+				idleTimeout3Sine = sf.params.Now()
 				continue
-			case <-sf.ctx.Done():
-				return
-			default: // make no block
+			}
+			if draining { // everything queued is sent
+				sendUFrame(uStopDtActive)
+				setActive(false)
+				stopDtActiveSendSince = sf.params.Now()
 			}
 		}
+		// arm the timeout at the earliest deadline, the checks tell which one elapsed
+		next := earliest(idleTimeout3Sine.Add(sf.config.IdleTimeout3),
+			stopDtActiveSendSince.Add(sf.config.SendUnAckTimeout1), testFrAliveSendSince.Add(sf.config.SendUnAckTimeout1))
+		if sf.ackNoSend != sf.seqNoSend {
+			next = earliest(next, sf.pending[0].sendTime.Add(sf.config.SendUnAckTimeout1))
+		}
+		if sf.ackNoRcv != sf.seqNoRcv {
+			next = earliest(next, unAckRcvSince.Add(sf.config.RecvUnAckTimeout2), idleTimeout3Sine.Add(timeoutResolution))
+		}
+		if sf.keepalive.Interval > 0 && testFrAliveSendSince == willNotTimeout {
+			next = earliest(next, testFrLastSend.Add(sf.keepalive.Interval))
+		}
+		if armed.IsZero() || next.Before(armed) {
+			timeout.earlier(next)
+			armed = next
+		}
 		select {
 		case <-sf.ctx.Done():
 			return
-		case now := <-checkTicker.C:
+		case <-sf.notify:
+		case <-drain:
+			drain = nil
+			draining = true
+		case now := <-timeout.fired():
+			armed = time.Time{}
 			// check all timeouts
+			if now.Sub(stopDtActiveSendSince) >= sf.config.SendUnAckTimeout1 {
+				sf.Error("stop data transfer confirm timeout t₁")
+				violation(sf, sf.onViolation, timeout1Violation(sf.config.SendUnAckTimeout1, "STOPDT act"))
+				return
+			}
 			if now.Sub(testFrAliveSendSince) >= sf.config.SendUnAckTimeout1 {
 				// now.Sub(startDtActiveSendSince) >= t.SendUnAckTimeout1 ||
 				// now.Sub(stopDtActiveSendSince) >= t.SendUnAckTimeout1 ||
 				sf.Error("test frame alive confirm timeout t₁")
+				violation(sf, sf.onViolation, timeout1Violation(sf.config.SendUnAckTimeout1, "TESTFR act"))
 				return
 			}
 			// check oldest unacknowledged outbound
@@ -246,6 +365,7 @@ func (sf *SrvSession) run(ctx context.Context) {
 				now.Sub(sf.pending[0].sendTime) >= sf.config.SendUnAckTimeout1 {
 				sf.ackNoSend++
 				sf.Error("fatal transmission timeout t₁")
+				violation(sf, sf.onViolation, timeout1Violation(sf.config.SendUnAckTimeout1, "I frame"))
 				return
 			}
 
@@ -258,20 +378,26 @@ func (sf *SrvSession) run(ctx context.Context) {
 			}
 
 			// When the idle time is up, send a TestFrActive frame to keep alive
-			if now.Sub(idleTimeout3Sine) >= sf.config.IdleTimeout3 {
+			// or every keepalive interval, one test frame outstanding at most
+			if now.Sub(idleTimeout3Sine) >= sf.config.IdleTimeout3 ||
+				(sf.keepalive.Interval > 0 && testFrAliveSendSince == willNotTimeout &&
+					now.Sub(testFrLastSend) >= sf.keepalive.Interval) {
 				sendUFrame(uTestFrActive)
-				testFrAliveSendSince = time.Now()
+				testFrAliveSendSince = sf.params.Now()
+				testFrLastSend = testFrAliveSendSince
 				idleTimeout3Sine = testFrAliveSendSince
 			}
 
 		case apdu := <-sf.rcvRaw:
-			idleTimeout3Sine = time.Now() // Every time an i frame, S frame, U frame is received, the idle timer is reset, t3
+			idleTimeout3Sine = sf.params.Now() // Every time an i frame, S frame, U frame is received, the idle timer is reset, t3
 			apci, asduVal := parse(apdu)
 			switch head := apci.(type) {
 			case sAPCI:
 				sf.Debug("RX sFrame %v", head)
 				if !sf.updateAckNoOut(head.rcvSN) {
-					sf.Error("fatal incoming acknowledge either earlier than previous or later than sendTime")
+					sf.win.fail(ErrSeqNoAck)
+					sf.Error("fatal incoming acknowledge N(R) %d outside the window [%d, %d]", head.rcvSN, sf.ackNoSend, sf.seqNoSend)
+					violation(sf, sf.onViolation, seqNoAckViolation(apdu, head.rcvSN, sf.ackNoSend, sf.seqNoSend))
 					return
 				}
 
@@ -281,14 +407,23 @@ func (sf *SrvSession) run(ctx context.Context) {
 					sf.Warn("station not active")
 					break // not active, discard apdu
 				}
-				if !sf.updateAckNoOut(head.rcvSN) || head.sendSN != sf.seqNoRcv {
-					sf.Error("fatal incoming acknowledge either earlier than previous or later than sendTime")
+				if !sf.updateAckNoOut(head.rcvSN) {
+					sf.win.fail(ErrSeqNoAck)
+					sf.Error("fatal incoming acknowledge N(R) %d outside the window [%d, %d]", head.rcvSN, sf.ackNoSend, sf.seqNoSend)
+					violation(sf, sf.onViolation, seqNoAckViolation(apdu, head.rcvSN, sf.ackNoSend, sf.seqNoSend))
+					return
+				}
+				if head.sendSN != sf.seqNoRcv {
+					sf.win.fail(ErrSeqNoSend)
+					sf.Error("fatal incoming sequence number N(S) %d, expected %d", head.sendSN, sf.seqNoRcv)
+					violation(sf, sf.onViolation, seqNoSendViolation(apdu, head.sendSN, sf.seqNoRcv))
 					return
 				}
 
 				sf.rcvASDU <- asduVal
+				sf.rx.kick()
 				if sf.ackNoRcv == sf.seqNoRcv { // first unacked
-					unAckRcvSince = time.Now()
+					unAckRcvSince = sf.params.Now()
 				}
 
 				sf.seqNoRcv = (sf.seqNoRcv + 1) & 32767
@@ -301,20 +436,40 @@ func (sf *SrvSession) run(ctx context.Context) {
 				sf.Debug("RX uFrame %v", head)
 				switch head.function {
 				case uStartDtActive:
+					if sf.authorize != nil {
+						access := sf.authorize(sf.conn, true)
+						if access == AccessDeny {
+							return
+						}
+						sf.setAccess(access)
+					}
 					sendUFrame(uStartDtConfirm)
-					isActive = true
+					setActive(true)
+					stopDtPending = false
+					if sf.endOfInit != nil { // sent aside, the rate limit must not block the state machine
+						go sf.endOfInit.startDt(sf)
+					}
 				// case uStartDtConfirm:
 				// 	isActive = true
 				// 	startDtActiveSendSince = willNotTimeout
 				case uStopDtActive:
-					sendUFrame(uStopDtConfirm)
-					isActive = false
-				// case uStopDtConfirm:
-				// 	isActive = false
-				// 	stopDtActiveSendSince = willNotTimeout
+					// stop sending at once, confirm once the I-frames sent are acknowledged
+					setActive(false)
+					stopDtPending = true
+					if sf.stopped == StoppedDiscard {
+						sf.discardQueued()
+					}
+				case uStopDtConfirm:
+					setActive(false)
+					stopDtActiveSendSince = willNotTimeout
 				case uTestFrActive:
 					sendUFrame(uTestFrConfirm)
 				case uTestFrConfirm:
+					if testFrAliveSendSince != willNotTimeout &&
+						sf.rtt.record(sf.params.Now().Sub(testFrAliveSendSince), sf.keepalive) {
+						sf.Error("test frame round trip time exceeds %v repeatedly", sf.keepalive.MaxRTT)
+						return
+					}
 					testFrAliveSendSince = willNotTimeout
 				default:
 					sf.Error("illegal U-Frame functions[0x%02x] ignored", head.function)
@@ -324,29 +479,28 @@ func (sf *SrvSession) run(ctx context.Context) {
 	}
 }
 
-// handlerLoop handler iFrame asdu
-func (sf *SrvSession) handlerLoop() {
-	sf.Debug("handlerLoop started")
-	defer func() {
-		sf.wg.Done()
-		sf.Debug("handlerLoop stopped")
-	}()
-
-	for {
-		select {
-		case <-sf.ctx.Done():
-			return
-		case rawAsdu := <-sf.rcvASDU:
-			asduPack := asdu.NewEmptyASDU(sf.params)
-			if err := asduPack.UnmarshalBinary(rawAsdu); err != nil {
-				sf.Error("asdu UnmarshalBinary failed,%+v", err)
-				continue
-			}
-			if err := sf.serverHandler(asduPack); err != nil {
-				sf.Error("serverHandler falied,%+v", err)
+// handle handler iFrame asdu
+func (sf *SrvSession) handle(rawAsdu []byte) bool {
+	asduPack := asdu.NewEmptyASDU(sf.params)
+	if err := asduPack.UnmarshalBinary(rawAsdu); err != nil {
+		sf.Error("asdu UnmarshalBinary failed,%+v", err)
+		if err == asdu.ErrTypeIdentifier && sf.negConfirm { // the identifier is decoded
+			if err = sf.reject(asduPack, asdu.UnknownTypeID); err != nil {
+				sf.Error("reject unknown type identification failed,%+v", err)
 			}
 		}
+		return true
 	}
+	if err := intercept(sf.rcvChain, sf.receive)(sf, asduPack); err != nil {
+		sf.Error("serverHandler falied,%+v", err)
+	}
+	return true
+}
+
+// receive the asdu passed by the receive interceptors
+func (sf *SrvSession) receive(_ asdu.Connect, asduPack *asdu.ASDU) error {
+	audit(sf.audit, sf.conn, asduPack)
+	return sf.serverHandler(asduPack)
 }
 
 func (sf *SrvSession) setConnectStatus(status uint32) {
@@ -368,6 +522,7 @@ func (sf *SrvSession) cleanUp() {
 	sf.seqNoRcv = 0
 	sf.seqNoSend = 0
 	sf.pending = nil
+	sf.soePending = nil
 	// clear sending chan buffer
 loop:
 	for {
@@ -376,12 +531,27 @@ loop:
 		case <-sf.rcvRaw:
 		case <-sf.rcvASDU:
 		case <-sf.sendASDU:
+		case <-sf.sendHigh:
+		case <-sf.sendLow:
+		case <-sf.interro:
 		default:
 			break loop
 		}
 	}
 }
 
+// nextASDU take the next asdu to send in the order of priority
+func (sf *SrvSession) nextASDU() ([]byte, bool) {
+	for _, ch := range [...]chan []byte{sf.sendHigh, sf.sendASDU, sf.sendLow} {
+		select {
+		case o := <-ch:
+			return o, true
+		default:
+		}
+	}
+	return nil, false
+}
+
 // rewind mechanism
 func seqNoCount(nextAckNo, nextSeqNo uint16) uint16 {
 	if nextAckNo > nextSeqNo {
@@ -401,12 +571,23 @@ func (sf *SrvSession) updateAckNoOut(ackNo uint16) (ok bool) {
 
 	// confirm reception
 	for i, v := range sf.pending {
-		if v.seq == (ackNo - 1) {
+		if v.seq == (ackNo-1)&32767 { // rollover 32767 -> 0
 			sf.pending = sf.pending[i+1:]
 			break
 		}
 	}
 
+	// confirm sequence of events
+	var n int
+	for len(sf.soePending) > 0 &&
+		seqNoCount(sf.ackNoSend, sf.soePending[0].seq) < seqNoCount(sf.ackNoSend, ackNo) {
+		n += sf.soePending[0].count
+		sf.soePending = sf.soePending[1:]
+	}
+	if n > 0 {
+		sf.soe.ack(sf, n)
+	}
+
 	sf.ackNoSend = ackNo
 	return true
 }
@@ -418,105 +599,230 @@ func (sf *SrvSession) serverHandler(asduPack *asdu.ASDU) error {
 		}
 	}()
 
-	sf.Debug("ASDU %+v", asduPack)
+	sf.Debug("ASDU %v", describeASDU(sf.Clog, asduPack))
+	if sf.mirror { // control direction never accepted from a mirror connection
+		return sf.Send(asduPack.Mirror(asdu.Unused, true))
+	}
+	if sf.readOnly() && isControl(asduPack.Identifier.Type) {
+		securityEvent(sf.Clog, sf.onSecurity, sf.conn, asduPack.Identifier, "read-only")
+		return sf.Send(asduPack.Mirror(asdu.Unused, true))
+	}
+	if p := sf.params.Profile; p != nil && !p.Allows(asduPack.Identifier.Type) {
+		return negativeMirror(sf, asduPack, asdu.UnknownTypeID)
+	}
+	if err := asduPack.CheckTimeTags(); err != nil {
+		sf.Warn("reject asdu, %v", err)
+		return sf.Send(asduPack.Mirror(asdu.Unused, true))
+	}
+	if sf.freshness > 0 && isTimeTaggedCommand(asduPack.Type) {
+		if err := checkFreshness(asduPack, sf.freshness, sf.params.Now()); err != nil {
+			sf.Warn("reject %v, %v", asduPack.Identifier, err)
+			return sf.Send(asduPack.Mirror(asdu.Unused, true))
+		}
+	}
+	origin := asduPack.Clone() // decoding consumes the information object, keep it for the mirror
+
+	if asduPack.CommonAddr == asdu.GlobalCommonAddr && (len(sf.commonAddrs) > 0 || sf.peerCAs != nil) {
+		return sf.broadcastHandler(asduPack)
+	}
+	if sf.peerCAs != nil && !hasCommonAddr(sf.peerCAs, asduPack.CommonAddr) {
+		return negativeMirror(sf, asduPack, asdu.UnknownCA)
+	}
+	if len(sf.commonAddrs) > 0 && !hasCommonAddr(sf.commonAddrs, asduPack.CommonAddr) {
+		return negativeMirror(sf, asduPack, asdu.UnknownCA)
+	}
+	conf := sf.configuration()
+	handler, pointStore := sf.handler, conf.PointStore
+	if sector, ok := sf.sectors[asduPack.CommonAddr]; ok {
+		if sector.Handler != nil {
+			handler = sector.Handler
+		}
+		if sector.PointStore != nil {
+			pointStore = sector.PointStore
+		}
+	}
+
+	if sf.delayAcq && asduPack.Identifier.Type == asdu.C_CD_NA_1 {
+		return sf.delayHandler(origin, asduPack)
+	}
+	if sf.resetHook != nil && asduPack.Identifier.Type == asdu.C_RP_NA_1 {
+		return sf.resetHandler(origin, asduPack)
+	}
+	if sf.cmdHandler != nil && isProcessCommand(asduPack.Identifier.Type) {
+		return confirmDispatch(sf, commandConfirmHandler{sf.cmdHandler}, asduPack)
+	}
+	if sf.confirmHandler != nil && isConfirmable(asduPack.Identifier.Type) &&
+		!(conf.Deadband != nil && asduPack.Identifier.Type >= asdu.P_ME_NA_1 && asduPack.Identifier.Type <= asdu.P_AC_NA_1) {
+		return confirmDispatch(sf, sf.confirmHandler, asduPack)
+	}
 
 	switch asduPack.Identifier.Type {
 	case asdu.C_IC_NA_1: // InterrogationCmd
 		if !(asduPack.Identifier.Coa.Cause == asdu.Activation ||
 			asduPack.Identifier.Coa.Cause == asdu.Deactivation) {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCOT)
+			return sf.reject(origin, asdu.UnknownCOT)
 		}
 		if asduPack.CommonAddr == asdu.InvalidCommonAddr {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCA)
+			return sf.reject(origin, asdu.UnknownCA)
 		}
 		ioa, qoi := asduPack.GetInterrogationCmd()
 		if ioa != asdu.InfoObjAddrIrrelevant {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownIOA)
+			return sf.reject(origin, asdu.UnknownIOA)
+		}
+		if sf.shaping != nil {
+			return sf.enqueueInterrogation(handler, asduPack, qoi)
 		}
-		return sf.handler.InterrogationHandler(sf, asduPack, qoi)
+		return handler.InterrogationHandler(sf, asduPack, qoi)
 
 	case asdu.C_CI_NA_1: // CounterInterrogationCmd
 		if asduPack.Identifier.Coa.Cause != asdu.Activation {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCOT)
+			return sf.reject(origin, asdu.UnknownCOT)
 		}
 		if asduPack.CommonAddr == asdu.InvalidCommonAddr {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCA)
+			return sf.reject(origin, asdu.UnknownCA)
 		}
 		ioa, qcc := asduPack.GetCounterInterrogationCmd()
 		if ioa != asdu.InfoObjAddrIrrelevant {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownIOA)
+			return sf.reject(origin, asdu.UnknownIOA)
 		}
-		return sf.handler.CounterInterrogationHandler(sf, asduPack, qcc)
+		if conf.Counters != nil {
+			return conf.Counters.CounterInterrogationHandler(sf, asduPack, qcc)
+		}
+		return handler.CounterInterrogationHandler(sf, asduPack, qcc)
 
 	case asdu.C_RD_NA_1: // ReadCmd
 		if asduPack.Identifier.Coa.Cause != asdu.Request {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCOT)
+			return sf.reject(origin, asdu.UnknownCOT)
 		}
 		if asduPack.CommonAddr == asdu.InvalidCommonAddr {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCA)
+			return sf.reject(origin, asdu.UnknownCA)
+		}
+		if pointStore != nil {
+			return readHandler(sf, asduPack, pointStore)
 		}
-		return sf.handler.ReadHandler(sf, asduPack, asduPack.GetReadCmd())
+		return handler.ReadHandler(sf, asduPack, asduPack.GetReadCmd())
 
 	case asdu.C_CS_NA_1: // ClockSynchronizationCmd
 		if asduPack.Identifier.Coa.Cause != asdu.Activation {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCOT)
+			return sf.reject(origin, asdu.UnknownCOT)
 		}
 		if asduPack.CommonAddr == asdu.InvalidCommonAddr {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCA)
+			return sf.reject(origin, asdu.UnknownCA)
 		}
 
 		ioa, tm := asduPack.GetClockSynchronizationCmd()
 		if ioa != asdu.InfoObjAddrIrrelevant {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownIOA)
+			return sf.reject(origin, asdu.UnknownIOA)
 		}
-		return sf.handler.ClockSyncHandler(sf, asduPack, tm)
+		if sf.delayAcq {
+			tm = tm.Add(sf.delay.get())
+		}
+		return handler.ClockSyncHandler(sf, asduPack, tm)
 
 	case asdu.C_TS_NA_1: // TestCommand
 		if asduPack.Identifier.Coa.Cause != asdu.Activation {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCOT)
+			return sf.reject(origin, asdu.UnknownCOT)
 		}
 		if asduPack.CommonAddr == asdu.InvalidCommonAddr {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCA)
+			return sf.reject(origin, asdu.UnknownCA)
 		}
 		ioa, _ := asduPack.GetTestCommand()
 		if ioa != asdu.InfoObjAddrIrrelevant {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownIOA)
+			return sf.reject(origin, asdu.UnknownIOA)
 		}
-		return asduPack.SendReplyMirror(sf, asdu.ActivationCon)
+		return origin.SendReplyMirror(sf, asdu.ActivationCon)
 
 	case asdu.C_RP_NA_1: // ResetProcessCmd
 		if asduPack.Identifier.Coa.Cause != asdu.Activation {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCOT)
+			return sf.reject(origin, asdu.UnknownCOT)
 		}
 		if asduPack.CommonAddr == asdu.InvalidCommonAddr {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCA)
+			return sf.reject(origin, asdu.UnknownCA)
 		}
 		ioa, qrp := asduPack.GetResetProcessCmd()
 		if ioa != asdu.InfoObjAddrIrrelevant {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownIOA)
+			return sf.reject(origin, asdu.UnknownIOA)
 		}
-		return sf.handler.ResetProcessHandler(sf, asduPack, qrp)
+		return handler.ResetProcessHandler(sf, asduPack, qrp)
 	case asdu.C_CD_NA_1: // DelayAcquireCommand
 		if !(asduPack.Identifier.Coa.Cause == asdu.Activation ||
 			asduPack.Identifier.Coa.Cause == asdu.Spontaneous) {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCOT)
+			return sf.reject(origin, asdu.UnknownCOT)
 		}
 		if asduPack.CommonAddr == asdu.InvalidCommonAddr {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownCA)
+			return sf.reject(origin, asdu.UnknownCA)
 		}
 		ioa, msec := asduPack.GetDelayAcquireCommand()
 		if ioa != asdu.InfoObjAddrIrrelevant {
-			return asduPack.SendReplyMirror(sf, asdu.UnknownIOA)
+			return sf.reject(origin, asdu.UnknownIOA)
+		}
+		return handler.DelayAcquisitionHandler(sf, asduPack, msec)
+
+	case asdu.P_ME_NA_1, asdu.P_ME_NB_1, asdu.P_ME_NC_1, asdu.P_AC_NA_1: // parameter command
+		if conf.Deadband != nil {
+			return conf.Deadband.ParameterHandler(sf, asduPack)
 		}
-		return sf.handler.DelayAcquisitionHandler(sf, asduPack, msec)
 	}
 
-	if err := sf.handler.ASDUHandler(sf, asduPack); err != nil {
-		return asduPack.SendReplyMirror(sf, asdu.UnknownTypeID)
+	if err := handler.ASDUHandler(sf, asduPack); err != nil {
+		return sf.reject(origin, asdu.UnknownTypeID)
 	}
 	return nil
 }
 
+// reject mirror the asdu back with the cause, with IsNegative set if negative confirmation enabled
+func (sf *SrvSession) reject(origin *asdu.ASDU, cause asdu.Cause) error {
+	if sf.negConfirm {
+		origin.Coa.IsNegative = true
+	}
+	return origin.SendReplyMirror(sf, cause)
+}
+
+// broadcastHandler fan out the command with the global common address to all the logical stations,
+// each of them answers with its own common address.
+// only [C_IC_NA_1], [C_CI_NA_1], [C_CS_NA_1] and [C_RP_NA_1] may be broadcast.
+func (sf *SrvSession) broadcastHandler(asduPack *asdu.ASDU) error {
+	switch asduPack.Identifier.Type {
+	case asdu.C_IC_NA_1, asdu.C_CI_NA_1, asdu.C_CS_NA_1, asdu.C_RP_NA_1:
+	default:
+		return negativeMirror(sf, asduPack, asdu.UnknownCA)
+	}
+
+	cas := sf.commonAddrs
+	if sf.peerCAs != nil {
+		if len(cas) == 0 {
+			cas = sf.peerCAs
+		} else {
+			cas = make([]asdu.CommonAddr, 0, len(sf.commonAddrs))
+			for _, ca := range sf.commonAddrs {
+				if hasCommonAddr(sf.peerCAs, ca) {
+					cas = append(cas, ca)
+				}
+			}
+		}
+	}
+
+	var err error
+	for _, ca := range cas {
+		pack := asduPack.Clone()
+		pack.CommonAddr = ca
+		if e := sf.serverHandler(pack); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// hasCommonAddr whether the common address is in cas
+func hasCommonAddr(cas []asdu.CommonAddr, ca asdu.CommonAddr) bool {
+	for _, v := range cas {
+		if v == ca {
+			return true
+		}
+	}
+	return false
+}
+
 // IsConnected get server session connected state
 func (sf *SrvSession) IsConnected() bool {
 	return sf.connectStatus() == connected
@@ -529,21 +835,119 @@ func (sf *SrvSession) Params() *asdu.Params {
 
 // Send asdu frame
 func (sf *SrvSession) Send(u *asdu.ASDU) error {
-	if !sf.IsConnected() {
-		return ErrUseClosedConnection
+	if len(sf.sendChain) == 0 {
+		return sf.transmit(sf, u)
 	}
-	data, err := u.MarshalBinary()
+	return intercept(sf.sendChain, sf.transmit)(sf, u)
+}
+
+// transmit the asdu passed by the send interceptors
+func (sf *SrvSession) transmit(_ asdu.Connect, u *asdu.ASDU) error {
+	data, err := sf.send(u)
 	if err != nil {
 		return err
 	}
+	audit(sf.audit, sf.conn, u)
+	if sf.forward != nil {
+		sf.forward(data)
+	}
+	return nil
+}
+
+// send asdu frame only to the session
+func (sf *SrvSession) send(u *asdu.ASDU) ([]byte, error) {
+	if !sf.IsConnected() {
+		return nil, ErrUseClosedConnection
+	}
+	raw, err := u.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	life := sf.life
+	if life == nil {
+		life = context.Background()
+	}
+	for _, l := range sf.limiters {
+		if err = l.Wait(life, u, len(raw)); err != nil {
+			return nil, err
+		}
+	}
+	ch := sf.sendASDU
+	if sf.priority != nil {
+		switch sf.priority(u) {
+		case PriorityHigh:
+			ch = sf.sendHigh
+		case PriorityLow:
+			ch = sf.sendLow
+		}
+	}
+	// a pooled copy, so that the asdu may be released once sent, see asdu.ReleaseASDU
+	data := append(getASDUBuffer(), raw...)
+	if err = sf.enqueue(ch, data); err != nil {
+		putASDUBuffer(data)
+		return nil, err
+	}
+	return raw, nil
+}
+
+func (sf *SrvSession) sendData(data []byte) error {
+	return sf.enqueue(sf.sendASDU, data)
+}
+
+func (sf *SrvSession) enqueue(ch chan []byte, data []byte) error {
+	if !sf.isActive() {
+		if handled, err := sf.enqueueStopped(ch, data); handled {
+			return err
+		}
+	}
 	select {
-	case sf.sendASDU <- data:
+	case ch <- data:
 	default:
 		return ErrBufferFulled
 	}
+	sf.signal()
 	return nil
 }
 
+// signal wake the state machine to send the asdu or events queued
+func (sf *SrvSession) signal() {
+	select {
+	case sf.notify <- struct{}{}:
+	default:
+	}
+}
+
+// SendContext send the asdu, blocks while the k window is full of unacknowledged and queued asdu,
+// so that the upper layer applies backpressure instead of buffering unboundedly.
+func (sf *SrvSession) SendContext(ctx context.Context, a *asdu.ASDU) error {
+	return sendContext(ctx, sf, a)
+}
+
+// TrySend send the asdu, returns ErrWindowFull at once if the k window is full
+func (sf *SrvSession) TrySend(a *asdu.ASDU) error {
+	return trySend(sf, a)
+}
+
+// Err returns the sequence number error the last connection was closed for, nil if none
+func (sf *SrvSession) Err() error {
+	return sf.win.err()
+}
+
+// RTTStats returns the round trip time statistics of the test frames
+func (sf *SrvSession) RTTStats() RTTStats {
+	return sf.rtt.get()
+}
+
+// WindowStats returns the current k/w flow control state
+func (sf *SrvSession) WindowStats() WindowStats {
+	stats, _ := sf.windowStats()
+	return stats
+}
+
+func (sf *SrvSession) windowStats() (WindowStats, <-chan struct{}) {
+	return sf.win.stats(sf.config, len(sf.sendHigh)+len(sf.sendASDU)+len(sf.sendLow))
+}
+
 // UnderlyingConn got under net.conn
 func (sf *SrvSession) UnderlyingConn() net.Conn {
 	return sf.conn
@@ -0,0 +1,228 @@
+package cs104
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// mockServerHandler records the common address of the handled commands
+type mockServerHandler struct {
+	cas []asdu.CommonAddr
+}
+
+func (sf *mockServerHandler) confirm(_ asdu.Connect, pack *asdu.ASDU) error {
+	sf.cas = append(sf.cas, pack.CommonAddr)
+	return nil
+}
+
+func (sf *mockServerHandler) InterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfInterrogation) error {
+	return sf.confirm(c, pack)
+}
+func (sf *mockServerHandler) CounterInterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierCountCall) error {
+	return sf.confirm(c, pack)
+}
+func (sf *mockServerHandler) ReadHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.InfoObjAddr) error {
+	return sf.confirm(c, pack)
+}
+func (sf *mockServerHandler) ClockSyncHandler(c asdu.Connect, pack *asdu.ASDU, _ time.Time) error {
+	return sf.confirm(c, pack)
+}
+func (sf *mockServerHandler) ResetProcessHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfResetProcessCmd) error {
+	return sf.confirm(c, pack)
+}
+func (sf *mockServerHandler) DelayAcquisitionHandler(c asdu.Connect, pack *asdu.ASDU, _ uint16) error {
+	return sf.confirm(c, pack)
+}
+func (sf *mockServerHandler) ASDUHandler(c asdu.Connect, pack *asdu.ASDU) error {
+	return errors.New("not support")
+}
+
+// newTestSession new a connected session without the underlying connection
+func newTestSession(h ServerHandlerInterface) *SrvSession {
+	return &SrvSession{
+		config:   &Config{},
+		params:   asdu.ParamsWide,
+		handler:  h,
+		sendASDU: make(chan []byte, 1024),
+		status:   connected,
+	}
+}
+
+// sent returns the asdu sent by the session
+func (sf *SrvSession) sent(t *testing.T) []*asdu.ASDU {
+	var r []*asdu.ASDU
+	for {
+		select {
+		case data := <-sf.sendASDU:
+			a := asdu.NewEmptyASDU(sf.params)
+			if err := a.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			r = append(r, a)
+		default:
+			return r
+		}
+	}
+}
+
+func TestSrvSession_broadcast(t *testing.T) {
+	h := &mockServerHandler{}
+	sess := newTestSession(h)
+	sess.commonAddrs = []asdu.CommonAddr{1, 2}
+	c := &recordConn{}
+
+	if err := asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation},
+		asdu.GlobalCommonAddr, asdu.QOIStation); err != nil {
+		t.Fatal(err)
+	}
+	if err := asdu.SingleCmd(c, asdu.C_SC_NA_1, asdu.CauseOfTransmission{Cause: asdu.Activation},
+		asdu.GlobalCommonAddr, asdu.SingleCommandInfo{Ioa: 1, Value: true}); err != nil {
+		t.Fatal(err)
+	}
+	reqs := c.take()
+
+	if err := sess.serverHandler(reqs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.cas, []asdu.CommonAddr{1, 2}) {
+		t.Fatalf("broadcast interrogation handled by %v", h.cas)
+	}
+
+	if err := sess.serverHandler(reqs[1]); err != nil {
+		t.Fatal(err)
+	}
+	want := asdu.CauseOfTransmission{Cause: asdu.UnknownCA, IsNegative: true}
+	if sent := sess.sent(t); len(sent) != 1 || sent[0].Coa != want {
+		t.Errorf("broadcast single command answered %v, want %v", sent, want)
+	}
+}
+
+func TestSrvSession_peerBinding(t *testing.T) {
+	h := &mockServerHandler{}
+	sess := newTestSession(h)
+	sess.commonAddrs = []asdu.CommonAddr{1, 2, 3}
+	sess.peerCAs = []asdu.CommonAddr{1, 3}
+	c := &recordConn{}
+
+	for _, ca := range []asdu.CommonAddr{asdu.GlobalCommonAddr, 2} {
+		if err := asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, ca, asdu.QOIStation); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reqs := c.take()
+
+	if err := sess.serverHandler(reqs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.cas, []asdu.CommonAddr{1, 3}) {
+		t.Fatalf("broadcast interrogation handled by %v", h.cas)
+	}
+
+	if err := sess.serverHandler(reqs[1]); err != nil {
+		t.Fatal(err)
+	}
+	want := asdu.CauseOfTransmission{Cause: asdu.UnknownCA, IsNegative: true}
+	if sent := sess.sent(t); len(sent) != 1 || sent[0].Coa != want || sent[0].CommonAddr != 2 {
+		t.Errorf("cross bound interrogation answered %v, want %v", sent, want)
+	}
+}
+
+func TestSrvSession_sectors(t *testing.T) {
+	h1, h2 := &mockServerHandler{}, &mockServerHandler{}
+	sess := newTestSession(h1)
+	sess.commonAddrs = []asdu.CommonAddr{1, 2}
+	sess.sectors = map[asdu.CommonAddr]Sector{2: {Handler: h2}}
+	c := &recordConn{}
+
+	for _, ca := range []asdu.CommonAddr{1, 2, 3} {
+		if err := asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, ca, asdu.QOIStation); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, req := range c.take() {
+		if err := sess.serverHandler(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(h1.cas, []asdu.CommonAddr{1}) || !reflect.DeepEqual(h2.cas, []asdu.CommonAddr{2}) {
+		t.Errorf("sector handlers handled %v, %v", h1.cas, h2.cas)
+	}
+	want := asdu.CauseOfTransmission{Cause: asdu.UnknownCA, IsNegative: true}
+	if sent := sess.sent(t); len(sent) != 1 || sent[0].CommonAddr != 3 || sent[0].Coa != want {
+		t.Errorf("unknown common address answered %v, want %v", sent, want)
+	}
+}
+
+func TestSrvSession_negativeConfirm(t *testing.T) {
+	sess := newTestSession(&mockServerHandler{})
+	sess.negConfirm = true
+	c := &recordConn{}
+
+	if err := asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation); err != nil {
+		t.Fatal(err)
+	}
+	// handled by ASDUHandler which does not support it
+	if err := asdu.SingleCmd(c, asdu.C_SC_NA_1, asdu.CauseOfTransmission{Cause: asdu.Activation},
+		1, asdu.SingleCommandInfo{Ioa: 1, Value: true}); err != nil {
+		t.Fatal(err)
+	}
+	reqs := c.take()
+	reqs[0].Coa.Cause = asdu.Spontaneous
+
+	want := []asdu.CauseOfTransmission{
+		{Cause: asdu.UnknownCOT, IsNegative: true},
+		{Cause: asdu.UnknownTypeID, IsNegative: true},
+	}
+	for i, req := range reqs {
+		if err := sess.serverHandler(req); err != nil {
+			t.Fatal(err)
+		}
+		if sent := sess.sent(t); len(sent) != 1 || sent[0].Coa != want[i] {
+			t.Errorf("serverHandler() answered %v, want %v", sent, want[i])
+		}
+	}
+}
+
+func TestServer_mirror(t *testing.T) {
+	h := &mockServerHandler{}
+	srv := NewServer(h)
+	primary, mirror := newTestSession(h), newTestSession(h)
+	primary.forward = srv.forwardToMirrors
+	mirror.mirror = true
+	srv.sessions[primary] = struct{}{}
+	srv.sessions[mirror] = struct{}{}
+
+	if err := asdu.Single(primary, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+		asdu.SinglePointInfo{Ioa: 1, Value: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := asdu.Single(srv, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+		asdu.SinglePointInfo{Ioa: 2, Value: true}); err != nil {
+		t.Fatal(err)
+	}
+	if sent := primary.sent(t); len(sent) != 2 {
+		t.Errorf("primary sent %v", sent)
+	}
+	if sent := mirror.sent(t); len(sent) != 2 {
+		t.Errorf("mirror sent %v", sent)
+	}
+
+	c := &recordConn{}
+	if err := asdu.InterrogationCmd(c, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation); err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.serverHandler(c.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	want := asdu.CauseOfTransmission{Cause: asdu.ActivationCon, IsNegative: true}
+	if sent := mirror.sent(t); len(sent) != 1 || sent[0].Coa != want || len(h.cas) != 0 {
+		t.Errorf("mirror answered %v, want %v", sent, want)
+	}
+	if sent := primary.sent(t); len(sent) != 0 {
+		t.Errorf("primary got the answer of the mirror %v", sent)
+	}
+}
@@ -6,7 +6,6 @@ package cs104
 
 import (
 	"context"
-	"errors"
 	"math/rand"
 	"sync/atomic"
 	"time"
@@ -22,6 +21,7 @@ type ServerSpecial interface {
 	IsConnected() bool
 	IsClosed() bool
 	Start() error
+	StartContext(ctx context.Context) error
 	Close() error
 
 	SetOnConnectHandler(f func(c asdu.Connect))
@@ -49,6 +49,7 @@ func NewServerSpecial(handler ServerHandlerInterface, o *ClientOption) ServerSpe
 			sendASDU: make(chan []byte, 1024),
 			rcvRaw:   make(chan []byte, 1024),
 			sendRaw:  make(chan []byte, 1024), // may not block!
+			notify:   make(chan struct{}, 1),
 
 			Clog: clog.NewLogger("cs104 serverSpec => "),
 		},
@@ -68,16 +69,22 @@ func (sf *serverSpec) SetConnectionLostHandler(f func(c asdu.Connect)) {
 
 // Start start the server,and return quickly,if it nil,the server will disconnected background,other failed
 func (sf *serverSpec) Start() error {
+	return sf.StartContext(context.Background())
+}
+
+// StartContext is like Start, the connection and the reconnection are
+// stopped when ctx is done.
+func (sf *serverSpec) StartContext(ctx context.Context) error {
 	if sf.option.server == nil {
-		return errors.New("empty remote server")
+		return ErrRemoteServer
 	}
 
-	go sf.running()
+	go sf.running(ctx)
 	return nil
 }
 
 // Increase the reconnection interval
-func (sf *serverSpec) running() {
+func (sf *serverSpec) running(parent context.Context) {
 	var ctx context.Context
 
 	sf.rwMux.Lock()
@@ -85,7 +92,7 @@ func (sf *serverSpec) running() {
 		sf.rwMux.Unlock()
 		return
 	}
-	ctx, sf.closeCancel = context.WithCancel(context.Background())
+	ctx, sf.closeCancel = context.WithCancel(parent)
 	sf.rwMux.Unlock()
 	defer sf.setConnectStatus(initial)
 
@@ -97,25 +104,21 @@ func (sf *serverSpec) running() {
 		}
 
 		sf.Debug("connecting server %+v", sf.option.server)
-		conn, err := openConnection(sf.option.server, sf.option.TLSConfig, sf.config.ConnectTimeout0)
+		conn, err := openConnection(ctx, sf.option.server, sf.option.TLSConfig, sf.config.ConnectTimeout0)
 		if err != nil {
 			sf.Error("connect failed, %v", err)
-			if !sf.option.autoReconnect {
+			if !sf.option.autoReconnect || !sleepContext(ctx, sf.option.reconnectInterval) {
 				return
 			}
-			time.Sleep(sf.option.reconnectInterval)
 			continue
 		}
 		sf.Debug("connect success")
 		sf.conn = conn
 		sf.run(ctx)
 		sf.Debug("disconnected server %+v", sf.option.server)
-		select {
-		case <-ctx.Done():
+		// Random 500ms-1s retry to avoid fast retry causing many invalid connections to the server
+		if !sleepContext(ctx, time.Millisecond*time.Duration(500+rand.Intn(500))) {
 			return
-		default:
-			// Random 500ms-1s retry to avoid fast retry causing many invalid connections to the server
-			time.Sleep(time.Millisecond * time.Duration(500+rand.Intn(500)))
 		}
 	}
 }
@@ -0,0 +1,160 @@
+package cs104
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// startTestServer runs a server on a free local port and returns a raw
+// connection to it with the data transfer started.
+func startTestServer(t *testing.T) (*Server, net.Conn) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	srv := NewServer(&mockServerHandler{})
+	go srv.ListenAndServer(addr)
+	var conn net.Conn
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.Write(newUFrame(uStartDtActive)); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := readTestAPDU(t, conn); head != (uAPCI{uStartDtConfirm}) {
+		t.Fatalf("got %v, want StartDtConfirm", head)
+	}
+	return srv, conn
+}
+
+func readTestAPDU(t *testing.T, conn net.Conn) (interface{}, []byte) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	b := make([]byte, 2, APDUSizeMax)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return nil, nil
+	}
+	b = b[:2+int(b[1])]
+	if _, err := io.ReadFull(conn, b[2:]); err != nil {
+		t.Fatal(err)
+	}
+	return parse(b)
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	srv, conn := startTestServer(t)
+	defer conn.Close()
+
+	cot := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	for i := 0; i < 3; i++ {
+		if err := asdu.Single(srv, false, cot, 1, asdu.SinglePointInfo{Ioa: asdu.InfoObjAddr(i + 1)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := make(chan error, 1)
+	go func() { done <- srv.Shutdown(context.Background()) }()
+
+	var n uint16
+	for {
+		head, _ := readTestAPDU(t, conn)
+		if _, ok := head.(iAPCI); ok {
+			n++
+			continue
+		}
+		if head != (uAPCI{uStopDtActive}) {
+			t.Fatalf("got %v, want StopDtActive", head)
+		}
+		break
+	}
+	if n != 3 {
+		t.Errorf("got %d queued asdu before STOPDT, want 3", n)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown() = %v before the acknowledgement", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	_, _ = conn.Write(newSFrame(n))
+	_, _ = conn.Write(newUFrame(uStopDtConfirm))
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Shutdown() = %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Shutdown() not returned")
+	}
+	if head, _ := readTestAPDU(t, conn); head != nil {
+		t.Errorf("got %v, want the connection closed", head)
+	}
+}
+
+func TestServer_Shutdown_deadline(t *testing.T) {
+	srv, conn := startTestServer(t)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n := srv.GetSessionsLen(); n != 0 {
+		t.Errorf("%d sessions left", n)
+	}
+}
+
+func TestServer_ListenAndServerContext(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := NewServer(&mockServerHandler{})
+	done := make(chan error, 1)
+	go func() { done <- srv.ListenAndServerContext(ctx, addr) }()
+
+	var conn net.Conn
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	cancel()
+	select {
+	case err = <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ListenAndServerContext() = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("ListenAndServerContext() not returned")
+	}
+	if head, _ := readTestAPDU(t, conn); head != nil {
+		t.Errorf("got %v, want the connection closed", head)
+	}
+}
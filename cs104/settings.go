@@ -0,0 +1,194 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/model"
+)
+
+// Settings the protocol tuning of an application kept out of the code, see LoadConfig and SaveConfig.
+// The file is JSON, for example:
+//
+//	{
+//	  "config": {"t1": "15s", "k": 12},
+//	  "params": {"causeSize": 2, "commonAddrSize": 2, "infoObjAddrSize": 3, "timeZone": "UTC"},
+//	  "points": [{"ca": 1, "ioa": 100, "type": "M_ME_NC_1", "value": 1.5}],
+//	  "redundancy": [{"name": "main", "servers": ["10.0.0.1:2404", "10.0.0.2:2404"], "ca": 1}]
+//	}
+type Settings struct {
+	Config     Config            `json:"config"`
+	Params     asdu.Params       `json:"params"`
+	Points     []PointConfig     `json:"points,omitempty"`
+	Redundancy []RedundancyGroup `json:"redundancy,omitempty"`
+}
+
+// PointConfig a monitored point of the point table.
+type PointConfig struct {
+	CA      asdu.CommonAddr        `json:"ca"`
+	IOA     asdu.InfoObjAddr       `json:"ioa"`
+	Type    string                 `json:"type"` // type identification name, such as M_SP_NA_1
+	Value   float64                `json:"value,omitempty"`
+	Quality asdu.QualityDescriptor `json:"quality,omitempty"`
+}
+
+// RedundancyGroup the endpoints of a redundant client, see NewRedundantClient.
+type RedundancyGroup struct {
+	Name string `json:"name"`
+	// Servers the primary first, then the backups in order.
+	Servers []string `json:"servers"`
+	// CA the common address of the general interrogation performed on switchover, 0 for the global one.
+	CA asdu.CommonAddr `json:"ca,omitempty"`
+}
+
+// NewSettings new settings with the default config and asdu.ParamsWide params
+func NewSettings() *Settings {
+	return &Settings{Config: DefaultConfig(), Params: *asdu.ParamsWide}
+}
+
+// LoadConfig loads and validates the JSON settings file,
+// the values absent are the ones of NewSettings.
+func LoadConfig(path string) (*Settings, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sf := NewSettings()
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(sf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err = sf.Valid(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sf, nil
+}
+
+// SaveConfig validates and saves the settings as JSON file.
+func SaveConfig(path string, sf *Settings) error {
+	if err := sf.Valid(); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(sf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Valid applies the default config for each unspecified value and checks the settings.
+func (sf *Settings) Valid() error {
+	if err := sf.Config.Valid(); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := sf.Params.Valid(); err != nil {
+		return fmt.Errorf("params: %w", err)
+	}
+	type addr struct {
+		ca  asdu.CommonAddr
+		ioa asdu.InfoObjAddr
+	}
+	points := make(map[addr]bool, len(sf.Points))
+	for i, p := range sf.Points {
+		if err := sf.Params.ValidCommonAddr(p.CA); err != nil {
+			return fmt.Errorf("point %d: %w", i, err)
+		}
+		if _, err := p.Point(); err != nil {
+			return fmt.Errorf("point %d: %w", i, err)
+		}
+		if points[addr{p.CA, p.IOA}] {
+			return fmt.Errorf("point %d: duplicate address %d@%d", i, p.IOA, p.CA)
+		}
+		points[addr{p.CA, p.IOA}] = true
+	}
+	groups := make(map[string]bool, len(sf.Redundancy))
+	for i, g := range sf.Redundancy {
+		if len(g.Servers) == 0 {
+			return fmt.Errorf("redundancy %d: empty remote server", i)
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("redundancy %d: duplicate name %q", i, g.Name)
+		}
+		groups[g.Name] = true
+	}
+	return nil
+}
+
+// PointStore returns the point table in a MemPointStore.
+func (sf *Settings) PointStore() (*MemPointStore, error) {
+	store := NewMemPointStore()
+	for _, p := range sf.Points {
+		pt, err := p.Point()
+		if err != nil {
+			return nil, err
+		}
+		if err = store.Set(p.CA, pt); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// ClientOption returns a client option of the config and params.
+func (sf *Settings) ClientOption() *ClientOption {
+	return NewOption().SetConfig(sf.Config).SetParams(&sf.Params)
+}
+
+// Group returns the redundancy group of the name.
+func (sf *Settings) Group(name string) (RedundancyGroup, bool) {
+	for _, g := range sf.Redundancy {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return RedundancyGroup{}, false
+}
+
+// Point returns the point of the configuration, the type must be a monitored one a Point supports.
+func (sf PointConfig) Point() (Point, error) {
+	t, err := asdu.ParseTypeID(sf.Type)
+	if err != nil {
+		return Point{}, fmt.Errorf("%w %q", err, sf.Type)
+	}
+	p := model.Point{Index: uint32(sf.IOA), Value: sf.Value, Quality: model.FromQDS(sf.Quality)}
+	var info interface{}
+	switch t {
+	case asdu.M_SP_NA_1, asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		info = model.ToSinglePoint(p)
+	case asdu.M_DP_NA_1, asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		info = model.ToDoublePoint(p)
+	case asdu.M_ST_NA_1, asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		info = model.ToStepPosition(p)
+	case asdu.M_BO_NA_1, asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		info = model.ToBitString32(p)
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1:
+		info = model.ToMeasuredValueNormal(p)
+	case asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		info = model.ToMeasuredValueScaled(p)
+	case asdu.M_ME_NC_1, asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		info = model.ToMeasuredValueFloat(p)
+	default:
+		return Point{}, fmt.Errorf("%w %s", ErrPointType, t)
+	}
+	return Point{t, info}, nil
+}
+
+// NewClient new the redundant client of the group with the option of every endpoint.
+func (sf RedundancyGroup) NewClient(handler ClientHandlerInterface, o *ClientOption) (*RedundantClient, error) {
+	c, err := NewRedundantClient(handler, o, sf.Servers...)
+	if err != nil {
+		return nil, err
+	}
+	if sf.CA != 0 {
+		c.SetInterrogationCommonAddr(sf.CA)
+	}
+	return c, nil
+}
@@ -0,0 +1,100 @@
+package cs104
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	err := os.WriteFile(path, []byte(`{
+		"config": {"t1": "5s", "k": 20},
+		"params": {"causeSize": 1, "commonAddrSize": 1, "timeTagTolerance": "1m"},
+		"points": [{"ca": 1, "ioa": 100, "type": "M_ME_NC_1", "value": 1.5, "quality": "NT"}],
+		"redundancy": [{"name": "main", "servers": ["127.0.0.1:2404", "127.0.0.2:2404"], "ca": 1}]
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.Config.SendUnAckTimeout1 != 5*time.Second || sf.Config.SendUnAckLimitK != 20 || sf.Config.IdleTimeout3 != 20*time.Second {
+		t.Errorf("Config = %+v, want t1 and k loaded, the others default", sf.Config)
+	}
+	if p := sf.Params; p.CauseSize != 1 || p.CommonAddrSize != 1 || p.InfoObjAddrSize != 3 ||
+		p.InfoObjTimeZone != time.UTC || p.TimeTagTolerance != time.Minute {
+		t.Errorf("Params = %+v, want the sizes loaded, the others of ParamsWide", p)
+	}
+	store, err := sf.PointStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := asdu.MeasuredValueFloatInfo{Ioa: 100, Value: 1.5, Qds: asdu.QDSNotTopical}
+	if p, ok := store.Point(1, 100); !ok || p.Type != asdu.M_ME_NC_1 || p.Info != want {
+		t.Errorf("Point() = %+v, want %+v", p, want)
+	}
+	if g, ok := sf.Group("main"); !ok || len(g.Servers) != 2 || g.CA != 1 {
+		t.Errorf("Group() = %+v, want the group loaded", g)
+	}
+
+	saved := filepath.Join(t.TempDir(), "saved.json")
+	if err = SaveConfig(saved, sf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadConfig(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sf) {
+		t.Errorf("LoadConfig() = %+v, want the settings saved %+v", got, sf)
+	}
+
+	for _, misspelled := range []string{
+		`{"config": {"t1": "5s", "kk": 20}}`,
+		`{"params": {"causeSize": 1, "commonAdrSize": 1}}`,
+	} {
+		if err = os.WriteFile(path, []byte(misspelled), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = LoadConfig(path); err == nil {
+			t.Errorf("LoadConfig(%s) error nil, want the unknown field rejected", misspelled)
+		}
+	}
+}
+
+func TestSettings_Valid(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(sf *Settings)
+	}{
+		{"config", func(sf *Settings) { sf.Config.SendUnAckTimeout1 = time.Hour }},
+		{"params", func(sf *Settings) { sf.Params.CauseSize = 3 }},
+		{"type", func(sf *Settings) { sf.Points[0].Type = "C_SC_NA_1" }},
+		{"type name", func(sf *Settings) { sf.Points[0].Type = "M_XX_NA_1" }},
+		{"common address", func(sf *Settings) { sf.Points[0].CA = 0 }},
+		{"duplicate point", func(sf *Settings) { sf.Points = append(sf.Points, sf.Points[0]) }},
+		{"empty group", func(sf *Settings) { sf.Redundancy[0].Servers = nil }},
+		{"duplicate group", func(sf *Settings) { sf.Redundancy = append(sf.Redundancy, sf.Redundancy[0]) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sf := NewSettings()
+			sf.Points = []PointConfig{{CA: 1, IOA: 1, Type: "M_SP_NA_1", Value: 1}}
+			sf.Redundancy = []RedundancyGroup{{Name: "main", Servers: []string{"127.0.0.1:2404"}}}
+			if err := sf.Valid(); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(sf)
+			if err := sf.Valid(); err == nil {
+				t.Error("Valid() error = nil, want invalid")
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Shaping the traffic shaping of the interrogation responses, so slow masters or
+// constrained links are not flooded with hundreds of back-to-back I-frames.
+// Only the asdu with an interrogated cause are shaped, the activation confirmation
+// and termination are sent without delay. The sessions answer the interrogations
+// one after the other apart from the received asdu, which are handled meanwhile.
+type Shaping struct {
+	// Gap minimum delay between two interrogated asdu, 0 for none
+	Gap time.Duration
+	// Chunk number of interrogated asdu sent before a Pause, 0 for no chunking
+	Chunk int
+	// Pause delay after each chunk, the Gap is used if it is larger
+	Pause time.Duration
+	// Progress called after each interrogated asdu sent with the number sent so far,
+	// and with done set once the activation termination is sent.
+	Progress func(c asdu.Connect, sent int, done bool)
+}
+
+// SetInterrogationShaping set the traffic shaping of the responses sent by the
+// interrogation handler of the sessions, see Shaping
+func (sf *Server) SetInterrogationShaping(s Shaping) *Server {
+	sf.shaping = &s
+	return sf
+}
+
+// Wrap returns a connect sending through c with the shaping applied until ctx is done.
+// A new connect should be wrapped for each interrogation.
+func (sf *Shaping) Wrap(ctx context.Context, c asdu.Connect) asdu.Connect {
+	return &shapedConn{Connect: c, ctx: ctx, shaping: sf}
+}
+
+// shapedConn the connect of one interrogation response
+type shapedConn struct {
+	asdu.Connect
+	ctx     context.Context
+	shaping *Shaping
+	sent    int
+	last    time.Time
+}
+
+// Send imp interface Connect
+func (sf *shapedConn) Send(a *asdu.ASDU) error {
+	cause := a.Coa.Cause
+	if cause < asdu.InterrogatedByStation || cause > asdu.InterrogatedByGroup16 {
+		err := sf.Connect.Send(a)
+		if err == nil && cause == asdu.ActivationTerm && sf.shaping.Progress != nil {
+			sf.shaping.Progress(sf.Connect, sf.sent, true)
+		}
+		return err
+	}
+	if sf.sent > 0 {
+		d := sf.shaping.Gap
+		if sf.shaping.Chunk > 0 && sf.sent%sf.shaping.Chunk == 0 && sf.shaping.Pause > d {
+			d = sf.shaping.Pause
+		}
+		if wait := time.Until(sf.last.Add(d)); wait > 0 && !sleepContext(sf.ctx, wait) {
+			return sf.ctx.Err()
+		}
+	}
+	if err := sf.Connect.Send(a); err != nil {
+		return err
+	}
+	sf.sent++
+	sf.last = time.Now()
+	if sf.shaping.Progress != nil {
+		sf.shaping.Progress(sf.Connect, sf.sent, false)
+	}
+	return nil
+}
+
+// shapedInterrogationMax the interrogations a session queues behind the one answered,
+// the following ones are negative confirmed
+const shapedInterrogationMax = 4
+
+// shapedInterrogation an interrogation queued to be answered with shaping
+type shapedInterrogation struct {
+	handler ServerHandlerInterface
+	pack    *asdu.ASDU
+	qoi     asdu.QualifierOfInterrogation
+}
+
+// enqueueInterrogation queue the interrogation to be answered with shaping, so the received asdu
+// are not held up for the duration of the response.
+func (sf *SrvSession) enqueueInterrogation(handler ServerHandlerInterface, pack *asdu.ASDU, qoi asdu.QualifierOfInterrogation) error {
+	select {
+	case sf.interro <- shapedInterrogation{handler, pack, qoi}:
+		sf.gi.kick()
+		return nil
+	default:
+		sf.Warn("interrogation queue full, reject %v", pack.Identifier)
+		return sf.Send(pack.Mirror(asdu.ActivationCon, true))
+	}
+}
+
+// interrogate answer the queued interrogation with the shaping applied
+func (sf *SrvSession) interrogate(gi shapedInterrogation) bool {
+	defer func() {
+		if err := recover(); err != nil {
+			sf.Critical("interrogation handler %+v", err)
+		}
+	}()
+	if err := gi.handler.InterrogationHandler(sf.shaping.Wrap(sf.ctx, sf), gi.pack, gi.qoi); err != nil {
+		sf.Error("interrogation handler failed,%+v", err)
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package cs104
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/asdu/asdutest"
+)
+
+func TestShaping_Wrap(t *testing.T) {
+	conn := asdutest.NewConn(asdu.ParamsWide)
+	type progress struct {
+		sent int
+		done bool
+	}
+	var got []progress
+	s := &Shaping{
+		Gap:   5 * time.Millisecond,
+		Chunk: 2,
+		Pause: 30 * time.Millisecond,
+		Progress: func(_ asdu.Connect, sent int, done bool) {
+			got = append(got, progress{sent, done})
+		},
+	}
+	c := s.Wrap(context.Background(), conn)
+
+	begin := time.Now()
+	coa := asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}
+	for i := 0; i < 4; i++ {
+		if err := asdu.Single(c, false, coa, 1, asdu.SinglePointInfo{Ioa: asdu.InfoObjAddr(i + 1)}); err != nil {
+			t.Fatalf("Single() error = %v", err)
+		}
+	}
+	// gap, pause, gap
+	if elapsed := time.Since(begin); elapsed < 40*time.Millisecond {
+		t.Errorf("4 asdu sent in %v, want at least 40ms", elapsed)
+	}
+	begin = time.Now()
+	term := asdu.NewASDU(asdu.ParamsWide, asdu.Identifier{
+		Type:       asdu.C_IC_NA_1,
+		Variable:   asdu.VariableStruct{Number: 1},
+		Coa:        asdu.CauseOfTransmission{Cause: asdu.ActivationTerm},
+		CommonAddr: 1,
+	})
+	if err := term.AppendInfoObjAddr(asdu.InfoObjAddrIrrelevant); err != nil {
+		t.Fatal(err)
+	}
+	term.AppendBytes(byte(asdu.QOIStation))
+	if err := c.Send(term); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 20*time.Millisecond {
+		t.Errorf("termination delayed %v", elapsed)
+	}
+	want := []progress{{1, false}, {2, false}, {3, false}, {4, false}, {4, true}}
+	if len(got) != len(want) {
+		t.Fatalf("progress = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("progress[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if n := len(conn.Sent()); n != 5 {
+		t.Errorf("sent %d asdu, want 5", n)
+	}
+}
+
+func TestShaping_WrapCanceled(t *testing.T) {
+	conn := asdutest.NewConn(asdu.ParamsWide)
+	ctx, cancel := context.WithCancel(context.Background())
+	c := (&Shaping{Gap: time.Hour}).Wrap(ctx, conn)
+	coa := asdu.CauseOfTransmission{Cause: asdu.InterrogatedByGroup1}
+	if err := asdu.Single(c, false, coa, 1, asdu.SinglePointInfo{Ioa: 1}); err != nil {
+		t.Fatalf("Single() error = %v", err)
+	}
+	cancel()
+	if err := asdu.Single(c, false, coa, 1, asdu.SinglePointInfo{Ioa: 2}); err != context.Canceled {
+		t.Errorf("Single() error = %v, want %v", err, context.Canceled)
+	}
+	if n := len(conn.Sent()); n != 1 {
+		t.Errorf("sent %d asdu, want 1", n)
+	}
+}
+
+// shapedHandler answers the station interrogation with 3 points, and confirms the clock synchronization
+type shapedHandler struct {
+	mockServerHandler
+}
+
+func (sf *shapedHandler) InterrogationHandler(c asdu.Connect, pack *asdu.ASDU, _ asdu.QualifierOfInterrogation) error {
+	if err := c.Send(pack.Mirror(asdu.ActivationCon, false)); err != nil {
+		return err
+	}
+	coa := asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}
+	for i := 1; i <= 3; i++ {
+		if err := asdu.Single(c, false, coa, pack.CommonAddr, asdu.SinglePointInfo{Ioa: asdu.InfoObjAddr(i)}); err != nil {
+			return err
+		}
+	}
+	return c.Send(pack.Mirror(asdu.ActivationTerm, false))
+}
+
+func (sf *shapedHandler) ClockSyncHandler(c asdu.Connect, pack *asdu.ASDU, _ time.Time) error {
+	return c.Send(pack.Mirror(asdu.ActivationCon, false))
+}
+
+func TestSrvSession_ShapedInterrogation(t *testing.T) {
+	sess := newTestSession(&shapedHandler{})
+	sess.shaping = &Shaping{Gap: 50 * time.Millisecond}
+	sess.interro = make(chan shapedInterrogation, shapedInterrogationMax)
+	ctx, cancel := context.WithCancel(context.Background())
+	sess.ctx = ctx
+	sess.gi.open(ctx, sess.interro, sess.interrogate)
+	defer func() {
+		cancel()
+		sess.gi.close()
+	}()
+
+	rc := &recordConn{}
+	act := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	_ = asdu.InterrogationCmd(rc, act, 1, asdu.QOIStation)
+	_ = asdu.ClockSynchronizationCmd(rc, act, 1, time.Now())
+	begin := time.Now()
+	for _, a := range rc.take() {
+		if err := sess.serverHandler(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed > 50*time.Millisecond {
+		t.Errorf("received asdu handled in %v, want the shaped interrogation answered apart", elapsed)
+	}
+
+	var sent []*asdu.ASDU
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if sent = append(sent, sess.sent(t)...); len(sent) == 6 {
+			break
+		}
+	}
+	if len(sent) != 6 {
+		t.Fatalf("sent %d asdu, want 6", len(sent))
+	}
+	clock, term := -1, -1
+	for i, a := range sent {
+		switch {
+		case a.Type == asdu.C_CS_NA_1 && a.Coa.Cause == asdu.ActivationCon:
+			clock = i
+		case a.Type == asdu.C_IC_NA_1 && a.Coa.Cause == asdu.ActivationTerm:
+			term = i
+		}
+	}
+	if clock < 0 || term < 0 || clock > term {
+		t.Errorf("clock synchronization confirmed at %d, interrogation terminated at %d, want the command answered first", clock, term)
+	}
+}
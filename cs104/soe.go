@@ -0,0 +1,271 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// DefaultSOECapacity default capacity of the sequence of events queue
+const DefaultSOECapacity = 4096
+
+type soeEvent struct {
+	typeID asdu.TypeID
+	ca     asdu.CommonAddr
+	time   time.Time
+	info   interface{}
+	raw    []byte // the record saved, only when a store is set
+}
+
+// soePending the I-frame sequence number carrying count events
+type soePending struct {
+	seq   uint16
+	count int
+}
+
+// SOE the sequence of events queue.
+// It buffers time tagged events in strict chronological order of their original
+// CP56Time2a time tag. An active session drains the queue before any other data,
+// an event is only removed once the controlling station acknowledged the I-frame
+// carrying it, so that the events survive short disconnections, and restarts
+// of the process with a store, see SetStore.
+type SOE struct {
+	mux      sync.Mutex
+	events   []soeEvent // chronological order
+	inflight int        // number of head events sent but not yet acknowledged
+	owner    *SrvSession
+	waiting  map[*SrvSession]struct{} // the sessions to signal when events are available
+	capacity int
+	store    SOEStore
+	dirty    bool // the queue changed since the last save
+	saving   bool // the saver goroutine is running
+
+	saveMux sync.Mutex // serializes the saves, never held with mux waiting for it
+}
+
+// NewSOE new a sequence of events queue with the capacity, if capacity <= 0 use DefaultSOECapacity
+func NewSOE(capacity int) *SOE {
+	if capacity <= 0 {
+		capacity = DefaultSOECapacity
+	}
+	return &SOE{capacity: capacity}
+}
+
+// Len returns the number of buffered events, including the events not acknowledged yet
+func (sf *SOE) Len() int {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return len(sf.events)
+}
+
+// Clear drop the buffered events not sent yet, returns the number of them.
+// The events in flight are kept until acknowledged.
+func (sf *SOE) Clear() int {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	n := len(sf.events) - sf.inflight
+	sf.events = sf.events[:sf.inflight]
+	sf.changed()
+	return n
+}
+
+// EnqueueSingle enqueue single point events sent as [M_SP_TB_1]
+func (sf *SOE) EnqueueSingle(ca asdu.CommonAddr, infos ...asdu.SinglePointInfo) error {
+	events := make([]soeEvent, 0, len(infos))
+	for _, v := range infos {
+		events = append(events, soeEvent{asdu.M_SP_TB_1, ca, v.Time, v, nil})
+	}
+	return sf.enqueue(events...)
+}
+
+// EnqueueDouble enqueue double point events sent as [M_DP_TB_1]
+func (sf *SOE) EnqueueDouble(ca asdu.CommonAddr, infos ...asdu.DoublePointInfo) error {
+	events := make([]soeEvent, 0, len(infos))
+	for _, v := range infos {
+		events = append(events, soeEvent{asdu.M_DP_TB_1, ca, v.Time, v, nil})
+	}
+	return sf.enqueue(events...)
+}
+
+// EnqueueProtection enqueue events of protection equipment sent as [M_EP_TD_1]
+func (sf *SOE) EnqueueProtection(ca asdu.CommonAddr, infos ...asdu.EventOfProtectionEquipmentInfo) error {
+	events := make([]soeEvent, 0, len(infos))
+	for _, v := range infos {
+		events = append(events, soeEvent{asdu.M_EP_TD_1, ca, v.Time, v, nil})
+	}
+	return sf.enqueue(events...)
+}
+
+// EnqueuePackedStartEvents enqueue packed start events of protection equipment sent as [M_EP_TE_1]
+func (sf *SOE) EnqueuePackedStartEvents(ca asdu.CommonAddr, info asdu.PackedStartEventsOfProtectionEquipmentInfo) error {
+	return sf.enqueue(soeEvent{asdu.M_EP_TE_1, ca, info.Time, info, nil})
+}
+
+// EnqueuePackedOutputCircuit enqueue packed output circuit information of protection equipment sent as [M_EP_TF_1]
+func (sf *SOE) EnqueuePackedOutputCircuit(ca asdu.CommonAddr, info asdu.PackedOutputCircuitInfoInfo) error {
+	return sf.enqueue(soeEvent{asdu.M_EP_TF_1, ca, info.Time, info, nil})
+}
+
+// enqueue insert the events in chronological order, events with the same time
+// keep the enqueue order, events already in flight are never reordered.
+// Either all the events are inserted or none.
+func (sf *SOE) enqueue(events ...soeEvent) error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if len(sf.events)+len(events) > sf.capacity {
+		return ErrBufferFulled
+	}
+	if sf.store != nil {
+		for i := range events {
+			if events[i].raw != nil {
+				continue
+			}
+			var err error
+			if events[i].raw, err = encodeSOEEvent(events[i]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, e := range events {
+		i := sf.inflight + sort.Search(len(sf.events)-sf.inflight, func(i int) bool {
+			return sf.events[sf.inflight+i].time.After(e.time)
+		})
+		sf.events = append(sf.events, soeEvent{})
+		copy(sf.events[i+1:], sf.events[i:])
+		sf.events[i] = e
+	}
+	sf.signal()
+	sf.changed()
+	return nil
+}
+
+// signal wake the sessions waiting for events
+func (sf *SOE) signal() {
+	for s := range sf.waiting {
+		s.signal()
+		delete(sf.waiting, s)
+	}
+}
+
+// next encode the next events not in flight for the session s,
+// it returns the asdu raw data and the number of events it carries.
+func (sf *SOE) next(s *SrvSession) ([]byte, int) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if (sf.owner != nil && sf.owner != s) || sf.inflight >= len(sf.events) {
+		if sf.waiting == nil {
+			sf.waiting = make(map[*SrvSession]struct{})
+		}
+		sf.waiting[s] = struct{}{}
+		return nil, 0
+	}
+
+	head := sf.events[sf.inflight]
+	n := 1
+	switch head.typeID {
+	case asdu.M_SP_TB_1, asdu.M_DP_TB_1, asdu.M_EP_TD_1:
+		objSize, _ := asdu.GetInfoObjSize(head.typeID)
+		max := (s.params.MaxASDUSize() - s.params.IdentifierSize()) / (s.params.InfoObjAddrSize + objSize)
+		for n < max && sf.inflight+n < len(sf.events) {
+			if e := sf.events[sf.inflight+n]; e.typeID != head.typeID || e.ca != head.ca {
+				break
+			}
+			n++
+		}
+	}
+
+	c := &captureConn{params: s.params}
+	events := sf.events[sf.inflight : sf.inflight+n]
+	coa := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	var err error
+	switch head.typeID {
+	case asdu.M_SP_TB_1:
+		infos := make([]asdu.SinglePointInfo, 0, n)
+		for _, e := range events {
+			infos = append(infos, e.info.(asdu.SinglePointInfo))
+		}
+		err = asdu.SingleCP56Time2a(c, coa, head.ca, infos...)
+	case asdu.M_DP_TB_1:
+		infos := make([]asdu.DoublePointInfo, 0, n)
+		for _, e := range events {
+			infos = append(infos, e.info.(asdu.DoublePointInfo))
+		}
+		err = asdu.DoubleCP56Time2a(c, coa, head.ca, infos...)
+	case asdu.M_EP_TD_1:
+		infos := make([]asdu.EventOfProtectionEquipmentInfo, 0, n)
+		for _, e := range events {
+			infos = append(infos, e.info.(asdu.EventOfProtectionEquipmentInfo))
+		}
+		err = asdu.EventOfProtectionEquipmentCP56Time2a(c, coa, head.ca, infos...)
+	case asdu.M_EP_TE_1:
+		err = asdu.PackedStartEventsOfProtectionEquipmentCP56Time2a(c, coa, head.ca,
+			head.info.(asdu.PackedStartEventsOfProtectionEquipmentInfo))
+	case asdu.M_EP_TF_1:
+		err = asdu.PackedOutputCircuitInfoCP56Time2a(c, coa, head.ca,
+			head.info.(asdu.PackedOutputCircuitInfoInfo))
+	}
+	var data []byte
+	if err == nil && len(c.asdus) == 1 {
+		data, err = c.asdus[0].MarshalBinary()
+	}
+	if err != nil || data == nil {
+		// can never be encoded, drop it rather than blocking the queue
+		sf.events = append(sf.events[:sf.inflight], sf.events[sf.inflight+n:]...)
+		sf.changed()
+		return nil, 0
+	}
+	sf.owner = s
+	sf.inflight += n
+	return data, n
+}
+
+// ack remove the n oldest events acknowledged by the session s
+func (sf *SOE) ack(s *SrvSession, n int) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.owner != s {
+		return
+	}
+	if n > sf.inflight {
+		n = sf.inflight
+	}
+	sf.events = sf.events[n:]
+	sf.inflight -= n
+	if sf.inflight == 0 {
+		sf.owner = nil
+		sf.signal()
+	}
+	sf.changed()
+}
+
+// release make the events not acknowledged by the session s available again
+func (sf *SOE) release(s *SrvSession) {
+	sf.mux.Lock()
+	delete(sf.waiting, s)
+	if sf.owner == s {
+		sf.owner = nil
+		sf.inflight = 0
+		sf.signal()
+	}
+	sf.mux.Unlock()
+}
+
+// captureConn a asdu.Connect that keeps the sent asdu instead of transmitting it
+type captureConn struct {
+	params *asdu.Params
+	asdus  []*asdu.ASDU
+}
+
+func (sf *captureConn) Params() *asdu.Params     { return sf.params }
+func (sf *captureConn) UnderlyingConn() net.Conn { return nil }
+func (sf *captureConn) Send(a *asdu.ASDU) error {
+	sf.asdus = append(sf.asdus, a)
+	return nil
+}
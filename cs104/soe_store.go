@@ -0,0 +1,240 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// SOEStore persists the sequence of events queue, so that the events not
+// acknowledged yet survive a restart of the process. A record is the event
+// encoded as an asdu of one information object with asdu.ParamsWide.
+type SOEStore interface {
+	// Load returns the records saved, in chronological order.
+	Load() ([][]byte, error)
+	// Save replaces the records saved.
+	Save(records [][]byte) error
+}
+
+// SetStore restores the events saved in the store into the queue, then every
+// change of the queue is saved in background, off the sessions, the changes made
+// meanwhile saved at once. A failed save is retried on the next change, see Flush.
+func (sf *SOE) SetStore(store SOEStore) error {
+	records, err := store.Load()
+	if err != nil {
+		return err
+	}
+	events := make([]soeEvent, 0, len(records))
+	for _, r := range records {
+		e, err := decodeSOEEvent(r)
+		if err != nil {
+			return err
+		}
+		events = append(events, e)
+	}
+
+	sf.mux.Lock()
+	sf.store = store
+	for i := range sf.events { // enqueued before, saved from now on
+		if sf.events[i].raw, err = encodeSOEEvent(sf.events[i]); err != nil {
+			sf.store = nil
+			sf.mux.Unlock()
+			return err
+		}
+	}
+	sf.mux.Unlock()
+	return sf.enqueue(events...)
+}
+
+// Flush saves the queue to the store now and returns the error of the save,
+// for example before the process exits. Nothing is done without a store.
+func (sf *SOE) Flush() error {
+	sf.mux.Lock()
+	sf.dirty = false
+	sf.mux.Unlock()
+	return sf.save()
+}
+
+// changed start the saver goroutine if not running, with the lock held
+func (sf *SOE) changed() {
+	if sf.store == nil {
+		return
+	}
+	sf.dirty = true
+	if !sf.saving {
+		sf.saving = true
+		go sf.saver()
+	}
+}
+
+// saver saves the queue until it no longer changed, then exits
+func (sf *SOE) saver() {
+	for {
+		sf.mux.Lock()
+		if !sf.dirty {
+			sf.saving = false
+			sf.mux.Unlock()
+			return
+		}
+		sf.dirty = false
+		sf.mux.Unlock()
+		_ = sf.save()
+	}
+}
+
+// save the queue to the store, the records are taken and saved in order of the saves
+func (sf *SOE) save() error {
+	sf.saveMux.Lock()
+	defer sf.saveMux.Unlock()
+	sf.mux.Lock()
+	store := sf.store
+	records := make([][]byte, 0, len(sf.events))
+	for _, e := range sf.events {
+		records = append(records, e.raw)
+	}
+	sf.mux.Unlock()
+	if store == nil {
+		return nil
+	}
+	return store.Save(records)
+}
+
+func encodeSOEEvent(e soeEvent) ([]byte, error) {
+	c := &captureConn{params: asdu.ParamsWide}
+	coa := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	var err error
+	switch v := e.info.(type) {
+	case asdu.SinglePointInfo:
+		err = asdu.SingleCP56Time2a(c, coa, e.ca, v)
+	case asdu.DoublePointInfo:
+		err = asdu.DoubleCP56Time2a(c, coa, e.ca, v)
+	case asdu.EventOfProtectionEquipmentInfo:
+		err = asdu.EventOfProtectionEquipmentCP56Time2a(c, coa, e.ca, v)
+	case asdu.PackedStartEventsOfProtectionEquipmentInfo:
+		err = asdu.PackedStartEventsOfProtectionEquipmentCP56Time2a(c, coa, e.ca, v)
+	case asdu.PackedOutputCircuitInfoInfo:
+		err = asdu.PackedOutputCircuitInfoCP56Time2a(c, coa, e.ca, v)
+	default:
+		err = asdu.ErrTypeIDNotMatch
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := c.asdus[0].MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), raw...), nil
+}
+
+func decodeSOEEvent(raw []byte) (soeEvent, error) {
+	if err := asdu.ParseASDU(asdu.ParamsWide, raw); err != nil {
+		return soeEvent{}, err
+	}
+	a := asdu.NewEmptyASDU(asdu.ParamsWide)
+	_ = a.UnmarshalBinary(raw)
+	if a.Variable.Number != 1 {
+		return soeEvent{}, asdu.ErrInfoObjIndexFit
+	}
+
+	e := soeEvent{typeID: a.Type, ca: a.CommonAddr, raw: append([]byte(nil), raw...)}
+	switch a.Type {
+	case asdu.M_SP_TB_1:
+		v := a.GetSinglePoint()[0]
+		e.time, e.info = v.Time, v
+	case asdu.M_DP_TB_1:
+		v := a.GetDoublePoint()[0]
+		e.time, e.info = v.Time, v
+	case asdu.M_EP_TD_1:
+		v := a.GetEventOfProtectionEquipment()[0]
+		e.time, e.info = v.Time, v
+	case asdu.M_EP_TE_1:
+		v := a.GetPackedStartEventsOfProtectionEquipment()
+		e.time, e.info = v.Time, v
+	case asdu.M_EP_TF_1:
+		v := a.GetPackedOutputCircuitInfo()
+		e.time, e.info = v.Time, v
+	default:
+		return soeEvent{}, asdu.ErrTypeIDNotMatch
+	}
+	return e, nil
+}
+
+// soeFileMagic heads the file of a SOEFileStore
+var soeFileMagic = []byte("SOE\x01")
+
+// SOEFileStore is a SOEStore saving the records in a file, which is replaced
+// atomically on each save.
+type SOEFileStore struct {
+	path string
+}
+
+// NewSOEFileStore new a SOEStore saving to the file path
+func NewSOEFileStore(path string) *SOEFileStore {
+	return &SOEFileStore{path}
+}
+
+// Load returns the records saved, nothing if the file does not exist.
+func (sf *SOEFileStore) Load() ([][]byte, error) {
+	data, err := os.ReadFile(sf.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, soeFileMagic) {
+		return nil, fmt.Errorf("%s: not a sequence of events file", sf.path)
+	}
+	data = data[len(soeFileMagic):]
+
+	var records [][]byte
+	for len(data) > 0 {
+		n := int(data[0])
+		if 1+n > len(data) {
+			return nil, fmt.Errorf("%s: %w", sf.path, io.ErrUnexpectedEOF)
+		}
+		records = append(records, data[1:1+n])
+		data = data[1+n:]
+	}
+	return records, nil
+}
+
+// Save replaces the records saved.
+func (sf *SOEFileStore) Save(records [][]byte) error {
+	tmp := sf.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	_, _ = w.Write(soeFileMagic)
+	max := asdu.ParamsWide.MaxASDUSize() // the records are encoded with asdu.ParamsWide
+	for _, r := range records {
+		if len(r) > max {
+			_ = f.Close()
+			return fmt.Errorf("%w: %d", asdu.ErrLengthOutOfRange, max)
+		}
+		_ = w.WriteByte(byte(len(r)))
+		_, _ = w.Write(r)
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, sf.path)
+}
@@ -0,0 +1,140 @@
+package cs104
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSOE_SetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soe")
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	q := NewSOE(0)
+	if err := q.EnqueueSingle(1, asdu.SinglePointInfo{Ioa: 1, Value: true, Time: base}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetStore(NewSOEFileStore(path)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueueDouble(2, asdu.DoublePointInfo{Ioa: 2, Value: asdu.DPIDeterminedOn, Time: base.Add(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueuePackedOutputCircuit(3, asdu.PackedOutputCircuitInfoInfo{Ioa: 3, Oci: asdu.OCIGeneralCommand, Time: base.Add(2 * time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	s := &SrvSession{params: asdu.ParamsWide}
+	if _, n := q.next(s); n != 1 {
+		t.Fatalf("next() carries %d events, want 1", n)
+	}
+	q.ack(s, 1)
+	if err := q.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart: the events not acknowledged are restored in order
+	r := NewSOE(0)
+	if err := r.SetStore(NewSOEFileStore(path)); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", r.Len())
+	}
+	data, _ := r.next(s)
+	a := asdu.NewEmptyASDU(asdu.ParamsWide)
+	if err := a.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.GetDoublePoint()[0]; a.CommonAddr != 2 || got.Ioa != 2 || got.Value != asdu.DPIDeterminedOn || !got.Time.Equal(base.Add(time.Second)) {
+		t.Errorf("next() = %v %+v", a.CommonAddr, got)
+	}
+	r.ack(s, 1)
+	data, _ = r.next(s)
+	if err := a.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.GetPackedOutputCircuitInfo(); a.Type != asdu.M_EP_TF_1 || got.Ioa != 3 || got.Oci != asdu.OCIGeneralCommand {
+		t.Errorf("next() = %v %+v", a.Type, got)
+	}
+	r.ack(s, 1)
+	if err := r.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := NewSOEFileStore(path).Load()
+	if err != nil || len(records) != 0 {
+		t.Errorf("Load() = %d records, %v, want none", len(records), err)
+	}
+}
+
+// blockingStore a SOEStore whose saves block until released
+type blockingStore struct {
+	release chan struct{}
+	saves   atomic.Int32
+	last    atomic.Int32 // number of records of the last save
+}
+
+func (sf *blockingStore) Load() ([][]byte, error) { return nil, nil }
+func (sf *blockingStore) Save(records [][]byte) error {
+	<-sf.release
+	sf.saves.Add(1)
+	sf.last.Store(int32(len(records)))
+	return nil
+}
+
+func TestSOE_saveInBackground(t *testing.T) {
+	st := &blockingStore{release: make(chan struct{})}
+	q := NewSOE(0)
+	if err := q.SetStore(st); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &SrvSession{params: asdu.ParamsWide}
+
+	// the queue changes while a save is blocked
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			_ = q.EnqueueSingle(1, asdu.SinglePointInfo{Ioa: asdu.InfoObjAddr(i), Time: base.Add(time.Duration(i) * time.Second)})
+		}
+		_, n := q.next(s)
+		q.ack(s, n)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("queue changes blocked by the store")
+	}
+
+	close(st.release)
+	if err := q.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := st.saves.Load(); n > 3 {
+		t.Errorf("saved %d times, want the changes saved at once", n)
+	}
+	if got, want := int(st.last.Load()), q.Len(); got != want {
+		t.Errorf("last save has %d records, want %d", got, want)
+	}
+}
+
+func TestSOEFileStore_Load(t *testing.T) {
+	dir := t.TempDir()
+	if records, err := NewSOEFileStore(filepath.Join(dir, "none")).Load(); err != nil || records != nil {
+		t.Errorf("Load() = %v, %v, want nothing", records, err)
+	}
+
+	path := filepath.Join(dir, "soe")
+	st := NewSOEFileStore(path)
+	if err := st.Save([][]byte{{1, 2, 3}, {4}}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := st.Load()
+	if err != nil || len(records) != 2 || len(records[0]) != 3 || records[1][0] != 4 {
+		t.Errorf("Load() = %v, %v", records, err)
+	}
+}
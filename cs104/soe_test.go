@@ -0,0 +1,66 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSOE(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := NewSOE(4)
+	if err := q.EnqueueSingle(1,
+		asdu.SinglePointInfo{Ioa: 3, Value: true, Time: base.Add(3 * time.Millisecond)},
+		asdu.SinglePointInfo{Ioa: 1, Value: true, Time: base.Add(1 * time.Millisecond)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueueDouble(1,
+		asdu.DoublePointInfo{Ioa: 2, Value: asdu.DPIDeterminedOn, Time: base.Add(2 * time.Millisecond)}); err != nil {
+		t.Fatal(err)
+	}
+
+	s1 := &SrvSession{params: asdu.ParamsWide}
+	s2 := &SrvSession{params: asdu.ParamsWide}
+	decode := func(data []byte) *asdu.ASDU {
+		a := asdu.NewEmptyASDU(asdu.ParamsWide)
+		if err := a.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	// chronological order: ioa 1(SP), 2(DP), 3(SP)
+	data, n := q.next(s1)
+	if a := decode(data); n != 1 || a.Type != asdu.M_SP_TB_1 || a.GetSinglePoint()[0].Ioa != 1 {
+		t.Fatalf("next() = %v, %d", a, n)
+	}
+	if data, _ = q.next(s2); data != nil {
+		t.Fatal("next() other session got events in flight of a session")
+	}
+
+	// lost connection before acknowledge, the events are sent again
+	q.release(s1)
+	if data, _ = q.next(s2); decode(data).GetSinglePoint()[0].Ioa != 1 {
+		t.Fatal("next() after release do not resend the event")
+	}
+	data, _ = q.next(s2)
+	if a := decode(data); a.Type != asdu.M_DP_TB_1 || a.GetDoublePoint()[0].Ioa != 2 {
+		t.Fatalf("next() = %v", a)
+	}
+	// events enqueued later but older never pass the events in flight
+	if err := q.EnqueueSingle(1, asdu.SinglePointInfo{Ioa: 0, Time: base}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.EnqueueSingle(1, asdu.SinglePointInfo{Ioa: 9}); err != ErrBufferFulled {
+		t.Errorf("Enqueue() error = %v, want %v", err, ErrBufferFulled)
+	}
+	q.ack(s2, 2)
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", q.Len())
+	}
+	data, n = q.next(s2)
+	if info := decode(data).GetSinglePoint(); n != 2 || info[0].Ioa != 0 || info[1].Ioa != 3 {
+		t.Fatalf("next() = %v, %d", info, n)
+	}
+}
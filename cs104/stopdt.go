@@ -0,0 +1,86 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync/atomic"
+)
+
+// StoppedPolicy the handling of the asdu sent by a session while its data transfer is stopped,
+// before the STARTDT act or after the STOPDT act. The asdu queued are sent once the data transfer
+// is started again.
+type StoppedPolicy int
+
+// stopped policy defined
+const (
+	// StoppedBuffer queue the asdu, Send fails with ErrBufferFulled once the buffer is full, the default
+	StoppedBuffer StoppedPolicy = iota
+	// StoppedDropOldest queue the asdu, the oldest asdu queued of its priority is dropped once the buffer is full
+	StoppedDropOldest
+	// StoppedDiscard Send fails with ErrNotActive, the asdu queued are discarded on STOPDT act
+	StoppedDiscard
+)
+
+// SetStoppedPolicy set the handling of the asdu sent while the data transfer is stopped, see StoppedPolicy.
+// Whatever the policy, on STOPDT act the session stops sending I-frames at once, acknowledges the
+// I-frames received and confirms with STOPDT con once all its I-frames are acknowledged.
+func (sf *Server) SetStoppedPolicy(p StoppedPolicy) *Server {
+	sf.stopped = p
+	return sf
+}
+
+// isActive whether the data transfer of the session is started
+func (sf *SrvSession) isActive() bool {
+	return atomic.LoadUint32(&sf.active) == active
+}
+
+// setActive start or stop the data transfer of the session
+func (sf *SrvSession) setActive(b bool) {
+	if b {
+		atomic.StoreUint32(&sf.active, active)
+	} else {
+		atomic.StoreUint32(&sf.active, inactive)
+	}
+}
+
+// enqueueStopped queue the data while the data transfer is stopped as the policy handles it,
+// false if the data is not handled and must be queued as usual.
+func (sf *SrvSession) enqueueStopped(ch chan []byte, data []byte) (bool, error) {
+	switch sf.stopped {
+	case StoppedDiscard:
+		return true, ErrNotActive
+	case StoppedDropOldest:
+		for cap(ch) > 0 {
+			select {
+			case ch <- data:
+				sf.signal()
+				return true, nil
+			default:
+			}
+			select {
+			case old := <-ch:
+				putASDUBuffer(old)
+				sf.Debug("data transfer stopped, oldest asdu dropped")
+			default:
+			}
+		}
+	}
+	return false, nil
+}
+
+// discardQueued discard the asdu queued
+func (sf *SrvSession) discardQueued() {
+	for _, ch := range [...]chan []byte{sf.sendHigh, sf.sendASDU, sf.sendLow} {
+	loop:
+		for {
+			select {
+			case data := <-ch:
+				putASDUBuffer(data)
+			default:
+				break loop
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package cs104
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// readUntil read the frames until the want one, they must be all allowed, it returns them
+func readUntil(t *testing.T, conn net.Conn, want interface{}, allowed ...interface{}) []interface{} {
+	t.Helper()
+	var got []interface{}
+	for {
+		head, _ := readTestAPDU(t, conn)
+		if head == nil {
+			t.Fatalf("got %v then nothing, want %v", got, want)
+		}
+		got = append(got, head)
+		if head == want {
+			return got
+		}
+		ok := false
+		for _, a := range allowed {
+			ok = ok || head == a
+		}
+		if !ok {
+			t.Fatalf("got %v, want %v", head, want)
+		}
+	}
+}
+
+// expectQuiet expect no frame but the allowed ones for d
+func expectQuiet(t *testing.T, conn net.Conn, d time.Duration, allowed ...interface{}) []interface{} {
+	t.Helper()
+	var got []interface{}
+	deadline := time.Now().Add(d)
+	for {
+		_ = conn.SetReadDeadline(deadline)
+		b := make([]byte, 2, APDUSizeMax)
+		if _, err := conn.Read(b[:1]); err != nil {
+			return got
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := conn.Read(b[1:2]); err != nil {
+			t.Fatal(err)
+		}
+		b = b[:2+int(b[1])]
+		for n := 2; n < len(b); {
+			m, err := conn.Read(b[n:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			n += m
+		}
+		head, _ := parse(b)
+		ok := false
+		for _, a := range allowed {
+			ok = ok || head == a
+		}
+		if !ok {
+			t.Fatalf("got %v while quiet", head)
+		}
+		got = append(got, head)
+	}
+}
+
+func TestSrvSession_StopDt(t *testing.T) {
+	srv := NewServer(&mockServerHandler{})
+	listen := &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = srv.Serve(ctx, listen) }()
+	defer srv.Close()
+	conn, err := listen.dial(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	write := func(frame []byte) {
+		t.Helper()
+		if _, err := conn.Write(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send := func(ioa asdu.InfoObjAddr) {
+		t.Helper()
+		if err := asdu.Single(srv, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+			asdu.SinglePointInfo{Ioa: ioa}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(newUFrame(uStartDtActive))
+	readUntil(t, conn, uAPCI{uStartDtConfirm})
+	send(1)
+	if head, _ := readTestAPDU(t, conn); head != (iAPCI{0, 0}) {
+		t.Fatalf("got %v, want I-frame 0", head)
+	}
+
+	// an I-frame received then STOPDT act while the I-frame 0 is not acknowledged
+	rc := &recordConn{}
+	if err = asdu.InterrogationCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation); err != nil {
+		t.Fatal(err)
+	}
+	data, err := rc.take()[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	iframe, err := newIFrame(0, 0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(iframe)
+	write(newUFrame(uStopDtActive))
+	send(2) // buffered while stopped
+	acked := expectQuiet(t, conn, 200*time.Millisecond, sAPCI{1})
+
+	// STOPDT con once the I-frame 0 is acknowledged, after the acknowledgement of the I-frame received
+	write(newSFrame(1))
+	acked = append(acked, readUntil(t, conn, uAPCI{uStopDtConfirm}, sAPCI{1})...)
+	if acked[0] != (sAPCI{1}) {
+		t.Errorf("got %v, want the I-frame received acknowledged before STOPDT con", acked)
+	}
+
+	// flushed after STARTDT
+	write(newUFrame(uStartDtActive))
+	readUntil(t, conn, uAPCI{uStartDtConfirm})
+	head, raw := readTestAPDU(t, conn)
+	if head != (iAPCI{1, 1}) {
+		t.Fatalf("got %v, want I-frame 1", head)
+	}
+	a := asdu.NewEmptyASDU(asdu.ParamsWide)
+	if err = a.UnmarshalBinary(raw); err != nil {
+		t.Fatal(err)
+	}
+	if infos := a.GetSinglePoint(); len(infos) != 1 || infos[0].Ioa != 2 {
+		t.Errorf("flushed %+v, want the point 2 buffered", infos)
+	}
+}
+
+func TestSrvSession_StoppedPolicy(t *testing.T) {
+	send := func(sess *SrvSession, ioa asdu.InfoObjAddr) error {
+		return asdu.Single(sess, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.SinglePointInfo{Ioa: ioa})
+	}
+
+	sess := newTestSession(&mockServerHandler{})
+	sess.sendASDU = make(chan []byte, 2)
+	sess.stopped = StoppedDropOldest
+	for ioa := asdu.InfoObjAddr(1); ioa <= 3; ioa++ {
+		if err := send(sess, ioa); err != nil {
+			t.Fatalf("Send(%d) error = %v", ioa, err)
+		}
+	}
+	if sent := sess.sent(t); len(sent) != 2 || sent[0].GetSinglePoint()[0].Ioa != 2 {
+		t.Errorf("queued %v, want the points 2 and 3", sent)
+	}
+
+	sess = newTestSession(&mockServerHandler{})
+	sess.sendASDU = make(chan []byte, 2)
+	if err := send(sess, 1); err != nil {
+		t.Fatal(err)
+	}
+	sess.stopped = StoppedDiscard
+	if err := send(sess, 2); err != ErrNotActive {
+		t.Errorf("Send() error = %v, want %v", err, ErrNotActive)
+	}
+	sess.discardQueued()
+	if sent := sess.sent(t); len(sent) != 0 {
+		t.Errorf("queued %v, want discarded", sent)
+	}
+	sess.setActive(true)
+	if err := send(sess, 3); err != nil {
+		t.Errorf("Send() started error = %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// Tap a passive decoder of one direction of a mirrored cs104 tcp stream, for example captured
+// by a switch port mirroring, it never transmits anything so it can not influence the process.
+type Tap struct {
+	r      *bufio.Reader
+	params *asdu.Params
+}
+
+// NewTap new a tap decoding the stream r with the params
+func NewTap(r io.Reader, p *asdu.Params) *Tap {
+	return &Tap{bufio.NewReaderSize(r, APDUSizeMax), p}
+}
+
+// Next returns the next asdu carried by an I-frame, the S-frames and U-frames are skipped,
+// so are the bytes before a start character. It returns io.EOF at the end of the stream,
+// the asdu which can not be decoded is returned with the error.
+func (sf *Tap) Next() (*asdu.ASDU, error) {
+	apdu := make([]byte, APDUSizeMax)
+	for {
+		b, err := sf.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != startFrame {
+			continue
+		}
+		length, err := sf.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if int(length) < APCICtlFiledSize || int(length) > APDUFieldSizeMax {
+			continue // resynchronize
+		}
+		apdu[0], apdu[1] = b, length
+		if _, err = io.ReadFull(sf.r, apdu[2:2+int(length)]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		if apci, data := parse(apdu[:2+int(length)]); isIFrame(apci) {
+			a := asdu.NewEmptyASDU(sf.params)
+			return a, a.UnmarshalBinary(append([]byte(nil), data...))
+		}
+	}
+}
+
+func isIFrame(apci interface{}) bool {
+	_, ok := apci.(iAPCI)
+	return ok
+}
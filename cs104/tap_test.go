@@ -0,0 +1,56 @@
+package cs104
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestTap(t *testing.T) {
+	c := &recordConn{}
+	_ = asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.SinglePointInfo{Ioa: 1})
+	_ = asdu.MeasuredValueFloat(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+		asdu.MeasuredValueFloatInfo{Ioa: 2, Value: 1.5})
+
+	var stream bytes.Buffer
+	stream.Write([]byte{0x00, 0x11}) // garbage
+	stream.Write(newUFrame(uStartDtActive))
+	for i, a := range c.take() {
+		data, err := a.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame, err := newIFrame(uint16(i), 0, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream.Write(frame)
+		stream.Write(newSFrame(3))
+	}
+
+	tap := NewTap(&stream, asdu.ParamsWide)
+	for _, want := range []asdu.TypeID{asdu.M_SP_NA_1, asdu.M_ME_NC_1} {
+		a, err := tap.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Type != want {
+			t.Errorf("Next() = %v, want %v", a.Identifier, want)
+		}
+	}
+	if _, err := tap.Next(); err != io.EOF {
+		t.Errorf("Next() error = %v, want EOF", err)
+	}
+}
+
+func TestClient_listenOnly(t *testing.T) {
+	c := NewClient(NewTypedClientHandler(ClientHandlerBase{}), NewOption().SetListenOnly(true))
+	c.setConnectStatus(connected)
+	atomic.StoreUint32(&c.isActive, active)
+	if err := c.InterrogationCmd(asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, asdu.QOIStation); err != ErrListenOnly {
+		t.Errorf("InterrogationCmd() error = %v, want %v", err, ErrListenOnly)
+	}
+}
@@ -0,0 +1,96 @@
+package cs104
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// floatCounter counts the measured values received
+type floatCounter struct {
+	ClientHandlerBase
+	ch chan int
+}
+
+func (sf floatCounter) OnMeasuredFloat(infos []asdu.MeasuredValueFloatInfo, _ asdu.Identifier) error {
+	sf.ch <- len(infos)
+	return nil
+}
+
+// blockingConn sends in the k window, blocking while it is full
+type blockingConn struct{ *SrvSession }
+
+func (sf blockingConn) Send(a *asdu.ASDU) error { return sf.SendContext(context.Background(), a) }
+
+// BenchmarkLoopback measure the end to end throughput of the measured values sent by a server
+// to a client over the loopback, an asdu of ten short floating point values each.
+// Profile with go test -bench Loopback -cpuprofile cpu.out, then go tool pprof cpu.out.
+func BenchmarkLoopback(b *testing.B) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	sessions := make(chan asdu.Connect, 1)
+	srv := NewServer(&mockServerHandler{})
+	srv.LogMode(false)
+	srv.SetOnConnectionHandler(func(c asdu.Connect) { sessions <- c })
+	go srv.ListenAndServer(addr)
+	defer srv.Close()
+
+	counter := floatCounter{ch: make(chan int, 1024)}
+	o := NewOption().SetReconnectInterval(10 * time.Millisecond)
+	if err = o.AddRemoteServer(addr); err != nil {
+		b.Fatal(err)
+	}
+	c := NewClient(NewTypedClientHandler(counter), o)
+	c.LogMode(false)
+	c.SetOnConnectHandler(func(c *Client) { c.SendStartDt() })
+	if err = c.Start(); err != nil {
+		b.Fatal(err)
+	}
+	defer c.Close()
+
+	var sess *SrvSession
+	select {
+	case s := <-sessions:
+		sess = s.(*SrvSession)
+	case <-time.After(5 * time.Second):
+		b.Fatal("no connection")
+	}
+	for atomic.LoadUint32(&c.isActive) != active {
+		time.Sleep(time.Millisecond)
+	}
+
+	infos := make([]asdu.MeasuredValueFloatInfo, 10)
+	for i := range infos {
+		infos[i] = asdu.MeasuredValueFloatInfo{Ioa: asdu.InfoObjAddr(i + 1), Value: float32(i)}
+	}
+	received := make(chan struct{})
+	go func() {
+		for n := 0; n < b.N*len(infos); n += <-counter.ch {
+		}
+		close(received)
+	}()
+	b.ReportAllocs()
+	b.ResetTimer()
+	coa := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	for i := 0; i < b.N; i++ {
+		if err = asdu.MeasuredValueFloat(blockingConn{sess}, false, coa, 1, infos...); err != nil {
+			b.Fatal(err)
+		}
+	}
+	select {
+	case <-received:
+	case <-time.After(30 * time.Second):
+		b.Fatal("measured values lost")
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(b.N*len(infos))/b.Elapsed().Seconds(), "values/s")
+}
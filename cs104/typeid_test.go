@@ -0,0 +1,36 @@
+package cs104
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestServer_SetTypeIDs(t *testing.T) {
+	srv := NewServer(&mockServerHandler{}).SetTypeIDs(asdu.C_IC_NA_1, asdu.M_SP_NA_1)
+	sess := newTestSession(srv.handler)
+	sess.params = &srv.params
+	rc := &recordConn{}
+
+	if err := asdu.ClockSynchronizationCmd(rc, asdu.CauseOfTransmission{Cause: asdu.Activation}, 1, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.serverHandler(rc.take()[0]); err != nil {
+		t.Fatal(err)
+	}
+	sent := sess.sent(t)
+	if len(sent) != 1 || sent[0].Coa.Cause != asdu.UnknownTypeID || !sent[0].Coa.IsNegative {
+		t.Errorf("sent %v, want negative UnknownTypeID", sent)
+	}
+
+	err := asdu.Single(sess, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.SinglePointInfo{Ioa: 1})
+	if err != nil {
+		t.Errorf("Single() error = %v, want nil", err)
+	}
+	err = asdu.Double(sess, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1, asdu.DoublePointInfo{Ioa: 1})
+	if !errors.Is(err, asdu.ErrProfileTypeID) {
+		t.Errorf("Double() error = %v, want %v", err, asdu.ErrProfileTypeID)
+	}
+}
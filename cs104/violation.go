@@ -0,0 +1,101 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// ErrTimeout1 the peer did not acknowledge within t₁
+var ErrTimeout1 = asdu.NewError(asdu.ErrPeer, "acknowledgement timeout t₁")
+
+// ProtocolError a violation of the APCI procedures by the peer, the connection is closed after
+// except for a malformed length, whose frame is skipped. It unwraps to ErrSeqNoAck, ErrSeqNoSend,
+// ErrAPDU or ErrTimeout1.
+type ProtocolError struct {
+	Err error
+	// Frame the raw apdu received, the start and the length octets for a malformed length, nil for a timeout
+	Frame []byte
+	// Expected the N(S) expected, the upper bound N(S) of the send window for N(R)
+	Expected int
+	// Actual the N(S) or the N(R) received, the apdu length received
+	Actual int
+	// Timer the timer elapsed, like "t₁", and its Timeout
+	Timer   string
+	Timeout time.Duration
+	// Detail the description
+	Detail string
+}
+
+func (sf *ProtocolError) Error() string {
+	return fmt.Sprintf("%v: %s", sf.Err, sf.Detail)
+}
+
+// Unwrap returns the error of the violation
+func (sf *ProtocolError) Unwrap() error { return sf.Err }
+
+// SetViolationHandler set the handler of the protocol violations of the sessions, see ProtocolError
+func (sf *Server) SetViolationHandler(f func(c asdu.Connect, e *ProtocolError)) *Server {
+	sf.onViolation = f
+	return sf
+}
+
+// SetViolationHandler set the handler of the protocol violations, see ProtocolError
+func (sf *Client) SetViolationHandler(f func(c asdu.Connect, e *ProtocolError)) *Client {
+	sf.onViolation = f
+	return sf
+}
+
+// violation report the violation to the handler if any
+func violation(c asdu.Connect, f func(c asdu.Connect, e *ProtocolError), e *ProtocolError) {
+	if f != nil {
+		f(c, e)
+	}
+}
+
+// seqNoAckViolation the N(R) received outside the window [ackNo, seqNo]
+func seqNoAckViolation(apdu []byte, rcvSN, ackNo, seqNo uint16) *ProtocolError {
+	return &ProtocolError{
+		Err:      ErrSeqNoAck,
+		Frame:    append([]byte(nil), apdu...),
+		Expected: int(seqNo),
+		Actual:   int(rcvSN),
+		Detail:   fmt.Sprintf("N(R) %d outside the window [%d, %d]", rcvSN, ackNo, seqNo),
+	}
+}
+
+// seqNoSendViolation the N(S) received out of order
+func seqNoSendViolation(apdu []byte, sendSN, expected uint16) *ProtocolError {
+	return &ProtocolError{
+		Err:      ErrSeqNoSend,
+		Frame:    append([]byte(nil), apdu...),
+		Expected: int(expected),
+		Actual:   int(sendSN),
+		Detail:   fmt.Sprintf("N(S) %d, expected %d", sendSN, expected),
+	}
+}
+
+// lengthViolation the apdu length out of range
+func lengthViolation(head []byte) *ProtocolError {
+	return &ProtocolError{
+		Err:    ErrAPDU,
+		Frame:  append([]byte(nil), head...),
+		Actual: int(head[1]),
+		Detail: fmt.Sprintf("apdu length %d not in [%d, %d]", head[1], APCICtlFiledSize, APDUSizeMax-2),
+	}
+}
+
+// timeout1Violation the t₁ elapsed waiting for the acknowledgement of what
+func timeout1Violation(t1 time.Duration, what string) *ProtocolError {
+	return &ProtocolError{
+		Err:     ErrTimeout1,
+		Timer:   "t₁",
+		Timeout: t1,
+		Detail:  what + " not acknowledged",
+	}
+}
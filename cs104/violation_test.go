@@ -0,0 +1,75 @@
+package cs104
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestServer_SetViolationHandler(t *testing.T) {
+	violations := make(chan *ProtocolError, 4)
+	srv := NewServer(&mockServerHandler{}).SetViolationHandler(func(_ asdu.Connect, e *ProtocolError) {
+		violations <- e
+	})
+	listen := &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = srv.Serve(ctx, listen) }()
+	defer srv.Close()
+	conn, err := listen.dial(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	next := func() *ProtocolError {
+		t.Helper()
+		select {
+		case e := <-violations:
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatal("violation not reported")
+		}
+		return nil
+	}
+
+	// malformed length skipped
+	if _, err = conn.Write([]byte{startFrame, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); !errors.Is(e, ErrAPDU) || e.Actual != 2 || len(e.Frame) != 2 {
+		t.Errorf("violation = %+v, want the malformed length", e)
+	}
+
+	if _, err = conn.Write(newUFrame(uStartDtActive)); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := readTestAPDU(t, conn); head != (uAPCI{uStartDtConfirm}) {
+		t.Fatalf("got %v, want StartDtConfirm", head)
+	}
+	a := asdu.NewASDU(asdu.ParamsWide, asdu.Identifier{Type: asdu.C_IC_NA_1, Variable: asdu.VariableStruct{Number: 1},
+		Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}, CommonAddr: 1})
+	_ = a.AppendInfoObjAddr(0)
+	a.AppendBytes(byte(asdu.QOIStation))
+	raw, _ := a.MarshalBinary()
+	frame, err := newIFrame(5, 0, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+	e := next()
+	if !errors.Is(e, ErrSeqNoSend) || !errors.Is(e, asdu.ErrProtocol) || e.Expected != 0 || e.Actual != 5 || len(e.Frame) != len(frame) {
+		t.Errorf("violation = %+v, want N(S) 5 expected 0", e)
+	}
+	if e.Error() == "" || e.Detail == "" {
+		t.Error("violation without description")
+	}
+	if head, _ := readTestAPDU(t, conn); head != nil {
+		t.Errorf("got %v, want the connection closed", head)
+	}
+}
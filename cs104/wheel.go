@@ -0,0 +1,205 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// wheelSlots the number of slots of the timer wheel, one turn lasts wheelSlots * timeoutResolution
+const wheelSlots = 512
+
+// sharedWheel the timer wheel of the t1, t2 and t3 timeouts of all the connections of the process
+var sharedWheel timerWheel
+
+// timerWheel a hashed timer wheel of timeoutResolution slots driven by one ticker, which runs only
+// while timers are armed. A connection arms a single timer at its earliest deadline instead of
+// checking its timeouts on every tick or allocating a timer for every frame.
+type timerWheel struct {
+	mux    sync.Mutex
+	slots  [wheelSlots]map[*wheelTimer]struct{}
+	armed  int
+	cursor int64 // the last tick handled
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// wheelTimer a timer of the wheel, it fires once at the deadline armed, within timeoutResolution
+type wheelTimer struct {
+	C     chan time.Time
+	wheel *timerWheel
+	at    time.Time // the deadline, zero if not armed
+	slot  int
+}
+
+// newTimer returns a timer not armed
+func (sf *timerWheel) newTimer() *wheelTimer {
+	return &wheelTimer{C: make(chan time.Time, 1), wheel: sf}
+}
+
+// tick returns the index of the tick of t
+func tick(t time.Time) int64 {
+	return t.UnixNano() / int64(timeoutResolution)
+}
+
+// insert the timer in the slot of the tick following its deadline, so that the deadline is elapsed
+// when the slot is handled, the next tick if the deadline is already handled
+func (sf *timerWheel) insert(t *wheelTimer) {
+	if sf.ticker == nil {
+		sf.ticker = time.NewTicker(timeoutResolution)
+		sf.stop = make(chan struct{})
+		sf.cursor = tick(time.Now())
+		go sf.run(sf.ticker, sf.stop)
+	}
+	n := tick(t.at) + 1
+	if n <= sf.cursor {
+		n = sf.cursor + 1
+	}
+	t.slot = int(n % wheelSlots)
+	if sf.slots[t.slot] == nil {
+		sf.slots[t.slot] = make(map[*wheelTimer]struct{})
+	}
+	sf.slots[t.slot][t] = struct{}{}
+	sf.armed++
+}
+
+// remove the timer if armed, the ticker stops with the last timer
+func (sf *timerWheel) remove(t *wheelTimer) {
+	if t.at.IsZero() {
+		return
+	}
+	delete(sf.slots[t.slot], t)
+	t.at = time.Time{}
+	sf.armed--
+	if sf.armed == 0 && sf.ticker != nil {
+		sf.ticker.Stop()
+		close(sf.stop)
+		sf.ticker, sf.stop = nil, nil
+	}
+}
+
+func (sf *timerWheel) run(ticker *time.Ticker, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			sf.mux.Lock()
+			if sf.ticker != ticker { // stopped and restarted meanwhile
+				sf.mux.Unlock()
+				return
+			}
+			n := tick(now)
+			for i := sf.cursor + 1; i <= n && i <= sf.cursor+wheelSlots; i++ {
+				for t := range sf.slots[i%wheelSlots] {
+					if !t.at.After(now) {
+						sf.remove(t)
+						select {
+						case t.C <- now:
+						default:
+						}
+					}
+				}
+			}
+			if sf.ticker == ticker {
+				sf.cursor = n
+			}
+			sf.mux.Unlock()
+		}
+	}
+}
+
+// earlier arm the timer at the deadline if it is not armed or armed later
+func (sf *wheelTimer) earlier(at time.Time) {
+	sf.wheel.mux.Lock()
+	if sf.at.IsZero() || at.Before(sf.at) {
+		if !sf.at.IsZero() { // move, the ticker keeps running
+			delete(sf.wheel.slots[sf.slot], sf)
+			sf.wheel.armed--
+		}
+		sf.at = at
+		sf.wheel.insert(sf)
+	}
+	sf.wheel.mux.Unlock()
+}
+
+// stop disarm the timer and drop the tick not received
+func (sf *wheelTimer) stop() {
+	sf.wheel.mux.Lock()
+	sf.wheel.remove(sf)
+	sf.wheel.mux.Unlock()
+	select {
+	case <-sf.C:
+	default:
+	}
+}
+
+// fired returns the channel of the ticks
+func (sf *wheelTimer) fired() <-chan time.Time { return sf.C }
+
+// timeout the timer of the t1, t2 and t3 timeouts of a connection
+type timeout interface {
+	// earlier arm the timer at the deadline if it is not armed or armed later
+	earlier(at time.Time)
+	// stop disarm the timer and drop the tick not received
+	stop()
+	// fired returns the channel of the ticks
+	fired() <-chan time.Time
+}
+
+// newTimeout returns a timer not armed, of the shared wheel or of the clock if any
+func newTimeout(clock asdu.Clock) timeout {
+	if clock == nil {
+		return sharedWheel.newTimer()
+	}
+	t := clock.NewTimer(time.Hour)
+	t.Stop()
+	return &clockTimer{clock: clock, timer: t}
+}
+
+// clockTimer a timer of an injected clock
+type clockTimer struct {
+	mux   sync.Mutex
+	clock asdu.Clock
+	timer asdu.Timer
+	at    time.Time // the deadline, zero if not armed
+}
+
+func (sf *clockTimer) earlier(at time.Time) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	now := sf.clock.Now()
+	if sf.at.IsZero() || !sf.at.After(now) || at.Before(sf.at) {
+		sf.at = at
+		sf.timer.Stop()
+		sf.timer.Reset(at.Sub(now))
+	}
+}
+
+func (sf *clockTimer) stop() {
+	sf.mux.Lock()
+	sf.at = time.Time{}
+	sf.timer.Stop()
+	sf.mux.Unlock()
+	select {
+	case <-sf.timer.C():
+	default:
+	}
+}
+
+func (sf *clockTimer) fired() <-chan time.Time { return sf.timer.C() }
+
+// earliest returns the earliest of the times
+func earliest(t time.Time, ts ...time.Time) time.Time {
+	for _, v := range ts {
+		if v.Before(t) {
+			t = v
+		}
+	}
+	return t
+}
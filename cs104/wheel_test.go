@@ -0,0 +1,41 @@
+package cs104
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerWheel(t *testing.T) {
+	var w timerWheel
+	a, b := w.newTimer(), w.newTimer()
+	start := time.Now()
+	a.earlier(start.Add(3 * timeoutResolution))
+	b.earlier(start.Add(time.Hour))
+	b.earlier(start.Add(2 * time.Hour)) // later, kept
+	a.earlier(start)                    // earlier, moved to the next tick
+	select {
+	case <-a.C:
+		if d := time.Since(start); d > 2*timeoutResolution {
+			t.Errorf("fired after %v, want within %v", d, timeoutResolution)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timer not fired")
+	}
+	if !b.at.Equal(start.Add(time.Hour)) {
+		t.Errorf("deadline %v, want the earlier one", b.at)
+	}
+	b.stop()
+	w.mux.Lock()
+	running := w.ticker != nil
+	w.mux.Unlock()
+	if running {
+		t.Error("ticker running without armed timer")
+	}
+
+	a.earlier(time.Now().Add(timeoutResolution))
+	select {
+	case <-a.C:
+	case <-time.After(time.Second):
+		t.Fatal("timer not fired after restart")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package cs104
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// WindowStats the k/w flow control state of a connection, see IEC 60870-5-104, subclass 5.5
+type WindowStats struct {
+	K         uint16 // the maximum unacknowledged I-frames sent
+	W         uint16 // the latest acknowledgement after receiving w I-frames
+	Unacked   uint16 // the I-frames sent not acknowledged yet
+	Queued    int    // the asdu queued not sent yet
+	SeqNoSend uint16 // sequence number of next outbound I-frame
+	AckNoSend uint16 // outbound sequence number yet to be confirmed
+	SeqNoRcv  uint16 // sequence number of next inbound I-frame
+	AckNoRcv  uint16 // inbound sequence number yet to be confirmed
+	SeqErrors uint64 // connections closed for invalid sequence numbers, see Err
+}
+
+// Full whether the window has no room for one more asdu, the queued ones counted
+func (sf WindowStats) Full() bool {
+	return int(sf.Unacked)+sf.Queued >= int(sf.K)
+}
+
+// window the snapshot of the sequence numbers published by the run loop
+type window struct {
+	mux     sync.Mutex
+	seq     [4]uint16 // seqNoSend, ackNoSend, seqNoRcv, ackNoRcv
+	wake    chan struct{}
+	seqErrs uint64
+	lastErr error
+}
+
+// update publish the sequence numbers, waking up the waiters if changed
+func (sf *window) update(seqNoSend, ackNoSend, seqNoRcv, ackNoRcv uint16) {
+	sf.mux.Lock()
+	if v := [4]uint16{seqNoSend, ackNoSend, seqNoRcv, ackNoRcv}; v != sf.seq {
+		sf.seq = v
+		sf.notify()
+	}
+	sf.mux.Unlock()
+}
+
+// fail record the sequence number error the connection is closed for
+func (sf *window) fail(err error) {
+	sf.mux.Lock()
+	sf.seqErrs++
+	sf.lastErr = err
+	sf.mux.Unlock()
+}
+
+func (sf *window) err() error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return sf.lastErr
+}
+
+// wakeup wake up the waiters, for example on disconnection
+func (sf *window) wakeup() {
+	sf.mux.Lock()
+	sf.notify()
+	sf.mux.Unlock()
+}
+
+func (sf *window) notify() {
+	if sf.wake != nil {
+		close(sf.wake)
+		sf.wake = nil
+	}
+}
+
+// stats returns the window state and the channel closed on next change
+func (sf *window) stats(cfg *Config, queued int) (WindowStats, <-chan struct{}) {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.wake == nil {
+		sf.wake = make(chan struct{})
+	}
+	return WindowStats{
+		K:         cfg.SendUnAckLimitK,
+		W:         cfg.RecvUnAckLimitW,
+		Unacked:   seqNoCount(sf.seq[1], sf.seq[0]),
+		Queued:    queued,
+		SeqNoSend: sf.seq[0],
+		AckNoSend: sf.seq[1],
+		SeqNoRcv:  sf.seq[2],
+		AckNoRcv:  sf.seq[3],
+		SeqErrors: sf.seqErrs,
+	}, sf.wake
+}
+
+// windowConn a connection with the k/w flow control
+type windowConn interface {
+	IsConnected() bool
+	Send(a *asdu.ASDU) error
+	windowStats() (WindowStats, <-chan struct{})
+}
+
+// sendContext block until the window of the connection has room, then send the asdu
+func sendContext(ctx context.Context, c windowConn, a *asdu.ASDU) error {
+	for {
+		if !c.IsConnected() {
+			return ErrUseClosedConnection
+		}
+		stats, wake := c.windowStats()
+		if !stats.Full() {
+			return c.Send(a)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// trySend send the asdu if the window of the connection has room, or ErrWindowFull
+func trySend(c windowConn, a *asdu.ASDU) error {
+	if stats, _ := c.windowStats(); stats.Full() {
+		return ErrWindowFull
+	}
+	return c.Send(a)
+}
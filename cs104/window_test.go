@@ -0,0 +1,50 @@
+package cs104
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestSrvSession_window(t *testing.T) {
+	sess := newTestSession(&mockServerHandler{})
+	sess.config = &Config{SendUnAckLimitK: 3, RecvUnAckLimitW: 2}
+	sess.win.update(5, 4, 0, 0) // one unacknowledged
+
+	coa := asdu.CauseOfTransmission{Cause: asdu.Spontaneous}
+	c := &recordConn{}
+	for i := 0; i < 3; i++ {
+		_ = asdu.Single(c, false, coa, 1, asdu.SinglePointInfo{Ioa: asdu.InfoObjAddr(i + 1)})
+	}
+	packs := c.take()
+
+	for _, a := range packs[:2] {
+		if err := sess.TrySend(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := sess.WindowStats(); stats.Unacked != 1 || stats.Queued != 2 || !stats.Full() {
+		t.Fatalf("WindowStats() = %+v", stats)
+	}
+	if err := sess.TrySend(packs[2]); err != ErrWindowFull {
+		t.Fatalf("TrySend() error = %v, want %v", err, ErrWindowFull)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sess.SendContext(ctx, packs[2]); err != context.DeadlineExceeded {
+		t.Fatalf("SendContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	done := make(chan error)
+	go func() { done <- sess.SendContext(context.Background(), packs[2]) }()
+	time.Sleep(10 * time.Millisecond)
+	sess.win.update(5, 5, 0, 0) // acknowledged
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if sent := sess.sent(t); len(sent) != 3 {
+		t.Errorf("sent %d asdu, want 3", len(sent))
+	}
+}
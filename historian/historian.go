@@ -0,0 +1,231 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package historian feeds the points received by a client to a time series
+// database in batches. A database is plugged in through the Sink interface,
+// InfluxSink writes the InfluxDB line protocol.
+package historian
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/model"
+)
+
+// defined default
+const (
+	DefaultBatchSize     = 1000
+	DefaultFlushInterval = time.Second
+	DefaultMaxPending    = 100000
+	DefaultBackoff       = time.Second
+	DefaultMaxBackoff    = time.Minute
+)
+
+// ErrPermanent a sink error the batch is dropped for instead of retried, see Permanent
+var ErrPermanent = errors.New("historian: permanent error")
+
+// Permanent wraps the error so that the batch is not retried, like a rejected request
+func Permanent(err error) error {
+	return errors.Join(ErrPermanent, err)
+}
+
+// Sink the time series database the points are written to.
+type Sink interface {
+	// WritePoints writes the batch of points, the batch is retried on an error
+	// unless it is Permanent.
+	WritePoints(ctx context.Context, points []model.Point) error
+}
+
+// SinkFunc an adapter to use a function as a Sink
+type SinkFunc func(ctx context.Context, points []model.Point) error
+
+// WritePoints imp interface Sink
+func (sf SinkFunc) WritePoints(ctx context.Context, points []model.Point) error {
+	return sf(ctx, points)
+}
+
+// Config the collector configuration.
+type Config struct {
+	// BatchSize the points written at most at once, default DefaultBatchSize.
+	// A batch is written as soon as it is full.
+	BatchSize int
+	// FlushInterval the points are written at least every interval, default DefaultFlushInterval.
+	FlushInterval time.Duration
+	// MaxPending the points kept at most while the sink fails, the oldest are dropped
+	// beyond, default DefaultMaxPending.
+	MaxPending int
+	// Backoff the delay before the first retry of a failed batch, doubled on every
+	// retry up to MaxBackoff, default DefaultBackoff and DefaultMaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Stats the statistics of the collector.
+type Stats struct {
+	Written uint64 // number of points written
+	Dropped uint64 // number of points dropped by MaxPending or a permanent error
+	Retries uint64 // number of batches retried
+	Pending int    // number of points waiting to be written
+}
+
+// Collector collects the points of the monitor direction asdu and writes them
+// to the sink in batches, retrying with backoff while the sink fails.
+type Collector struct {
+	sink Sink
+	cfg  Config
+
+	mux     sync.Mutex
+	pending []model.Point
+	stats   Stats
+	full    chan struct{} // a batch is full
+	now     func() time.Time
+}
+
+// NewCollector new a collector writing to the sink, call Run to start writing.
+func NewCollector(sink Sink, cfg Config) *Collector {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = DefaultMaxPending
+	}
+	if cfg.MaxPending < cfg.BatchSize {
+		cfg.MaxPending = cfg.BatchSize
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = max(DefaultMaxBackoff, cfg.Backoff)
+	}
+	return &Collector{
+		sink: sink,
+		cfg:  cfg,
+		full: make(chan struct{}, 1),
+		now:  time.Now,
+	}
+}
+
+// Add queues the points, a point without time tag is stamped with the current time.
+func (sf *Collector) Add(points ...model.Point) {
+	if len(points) == 0 {
+		return
+	}
+	now := sf.now()
+	sf.mux.Lock()
+	for _, p := range points {
+		if p.Time.IsZero() {
+			p.Time = now
+		}
+		sf.pending = append(sf.pending, p)
+	}
+	if n := len(sf.pending) - sf.cfg.MaxPending; n > 0 {
+		sf.stats.Dropped += uint64(n)
+		sf.pending = append(sf.pending[:0], sf.pending[n:]...)
+	}
+	full := len(sf.pending) >= sf.cfg.BatchSize
+	sf.mux.Unlock()
+	if full {
+		select {
+		case sf.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ASDUHandler queues the points of the asdu, it fits the ASDUHandler of the client handlers.
+func (sf *Collector) ASDUHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	sf.Add(model.FromASDU(a)...)
+	return nil
+}
+
+// Stats returns the statistics of the collector
+func (sf *Collector) Stats() Stats {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	s := sf.stats
+	s.Pending = len(sf.pending)
+	return s
+}
+
+// Run writes the points queued until ctx is done, then tries once more to write
+// the points left within MaxBackoff. It returns ctx.Err().
+func (sf *Collector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(sf.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	backoff := sf.cfg.Backoff
+	for {
+		all := true // false writes the full batches only
+		select {
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.WithoutCancel(ctx), sf.cfg.MaxBackoff)
+			defer cancel()
+			for sf.Stats().Pending > 0 {
+				if err := sf.flush(final); err != nil {
+					break
+				}
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		case <-sf.full:
+			all = false
+		}
+
+		for ctx.Err() == nil {
+			if n := sf.Stats().Pending; n == 0 || !all && n < sf.cfg.BatchSize {
+				break
+			}
+			if err := sf.flush(ctx); err == nil {
+				backoff = sf.cfg.Backoff
+				continue
+			}
+			sf.mux.Lock()
+			sf.stats.Retries++
+			sf.mux.Unlock()
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, sf.cfg.MaxBackoff)
+		}
+	}
+}
+
+// flush writes a batch of the pending points, they are removed unless the write fails
+// with an error not permanent, which is returned
+func (sf *Collector) flush(ctx context.Context) error {
+	sf.mux.Lock()
+	n := min(len(sf.pending), sf.cfg.BatchSize)
+	batch := append([]model.Point(nil), sf.pending[:n]...)
+	dropped := sf.stats.Dropped
+	sf.mux.Unlock()
+	if n == 0 {
+		return nil
+	}
+
+	err := sf.sink.WritePoints(ctx, batch)
+	if err != nil && !errors.Is(err, ErrPermanent) {
+		return err
+	}
+
+	sf.mux.Lock()
+	// the oldest points of the batch may be dropped by Add meanwhile
+	n -= min(n, int(sf.stats.Dropped-dropped))
+	sf.pending = append(sf.pending[:0], sf.pending[n:]...)
+	if err != nil {
+		sf.stats.Dropped += uint64(n)
+	} else {
+		sf.stats.Written += uint64(len(batch))
+	}
+	sf.mux.Unlock()
+	return nil
+}
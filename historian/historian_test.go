@@ -0,0 +1,157 @@
+package historian
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/model"
+)
+
+type recordSink struct {
+	mux     sync.Mutex
+	batches [][]model.Point
+	fail    int // the writes failing first
+	err     error
+}
+
+func (sf *recordSink) WritePoints(_ context.Context, points []model.Point) error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if sf.fail > 0 {
+		sf.fail--
+		return sf.err
+	}
+	sf.batches = append(sf.batches, points)
+	return nil
+}
+
+func (sf *recordSink) written() int {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	var n int
+	for _, b := range sf.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func waitStats(t *testing.T, c *Collector, ok func(Stats) bool) Stats {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s := c.Stats()
+		if ok(s) {
+			return s
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats() = %+v", s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCollector_batch(t *testing.T) {
+	sink := &recordSink{fail: 2, err: errors.New("unavailable")}
+	c := NewCollector(sink, Config{BatchSize: 3, FlushInterval: time.Hour, Backoff: time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.Add(model.Point{Index: 1, Time: tm}, model.Point{Index: 2}, model.Point{Index: 3}, model.Point{Index: 4})
+	s := waitStats(t, c, func(s Stats) bool { return s.Written == 3 })
+	if s.Retries != 2 || s.Pending != 1 {
+		t.Errorf("Stats() = %+v, want 2 retries and 1 pending", s)
+	}
+	if b := sink.batches[0]; b[0].Time != tm || b[1].Time.IsZero() {
+		t.Errorf("batch times = %v, %v, want the time tag and the reception time", b[0].Time, b[1].Time)
+	}
+
+	// the points left are written on exit
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v, want %v", err, context.Canceled)
+	}
+	if n := sink.written(); n != 4 {
+		t.Errorf("written %d, want 4", n)
+	}
+}
+
+func TestCollector_drop(t *testing.T) {
+	sink := &recordSink{fail: 1, err: Permanent(errors.New("bad request"))}
+	c := NewCollector(sink, Config{BatchSize: 2, MaxPending: 3, FlushInterval: time.Millisecond})
+	c.Add(model.Point{Index: 1}, model.Point{Index: 2}, model.Point{Index: 3}, model.Point{Index: 4})
+	if s := c.Stats(); s.Dropped != 1 || s.Pending != 3 {
+		t.Fatalf("Stats() = %+v, want 1 dropped beyond MaxPending", s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+	s := waitStats(t, c, func(s Stats) bool { return s.Pending == 0 })
+	if s.Dropped != 3 || s.Written != 1 || s.Retries != 0 {
+		t.Errorf("Stats() = %+v, want the batch of the permanent error dropped", s)
+	}
+}
+
+func TestAppendLine(t *testing.T) {
+	tm := time.Unix(1577934245, 0)
+	tests := []struct {
+		p    model.Point
+		want string
+	}{
+		{model.Point{Station: 1, Index: 100, Kind: model.Analog, Value: 12.5, Time: tm},
+			"iec104,station=1,index=100,kind=Analog value=12.5,quality=0i 1577934245000000000\n"},
+		{model.Point{Station: 2, Index: 7, Kind: model.Counter, Value: -3, Quality: model.Invalid, SeqNumber: 4, Time: tm},
+			"iec104,station=2,index=7,kind=Counter value=-3,quality=1i,seq=4i 1577934245000000000\n"},
+		{model.Point{Station: 1, Index: 101, Kind: model.Analog, Value: math.NaN(), Quality: model.Invalid, Time: tm},
+			"iec104,station=1,index=101,kind=Analog quality=1i 1577934245000000000\n"},
+		{model.Point{Station: 1, Index: 102, Kind: model.Analog, Value: math.Inf(-1), Time: tm},
+			"iec104,station=1,index=102,kind=Analog quality=0i 1577934245000000000\n"},
+	}
+	for _, tt := range tests {
+		if got := string(AppendLine(nil, DefaultMeasurement, tt.p)); got != tt.want {
+			t.Errorf("AppendLine() = %q, want %q", got, tt.want)
+		}
+	}
+	p := model.Point{Station: 1, Index: 1, Kind: model.Binary, Value: 1, Time: time.Unix(1, 0)}
+	if got, want := string(AppendLine(nil, "plant a,b", p)), "plant\\ a\\,b,station=1,index=1,kind=Binary value=1,quality=0i 1000000000\n"; got != want {
+		t.Errorf("AppendLine() = %q, want %q", got, want)
+	}
+}
+
+func TestInfluxSink_WritePoints(t *testing.T) {
+	var body, auth string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, auth = string(b), r.Header.Get("Authorization")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := &InfluxSink{URL: srv.URL, Token: "secret", Measurement: "plant"}
+	p := model.Point{Station: 1, Index: 1, Kind: model.Binary, Value: 1, Time: time.Unix(1, 0)}
+	if err := sink.WritePoints(context.Background(), []model.Point{p}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "plant,station=1,index=1,kind=Binary value=1,quality=0i 1000000000\n"; body != want || auth != "Token secret" {
+		t.Errorf("request = %q %q", body, auth)
+	}
+
+	status = http.StatusBadRequest
+	if err := sink.WritePoints(context.Background(), []model.Point{p}); !errors.Is(err, ErrPermanent) {
+		t.Errorf("WritePoints() = %v, want %v", err, ErrPermanent)
+	}
+	status = http.StatusServiceUnavailable
+	if err := sink.WritePoints(context.Background(), []model.Point{p}); err == nil || errors.Is(err, ErrPermanent) {
+		t.Errorf("WritePoints() = %v, want a temporary error", err)
+	}
+}
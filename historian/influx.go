@@ -0,0 +1,115 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package historian
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/rob-gra/go-iecp5/model"
+)
+
+// DefaultMeasurement the default measurement of the InfluxDB line protocol
+const DefaultMeasurement = "iec104"
+
+// InfluxSink a Sink writing the points in the InfluxDB line protocol to the write
+// endpoint over HTTP, one line per point:
+//
+//	iec104,station=1,index=100,kind=Analog value=12.5,quality=0i 1577934245000000000
+//
+// A response 4xx other than 429 is a Permanent error, the batch is dropped.
+type InfluxSink struct {
+	// URL the write endpoint with the nanosecond precision, like
+	// http://localhost:8086/api/v2/write?org=org&bucket=bucket&precision=ns
+	// or http://localhost:8086/write?db=db for InfluxDB 1.x.
+	URL string
+	// Token the API token, sent as "Authorization: Token <Token>" if not empty.
+	Token string
+	// Measurement the measurement of the points, default DefaultMeasurement.
+	Measurement string
+	// Client the HTTP client, default http.DefaultClient.
+	Client *http.Client
+}
+
+// WritePoints imp interface Sink
+func (sf *InfluxSink) WritePoints(ctx context.Context, points []model.Point) error {
+	measurement := sf.Measurement
+	if measurement == "" {
+		measurement = DefaultMeasurement
+	}
+	var body []byte
+	for _, p := range points {
+		body = AppendLine(body, measurement, p)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sf.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if sf.Token != "" {
+		req.Header.Set("Authorization", "Token "+sf.Token)
+	}
+	client := sf.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("historian: influx write %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
+
+// AppendLine appends the line of the point in the InfluxDB line protocol, terminated by a new line.
+// The station, index and kind are tags, the value and quality fields, a counter has the seq field too.
+// A NaN or infinite value has no representation in the protocol, the value field is left out.
+// The commas and spaces of the measurement are escaped.
+func AppendLine(b []byte, measurement string, p model.Point) []byte {
+	for i := 0; i < len(measurement); i++ {
+		if c := measurement[i]; c == ',' || c == ' ' {
+			b = append(b, '\\')
+		}
+		b = append(b, measurement[i])
+	}
+	b = append(b, ",station="...)
+	b = strconv.AppendUint(b, uint64(p.Station), 10)
+	b = append(b, ",index="...)
+	b = strconv.AppendUint(b, uint64(p.Index), 10)
+	b = append(b, ",kind="...)
+	b = append(b, p.Kind.String()...)
+	if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+		b = append(b, " quality="...)
+	} else {
+		b = append(b, " value="...)
+		b = strconv.AppendFloat(b, p.Value, 'g', -1, 64)
+		b = append(b, ",quality="...)
+	}
+	b = strconv.AppendUint(b, uint64(p.Quality), 10)
+	b = append(b, 'i')
+	if p.Kind == model.Counter {
+		b = append(b, ",seq="...)
+		b = strconv.AppendUint(b, uint64(p.SeqNumber), 10)
+		b = append(b, 'i')
+	}
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, p.Time.UnixNano(), 10)
+	return append(b, '\n')
+}
@@ -0,0 +1,254 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package live streams the decoded asdu to web dashboards over WebSocket as
+// JSON messages, served straight from the process without a broker. The Hub
+// is an http.Handler, mount it on any mux, and feed it with the asdu received.
+package live
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/bridge"
+)
+
+// DefaultQueueSize the default messages queued for a subscriber
+const DefaultQueueSize = 256
+
+// writeTimeout the timeout of the write of a frame, a subscriber stuck longer is closed
+const writeTimeout = 10 * time.Second
+
+// Message the JSON message of an asdu, the points of the monitor direction asdu
+// are encoded like the payload of the bridge.
+type Message struct {
+	Type   string          `json:"type"`
+	Cause  asdu.Cause      `json:"cause"`
+	CA     asdu.CommonAddr `json:"ca"`
+	RxTime time.Time       `json:"rxTime"`
+	Points []bridge.Point  `json:"points,omitempty"`
+}
+
+// subscriber a websocket connection of the hub
+type subscriber struct {
+	conn  net.Conn
+	wr    *bufio.Writer
+	wmux  sync.Mutex // serializes the frames written
+	queue chan []byte
+	cas   map[asdu.CommonAddr]bool // the common addresses subscribed, nil all
+	done  chan struct{}
+	once  sync.Once
+}
+
+// Hub serves the WebSocket subscribers and fans the messages out to them. A subscriber
+// may restrict the stream to common addresses by the query, like /live?ca=1&ca=2.
+// A slow subscriber loses the messages beyond its queue instead of holding up the others.
+type Hub struct {
+	mux         sync.Mutex
+	subscribers map[*subscriber]struct{}
+	queueSize   int
+	checkOrigin func(r *http.Request) bool
+	dropped     atomic.Uint64
+	closed      bool
+}
+
+// NewHub new a hub with a queue of queueSize messages per subscriber, DefaultQueueSize if not positive
+func NewHub(queueSize int) *Hub {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	return &Hub{
+		subscribers: make(map[*subscriber]struct{}),
+		queueSize:   queueSize,
+		checkOrigin: sameOrigin,
+	}
+}
+
+// SetCheckOrigin set the check of the Origin header of the handshake, by default a browser
+// page of another host than the request is refused, against the cross site websocket hijacking.
+func (sf *Hub) SetCheckOrigin(f func(r *http.Request) bool) *Hub {
+	if f != nil {
+		sf.checkOrigin = f
+	}
+	return sf
+}
+
+// sameOrigin whether the request has no Origin header or the host of it is the host of the request
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Publish sends the message of the asdu to the subscribers, the asdu is left unchanged
+func (sf *Hub) Publish(a *asdu.ASDU) error {
+	msg := Message{
+		Type:   a.Type.Name(),
+		Cause:  a.Coa.Cause,
+		CA:     a.CommonAddr,
+		RxTime: time.Now(),
+		Points: bridge.Points(a),
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	for s := range sf.subscribers {
+		if s.cas != nil && !s.cas[a.CommonAddr] {
+			continue
+		}
+		select {
+		case s.queue <- payload:
+		default:
+			sf.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// ASDUHandler publishes the asdu, it fits the ASDUHandler of the client handlers.
+func (sf *Hub) ASDUHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.Publish(a)
+}
+
+// Subscribers returns the number of the subscribers connected
+func (sf *Hub) Subscribers() int {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return len(sf.subscribers)
+}
+
+// Dropped returns the number of the messages dropped for the slow subscribers
+func (sf *Hub) Dropped() uint64 {
+	return sf.dropped.Load()
+}
+
+// Close disconnect all the subscribers and refuse the new ones
+func (sf *Hub) Close() error {
+	sf.mux.Lock()
+	sf.closed = true
+	subs := make([]*subscriber, 0, len(sf.subscribers))
+	for s := range sf.subscribers {
+		subs = append(subs, s)
+	}
+	sf.mux.Unlock()
+	for _, s := range subs {
+		s.close(1001) // going away
+	}
+	return nil
+}
+
+// ServeHTTP imp interface http.Handler, it upgrades the request to a websocket
+// and streams the messages until the subscriber or the hub closes.
+func (sf *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !sf.checkOrigin(r) {
+		http.Error(w, "live: origin not allowed", http.StatusForbidden)
+		return
+	}
+	var cas map[asdu.CommonAddr]bool
+	for _, v := range r.URL.Query()["ca"] {
+		ca, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			http.Error(w, "live: bad common address "+v, http.StatusBadRequest)
+			return
+		}
+		if cas == nil {
+			cas = make(map[asdu.CommonAddr]bool)
+		}
+		cas[asdu.CommonAddr(ca)] = true
+	}
+
+	conn, rw, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	s := &subscriber{
+		conn:  conn,
+		wr:    rw.Writer,
+		queue: make(chan []byte, sf.queueSize),
+		cas:   cas,
+		done:  make(chan struct{}),
+	}
+	sf.mux.Lock()
+	if sf.closed {
+		sf.mux.Unlock()
+		s.close(1001)
+		return
+	}
+	sf.subscribers[s] = struct{}{}
+	sf.mux.Unlock()
+	defer func() {
+		sf.mux.Lock()
+		delete(sf.subscribers, s)
+		sf.mux.Unlock()
+	}()
+
+	go s.readLoop(rw.Reader)
+	for {
+		select {
+		case <-s.done:
+			return
+		case payload := <-s.queue:
+			if err := s.write(opText, payload); err != nil {
+				s.close(0)
+				return
+			}
+		}
+	}
+}
+
+// readLoop answer the control frames until the subscriber closes
+func (sf *subscriber) readLoop(r *bufio.Reader) {
+	for {
+		op, payload, err := readFrame(r)
+		if err != nil {
+			sf.close(1002) // protocol error
+			return
+		}
+		switch op {
+		case opPing:
+			if sf.write(opPong, payload) != nil {
+				sf.close(0)
+				return
+			}
+		case opClose:
+			sf.close(1000)
+			return
+		}
+	}
+}
+
+// write a frame within the write timeout
+func (sf *subscriber) write(op byte, payload []byte) error {
+	sf.wmux.Lock()
+	defer sf.wmux.Unlock()
+	_ = sf.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return writeFrame(sf.wr, op, payload)
+}
+
+// close send the close frame of the status code if not 0 and close the connection
+func (sf *subscriber) close(code uint16) {
+	sf.once.Do(func() {
+		if code != 0 {
+			_ = sf.write(opClose, []byte{byte(code >> 8), byte(code)})
+		}
+		sf.conn.Close()
+		close(sf.done)
+	})
+}
@@ -0,0 +1,185 @@
+package live
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// recordConn record the sent asdu
+type recordConn struct {
+	sent []*asdu.ASDU
+}
+
+func (sf *recordConn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *recordConn) UnderlyingConn() net.Conn { return nil }
+func (sf *recordConn) Send(a *asdu.ASDU) error {
+	sf.sent = append(sf.sent, a)
+	return nil
+}
+
+type wsClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, srv *httptest.Server, path string) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: "+strings.TrimPrefix(srv.URL, "http://")+"\r\n"+
+		"Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake response %s %v", resp.Status, resp.Header)
+	}
+	return &wsClient{conn, r}
+}
+
+// read a frame sent by the server
+func (sf *wsClient) read(t *testing.T) (byte, []byte) {
+	t.Helper()
+	_ = sf.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var hdr [8]byte
+	if _, err := io.ReadFull(sf.r, hdr[:2]); err != nil {
+		t.Fatal(err)
+	}
+	op, l := hdr[0]&0x0f, int(hdr[1]&0x7f)
+	if l == 126 {
+		_, _ = io.ReadFull(sf.r, hdr[:2])
+		l = int(binary.BigEndian.Uint16(hdr[:2]))
+	}
+	payload := make([]byte, l)
+	if _, err := io.ReadFull(sf.r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return op, payload
+}
+
+// write a masked frame
+func (sf *wsClient) write(op byte, payload []byte) {
+	mask := [4]byte{1, 2, 3, 4}
+	b := []byte{0x80 | op, 0x80 | byte(len(payload))}
+	b = append(b, mask[:]...)
+	for i, v := range payload {
+		b = append(b, v^mask[i%4])
+	}
+	_, _ = sf.conn.Write(b)
+}
+
+func waitSubscribers(t *testing.T, h *Hub, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); h.Subscribers() != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Subscribers() = %d, want %d", h.Subscribers(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHub(t *testing.T) {
+	h := NewHub(0)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	all := dial(t, srv, "/")
+	only2 := dial(t, srv, "/?ca=2")
+	waitSubscribers(t, h, 2)
+
+	c := &recordConn{}
+	_ = asdu.MeasuredValueFloat(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 1,
+		asdu.MeasuredValueFloatInfo{Ioa: 100, Value: 1.5})
+	_ = asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 2, asdu.SinglePointInfo{Ioa: 7, Value: true})
+	for _, a := range c.sent {
+		if err := h.ASDUHandler(c, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var msg struct {
+		Type   string
+		CA     int
+		Points []struct {
+			IOA   int
+			Value interface{}
+		}
+	}
+	op, payload := all.read(t)
+	if err := json.Unmarshal(payload, &msg); err != nil || op != opText {
+		t.Fatalf("message %d %s: %v", op, payload, err)
+	}
+	if msg.Type != "M_ME_NC_1" || msg.CA != 1 || len(msg.Points) != 1 || msg.Points[0].IOA != 100 || msg.Points[0].Value != 1.5 {
+		t.Errorf("message = %s", payload)
+	}
+	_, payload = only2.read(t)
+	if err := json.Unmarshal(payload, &msg); err != nil || msg.CA != 2 || msg.Points[0].Value != true {
+		t.Errorf("message of the subscriber of ca 2 = %s", payload)
+	}
+
+	all.write(opPing, []byte("hi"))
+	if op, payload = all.read(t); op == opText {
+		op, payload = all.read(t) // the single point
+	}
+	if op != opPong || string(payload) != "hi" {
+		t.Errorf("ping answered %d %q", op, payload)
+	}
+
+	all.write(opClose, []byte{0x03, 0xe8})
+	if op, _ = all.read(t); op != opClose {
+		t.Errorf("close answered %d", op)
+	}
+	waitSubscribers(t, h, 1)
+
+	_ = h.Close()
+	if op, payload = only2.read(t); op != opClose || binary.BigEndian.Uint16(payload) != 1001 {
+		t.Errorf("hub close sent %d % x", op, payload)
+	}
+	waitSubscribers(t, h, 0)
+}
+
+func TestHub_refused(t *testing.T) {
+	srv := httptest.NewServer(NewHub(0))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		path   string
+		want   int
+	}{
+		{"not websocket", http.Header{}, "/", http.StatusBadRequest},
+		{"other origin", http.Header{"Origin": {"http://evil.example"}}, "/", http.StatusForbidden},
+		{"bad ca", http.Header{}, "/?ca=x", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			req.Header = tt.header
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package modbus maps Modbus coils and registers to IEC 60870-5-104
+// information objects after a declarative mapping table. Any Modbus
+// library is plugged in through the Client interface.
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+// error defined
+var (
+	ErrMapping = errors.New("modbus: invalid mapping")
+	ErrRead    = errors.New("modbus: read failed")
+)
+
+// Client reads and writes the Modbus data of a device.
+type Client interface {
+	ReadCoils(address, quantity uint16) ([]bool, error)
+	ReadDiscreteInputs(address, quantity uint16) ([]bool, error)
+	ReadHoldingRegisters(address, quantity uint16) ([]uint16, error)
+	ReadInputRegisters(address, quantity uint16) ([]uint16, error)
+	WriteSingleCoil(address uint16, value bool) error
+	WriteMultipleRegisters(address uint16, values []uint16) error
+}
+
+// Area is the Modbus data area of a mapping.
+type Area byte
+
+// defined areas
+const (
+	Coil Area = iota
+	DiscreteInput
+	HoldingRegister
+	InputRegister
+)
+
+// Format is the encoding of a register value, the 32 bit formats span two
+// registers with the high word first.
+type Format byte
+
+// defined formats
+const (
+	Uint16 Format = iota
+	Int16
+	Uint32
+	Int32
+	Float32
+)
+
+// words returns the number of registers of the format
+func (sf Format) words() uint16 {
+	if sf >= Uint32 {
+		return 2
+	}
+	return 1
+}
+
+// Mapping maps a coil, a discrete input or a register to an information object.
+type Mapping struct {
+	Area    Area
+	Address uint16
+	Format  Format // of the registers
+
+	CA  asdu.CommonAddr
+	IOA asdu.InfoObjAddr
+	// Type is M_SP_NA_1 for the coils and discrete inputs,
+	// M_ME_NA_1, M_ME_NB_1 or M_ME_NC_1 for the registers.
+	Type asdu.TypeID
+	// the value reported is raw*Scale + Offset, Scale zero means 1.
+	Scale, Offset float64
+	// Deadband is the minimum change of the value since the last report
+	// that is reported, zero reports every change.
+	Deadband float64
+	// Writable the commands addressed to the information object are written,
+	// C_SC_NA_1 to a coil, C_SE_NA_1, C_SE_NB_1 or C_SE_NC_1 to holding registers.
+	Writable bool
+}
+
+func (sf *Mapping) scale() float64 {
+	if sf.Scale == 0 {
+		return 1
+	}
+	return sf.Scale
+}
+
+func (sf *Mapping) valid() error {
+	switch {
+	case sf.Area <= DiscreteInput && sf.Type != asdu.M_SP_NA_1,
+		sf.Area >= HoldingRegister && sf.Type != asdu.M_ME_NA_1 && sf.Type != asdu.M_ME_NB_1 && sf.Type != asdu.M_ME_NC_1,
+		sf.Area > InputRegister, sf.Format > Float32,
+		sf.Writable && sf.Area != Coil && sf.Area != HoldingRegister:
+		return fmt.Errorf("%w: %s ioa %d", ErrMapping, sf.Type, sf.IOA)
+	}
+	return nil
+}
+
+type reading struct {
+	value       float64
+	qds         asdu.QualityDescriptor
+	reported    float64
+	reportedQds asdu.QualityDescriptor
+	hasReported bool
+}
+
+type key struct {
+	ca  asdu.CommonAddr
+	ioa asdu.InfoObjAddr
+}
+
+// Gateway polls the Modbus device and forwards the commands to it.
+type Gateway struct {
+	client Client
+	maps   []Mapping
+	index  map[key]int
+
+	mux      sync.Mutex
+	readings []reading
+}
+
+// New new a gateway of the Modbus client with the mapping table.
+func New(client Client, maps ...Mapping) (*Gateway, error) {
+	sf := &Gateway{
+		client:   client,
+		maps:     maps,
+		index:    make(map[key]int, len(maps)),
+		readings: make([]reading, len(maps)),
+	}
+	for i := range maps {
+		if err := maps[i].valid(); err != nil {
+			return nil, err
+		}
+		k := key{maps[i].CA, maps[i].IOA}
+		if _, ok := sf.index[k]; ok {
+			return nil, fmt.Errorf("%w: duplicate ca %d ioa %d", ErrMapping, k.ca, k.ioa)
+		}
+		sf.index[k] = i
+	}
+	return sf, nil
+}
+
+// Poll reads every mapping and sends the changes spontaneously over c. A
+// failed read marks the point invalid, the errors are joined and returned
+// after the changes are sent.
+func (sf *Gateway) Poll(c asdu.Connect) error {
+	var errs []error
+	var changed []int
+
+	sf.mux.Lock()
+	for i := range sf.maps {
+		m := &sf.maps[i]
+		r := &sf.readings[i]
+		v, err := sf.read(m)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: ca %d ioa %d, %v", ErrRead, m.CA, m.IOA, err))
+			r.qds = asdu.QDSInvalid
+		} else {
+			r.value, r.qds = v*m.scale()+m.Offset, asdu.QDSGood
+		}
+		if !r.hasReported || r.qds != r.reportedQds || math.Abs(r.value-r.reported) > m.Deadband {
+			r.reported, r.reportedQds, r.hasReported = r.value, r.qds, true
+			changed = append(changed, i)
+		}
+	}
+	sf.mux.Unlock()
+
+	if err := sf.send(c, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, changed); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Interrogate sends the last readings of the common address over c with the
+// cause interrogated by station, the global address sends all of them.
+func (sf *Gateway) Interrogate(c asdu.Connect, ca asdu.CommonAddr) error {
+	var points []int
+	sf.mux.Lock()
+	for i := range sf.maps {
+		if sf.readings[i].hasReported && (ca == asdu.GlobalCommonAddr || sf.maps[i].CA == ca) {
+			points = append(points, i)
+		}
+	}
+	sf.mux.Unlock()
+	return sf.send(c, asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}, points)
+}
+
+func (sf *Gateway) read(m *Mapping) (float64, error) {
+	switch m.Area {
+	case Coil, DiscreteInput:
+		read := sf.client.ReadCoils
+		if m.Area == DiscreteInput {
+			read = sf.client.ReadDiscreteInputs
+		}
+		bits, err := read(m.Address, 1)
+		if err != nil {
+			return 0, err
+		}
+		if len(bits) < 1 {
+			return 0, errors.New("short response")
+		}
+		if bits[0] {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	read := sf.client.ReadHoldingRegisters
+	if m.Area == InputRegister {
+		read = sf.client.ReadInputRegisters
+	}
+	regs, err := read(m.Address, m.Format.words())
+	if err != nil {
+		return 0, err
+	}
+	if len(regs) < int(m.Format.words()) {
+		return 0, errors.New("short response")
+	}
+	return decode(m.Format, regs), nil
+}
+
+func decode(f Format, regs []uint16) float64 {
+	switch f {
+	case Int16:
+		return float64(int16(regs[0]))
+	case Uint32:
+		return float64(uint32(regs[0])<<16 | uint32(regs[1]))
+	case Int32:
+		return float64(int32(uint32(regs[0])<<16 | uint32(regs[1])))
+	case Float32:
+		return float64(math.Float32frombits(uint32(regs[0])<<16 | uint32(regs[1])))
+	}
+	return float64(regs[0])
+}
+
+func encode(f Format, v float64) []uint16 {
+	var u uint32
+	switch f {
+	case Uint16:
+		return []uint16{uint16(math.Max(0, math.Min(math.MaxUint16, math.Round(v))))}
+	case Int16:
+		return []uint16{uint16(int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(v)))))}
+	case Uint32:
+		u = uint32(math.Max(0, math.Min(math.MaxUint32, math.Round(v))))
+	case Int32:
+		u = uint32(int32(math.Max(math.MinInt32, math.Min(math.MaxInt32, math.Round(v)))))
+	default:
+		u = math.Float32bits(float32(v))
+	}
+	return []uint16{uint16(u >> 16), uint16(u)}
+}
+
+// send the points over c, grouped by common address and type identification
+func (sf *Gateway) send(c asdu.Connect, coa asdu.CauseOfTransmission, points []int) error {
+	sf.mux.Lock()
+	type group struct {
+		ca     asdu.CommonAddr
+		typeID asdu.TypeID
+	}
+	var order []group
+	groups := make(map[group][]int)
+	for _, i := range points {
+		g := group{sf.maps[i].CA, sf.maps[i].Type}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	var sps = make(map[group][]asdu.SinglePointInfo)
+	var mes = make(map[group][]asdu.MeasuredValueFloatInfo)
+	for _, g := range order {
+		for _, i := range groups[g] {
+			r := sf.readings[i]
+			if g.typeID == asdu.M_SP_NA_1 {
+				sps[g] = append(sps[g], asdu.SinglePointInfo{Ioa: sf.maps[i].IOA, Value: r.reported != 0, Qds: r.reportedQds})
+			} else {
+				mes[g] = append(mes[g], asdu.MeasuredValueFloatInfo{Ioa: sf.maps[i].IOA, Value: float32(r.reported), Qds: r.reportedQds})
+			}
+		}
+	}
+	sf.mux.Unlock()
+
+	for _, g := range order {
+		var err error
+		switch g.typeID {
+		case asdu.M_SP_NA_1:
+			err = asdu.SingleBatch(c, g.typeID, coa, g.ca, sps[g]...)
+		case asdu.M_ME_NA_1:
+			infos := make([]asdu.MeasuredValueNormalInfo, 0, len(mes[g]))
+			for _, v := range mes[g] {
+				infos = append(infos, asdu.MeasuredValueNormalInfo{Ioa: v.Ioa, Value: normalize(float64(v.Value)), Qds: v.Qds})
+			}
+			err = asdu.MeasuredValueNormalBatch(c, g.typeID, coa, g.ca, infos...)
+		case asdu.M_ME_NB_1:
+			infos := make([]asdu.MeasuredValueScaledInfo, 0, len(mes[g]))
+			for _, v := range mes[g] {
+				infos = append(infos, asdu.MeasuredValueScaledInfo{Ioa: v.Ioa,
+					Value: int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(float64(v.Value))))), Qds: v.Qds})
+			}
+			err = asdu.MeasuredValueScaledBatch(c, g.typeID, coa, g.ca, infos...)
+		default:
+			err = asdu.MeasuredValueFloatBatch(c, g.typeID, coa, g.ca, mes[g]...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalize the value in [-1, 1)
+func normalize(v float64) asdu.Normalize {
+	return asdu.Normalize(math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(v*32768))))
+}
+
+var _ cs104.ServerCommandHandler = (*Gateway)(nil)
+
+// writable returns the writable mapping addressed by the command
+func (sf *Gateway) writable(id asdu.Identifier, ioa asdu.InfoObjAddr, area Area) (*Mapping, error) {
+	i, ok := sf.index[key{id.CommonAddr, ioa}]
+	if !ok || !sf.maps[i].Writable {
+		return nil, cs104.ErrReject{Cause: asdu.UnknownIOA}
+	}
+	if sf.maps[i].Area != area {
+		return nil, cs104.ErrReject{Cause: asdu.UnknownTypeID}
+	}
+	if id.Coa.Cause != asdu.Activation {
+		return nil, cs104.ErrReject{Cause: asdu.UnknownCOT}
+	}
+	return &sf.maps[i], nil
+}
+
+// OnSingleCommand writes the coil, a select is only checked.
+func (sf *Gateway) OnSingleCommand(_ asdu.Connect, id asdu.Identifier, cmd asdu.SingleCommandInfo) error {
+	m, err := sf.writable(id, cmd.Ioa, Coil)
+	if err != nil || cmd.Qoc.InSelect {
+		return err
+	}
+	return sf.client.WriteSingleCoil(m.Address, cmd.Value)
+}
+
+// OnSetpointNormal writes the holding registers, a select is only checked.
+func (sf *Gateway) OnSetpointNormal(_ asdu.Connect, id asdu.Identifier, cmd asdu.SetpointCommandNormalInfo) error {
+	return sf.setpoint(id, cmd.Ioa, cmd.Qos, cmd.Value.Float64())
+}
+
+// OnSetpointScaled writes the holding registers, a select is only checked.
+func (sf *Gateway) OnSetpointScaled(_ asdu.Connect, id asdu.Identifier, cmd asdu.SetpointCommandScaledInfo) error {
+	return sf.setpoint(id, cmd.Ioa, cmd.Qos, float64(cmd.Value))
+}
+
+// OnSetpointFloat writes the holding registers, a select is only checked.
+func (sf *Gateway) OnSetpointFloat(_ asdu.Connect, id asdu.Identifier, cmd asdu.SetpointCommandFloatInfo) error {
+	return sf.setpoint(id, cmd.Ioa, cmd.Qos, float64(cmd.Value))
+}
+
+func (sf *Gateway) setpoint(id asdu.Identifier, ioa asdu.InfoObjAddr, qos asdu.QualifierOfSetpointCmd, v float64) error {
+	m, err := sf.writable(id, ioa, HoldingRegister)
+	if err != nil || qos.InSelect {
+		return err
+	}
+	return sf.client.WriteMultipleRegisters(m.Address, encode(m.Format, (v-m.Offset)/m.scale()))
+}
+
+// OnDoubleCommand rejected, no mapping
+func (sf *Gateway) OnDoubleCommand(asdu.Connect, asdu.Identifier, asdu.DoubleCommandInfo) error {
+	return cs104.ErrReject{Cause: asdu.UnknownTypeID}
+}
+
+// OnStepCommand rejected, no mapping
+func (sf *Gateway) OnStepCommand(asdu.Connect, asdu.Identifier, asdu.StepCommandInfo) error {
+	return cs104.ErrReject{Cause: asdu.UnknownTypeID}
+}
+
+// OnBitString32Command rejected, no mapping
+func (sf *Gateway) OnBitString32Command(asdu.Connect, asdu.Identifier, asdu.BitsString32CommandInfo) error {
+	return cs104.ErrReject{Cause: asdu.UnknownTypeID}
+}
@@ -0,0 +1,171 @@
+package modbus
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+)
+
+type mockClient struct {
+	coils   map[uint16]bool
+	regs    map[uint16]uint16
+	fail    bool
+	written map[uint16][]uint16
+}
+
+func (sf *mockClient) bits(address, quantity uint16) ([]bool, error) {
+	if sf.fail {
+		return nil, errors.New("timeout")
+	}
+	v := make([]bool, quantity)
+	for i := range v {
+		v[i] = sf.coils[address+uint16(i)]
+	}
+	return v, nil
+}
+
+func (sf *mockClient) registers(address, quantity uint16) ([]uint16, error) {
+	if sf.fail {
+		return nil, errors.New("timeout")
+	}
+	v := make([]uint16, quantity)
+	for i := range v {
+		v[i] = sf.regs[address+uint16(i)]
+	}
+	return v, nil
+}
+
+func (sf *mockClient) ReadCoils(address, quantity uint16) ([]bool, error) {
+	return sf.bits(address, quantity)
+}
+func (sf *mockClient) ReadDiscreteInputs(address, quantity uint16) ([]bool, error) {
+	return sf.bits(address, quantity)
+}
+func (sf *mockClient) ReadHoldingRegisters(address, quantity uint16) ([]uint16, error) {
+	return sf.registers(address, quantity)
+}
+func (sf *mockClient) ReadInputRegisters(address, quantity uint16) ([]uint16, error) {
+	return sf.registers(address, quantity)
+}
+func (sf *mockClient) WriteSingleCoil(address uint16, value bool) error {
+	sf.coils[address] = value
+	return nil
+}
+func (sf *mockClient) WriteMultipleRegisters(address uint16, values []uint16) error {
+	if sf.written == nil {
+		sf.written = make(map[uint16][]uint16)
+	}
+	sf.written[address] = values
+	return nil
+}
+
+// conn keeps the asdu sent
+type conn struct {
+	sent []*asdu.ASDU
+}
+
+func (sf *conn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *conn) UnderlyingConn() net.Conn { return nil }
+func (sf *conn) Send(a *asdu.ASDU) error {
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	r := asdu.NewEmptyASDU(asdu.ParamsWide)
+	if err = r.UnmarshalBinary(append([]byte(nil), raw...)); err != nil {
+		return err
+	}
+	sf.sent = append(sf.sent, r)
+	return nil
+}
+
+func TestGateway_Poll(t *testing.T) {
+	mc := &mockClient{
+		coils: map[uint16]bool{1: true},
+		regs:  map[uint16]uint16{10: 0xfffe, 20: 0x4048, 21: 0xf5c3},
+	}
+	g, err := New(mc,
+		Mapping{Area: Coil, Address: 1, CA: 1, IOA: 100, Type: asdu.M_SP_NA_1},
+		Mapping{Area: InputRegister, Address: 10, Format: Int16, CA: 1, IOA: 200, Type: asdu.M_ME_NB_1, Scale: 10, Deadband: 15},
+		Mapping{Area: HoldingRegister, Address: 20, Format: Float32, CA: 1, IOA: 300, Type: asdu.M_ME_NC_1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &conn{}
+	if err = g.Poll(c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 3 {
+		t.Fatalf("sent %d asdu, want 3", len(c.sent))
+	}
+	if v := c.sent[0].GetSinglePoint(); c.sent[0].Coa.Cause != asdu.Spontaneous || v[0].Ioa != 100 || !v[0].Value {
+		t.Errorf("sent %+v", v)
+	}
+	if v := c.sent[1].GetMeasuredValueScaled(); v[0].Value != -20 {
+		t.Errorf("sent %+v, want the value scaled", v)
+	}
+	if v := c.sent[2].GetMeasuredValueFloat(); v[0].Value < 3.13 || v[0].Value > 3.15 {
+		t.Errorf("sent %+v", v)
+	}
+
+	// within the deadband: nothing sent
+	c.sent = nil
+	mc.regs[10] = 0xffff
+	if err = g.Poll(c); err != nil || len(c.sent) != 0 {
+		t.Errorf("Poll() sent %d asdu, %v, want none", len(c.sent), err)
+	}
+
+	// a failed read marks the points invalid
+	mc.fail = true
+	if err = g.Poll(c); !errors.Is(err, ErrRead) {
+		t.Errorf("Poll() error = %v, want %v", err, ErrRead)
+	}
+	if len(c.sent) != 3 || c.sent[0].GetSinglePoint()[0].Qds != asdu.QDSInvalid {
+		t.Errorf("sent %d asdu, want the points invalid", len(c.sent))
+	}
+
+	c.sent = nil
+	if err = g.Interrogate(c, 1); err != nil || len(c.sent) != 3 || c.sent[0].Coa.Cause != asdu.InterrogatedByStation {
+		t.Errorf("Interrogate() sent %d asdu, %v", len(c.sent), err)
+	}
+}
+
+func TestGateway_Command(t *testing.T) {
+	mc := &mockClient{coils: map[uint16]bool{}}
+	g, err := New(mc,
+		Mapping{Area: Coil, Address: 1, CA: 1, IOA: 100, Type: asdu.M_SP_NA_1, Writable: true},
+		Mapping{Area: HoldingRegister, Address: 20, Format: Uint16, CA: 1, IOA: 300, Type: asdu.M_ME_NB_1, Scale: 0.1, Writable: true},
+		Mapping{Area: HoldingRegister, Address: 30, CA: 1, IOA: 400, Type: asdu.M_ME_NB_1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act := asdu.Identifier{CommonAddr: 1, Coa: asdu.CauseOfTransmission{Cause: asdu.Activation}}
+
+	if err = g.OnSingleCommand(nil, act, asdu.SingleCommandInfo{Ioa: 100, Value: true, Qoc: asdu.QualifierOfCommand{InSelect: true}}); err != nil || mc.coils[1] {
+		t.Errorf("select: error = %v, coil written %v", err, mc.coils[1])
+	}
+	if err = g.OnSingleCommand(nil, act, asdu.SingleCommandInfo{Ioa: 100, Value: true}); err != nil || !mc.coils[1] {
+		t.Errorf("execute: error = %v, coil written %v", err, mc.coils[1])
+	}
+	if err = g.OnSetpointScaled(nil, act, asdu.SetpointCommandScaledInfo{Ioa: 300, Value: 25}); err != nil || mc.written[20][0] != 250 {
+		t.Errorf("setpoint: error = %v, written %v", err, mc.written[20])
+	}
+
+	var rej cs104.ErrReject
+	if err = g.OnSetpointScaled(nil, act, asdu.SetpointCommandScaledInfo{Ioa: 400}); !errors.As(err, &rej) || rej.Cause != asdu.UnknownIOA {
+		t.Errorf("read only: error = %v, want rejected", err)
+	}
+	if err = g.OnDoubleCommand(nil, act, asdu.DoubleCommandInfo{Ioa: 100}); !errors.As(err, &rej) || rej.Cause != asdu.UnknownTypeID {
+		t.Errorf("double command: error = %v, want rejected", err)
+	}
+
+	if _, err = New(mc, Mapping{Area: Coil, Type: asdu.M_ME_NC_1}); !errors.Is(err, ErrMapping) {
+		t.Errorf("New() error = %v, want %v", err, ErrMapping)
+	}
+}
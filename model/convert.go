@@ -0,0 +1,318 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"math"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// the quality flags shared with the quality descriptors
+var qualityFlags = [...]struct {
+	q   Quality
+	qds asdu.QualityDescriptor
+	qdp asdu.QualityDescriptorProtection
+}{
+	{Invalid, asdu.QDSInvalid, asdu.QDPInvalid},
+	{NotTopical, asdu.QDSNotTopical, asdu.QDPNotTopical},
+	{Substituted, asdu.QDSSubstituted, asdu.QDPSubstituted},
+	{Blocked, asdu.QDSBlocked, asdu.QDPBlocked},
+	{Overflow, asdu.QDSOverflow, 0},
+	{ElapsedTimeInvalid, 0, asdu.QDPElapsedTimeInvalid},
+}
+
+// FromQDS returns the quality of the quality descriptor.
+func FromQDS(qds asdu.QualityDescriptor) Quality {
+	var q Quality
+	for _, f := range qualityFlags {
+		if f.qds != 0 && qds&f.qds != 0 {
+			q |= f.q
+		}
+	}
+	return q
+}
+
+// QDS returns the quality descriptor of the quality, the flags it has no
+// bit for are dropped.
+func (sf Quality) QDS() asdu.QualityDescriptor {
+	var qds asdu.QualityDescriptor
+	for _, f := range qualityFlags {
+		if sf&f.q != 0 {
+			qds |= f.qds
+		}
+	}
+	return qds
+}
+
+// FromQDP returns the quality of the quality descriptor of a protection event.
+func FromQDP(qdp asdu.QualityDescriptorProtection) Quality {
+	var q Quality
+	for _, f := range qualityFlags {
+		if f.qdp != 0 && qdp&f.qdp != 0 {
+			q |= f.q
+		}
+	}
+	return q
+}
+
+// QDP returns the quality descriptor of a protection event of the quality,
+// the flags it has no bit for are dropped.
+func (sf Quality) QDP() asdu.QualityDescriptorProtection {
+	var qdp asdu.QualityDescriptorProtection
+	for _, f := range qualityFlags {
+		if sf&f.q != 0 {
+			qdp |= f.qdp
+		}
+	}
+	return qdp
+}
+
+// FromSinglePoint returns the point of a single point information.
+func FromSinglePoint(ca asdu.CommonAddr, v asdu.SinglePointInfo) Point {
+	p := Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: Binary, Quality: FromQDS(v.Qds), Time: v.Time}
+	if v.Value {
+		p.Value = 1
+	}
+	return p
+}
+
+// ToSinglePoint returns the single point information of the point.
+func ToSinglePoint(p Point) asdu.SinglePointInfo {
+	return asdu.SinglePointInfo{Ioa: asdu.InfoObjAddr(p.Index), Value: p.Bool(), Qds: p.Quality.QDS(), Time: p.Time}
+}
+
+// FromDoublePoint returns the point of a double point information.
+func FromDoublePoint(ca asdu.CommonAddr, v asdu.DoublePointInfo) Point {
+	return Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: DoubleBinary,
+		Value: float64(v.Value & 0x03), Quality: FromQDS(v.Qds), Time: v.Time}
+}
+
+// ToDoublePoint returns the double point information of the point.
+func ToDoublePoint(p Point) asdu.DoublePointInfo {
+	return asdu.DoublePointInfo{Ioa: asdu.InfoObjAddr(p.Index), Value: asdu.DoublePoint(p.DoubleState()),
+		Qds: p.Quality.QDS(), Time: p.Time}
+}
+
+// FromStepPosition returns the point of a step position information, the
+// transient state is the Transient flag.
+func FromStepPosition(ca asdu.CommonAddr, v asdu.StepPositionInfo) Point {
+	p := Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: Step,
+		Value: float64(v.Value.Val), Quality: FromQDS(v.Qds), Time: v.Time}
+	if v.Value.HasTransient {
+		p.Quality |= Transient
+	}
+	return p
+}
+
+// ToStepPosition returns the step position information of the point, a
+// value out of [-64, 63] is limited and flagged overflow.
+func ToStepPosition(p Point) asdu.StepPositionInfo {
+	v, over := limit(p.Value, -64, 63)
+	q := p.Quality
+	if over {
+		q |= Overflow
+	}
+	return asdu.StepPositionInfo{Ioa: asdu.InfoObjAddr(p.Index),
+		Value: asdu.StepPosition{Val: int(v), HasTransient: q&Transient != 0}, Qds: q.QDS(), Time: p.Time}
+}
+
+// FromBitString32 returns the point of a bit string information.
+func FromBitString32(ca asdu.CommonAddr, v asdu.BitString32Info) Point {
+	return Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: BitString,
+		Value: float64(v.Value), Quality: FromQDS(v.Qds), Time: v.Time}
+}
+
+// ToBitString32 returns the bit string information of the point.
+func ToBitString32(p Point) asdu.BitString32Info {
+	v, _ := limit(p.Value, 0, math.MaxUint32)
+	return asdu.BitString32Info{Ioa: asdu.InfoObjAddr(p.Index), Value: uint32(v), Qds: p.Quality.QDS(), Time: p.Time}
+}
+
+// FromMeasuredValueNormal returns the point of a normalized measured value,
+// the value in [-1, 1).
+func FromMeasuredValueNormal(ca asdu.CommonAddr, v asdu.MeasuredValueNormalInfo) Point {
+	return Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: Analog,
+		Value: v.Value.Float64(), Quality: FromQDS(v.Qds), Time: v.Time}
+}
+
+// ToMeasuredValueNormal returns the normalized measured value of the point,
+// a value out of [-1, 1) is limited and flagged overflow.
+func ToMeasuredValueNormal(p Point) asdu.MeasuredValueNormalInfo {
+	v, over := limit(p.Value*32768, math.MinInt16, math.MaxInt16)
+	q := p.Quality
+	if over {
+		q |= Overflow
+	}
+	return asdu.MeasuredValueNormalInfo{Ioa: asdu.InfoObjAddr(p.Index), Value: asdu.Normalize(v), Qds: q.QDS(), Time: p.Time}
+}
+
+// FromMeasuredValueScaled returns the point of a scaled measured value.
+func FromMeasuredValueScaled(ca asdu.CommonAddr, v asdu.MeasuredValueScaledInfo) Point {
+	return Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: Analog,
+		Value: float64(v.Value), Quality: FromQDS(v.Qds), Time: v.Time}
+}
+
+// ToMeasuredValueScaled returns the scaled measured value of the point, a
+// value out of the int16 range is limited and flagged overflow.
+func ToMeasuredValueScaled(p Point) asdu.MeasuredValueScaledInfo {
+	v, over := limit(p.Value, math.MinInt16, math.MaxInt16)
+	q := p.Quality
+	if over {
+		q |= Overflow
+	}
+	return asdu.MeasuredValueScaledInfo{Ioa: asdu.InfoObjAddr(p.Index), Value: int16(v), Qds: q.QDS(), Time: p.Time}
+}
+
+// FromMeasuredValueFloat returns the point of a short floating point measured value.
+func FromMeasuredValueFloat(ca asdu.CommonAddr, v asdu.MeasuredValueFloatInfo) Point {
+	return Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: Analog,
+		Value: float64(v.Value), Quality: FromQDS(v.Qds), Time: v.Time}
+}
+
+// ToMeasuredValueFloat returns the short floating point measured value of the point.
+func ToMeasuredValueFloat(p Point) asdu.MeasuredValueFloatInfo {
+	return asdu.MeasuredValueFloatInfo{Ioa: asdu.InfoObjAddr(p.Index), Value: float32(p.Value), Qds: p.Quality.QDS(), Time: p.Time}
+}
+
+// FromIntegratedTotals returns the point of a binary counter reading.
+func FromIntegratedTotals(ca asdu.CommonAddr, v asdu.BinaryCounterReadingInfo) Point {
+	p := Point{Station: uint16(ca), Index: uint32(v.Ioa), Kind: Counter,
+		Value: float64(v.Value.CounterReading), Time: v.Time, SeqNumber: v.Value.SeqNumber & 0x1f}
+	if v.Value.IsInvalid {
+		p.Quality |= Invalid
+	}
+	if v.Value.HasCarry {
+		p.Quality |= Carry
+	}
+	if v.Value.IsAdjusted {
+		p.Quality |= Adjusted
+	}
+	return p
+}
+
+// ToIntegratedTotals returns the binary counter reading of the point, the
+// flags other than Invalid, Carry and Adjusted are dropped.
+func ToIntegratedTotals(p Point) asdu.BinaryCounterReadingInfo {
+	v, _ := limit(p.Value, math.MinInt32, math.MaxInt32)
+	return asdu.BinaryCounterReadingInfo{
+		Ioa: asdu.InfoObjAddr(p.Index),
+		Value: asdu.BinaryCounterReading{
+			CounterReading: int32(v),
+			SeqNumber:      p.SeqNumber & 0x1f,
+			HasCarry:       p.Quality&Carry != 0,
+			IsAdjusted:     p.Quality&Adjusted != 0,
+			IsInvalid:      p.Quality&Invalid != 0,
+		},
+		Time: p.Time,
+	}
+}
+
+// limit rounds v and limits it in [lo, hi], reports whether it was out of range.
+func limit(v, lo, hi float64) (float64, bool) {
+	v = math.Round(v)
+	switch {
+	case v < lo:
+		return lo, true
+	case v > hi:
+		return hi, true
+	case math.IsNaN(v):
+		return 0, true
+	}
+	return v, false
+}
+
+// FromASDU returns the points of a monitor direction asdu, nothing for the
+// other types. The asdu is left unchanged.
+func FromASDU(a *asdu.ASDU) []Point {
+	u := a.Clone()
+	ca := u.CommonAddr
+	var points []Point
+
+	switch u.Type {
+	case asdu.M_SP_NA_1, asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		for _, v := range u.GetSinglePoint() {
+			points = append(points, FromSinglePoint(ca, v))
+		}
+	case asdu.M_DP_NA_1, asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		for _, v := range u.GetDoublePoint() {
+			points = append(points, FromDoublePoint(ca, v))
+		}
+	case asdu.M_ST_NA_1, asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		for _, v := range u.GetStepPosition() {
+			points = append(points, FromStepPosition(ca, v))
+		}
+	case asdu.M_BO_NA_1, asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		for _, v := range u.GetBitString32() {
+			points = append(points, FromBitString32(ca, v))
+		}
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1:
+		for _, v := range u.GetMeasuredValueNormal() {
+			points = append(points, FromMeasuredValueNormal(ca, v))
+		}
+	case asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		for _, v := range u.GetMeasuredValueScaled() {
+			points = append(points, FromMeasuredValueScaled(ca, v))
+		}
+	case asdu.M_ME_NC_1, asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		for _, v := range u.GetMeasuredValueFloat() {
+			points = append(points, FromMeasuredValueFloat(ca, v))
+		}
+	case asdu.M_IT_NA_1, asdu.M_IT_TA_1, asdu.M_IT_TB_1:
+		for _, v := range u.GetIntegratedTotals() {
+			points = append(points, FromIntegratedTotals(ca, v))
+		}
+	}
+	return points
+}
+
+// Send sends the points as asdu of the type identification over c, split
+// by station and by the size of the asdu.
+func Send(c asdu.Connect, typeID asdu.TypeID, coa asdu.CauseOfTransmission, points ...Point) error {
+	var order []uint16
+	stations := make(map[uint16][]Point)
+	for _, p := range points {
+		if _, ok := stations[p.Station]; !ok {
+			order = append(order, p.Station)
+		}
+		stations[p.Station] = append(stations[p.Station], p)
+	}
+	for _, st := range order {
+		if err := send(c, typeID, coa, asdu.CommonAddr(st), stations[st]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func send(c asdu.Connect, typeID asdu.TypeID, coa asdu.CauseOfTransmission, ca asdu.CommonAddr, points []Point) error {
+	switch typeID {
+	case asdu.M_SP_NA_1, asdu.M_SP_TA_1, asdu.M_SP_TB_1:
+		return asdu.SingleBatch(c, typeID, coa, ca, convert(points, ToSinglePoint)...)
+	case asdu.M_DP_NA_1, asdu.M_DP_TA_1, asdu.M_DP_TB_1:
+		return asdu.DoubleBatch(c, typeID, coa, ca, convert(points, ToDoublePoint)...)
+	case asdu.M_ST_NA_1, asdu.M_ST_TA_1, asdu.M_ST_TB_1:
+		return asdu.StepBatch(c, typeID, coa, ca, convert(points, ToStepPosition)...)
+	case asdu.M_BO_NA_1, asdu.M_BO_TA_1, asdu.M_BO_TB_1:
+		return asdu.BitString32Batch(c, typeID, coa, ca, convert(points, ToBitString32)...)
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1:
+		return asdu.MeasuredValueNormalBatch(c, typeID, coa, ca, convert(points, ToMeasuredValueNormal)...)
+	case asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		return asdu.MeasuredValueScaledBatch(c, typeID, coa, ca, convert(points, ToMeasuredValueScaled)...)
+	case asdu.M_ME_NC_1, asdu.M_ME_TC_1, asdu.M_ME_TF_1:
+		return asdu.MeasuredValueFloatBatch(c, typeID, coa, ca, convert(points, ToMeasuredValueFloat)...)
+	case asdu.M_IT_NA_1, asdu.M_IT_TA_1, asdu.M_IT_TB_1:
+		return asdu.IntegratedTotalsBatch(c, typeID, coa, ca, convert(points, ToIntegratedTotals)...)
+	}
+	return asdu.ErrTypeIDNotMatch
+}
+
+func convert[T any](points []Point, to func(Point) T) []T {
+	infos := make([]T, 0, len(points))
+	for _, p := range points {
+		infos = append(infos, to(p))
+	}
+	return infos
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package model is a protocol neutral data model of the monitored points,
+// with the conversions to and from the asdu information objects. Adapters
+// of other protocols, such as DNP3 or IEC 61850, map their points to this
+// model instead of the IEC 60870-5 quality descriptors and time tags.
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of a point.
+type Kind byte
+
+// defined kinds
+const (
+	Binary       Kind = iota + 1 // single point, value 0 or 1
+	DoubleBinary                 // double point, value a DoubleState
+	Step                         // step position, value in [-64, 63]
+	BitString                    // bit string of 32 bits
+	Analog                       // measured value
+	Counter                      // integrated total, value a signed 32 bits
+)
+
+var kindNames = [...]string{"Unknown", "Binary", "DoubleBinary", "Step", "BitString", "Analog", "Counter"}
+
+func (sf Kind) String() string {
+	if int(sf) < len(kindNames) {
+		return kindNames[sf]
+	}
+	return fmt.Sprintf("Kind<%d>", byte(sf))
+}
+
+// DoubleState is the value of a double binary point, the same states as
+// the IEC 60870-5 double point and the DNP3 double-bit binary input.
+type DoubleState byte
+
+// defined double states
+const (
+	Intermediate DoubleState = iota
+	Off
+	On
+	Indeterminate
+)
+
+// Quality is the set of flags qualifying the value of a point.
+type Quality uint16
+
+// defined quality flags
+const (
+	// Invalid the value was incorrectly acquired.
+	Invalid Quality = 1 << iota
+	// NotTopical the most recent update was unsuccessful, also known as old or stale.
+	NotTopical
+	// Substituted the value was set by an operator or an automatic source.
+	Substituted
+	// Blocked the value is blocked for transmission.
+	Blocked
+	// Overflow the value is beyond a predefined range.
+	Overflow
+	// Transient the step position device is in transient state.
+	Transient
+	// Carry the counter overflowed in the period.
+	Carry
+	// Adjusted the counter was adjusted in the period.
+	Adjusted
+	// ElapsedTimeInvalid the elapsed time of a protection event was incorrectly acquired.
+	ElapsedTimeInvalid
+
+	// Good no flags.
+	Good Quality = 0
+)
+
+var qualityNames = [...]string{"IV", "NT", "SB", "BL", "OV", "TR", "CY", "CA", "EI"}
+
+// String returns the names of the flags set, separated by "|", "OK" if none.
+func (sf Quality) String() string {
+	if sf == Good {
+		return "OK"
+	}
+	var names []string
+	for i, name := range qualityNames {
+		if sf&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if rest := sf &^ (1<<len(qualityNames) - 1); rest != 0 {
+		names = append(names, fmt.Sprintf("0x%04x", uint16(rest)))
+	}
+	return strings.Join(names, "|")
+}
+
+// Point is a monitored point.
+type Point struct {
+	Station uint16 // common address of the asdu
+	Index   uint32 // information object address
+	Kind    Kind
+	Value   float64
+	Quality Quality
+	// Time is the time tag, zero if none.
+	Time time.Time
+	// SeqNumber is the sequence notation of a counter.
+	SeqNumber byte
+}
+
+// Bool reports whether the value of a binary point is set.
+func (sf Point) Bool() bool {
+	return sf.Value != 0
+}
+
+// DoubleState returns the value of a double binary point.
+func (sf Point) DoubleState() DoubleState {
+	return DoubleState(sf.Value) & 0x03
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// conn keeps the asdu sent
+type conn struct {
+	sent []*asdu.ASDU
+}
+
+func (sf *conn) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *conn) UnderlyingConn() net.Conn { return nil }
+func (sf *conn) Send(a *asdu.ASDU) error {
+	raw, err := a.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	r := asdu.NewEmptyASDU(asdu.ParamsWide)
+	if err = r.UnmarshalBinary(append([]byte(nil), raw...)); err != nil {
+		return err
+	}
+	sf.sent = append(sf.sent, r)
+	return nil
+}
+
+func TestQuality(t *testing.T) {
+	qds := asdu.QDSInvalid | asdu.QDSOverflow
+	if q := FromQDS(qds); q != Invalid|Overflow || q.QDS() != qds {
+		t.Errorf("FromQDS() = %v", q)
+	}
+	if q := FromQDP(asdu.QDPElapsedTimeInvalid | asdu.QDPBlocked); q != ElapsedTimeInvalid|Blocked || q.QDS() != asdu.QDSBlocked {
+		t.Errorf("FromQDP() = %v", q)
+	}
+	if s := (Invalid | Carry).String(); s != "IV|CY" {
+		t.Errorf("String() = %s", s)
+	}
+	if s := Good.String(); s != "OK" {
+		t.Errorf("String() = %s", s)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tm := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	step := FromStepPosition(1, asdu.StepPositionInfo{Ioa: 2, Value: asdu.StepPosition{Val: -5, HasTransient: true}, Time: tm})
+	if step.Kind != Step || step.Value != -5 || step.Quality != Transient || !step.Time.Equal(tm) {
+		t.Errorf("FromStepPosition() = %+v", step)
+	}
+	if v := ToStepPosition(step); v.Value.Val != -5 || !v.Value.HasTransient || v.Qds != asdu.QDSGood {
+		t.Errorf("ToStepPosition() = %+v", v)
+	}
+
+	// out of range is limited and flagged
+	if v := ToMeasuredValueNormal(Point{Value: 2}); v.Value != 32767 || v.Qds != asdu.QDSOverflow {
+		t.Errorf("ToMeasuredValueNormal() = %+v", v)
+	}
+	if v := ToMeasuredValueScaled(Point{Value: -1e6}); v.Value != -32768 || v.Qds != asdu.QDSOverflow {
+		t.Errorf("ToMeasuredValueScaled() = %+v", v)
+	}
+
+	it := asdu.BinaryCounterReadingInfo{Ioa: 3, Value: asdu.BinaryCounterReading{CounterReading: -7, SeqNumber: 4, HasCarry: true}}
+	p := FromIntegratedTotals(1, it)
+	if p.Kind != Counter || p.Value != -7 || p.Quality != Carry || p.SeqNumber != 4 {
+		t.Errorf("FromIntegratedTotals() = %+v", p)
+	}
+	if v := ToIntegratedTotals(p); v != it {
+		t.Errorf("ToIntegratedTotals() = %+v, want %+v", v, it)
+	}
+}
+
+func TestSend_FromASDU(t *testing.T) {
+	tm := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Station: 1, Index: 1, Kind: DoubleBinary, Value: float64(On), Time: tm},
+		{Station: 2, Index: 1, Kind: DoubleBinary, Value: float64(Off), Quality: NotTopical, Time: tm},
+		{Station: 1, Index: 2, Kind: DoubleBinary, Value: float64(Indeterminate), Quality: Invalid, Time: tm},
+	}
+	c := &conn{}
+	if err := Send(c, asdu.M_DP_TB_1, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, points...); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 2 {
+		t.Fatalf("sent %d asdu, want one per station", len(c.sent))
+	}
+	var got []Point
+	for _, a := range c.sent {
+		got = append(got, FromASDU(a)...)
+	}
+	want := []Point{points[0], points[2], points[1]}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FromASDU() = %+v, want %+v", got[i], want[i])
+		}
+	}
+	if len(FromASDU(c.sent[0])) != 2 {
+		t.Error("FromASDU() consumed the information objects")
+	}
+
+	if err := Send(c, asdu.C_SC_NA_1, asdu.CauseOfTransmission{Cause: asdu.Activation}, points...); err != asdu.ErrTypeIDNotMatch {
+		t.Errorf("Send() error = %v, want %v", err, asdu.ErrTypeIDNotMatch)
+	}
+}
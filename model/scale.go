@@ -0,0 +1,161 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package model
+
+import (
+	"errors"
+	"math"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// error defined
+var (
+	ErrRange = errors.New("value out of the engineering range")
+)
+
+// Scale the engineering range [Min, Max] of a point, mapped linearly onto the full range
+// of the normalized value [-1, 1) and of the scaled value [-32768, 32767]. The zero Scale
+// is the identity of the point conversions: the normalized value as is, the scaled value
+// as an integer.
+type Scale struct {
+	Min, Max float64
+}
+
+// raw returns the 16 bit representation of the engineering value, unit the representation
+// of 1 for the identity, limited to the int16 range and flagged out of range.
+func (sf Scale) raw(v, unit float64) (float64, bool) {
+	if sf.Min == sf.Max {
+		return limit(v*unit, math.MinInt16, math.MaxInt16)
+	}
+	return limit(math.MinInt16+(v-sf.Min)/(sf.Max-sf.Min)*math.MaxUint16, math.MinInt16, math.MaxInt16)
+}
+
+// value returns the engineering value of the 16 bit representation, see raw.
+func (sf Scale) value(r, unit float64) float64 {
+	if sf.Min == sf.Max {
+		return r / unit
+	}
+	return sf.Min + (r-math.MinInt16)/math.MaxUint16*(sf.Max-sf.Min)
+}
+
+// Normalize returns the normalized value of the engineering value, a value out of
+// the range is limited and reported ErrRange.
+func (sf Scale) Normalize(v float64) (asdu.Normalize, error) {
+	r, over := sf.raw(v, 32768)
+	if over {
+		return asdu.Normalize(r), ErrRange
+	}
+	return asdu.Normalize(r), nil
+}
+
+// FromNormalize returns the engineering value of the normalized value.
+func (sf Scale) FromNormalize(n asdu.Normalize) float64 {
+	return sf.value(float64(n), 32768)
+}
+
+// Scaled returns the scaled value of the engineering value, a value out of
+// the range is limited and reported ErrRange.
+func (sf Scale) Scaled(v float64) (int16, error) {
+	r, over := sf.raw(v, 1)
+	if over {
+		return int16(r), ErrRange
+	}
+	return int16(r), nil
+}
+
+// FromScaled returns the engineering value of the scaled value.
+func (sf Scale) FromScaled(v int16) float64 {
+	return sf.value(float64(v), 1)
+}
+
+// PointID the identity of a point.
+type PointID struct {
+	Station uint16
+	Index   uint32
+}
+
+// Scales the engineering ranges of the points, the points it has no range
+// for are the identity. The same ranges convert the monitor direction and the
+// control direction, the setpoints of a point in the units of its measurements.
+type Scales map[PointID]Scale
+
+// Of returns the range of the point.
+func (sf Scales) Of(station uint16, index uint32) Scale {
+	return sf[PointID{station, index}]
+}
+
+// FromASDU returns the points of a monitor direction asdu like FromASDU,
+// the normalized and scaled measured values in engineering units.
+func (sf Scales) FromASDU(a *asdu.ASDU) []Point {
+	points := FromASDU(a)
+	unit := unitOf(a.Type)
+	if unit == 0 {
+		return points
+	}
+	for i, p := range points {
+		sc := sf.Of(p.Station, p.Index)
+		points[i].Value = sc.value(math.Round(p.Value*unit), unit)
+	}
+	return points
+}
+
+// Send sends the points in engineering units like Send, the normalized and
+// scaled measured values out of the range of their point are limited and
+// flagged overflow.
+func (sf Scales) Send(c asdu.Connect, typeID asdu.TypeID, coa asdu.CauseOfTransmission, points ...Point) error {
+	unit := unitOf(typeID)
+	if unit == 0 {
+		return Send(c, typeID, coa, points...)
+	}
+	raw := make([]Point, 0, len(points))
+	for _, p := range points {
+		r, over := sf.Of(p.Station, p.Index).raw(p.Value, unit)
+		p.Value = r / unit
+		if over {
+			p.Quality |= Overflow
+		}
+		raw = append(raw, p)
+	}
+	return Send(c, typeID, coa, raw...)
+}
+
+// unitOf returns the representation of 1 in the point value of the normalized
+// and scaled measured value types, 0 for the other types.
+func unitOf(typeID asdu.TypeID) float64 {
+	switch typeID {
+	case asdu.M_ME_NA_1, asdu.M_ME_TA_1, asdu.M_ME_TD_1, asdu.M_ME_ND_1:
+		return 32768
+	case asdu.M_ME_NB_1, asdu.M_ME_TB_1, asdu.M_ME_TE_1:
+		return 1
+	}
+	return 0
+}
+
+// SetpointNormal returns the normalized setpoint command of the engineering value,
+// a value out of the range of the point is limited and reported ErrRange.
+func (sf Scales) SetpointNormal(ca asdu.CommonAddr, ioa asdu.InfoObjAddr, v float64,
+	qos asdu.QualifierOfSetpointCmd) (asdu.SetpointCommandNormalInfo, error) {
+	n, err := sf.Of(uint16(ca), uint32(ioa)).Normalize(v)
+	return asdu.SetpointCommandNormalInfo{Ioa: ioa, Value: n, Qos: qos}, err
+}
+
+// FromSetpointNormal returns the engineering value of the normalized setpoint command.
+func (sf Scales) FromSetpointNormal(ca asdu.CommonAddr, v asdu.SetpointCommandNormalInfo) float64 {
+	return sf.Of(uint16(ca), uint32(v.Ioa)).FromNormalize(v.Value)
+}
+
+// SetpointScaled returns the scaled setpoint command of the engineering value,
+// a value out of the range of the point is limited and reported ErrRange.
+func (sf Scales) SetpointScaled(ca asdu.CommonAddr, ioa asdu.InfoObjAddr, v float64,
+	qos asdu.QualifierOfSetpointCmd) (asdu.SetpointCommandScaledInfo, error) {
+	s, err := sf.Of(uint16(ca), uint32(ioa)).Scaled(v)
+	return asdu.SetpointCommandScaledInfo{Ioa: ioa, Value: s, Qos: qos}, err
+}
+
+// FromSetpointScaled returns the engineering value of the scaled setpoint command.
+func (sf Scales) FromSetpointScaled(ca asdu.CommonAddr, v asdu.SetpointCommandScaledInfo) float64 {
+	return sf.Of(uint16(ca), uint32(v.Ioa)).FromScaled(v.Value)
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+func TestScale(t *testing.T) {
+	sc := Scale{Min: 0, Max: 100}
+	tests := []struct {
+		name   string
+		v      float64
+		norm   asdu.Normalize
+		scaled int16
+		err    error
+	}{
+		{"min", 0, math.MinInt16, math.MinInt16, nil},
+		{"max", 100, math.MaxInt16, math.MaxInt16, nil},
+		{"quarter", 25, -16384, -16384, nil},
+		{"below", -1, math.MinInt16, math.MinInt16, ErrRange},
+		{"above", 101, math.MaxInt16, math.MaxInt16, ErrRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := sc.Normalize(tt.v)
+			if n != tt.norm || err != tt.err {
+				t.Errorf("Normalize() = %v, %v, want %v, %v", n, err, tt.norm, tt.err)
+			}
+			s, err := sc.Scaled(tt.v)
+			if s != tt.scaled || err != tt.err {
+				t.Errorf("Scaled() = %v, %v, want %v, %v", s, err, tt.scaled, tt.err)
+			}
+			if tt.err == nil && math.Abs(sc.FromNormalize(n)-tt.v) > 0.01 {
+				t.Errorf("FromNormalize() = %v, want %v", sc.FromNormalize(n), tt.v)
+			}
+		})
+	}
+
+	// the zero scale is the identity
+	if n, err := (Scale{}).Normalize(0.5); n != 16384 || err != nil {
+		t.Errorf("Normalize() = %v, %v", n, err)
+	}
+	if v := (Scale{}).FromScaled(-300); v != -300 {
+		t.Errorf("FromScaled() = %v", v)
+	}
+}
+
+func TestScales(t *testing.T) {
+	scales := Scales{{1, 10}: {Min: -50, Max: 50}, {1, 11}: {Min: 0, Max: 1000}}
+	points := []Point{
+		{Station: 1, Index: 10, Kind: Analog, Value: 25},
+		{Station: 1, Index: 11, Kind: Analog, Value: 2000},
+		{Station: 1, Index: 12, Kind: Analog, Value: 0.25},
+	}
+	c := &conn{}
+	if err := scales.Send(c, asdu.M_ME_NA_1, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, points...); err != nil {
+		t.Fatal(err)
+	}
+	got := scales.FromASDU(c.sent[0])
+	want := []struct {
+		v float64
+		q Quality
+	}{{25, 0}, {1000, Overflow}, {0.25, 0}}
+	for i, w := range want {
+		if math.Abs(got[i].Value-w.v) > 0.01 || got[i].Quality != w.q {
+			t.Errorf("FromASDU() = %+v, want %v %v", got[i], w.v, w.q)
+		}
+	}
+
+	// the setpoints in the units of the measurements
+	qos := asdu.QualifierOfSetpointCmd{}
+	sp, err := scales.SetpointScaled(1, 10, -50, qos)
+	if err != nil || sp.Value != math.MinInt16 || sp.Ioa != 10 {
+		t.Errorf("SetpointScaled() = %+v, %v", sp, err)
+	}
+	if v := scales.FromSetpointScaled(1, sp); v != -50 {
+		t.Errorf("FromSetpointScaled() = %v", v)
+	}
+	if _, err = scales.SetpointNormal(1, 11, -1, qos); err != ErrRange {
+		t.Errorf("SetpointNormal() error = %v, want %v", err, ErrRange)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+package sidecar
+
+//go:generate protoc --go_out=sidecarpb --go_opt=paths=source_relative --go-grpc_out=sidecarpb --go-grpc_opt=paths=source_relative iec104.proto
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/sidecar/sidecarpb"
+)
+
+// GRPCServer the gRPC server of the IEC104 service, it forwards the calls to a Service
+type GRPCServer struct {
+	sidecarpb.UnimplementedIEC104Server
+	svc *Service
+}
+
+var _ sidecarpb.IEC104Server = (*GRPCServer)(nil)
+
+// NewGRPCServer new the gRPC server of the service
+func NewGRPCServer(svc *Service) *GRPCServer {
+	return &GRPCServer{svc: svc}
+}
+
+// Register register the IEC104 service of svc to the gRPC server s
+func Register(s grpc.ServiceRegistrar, svc *Service) {
+	sidecarpb.RegisterIEC104Server(s, NewGRPCServer(svc))
+}
+
+// Interrogate imp interface sidecarpb.IEC104Server
+func (sf *GRPCServer) Interrogate(ctx context.Context, r *sidecarpb.InterrogateRequest) (*sidecarpb.InterrogateResponse, error) {
+	resp, err := sf.svc.Interrogate(ctx, &InterrogateRequest{
+		CommonAddress: r.GetCommonAddress(),
+		Group:         r.GetGroup(),
+		TimeoutMs:     r.GetTimeoutMs(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &sidecarpb.InterrogateResponse{Points: toPBPoints(resp.Points)}, nil
+}
+
+// SendCommand imp interface sidecarpb.IEC104Server
+func (sf *GRPCServer) SendCommand(ctx context.Context, r *sidecarpb.CommandRequest) (*sidecarpb.CommandResponse, error) {
+	resp, err := sf.svc.SendCommand(ctx, &CommandRequest{
+		CommonAddress: r.GetCommonAddress(),
+		Ioa:           r.GetIoa(),
+		Type:          r.GetType(),
+		Value:         r.GetValue(),
+		Select:        r.GetSelect(),
+		Qualifier:     r.GetQualifier(),
+		TimeoutMs:     r.GetTimeoutMs(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &sidecarpb.CommandResponse{Positive: resp.Positive, Cause: resp.Cause}, nil
+}
+
+// StreamEvents imp interface sidecarpb.IEC104Server
+func (sf *GRPCServer) StreamEvents(r *sidecarpb.StreamEventsRequest, stream sidecarpb.IEC104_StreamEventsServer) error {
+	err := sf.svc.StreamEvents(stream.Context(), &StreamEventsRequest{CommonAddresses: r.GetCommonAddresses()},
+		func(e *Event) error {
+			return stream.Send(&sidecarpb.Event{
+				Type:          e.Type,
+				Cause:         e.Cause,
+				CommonAddress: e.CommonAddress,
+				RxTime:        e.RxTime,
+				Points:        toPBPoints(e.Points),
+			})
+		})
+	return toStatus(err)
+}
+
+func toPBPoints(points []*Point) []*sidecarpb.Point {
+	pbs := make([]*sidecarpb.Point, 0, len(points))
+	for _, p := range points {
+		pbs = append(pbs, &sidecarpb.Point{
+			CommonAddress: p.CommonAddress,
+			Ioa:           p.Ioa,
+			Kind:          p.Kind,
+			Value:         p.Value,
+			Quality:       p.Quality,
+			Time:          p.Time,
+		})
+	}
+	return pbs
+}
+
+// toStatus returns the gRPC status error of the service error
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, ErrBusy):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ErrGroup), errors.Is(err, ErrCommandType), errors.Is(err, asdu.ErrTypeIdentifier):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrNegative):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
@@ -0,0 +1,72 @@
+package sidecar
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/sidecar/sidecarpb"
+)
+
+func TestRegister(t *testing.T) {
+	svc := newService(nil)
+	svc.conn = &outstation{svc}
+
+	listen := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	Register(srv, svc)
+	go func() { _ = srv.Serve(listen) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///sidecar",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listen.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := sidecarpb.NewIEC104Client(conn)
+	ctx := context.Background()
+
+	gi, err := c.Interrogate(ctx, &sidecarpb.InterrogateRequest{CommonAddress: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := gi.GetPoints(); len(p) != 1 || p[0].GetIoa() != 100 || p[0].GetKind() != "Analog" || p[0].GetValue() != 1.5 {
+		t.Errorf("Interrogate() = %v", p)
+	}
+
+	cmd, err := c.SendCommand(ctx, &sidecarpb.CommandRequest{CommonAddress: 1, Ioa: 10, Type: "C_SC_NA_1", Value: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmd.GetPositive() || cmd.GetCause() != uint32(asdu.ActivationCon) {
+		t.Errorf("SendCommand() = %v", cmd)
+	}
+	if _, err = c.SendCommand(ctx, &sidecarpb.CommandRequest{CommonAddress: 1, Ioa: 10, Type: "M_SP_NA_1"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SendCommand() error = %v, want %v", err, codes.InvalidArgument)
+	}
+
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.StreamEvents(sctx, &sidecarpb.StreamEventsRequest{CommonAddresses: []uint32{2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitStreams(t, svc, 1)
+	_ = asdu.Single(svc.conn, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, 2, asdu.SinglePointInfo{Ioa: 7, Value: true})
+	e, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.GetType() != "M_SP_NA_1" || e.GetCommonAddress() != 2 || len(e.GetPoints()) != 1 || e.GetPoints()[0].GetValue() != 1 {
+		t.Errorf("StreamEvents() event = %v", e)
+	}
+}
@@ -0,0 +1,421 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// Package sidecar exposes the operations of a cs104 client as a gRPC service, so that
+// applications in other languages drive the IEC 60870-5-104 communication through
+// this library run as a sidecar. The service is defined by iec104.proto, generated
+// into package sidecarpb. The Service methods are the server side of it over plain
+// Go types matching the messages field by field, Register serves them on a gRPC server:
+//
+//	o := cs104.NewOption()
+//	_ = o.AddRemoteServer("127.0.0.1:2404")
+//	svc := sidecar.New(o)
+//	_ = svc.Client().Start()
+//	srv := grpc.NewServer()
+//	sidecar.Register(srv, svc)
+//	_ = srv.Serve(listen)
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+	"github.com/rob-gra/go-iecp5/cs104"
+	"github.com/rob-gra/go-iecp5/model"
+)
+
+// DefaultTimeout the default timeout of the interrogation and the command confirmation
+const DefaultTimeout = 30 * time.Second
+
+// DefaultQueueSize the events queued for a stream, the slow streams lose the events beyond
+const DefaultQueueSize = 1024
+
+// error defined
+var (
+	ErrBusy        = errors.New("sidecar: the same request is pending")
+	ErrGroup       = errors.New("sidecar: interrogation group out of [0, 16]")
+	ErrCommandType = errors.New("sidecar: command type not supported")
+	ErrNegative    = errors.New("sidecar: negative confirmation")
+)
+
+// Point the message Point
+type Point struct {
+	CommonAddress uint32
+	Ioa           uint32
+	Kind          string
+	Value         float64
+	Quality       uint32
+	Time          int64
+}
+
+// InterrogateRequest the message InterrogateRequest
+type InterrogateRequest struct {
+	CommonAddress uint32
+	Group         uint32
+	TimeoutMs     uint32
+}
+
+// InterrogateResponse the message InterrogateResponse
+type InterrogateResponse struct {
+	Points []*Point
+}
+
+// CommandRequest the message CommandRequest
+type CommandRequest struct {
+	CommonAddress uint32
+	Ioa           uint32
+	Type          string
+	Value         float64
+	Select        bool
+	Qualifier     uint32
+	TimeoutMs     uint32
+}
+
+// CommandResponse the message CommandResponse
+type CommandResponse struct {
+	Positive bool
+	Cause    uint32
+}
+
+// StreamEventsRequest the message StreamEventsRequest
+type StreamEventsRequest struct {
+	CommonAddresses []uint32
+}
+
+// Event the message Event
+type Event struct {
+	Type          string
+	Cause         uint32
+	CommonAddress uint32
+	RxTime        int64
+	Points        []*Point
+}
+
+// waitKey identifies a pending request by its type and addresses
+type waitKey struct {
+	typeID asdu.TypeID
+	ca     asdu.CommonAddr
+	ioa    asdu.InfoObjAddr
+}
+
+// pending a request waiting for its confirmation, and for the interrogation its termination
+type pending struct {
+	cause  asdu.Cause // the cause of the interrogated data, interrogation only
+	points []*Point
+	con    chan asdu.CauseOfTransmission // the confirmation, then the termination
+}
+
+// stream a StreamEvents call
+type stream struct {
+	cas   map[asdu.CommonAddr]bool // nil all
+	queue chan *Event
+}
+
+// Service the IEC104 service of a cs104 client
+type Service struct {
+	conn   asdu.Connect
+	client *cs104.Client
+
+	mux     sync.Mutex
+	waiting map[waitKey]*pending
+	streams map[*stream]struct{}
+}
+
+// New new a service of a client of the option, the client sends STARTDT once connected.
+// Start the client with Client().Start.
+func New(o *cs104.ClientOption) *Service {
+	sf := newService(nil)
+	sf.client = cs104.NewClient(handler{sf}, o)
+	sf.client.SetOnConnectHandler(func(c *cs104.Client) { c.SendStartDt() })
+	sf.conn = sf.client
+	return sf
+}
+
+func newService(c asdu.Connect) *Service {
+	return &Service{
+		conn:    c,
+		waiting: make(map[waitKey]*pending),
+		streams: make(map[*stream]struct{}),
+	}
+}
+
+// Client returns the client of the service
+func (sf *Service) Client() *cs104.Client {
+	return sf.client
+}
+
+// timeout returns the context of the request timeout, DefaultTimeout if 0
+func timeout(ctx context.Context, ms uint32) (context.Context, context.CancelFunc) {
+	d := DefaultTimeout
+	if ms > 0 {
+		d = time.Duration(ms) * time.Millisecond
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// register the pending request, ErrBusy if the same is pending
+func (sf *Service) register(key waitKey, p *pending) error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	if _, ok := sf.waiting[key]; ok {
+		return ErrBusy
+	}
+	sf.waiting[key] = p
+	return nil
+}
+
+func (sf *Service) unregister(key waitKey) {
+	sf.mux.Lock()
+	delete(sf.waiting, key)
+	sf.mux.Unlock()
+}
+
+// next returns the next confirmation or termination of the pending request
+func (p *pending) next(ctx context.Context) (asdu.CauseOfTransmission, error) {
+	select {
+	case <-ctx.Done():
+		return asdu.CauseOfTransmission{}, ctx.Err()
+	case coa := <-p.con:
+		return coa, nil
+	}
+}
+
+// Interrogate imp rpc Interrogate, it fails with ErrNegative if the interrogation is negative confirmed
+func (sf *Service) Interrogate(ctx context.Context, r *InterrogateRequest) (*InterrogateResponse, error) {
+	if r.Group > 16 {
+		return nil, ErrGroup
+	}
+	ctx, cancel := timeout(ctx, r.TimeoutMs)
+	defer cancel()
+
+	ca := asdu.CommonAddr(r.CommonAddress)
+	key := waitKey{asdu.C_IC_NA_1, ca, 0}
+	p := &pending{
+		cause: asdu.InterrogatedByStation + asdu.Cause(r.Group),
+		con:   make(chan asdu.CauseOfTransmission, 2),
+	}
+	if err := sf.register(key, p); err != nil {
+		return nil, err
+	}
+	defer sf.unregister(key)
+
+	qoi := asdu.QOIStation + asdu.QualifierOfInterrogation(r.Group)
+	if err := asdu.InterrogationCmd(sf.conn, asdu.CauseOfTransmission{Cause: asdu.Activation}, ca, qoi); err != nil {
+		return nil, err
+	}
+	// the confirmation then the termination, the data is collected meanwhile
+	for {
+		coa, err := p.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if coa.IsNegative || coa.Cause != asdu.ActivationCon && coa.Cause != asdu.ActivationTerm {
+			return nil, fmt.Errorf("%w: %s", ErrNegative, coa)
+		}
+		if coa.Cause == asdu.ActivationTerm {
+			break
+		}
+	}
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+	return &InterrogateResponse{Points: p.points}, nil
+}
+
+// SendCommand imp rpc SendCommand, it returns the confirmation of the command
+func (sf *Service) SendCommand(ctx context.Context, r *CommandRequest) (*CommandResponse, error) {
+	typeID, err := asdu.ParseTypeID(r.Type)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := timeout(ctx, r.TimeoutMs)
+	defer cancel()
+
+	ca, ioa := asdu.CommonAddr(r.CommonAddress), asdu.InfoObjAddr(r.Ioa)
+	coa := asdu.CauseOfTransmission{Cause: asdu.Activation}
+	qoc := asdu.QualifierOfCommand{Qual: asdu.QOCQual(r.Qualifier), InSelect: r.Select}
+	qos := asdu.QualifierOfSetpointCmd{Qual: asdu.QOSQual(r.Qualifier), InSelect: r.Select}
+	var send func() error
+	switch typeID {
+	case asdu.C_SC_NA_1:
+		send = func() error {
+			return asdu.SingleCmd(sf.conn, typeID, coa, ca, asdu.SingleCommandInfo{Ioa: ioa, Value: r.Value != 0, Qoc: qoc})
+		}
+	case asdu.C_DC_NA_1:
+		send = func() error {
+			return asdu.DoubleCmd(sf.conn, typeID, coa, ca, asdu.DoubleCommandInfo{Ioa: ioa, Value: asdu.DoubleCommand(r.Value), Qoc: qoc})
+		}
+	case asdu.C_RC_NA_1:
+		send = func() error {
+			return asdu.StepCmd(sf.conn, typeID, coa, ca, asdu.StepCommandInfo{Ioa: ioa, Value: asdu.StepCommand(r.Value), Qoc: qoc})
+		}
+	case asdu.C_SE_NA_1:
+		v := asdu.Normalize(math.Max(-32768, math.Min(32767, r.Value*32768)))
+		send = func() error {
+			return asdu.SetpointCmdNormal(sf.conn, typeID, coa, ca, asdu.SetpointCommandNormalInfo{Ioa: ioa, Value: v, Qos: qos})
+		}
+	case asdu.C_SE_NB_1:
+		v := int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, r.Value)))
+		send = func() error {
+			return asdu.SetpointCmdScaled(sf.conn, typeID, coa, ca, asdu.SetpointCommandScaledInfo{Ioa: ioa, Value: v, Qos: qos})
+		}
+	case asdu.C_SE_NC_1:
+		send = func() error {
+			return asdu.SetpointCmdFloat(sf.conn, typeID, coa, ca, asdu.SetpointCommandFloatInfo{Ioa: ioa, Value: float32(r.Value), Qos: qos})
+		}
+	case asdu.C_BO_NA_1:
+		send = func() error {
+			return asdu.BitsString32Cmd(sf.conn, typeID, coa, ca, asdu.BitsString32CommandInfo{Ioa: ioa, Value: uint32(r.Value)})
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrCommandType, r.Type)
+	}
+
+	key := waitKey{typeID, ca, ioa}
+	p := &pending{con: make(chan asdu.CauseOfTransmission, 2)}
+	if err = sf.register(key, p); err != nil {
+		return nil, err
+	}
+	defer sf.unregister(key)
+	if err = send(); err != nil {
+		return nil, err
+	}
+	con, err := p.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &CommandResponse{Positive: !con.IsNegative, Cause: uint32(con.Cause)}, nil
+}
+
+// StreamEvents imp rpc StreamEvents, it sends the events of the common addresses requested
+// until ctx is done or send fails. A stream slower than the data loses the events beyond DefaultQueueSize.
+func (sf *Service) StreamEvents(ctx context.Context, r *StreamEventsRequest, send func(*Event) error) error {
+	s := &stream{queue: make(chan *Event, DefaultQueueSize)}
+	for _, ca := range r.CommonAddresses {
+		if s.cas == nil {
+			s.cas = make(map[asdu.CommonAddr]bool)
+		}
+		s.cas[asdu.CommonAddr(ca)] = true
+	}
+	sf.mux.Lock()
+	sf.streams[s] = struct{}{}
+	sf.mux.Unlock()
+	defer func() {
+		sf.mux.Lock()
+		delete(sf.streams, s)
+		sf.mux.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e := <-s.queue:
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toPoints returns the points of the monitor direction asdu
+func toPoints(a *asdu.ASDU) []*Point {
+	mps := model.FromASDU(a)
+	points := make([]*Point, 0, len(mps))
+	for _, v := range mps {
+		p := &Point{
+			CommonAddress: uint32(v.Station),
+			Ioa:           v.Index,
+			Kind:          v.Kind.String(),
+			Value:         v.Value,
+			Quality:       uint32(v.Quality),
+		}
+		if !v.Time.IsZero() {
+			p.Time = v.Time.UnixNano()
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// handle the asdu received, it takes the confirmations and the interrogated data of the
+// pending requests and posts the monitor direction asdu to the streams
+func (sf *Service) handle(a *asdu.ASDU) error {
+	sf.mux.Lock()
+	defer sf.mux.Unlock()
+
+	if a.Type >= asdu.C_SC_NA_1 {
+		switch a.Coa.Cause {
+		case asdu.ActivationCon, asdu.ActivationTerm,
+			asdu.UnknownTypeID, asdu.UnknownCOT, asdu.UnknownCA, asdu.UnknownIOA:
+		default:
+			return nil
+		}
+		key := waitKey{a.Type, a.CommonAddr, 0}
+		if a.Type != asdu.C_IC_NA_1 {
+			key.ioa = a.Clone().DecodeInfoObjAddr()
+		}
+		if p, ok := sf.waiting[key]; ok {
+			select {
+			case p.con <- a.Coa:
+			default:
+			}
+		}
+		return nil
+	}
+
+	points := toPoints(a)
+	for k, p := range sf.waiting {
+		if p.cause != 0 && p.cause == a.Coa.Cause && k.ca == a.CommonAddr {
+			p.points = append(p.points, points...)
+		}
+	}
+	if len(sf.streams) == 0 {
+		return nil
+	}
+	e := &Event{
+		Type:          a.Type.Name(),
+		Cause:         uint32(a.Coa.Cause),
+		CommonAddress: uint32(a.CommonAddr),
+		RxTime:        time.Now().UnixNano(),
+		Points:        points,
+	}
+	for s := range sf.streams {
+		if s.cas != nil && !s.cas[a.CommonAddr] {
+			continue
+		}
+		select {
+		case s.queue <- e:
+		default:
+		}
+	}
+	return nil
+}
+
+// handler the client handler of the service
+type handler struct {
+	s *Service
+}
+
+func (sf handler) InterrogationHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.s.handle(a) }
+func (sf handler) CounterInterrogationHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.s.handle(a)
+}
+func (sf handler) ReadHandler(_ asdu.Connect, a *asdu.ASDU) error        { return sf.s.handle(a) }
+func (sf handler) TestCommandHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.s.handle(a) }
+func (sf handler) ClockSyncHandler(_ asdu.Connect, a *asdu.ASDU) error   { return sf.s.handle(a) }
+func (sf handler) ResetProcessHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.s.handle(a)
+}
+func (sf handler) DelayAcquisitionHandler(_ asdu.Connect, a *asdu.ASDU) error {
+	return sf.s.handle(a)
+}
+func (sf handler) ASDUHandler(_ asdu.Connect, a *asdu.ASDU) error { return sf.s.handle(a) }
+func (sf handler) ASDUHandlerAll(_ asdu.Connect, a *asdu.ASDU, _ *cs104.Server, _ int) error {
+	return sf.s.handle(a)
+}
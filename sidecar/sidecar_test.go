@@ -0,0 +1,128 @@
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rob-gra/go-iecp5/asdu"
+)
+
+// outstation answers the requests by calling the service handler
+type outstation struct {
+	svc *Service
+}
+
+func (sf *outstation) Params() *asdu.Params     { return asdu.ParamsWide }
+func (sf *outstation) UnderlyingConn() net.Conn { return nil }
+func (sf *outstation) Send(a *asdu.ASDU) error {
+	req := a.Clone()
+	h := handler{sf.svc}
+	switch req.Type {
+	case asdu.C_IC_NA_1:
+		_ = h.InterrogationHandler(sf, req.Mirror(asdu.ActivationCon, false))
+		_ = asdu.MeasuredValueFloat(sf, false, asdu.CauseOfTransmission{Cause: asdu.InterrogatedByStation}, req.CommonAddr,
+			asdu.MeasuredValueFloatInfo{Ioa: 100, Value: 1.5})
+		_ = h.InterrogationHandler(sf, req.Mirror(asdu.ActivationTerm, false))
+	case asdu.C_SC_NA_1:
+		if a.Clone().GetSingleCmd().Ioa == 99 {
+			return h.ASDUHandlerAll(sf, req.Mirror(asdu.UnknownIOA, true), nil, 0)
+		}
+		return h.ASDUHandlerAll(sf, req.Mirror(asdu.ActivationCon, false), nil, 0)
+	default: // monitor direction data
+		return h.ASDUHandlerAll(sf, req, nil, 0)
+	}
+	return nil
+}
+
+func TestService_Interrogate(t *testing.T) {
+	svc := newService(nil)
+	svc.conn = &outstation{svc}
+
+	got, err := svc.Interrogate(context.Background(), &InterrogateRequest{CommonAddress: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Points) != 1 || *got.Points[0] != (Point{CommonAddress: 1, Ioa: 100, Kind: "Analog", Value: 1.5}) {
+		t.Errorf("Interrogate() = %+v", got.Points)
+	}
+	if _, err = svc.Interrogate(context.Background(), &InterrogateRequest{Group: 17}); err != ErrGroup {
+		t.Errorf("Interrogate() error = %v, want %v", err, ErrGroup)
+	}
+}
+
+func TestService_SendCommand(t *testing.T) {
+	svc := newService(nil)
+	svc.conn = &outstation{svc}
+
+	tests := []struct {
+		name string
+		req  CommandRequest
+		want CommandResponse
+		err  error
+	}{
+		{"positive", CommandRequest{CommonAddress: 1, Ioa: 10, Type: "C_SC_NA_1", Value: 1}, CommandResponse{true, uint32(asdu.ActivationCon)}, nil},
+		{"unknown ioa", CommandRequest{CommonAddress: 1, Ioa: 99, Type: "C_SC_NA_1"}, CommandResponse{false, uint32(asdu.UnknownIOA)}, nil},
+		{"not a command", CommandRequest{CommonAddress: 1, Ioa: 10, Type: "M_SP_NA_1"}, CommandResponse{}, ErrCommandType},
+		{"no confirmation", CommandRequest{CommonAddress: 1, Ioa: 10, Type: "C_SE_NC_1", TimeoutMs: 10}, CommandResponse{}, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.SendCommand(context.Background(), &tt.req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("SendCommand() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("SendCommand() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_StreamEvents(t *testing.T) {
+	svc := newService(nil)
+	c := &outstation{svc}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *Event, 2)
+	done := make(chan error)
+	go func() {
+		done <- svc.StreamEvents(ctx, &StreamEventsRequest{CommonAddresses: []uint32{2}}, func(e *Event) error {
+			events <- e
+			return nil
+		})
+	}()
+	waitStreams(t, svc, 1)
+
+	for ca := asdu.CommonAddr(1); ca <= 2; ca++ {
+		_ = asdu.Single(c, false, asdu.CauseOfTransmission{Cause: asdu.Spontaneous}, ca, asdu.SinglePointInfo{Ioa: 7, Value: true})
+	}
+	e := <-events
+	if e.Type != "M_SP_NA_1" || e.CommonAddress != 2 || e.Cause != uint32(asdu.Spontaneous) || len(e.Points) != 1 || e.Points[0].Value != 1 {
+		t.Errorf("event = %+v", e)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("StreamEvents() = %v, want %v", err, context.Canceled)
+	}
+	if len(events) != 0 {
+		t.Errorf("event of another common address streamed")
+	}
+}
+
+// waitStreams wait until n streams are registered to the service
+func waitStreams(t *testing.T, svc *Service, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		svc.mux.Lock()
+		got := len(svc.streams)
+		svc.mux.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stream not registered")
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 thinkgos (thinkgo@aliyun.com).  All rights reserved.
+// Use of this source code is governed by a version 3 of the GNU General
+// Public License, license that can be found in the LICENSE file.
+
+// The IEC 60870-5-104 controlling station service of the sidecar package, generated
+// into sidecarpb, see the go:generate directive of the package,
+// the messages match the Go types of sidecar field by field.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: iec104.proto
+
+package sidecarpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	IEC104_Interrogate_FullMethodName  = "/iec104.sidecar.v1.IEC104/Interrogate"
+	IEC104_SendCommand_FullMethodName  = "/iec104.sidecar.v1.IEC104/SendCommand"
+	IEC104_StreamEvents_FullMethodName = "/iec104.sidecar.v1.IEC104/StreamEvents"
+)
+
+// IEC104Client is the client API for IEC104 service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IEC104Client interface {
+	// Interrogate performs a station or group interrogation and returns the
+	// points received until the activation termination.
+	Interrogate(ctx context.Context, in *InterrogateRequest, opts ...grpc.CallOption) (*InterrogateResponse, error)
+	// SendCommand sends a process command and returns its confirmation.
+	SendCommand(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	// StreamEvents streams the monitor direction asdu received.
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type iEC104Client struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIEC104Client(cc grpc.ClientConnInterface) IEC104Client {
+	return &iEC104Client{cc}
+}
+
+func (c *iEC104Client) Interrogate(ctx context.Context, in *InterrogateRequest, opts ...grpc.CallOption) (*InterrogateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InterrogateResponse)
+	err := c.cc.Invoke(ctx, IEC104_Interrogate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iEC104Client) SendCommand(ctx context.Context, in *CommandRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, IEC104_SendCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iEC104Client) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IEC104_ServiceDesc.Streams[0], IEC104_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IEC104_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+// IEC104Server is the server API for IEC104 service.
+// All implementations must embed UnimplementedIEC104Server
+// for forward compatibility.
+type IEC104Server interface {
+	// Interrogate performs a station or group interrogation and returns the
+	// points received until the activation termination.
+	Interrogate(context.Context, *InterrogateRequest) (*InterrogateResponse, error)
+	// SendCommand sends a process command and returns its confirmation.
+	SendCommand(context.Context, *CommandRequest) (*CommandResponse, error)
+	// StreamEvents streams the monitor direction asdu received.
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedIEC104Server()
+}
+
+// UnimplementedIEC104Server must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIEC104Server struct{}
+
+func (UnimplementedIEC104Server) Interrogate(context.Context, *InterrogateRequest) (*InterrogateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Interrogate not implemented")
+}
+func (UnimplementedIEC104Server) SendCommand(context.Context, *CommandRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendCommand not implemented")
+}
+func (UnimplementedIEC104Server) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedIEC104Server) mustEmbedUnimplementedIEC104Server() {}
+func (UnimplementedIEC104Server) testEmbeddedByValue()                {}
+
+// UnsafeIEC104Server may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IEC104Server will
+// result in compilation errors.
+type UnsafeIEC104Server interface {
+	mustEmbedUnimplementedIEC104Server()
+}
+
+func RegisterIEC104Server(s grpc.ServiceRegistrar, srv IEC104Server) {
+	// If the following call pancis, it indicates UnimplementedIEC104Server was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IEC104_ServiceDesc, srv)
+}
+
+func _IEC104_Interrogate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InterrogateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IEC104Server).Interrogate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IEC104_Interrogate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IEC104Server).Interrogate(ctx, req.(*InterrogateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IEC104_SendCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IEC104Server).SendCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IEC104_SendCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IEC104Server).SendCommand(ctx, req.(*CommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IEC104_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IEC104Server).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IEC104_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+// IEC104_ServiceDesc is the grpc.ServiceDesc for IEC104 service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IEC104_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iec104.sidecar.v1.IEC104",
+	HandlerType: (*IEC104Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Interrogate",
+			Handler:    _IEC104_Interrogate_Handler,
+		},
+		{
+			MethodName: "SendCommand",
+			Handler:    _IEC104_SendCommand_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _IEC104_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "iec104.proto",
+}